@@ -0,0 +1,337 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	xmppparser "github.com/ortuman/jackal/pkg/parser"
+)
+
+const (
+	clientNamespace = "jabber:client"
+	streamNamespace = "http://etherx.jabber.org/streams"
+	tlsNamespace    = "urn:ietf:params:xml:ns:xmpp-tls"
+	saslNamespace   = "urn:ietf:params:xml:ns:xmpp-sasl"
+	bindNamespace   = "urn:ietf:params:xml:ns:xmpp-bind"
+)
+
+var errClientClosed = errors.New("client closed")
+
+// client represents a minimal XMPP client used to generate load.
+type client struct {
+	cfg      *config
+	username string
+
+	conn net.Conn
+	pr   *xmppparser.Parser
+	wMu  sync.Mutex
+
+	jid string
+
+	mu      sync.Mutex
+	pending map[string]chan stravaganza.Element
+	onMsg   func(elem stravaganza.Element)
+	doneCh  chan struct{}
+}
+
+func newClient(cfg *config, username string) *client {
+	return &client{
+		cfg:      cfg,
+		username: username,
+		pending:  make(map[string]chan stravaganza.Element),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// login connects to the server, secures the connection, authenticates and binds a resource.
+func (c *client) login(ctx context.Context) error {
+	d := net.Dialer{Timeout: c.cfg.timeout}
+	conn, err := d.DialContext(ctx, "tcp", c.cfg.addr)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	features, err := c.openStream()
+	if err != nil {
+		return err
+	}
+	if features.ChildNamespace("starttls", tlsNamespace) != nil {
+		if err := c.startTLS(); err != nil {
+			return err
+		}
+		if features, err = c.openStream(); err != nil {
+			return err
+		}
+	}
+	if err := c.authenticate(features); err != nil {
+		return err
+	}
+	if features, err = c.openStream(); err != nil {
+		return err
+	}
+	if features.ChildNamespace("bind", bindNamespace) == nil {
+		return errors.New("resource binding not offered")
+	}
+	if err := c.bind(); err != nil {
+		return err
+	}
+	go c.readLoop()
+	return nil
+}
+
+// close gracefully closes client stream.
+func (c *client) close() {
+	_ = c.sendString("</stream:stream>")
+	_ = c.conn.Close()
+	<-c.doneCh
+}
+
+// sendIQ sends an IQ stanza and waits for its response.
+func (c *client) sendIQ(ctx context.Context, iq stravaganza.Element) (stravaganza.Element, error) {
+	ch := make(chan stravaganza.Element, 1)
+	id := iq.Attribute(stravaganza.ID)
+
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+	if err := c.send(iq); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Attribute(stravaganza.Type) == stravaganza.ErrorType {
+			return resp, fmt.Errorf("iq %s failed", id)
+		}
+		return resp, nil
+	case <-c.doneCh:
+		return nil, errClientClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *client) send(elem stravaganza.Element) error {
+	sb := &strings.Builder{}
+	if err := elem.ToXML(sb, true); err != nil {
+		return err
+	}
+	return c.sendString(sb.String())
+}
+
+func (c *client) sendString(s string) error {
+	c.wMu.Lock()
+	defer c.wMu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.cfg.timeout))
+	_, err := c.conn.Write([]byte(s))
+	return err
+}
+
+func (c *client) receive() (stravaganza.Element, error) {
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.cfg.timeout))
+	return c.pr.Parse()
+}
+
+func (c *client) openStream() (stravaganza.Element, error) {
+	c.pr = xmppparser.New(c.conn, xmppparser.SocketStream, 0)
+
+	err := c.sendString(fmt.Sprintf(
+		`<?xml version='1.0'?><stream:stream xmlns="%s" xmlns:stream="%s" to="%s" version="1.0">`,
+		clientNamespace, streamNamespace, c.cfg.domain,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.receive(); err != nil { // stream:stream
+		return nil, err
+	}
+	features, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+	if features.Name() != "stream:features" {
+		return nil, fmt.Errorf("unexpected element: %s", features.Name())
+	}
+	return features, nil
+}
+
+func (c *client) startTLS() error {
+	err := c.send(stravaganza.NewBuilder("starttls").
+		WithAttribute(stravaganza.Namespace, tlsNamespace).
+		Build(),
+	)
+	if err != nil {
+		return err
+	}
+	elem, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if elem.Name() != "proceed" {
+		return errors.New("STARTTLS negotiation failed")
+	}
+	tlsConn := tls.Client(c.conn, &tls.Config{
+		ServerName:         c.cfg.domain,
+		InsecureSkipVerify: c.cfg.insecure,
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	return nil
+}
+
+func (c *client) authenticate(features stravaganza.Element) error {
+	mechs := features.ChildNamespace("mechanisms", saslNamespace)
+	if mechs == nil {
+		return errors.New("SASL authentication not offered")
+	}
+	var offered bool
+	for _, m := range mechs.Children("mechanism") {
+		if m.Text() == c.cfg.mechanism {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		return fmt.Errorf("SASL mechanism %s not offered", c.cfg.mechanism)
+	}
+	sc, err := newScramClient(c.cfg.mechanism, c.username, c.cfg.password)
+	if err != nil {
+		return err
+	}
+	err = c.send(stravaganza.NewBuilder("auth").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		WithAttribute("mechanism", c.cfg.mechanism).
+		WithText(sc.firstMessage()).
+		Build(),
+	)
+	if err != nil {
+		return err
+	}
+	challenge, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if challenge.Name() != "challenge" {
+		return fmt.Errorf("authentication failed for %s", c.username)
+	}
+	resp, err := sc.finalMessage(challenge.Text())
+	if err != nil {
+		return err
+	}
+	err = c.send(stravaganza.NewBuilder("response").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		WithText(resp).
+		Build(),
+	)
+	if err != nil {
+		return err
+	}
+	elem, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if elem.Name() != "success" {
+		return fmt.Errorf("authentication failed for %s", c.username)
+	}
+	return nil
+}
+
+func (c *client) bind() error {
+	err := c.send(stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithChild(
+			stravaganza.NewBuilder("bind").
+				WithAttribute(stravaganza.Namespace, bindNamespace).
+				WithChild(
+					stravaganza.NewBuilder("resource").
+						WithText(c.cfg.resource).
+						Build(),
+				).
+				Build(),
+		).
+		Build(),
+	)
+	if err != nil {
+		return err
+	}
+	elem, err := c.receive()
+	if err != nil {
+		return err
+	}
+	bindEl := elem.ChildNamespace("bind", bindNamespace)
+	if elem.Attribute(stravaganza.Type) != stravaganza.ResultType || bindEl == nil || bindEl.Child("jid") == nil {
+		return errors.New("resource binding failed")
+	}
+	c.jid = bindEl.Child("jid").Text()
+	return nil
+}
+
+func (c *client) readLoop() {
+	defer close(c.doneCh)
+
+	for {
+		_ = c.conn.SetReadDeadline(time.Time{})
+		elem, err := c.pr.Parse()
+		if err != nil {
+			return
+		}
+		switch elem.Name() {
+		case "iq":
+			switch elem.Attribute(stravaganza.Type) {
+			case stravaganza.ResultType, stravaganza.ErrorType:
+				c.mu.Lock()
+				ch := c.pending[elem.Attribute(stravaganza.ID)]
+				c.mu.Unlock()
+				if ch != nil {
+					ch <- elem
+				}
+			case stravaganza.GetType, stravaganza.SetType:
+				_ = c.send(stravaganza.NewBuilder("iq").
+					WithAttribute(stravaganza.ID, elem.Attribute(stravaganza.ID)).
+					WithAttribute(stravaganza.To, elem.Attribute(stravaganza.From)).
+					WithAttribute(stravaganza.Type, stravaganza.ResultType).
+					Build(),
+				)
+			}
+		case "message":
+			c.mu.Lock()
+			onMsg := c.onMsg
+			c.mu.Unlock()
+			if onMsg != nil {
+				onMsg(elem)
+			}
+		}
+	}
+}
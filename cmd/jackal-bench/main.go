@@ -0,0 +1,254 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// jackal-bench is a load testing tool that simulates concurrent XMPP clients against a running jackal server.
+//
+// Every simulated client logs in as <prefix><n>, where n ranges from 0 to clients-1, hence all those accounts
+// must exist beforehand sharing the same password (e.g. created via jackalctl).
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+)
+
+const (
+	mamNamespace = "urn:xmpp:mam:2"
+	rsmNamespace = "http://jabber.org/protocol/rsm"
+
+	rosterNamespace = "jabber:iq:roster"
+
+	benchStampAttr = "bench-stamp"
+)
+
+type config struct {
+	addr      string
+	domain    string
+	prefix    string
+	password  string
+	resource  string
+	mechanism string
+	insecure  bool
+	timeout   time.Duration
+
+	clients  int
+	rampUp   time.Duration
+	duration time.Duration
+	msgRate  time.Duration
+	mamMax   int
+}
+
+func main() {
+	var cfg config
+
+	fs := flag.NewFlagSet("jackal-bench", flag.ExitOnError)
+	fs.StringVar(&cfg.addr, "addr", "127.0.0.1:5222", "C2S server address")
+	fs.StringVar(&cfg.domain, "domain", "localhost", "XMPP domain")
+	fs.StringVar(&cfg.prefix, "user-prefix", "bench", "username prefix of simulated accounts")
+	fs.StringVar(&cfg.password, "password", "bench", "password shared by all simulated accounts")
+	fs.StringVar(&cfg.resource, "resource", "bench", "resource bound by every simulated client")
+	fs.StringVar(&cfg.mechanism, "sasl", "SCRAM-SHA-1", "SASL mechanism (SCRAM-SHA-1 or SCRAM-SHA-256)")
+	fs.BoolVar(&cfg.insecure, "insecure", false, "skip server certificate verification")
+	fs.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "network and request timeout")
+	fs.IntVar(&cfg.clients, "clients", 10, "number of concurrent clients")
+	fs.DurationVar(&cfg.rampUp, "ramp-up", 5*time.Second, "time window over which clients log in")
+	fs.DurationVar(&cfg.duration, "duration", 30*time.Second, "chat phase duration")
+	fs.DurationVar(&cfg.msgRate, "msg-interval", time.Second, "interval between messages sent by each client")
+	fs.IntVar(&cfg.mamMax, "mam-max", 50, "number of archived messages requested on MAM catch-up (0 disables it)")
+	_ = fs.Parse(os.Args[1:])
+
+	if cfg.clients < 2 {
+		_, _ = fmt.Fprintln(os.Stderr, "at least 2 clients are required")
+		os.Exit(1)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	st := newStats()
+
+	start := time.Now()
+	run(ctx, &cfg, st)
+	elapsed := time.Since(start)
+
+	fmt.Printf("clients: %d, elapsed: %v\n\n", cfg.clients, elapsed.Round(time.Millisecond))
+	st.report(os.Stdout, elapsed)
+}
+
+func run(ctx context.Context, cfg *config, st *stats) {
+	clients := make([]*client, cfg.clients)
+
+	// login phase
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			delay := time.Duration(int64(cfg.rampUp) * int64(i) / int64(cfg.clients))
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			c := newClient(cfg, cfg.prefix+strconv.Itoa(i))
+			c.onMsg = func(elem stravaganza.Element) { onMessage(elem, st) }
+
+			t0 := time.Now()
+			err := c.login(ctx)
+			st.observe("login", time.Since(t0), err)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", c.username, err)
+				return
+			}
+			t0 = time.Now()
+			_, err = c.sendIQ(ctx, rosterGetIQ())
+			st.observe("roster", time.Since(t0), err)
+
+			_ = c.send(stravaganza.NewBuilder("presence").Build())
+
+			clients[i] = c
+		}(i)
+	}
+	wg.Wait()
+
+	// chat phase
+	chatCtx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	for i, c := range clients {
+		if c == nil || i^1 >= len(clients) || clients[i^1] == nil {
+			continue
+		}
+		peer := clients[i^1]
+		wg.Add(1)
+		go func(c *client, to string) {
+			defer wg.Done()
+			chat(chatCtx, c, to, cfg.msgRate, st)
+		}(c, peer.username+"@"+cfg.domain)
+	}
+	wg.Wait()
+
+	// MAM catch-up phase
+	for _, c := range clients {
+		if c == nil || cfg.mamMax <= 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(c *client) {
+			defer wg.Done()
+
+			t0 := time.Now()
+			_, err := c.sendIQ(ctx, mamQueryIQ(cfg.mamMax))
+			st.observe("mam", time.Since(t0), err)
+		}(c)
+	}
+	wg.Wait()
+
+	for _, c := range clients {
+		if c != nil {
+			c.close()
+		}
+	}
+}
+
+func chat(ctx context.Context, c *client, to string, interval time.Duration, st *stats) {
+	tc := time.NewTicker(interval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			msg := stravaganza.NewBuilder("message").
+				WithAttribute(stravaganza.ID, uuid.New().String()).
+				WithAttribute(stravaganza.To, to).
+				WithAttribute(stravaganza.Type, stravaganza.ChatType).
+				WithChild(
+					stravaganza.NewBuilder("body").
+						WithText("jackal-bench").
+						Build(),
+				).
+				WithChild(
+					stravaganza.NewBuilder("bench").
+						WithAttribute(stravaganza.Namespace, "urn:jackal:bench").
+						WithAttribute(benchStampAttr, strconv.FormatInt(time.Now().UnixNano(), 10)).
+						Build(),
+				).
+				Build()
+			if err := c.send(msg); err != nil {
+				st.observe("message", 0, err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func onMessage(elem stravaganza.Element, st *stats) {
+	b := elem.ChildNamespace("bench", "urn:jackal:bench")
+	if b == nil {
+		return // archived or foreign message
+	}
+	ns, err := strconv.ParseInt(b.Attribute(benchStampAttr), 10, 64)
+	if err != nil {
+		return
+	}
+	st.observe("message", time.Since(time.Unix(0, ns)), nil)
+}
+
+func rosterGetIQ() stravaganza.Element {
+	return stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, rosterNamespace).
+				Build(),
+		).
+		Build()
+}
+
+func mamQueryIQ(max int) stravaganza.Element {
+	return stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithAttribute("queryid", uuid.New().String()).
+				WithChild(
+					stravaganza.NewBuilder("set").
+						WithAttribute(stravaganza.Namespace, rsmNamespace).
+						WithChild(
+							stravaganza.NewBuilder("max").
+								WithText(strconv.Itoa(max)).
+								Build(),
+						).
+						Build(),
+				).
+				Build(),
+		).
+		Build()
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const gs2Header = "n,,"
+
+// scramClient implements the client side of a SCRAM authentication exchange.
+type scramClient struct {
+	h         func() hash.Hash
+	username  string
+	password  string
+	cNonce    string
+	firstBare string
+}
+
+func newScramClient(mechanism, username, password string) (*scramClient, error) {
+	var h func() hash.Hash
+	switch mechanism {
+	case "SCRAM-SHA-1":
+		h = sha1.New
+	case "SCRAM-SHA-256":
+		h = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %s", mechanism)
+	}
+	return &scramClient{
+		h:        h,
+		username: username,
+		password: password,
+		cNonce:   strings.ReplaceAll(uuid.New().String(), "-", ""),
+	}, nil
+}
+
+// firstMessage returns the base64 encoded client-first-message.
+func (c *scramClient) firstMessage() string {
+	c.firstBare = fmt.Sprintf("n=%s,r=%s", c.username, c.cNonce)
+	return base64.StdEncoding.EncodeToString([]byte(gs2Header + c.firstBare))
+}
+
+// finalMessage returns the base64 encoded client-final-message for a given server challenge.
+func (c *scramClient) finalMessage(challenge string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(challenge)
+	if err != nil {
+		return "", err
+	}
+	serverFirst := string(b)
+
+	var nonce, salt string
+	var iterations int
+	for _, p := range strings.Split(serverFirst, ",") {
+		if len(p) < 2 || p[1] != '=' {
+			continue
+		}
+		switch p[0] {
+		case 'r':
+			nonce = p[2:]
+		case 's':
+			salt = p[2:]
+		case 'i':
+			iterations, _ = strconv.Atoi(p[2:])
+		}
+	}
+	if !strings.HasPrefix(nonce, c.cNonce) || len(salt) == 0 || iterations == 0 {
+		return "", errors.New("malformed SCRAM challenge")
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return "", err
+	}
+	saltedPassword := pbkdf2.Key([]byte(c.password), saltBytes, iterations, c.h().Size(), c.h)
+
+	clientFinalBare := fmt.Sprintf("c=%s,r=%s", base64.StdEncoding.EncodeToString([]byte(gs2Header)), nonce)
+	authMessage := c.firstBare + "," + serverFirst + "," + clientFinalBare
+
+	clientKey := c.hmac(saltedPassword, []byte("Client Key"))
+	storedKey := c.h()
+	storedKey.Write(clientKey)
+	clientSignature := c.hmac(storedKey.Sum(nil), []byte(authMessage))
+
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	final := clientFinalBare + ",p=" + base64.StdEncoding.EncodeToString(proof)
+	return base64.StdEncoding.EncodeToString([]byte(final)), nil
+}
+
+func (c *scramClient) hmac(key, b []byte) []byte {
+	m := hmac.New(c.h, key)
+	m.Write(b)
+	return m.Sum(nil)
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// opStats collects latency samples and error counts for a single operation.
+type opStats struct {
+	samples []time.Duration
+	errors  int
+}
+
+// stats collects per-operation benchmark results.
+type stats struct {
+	mu  sync.Mutex
+	ops map[string]*opStats
+}
+
+func newStats() *stats {
+	return &stats{ops: make(map[string]*opStats)}
+}
+
+func (s *stats) observe(op string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.ops[op]
+	if st == nil {
+		st = &opStats{}
+		s.ops[op] = st
+	}
+	if err != nil {
+		st.errors++
+		return
+	}
+	st.samples = append(st.samples, d)
+}
+
+// report writes a latency percentiles table into w.
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ops := make([]string, 0, len(s.ops))
+	for op := range s.ops {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(tw, "OP\tCOUNT\tERRORS\tRATE/s\tP50\tP90\tP99\tMAX\t")
+	for _, op := range ops {
+		st := s.ops[op]
+		sort.Slice(st.samples, func(i, j int) bool { return st.samples[i] < st.samples[j] })
+
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%v\t%v\t%v\t%v\t\n",
+			op,
+			len(st.samples),
+			st.errors,
+			float64(len(st.samples))/elapsed.Seconds(),
+			percentile(st.samples, 0.50),
+			percentile(st.samples, 0.90),
+			percentile(st.samples, 0.99),
+			percentile(st.samples, 1),
+		)
+	}
+	_ = tw.Flush()
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx].Round(time.Microsecond)
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+// NewAbuseCommand returns the cobra command for "abuse".
+func NewAbuseCommand() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "abuse <subcommand>",
+		Short: "Abuse report review commands",
+	}
+
+	ac.AddCommand(newAbuseListCommand())
+	ac.AddCommand(newAbuseForwardCommand())
+	ac.AddCommand(newAbuseDiscardCommand())
+
+	return ac
+}
+
+func newAbuseListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists abuse reports awaiting review",
+		Run:   abuseListCommandFunc,
+	}
+}
+
+func newAbuseForwardCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "forward <id>",
+		Short: "Forwards an abuse report to the reported domain abuse addresses",
+		Run:   abuseForwardCommandFunc,
+	}
+}
+
+func newAbuseDiscardCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "discard <id>",
+		Short: "Discards an abuse report",
+		Run:   abuseDiscardCommandFunc,
+	}
+}
+
+// abuseListCommandFunc executes the "abuse list" command.
+func abuseListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("abuse list command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustAbuseReportsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ListAbuseReports(ctx, &adminpb.ListAbuseReportsRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ListAbuseReports(resp)
+}
+
+// abuseForwardCommandFunc executes the "abuse forward" command.
+func abuseForwardCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("abuse forward command requires report id as its argument"))
+	}
+	id := args[0]
+
+	cc, ctx, cancel := mustAbuseReportsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ForwardAbuseReport(ctx, &adminpb.ForwardAbuseReportRequest{Id: id})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ForwardAbuseReport(id, resp)
+}
+
+// abuseDiscardCommandFunc executes the "abuse discard" command.
+func abuseDiscardCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("abuse discard command requires report id as its argument"))
+	}
+	id := args[0]
+
+	cc, ctx, cancel := mustAbuseReportsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.DiscardAbuseReport(ctx, &adminpb.DiscardAbuseReportRequest{Id: id})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.DiscardAbuseReport(id, resp)
+}
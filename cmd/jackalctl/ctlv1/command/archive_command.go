@@ -0,0 +1,274 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteOrphans bool
+
+	archiveExportFormat         string
+	archiveExportOutputFile     string
+	archiveExportIncludeRoster  bool
+	archiveExportIncludeVCard   bool
+	archiveExportIncludeOffline bool
+
+	archiveQuotaUsername  string
+	archiveQuotaHost      string
+	archiveQuotaQueueSize int
+	archiveQuotaMaxAge    time.Duration
+)
+
+// NewArchiveCommand returns the cobra command for "archive".
+func NewArchiveCommand() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "archive <subcommand>",
+		Short: "Message archive related commands",
+	}
+
+	ac.AddCommand(newArchiveRepairCommand())
+	ac.AddCommand(newArchiveDeleteConversationCommand())
+	ac.AddCommand(newArchiveExportCommand())
+	ac.AddCommand(newArchiveQuotaSetCommand())
+	ac.AddCommand(newArchiveQuotaListCommand())
+	ac.AddCommand(newArchiveQuotaDeleteCommand())
+
+	return ac
+}
+
+func newArchiveRepairCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "repair [options]",
+		Short: "Checks stored archives and reports orphaned ones",
+		Run:   archiveRepairCommandFunc,
+	}
+
+	cmd.Flags().BoolVar(&deleteOrphans, "delete-orphans", false, "Remove archives whose owner user no longer exists")
+
+	return &cmd
+}
+
+func newArchiveDeleteConversationCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-conversation <username> <jid>",
+		Short: "Removes all messages a user archived with a given JID",
+		Run:   archiveDeleteConversationCommandFunc,
+	}
+}
+
+func newArchiveExportCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "export <username> [options]",
+		Short: "Exports a user message archive to a portable document",
+		Long: `Exports a user full message archive, and optionally other user data, to a portable document.
+
+With json format the document can be imported back using "user import --format jackal".
+With xml format an XEP-0227 (Portable Import/Export Format) document is generated.`,
+		Run: archiveExportCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&archiveExportFormat, "format", "json", "Exported document format (json or xml)")
+	cmd.Flags().StringVar(&archiveExportOutputFile, "output", "", "Write the exported document to a file instead of stdout")
+	cmd.Flags().BoolVar(&archiveExportIncludeRoster, "include-roster", false, "Include user roster")
+	cmd.Flags().BoolVar(&archiveExportIncludeVCard, "include-vcard", false, "Include user vCard")
+	cmd.Flags().BoolVar(&archiveExportIncludeOffline, "include-offline", false, "Include user pending offline messages")
+
+	return &cmd
+}
+
+func newArchiveQuotaSetCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "quota-set [options]",
+		Short: "Overrides archive queue size and max age for a user or host",
+		Run:   archiveQuotaSetCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&archiveQuotaUsername, "user", "", "Archive owner username")
+	cmd.Flags().StringVar(&archiveQuotaHost, "host", "", "Host domain")
+	cmd.Flags().IntVar(&archiveQuotaQueueSize, "queue-size", 0, "Maximum number of archived messages")
+	cmd.Flags().DurationVar(&archiveQuotaMaxAge, "max-age", 0, "How long archived messages are kept (e.g. 720h)")
+
+	return &cmd
+}
+
+func newArchiveQuotaListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "quota-list",
+		Short: "Lists archive quota overrides",
+		Run:   archiveQuotaListCommandFunc,
+	}
+}
+
+func newArchiveQuotaDeleteCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "quota-delete [options]",
+		Short: "Removes the archive quota override of a user or host",
+		Run:   archiveQuotaDeleteCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&archiveQuotaUsername, "user", "", "Archive owner username")
+	cmd.Flags().StringVar(&archiveQuotaHost, "host", "", "Host domain")
+
+	return &cmd
+}
+
+// archiveRepairCommandFunc executes the "archive repair" command.
+func archiveRepairCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("archive repair command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustArchivesClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.RepairArchives(ctx, &adminpb.RepairArchivesRequest{
+		DeleteOrphans: deleteOrphans,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.RepairArchives(resp)
+}
+
+// archiveDeleteConversationCommandFunc executes the "archive delete-conversation" command.
+func archiveDeleteConversationCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("archive delete-conversation command requires username and jid as its arguments"))
+	}
+	cc, ctx, cancel := mustArchivesClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.DeleteConversation(ctx, &adminpb.DeleteConversationRequest{
+		Username: args[0],
+		Jid:      args[1],
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.DeleteConversation(args[0], args[1], resp)
+}
+
+// archiveExportCommandFunc executes the "archive export" command.
+func archiveExportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("archive export command requires username as its argument"))
+	}
+	format, ok := adminpb.ExportFormat_value["EXPORT_FORMAT_"+strings.ToUpper(archiveExportFormat)]
+	if !ok {
+		ExitWithError(ExitBadArgs, fmt.Errorf("unsupported export format: %s", archiveExportFormat))
+	}
+	cc, ctx, cancel := mustArchivesClientFromCmd(cmd)
+	defer cancel()
+
+	stream, err := cc.ExportArchive(ctx, &adminpb.ExportArchiveRequest{
+		Username:       args[0],
+		Format:         adminpb.ExportFormat(format),
+		IncludeRoster:  archiveExportIncludeRoster,
+		IncludeVcard:   archiveExportIncludeVCard,
+		IncludeOffline: archiveExportIncludeOffline,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	var w io.Writer = os.Stdout
+	if len(archiveExportOutputFile) > 0 {
+		f, err := os.OpenFile(archiveExportOutputFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	var size int64
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		n, err := w.Write(resp.GetData())
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		size += int64(n)
+	}
+	display.ExportArchive(args[0], archiveExportOutputFile, size)
+}
+
+// archiveQuotaSetCommandFunc executes the "archive quota-set" command.
+func archiveQuotaSetCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("archive quota-set command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustArchivesClientFromCmd(cmd)
+	defer cancel()
+
+	_, err := cc.SetArchiveQuota(ctx, &adminpb.SetArchiveQuotaRequest{
+		Quota: &adminpb.ArchiveQuota{
+			Username:      archiveQuotaUsername,
+			Host:          archiveQuotaHost,
+			QueueSize:     int32(archiveQuotaQueueSize),
+			MaxAgeSeconds: int64(archiveQuotaMaxAge / time.Second),
+		},
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.SetArchiveQuota(archiveQuotaUsername, archiveQuotaHost)
+}
+
+// archiveQuotaListCommandFunc executes the "archive quota-list" command.
+func archiveQuotaListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("archive quota-list command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustArchivesClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetArchiveQuotas(ctx, &adminpb.GetArchiveQuotasRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ListArchiveQuotas(resp)
+}
+
+// archiveQuotaDeleteCommandFunc executes the "archive quota-delete" command.
+func archiveQuotaDeleteCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("archive quota-delete command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustArchivesClientFromCmd(cmd)
+	defer cancel()
+
+	_, err := cc.DeleteArchiveQuota(ctx, &adminpb.DeleteArchiveQuotaRequest{
+		Username: archiveQuotaUsername,
+		Host:     archiveQuotaHost,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.DeleteArchiveQuota(archiveQuotaUsername, archiveQuotaHost)
+}
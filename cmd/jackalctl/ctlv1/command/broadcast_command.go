@@ -0,0 +1,63 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	broadcastHost       string
+	broadcastJIDPattern string
+	broadcastSubject    string
+)
+
+// NewBroadcastCommand returns the cobra command for "broadcast".
+func NewBroadcastCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "broadcast <message body> [options]",
+		Short: "Sends a headline message to all matching online sessions",
+		Run:   broadcastCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&broadcastHost, "host", "", "Deliver only to sessions of this host")
+	cmd.Flags().StringVar(&broadcastJIDPattern, "jid-pattern", "", "Deliver only to sessions whose full JID matches this pattern")
+	cmd.Flags().StringVar(&broadcastSubject, "subject", "", "Message subject")
+
+	return &cmd
+}
+
+// broadcastCommandFunc executes the "broadcast" command.
+func broadcastCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("broadcast command requires message body as its argument"))
+	}
+	cc, ctx, cancel := mustBroadcastClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.BroadcastMessage(ctx, &adminpb.BroadcastMessageRequest{
+		Host:       broadcastHost,
+		JidPattern: broadcastJIDPattern,
+		Subject:    broadcastSubject,
+		Body:       args[0],
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.Broadcast(resp)
+}
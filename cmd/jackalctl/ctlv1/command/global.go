@@ -57,6 +57,54 @@ func mustUsersClientFromCmd(cmd *cobra.Command) (adminpb.UsersClient, context.Co
 	return adminpb.NewUsersClient(conn), ctx, cancel
 }
 
+func mustArchivesClientFromCmd(cmd *cobra.Command) (adminpb.ArchivesClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewArchivesClient(conn), ctx, cancel
+}
+
+func mustS2SClientFromCmd(cmd *cobra.Command) (adminpb.S2SClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewS2SClient(conn), ctx, cancel
+}
+
+func mustStatsClientFromCmd(cmd *cobra.Command) (adminpb.StatsClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewStatsClient(conn), ctx, cancel
+}
+
+func mustBroadcastClientFromCmd(cmd *cobra.Command) (adminpb.BroadcastClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewBroadcastClient(conn), ctx, cancel
+}
+
+func mustHostsClientFromCmd(cmd *cobra.Command) (adminpb.HostsClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewHostsClient(conn), ctx, cancel
+}
+
+func mustListenersClientFromCmd(cmd *cobra.Command) (adminpb.ListenersClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewListenersClient(conn), ctx, cancel
+}
+
+func mustAbuseReportsClientFromCmd(cmd *cobra.Command) (adminpb.AbuseReportsClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewAbuseReportsClient(conn), ctx, cancel
+}
+
+func mustOutageStatusClientFromCmd(cmd *cobra.Command) (adminpb.OutageStatusClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewOutageStatusClient(conn), ctx, cancel
+}
+
 func initDisplayFromCmd(cmd *cobra.Command) {
 	display = &simplePrinter{}
 }
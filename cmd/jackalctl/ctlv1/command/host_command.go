@@ -0,0 +1,129 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostCertFile      string
+	hostPrivKeyFile   string
+	hostMobileProfile bool
+	hostDisabledMods  []string
+)
+
+// NewHostCommand returns the cobra command for "host".
+func NewHostCommand() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "host <subcommand>",
+		Short: "Host related commands",
+	}
+
+	ac.AddCommand(newHostListCommand())
+	ac.AddCommand(newHostAddCommand())
+	ac.AddCommand(newHostRemoveCommand())
+
+	return ac
+}
+
+func newHostListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists all local hosts",
+		Run:   hostListCommandFunc,
+	}
+}
+
+func newHostAddCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "add <domain> [options]",
+		Short: "Adds a new local host until next restart (not available in clustered deployments)",
+		Run:   hostAddCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&hostCertFile, "cert-file", "", "Server local path of the host TLS certificate")
+	cmd.Flags().StringVar(&hostPrivKeyFile, "privkey-file", "", "Server local path of the host TLS private key")
+	cmd.Flags().BoolVar(&hostMobileProfile, "mobile-profile", false, "Whether the host serves mostly mobile clients")
+	cmd.Flags().StringSliceVar(&hostDisabledMods, "disabled-module", nil, "Module not serving the host (can be repeated)")
+
+	return &cmd
+}
+
+func newHostRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <domain>",
+		Short: "Removes a local host",
+		Run:   hostRemoveCommandFunc,
+	}
+}
+
+// hostListCommandFunc executes the "host list" command.
+func hostListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("host list command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ListHosts(ctx, &adminpb.ListHostsRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ListHosts(resp)
+}
+
+// hostAddCommandFunc executes the "host add" command.
+func hostAddCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("host add command requires domain as its argument"))
+	}
+	domain := args[0]
+
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.AddHost(ctx, &adminpb.AddHostRequest{
+		Domain:          domain,
+		CertFile:        hostCertFile,
+		PrivkeyFile:     hostPrivKeyFile,
+		MobileProfile:   hostMobileProfile,
+		DisabledModules: hostDisabledMods,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.AddHost(domain, resp)
+}
+
+// hostRemoveCommandFunc executes the "host remove" command.
+func hostRemoveCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("host remove command requires domain as its argument"))
+	}
+	domain := args[0]
+
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.RemoveHost(ctx, &adminpb.RemoveHostRequest{Domain: domain})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.RemoveHost(domain, resp)
+}
@@ -0,0 +1,748 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackal-xmpp/stravaganza"
+	xmppparser "github.com/ortuman/jackal/pkg/parser"
+)
+
+const (
+	jackalImportFormat   = "jackal"
+	ejabberdImportFormat = "ejabberd"
+	prosodyImportFormat  = "prosody"
+	xep0227ImportFormat  = "xep0227"
+)
+
+const xep0227Namespace = "urn:xmpp:pie:0"
+
+// importBundle mirrors the user data bundle accepted by ImportUser rpc.
+type importBundle struct {
+	Username      string                     `json:"username"`
+	Password      string                     `json:"password,omitempty"`
+	Last          *importLast                `json:"last,omitempty"`
+	Roster        []*importRosterItem        `json:"roster"`
+	Notifications []importRosterNotification `json:"roster_notifications"`
+	BlockList     []string                   `json:"block_list"`
+	VCard         string                     `json:"vcard,omitempty"`
+	Private       []string                   `json:"private_storage"`
+	Offline       []string                   `json:"offline_messages"`
+	Archive       []importArchiveMessage     `json:"archive"`
+}
+
+type importLast struct {
+	Seconds int64  `json:"seconds"`
+	Status  string `json:"status,omitempty"`
+}
+
+type importRosterItem struct {
+	JID          string   `json:"jid"`
+	Name         string   `json:"name,omitempty"`
+	Subscription string   `json:"subscription"`
+	Ask          bool     `json:"ask"`
+	Groups       []string `json:"groups,omitempty"`
+}
+
+type importRosterNotification struct {
+	JID      string `json:"jid"`
+	Presence string `json:"presence,omitempty"`
+}
+
+type importArchiveMessage struct {
+	ID      string    `json:"id"`
+	Stamp   time.Time `json:"stamp"`
+	Message string    `json:"message"`
+}
+
+// importBundles keeps track of the bundles being built, preserving the order in which users were found.
+type importBundles struct {
+	host    string
+	order   []string
+	bundles map[string]*importBundle
+}
+
+func newImportBundles(host string) *importBundles {
+	return &importBundles{
+		host:    host,
+		bundles: make(map[string]*importBundle),
+	}
+}
+
+func (b *importBundles) add(username, password string) {
+	if _, ok := b.bundles[username]; ok {
+		return
+	}
+	b.bundles[username] = &importBundle{Username: username, Password: password}
+	b.order = append(b.order, username)
+}
+
+func (b *importBundles) get(username string) *importBundle {
+	return b.bundles[username]
+}
+
+func (b *importBundles) rosterItem(username, jid string) *importRosterItem {
+	bundle := b.get(username)
+	if bundle == nil {
+		return nil
+	}
+	for _, itm := range bundle.Roster {
+		if itm.JID == jid {
+			return itm
+		}
+	}
+	return nil
+}
+
+func (b *importBundles) list(onlyUser string) []*importBundle {
+	var ret []*importBundle
+	for _, username := range b.order {
+		if len(onlyUser) > 0 && username != onlyUser {
+			continue
+		}
+		ret = append(ret, b.bundles[username])
+	}
+	return ret
+}
+
+// loadImportBundles reads the data stored at path in the given format and converts it into a set of user bundles.
+func loadImportBundles(format, path, host, onlyUser string) ([][]byte, []string, error) {
+	var bundles *importBundles
+	var err error
+
+	switch format {
+	case jackalImportFormat:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		var bundle importBundle
+		if err := json.Unmarshal(b, &bundle); err != nil {
+			return nil, nil, err
+		}
+		return [][]byte{b}, []string{bundle.Username}, nil
+
+	case ejabberdImportFormat:
+		bundles, err = loadEjabberdBundles(path, host)
+
+	case xep0227ImportFormat:
+		bundles, err = loadXEP0227Bundles(path, host)
+
+	case prosodyImportFormat:
+		if len(host) == 0 {
+			return nil, nil, errors.New("host is required when importing prosody data")
+		}
+		bundles, err = loadProsodyBundles(path, host)
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var data [][]byte
+	var usernames []string
+	for _, bundle := range bundles.list(onlyUser) {
+		b, err := json.Marshal(bundle)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, b)
+		usernames = append(usernames, bundle.Username)
+	}
+	return data, usernames, nil
+}
+
+// loadXEP0227Bundles converts an XEP-0227 (Portable Import/Export Format) document into user bundles.
+func loadXEP0227Bundles(path, host string) (*importBundles, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	root, err := xmppparser.New(f, xmppparser.DefaultMode, 0).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if root.Name() != "server-data" || root.Attribute(stravaganza.Namespace) != xep0227Namespace {
+		return nil, errors.New("not an XEP-0227 document")
+	}
+	bundles := newImportBundles(host)
+
+	for _, hostElem := range root.Children("host") {
+		domain := hostElem.Attribute("jid")
+		if len(host) > 0 && domain != host {
+			continue
+		}
+		for _, userElem := range hostElem.Children("user") {
+			username := userElem.Attribute("name")
+			if len(username) == 0 {
+				return nil, fmt.Errorf("%s: missing user name", domain)
+			}
+			if bundles.get(username) != nil {
+				return nil, fmt.Errorf("user %s found in multiple hosts, use --host to select one of them", username)
+			}
+			bundles.add(username, userElem.Attribute("password"))
+
+			if err := addXEP0227UserData(bundles.get(username), domain, userElem); err != nil {
+				return nil, fmt.Errorf("%s@%s: %v", username, domain, err)
+			}
+		}
+	}
+	return bundles, nil
+}
+
+func addXEP0227UserData(bundle *importBundle, domain string, userElem stravaganza.Element) error {
+	if roster := userElem.ChildNamespace("query", "jabber:iq:roster"); roster != nil {
+		for _, itm := range roster.Children("item") {
+			subscription := itm.Attribute("subscription")
+			if len(subscription) == 0 {
+				subscription = "none"
+			}
+			ri := &importRosterItem{
+				JID:          itm.Attribute("jid"),
+				Name:         itm.Attribute("name"),
+				Subscription: subscription,
+				Ask:          itm.Attribute("ask") == "subscribe",
+			}
+			for _, group := range itm.Children("group") {
+				ri.Groups = append(ri.Groups, group.Text())
+			}
+			bundle.Roster = append(bundle.Roster, ri)
+		}
+	}
+	for _, presence := range userElem.Children("presence") {
+		if presence.Attribute(stravaganza.Type) != stravaganza.SubscribeType {
+			continue
+		}
+		var status string
+		if st := presence.Child("status"); st != nil {
+			status = st.Text()
+		}
+		from := presence.Attribute(stravaganza.From)
+		bundle.Notifications = append(bundle.Notifications, importRosterNotification{
+			JID:      from,
+			Presence: subscribePresence(from, bundle.Username, domain, status),
+		})
+	}
+	if blockList := userElem.ChildNamespace("blocklist", "urn:xmpp:blocking"); blockList != nil {
+		for _, itm := range blockList.Children("item") {
+			bundle.BlockList = append(bundle.BlockList, itm.Attribute("jid"))
+		}
+	}
+	if vCard := userElem.ChildNamespace("vCard", "vcard-temp"); vCard != nil {
+		bundle.VCard = vCard.String()
+	}
+	if private := userElem.ChildNamespace("query", "jabber:iq:private"); private != nil {
+		for _, prv := range private.AllChildren() {
+			bundle.Private = append(bundle.Private, prv.String())
+		}
+	}
+	if offline := userElem.Child("offline-messages"); offline != nil {
+		for _, msg := range offline.Children("message") {
+			bundle.Offline = append(bundle.Offline, msg.String())
+		}
+	}
+	archive := userElem.Child("archive")
+	if archive == nil {
+		archive = userElem.ChildNamespace("query", "urn:xmpp:mam:2")
+	}
+	if archive == nil {
+		return nil
+	}
+	for _, res := range archive.Children("result") {
+		forwarded := res.ChildNamespace("forwarded", "urn:xmpp:forward:0")
+		if forwarded == nil {
+			continue
+		}
+		msg := forwarded.Child("message")
+		if msg == nil {
+			continue
+		}
+		var stamp time.Time
+		if delay := forwarded.ChildNamespace("delay", "urn:xmpp:delay"); delay != nil {
+			ts, err := time.Parse(time.RFC3339, delay.Attribute("stamp"))
+			if err != nil {
+				return fmt.Errorf("archive: %v", err)
+			}
+			stamp = ts.UTC()
+		}
+		bundle.Archive = append(bundle.Archive, importArchiveMessage{
+			ID:      res.Attribute(stravaganza.ID),
+			Stamp:   stamp,
+			Message: msg.String(),
+		})
+	}
+	return nil
+}
+
+// loadEjabberdBundles converts a directory of CSV files, one per ejabberd SQL table
+// and including a header row, into user bundles.
+func loadEjabberdBundles(dir, host string) (*importBundles, error) {
+	bundles := newImportBundles(host)
+
+	rows, err := readCSVTable(dir, "users", host)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		password := row["password"]
+		if len(row["serverkey"]) > 0 {
+			// SCRAM hashed credentials cannot be carried over
+			password = ""
+		}
+		bundles.add(row["username"], password)
+	}
+	// roster
+	if rows, err = readCSVTable(dir, "rosterusers", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		bundle := bundles.get(row["username"])
+		if bundle == nil {
+			continue
+		}
+		jid := row["jid"]
+		ask := row["ask"]
+		if ask == "I" || ask == "B" {
+			bundle.Notifications = append(bundle.Notifications, importRosterNotification{
+				JID:      jid,
+				Presence: subscribePresence(jid, bundle.Username, ejabberdHost(bundles.host, row), row["askmessage"]),
+			})
+		}
+		subscription := ejabberdSubscription(row["subscription"])
+		if subscription == "none" && ask == "I" {
+			continue // only a pending inbound subscription request
+		}
+		bundle.Roster = append(bundle.Roster, &importRosterItem{
+			JID:          jid,
+			Name:         row["nick"],
+			Subscription: subscription,
+			Ask:          ask == "S" || ask == "O" || ask == "B",
+		})
+	}
+	if rows, err = readCSVTable(dir, "rostergroups", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if itm := bundles.rosterItem(row["username"], row["jid"]); itm != nil {
+			itm.Groups = append(itm.Groups, row["grp"])
+		}
+	}
+	// vCard
+	if rows, err = readCSVTable(dir, "vcard", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if bundle := bundles.get(row["username"]); bundle != nil {
+			bundle.VCard = row["vcard"]
+		}
+	}
+	// private storage
+	if rows, err = readCSVTable(dir, "private_storage", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if bundle := bundles.get(row["username"]); bundle != nil {
+			bundle.Private = append(bundle.Private, row["data"])
+		}
+	}
+	// last activity
+	if rows, err = readCSVTable(dir, "last", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		bundle := bundles.get(row["username"])
+		if bundle == nil {
+			continue
+		}
+		seconds, err := strconv.ParseInt(row["seconds"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("last: %v", err)
+		}
+		bundle.Last = &importLast{Seconds: seconds, Status: row["state"]}
+	}
+	// offline messages
+	if rows, err = readCSVTable(dir, "spool", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if bundle := bundles.get(row["username"]); bundle != nil {
+			bundle.Offline = append(bundle.Offline, row["xml"])
+		}
+	}
+	// archive
+	if rows, err = readCSVTable(dir, "archive", host); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		bundle := bundles.get(row["username"])
+		if bundle == nil || row["kind"] == "groupchat" {
+			continue
+		}
+		ts, err := strconv.ParseInt(row["timestamp"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("archive: %v", err)
+		}
+		bundle.Archive = append(bundle.Archive, importArchiveMessage{
+			ID:      row["timestamp"],
+			Stamp:   time.UnixMicro(ts).UTC(),
+			Message: row["xml"],
+		})
+	}
+	return bundles, nil
+}
+
+func ejabberdHost(host string, row map[string]string) string {
+	if len(host) > 0 {
+		return host
+	}
+	return row["server_host"]
+}
+
+func ejabberdSubscription(s string) string {
+	switch s {
+	case "B":
+		return "both"
+	case "T":
+		return "to"
+	case "F":
+		return "from"
+	default:
+		return "none"
+	}
+}
+
+// loadProsodyBundles converts CSV dumps of the prosody and prosody_archive tables
+// used by Prosody's SQL storage backend into user bundles.
+func loadProsodyBundles(dir, host string) (*importBundles, error) {
+	bundles := newImportBundles(host)
+
+	rows, err := readCSVTable(dir, "prosody", "")
+	if err != nil {
+		return nil, err
+	}
+	var hostRows []map[string]string
+	for _, row := range rows {
+		if row["host"] == host {
+			hostRows = append(hostRows, row)
+		}
+	}
+	for _, row := range hostRows {
+		if row["store"] != "accounts" {
+			continue
+		}
+		bundles.add(row["user"], "")
+		if row["key"] == "password" {
+			bundles.get(row["user"]).Password = row["value"]
+		}
+	}
+	vCards := make(map[string]map[string]interface{})
+
+	for _, row := range hostRows {
+		bundle := bundles.get(row["user"])
+		if bundle == nil {
+			continue
+		}
+		switch row["store"] {
+		case "roster":
+			if err := addProsodyRosterRow(bundle, host, row); err != nil {
+				return nil, fmt.Errorf("roster: %v", err)
+			}
+
+		case "private":
+			prv, err := prosodyElement(row)
+			if err != nil {
+				return nil, fmt.Errorf("private: %v", err)
+			}
+			bundle.Private = append(bundle.Private, prv)
+
+		case "vcard":
+			if row["type"] == "xml" {
+				bundle.VCard = row["value"]
+				continue
+			}
+			// preserialized vCard element split across multiple rows
+			v, err := prosodyValue(row)
+			if err != nil {
+				return nil, fmt.Errorf("vcard: %v", err)
+			}
+			if vCards[bundle.Username] == nil {
+				vCards[bundle.Username] = make(map[string]interface{})
+			}
+			if key := row["key"]; len(key) > 0 {
+				vCards[bundle.Username][key] = v
+			} else {
+				vCards[bundle.Username]["__array"] = v
+			}
+
+		case "lastlog":
+			if row["key"] != "timestamp" {
+				continue
+			}
+			seconds, err := strconv.ParseInt(row["value"], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("lastlog: %v", err)
+			}
+			bundle.Last = &importLast{Seconds: seconds}
+		}
+	}
+	for username, vCard := range vCards {
+		elem, err := preserializedElement(vCard)
+		if err != nil {
+			return nil, fmt.Errorf("vcard: %v", err)
+		}
+		bundles.get(username).VCard = elem.String()
+	}
+	// offline messages and archive
+	if rows, err = readCSVTable(dir, "prosody_archive", ""); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		si, _ := strconv.ParseInt(rows[i]["sort_id"], 10, 64)
+		sj, _ := strconv.ParseInt(rows[j]["sort_id"], 10, 64)
+		return si < sj
+	})
+	for _, row := range rows {
+		if row["host"] != host {
+			continue
+		}
+		bundle := bundles.get(row["user"])
+		if bundle == nil {
+			continue
+		}
+		msg, err := prosodyElement(row)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", row["store"], err)
+		}
+		switch row["store"] {
+		case "offline":
+			bundle.Offline = append(bundle.Offline, msg)
+
+		case "archive", "archive2":
+			when, err := strconv.ParseFloat(row["when"], 64)
+			if err != nil {
+				return nil, fmt.Errorf("archive: %v", err)
+			}
+			bundle.Archive = append(bundle.Archive, importArchiveMessage{
+				ID:      row["key"],
+				Stamp:   time.Unix(int64(when), 0).UTC(),
+				Message: msg,
+			})
+		}
+	}
+	return bundles, nil
+}
+
+func addProsodyRosterRow(bundle *importBundle, host string, row map[string]string) error {
+	v, err := prosodyValue(row)
+	if err != nil {
+		return err
+	}
+	m, _ := v.(map[string]interface{})
+	if m == nil {
+		return nil
+	}
+	if len(row["key"]) == 0 {
+		// roster metadata, holding pending inbound subscription requests
+		pending, _ := m["pending"].(map[string]interface{})
+		for jid := range pending {
+			bundle.Notifications = append(bundle.Notifications, importRosterNotification{
+				JID:      jid,
+				Presence: subscribePresence(jid, bundle.Username, host, ""),
+			})
+		}
+		return nil
+	}
+	itm := &importRosterItem{
+		JID:          row["key"],
+		Subscription: "none",
+	}
+	if s, ok := m["subscription"].(string); ok {
+		itm.Subscription = s
+	}
+	if name, ok := m["name"].(string); ok {
+		itm.Name = name
+	}
+	itm.Ask = m["ask"] == "subscribe"
+
+	groups, _ := m["groups"].(map[string]interface{})
+	for group := range groups {
+		itm.Groups = append(itm.Groups, group)
+	}
+	sort.Strings(itm.Groups)
+
+	bundle.Roster = append(bundle.Roster, itm)
+	return nil
+}
+
+func prosodyValue(row map[string]string) (interface{}, error) {
+	switch row["type"] {
+	case "json":
+		var v interface{}
+		if err := json.Unmarshal([]byte(row["value"]), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case "number":
+		return strconv.ParseFloat(row["value"], 64)
+	case "boolean":
+		return row["value"] == "true", nil
+	default:
+		return row["value"], nil
+	}
+}
+
+// prosodyElement returns the XML representation of an element stored either
+// as raw XML or as a JSON encoded preserialized stanza.
+func prosodyElement(row map[string]string) (string, error) {
+	if row["type"] == "xml" {
+		return row["value"], nil
+	}
+	v, err := prosodyValue(row)
+	if err != nil {
+		return "", err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected %s value type", row["type"])
+	}
+	elem, err := preserializedElement(m)
+	if err != nil {
+		return "", err
+	}
+	return elem.String(), nil
+}
+
+func preserializedElement(m map[string]interface{}) (stravaganza.Element, error) {
+	name, _ := m["name"].(string)
+	if len(name) == 0 {
+		return nil, errors.New("missing element name")
+	}
+	b := stravaganza.NewBuilder(name)
+
+	attrs, _ := m["attr"].(map[string]interface{})
+	for k, v := range attrs {
+		if s, ok := v.(string); ok {
+			b.WithAttribute(k, s)
+		}
+	}
+	var children []interface{}
+	if arr, ok := m["__array"].([]interface{}); ok {
+		children = arr
+	} else {
+		for i := 1; ; i++ {
+			child, ok := m[strconv.Itoa(i)]
+			if !ok {
+				break
+			}
+			children = append(children, child)
+		}
+	}
+	var text strings.Builder
+	for _, child := range children {
+		switch c := child.(type) {
+		case string:
+			text.WriteString(c)
+		case map[string]interface{}:
+			elem, err := preserializedElement(c)
+			if err != nil {
+				return nil, err
+			}
+			b.WithChild(elem)
+		}
+	}
+	if text.Len() > 0 {
+		b.WithText(text.String())
+	}
+	return b.Build(), nil
+}
+
+func subscribePresence(from, username, host, status string) string {
+	to := username
+	if len(host) > 0 {
+		to = username + "@" + host
+	}
+	b := stravaganza.NewPresenceBuilder().
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, to).
+		WithAttribute(stravaganza.Type, stravaganza.SubscribeType)
+	if len(status) > 0 {
+		b.WithChild(
+			stravaganza.NewBuilder("status").
+				WithText(status).
+				Build(),
+		)
+	}
+	return b.Build().String()
+}
+
+// readCSVTable reads the name.csv file contained in dir, returning its rows keyed by header column.
+// A missing file is considered an empty table.
+// In case host is provided, rows whose server_host column doesn't match it are skipped.
+func readCSVTable(dir, name, host string) ([]map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, name+".csv"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	var rows []map[string]string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(rec) {
+				row[col] = rec[i]
+			}
+		}
+		if sh, ok := row["server_host"]; ok && len(host) > 0 && sh != host {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
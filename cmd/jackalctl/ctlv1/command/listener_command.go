@@ -0,0 +1,127 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listenerBindAddr  string
+	listenerDirectTLS bool
+)
+
+// NewListenerCommand returns the cobra command for "listener".
+func NewListenerCommand() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "listener <subcommand>",
+		Short: "Listener related commands",
+	}
+
+	ac.AddCommand(newListenerListCommand())
+	ac.AddCommand(newListenerAddCommand())
+	ac.AddCommand(newListenerRemoveCommand())
+
+	return ac
+}
+
+func newListenerListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists all active listeners",
+		Run:   listenerListCommandFunc,
+	}
+}
+
+func newListenerAddCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "add <c2s|s2s|component> <port> [options]",
+		Short: "Opens a new listener inheriting the settings of the first configured listener of the same type",
+		Run:   listenerAddCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&listenerBindAddr, "bind-addr", "", "Listener bind address")
+	cmd.Flags().BoolVar(&listenerDirectTLS, "direct-tls", false, "Whether TLS is negotiated right after accepting a connection")
+
+	return &cmd
+}
+
+func newListenerRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id>",
+		Short: "Closes an active listener",
+		Run:   listenerRemoveCommandFunc,
+	}
+}
+
+// listenerListCommandFunc executes the "listener list" command.
+func listenerListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("listener list command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustListenersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ListListeners(ctx, &adminpb.ListListenersRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ListListeners(resp)
+}
+
+// listenerAddCommandFunc executes the "listener add" command.
+func listenerAddCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("listener add command requires type and port as its arguments"))
+	}
+	port, err := strconv.Atoi(args[1])
+	if err != nil {
+		ExitWithError(ExitBadArgs, fmt.Errorf("invalid port value: %s", args[1]))
+	}
+	cc, ctx, cancel := mustListenersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.AddListener(ctx, &adminpb.AddListenerRequest{
+		Type:      args[0],
+		BindAddr:  listenerBindAddr,
+		Port:      int32(port),
+		DirectTls: listenerDirectTLS,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.AddListener(resp)
+}
+
+// listenerRemoveCommandFunc executes the "listener remove" command.
+func listenerRemoveCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("listener remove command requires listener id as its argument"))
+	}
+	id := args[0]
+
+	cc, ctx, cancel := mustListenersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.RemoveListener(ctx, &adminpb.RemoveListenerRequest{Id: id})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.RemoveListener(id, resp)
+}
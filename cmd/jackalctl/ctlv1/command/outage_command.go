@@ -0,0 +1,111 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var outageMessage string
+
+// NewOutageCommand returns the cobra command for "outage".
+func NewOutageCommand() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "outage <subcommand>",
+		Short: "Service outage status commands",
+	}
+
+	ac.AddCommand(newOutageStatusCommand())
+	ac.AddCommand(newOutageDegradeCommand())
+	ac.AddCommand(newOutageRestoreCommand())
+
+	return ac
+}
+
+func newOutageStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Shows the advertised service outage status",
+		Run:   outageStatusCommandFunc,
+	}
+}
+
+func newOutageDegradeCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "degrade [options]",
+		Short: "Announces the service as degraded",
+		Run:   outageDegradeCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&outageMessage, "message", "", "Incident description advertised to clients")
+
+	return &cmd
+}
+
+func newOutageRestoreCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore",
+		Short: "Announces the service as operational",
+		Run:   outageRestoreCommandFunc,
+	}
+}
+
+// outageStatusCommandFunc executes the "outage status" command.
+func outageStatusCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("outage status command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustOutageStatusClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetOutageStatus(ctx, &adminpb.GetOutageStatusRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.OutageStatus(resp.GetStatus())
+}
+
+// outageDegradeCommandFunc executes the "outage degrade" command.
+func outageDegradeCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("outage degrade command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustOutageStatusClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.SetOutageStatus(ctx, &adminpb.SetOutageStatusRequest{Degraded: true, Message: outageMessage})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.OutageStatus(resp.GetStatus())
+}
+
+// outageRestoreCommandFunc executes the "outage restore" command.
+func outageRestoreCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("outage restore command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustOutageStatusClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.SetOutageStatus(ctx, &adminpb.SetOutageStatusRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.OutageStatus(resp.GetStatus())
+}
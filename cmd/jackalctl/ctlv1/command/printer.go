@@ -16,6 +16,7 @@ package command
 
 import (
 	"fmt"
+	"time"
 
 	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
 )
@@ -24,6 +25,32 @@ type printer interface {
 	CreateUser(name string, _ *adminpb.CreateUserResponse)
 	ChangeUserPassword(*adminpb.ChangeUserPasswordResponse)
 	DeleteUser(string, *adminpb.DeleteUserResponse)
+	UndeleteUser(string, *adminpb.UndeleteUserResponse)
+	SuspendUser(string, *adminpb.SuspendUserResponse)
+	UnsuspendUser(string, *adminpb.UnsuspendUserResponse)
+	KickUser(string, *adminpb.KickUserResponse)
+	ExportUser(user, outputFile string, resp *adminpb.ExportUserResponse)
+	ImportUser(*adminpb.ImportUserResponse)
+	RepairArchives(*adminpb.RepairArchivesResponse)
+	DeleteConversation(username, jid string, resp *adminpb.DeleteConversationResponse)
+	ExportArchive(username, outputFile string, size int64)
+	SetArchiveQuota(username, host string)
+	ListArchiveQuotas(*adminpb.GetArchiveQuotasResponse)
+	DeleteArchiveQuota(username, host string)
+	DomainStatus(*adminpb.GetDomainStatusResponse)
+	Stats(*adminpb.GetStatsResponse)
+	ErrorStats(*adminpb.GetErrorStatsResponse)
+	Broadcast(*adminpb.BroadcastMessageResponse)
+	ListHosts(*adminpb.ListHostsResponse)
+	AddHost(string, *adminpb.AddHostResponse)
+	RemoveHost(string, *adminpb.RemoveHostResponse)
+	ListListeners(*adminpb.ListListenersResponse)
+	AddListener(*adminpb.AddListenerResponse)
+	RemoveListener(string, *adminpb.RemoveListenerResponse)
+	ListAbuseReports(*adminpb.ListAbuseReportsResponse)
+	ForwardAbuseReport(string, *adminpb.ForwardAbuseReportResponse)
+	DiscardAbuseReport(string, *adminpb.DiscardAbuseReportResponse)
+	OutageStatus(*adminpb.OutageStatusInfo)
 }
 
 type simplePrinter struct{}
@@ -36,6 +63,212 @@ func (p *simplePrinter) ChangeUserPassword(*adminpb.ChangeUserPasswordResponse)
 	fmt.Println("Password updated")
 }
 
-func (p *simplePrinter) DeleteUser(user string, _ *adminpb.DeleteUserResponse) {
+func (p *simplePrinter) DeleteUser(user string, resp *adminpb.DeleteUserResponse) {
+	if resp.GetPurgeAt() != nil {
+		fmt.Printf("User %s disabled, will be deleted at %s\n", user, resp.GetPurgeAt().AsTime().Format(time.RFC3339))
+		return
+	}
 	fmt.Printf("User %s deleted\n", user)
 }
+
+func (p *simplePrinter) UndeleteUser(user string, _ *adminpb.UndeleteUserResponse) {
+	fmt.Printf("User %s restored\n", user)
+}
+
+func (p *simplePrinter) SuspendUser(user string, _ *adminpb.SuspendUserResponse) {
+	fmt.Printf("User %s suspended\n", user)
+}
+
+func (p *simplePrinter) UnsuspendUser(user string, _ *adminpb.UnsuspendUserResponse) {
+	fmt.Printf("User %s unsuspended\n", user)
+}
+
+func (p *simplePrinter) KickUser(user string, resp *adminpb.KickUserResponse) {
+	fmt.Printf("User %s kicked (%d sessions disconnected)\n", user, resp.GetDisconnectedCount())
+}
+
+func (p *simplePrinter) ExportUser(user, outputFile string, resp *adminpb.ExportUserResponse) {
+	if len(outputFile) > 0 {
+		fmt.Printf("User %s data exported to %s\n", user, outputFile)
+		return
+	}
+	fmt.Println(string(resp.GetData()))
+}
+
+func (p *simplePrinter) ImportUser(resp *adminpb.ImportUserResponse) {
+	if !resp.GetExisting() {
+		fmt.Printf("User %s imported\n", resp.GetUsername())
+		return
+	}
+	switch resp.GetConflictPolicy() {
+	case adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_SKIP:
+		fmt.Printf("User %s already exists, skipped\n", resp.GetUsername())
+	case adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_MERGE:
+		fmt.Printf("User %s already exists, data merged\n", resp.GetUsername())
+	case adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_REPLACE:
+		fmt.Printf("User %s already exists, data replaced\n", resp.GetUsername())
+	}
+}
+
+func (p *simplePrinter) RepairArchives(resp *adminpb.RepairArchivesResponse) {
+	fmt.Printf("%d archives checked, %d orphaned\n", resp.GetCheckedCount(), len(resp.GetOrphanedArchives()))
+	for _, archiveID := range resp.GetOrphanedArchives() {
+		fmt.Println(archiveID)
+	}
+	if resp.GetDeleted() {
+		fmt.Println("Orphaned archives deleted")
+	}
+}
+
+func (p *simplePrinter) DeleteConversation(username, jid string, resp *adminpb.DeleteConversationResponse) {
+	fmt.Printf("Removed %d messages archived by %s with %s\n", resp.GetDeletedCount(), username, jid)
+}
+
+func (p *simplePrinter) ExportArchive(username, outputFile string, size int64) {
+	if len(outputFile) == 0 {
+		return // document already written to stdout
+	}
+	fmt.Printf("Archive of user %s exported to %s (%d bytes)\n", username, outputFile, size)
+}
+
+func (p *simplePrinter) SetArchiveQuota(username, host string) {
+	fmt.Printf("Archive quota of %s set\n", archiveQuotaTarget(username, host))
+}
+
+func (p *simplePrinter) ListArchiveQuotas(resp *adminpb.GetArchiveQuotasResponse) {
+	for _, q := range resp.GetQuotas() {
+		queueSize, maxAge := "-", "-"
+		if q.GetQueueSize() > 0 {
+			queueSize = fmt.Sprintf("%d", q.GetQueueSize())
+		}
+		if q.GetMaxAgeSeconds() > 0 {
+			maxAge = (time.Duration(q.GetMaxAgeSeconds()) * time.Second).String()
+		}
+		fmt.Printf("%s: queue_size=%s max_age=%s\n", archiveQuotaTarget(q.GetUsername(), q.GetHost()), queueSize, maxAge)
+	}
+}
+
+func (p *simplePrinter) DeleteArchiveQuota(username, host string) {
+	fmt.Printf("Archive quota of %s removed\n", archiveQuotaTarget(username, host))
+}
+
+func archiveQuotaTarget(username, host string) string {
+	if len(username) > 0 {
+		return "user " + username
+	}
+	return "host " + host
+}
+
+func (p *simplePrinter) DomainStatus(resp *adminpb.GetDomainStatusResponse) {
+	for _, st := range resp.GetStatuses() {
+		fmt.Printf("%s: connected=%t secured=%t auth=%s dialback=%s sent=%d received=%d\n",
+			st.GetDomain(), st.GetConnected(), st.GetSecured(), st.GetAuthMethod(), st.GetDialbackResult(),
+			st.GetStanzasSent(), st.GetStanzasReceived(),
+		)
+		if len(st.GetLastError()) > 0 {
+			fmt.Printf("  last error: %s (%s)\n", st.GetLastError(), st.GetLastErrorAt().AsTime().Format(time.RFC3339))
+		}
+	}
+}
+
+func (p *simplePrinter) Stats(resp *adminpb.GetStatsResponse) {
+	fmt.Printf("daily active users: %d\n", resp.GetDailyActiveUsers())
+	fmt.Printf("monthly active users: %d\n", resp.GetMonthlyActiveUsers())
+	for _, st := range resp.GetHosts() {
+		fmt.Printf("%s: messages=%d registrations=%d\n", st.GetHost(), st.GetMessages(), st.GetRegistrations())
+	}
+	fmt.Printf("s2s peers: %d\n", len(resp.GetS2SPeers()))
+	for _, peer := range resp.GetS2SPeers() {
+		fmt.Printf("  %s\n", peer)
+	}
+}
+
+func (p *simplePrinter) ErrorStats(resp *adminpb.GetErrorStatsResponse) {
+	fmt.Printf("total errors: %d\n", resp.GetTotal())
+	for _, src := range resp.GetSources() {
+		peer := src.GetPeer()
+		if len(peer) == 0 {
+			peer = "-"
+		}
+		fmt.Printf("%s: origin=%s peer=%s count=%d previous=%d\n",
+			src.GetCondition(), src.GetOrigin(), peer, src.GetCount(), src.GetPreviousCount(),
+		)
+	}
+}
+
+func (p *simplePrinter) Broadcast(resp *adminpb.BroadcastMessageResponse) {
+	fmt.Printf("Message delivered to %d sessions (%d failed)\n", resp.GetDeliveredCount(), resp.GetFailedCount())
+}
+
+func (p *simplePrinter) ListHosts(resp *adminpb.ListHostsResponse) {
+	for _, h := range resp.GetHosts() {
+		if h == resp.GetDefaultHost() {
+			fmt.Printf("%s (default)\n", h)
+			continue
+		}
+		fmt.Println(h)
+	}
+}
+
+func (p *simplePrinter) AddHost(domain string, _ *adminpb.AddHostResponse) {
+	fmt.Printf("Host %s added\n", domain)
+}
+
+func (p *simplePrinter) RemoveHost(domain string, resp *adminpb.RemoveHostResponse) {
+	fmt.Printf("Host %s removed, %d sessions disconnected\n", domain, resp.GetDisconnectedCount())
+}
+
+func (p *simplePrinter) ListListeners(resp *adminpb.ListListenersResponse) {
+	for _, ln := range resp.GetListeners() {
+		var tlsSuffix string
+		if ln.GetDirectTls() {
+			tlsSuffix = " (direct tls)"
+		}
+		fmt.Printf("%s: %s:%d%s\n", ln.GetId(), ln.GetBindAddr(), ln.GetPort(), tlsSuffix)
+	}
+}
+
+func (p *simplePrinter) AddListener(resp *adminpb.AddListenerResponse) {
+	ln := resp.GetListener()
+	fmt.Printf("Listener %s added on %s:%d\n", ln.GetId(), ln.GetBindAddr(), ln.GetPort())
+}
+
+func (p *simplePrinter) RemoveListener(id string, _ *adminpb.RemoveListenerResponse) {
+	fmt.Printf("Listener %s removed\n", id)
+}
+
+func (p *simplePrinter) ListAbuseReports(resp *adminpb.ListAbuseReportsResponse) {
+	for _, r := range resp.GetReports() {
+		fmt.Printf("%s: %s reason=%s reported_at=%s\n",
+			r.GetId(), r.GetJid(), r.GetReason(), r.GetReportedAt().AsTime().Format(time.RFC3339),
+		)
+		if len(r.GetText()) > 0 {
+			fmt.Printf("  %s\n", r.GetText())
+		}
+	}
+}
+
+func (p *simplePrinter) ForwardAbuseReport(id string, _ *adminpb.ForwardAbuseReportResponse) {
+	fmt.Printf("Abuse report %s forwarded\n", id)
+}
+
+func (p *simplePrinter) DiscardAbuseReport(id string, _ *adminpb.DiscardAbuseReportResponse) {
+	fmt.Printf("Abuse report %s discarded\n", id)
+}
+
+func (p *simplePrinter) OutageStatus(st *adminpb.OutageStatusInfo) {
+	status := "operational"
+	if st.GetDegraded() {
+		status = "degraded"
+	}
+	fmt.Printf("Status: %s\n", status)
+	if len(st.GetMessage()) > 0 {
+		fmt.Printf("Message: %s\n", st.GetMessage())
+	}
+	if st.GetUpdatedAt() != nil {
+		fmt.Printf("Updated at: %s\n", st.GetUpdatedAt().AsTime().Format(time.RFC3339))
+	}
+	for _, addr := range st.GetExternalStatusAddresses() {
+		fmt.Printf("External status address: %s\n", addr)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+// NewS2SCommand returns the cobra command for "s2s".
+func NewS2SCommand() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "s2s <subcommand>",
+		Short: "S2S federation related commands",
+	}
+
+	ac.AddCommand(newS2SStatusCommand())
+
+	return ac
+}
+
+func newS2SStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [domain]",
+		Short: "Shows remote domains connectivity state",
+		Run:   s2sStatusCommandFunc,
+	}
+}
+
+// s2sStatusCommandFunc executes the "s2s status" command.
+func s2sStatusCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("s2s status command accepts at most one domain argument"))
+	}
+	var domain string
+	if len(args) == 1 {
+		domain = args[0]
+	}
+	cc, ctx, cancel := mustS2SClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetDomainStatus(ctx, &adminpb.GetDomainStatusRequest{
+		Domain: domain,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.DomainStatus(resp)
+}
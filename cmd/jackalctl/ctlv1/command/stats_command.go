@@ -0,0 +1,92 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsDays int32
+
+	errorStatsMinutes int32
+	errorStatsLimit   int32
+)
+
+// NewStatsCommand returns the cobra command for "stats".
+func NewStatsCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "stats [options]",
+		Short: "Shows server usage statistics",
+		Run:   statsCommandFunc,
+	}
+
+	cmd.Flags().Int32Var(&statsDays, "days", 30, "Number of days over which host counters are aggregated")
+
+	cmd.AddCommand(newStatsErrorsCommand())
+
+	return &cmd
+}
+
+func newStatsErrorsCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "errors [options]",
+		Short: "Shows top error stanza sources of the queried node",
+		Run:   statsErrorsCommandFunc,
+	}
+
+	cmd.Flags().Int32Var(&errorStatsMinutes, "minutes", 5, "Number of minutes over which errors are counted")
+	cmd.Flags().Int32Var(&errorStatsLimit, "limit", 10, "Maximum number of displayed error sources")
+
+	return &cmd
+}
+
+// statsCommandFunc executes the "stats" command.
+func statsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("stats command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustStatsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetStats(ctx, &adminpb.GetStatsRequest{
+		Days: statsDays,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.Stats(resp)
+}
+
+// statsErrorsCommandFunc executes the "stats errors" command.
+func statsErrorsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("stats errors command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustStatsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetErrorStats(ctx, &adminpb.GetErrorStatsRequest{
+		Minutes: errorStatsMinutes,
+		Limit:   errorStatsLimit,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ErrorStats(resp)
+}
@@ -18,7 +18,9 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/bgentry/speakeasy"
 	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
@@ -28,6 +30,18 @@ import (
 var (
 	passwordFromFlag    string
 	passwordInteractive bool
+	exportOutputFile    string
+	forceDelete         bool
+	importFormat        string
+	importHost          string
+	importUser          string
+	importPassword      string
+	importOnConflict    string
+	kickResource        string
+	kickCondition       string
+	kickText            string
+	kickSeeOtherHost    string
+	kickRetryAfter      time.Duration
 )
 
 // NewUserCommand returns the cobra command for "user".
@@ -40,6 +54,12 @@ func NewUserCommand() *cobra.Command {
 	ac.AddCommand(newUserAddCommand())
 	ac.AddCommand(newUserChangePasswordCommand())
 	ac.AddCommand(newUserDeleteCommand())
+	ac.AddCommand(newUserUndeleteCommand())
+	ac.AddCommand(newUserSuspendCommand())
+	ac.AddCommand(newUserUnsuspendCommand())
+	ac.AddCommand(newUserKickCommand())
+	ac.AddCommand(newUserExportCommand())
+	ac.AddCommand(newUserImportCommand())
 
 	return ac
 }
@@ -70,11 +90,91 @@ func newUserChangePasswordCommand() *cobra.Command {
 }
 
 func newUserDeleteCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "delete <user name>",
+	cmd := cobra.Command{
+		Use:   "delete <user name> [options]",
 		Short: "Deletes a user",
 		Run:   userDeleteCommandFunc,
 	}
+
+	cmd.Flags().BoolVar(&forceDelete, "force", false, "Remove user immediately, skipping any configured grace period")
+
+	return &cmd
+}
+
+func newUserUndeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undelete <user name>",
+		Short: "Restores a deleted user whose grace period has not expired",
+		Run:   userUndeleteCommandFunc,
+	}
+}
+
+func newUserSuspendCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "suspend <user name>",
+		Short: "Suspends a user, keeping all its data",
+		Run:   userSuspendCommandFunc,
+	}
+}
+
+func newUserUnsuspendCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unsuspend <user name>",
+		Short: "Reactivates a suspended user",
+		Run:   userUnsuspendCommandFunc,
+	}
+}
+
+func newUserKickCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "kick <user name> [options]",
+		Short: "Disconnects user sessions, optionally hinting clients to reconnect to another host",
+		Run:   userKickCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&kickResource, "resource", "", "Only disconnect the session bound to this resource")
+	cmd.Flags().StringVar(&kickCondition, "condition", "", "Stream error condition (defaults to see-other-host if a host is provided, policy-violation otherwise)")
+	cmd.Flags().StringVar(&kickText, "text", "", "Human-readable text included into the stream error")
+	cmd.Flags().StringVar(&kickSeeOtherHost, "see-other-host", "", "Host (and optional port) clients should reconnect to")
+	cmd.Flags().DurationVar(&kickRetryAfter, "retry-after", 0, "Time clients should wait before reconnecting")
+
+	return &cmd
+}
+
+func newUserExportCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "export <user name> [options]",
+		Short: "Exports all data stored about a user",
+		Run:   userExportCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&exportOutputFile, "output", "", "Write the exported data to a file instead of stdout")
+
+	return &cmd
+}
+
+func newUserImportCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "import <path> [options]",
+		Short: "Imports user data exported from jackal, ejabberd, Prosody or any XEP-0227 compliant server",
+		Long: `Imports user data exported from jackal, ejabberd, Prosody or any XEP-0227 compliant server.
+
+With jackal format path refers to a file generated by "user export".
+With xep0227 format path refers to an XEP-0227 (Portable Import/Export Format) XML document.
+With ejabberd format path refers to a directory containing CSV dumps (header row included)
+of users, rosterusers, rostergroups, vcard, private_storage, last, spool and archive SQL tables.
+With prosody format path refers to a directory containing CSV dumps (header row included)
+of prosody and prosody_archive SQL tables.`,
+		Run: userImportCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&importFormat, "format", "jackal", "Import data format (jackal, xep0227, ejabberd or prosody)")
+	cmd.Flags().StringVar(&importHost, "host", "", "Only import users belonging to this host (required for prosody format)")
+	cmd.Flags().StringVar(&importUser, "user", "", "Only import this user")
+	cmd.Flags().StringVar(&importPassword, "password", "", "Password assigned to imported users, overriding the one contained in exported data")
+	cmd.Flags().StringVar(&importOnConflict, "on-conflict", "fail", "Action to take when a user already exists (fail, skip, merge or replace)")
+
+	return &cmd
 }
 
 // userAddCommandFunc executes the "user add" command.
@@ -156,13 +256,148 @@ func userDeleteCommandFunc(cmd *cobra.Command, args []string) {
 	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
 	defer cancel()
 
-	resp, err := cc.DeleteUser(ctx, &adminpb.DeleteUserRequest{Username: username})
+	resp, err := cc.DeleteUser(ctx, &adminpb.DeleteUserRequest{
+		Username: username,
+		Force:    forceDelete,
+	})
 	if err != nil {
 		ExitWithError(ExitError, err)
 	}
 	display.DeleteUser(username, resp)
 }
 
+// userUndeleteCommandFunc executes the "user undelete" command.
+func userUndeleteCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user undelete command requires user name as its argument"))
+	}
+	username := args[0]
+
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.UndeleteUser(ctx, &adminpb.UndeleteUserRequest{Username: username})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.UndeleteUser(username, resp)
+}
+
+// userSuspendCommandFunc executes the "user suspend" command.
+func userSuspendCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user suspend command requires user name as its argument"))
+	}
+	username := args[0]
+
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.SuspendUser(ctx, &adminpb.SuspendUserRequest{Username: username})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.SuspendUser(username, resp)
+}
+
+// userUnsuspendCommandFunc executes the "user unsuspend" command.
+func userUnsuspendCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user unsuspend command requires user name as its argument"))
+	}
+	username := args[0]
+
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.UnsuspendUser(ctx, &adminpb.UnsuspendUserRequest{Username: username})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.UnsuspendUser(username, resp)
+}
+
+// userKickCommandFunc executes the "user kick" command.
+func userKickCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user kick command requires user name as its argument"))
+	}
+	username := args[0]
+
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.KickUser(ctx, &adminpb.KickUserRequest{
+		Username:     username,
+		Resource:     kickResource,
+		Condition:    kickCondition,
+		Text:         kickText,
+		SeeOtherHost: kickSeeOtherHost,
+		RetryAfter:   uint32(kickRetryAfter.Seconds()),
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.KickUser(username, resp)
+}
+
+// userExportCommandFunc executes the "user export" command.
+func userExportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user export command requires user name as its argument"))
+	}
+	username := args[0]
+
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ExportUser(ctx, &adminpb.ExportUserRequest{Username: username})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	if len(exportOutputFile) > 0 {
+		if err := os.WriteFile(exportOutputFile, resp.GetData(), 0600); err != nil {
+			ExitWithError(ExitError, err)
+		}
+	}
+	display.ExportUser(username, exportOutputFile, resp)
+}
+
+// userImportCommandFunc executes the "user import" command.
+func userImportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user import command requires data path as its argument"))
+	}
+	policy, ok := adminpb.ImportConflictPolicy_value["IMPORT_CONFLICT_POLICY_"+strings.ToUpper(importOnConflict)]
+	if !ok {
+		ExitWithError(ExitBadArgs, fmt.Errorf("unsupported conflict action: %s", importOnConflict))
+	}
+	bundles, usernames, err := loadImportBundles(importFormat, args[0], importHost, importUser)
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	var failed int
+	for i, bundle := range bundles {
+		resp, err := cc.ImportUser(ctx, &adminpb.ImportUserRequest{
+			Data:           bundle,
+			Password:       importPassword,
+			ConflictPolicy: adminpb.ImportConflictPolicy(policy),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to import user %s: %v\n", usernames[i], err)
+			failed++
+			continue
+		}
+		display.ImportUser(resp)
+	}
+	if failed > 0 {
+		ExitWithError(ExitError, fmt.Errorf("%d of %d users could not be imported", failed, len(bundles)))
+	}
+}
+
 func readPasswordInteractive(name string) string {
 	prompt1 := fmt.Sprintf("Password of %s: ", name)
 	password1, err1 := speakeasy.Ask(prompt1)
@@ -51,6 +51,14 @@ func init() {
 
 	rootCmd.AddCommand(
 		command.NewUserCommand(),
+		command.NewArchiveCommand(),
+		command.NewS2SCommand(),
+		command.NewStatsCommand(),
+		command.NewBroadcastCommand(),
+		command.NewHostCommand(),
+		command.NewListenerCommand(),
+		command.NewAbuseCommand(),
+		command.NewOutageCommand(),
 		command.NewVersionCommand(),
 	)
 }
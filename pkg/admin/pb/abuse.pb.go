@@ -0,0 +1,591 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        v3.21.5
+// source: proto/admin/v1/abuse.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AbuseReport describes a spam or abuse report filed against a remote JID.
+type AbuseReport struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id is the report identifier.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// jid is the reported JID.
+	Jid string `protobuf:"bytes,2,opt,name=jid,proto3" json:"jid,omitempty"`
+	// reason is the report reason (e.g. urn:xmpp:reporting:spam).
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// text is the optional report description provided by the reporting user.
+	Text string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	// reported_at is the time at which the report was filed.
+	ReportedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=reported_at,json=reportedAt,proto3" json:"reported_at,omitempty"`
+}
+
+func (x *AbuseReport) Reset() {
+	*x = AbuseReport{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AbuseReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbuseReport) ProtoMessage() {}
+
+func (x *AbuseReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbuseReport.ProtoReflect.Descriptor instead.
+func (*AbuseReport) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AbuseReport) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AbuseReport) GetJid() string {
+	if x != nil {
+		return x.Jid
+	}
+	return ""
+}
+
+func (x *AbuseReport) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AbuseReport) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *AbuseReport) GetReportedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReportedAt
+	}
+	return nil
+}
+
+// ListAbuseReportsRequest is the parameter message for ListAbuseReports rpc.
+type ListAbuseReportsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListAbuseReportsRequest) Reset() {
+	*x = ListAbuseReportsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAbuseReportsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAbuseReportsRequest) ProtoMessage() {}
+
+func (x *ListAbuseReportsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAbuseReportsRequest.ProtoReflect.Descriptor instead.
+func (*ListAbuseReportsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{1}
+}
+
+// ListAbuseReportsResponse is the response returned by ListAbuseReports rpc.
+type ListAbuseReportsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// reports contains all pending reports, oldest first.
+	Reports []*AbuseReport `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
+}
+
+func (x *ListAbuseReportsResponse) Reset() {
+	*x = ListAbuseReportsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAbuseReportsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAbuseReportsResponse) ProtoMessage() {}
+
+func (x *ListAbuseReportsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAbuseReportsResponse.ProtoReflect.Descriptor instead.
+func (*ListAbuseReportsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListAbuseReportsResponse) GetReports() []*AbuseReport {
+	if x != nil {
+		return x.Reports
+	}
+	return nil
+}
+
+// ForwardAbuseReportRequest is the parameter message for ForwardAbuseReport rpc.
+type ForwardAbuseReportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id is the report identifier.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *ForwardAbuseReportRequest) Reset() {
+	*x = ForwardAbuseReportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ForwardAbuseReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardAbuseReportRequest) ProtoMessage() {}
+
+func (x *ForwardAbuseReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardAbuseReportRequest.ProtoReflect.Descriptor instead.
+func (*ForwardAbuseReportRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ForwardAbuseReportRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// ForwardAbuseReportResponse is the response returned by ForwardAbuseReport rpc.
+type ForwardAbuseReportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ForwardAbuseReportResponse) Reset() {
+	*x = ForwardAbuseReportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ForwardAbuseReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardAbuseReportResponse) ProtoMessage() {}
+
+func (x *ForwardAbuseReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardAbuseReportResponse.ProtoReflect.Descriptor instead.
+func (*ForwardAbuseReportResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{4}
+}
+
+// DiscardAbuseReportRequest is the parameter message for DiscardAbuseReport rpc.
+type DiscardAbuseReportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id is the report identifier.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DiscardAbuseReportRequest) Reset() {
+	*x = DiscardAbuseReportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DiscardAbuseReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscardAbuseReportRequest) ProtoMessage() {}
+
+func (x *DiscardAbuseReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscardAbuseReportRequest.ProtoReflect.Descriptor instead.
+func (*DiscardAbuseReportRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DiscardAbuseReportRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// DiscardAbuseReportResponse is the response returned by DiscardAbuseReport rpc.
+type DiscardAbuseReportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DiscardAbuseReportResponse) Reset() {
+	*x = DiscardAbuseReportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_abuse_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DiscardAbuseReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscardAbuseReportResponse) ProtoMessage() {}
+
+func (x *DiscardAbuseReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_abuse_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscardAbuseReportResponse.ProtoReflect.Descriptor instead.
+func (*DiscardAbuseReportResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_abuse_proto_rawDescGZIP(), []int{6}
+}
+
+var File_proto_admin_v1_abuse_proto protoreflect.FileDescriptor
+
+var file_proto_admin_v1_abuse_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2f, 0x76, 0x31,
+	0x2f, 0x61, 0x62, 0x75, 0x73, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x61, 0x64,
+	0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x98, 0x01, 0x0a, 0x0b, 0x41, 0x62, 0x75, 0x73,
+	0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6a, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6a, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64,
+	0x41, 0x74, 0x22, 0x19, 0x0a, 0x17, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x4b, 0x0a,
+	0x18, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x07, 0x72, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x64, 0x6d,
+	0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x22, 0x2b, 0x0a, 0x19, 0x46, 0x6f,
+	0x72, 0x77, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x1c, 0x0a, 0x1a, 0x46, 0x6f, 0x72, 0x77, 0x61,
+	0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2b, 0x0a, 0x19, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64,
+	0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x69, 0x64, 0x22, 0x1c, 0x0a, 0x1a, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75,
+	0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x32, 0xab, 0x02, 0x0a, 0x0c, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x12, 0x59, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65,
+	0x70, 0x6f, 0x72, 0x74, 0x73, 0x12, 0x21, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a, 0x12,
+	0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x23, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x6f,
+	0x72, 0x77, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a,
+	0x12, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x12, 0x23, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44,
+	0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e,
+	0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x41, 0x62, 0x75, 0x73, 0x65,
+	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0e,
+	0x5a, 0x0c, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2f, 0x70, 0x62, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_admin_v1_abuse_proto_rawDescOnce sync.Once
+	file_proto_admin_v1_abuse_proto_rawDescData = file_proto_admin_v1_abuse_proto_rawDesc
+)
+
+func file_proto_admin_v1_abuse_proto_rawDescGZIP() []byte {
+	file_proto_admin_v1_abuse_proto_rawDescOnce.Do(func() {
+		file_proto_admin_v1_abuse_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_admin_v1_abuse_proto_rawDescData)
+	})
+	return file_proto_admin_v1_abuse_proto_rawDescData
+}
+
+var file_proto_admin_v1_abuse_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_admin_v1_abuse_proto_goTypes = []interface{}{
+	(*AbuseReport)(nil),                // 0: admin.v1.AbuseReport
+	(*ListAbuseReportsRequest)(nil),    // 1: admin.v1.ListAbuseReportsRequest
+	(*ListAbuseReportsResponse)(nil),   // 2: admin.v1.ListAbuseReportsResponse
+	(*ForwardAbuseReportRequest)(nil),  // 3: admin.v1.ForwardAbuseReportRequest
+	(*ForwardAbuseReportResponse)(nil), // 4: admin.v1.ForwardAbuseReportResponse
+	(*DiscardAbuseReportRequest)(nil),  // 5: admin.v1.DiscardAbuseReportRequest
+	(*DiscardAbuseReportResponse)(nil), // 6: admin.v1.DiscardAbuseReportResponse
+	(*timestamppb.Timestamp)(nil),      // 7: google.protobuf.Timestamp
+}
+var file_proto_admin_v1_abuse_proto_depIdxs = []int32{
+	7, // 0: admin.v1.AbuseReport.reported_at:type_name -> google.protobuf.Timestamp
+	0, // 1: admin.v1.ListAbuseReportsResponse.reports:type_name -> admin.v1.AbuseReport
+	1, // 2: admin.v1.AbuseReports.ListAbuseReports:input_type -> admin.v1.ListAbuseReportsRequest
+	3, // 3: admin.v1.AbuseReports.ForwardAbuseReport:input_type -> admin.v1.ForwardAbuseReportRequest
+	5, // 4: admin.v1.AbuseReports.DiscardAbuseReport:input_type -> admin.v1.DiscardAbuseReportRequest
+	2, // 5: admin.v1.AbuseReports.ListAbuseReports:output_type -> admin.v1.ListAbuseReportsResponse
+	4, // 6: admin.v1.AbuseReports.ForwardAbuseReport:output_type -> admin.v1.ForwardAbuseReportResponse
+	6, // 7: admin.v1.AbuseReports.DiscardAbuseReport:output_type -> admin.v1.DiscardAbuseReportResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proto_admin_v1_abuse_proto_init() }
+func file_proto_admin_v1_abuse_proto_init() {
+	if File_proto_admin_v1_abuse_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_admin_v1_abuse_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AbuseReport); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_abuse_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAbuseReportsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_abuse_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAbuseReportsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_abuse_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ForwardAbuseReportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_abuse_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ForwardAbuseReportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_abuse_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DiscardAbuseReportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_abuse_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DiscardAbuseReportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_admin_v1_abuse_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_admin_v1_abuse_proto_goTypes,
+		DependencyIndexes: file_proto_admin_v1_abuse_proto_depIdxs,
+		MessageInfos:      file_proto_admin_v1_abuse_proto_msgTypes,
+	}.Build()
+	File_proto_admin_v1_abuse_proto = out.File
+	file_proto_admin_v1_abuse_proto_rawDesc = nil
+	file_proto_admin_v1_abuse_proto_goTypes = nil
+	file_proto_admin_v1_abuse_proto_depIdxs = nil
+}
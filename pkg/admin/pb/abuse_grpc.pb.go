@@ -0,0 +1,201 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AbuseReportsClient is the client API for AbuseReports service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AbuseReportsClient interface {
+	// ListAbuseReports returns all abuse reports awaiting operator review on the queried node.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - FAILED_PRECONDITION(9): When abuse report module is not enabled.
+	ListAbuseReports(ctx context.Context, in *ListAbuseReportsRequest, opts ...grpc.CallOption) (*ListAbuseReportsResponse, error)
+	// ForwardAbuseReport forwards a pending abuse report to the reported domain abuse addresses.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When report does not exist.
+	// - FAILED_PRECONDITION(9): When abuse report module is not enabled.
+	ForwardAbuseReport(ctx context.Context, in *ForwardAbuseReportRequest, opts ...grpc.CallOption) (*ForwardAbuseReportResponse, error)
+	// DiscardAbuseReport discards a pending abuse report.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When report does not exist.
+	// - FAILED_PRECONDITION(9): When abuse report module is not enabled.
+	DiscardAbuseReport(ctx context.Context, in *DiscardAbuseReportRequest, opts ...grpc.CallOption) (*DiscardAbuseReportResponse, error)
+}
+
+type abuseReportsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAbuseReportsClient(cc grpc.ClientConnInterface) AbuseReportsClient {
+	return &abuseReportsClient{cc}
+}
+
+func (c *abuseReportsClient) ListAbuseReports(ctx context.Context, in *ListAbuseReportsRequest, opts ...grpc.CallOption) (*ListAbuseReportsResponse, error) {
+	out := new(ListAbuseReportsResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.AbuseReports/ListAbuseReports", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *abuseReportsClient) ForwardAbuseReport(ctx context.Context, in *ForwardAbuseReportRequest, opts ...grpc.CallOption) (*ForwardAbuseReportResponse, error) {
+	out := new(ForwardAbuseReportResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.AbuseReports/ForwardAbuseReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *abuseReportsClient) DiscardAbuseReport(ctx context.Context, in *DiscardAbuseReportRequest, opts ...grpc.CallOption) (*DiscardAbuseReportResponse, error) {
+	out := new(DiscardAbuseReportResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.AbuseReports/DiscardAbuseReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AbuseReportsServer is the server API for AbuseReports service.
+// All implementations must embed UnimplementedAbuseReportsServer
+// for forward compatibility
+type AbuseReportsServer interface {
+	// ListAbuseReports returns all abuse reports awaiting operator review on the queried node.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - FAILED_PRECONDITION(9): When abuse report module is not enabled.
+	ListAbuseReports(context.Context, *ListAbuseReportsRequest) (*ListAbuseReportsResponse, error)
+	// ForwardAbuseReport forwards a pending abuse report to the reported domain abuse addresses.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When report does not exist.
+	// - FAILED_PRECONDITION(9): When abuse report module is not enabled.
+	ForwardAbuseReport(context.Context, *ForwardAbuseReportRequest) (*ForwardAbuseReportResponse, error)
+	// DiscardAbuseReport discards a pending abuse report.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When report does not exist.
+	// - FAILED_PRECONDITION(9): When abuse report module is not enabled.
+	DiscardAbuseReport(context.Context, *DiscardAbuseReportRequest) (*DiscardAbuseReportResponse, error)
+	mustEmbedUnimplementedAbuseReportsServer()
+}
+
+// UnimplementedAbuseReportsServer must be embedded to have forward compatible implementations.
+type UnimplementedAbuseReportsServer struct {
+}
+
+func (UnimplementedAbuseReportsServer) ListAbuseReports(context.Context, *ListAbuseReportsRequest) (*ListAbuseReportsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAbuseReports not implemented")
+}
+func (UnimplementedAbuseReportsServer) ForwardAbuseReport(context.Context, *ForwardAbuseReportRequest) (*ForwardAbuseReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForwardAbuseReport not implemented")
+}
+func (UnimplementedAbuseReportsServer) DiscardAbuseReport(context.Context, *DiscardAbuseReportRequest) (*DiscardAbuseReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscardAbuseReport not implemented")
+}
+func (UnimplementedAbuseReportsServer) mustEmbedUnimplementedAbuseReportsServer() {}
+
+// UnsafeAbuseReportsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AbuseReportsServer will
+// result in compilation errors.
+type UnsafeAbuseReportsServer interface {
+	mustEmbedUnimplementedAbuseReportsServer()
+}
+
+func RegisterAbuseReportsServer(s grpc.ServiceRegistrar, srv AbuseReportsServer) {
+	s.RegisterService(&AbuseReports_ServiceDesc, srv)
+}
+
+func _AbuseReports_ListAbuseReports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAbuseReportsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AbuseReportsServer).ListAbuseReports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.AbuseReports/ListAbuseReports",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AbuseReportsServer).ListAbuseReports(ctx, req.(*ListAbuseReportsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AbuseReports_ForwardAbuseReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardAbuseReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AbuseReportsServer).ForwardAbuseReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.AbuseReports/ForwardAbuseReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AbuseReportsServer).ForwardAbuseReport(ctx, req.(*ForwardAbuseReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AbuseReports_DiscardAbuseReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscardAbuseReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AbuseReportsServer).DiscardAbuseReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.AbuseReports/DiscardAbuseReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AbuseReportsServer).DiscardAbuseReport(ctx, req.(*DiscardAbuseReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AbuseReports_ServiceDesc is the grpc.ServiceDesc for AbuseReports service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AbuseReports_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.AbuseReports",
+	HandlerType: (*AbuseReportsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListAbuseReports",
+			Handler:    _AbuseReports_ListAbuseReports_Handler,
+		},
+		{
+			MethodName: "ForwardAbuseReport",
+			Handler:    _AbuseReports_ForwardAbuseReport_Handler,
+		},
+		{
+			MethodName: "DiscardAbuseReport",
+			Handler:    _AbuseReports_DiscardAbuseReport_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/abuse.proto",
+}
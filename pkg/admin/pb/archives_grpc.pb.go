@@ -0,0 +1,377 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ArchivesClient is the client API for Archives service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ArchivesClient interface {
+	// RepairArchives checks every stored message archive and reports those whose owner user no longer exists.
+	// Orphaned archives are removed if requested.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INTERNAL(13): When an internal problem happens.
+	RepairArchives(ctx context.Context, in *RepairArchivesRequest, opts ...grpc.CallOption) (*RepairArchivesResponse, error)
+	// DeleteConversation removes all messages a user archived while exchanging them with a given JID.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When username or JID are not valid.
+	// - NOT_FOUND(5): When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	DeleteConversation(ctx context.Context, in *DeleteConversationRequest, opts ...grpc.CallOption) (*DeleteConversationResponse, error)
+	// ExportArchive streams a portable document containing a user full message archive and, optionally,
+	// other user data. Document is sent as a sequence of chunks that must be concatenated in order.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When username is empty or format is not supported.
+	// - NOT_FOUND(5): When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	ExportArchive(ctx context.Context, in *ExportArchiveRequest, opts ...grpc.CallOption) (Archives_ExportArchiveClient, error)
+	// SetArchiveQuota overrides archive queue size and message max age for a given user or host.
+	// User quotas take precedence over host ones, which in turn take precedence over module configuration.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When target is not valid or limits are negative.
+	// - NOT_FOUND(5): When user or host does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	SetArchiveQuota(ctx context.Context, in *SetArchiveQuotaRequest, opts ...grpc.CallOption) (*SetArchiveQuotaResponse, error)
+	// GetArchiveQuotas returns all archive quota overrides.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INTERNAL(13): When an internal problem happens.
+	GetArchiveQuotas(ctx context.Context, in *GetArchiveQuotasRequest, opts ...grpc.CallOption) (*GetArchiveQuotasResponse, error)
+	// DeleteArchiveQuota removes the archive quota override associated to a given user or host.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When target is not valid.
+	// - INTERNAL(13): When an internal problem happens.
+	DeleteArchiveQuota(ctx context.Context, in *DeleteArchiveQuotaRequest, opts ...grpc.CallOption) (*DeleteArchiveQuotaResponse, error)
+}
+
+type archivesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewArchivesClient(cc grpc.ClientConnInterface) ArchivesClient {
+	return &archivesClient{cc}
+}
+
+func (c *archivesClient) RepairArchives(ctx context.Context, in *RepairArchivesRequest, opts ...grpc.CallOption) (*RepairArchivesResponse, error) {
+	out := new(RepairArchivesResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Archives/RepairArchives", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *archivesClient) DeleteConversation(ctx context.Context, in *DeleteConversationRequest, opts ...grpc.CallOption) (*DeleteConversationResponse, error) {
+	out := new(DeleteConversationResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Archives/DeleteConversation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *archivesClient) ExportArchive(ctx context.Context, in *ExportArchiveRequest, opts ...grpc.CallOption) (Archives_ExportArchiveClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Archives_ServiceDesc.Streams[0], "/admin.v1.Archives/ExportArchive", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &archivesExportArchiveClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Archives_ExportArchiveClient interface {
+	Recv() (*ExportArchiveResponse, error)
+	grpc.ClientStream
+}
+
+type archivesExportArchiveClient struct {
+	grpc.ClientStream
+}
+
+func (x *archivesExportArchiveClient) Recv() (*ExportArchiveResponse, error) {
+	m := new(ExportArchiveResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *archivesClient) SetArchiveQuota(ctx context.Context, in *SetArchiveQuotaRequest, opts ...grpc.CallOption) (*SetArchiveQuotaResponse, error) {
+	out := new(SetArchiveQuotaResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Archives/SetArchiveQuota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *archivesClient) GetArchiveQuotas(ctx context.Context, in *GetArchiveQuotasRequest, opts ...grpc.CallOption) (*GetArchiveQuotasResponse, error) {
+	out := new(GetArchiveQuotasResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Archives/GetArchiveQuotas", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *archivesClient) DeleteArchiveQuota(ctx context.Context, in *DeleteArchiveQuotaRequest, opts ...grpc.CallOption) (*DeleteArchiveQuotaResponse, error) {
+	out := new(DeleteArchiveQuotaResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Archives/DeleteArchiveQuota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ArchivesServer is the server API for Archives service.
+// All implementations must embed UnimplementedArchivesServer
+// for forward compatibility
+type ArchivesServer interface {
+	// RepairArchives checks every stored message archive and reports those whose owner user no longer exists.
+	// Orphaned archives are removed if requested.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INTERNAL(13): When an internal problem happens.
+	RepairArchives(context.Context, *RepairArchivesRequest) (*RepairArchivesResponse, error)
+	// DeleteConversation removes all messages a user archived while exchanging them with a given JID.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When username or JID are not valid.
+	// - NOT_FOUND(5): When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	DeleteConversation(context.Context, *DeleteConversationRequest) (*DeleteConversationResponse, error)
+	// ExportArchive streams a portable document containing a user full message archive and, optionally,
+	// other user data. Document is sent as a sequence of chunks that must be concatenated in order.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When username is empty or format is not supported.
+	// - NOT_FOUND(5): When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	ExportArchive(*ExportArchiveRequest, Archives_ExportArchiveServer) error
+	// SetArchiveQuota overrides archive queue size and message max age for a given user or host.
+	// User quotas take precedence over host ones, which in turn take precedence over module configuration.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When target is not valid or limits are negative.
+	// - NOT_FOUND(5): When user or host does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	SetArchiveQuota(context.Context, *SetArchiveQuotaRequest) (*SetArchiveQuotaResponse, error)
+	// GetArchiveQuotas returns all archive quota overrides.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INTERNAL(13): When an internal problem happens.
+	GetArchiveQuotas(context.Context, *GetArchiveQuotasRequest) (*GetArchiveQuotasResponse, error)
+	// DeleteArchiveQuota removes the archive quota override associated to a given user or host.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When target is not valid.
+	// - INTERNAL(13): When an internal problem happens.
+	DeleteArchiveQuota(context.Context, *DeleteArchiveQuotaRequest) (*DeleteArchiveQuotaResponse, error)
+	mustEmbedUnimplementedArchivesServer()
+}
+
+// UnimplementedArchivesServer must be embedded to have forward compatible implementations.
+type UnimplementedArchivesServer struct {
+}
+
+func (UnimplementedArchivesServer) RepairArchives(context.Context, *RepairArchivesRequest) (*RepairArchivesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RepairArchives not implemented")
+}
+func (UnimplementedArchivesServer) DeleteConversation(context.Context, *DeleteConversationRequest) (*DeleteConversationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteConversation not implemented")
+}
+func (UnimplementedArchivesServer) ExportArchive(*ExportArchiveRequest, Archives_ExportArchiveServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportArchive not implemented")
+}
+func (UnimplementedArchivesServer) SetArchiveQuota(context.Context, *SetArchiveQuotaRequest) (*SetArchiveQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetArchiveQuota not implemented")
+}
+func (UnimplementedArchivesServer) GetArchiveQuotas(context.Context, *GetArchiveQuotasRequest) (*GetArchiveQuotasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArchiveQuotas not implemented")
+}
+func (UnimplementedArchivesServer) DeleteArchiveQuota(context.Context, *DeleteArchiveQuotaRequest) (*DeleteArchiveQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteArchiveQuota not implemented")
+}
+func (UnimplementedArchivesServer) mustEmbedUnimplementedArchivesServer() {}
+
+// UnsafeArchivesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ArchivesServer will
+// result in compilation errors.
+type UnsafeArchivesServer interface {
+	mustEmbedUnimplementedArchivesServer()
+}
+
+func RegisterArchivesServer(s grpc.ServiceRegistrar, srv ArchivesServer) {
+	s.RegisterService(&Archives_ServiceDesc, srv)
+}
+
+func _Archives_RepairArchives_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepairArchivesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArchivesServer).RepairArchives(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Archives/RepairArchives",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArchivesServer).RepairArchives(ctx, req.(*RepairArchivesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Archives_DeleteConversation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteConversationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArchivesServer).DeleteConversation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Archives/DeleteConversation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArchivesServer).DeleteConversation(ctx, req.(*DeleteConversationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Archives_ExportArchive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportArchiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ArchivesServer).ExportArchive(m, &archivesExportArchiveServer{stream})
+}
+
+type Archives_ExportArchiveServer interface {
+	Send(*ExportArchiveResponse) error
+	grpc.ServerStream
+}
+
+type archivesExportArchiveServer struct {
+	grpc.ServerStream
+}
+
+func (x *archivesExportArchiveServer) Send(m *ExportArchiveResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Archives_SetArchiveQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetArchiveQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArchivesServer).SetArchiveQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Archives/SetArchiveQuota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArchivesServer).SetArchiveQuota(ctx, req.(*SetArchiveQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Archives_GetArchiveQuotas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArchiveQuotasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArchivesServer).GetArchiveQuotas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Archives/GetArchiveQuotas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArchivesServer).GetArchiveQuotas(ctx, req.(*GetArchiveQuotasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Archives_DeleteArchiveQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteArchiveQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArchivesServer).DeleteArchiveQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Archives/DeleteArchiveQuota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArchivesServer).DeleteArchiveQuota(ctx, req.(*DeleteArchiveQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Archives_ServiceDesc is the grpc.ServiceDesc for Archives service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Archives_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.Archives",
+	HandlerType: (*ArchivesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RepairArchives",
+			Handler:    _Archives_RepairArchives_Handler,
+		},
+		{
+			MethodName: "DeleteConversation",
+			Handler:    _Archives_DeleteConversation_Handler,
+		},
+		{
+			MethodName: "SetArchiveQuota",
+			Handler:    _Archives_SetArchiveQuota_Handler,
+		},
+		{
+			MethodName: "GetArchiveQuotas",
+			Handler:    _Archives_GetArchiveQuotas_Handler,
+		},
+		{
+			MethodName: "DeleteArchiveQuota",
+			Handler:    _Archives_DeleteArchiveQuota_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportArchive",
+			Handler:       _Archives_ExportArchive_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/admin/v1/archives.proto",
+}
@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// HostsClient is the client API for Hosts service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HostsClient interface {
+	// ListHosts returns all local hosts.
+	ListHosts(ctx context.Context, in *ListHostsRequest, opts ...grpc.CallOption) (*ListHostsResponse, error)
+	// AddHost registers a new local host at runtime.
+	//
+	// Added hosts are only kept in memory, so they must also be added to the configuration file to survive a restart.
+	// They share module instances with every other host, and disabled_modules is the only per-host module setting.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When host configuration is not valid.
+	// - ALREADY_EXISTS(6):  When host is already registered.
+	// - FAILED_PRECONDITION(9): When clustering is enabled, as hosts cannot be propagated to other cluster nodes.
+	AddHost(ctx context.Context, in *AddHostRequest, opts ...grpc.CallOption) (*AddHostResponse, error)
+	// RemoveHost unregisters a local host at runtime, disconnecting all its sessions.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When host is not registered.
+	// - FAILED_PRECONDITION(9): When trying to remove default host, or clustering is enabled.
+	// - INTERNAL(13): When an internal problem happens.
+	RemoveHost(ctx context.Context, in *RemoveHostRequest, opts ...grpc.CallOption) (*RemoveHostResponse, error)
+}
+
+type hostsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHostsClient(cc grpc.ClientConnInterface) HostsClient {
+	return &hostsClient{cc}
+}
+
+func (c *hostsClient) ListHosts(ctx context.Context, in *ListHostsRequest, opts ...grpc.CallOption) (*ListHostsResponse, error) {
+	out := new(ListHostsResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/ListHosts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostsClient) AddHost(ctx context.Context, in *AddHostRequest, opts ...grpc.CallOption) (*AddHostResponse, error) {
+	out := new(AddHostResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/AddHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostsClient) RemoveHost(ctx context.Context, in *RemoveHostRequest, opts ...grpc.CallOption) (*RemoveHostResponse, error) {
+	out := new(RemoveHostResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/RemoveHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HostsServer is the server API for Hosts service.
+// All implementations must embed UnimplementedHostsServer
+// for forward compatibility
+type HostsServer interface {
+	// ListHosts returns all local hosts.
+	ListHosts(context.Context, *ListHostsRequest) (*ListHostsResponse, error)
+	// AddHost registers a new local host at runtime.
+	//
+	// Added hosts are only kept in memory, so they must also be added to the configuration file to survive a restart.
+	// They share module instances with every other host, and disabled_modules is the only per-host module setting.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When host configuration is not valid.
+	// - ALREADY_EXISTS(6):  When host is already registered.
+	// - FAILED_PRECONDITION(9): When clustering is enabled, as hosts cannot be propagated to other cluster nodes.
+	AddHost(context.Context, *AddHostRequest) (*AddHostResponse, error)
+	// RemoveHost unregisters a local host at runtime, disconnecting all its sessions.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When host is not registered.
+	// - FAILED_PRECONDITION(9): When trying to remove default host, or clustering is enabled.
+	// - INTERNAL(13): When an internal problem happens.
+	RemoveHost(context.Context, *RemoveHostRequest) (*RemoveHostResponse, error)
+	mustEmbedUnimplementedHostsServer()
+}
+
+// UnimplementedHostsServer must be embedded to have forward compatible implementations.
+type UnimplementedHostsServer struct {
+}
+
+func (UnimplementedHostsServer) ListHosts(context.Context, *ListHostsRequest) (*ListHostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHosts not implemented")
+}
+func (UnimplementedHostsServer) AddHost(context.Context, *AddHostRequest) (*AddHostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddHost not implemented")
+}
+func (UnimplementedHostsServer) RemoveHost(context.Context, *RemoveHostRequest) (*RemoveHostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveHost not implemented")
+}
+func (UnimplementedHostsServer) mustEmbedUnimplementedHostsServer() {}
+
+// UnsafeHostsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HostsServer will
+// result in compilation errors.
+type UnsafeHostsServer interface {
+	mustEmbedUnimplementedHostsServer()
+}
+
+func RegisterHostsServer(s grpc.ServiceRegistrar, srv HostsServer) {
+	s.RegisterService(&Hosts_ServiceDesc, srv)
+}
+
+func _Hosts_ListHosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).ListHosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/ListHosts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).ListHosts(ctx, req.(*ListHostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hosts_AddHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).AddHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/AddHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).AddHost(ctx, req.(*AddHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hosts_RemoveHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).RemoveHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/RemoveHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).RemoveHost(ctx, req.(*RemoveHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Hosts_ServiceDesc is the grpc.ServiceDesc for Hosts service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Hosts_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.Hosts",
+	HandlerType: (*HostsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListHosts",
+			Handler:    _Hosts_ListHosts_Handler,
+		},
+		{
+			MethodName: "AddHost",
+			Handler:    _Hosts_AddHost_Handler,
+		},
+		{
+			MethodName: "RemoveHost",
+			Handler:    _Hosts_RemoveHost_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/hosts.proto",
+}
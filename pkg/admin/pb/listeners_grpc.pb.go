@@ -0,0 +1,201 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ListenersClient is the client API for Listeners service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ListenersClient interface {
+	// ListListeners returns all active C2S, S2S and component listeners.
+	ListListeners(ctx context.Context, in *ListListenersRequest, opts ...grpc.CallOption) (*ListListenersResponse, error)
+	// AddListener opens a new listener at runtime.
+	// All settings but the bind address are inherited from the first configured listener of the same type.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When listener parameters are not valid.
+	// - FAILED_PRECONDITION(9): When no listener of the requested type was configured at startup.
+	// - INTERNAL(13): When listener could not be started.
+	AddListener(ctx context.Context, in *AddListenerRequest, opts ...grpc.CallOption) (*AddListenerResponse, error)
+	// RemoveListener closes a listener at runtime.
+	// Established C2S and S2S sessions are kept, while component connections accepted by the listener are closed.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When listener does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	RemoveListener(ctx context.Context, in *RemoveListenerRequest, opts ...grpc.CallOption) (*RemoveListenerResponse, error)
+}
+
+type listenersClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewListenersClient(cc grpc.ClientConnInterface) ListenersClient {
+	return &listenersClient{cc}
+}
+
+func (c *listenersClient) ListListeners(ctx context.Context, in *ListListenersRequest, opts ...grpc.CallOption) (*ListListenersResponse, error) {
+	out := new(ListListenersResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Listeners/ListListeners", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *listenersClient) AddListener(ctx context.Context, in *AddListenerRequest, opts ...grpc.CallOption) (*AddListenerResponse, error) {
+	out := new(AddListenerResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Listeners/AddListener", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *listenersClient) RemoveListener(ctx context.Context, in *RemoveListenerRequest, opts ...grpc.CallOption) (*RemoveListenerResponse, error) {
+	out := new(RemoveListenerResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Listeners/RemoveListener", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListenersServer is the server API for Listeners service.
+// All implementations must embed UnimplementedListenersServer
+// for forward compatibility
+type ListenersServer interface {
+	// ListListeners returns all active C2S, S2S and component listeners.
+	ListListeners(context.Context, *ListListenersRequest) (*ListListenersResponse, error)
+	// AddListener opens a new listener at runtime.
+	// All settings but the bind address are inherited from the first configured listener of the same type.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When listener parameters are not valid.
+	// - FAILED_PRECONDITION(9): When no listener of the requested type was configured at startup.
+	// - INTERNAL(13): When listener could not be started.
+	AddListener(context.Context, *AddListenerRequest) (*AddListenerResponse, error)
+	// RemoveListener closes a listener at runtime.
+	// Established C2S and S2S sessions are kept, while component connections accepted by the listener are closed.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When listener does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	RemoveListener(context.Context, *RemoveListenerRequest) (*RemoveListenerResponse, error)
+	mustEmbedUnimplementedListenersServer()
+}
+
+// UnimplementedListenersServer must be embedded to have forward compatible implementations.
+type UnimplementedListenersServer struct {
+}
+
+func (UnimplementedListenersServer) ListListeners(context.Context, *ListListenersRequest) (*ListListenersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListListeners not implemented")
+}
+func (UnimplementedListenersServer) AddListener(context.Context, *AddListenerRequest) (*AddListenerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddListener not implemented")
+}
+func (UnimplementedListenersServer) RemoveListener(context.Context, *RemoveListenerRequest) (*RemoveListenerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveListener not implemented")
+}
+func (UnimplementedListenersServer) mustEmbedUnimplementedListenersServer() {}
+
+// UnsafeListenersServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ListenersServer will
+// result in compilation errors.
+type UnsafeListenersServer interface {
+	mustEmbedUnimplementedListenersServer()
+}
+
+func RegisterListenersServer(s grpc.ServiceRegistrar, srv ListenersServer) {
+	s.RegisterService(&Listeners_ServiceDesc, srv)
+}
+
+func _Listeners_ListListeners_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListListenersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ListenersServer).ListListeners(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Listeners/ListListeners",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ListenersServer).ListListeners(ctx, req.(*ListListenersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Listeners_AddListener_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddListenerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ListenersServer).AddListener(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Listeners/AddListener",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ListenersServer).AddListener(ctx, req.(*AddListenerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Listeners_RemoveListener_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveListenerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ListenersServer).RemoveListener(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Listeners/RemoveListener",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ListenersServer).RemoveListener(ctx, req.(*RemoveListenerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Listeners_ServiceDesc is the grpc.ServiceDesc for Listeners service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Listeners_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.Listeners",
+	HandlerType: (*ListenersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListListeners",
+			Handler:    _Listeners_ListListeners_Handler,
+		},
+		{
+			MethodName: "AddListener",
+			Handler:    _Listeners_AddListener_Handler,
+		},
+		{
+			MethodName: "RemoveListener",
+			Handler:    _Listeners_RemoveListener_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/listeners.proto",
+}
@@ -0,0 +1,153 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// OutageStatusClient is the client API for OutageStatus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OutageStatusClient interface {
+	// GetOutageStatus returns the service outage status (XEP-0455) advertised by the queried node.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - FAILED_PRECONDITION(9): When outage status module is not enabled.
+	GetOutageStatus(ctx context.Context, in *GetOutageStatusRequest, opts ...grpc.CallOption) (*GetOutageStatusResponse, error)
+	// SetOutageStatus flips the queried node into (or out of) degraded mode, updating the advertised outage status.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - FAILED_PRECONDITION(9): When outage status module is not enabled.
+	SetOutageStatus(ctx context.Context, in *SetOutageStatusRequest, opts ...grpc.CallOption) (*SetOutageStatusResponse, error)
+}
+
+type outageStatusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOutageStatusClient(cc grpc.ClientConnInterface) OutageStatusClient {
+	return &outageStatusClient{cc}
+}
+
+func (c *outageStatusClient) GetOutageStatus(ctx context.Context, in *GetOutageStatusRequest, opts ...grpc.CallOption) (*GetOutageStatusResponse, error) {
+	out := new(GetOutageStatusResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.OutageStatus/GetOutageStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *outageStatusClient) SetOutageStatus(ctx context.Context, in *SetOutageStatusRequest, opts ...grpc.CallOption) (*SetOutageStatusResponse, error) {
+	out := new(SetOutageStatusResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.OutageStatus/SetOutageStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OutageStatusServer is the server API for OutageStatus service.
+// All implementations must embed UnimplementedOutageStatusServer
+// for forward compatibility
+type OutageStatusServer interface {
+	// GetOutageStatus returns the service outage status (XEP-0455) advertised by the queried node.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - FAILED_PRECONDITION(9): When outage status module is not enabled.
+	GetOutageStatus(context.Context, *GetOutageStatusRequest) (*GetOutageStatusResponse, error)
+	// SetOutageStatus flips the queried node into (or out of) degraded mode, updating the advertised outage status.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - FAILED_PRECONDITION(9): When outage status module is not enabled.
+	SetOutageStatus(context.Context, *SetOutageStatusRequest) (*SetOutageStatusResponse, error)
+	mustEmbedUnimplementedOutageStatusServer()
+}
+
+// UnimplementedOutageStatusServer must be embedded to have forward compatible implementations.
+type UnimplementedOutageStatusServer struct {
+}
+
+func (UnimplementedOutageStatusServer) GetOutageStatus(context.Context, *GetOutageStatusRequest) (*GetOutageStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOutageStatus not implemented")
+}
+func (UnimplementedOutageStatusServer) SetOutageStatus(context.Context, *SetOutageStatusRequest) (*SetOutageStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetOutageStatus not implemented")
+}
+func (UnimplementedOutageStatusServer) mustEmbedUnimplementedOutageStatusServer() {}
+
+// UnsafeOutageStatusServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OutageStatusServer will
+// result in compilation errors.
+type UnsafeOutageStatusServer interface {
+	mustEmbedUnimplementedOutageStatusServer()
+}
+
+func RegisterOutageStatusServer(s grpc.ServiceRegistrar, srv OutageStatusServer) {
+	s.RegisterService(&OutageStatus_ServiceDesc, srv)
+}
+
+func _OutageStatus_GetOutageStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOutageStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OutageStatusServer).GetOutageStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.OutageStatus/GetOutageStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OutageStatusServer).GetOutageStatus(ctx, req.(*GetOutageStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OutageStatus_SetOutageStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetOutageStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OutageStatusServer).SetOutageStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.OutageStatus/SetOutageStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OutageStatusServer).SetOutageStatus(ctx, req.(*SetOutageStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OutageStatus_ServiceDesc is the grpc.ServiceDesc for OutageStatus service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OutageStatus_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.OutageStatus",
+	HandlerType: (*OutageStatusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOutageStatus",
+			Handler:    _OutageStatus_GetOutageStatus_Handler,
+		},
+		{
+			MethodName: "SetOutageStatus",
+			Handler:    _OutageStatus_SetOutageStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/outage.proto",
+}
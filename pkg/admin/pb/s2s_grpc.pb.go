@@ -0,0 +1,109 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// S2SClient is the client API for S2S service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type S2SClient interface {
+	// GetDomainStatus returns connectivity state of remote S2S domains known by the queried node.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When the requested domain is not known.
+	GetDomainStatus(ctx context.Context, in *GetDomainStatusRequest, opts ...grpc.CallOption) (*GetDomainStatusResponse, error)
+}
+
+type s2SClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewS2SClient(cc grpc.ClientConnInterface) S2SClient {
+	return &s2SClient{cc}
+}
+
+func (c *s2SClient) GetDomainStatus(ctx context.Context, in *GetDomainStatusRequest, opts ...grpc.CallOption) (*GetDomainStatusResponse, error) {
+	out := new(GetDomainStatusResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.S2S/GetDomainStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// S2SServer is the server API for S2S service.
+// All implementations must embed UnimplementedS2SServer
+// for forward compatibility
+type S2SServer interface {
+	// GetDomainStatus returns connectivity state of remote S2S domains known by the queried node.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When the requested domain is not known.
+	GetDomainStatus(context.Context, *GetDomainStatusRequest) (*GetDomainStatusResponse, error)
+	mustEmbedUnimplementedS2SServer()
+}
+
+// UnimplementedS2SServer must be embedded to have forward compatible implementations.
+type UnimplementedS2SServer struct {
+}
+
+func (UnimplementedS2SServer) GetDomainStatus(context.Context, *GetDomainStatusRequest) (*GetDomainStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDomainStatus not implemented")
+}
+func (UnimplementedS2SServer) mustEmbedUnimplementedS2SServer() {}
+
+// UnsafeS2SServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to S2SServer will
+// result in compilation errors.
+type UnsafeS2SServer interface {
+	mustEmbedUnimplementedS2SServer()
+}
+
+func RegisterS2SServer(s grpc.ServiceRegistrar, srv S2SServer) {
+	s.RegisterService(&S2S_ServiceDesc, srv)
+}
+
+func _S2S_GetDomainStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDomainStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(S2SServer).GetDomainStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.S2S/GetDomainStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(S2SServer).GetDomainStatus(ctx, req.(*GetDomainStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// S2S_ServiceDesc is the grpc.ServiceDesc for S2S service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var S2S_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.S2S",
+	HandlerType: (*S2SServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDomainStatus",
+			Handler:    _S2S_GetDomainStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/s2s.proto",
+}
@@ -28,14 +28,59 @@ type UsersClient interface {
 	//
 	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
 	// - NOT_FOUND(5):  When user does not exist.
+	// - FAILED_PRECONDITION(9): When user is pending deletion.
 	// - INTERNAL(13): When an internal problem happens.
 	ChangeUserPassword(ctx context.Context, in *ChangeUserPasswordRequest, opts ...grpc.CallOption) (*ChangeUserPasswordResponse, error)
 	// DeleteUser removes a previously registered user.
+	// If a deletion grace period is configured the account is disabled, and permanently removed once the period expires.
 	//
 	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
 	// - NOT_FOUND(5):  When user does not exist.
 	// - INTERNAL(13): When an internal problem happens.
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
+	// UndeleteUser restores a deleted user whose grace period has not expired yet.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - FAILED_PRECONDITION(9): When user is not pending deletion.
+	// - INTERNAL(13): When an internal problem happens.
+	UndeleteUser(ctx context.Context, in *UndeleteUserRequest, opts ...grpc.CallOption) (*UndeleteUserResponse, error)
+	// SuspendUser freezes a user account, disconnecting all its sessions and rejecting further logins
+	// and incoming stanzas. User data is kept untouched.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	SuspendUser(ctx context.Context, in *SuspendUserRequest, opts ...grpc.CallOption) (*SuspendUserResponse, error)
+	// UnsuspendUser reactivates a previously suspended user account.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - FAILED_PRECONDITION(9): When user is not suspended.
+	// - INTERNAL(13): When an internal problem happens.
+	UnsuspendUser(ctx context.Context, in *UnsuspendUserRequest, opts ...grpc.CallOption) (*UnsuspendUserResponse, error)
+	// KickUser disconnects user sessions using a custom stream error, optionally hinting clients
+	// to reconnect to a different host after a given amount of time.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When stream error condition is not defined in RFC 6120.
+	// - NOT_FOUND(5):  When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	KickUser(ctx context.Context, in *KickUserRequest, opts ...grpc.CallOption) (*KickUserResponse, error)
+	// ExportUser returns a machine-readable bundle containing all data stored about a user.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	ExportUser(ctx context.Context, in *ExportUserRequest, opts ...grpc.CallOption) (*ExportUserResponse, error)
+	// ImportUser creates a new user account out of a previously exported data bundle.
+	// In case the user already exists, the request conflict policy determines how the bundle is applied.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When data bundle is malformed or no password was provided.
+	// - ALREADY_EXISTS(6): When user already exists and conflict policy is IMPORT_CONFLICT_POLICY_FAIL.
+	// - INTERNAL(13): When an internal problem happens.
+	ImportUser(ctx context.Context, in *ImportUserRequest, opts ...grpc.CallOption) (*ImportUserResponse, error)
 }
 
 type usersClient struct {
@@ -73,6 +118,60 @@ func (c *usersClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opt
 	return out, nil
 }
 
+func (c *usersClient) UndeleteUser(ctx context.Context, in *UndeleteUserRequest, opts ...grpc.CallOption) (*UndeleteUserResponse, error) {
+	out := new(UndeleteUserResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/UndeleteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) SuspendUser(ctx context.Context, in *SuspendUserRequest, opts ...grpc.CallOption) (*SuspendUserResponse, error) {
+	out := new(SuspendUserResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/SuspendUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) UnsuspendUser(ctx context.Context, in *UnsuspendUserRequest, opts ...grpc.CallOption) (*UnsuspendUserResponse, error) {
+	out := new(UnsuspendUserResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/UnsuspendUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) KickUser(ctx context.Context, in *KickUserRequest, opts ...grpc.CallOption) (*KickUserResponse, error) {
+	out := new(KickUserResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/KickUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) ExportUser(ctx context.Context, in *ExportUserRequest, opts ...grpc.CallOption) (*ExportUserResponse, error) {
+	out := new(ExportUserResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/ExportUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) ImportUser(ctx context.Context, in *ImportUserRequest, opts ...grpc.CallOption) (*ImportUserResponse, error) {
+	out := new(ImportUserResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/ImportUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UsersServer is the server API for Users service.
 // All implementations must embed UnimplementedUsersServer
 // for forward compatibility
@@ -87,14 +186,59 @@ type UsersServer interface {
 	//
 	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
 	// - NOT_FOUND(5):  When user does not exist.
+	// - FAILED_PRECONDITION(9): When user is pending deletion.
 	// - INTERNAL(13): When an internal problem happens.
 	ChangeUserPassword(context.Context, *ChangeUserPasswordRequest) (*ChangeUserPasswordResponse, error)
 	// DeleteUser removes a previously registered user.
+	// If a deletion grace period is configured the account is disabled, and permanently removed once the period expires.
 	//
 	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
 	// - NOT_FOUND(5):  When user does not exist.
 	// - INTERNAL(13): When an internal problem happens.
 	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
+	// UndeleteUser restores a deleted user whose grace period has not expired yet.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - FAILED_PRECONDITION(9): When user is not pending deletion.
+	// - INTERNAL(13): When an internal problem happens.
+	UndeleteUser(context.Context, *UndeleteUserRequest) (*UndeleteUserResponse, error)
+	// SuspendUser freezes a user account, disconnecting all its sessions and rejecting further logins
+	// and incoming stanzas. User data is kept untouched.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	SuspendUser(context.Context, *SuspendUserRequest) (*SuspendUserResponse, error)
+	// UnsuspendUser reactivates a previously suspended user account.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - FAILED_PRECONDITION(9): When user is not suspended.
+	// - INTERNAL(13): When an internal problem happens.
+	UnsuspendUser(context.Context, *UnsuspendUserRequest) (*UnsuspendUserResponse, error)
+	// KickUser disconnects user sessions using a custom stream error, optionally hinting clients
+	// to reconnect to a different host after a given amount of time.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When stream error condition is not defined in RFC 6120.
+	// - NOT_FOUND(5):  When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	KickUser(context.Context, *KickUserRequest) (*KickUserResponse, error)
+	// ExportUser returns a machine-readable bundle containing all data stored about a user.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5):  When user does not exist.
+	// - INTERNAL(13): When an internal problem happens.
+	ExportUser(context.Context, *ExportUserRequest) (*ExportUserResponse, error)
+	// ImportUser creates a new user account out of a previously exported data bundle.
+	// In case the user already exists, the request conflict policy determines how the bundle is applied.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When data bundle is malformed or no password was provided.
+	// - ALREADY_EXISTS(6): When user already exists and conflict policy is IMPORT_CONFLICT_POLICY_FAIL.
+	// - INTERNAL(13): When an internal problem happens.
+	ImportUser(context.Context, *ImportUserRequest) (*ImportUserResponse, error)
 	mustEmbedUnimplementedUsersServer()
 }
 
@@ -111,6 +255,24 @@ func (UnimplementedUsersServer) ChangeUserPassword(context.Context, *ChangeUserP
 func (UnimplementedUsersServer) DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedUsersServer) UndeleteUser(context.Context, *UndeleteUserRequest) (*UndeleteUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeleteUser not implemented")
+}
+func (UnimplementedUsersServer) SuspendUser(context.Context, *SuspendUserRequest) (*SuspendUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendUser not implemented")
+}
+func (UnimplementedUsersServer) UnsuspendUser(context.Context, *UnsuspendUserRequest) (*UnsuspendUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnsuspendUser not implemented")
+}
+func (UnimplementedUsersServer) KickUser(context.Context, *KickUserRequest) (*KickUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickUser not implemented")
+}
+func (UnimplementedUsersServer) ExportUser(context.Context, *ExportUserRequest) (*ExportUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUser not implemented")
+}
+func (UnimplementedUsersServer) ImportUser(context.Context, *ImportUserRequest) (*ImportUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportUser not implemented")
+}
 func (UnimplementedUsersServer) mustEmbedUnimplementedUsersServer() {}
 
 // UnsafeUsersServer may be embedded to opt out of forward compatibility for this service.
@@ -178,6 +340,114 @@ func _Users_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Users_UndeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).UndeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/UndeleteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).UndeleteUser(ctx, req.(*UndeleteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_SuspendUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).SuspendUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/SuspendUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).SuspendUser(ctx, req.(*SuspendUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_UnsuspendUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnsuspendUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).UnsuspendUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/UnsuspendUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).UnsuspendUser(ctx, req.(*UnsuspendUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_KickUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).KickUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/KickUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).KickUser(ctx, req.(*KickUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_ExportUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).ExportUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/ExportUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).ExportUser(ctx, req.(*ExportUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_ImportUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).ImportUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/ImportUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).ImportUser(ctx, req.(*ImportUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Users_ServiceDesc is the grpc.ServiceDesc for Users service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -197,6 +467,30 @@ var Users_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _Users_DeleteUser_Handler,
 		},
+		{
+			MethodName: "UndeleteUser",
+			Handler:    _Users_UndeleteUser_Handler,
+		},
+		{
+			MethodName: "SuspendUser",
+			Handler:    _Users_SuspendUser_Handler,
+		},
+		{
+			MethodName: "UnsuspendUser",
+			Handler:    _Users_UnsuspendUser_Handler,
+		},
+		{
+			MethodName: "KickUser",
+			Handler:    _Users_KickUser_Handler,
+		},
+		{
+			MethodName: "ExportUser",
+			Handler:    _Users_ExportUser_Handler,
+		},
+		{
+			MethodName: "ImportUser",
+			Handler:    _Users_ImportUser_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/admin/v1/users.proto",
@@ -0,0 +1,96 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"errors"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/abusereport"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type abuseReportsService struct {
+	adminpb.UnimplementedAbuseReportsServer
+	mods *module.Modules
+}
+
+func newAbuseReportsService(mods *module.Modules) adminpb.AbuseReportsServer {
+	return &abuseReportsService{
+		mods: mods,
+	}
+}
+
+func (s *abuseReportsService) ListAbuseReports(_ context.Context, _ *adminpb.ListAbuseReportsRequest) (*adminpb.ListAbuseReportsResponse, error) {
+	m, err := s.abuseReport()
+	if err != nil {
+		return nil, err
+	}
+	var resp adminpb.ListAbuseReportsResponse
+	for _, r := range m.PendingReports() {
+		resp.Reports = append(resp.Reports, &adminpb.AbuseReport{
+			Id:         r.ID,
+			Jid:        r.JID.String(),
+			Reason:     r.Reason,
+			Text:       r.Text,
+			ReportedAt: timestamppb.New(r.ReportedAt),
+		})
+	}
+	return &resp, nil
+}
+
+func (s *abuseReportsService) ForwardAbuseReport(ctx context.Context, req *adminpb.ForwardAbuseReportRequest) (*adminpb.ForwardAbuseReportResponse, error) {
+	m, err := s.abuseReport()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ForwardReport(ctx, req.GetId()); err != nil {
+		return nil, abuseReportError(err)
+	}
+	return &adminpb.ForwardAbuseReportResponse{}, nil
+}
+
+func (s *abuseReportsService) DiscardAbuseReport(_ context.Context, req *adminpb.DiscardAbuseReportRequest) (*adminpb.DiscardAbuseReportResponse, error) {
+	m, err := s.abuseReport()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.DiscardReport(req.GetId()); err != nil {
+		return nil, abuseReportError(err)
+	}
+	return &adminpb.DiscardAbuseReportResponse{}, nil
+}
+
+func (s *abuseReportsService) abuseReport() (*abusereport.AbuseReport, error) {
+	if s.mods != nil {
+		for _, mod := range s.mods.AllModules() {
+			if m, ok := mod.(*abusereport.AbuseReport); ok {
+				return m, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "%s module is not enabled", abusereport.ModuleName)
+}
+
+func abuseReportError(err error) error {
+	if errors.Is(err, abusereport.ErrReportNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,355 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	archiveExportPageSize  = 500
+	archiveExportChunkSize = 64 * 1024
+)
+
+// archiveExportEncoder writes a user archive export document piece by piece.
+type archiveExportEncoder interface {
+	begin(username string) error
+	roster(items []*rostermodel.Item) error
+	vCard(vCard stravaganza.Element) error
+	offline(messages []*stravaganza.Message) error
+	beginArchive() error
+	archiveMessage(msg *archivemodel.Message) error
+	end() error
+}
+
+func (s *archivesService) ExportArchive(req *adminpb.ExportArchiveRequest, stream adminpb.Archives_ExportArchiveServer) error {
+	ctx := stream.Context()
+
+	username := req.GetUsername()
+	if len(username) == 0 {
+		return status.Error(codes.InvalidArgument, "username must not be empty")
+	}
+	w := &exportChunkWriter{stream: stream}
+
+	var enc archiveExportEncoder
+	switch req.GetFormat() {
+	case adminpb.ExportFormat_EXPORT_FORMAT_JSON:
+		enc = &jsonArchiveExportEncoder{w: w}
+	case adminpb.ExportFormat_EXPORT_FORMAT_XML:
+		enc = &xmlArchiveExportEncoder{w: w, host: s.hosts.DefaultHostName()}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported export format: %v", req.GetFormat())
+	}
+	exists, err := s.rep.UserExists(ctx, username)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if !exists {
+		return status.Errorf(codes.NotFound, "user %s not found", username)
+	}
+	n, err := s.exportArchive(ctx, req, enc)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := w.flush(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "archive exported", "username", username, "format", req.GetFormat(), "messages", n)
+	return nil
+}
+
+func (s *archivesService) exportArchive(ctx context.Context, req *adminpb.ExportArchiveRequest, enc archiveExportEncoder) (int, error) {
+	username := req.GetUsername()
+
+	if err := enc.begin(username); err != nil {
+		return 0, err
+	}
+	if req.GetIncludeRoster() {
+		items, err := s.rep.FetchRosterItems(ctx, username)
+		if err != nil {
+			return 0, err
+		}
+		if err := enc.roster(items); err != nil {
+			return 0, err
+		}
+	}
+	if req.GetIncludeVcard() {
+		vCard, err := s.rep.FetchVCard(ctx, username)
+		if err != nil {
+			return 0, err
+		}
+		if vCard != nil {
+			if err := enc.vCard(vCard); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if req.GetIncludeOffline() {
+		messages, err := s.rep.FetchOfflineMessages(ctx, username)
+		if err != nil {
+			return 0, err
+		}
+		if err := enc.offline(messages); err != nil {
+			return 0, err
+		}
+	}
+	// archive is paged to avoid loading it in memory all at once
+	if err := enc.beginArchive(); err != nil {
+		return 0, err
+	}
+	var count int
+	for {
+		messages, err := s.rep.FetchArchiveMessages(ctx, &archivemodel.Filters{}, &archivemodel.Page{
+			Offset: int32(count),
+			Limit:  archiveExportPageSize,
+		}, username)
+		if err != nil {
+			return 0, err
+		}
+		for _, msg := range messages {
+			if err := enc.archiveMessage(msg); err != nil {
+				return 0, err
+			}
+		}
+		count += len(messages)
+		if len(messages) < archiveExportPageSize {
+			break
+		}
+	}
+	return count, enc.end()
+}
+
+type exportChunkWriter struct {
+	stream adminpb.Archives_ExportArchiveServer
+	buf    bytes.Buffer
+}
+
+func (w *exportChunkWriter) Write(p []byte) (int, error) {
+	n, _ := w.buf.Write(p)
+	if w.buf.Len() >= archiveExportChunkSize {
+		return n, w.flush()
+	}
+	return n, nil
+}
+
+func (w *exportChunkWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	defer w.buf.Reset()
+	return w.stream.Send(&adminpb.ExportArchiveResponse{Data: w.buf.Bytes()})
+}
+
+// jsonArchiveExportEncoder produces a jackal user bundle, as the one returned by ExportUser rpc.
+type jsonArchiveExportEncoder struct {
+	w            io.Writer
+	wroteMessage bool
+}
+
+func (e *jsonArchiveExportEncoder) begin(username string) error {
+	if err := e.writeString(`{"username":`); err != nil {
+		return err
+	}
+	if err := e.writeValue(username); err != nil {
+		return err
+	}
+	if err := e.writeString(`,"exported_at":`); err != nil {
+		return err
+	}
+	return e.writeValue(time.Now().UTC())
+}
+
+func (e *jsonArchiveExportEncoder) roster(items []*rostermodel.Item) error {
+	exp := []rosterItemExport{}
+	for _, itm := range items {
+		exp = append(exp, rosterItemExport{
+			JID:          itm.Jid,
+			Name:         itm.Name,
+			Subscription: itm.Subscription,
+			Ask:          itm.Ask,
+			Groups:       itm.Groups,
+		})
+	}
+	if err := e.writeString(`,"roster":`); err != nil {
+		return err
+	}
+	return e.writeValue(exp)
+}
+
+func (e *jsonArchiveExportEncoder) vCard(vCard stravaganza.Element) error {
+	if err := e.writeString(`,"vcard":`); err != nil {
+		return err
+	}
+	return e.writeValue(vCard.String())
+}
+
+func (e *jsonArchiveExportEncoder) offline(messages []*stravaganza.Message) error {
+	exp := []string{}
+	for _, msg := range messages {
+		exp = append(exp, msg.String())
+	}
+	if err := e.writeString(`,"offline_messages":`); err != nil {
+		return err
+	}
+	return e.writeValue(exp)
+}
+
+func (e *jsonArchiveExportEncoder) beginArchive() error {
+	e.wroteMessage = false
+	return e.writeString(`,"archive":[`)
+}
+
+func (e *jsonArchiveExportEncoder) archiveMessage(msg *archivemodel.Message) error {
+	if e.wroteMessage {
+		if err := e.writeString(","); err != nil {
+			return err
+		}
+	}
+	e.wroteMessage = true
+
+	var msgStr string
+	if msg.Message != nil {
+		msgStr = stravaganza.NewBuilderFromProto(msg.Message).Build().String()
+	}
+	return e.writeValue(archiveMessageExport{
+		ID:      msg.Id,
+		From:    msg.FromJid,
+		To:      msg.ToJid,
+		Stamp:   msg.Stamp.AsTime(),
+		Message: msgStr,
+	})
+}
+
+func (e *jsonArchiveExportEncoder) end() error {
+	return e.writeString("]}")
+}
+
+func (e *jsonArchiveExportEncoder) writeValue(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonArchiveExportEncoder) writeString(s string) error {
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+// xmlArchiveExportEncoder produces an XEP-0227 (Portable Import/Export Format) document.
+type xmlArchiveExportEncoder struct {
+	w    io.Writer
+	host string
+}
+
+func (e *xmlArchiveExportEncoder) begin(username string) error {
+	if err := e.writeString(xml.Header + `<server-data xmlns="urn:xmpp:pie:0"><host jid="`); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(e.w, []byte(e.host)); err != nil {
+		return err
+	}
+	if err := e.writeString(`"><user name="`); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(e.w, []byte(username)); err != nil {
+		return err
+	}
+	return e.writeString(`">`)
+}
+
+func (e *xmlArchiveExportEncoder) roster(items []*rostermodel.Item) error {
+	qb := stravaganza.NewBuilder("query").
+		WithAttribute(stravaganza.Namespace, "jabber:iq:roster")
+	for _, itm := range items {
+		ib := stravaganza.NewBuilder("item").
+			WithAttribute("jid", itm.Jid).
+			WithAttribute("subscription", itm.Subscription)
+		if len(itm.Name) > 0 {
+			ib.WithAttribute("name", itm.Name)
+		}
+		if itm.Ask {
+			ib.WithAttribute("ask", "subscribe")
+		}
+		for _, group := range itm.Groups {
+			ib.WithChild(
+				stravaganza.NewBuilder("group").
+					WithText(group).
+					Build(),
+			)
+		}
+		qb.WithChild(ib.Build())
+	}
+	return e.writeString(qb.Build().String())
+}
+
+func (e *xmlArchiveExportEncoder) vCard(vCard stravaganza.Element) error {
+	return e.writeString(vCard.String())
+}
+
+func (e *xmlArchiveExportEncoder) offline(messages []*stravaganza.Message) error {
+	ob := stravaganza.NewBuilder("offline-messages")
+	for _, msg := range messages {
+		ob.WithChild(msg)
+	}
+	return e.writeString(ob.Build().String())
+}
+
+func (e *xmlArchiveExportEncoder) beginArchive() error {
+	return e.writeString("<archive>")
+}
+
+func (e *xmlArchiveExportEncoder) archiveMessage(msg *archivemodel.Message) error {
+	if msg.Message == nil {
+		return nil
+	}
+	msgStanza, err := stravaganza.NewBuilderFromProto(msg.Message).BuildStanza()
+	if err != nil {
+		return err
+	}
+	stamp := msg.Stamp.AsTime()
+
+	res := stravaganza.NewBuilder("result").
+		WithAttribute(stravaganza.Namespace, "urn:xmpp:mam:2").
+		WithAttribute(stravaganza.ID, msg.Id).
+		WithChild(xmpputil.MakeForwardedStanza(msgStanza, &stamp)).
+		Build()
+	return e.writeString(res.String())
+}
+
+func (e *xmlArchiveExportEncoder) end() error {
+	return e.writeString("</archive></user></host></server-data>")
+}
+
+func (e *xmlArchiveExportEncoder) writeString(s string) error {
+	_, err := io.WriteString(e.w, s)
+	return err
+}
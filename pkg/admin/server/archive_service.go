@@ -0,0 +1,194 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/host"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type archivesService struct {
+	adminpb.UnimplementedArchivesServer
+	rep    repository.Repository
+	hosts  *host.Hosts
+	logger kitlog.Logger
+}
+
+func newArchivesService(rep repository.Repository, hosts *host.Hosts, logger kitlog.Logger) adminpb.ArchivesServer {
+	return &archivesService{
+		rep:    rep,
+		hosts:  hosts,
+		logger: logger,
+	}
+}
+
+func (s *archivesService) RepairArchives(ctx context.Context, req *adminpb.RepairArchivesRequest) (*adminpb.RepairArchivesResponse, error) {
+	archiveIDs, err := s.rep.FetchArchiveIDs(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var orphans []string
+	for _, archiveID := range archiveIDs {
+		// an archive is orphaned when its owner user no longer exists
+		exists, err := s.rep.UserExists(ctx, archiveID)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if exists {
+			continue
+		}
+		orphans = append(orphans, archiveID)
+	}
+	if req.GetDeleteOrphans() {
+		for _, archiveID := range orphans {
+			if err := s.deleteArchive(ctx, archiveID); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+	}
+	level.Info(s.logger).Log("msg", "archives repaired",
+		"checked", len(archiveIDs), "orphaned", len(orphans), "deleted", req.GetDeleteOrphans(),
+	)
+	return &adminpb.RepairArchivesResponse{
+		CheckedCount:     int32(len(archiveIDs)),
+		OrphanedArchives: orphans,
+		Deleted:          req.GetDeleteOrphans() && len(orphans) > 0,
+	}, nil
+}
+
+func (s *archivesService) DeleteConversation(ctx context.Context, req *adminpb.DeleteConversationRequest) (*adminpb.DeleteConversationResponse, error) {
+	username := req.GetUsername()
+	if len(username) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "username must not be empty")
+	}
+	peerJID, err := jid.NewWithString(req.GetJid(), false)
+	if err != nil || len(req.GetJid()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid jid: %s", req.GetJid())
+	}
+	exists, err := s.rep.UserExists(ctx, username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "user %s not found", username)
+	}
+	n, err := s.rep.DeleteArchiveMessagesWith(ctx, username, peerJID.String())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "archived conversation deleted", "username", username, "jid", peerJID.String(), "deleted", n)
+
+	return &adminpb.DeleteConversationResponse{
+		DeletedCount: int32(n),
+	}, nil
+}
+
+func (s *archivesService) SetArchiveQuota(ctx context.Context, req *adminpb.SetArchiveQuotaRequest) (*adminpb.SetArchiveQuotaResponse, error) {
+	q := req.GetQuota()
+	if err := validateQuotaTarget(q.GetUsername(), q.GetHost()); err != nil {
+		return nil, err
+	}
+	if q.GetQueueSize() < 0 || q.GetMaxAgeSeconds() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "queue size and max age must not be negative")
+	}
+	if q.GetQueueSize() == 0 && q.GetMaxAgeSeconds() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "either queue size or max age must be set")
+	}
+	switch {
+	case len(q.GetUsername()) > 0:
+		exists, err := s.rep.UserExists(ctx, q.GetUsername())
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if !exists {
+			return nil, status.Errorf(codes.NotFound, "user %s not found", q.GetUsername())
+		}
+
+	default:
+		if !s.hosts.IsLocalHost(q.GetHost()) {
+			return nil, status.Errorf(codes.NotFound, "host %s not found", q.GetHost())
+		}
+	}
+	err := s.rep.UpsertArchiveQuota(ctx, &archivemodel.Quota{
+		ArchiveId:     q.GetUsername(),
+		Host:          q.GetHost(),
+		QueueSize:     q.GetQueueSize(),
+		MaxAgeSeconds: q.GetMaxAgeSeconds(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "archive quota set",
+		"username", q.GetUsername(), "host", q.GetHost(), "queue_size", q.GetQueueSize(), "max_age_seconds", q.GetMaxAgeSeconds(),
+	)
+	return &adminpb.SetArchiveQuotaResponse{}, nil
+}
+
+func (s *archivesService) GetArchiveQuotas(ctx context.Context, _ *adminpb.GetArchiveQuotasRequest) (*adminpb.GetArchiveQuotasResponse, error) {
+	quotas, err := s.rep.FetchArchiveQuotas(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var resp adminpb.GetArchiveQuotasResponse
+	for _, q := range quotas {
+		resp.Quotas = append(resp.Quotas, &adminpb.ArchiveQuota{
+			Username:      q.ArchiveId,
+			Host:          q.Host,
+			QueueSize:     q.QueueSize,
+			MaxAgeSeconds: q.MaxAgeSeconds,
+		})
+	}
+	return &resp, nil
+}
+
+func (s *archivesService) DeleteArchiveQuota(ctx context.Context, req *adminpb.DeleteArchiveQuotaRequest) (*adminpb.DeleteArchiveQuotaResponse, error) {
+	if err := validateQuotaTarget(req.GetUsername(), req.GetHost()); err != nil {
+		return nil, err
+	}
+	if err := s.rep.DeleteArchiveQuota(ctx, req.GetUsername(), req.GetHost()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "archive quota deleted", "username", req.GetUsername(), "host", req.GetHost())
+
+	return &adminpb.DeleteArchiveQuotaResponse{}, nil
+}
+
+func (s *archivesService) deleteArchive(ctx context.Context, archiveID string) error {
+	return s.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
+		if err := tx.DeleteArchive(ctx, archiveID); err != nil {
+			return err
+		}
+		if err := tx.DeleteArchiveQuota(ctx, archiveID, ""); err != nil {
+			return err
+		}
+		return tx.DeleteArchiveReactions(ctx, archiveID)
+	})
+}
+
+func validateQuotaTarget(username, host string) error {
+	if (len(username) == 0) == (len(host) == 0) {
+		return status.Error(codes.InvalidArgument, "exactly one of username or host must be provided")
+	}
+	return nil
+}
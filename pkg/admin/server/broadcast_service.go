@@ -0,0 +1,100 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"path"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
+	"github.com/ortuman/jackal/pkg/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type broadcastService struct {
+	adminpb.UnimplementedBroadcastServer
+	router router.Router
+	resMng resourcemanager.Manager
+	logger kitlog.Logger
+}
+
+func newBroadcastService(router router.Router, resMng resourcemanager.Manager, logger kitlog.Logger) adminpb.BroadcastServer {
+	return &broadcastService{
+		router: router,
+		resMng: resMng,
+		logger: logger,
+	}
+}
+
+func (s *broadcastService) BroadcastMessage(ctx context.Context, req *adminpb.BroadcastMessageRequest) (*adminpb.BroadcastMessageResponse, error) {
+	host, pattern := req.GetHost(), req.GetJidPattern()
+	if len(host) == 0 && len(pattern) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "host or jid pattern must be provided")
+	}
+	if len(pattern) > 0 {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "malformed jid pattern: %s", pattern)
+		}
+	}
+	if len(req.GetSubject()) == 0 && len(req.GetBody()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty message")
+	}
+	rss, err := s.resMng.GetAllResources(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var resp adminpb.BroadcastMessageResponse
+	for _, res := range rss {
+		toJID := res.JID()
+		if len(host) > 0 && toJID.Domain() != host {
+			continue
+		}
+		if len(pattern) > 0 {
+			if ok, _ := path.Match(pattern, toJID.String()); !ok {
+				continue
+			}
+		}
+		b := stravaganza.NewMessageBuilder().
+			WithAttribute(stravaganza.ID, uuid.New().String()).
+			WithAttribute(stravaganza.Type, stravaganza.HeadlineType).
+			WithAttribute(stravaganza.From, toJID.Domain()).
+			WithAttribute(stravaganza.To, toJID.String())
+		if len(req.GetSubject()) > 0 {
+			b.WithChild(stravaganza.NewBuilder("subject").WithText(req.GetSubject()).Build())
+		}
+		if len(req.GetBody()) > 0 {
+			b.WithChild(stravaganza.NewBuilder("body").WithText(req.GetBody()).Build())
+		}
+		msg, _ := b.BuildMessage()
+
+		// route directly to the session, so that message never ends up in offline storage
+		if _, err := s.router.C2S().Route(ctx, msg, router.RoutingOptions(0)); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to deliver broadcast message", "jid", toJID.String(), "err", err)
+			resp.FailedCount++
+			continue
+		}
+		resp.DeliveredCount++
+	}
+	level.Info(s.logger).Log("msg", "broadcast message delivered",
+		"host", host, "jid_pattern", pattern, "delivered", resp.DeliveredCount, "failed", resp.FailedCount,
+	)
+	return &resp, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userExport struct {
+	Username      string                     `json:"username"`
+	ExportedAt    time.Time                  `json:"exported_at"`
+	Last          *lastExport                `json:"last,omitempty"`
+	Roster        []rosterItemExport         `json:"roster"`
+	Notifications []rosterNotificationExport `json:"roster_notifications"`
+	BlockList     []string                   `json:"block_list"`
+	VCard         string                     `json:"vcard,omitempty"`
+	Private       []string                   `json:"private_storage"`
+	Offline       []string                   `json:"offline_messages"`
+	Archive       []archiveMessageExport     `json:"archive"`
+}
+
+type lastExport struct {
+	Seconds int64  `json:"seconds"`
+	Status  string `json:"status,omitempty"`
+}
+
+type rosterItemExport struct {
+	JID          string   `json:"jid"`
+	Name         string   `json:"name,omitempty"`
+	Subscription string   `json:"subscription"`
+	Ask          bool     `json:"ask"`
+	Groups       []string `json:"groups,omitempty"`
+}
+
+type rosterNotificationExport struct {
+	JID      string `json:"jid"`
+	Presence string `json:"presence,omitempty"`
+}
+
+type archiveMessageExport struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Stamp   time.Time `json:"stamp"`
+	Message string    `json:"message"`
+}
+
+func (s *usersService) ExportUser(ctx context.Context, req *adminpb.ExportUserRequest) (*adminpb.ExportUserResponse, error) {
+	username := req.GetUsername()
+	if err := s.ensureUserAlreadyExists(ctx, username); err != nil {
+		return nil, err
+	}
+	exp, err := s.exportUser(ctx, username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	b, err := json.Marshal(exp)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "user data exported", "username", username)
+
+	return &adminpb.ExportUserResponse{Data: b}, nil
+}
+
+func (s *usersService) exportUser(ctx context.Context, username string) (*userExport, error) {
+	exp := &userExport{
+		Username:      username,
+		ExportedAt:    time.Now().UTC(),
+		Roster:        []rosterItemExport{},
+		Notifications: []rosterNotificationExport{},
+		BlockList:     []string{},
+		Private:       []string{},
+		Offline:       []string{},
+		Archive:       []archiveMessageExport{},
+	}
+	// last activity
+	lst, err := s.rep.FetchLast(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if lst != nil {
+		exp.Last = &lastExport{Seconds: lst.Seconds, Status: lst.Status}
+	}
+	// roster
+	items, err := s.rep.FetchRosterItems(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, itm := range items {
+		exp.Roster = append(exp.Roster, rosterItemExport{
+			JID:          itm.Jid,
+			Name:         itm.Name,
+			Subscription: itm.Subscription,
+			Ask:          itm.Ask,
+			Groups:       itm.Groups,
+		})
+	}
+	notifications, err := s.rep.FetchRosterNotifications(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notifications {
+		var presence string
+		if n.Presence != nil {
+			presence = stravaganza.NewBuilderFromProto(n.Presence).Build().String()
+		}
+		exp.Notifications = append(exp.Notifications, rosterNotificationExport{
+			JID:      n.Jid,
+			Presence: presence,
+		})
+	}
+	// block list
+	blItems, err := s.rep.FetchBlockListItems(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, itm := range blItems {
+		exp.BlockList = append(exp.BlockList, itm.Jid)
+	}
+	// vCard
+	vCard, err := s.rep.FetchVCard(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if vCard != nil {
+		exp.VCard = vCard.String()
+	}
+	// private storage
+	privates, err := s.rep.FetchPrivates(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, prv := range privates {
+		exp.Private = append(exp.Private, prv.String())
+	}
+	// offline messages
+	offMessages, err := s.rep.FetchOfflineMessages(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range offMessages {
+		exp.Offline = append(exp.Offline, msg.String())
+	}
+	// archive
+	archiveMessages, err := s.rep.FetchArchiveMessages(ctx, &archivemodel.Filters{}, nil, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, aMsg := range archiveMessages {
+		var msg string
+		if aMsg.Message != nil {
+			msg = stravaganza.NewBuilderFromProto(aMsg.Message).Build().String()
+		}
+		exp.Archive = append(exp.Archive, archiveMessageExport{
+			ID:      aMsg.Id,
+			From:    aMsg.FromJid,
+			To:      aMsg.ToJid,
+			Stamp:   aMsg.Stamp.AsTime(),
+			Message: msg,
+		})
+	}
+	return exp, nil
+}
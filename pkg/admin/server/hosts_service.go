@@ -0,0 +1,118 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"fmt"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errClusteredHosts is returned when trying to modify local hosts in a clustered deployment,
+// since runtime hosts only live in the memory of the node handling the request.
+var errClusteredHosts = status.Error(codes.FailedPrecondition, "hosts cannot be modified at runtime when clustering is enabled")
+
+type hostsService struct {
+	adminpb.UnimplementedHostsServer
+	hosts     *host.Hosts
+	router    router.Router
+	resMng    resourcemanager.Manager
+	clustered bool
+	logger    kitlog.Logger
+}
+
+func newHostsService(hosts *host.Hosts, router router.Router, resMng resourcemanager.Manager, clustered bool, logger kitlog.Logger) adminpb.HostsServer {
+	return &hostsService{
+		hosts:     hosts,
+		router:    router,
+		resMng:    resMng,
+		clustered: clustered,
+		logger:    logger,
+	}
+}
+
+func (s *hostsService) ListHosts(_ context.Context, _ *adminpb.ListHostsRequest) (*adminpb.ListHostsResponse, error) {
+	return &adminpb.ListHostsResponse{
+		Hosts:       s.hosts.HostNames(),
+		DefaultHost: s.hosts.DefaultHostName(),
+	}, nil
+}
+
+func (s *hostsService) AddHost(_ context.Context, req *adminpb.AddHostRequest) (*adminpb.AddHostResponse, error) {
+	if s.clustered {
+		return nil, errClusteredHosts
+	}
+	domain := req.GetDomain()
+	if s.hosts.IsLocalHost(domain) {
+		return nil, status.Errorf(codes.AlreadyExists, fmt.Sprintf("host %s already exists", domain))
+	}
+	var cfg host.Config
+	cfg.Domain = domain
+	cfg.TLS.CertFile = req.GetCertFile()
+	cfg.TLS.PrivateKeyFile = req.GetPrivkeyFile()
+	cfg.MobileProfile = req.GetMobileProfile()
+	cfg.DisabledModules = req.GetDisabledModules()
+
+	if err := s.hosts.AddHost(cfg); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "host added", "domain", domain)
+
+	return &adminpb.AddHostResponse{}, nil
+}
+
+func (s *hostsService) RemoveHost(ctx context.Context, req *adminpb.RemoveHostRequest) (*adminpb.RemoveHostResponse, error) {
+	if s.clustered {
+		return nil, errClusteredHosts
+	}
+	domain := req.GetDomain()
+	if !s.hosts.IsLocalHost(domain) {
+		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("host %s not found", domain))
+	}
+	if domain == s.hosts.DefaultHostName() {
+		return nil, status.Errorf(codes.FailedPrecondition, fmt.Sprintf("default host %s cannot be removed", domain))
+	}
+	if err := s.hosts.RemoveHost(domain); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	// close all sessions bound to the removed host
+	rss, err := s.resMng.GetAllResources(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var resp adminpb.RemoveHostResponse
+	for _, res := range rss {
+		if res.JID().Domain() != domain {
+			continue
+		}
+		if err := s.router.C2S().Disconnect(ctx, res, streamerror.E(streamerror.HostUnknown)); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to disconnect session", "jid", res.JID().String(), "err", err)
+			continue
+		}
+		resp.DisconnectedCount++
+	}
+	level.Info(s.logger).Log("msg", "host removed", "domain", domain, "disconnected", resp.DisconnectedCount)
+
+	return &resp, nil
+}
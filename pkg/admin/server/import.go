@@ -0,0 +1,352 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/hook"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	blocklistmodel "github.com/ortuman/jackal/pkg/model/blocklist"
+	lastmodel "github.com/ortuman/jackal/pkg/model/last"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	xmppparser "github.com/ortuman/jackal/pkg/parser"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	ulidutil "github.com/ortuman/jackal/pkg/util/ulid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userImport extends the export bundle format with an optional clear text password,
+// allowing accounts coming from other servers to keep their credentials.
+type userImport struct {
+	userExport
+	Password string `json:"password,omitempty"`
+}
+
+// userEntities contains all repository entities derived from an import bundle.
+type userEntities struct {
+	user          *usermodel.User
+	last          *lastmodel.Last
+	roster        []*rostermodel.Item
+	notifications []*rostermodel.Notification
+	blockList     []*blocklistmodel.Item
+	vCard         stravaganza.Element
+	privates      []stravaganza.Element
+	offline       []*stravaganza.Message
+	archive       []*archivemodel.Message
+}
+
+func (s *usersService) ImportUser(ctx context.Context, req *adminpb.ImportUserRequest) (*adminpb.ImportUserResponse, error) {
+	var imp userImport
+	if err := json.Unmarshal(req.GetData(), &imp); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	username := imp.Username
+	if len(username) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing bundle username")
+	}
+	usr, err := s.rep.FetchUser(ctx, username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	policy := req.GetConflictPolicy()
+	if usr != nil {
+		switch policy {
+		case adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_SKIP:
+			level.Info(s.logger).Log("msg", "user import skipped", "username", username)
+			return &adminpb.ImportUserResponse{Username: username, ConflictPolicy: policy, Existing: true}, nil
+
+		case adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_MERGE, adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_REPLACE:
+		default:
+			return nil, status.Errorf(codes.AlreadyExists, fmt.Sprintf("user %s already exists", username))
+		}
+	}
+	merge := usr != nil && policy == adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_MERGE
+	replace := usr != nil && policy == adminpb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_REPLACE
+
+	ents, err := newUserEntities(&imp)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if merge {
+		ents.user = usr // keep current credentials unless explicitly overridden
+	}
+	password := req.GetPassword()
+	if len(password) == 0 && !merge {
+		password = imp.Password
+	}
+	switch {
+	case len(password) > 0:
+		if err := s.setCredentials(ents.user, password); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	case !merge:
+		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("no password provided for user %s", username))
+	}
+	err = s.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
+		if replace {
+			if err := deleteUserData(ctx, tx, username); err != nil {
+				return err
+			}
+		}
+		if merge {
+			if err := ents.skipArchived(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return ents.store(ctx, tx)
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if usr == nil {
+		// run user created hook
+		_, err = s.hk.Run(hook.UserCreated, &hook.ExecutionContext{
+			Info: &hook.UserInfo{
+				Username: username,
+			},
+			Context: ctx,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	level.Info(s.logger).Log("msg", "user data imported", "username", username, "merged", merge, "replaced", replace,
+		"roster_items", len(ents.roster), "offline_messages", len(ents.offline), "archived_messages", len(ents.archive),
+	)
+	resp := &adminpb.ImportUserResponse{Username: username}
+	if usr != nil {
+		resp.ConflictPolicy = policy
+		resp.Existing = true
+	}
+	return resp, nil
+}
+
+func newUserEntities(imp *userImport) (*userEntities, error) {
+	username := imp.Username
+
+	ents := &userEntities{
+		user: &usermodel.User{Username: username},
+	}
+	if imp.Last != nil {
+		ents.last = &lastmodel.Last{
+			Username: username,
+			Seconds:  imp.Last.Seconds,
+			Status:   imp.Last.Status,
+		}
+	}
+	for _, itm := range imp.Roster {
+		ents.roster = append(ents.roster, &rostermodel.Item{
+			Username:     username,
+			Jid:          itm.JID,
+			Name:         itm.Name,
+			Subscription: itm.Subscription,
+			Ask:          itm.Ask,
+			Groups:       itm.Groups,
+		})
+	}
+	for _, n := range imp.Notifications {
+		rn := &rostermodel.Notification{
+			Contact: username,
+			Jid:     n.JID,
+		}
+		if len(n.Presence) > 0 {
+			elem, err := parseElement(n.Presence)
+			if err != nil {
+				return nil, fmt.Errorf("roster notification %s: %v", n.JID, err)
+			}
+			rn.Presence = elem.Proto()
+		}
+		ents.notifications = append(ents.notifications, rn)
+	}
+	for _, j := range imp.BlockList {
+		ents.blockList = append(ents.blockList, &blocklistmodel.Item{
+			Username: username,
+			Jid:      j,
+		})
+	}
+	if len(imp.VCard) > 0 {
+		vCard, err := parseElement(imp.VCard)
+		if err != nil {
+			return nil, fmt.Errorf("vcard: %v", err)
+		}
+		ents.vCard = vCard
+	}
+	for _, prv := range imp.Private {
+		elem, err := parseElement(prv)
+		if err != nil {
+			return nil, fmt.Errorf("private storage: %v", err)
+		}
+		if len(elem.Attribute(stravaganza.Namespace)) == 0 {
+			return nil, fmt.Errorf("private storage: missing %s namespace", elem.Name())
+		}
+		ents.privates = append(ents.privates, elem)
+	}
+	for _, off := range imp.Offline {
+		msg, err := parseMessage(off)
+		if err != nil {
+			return nil, fmt.Errorf("offline message: %v", err)
+		}
+		ents.offline = append(ents.offline, msg)
+	}
+	for _, aMsg := range imp.Archive {
+		msg, err := parseMessage(aMsg.Message)
+		if err != nil {
+			return nil, fmt.Errorf("archive message %s: %v", aMsg.ID, err)
+		}
+		id := aMsg.ID
+		if len(id) == 0 {
+			id = ulidutil.New()
+		}
+		from, to := aMsg.From, aMsg.To
+		if len(from) == 0 && msg.FromJID() != nil {
+			from = msg.FromJID().String()
+		}
+		if len(to) == 0 && msg.ToJID() != nil {
+			to = msg.ToJID().String()
+		}
+		ents.archive = append(ents.archive, &archivemodel.Message{
+			ArchiveId: username,
+			Id:        id,
+			FromJid:   from,
+			ToJid:     to,
+			Message:   msg.Proto(),
+			Stamp:     timestamppb.New(aMsg.Stamp),
+		})
+	}
+	return ents, nil
+}
+
+func (e *userEntities) store(ctx context.Context, tx repository.Transaction) error {
+	username := e.user.Username
+
+	if err := tx.UpsertUser(ctx, e.user); err != nil {
+		return err
+	}
+	if e.last != nil {
+		if err := tx.UpsertLast(ctx, e.last); err != nil {
+			return err
+		}
+	}
+	for _, itm := range e.roster {
+		if err := tx.UpsertRosterItem(ctx, itm); err != nil {
+			return err
+		}
+	}
+	for _, rn := range e.notifications {
+		if err := tx.UpsertRosterNotification(ctx, rn); err != nil {
+			return err
+		}
+	}
+	for _, itm := range e.blockList {
+		if err := tx.UpsertBlockListItem(ctx, itm); err != nil {
+			return err
+		}
+	}
+	if e.vCard != nil {
+		if err := tx.UpsertVCard(ctx, e.vCard, username); err != nil {
+			return err
+		}
+	}
+	for _, prv := range e.privates {
+		if err := tx.UpsertPrivate(ctx, prv, prv.Attribute(stravaganza.Namespace), username); err != nil {
+			return err
+		}
+	}
+	for _, msg := range e.offline {
+		if err := tx.InsertOfflineMessage(ctx, msg, username); err != nil {
+			return err
+		}
+	}
+	for _, aMsg := range e.archive {
+		if _, err := tx.InsertArchiveMessage(ctx, aMsg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipArchived discards those imported archive messages already present in user archive.
+func (e *userEntities) skipArchived(ctx context.Context, tx repository.Transaction) error {
+	if len(e.archive) == 0 {
+		return nil
+	}
+	archived, err := tx.FetchArchiveMessages(ctx, &archivemodel.Filters{}, nil, e.user.Username)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]struct{}, len(archived))
+	for _, aMsg := range archived {
+		ids[aMsg.Id] = struct{}{}
+	}
+	var archive []*archivemodel.Message
+	for _, aMsg := range e.archive {
+		if _, ok := ids[aMsg.Id]; ok {
+			continue
+		}
+		archive = append(archive, aMsg)
+	}
+	e.archive = archive
+	return nil
+}
+
+func deleteUserData(ctx context.Context, tx repository.Transaction, username string) error {
+	if err := tx.DeleteLast(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeleteRosterItems(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeleteRosterNotifications(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeleteBlockListItems(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeleteVCard(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeletePrivates(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeleteOfflineMessages(ctx, username); err != nil {
+		return err
+	}
+	if err := tx.DeleteArchive(ctx, username); err != nil {
+		return err
+	}
+	return tx.DeleteArchiveReactions(ctx, username)
+}
+
+func parseElement(s string) (stravaganza.Element, error) {
+	return xmppparser.New(strings.NewReader(s), xmppparser.DefaultMode, 0).Parse()
+}
+
+func parseMessage(s string) (*stravaganza.Message, error) {
+	elem, err := parseElement(s)
+	if err != nil {
+		return nil, err
+	}
+	return stravaganza.NewBuilderFromElement(elem).BuildMessage()
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"errors"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/listener"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type listenersService struct {
+	adminpb.UnimplementedListenersServer
+	listeners *listener.Manager
+}
+
+func newListenersService(listeners *listener.Manager) adminpb.ListenersServer {
+	return &listenersService{
+		listeners: listeners,
+	}
+}
+
+func (s *listenersService) ListListeners(_ context.Context, _ *adminpb.ListListenersRequest) (*adminpb.ListListenersResponse, error) {
+	var resp adminpb.ListListenersResponse
+	for _, inf := range s.listeners.Listeners() {
+		resp.Listeners = append(resp.Listeners, listenerProto(inf))
+	}
+	return &resp, nil
+}
+
+func (s *listenersService) AddListener(ctx context.Context, req *adminpb.AddListenerRequest) (*adminpb.AddListenerResponse, error) {
+	typ := req.GetType()
+	switch typ {
+	case listener.C2S, listener.S2S:
+		break
+	case listener.Component:
+		if req.GetDirectTls() {
+			return nil, status.Error(codes.InvalidArgument, "direct TLS is not supported by component listeners")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unrecognized listener type: %s", typ)
+	}
+	if req.GetPort() <= 0 || req.GetPort() > 65535 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid listener port: %d", req.GetPort())
+	}
+	inf, err := s.listeners.Add(ctx, typ, listener.Options{
+		BindAddr:  req.GetBindAddr(),
+		Port:      int(req.GetPort()),
+		DirectTLS: req.GetDirectTls(),
+	})
+	switch {
+	case err == nil:
+		return &adminpb.AddListenerResponse{Listener: listenerProto(inf)}, nil
+
+	case errors.Is(err, listener.ErrUnsupportedType):
+		return nil, status.Errorf(codes.FailedPrecondition, "no %s listener configured to inherit settings from", typ)
+
+	case errors.Is(err, listener.ErrAddressInUse):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+
+	default:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (s *listenersService) RemoveListener(ctx context.Context, req *adminpb.RemoveListenerRequest) (*adminpb.RemoveListenerResponse, error) {
+	err := s.listeners.Remove(ctx, req.GetId())
+	switch {
+	case err == nil:
+		return &adminpb.RemoveListenerResponse{}, nil
+
+	case errors.Is(err, listener.ErrNotFound):
+		return nil, status.Errorf(codes.NotFound, "listener %s not found", req.GetId())
+
+	default:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+}
+
+func listenerProto(inf listener.Info) *adminpb.Listener {
+	return &adminpb.Listener{
+		Id:        inf.ID,
+		Type:      inf.Type,
+		BindAddr:  inf.BindAddr,
+		Port:      int32(inf.Port),
+		DirectTls: inf.DirectTLS,
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/xep0455"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type outageStatusService struct {
+	adminpb.UnimplementedOutageStatusServer
+	mods *module.Modules
+}
+
+func newOutageStatusService(mods *module.Modules) adminpb.OutageStatusServer {
+	return &outageStatusService{
+		mods: mods,
+	}
+}
+
+func (s *outageStatusService) GetOutageStatus(_ context.Context, _ *adminpb.GetOutageStatusRequest) (*adminpb.GetOutageStatusResponse, error) {
+	m, err := s.outageStatus()
+	if err != nil {
+		return nil, err
+	}
+	return &adminpb.GetOutageStatusResponse{
+		Status: toOutageStatusInfo(m, m.Status()),
+	}, nil
+}
+
+func (s *outageStatusService) SetOutageStatus(_ context.Context, req *adminpb.SetOutageStatusRequest) (*adminpb.SetOutageStatusResponse, error) {
+	m, err := s.outageStatus()
+	if err != nil {
+		return nil, err
+	}
+	st := m.SetStatus(req.GetDegraded(), req.GetMessage())
+	return &adminpb.SetOutageStatusResponse{
+		Status: toOutageStatusInfo(m, st),
+	}, nil
+}
+
+func (s *outageStatusService) outageStatus() (*xep0455.OutageStatus, error) {
+	if s.mods != nil {
+		for _, mod := range s.mods.AllModules() {
+			if m, ok := mod.(*xep0455.OutageStatus); ok {
+				return m, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "%s module is not enabled", xep0455.ModuleName)
+}
+
+func toOutageStatusInfo(m *xep0455.OutageStatus, st xep0455.Status) *adminpb.OutageStatusInfo {
+	info := &adminpb.OutageStatusInfo{
+		Degraded:                st.Degraded,
+		Message:                 st.Message,
+		ExternalStatusAddresses: m.ExternalStatusAddresses(),
+	}
+	if !st.UpdatedAt.IsZero() {
+		info.UpdatedAt = timestamppb.New(st.UpdatedAt)
+	}
+	return info
+}
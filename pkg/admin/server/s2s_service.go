@@ -0,0 +1,63 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/s2s"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type s2sService struct {
+	adminpb.UnimplementedS2SServer
+}
+
+func newS2SService() adminpb.S2SServer {
+	return &s2sService{}
+}
+
+func (s *s2sService) GetDomainStatus(_ context.Context, req *adminpb.GetDomainStatusRequest) (*adminpb.GetDomainStatusResponse, error) {
+	domain := req.GetDomain()
+
+	var resp adminpb.GetDomainStatusResponse
+	for _, st := range s2s.DomainStatuses() {
+		if len(domain) > 0 && st.Domain != domain {
+			continue
+		}
+		pbSt := &adminpb.DomainStatus{
+			Domain:          st.Domain,
+			Connected:       st.Connected,
+			Secured:         st.Secured,
+			AuthMethod:      st.AuthMethod,
+			DialbackResult:  st.DialbackResult,
+			LastError:       st.LastError,
+			StanzasSent:     st.StanzasSent,
+			StanzasReceived: st.StanzasReceived,
+		}
+		if !st.LastErrorAt.IsZero() {
+			pbSt.LastErrorAt = timestamppb.New(st.LastErrorAt)
+		}
+		resp.Statuses = append(resp.Statuses, pbSt)
+	}
+	if len(domain) > 0 && len(resp.Statuses) == 0 {
+		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("domain %s not found", domain))
+	}
+	return &resp, nil
+}
@@ -19,6 +19,7 @@ import (
 	"net"
 	"strconv"
 	"sync/atomic"
+	"time"
 
 	kitlog "github.com/go-kit/log"
 
@@ -27,7 +28,13 @@ import (
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
+	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
+	"github.com/ortuman/jackal/pkg/errorstats"
 	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/listener"
+	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 	"google.golang.org/grpc"
 )
@@ -41,10 +48,19 @@ type Server struct {
 	ln       net.Listener
 	active   int32
 
-	rep     repository.Repository
-	peppers *pepper.Keys
-	hk      *hook.Hooks
-	logger  kitlog.Logger
+	rep       repository.Repository
+	peppers   *pepper.Keys
+	hosts     *host.Hosts
+	router    router.Router
+	resMng    resourcemanager.Manager
+	listeners *listener.Manager
+	errStats  *errorstats.Tracker
+	mods      *module.Modules
+	hk        *hook.Hooks
+	logger    kitlog.Logger
+
+	deletionGracePeriod time.Duration
+	clustered           bool
 }
 
 // Config contains Server configuration parameters.
@@ -59,19 +75,36 @@ func New(
 	cfg Config,
 	rep repository.Repository,
 	peppers *pepper.Keys,
+	hosts *host.Hosts,
+	router router.Router,
+	resMng resourcemanager.Manager,
+	listeners *listener.Manager,
+	errStats *errorstats.Tracker,
+	mods *module.Modules,
 	hk *hook.Hooks,
+	deletionGracePeriod time.Duration,
+	clustered bool,
 	logger kitlog.Logger,
 ) *Server {
 	if cfg.Disabled {
 		return nil
 	}
 	return &Server{
-		bindAddr: cfg.BindAddr,
-		port:     cfg.Port,
-		rep:      rep,
-		peppers:  peppers,
-		hk:       hk,
-		logger:   logger,
+		bindAddr:  cfg.BindAddr,
+		port:      cfg.Port,
+		rep:       rep,
+		peppers:   peppers,
+		hosts:     hosts,
+		router:    router,
+		resMng:    resMng,
+		listeners: listeners,
+		errStats:  errStats,
+		mods:      mods,
+		hk:        hk,
+		logger:    logger,
+
+		deletionGracePeriod: deletionGracePeriod,
+		clustered:           clustered,
 	}
 }
 
@@ -93,7 +126,15 @@ func (s *Server) Start(_ context.Context) error {
 			grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
 			grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
 		)
-		adminpb.RegisterUsersServer(grpcServer, newUsersService(s.rep, s.peppers, s.hk, s.logger))
+		adminpb.RegisterUsersServer(grpcServer, newUsersService(s.rep, s.peppers, s.router, s.resMng, s.hk, s.deletionGracePeriod, s.logger))
+		adminpb.RegisterArchivesServer(grpcServer, newArchivesService(s.rep, s.hosts, s.logger))
+		adminpb.RegisterS2SServer(grpcServer, newS2SService())
+		adminpb.RegisterStatsServer(grpcServer, newStatsService(s.rep, s.errStats))
+		adminpb.RegisterBroadcastServer(grpcServer, newBroadcastService(s.router, s.resMng, s.logger))
+		adminpb.RegisterHostsServer(grpcServer, newHostsService(s.hosts, s.router, s.resMng, s.clustered, s.logger))
+		adminpb.RegisterListenersServer(grpcServer, newListenersService(s.listeners))
+		adminpb.RegisterAbuseReportsServer(grpcServer, newAbuseReportsService(s.mods))
+		adminpb.RegisterOutageStatusServer(grpcServer, newOutageStatusService(s.mods))
 		if err := grpcServer.Serve(s.ln); err != nil {
 			if atomic.LoadInt32(&s.active) == 1 {
 				level.Error(s.logger).Log("msg", "admin server error", "err", err)
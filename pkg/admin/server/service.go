@@ -24,20 +24,26 @@ import (
 	"encoding/base64"
 	"fmt"
 	"hash"
+	"time"
 
 	kitlog "github.com/go-kit/log"
 
 	"github.com/go-kit/log/level"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
 
 	userspb "github.com/ortuman/jackal/pkg/admin/pb"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
+	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
 	"github.com/ortuman/jackal/pkg/hook"
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/storage/repository"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/sha3"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const iterationCount = 15_000
@@ -46,16 +52,31 @@ type usersService struct {
 	userspb.UnimplementedUsersServer
 	rep     repository.Repository
 	peppers *pepper.Keys
+	router  router.Router
+	resMng  resourcemanager.Manager
 	hk      *hook.Hooks
 	logger  kitlog.Logger
+
+	deletionGracePeriod time.Duration
 }
 
-func newUsersService(rep repository.Repository, peppers *pepper.Keys, hk *hook.Hooks, logger kitlog.Logger) userspb.UsersServer {
+func newUsersService(
+	rep repository.Repository,
+	peppers *pepper.Keys,
+	router router.Router,
+	resMng resourcemanager.Manager,
+	hk *hook.Hooks,
+	deletionGracePeriod time.Duration,
+	logger kitlog.Logger,
+) userspb.UsersServer {
 	return &usersService{
-		rep:     rep,
-		peppers: peppers,
-		hk:      hk,
-		logger:  logger,
+		rep:                 rep,
+		peppers:             peppers,
+		router:              router,
+		resMng:              resMng,
+		hk:                  hk,
+		logger:              logger,
+		deletionGracePeriod: deletionGracePeriod,
 	}
 }
 
@@ -64,7 +85,7 @@ func (s *usersService) CreateUser(ctx context.Context, req *userspb.CreateUserRe
 	if err := s.ensureUserNotFound(ctx, username); err != nil {
 		return nil, err
 	}
-	if err := s.upsertUser(ctx, username, req.GetPassword()); err != nil {
+	if err := s.upsertUser(ctx, &usermodel.User{Username: username}, req.GetPassword()); err != nil {
 		return nil, err
 	}
 	// run user created hook
@@ -83,10 +104,14 @@ func (s *usersService) CreateUser(ctx context.Context, req *userspb.CreateUserRe
 
 func (s *usersService) ChangeUserPassword(ctx context.Context, req *userspb.ChangeUserPasswordRequest) (*userspb.ChangeUserPasswordResponse, error) {
 	username := req.GetUsername()
-	if err := s.ensureUserAlreadyExists(ctx, username); err != nil {
+	usr, err := s.fetchUser(ctx, username)
+	if err != nil {
 		return nil, err
 	}
-	if err := s.upsertUser(ctx, username, req.GetNewPassword()); err != nil {
+	if usr.DeletionScheduledAt != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, fmt.Sprintf("user %s is pending deletion", username))
+	}
+	if err := s.upsertUser(ctx, usr, req.GetNewPassword()); err != nil {
 		return nil, err
 	}
 	level.Info(s.logger).Log("msg", "password updated", "username", username)
@@ -96,14 +121,18 @@ func (s *usersService) ChangeUserPassword(ctx context.Context, req *userspb.Chan
 
 func (s *usersService) DeleteUser(ctx context.Context, req *userspb.DeleteUserRequest) (*userspb.DeleteUserResponse, error) {
 	username := req.GetUsername()
-	if err := s.ensureUserAlreadyExists(ctx, username); err != nil {
+	usr, err := s.fetchUser(ctx, username)
+	if err != nil {
 		return nil, err
 	}
+	if s.deletionGracePeriod > 0 && !req.GetForce() {
+		return s.scheduleUserDeletion(ctx, usr)
+	}
 	if err := s.rep.DeleteUser(ctx, username); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	// run user deleted hook
-	_, err := s.hk.Run(hook.UserDeleted, &hook.ExecutionContext{
+	_, err = s.hk.Run(hook.UserDeleted, &hook.ExecutionContext{
 		Info: &hook.UserInfo{
 			Username: username,
 		},
@@ -117,6 +146,149 @@ func (s *usersService) DeleteUser(ctx context.Context, req *userspb.DeleteUserRe
 	return &userspb.DeleteUserResponse{}, nil
 }
 
+func (s *usersService) UndeleteUser(ctx context.Context, req *userspb.UndeleteUserRequest) (*userspb.UndeleteUserResponse, error) {
+	username := req.GetUsername()
+	usr, err := s.fetchUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if usr.DeletionScheduledAt == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, fmt.Sprintf("user %s is not pending deletion", username))
+	}
+	usr.DeletionScheduledAt = nil
+	if err := s.rep.UpsertUser(ctx, usr); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "user restored", "username", username)
+
+	return &userspb.UndeleteUserResponse{}, nil
+}
+
+func (s *usersService) SuspendUser(ctx context.Context, req *userspb.SuspendUserRequest) (*userspb.SuspendUserResponse, error) {
+	username := req.GetUsername()
+	usr, err := s.fetchUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !usr.Suspended {
+		usr.Suspended = true
+		if err := s.rep.UpsertUser(ctx, usr); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	// kick out all user sessions
+	n, err := s.disconnectUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	level.Info(s.logger).Log("msg", "user suspended", "username", username, "disconnected", n)
+
+	return &userspb.SuspendUserResponse{}, nil
+}
+
+func (s *usersService) UnsuspendUser(ctx context.Context, req *userspb.UnsuspendUserRequest) (*userspb.UnsuspendUserResponse, error) {
+	username := req.GetUsername()
+	usr, err := s.fetchUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !usr.Suspended {
+		return nil, status.Errorf(codes.FailedPrecondition, fmt.Sprintf("user %s is not suspended", username))
+	}
+	usr.Suspended = false
+	if err := s.rep.UpsertUser(ctx, usr); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "user unsuspended", "username", username)
+
+	return &userspb.UnsuspendUserResponse{}, nil
+}
+
+func (s *usersService) KickUser(ctx context.Context, req *userspb.KickUserRequest) (*userspb.KickUserResponse, error) {
+	username := req.GetUsername()
+	seeOtherHost := req.GetSeeOtherHost()
+
+	condition := req.GetCondition()
+	if len(condition) == 0 {
+		condition = "policy-violation"
+		if len(seeOtherHost) > 0 {
+			condition = "see-other-host"
+		}
+	}
+	if !xmpputil.IsStreamErrorCondition(condition) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown stream error condition: %s", condition)
+	}
+	if condition == "see-other-host" && len(seeOtherHost) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "see-other-host condition requires a host")
+	}
+	if _, err := s.fetchUser(ctx, username); err != nil {
+		return nil, err
+	}
+	rss, err := s.resMng.GetResources(ctx, username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	retryAfter := time.Duration(req.GetRetryAfter()) * time.Second
+	streamErr := xmpputil.MakeReconnectStreamError(condition, req.GetText(), seeOtherHost, retryAfter)
+
+	var count int32
+	for _, res := range rss {
+		if len(req.GetResource()) > 0 && res.JID().Resource() != req.GetResource() {
+			continue
+		}
+		if err := s.router.C2S().Disconnect(ctx, res, streamErr); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		count++
+	}
+	level.Info(s.logger).Log("msg", "user kicked", "username", username, "condition", condition,
+		"see_other_host", seeOtherHost, "disconnected", count)
+
+	return &userspb.KickUserResponse{DisconnectedCount: count}, nil
+}
+
+func (s *usersService) scheduleUserDeletion(ctx context.Context, usr *usermodel.User) (*userspb.DeleteUserResponse, error) {
+	if usr.DeletionScheduledAt == nil {
+		usr.DeletionScheduledAt = timestamppb.New(time.Now().Add(s.deletionGracePeriod))
+		if err := s.rep.UpsertUser(ctx, usr); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	// a user pending deletion is not allowed to keep using the account
+	n, err := s.disconnectUser(ctx, usr.Username)
+	if err != nil {
+		return nil, err
+	}
+	level.Info(s.logger).Log("msg", "user deletion scheduled",
+		"username", usr.Username, "purge_at", usr.DeletionScheduledAt.AsTime().Format(time.RFC3339), "disconnected", n,
+	)
+	return &userspb.DeleteUserResponse{PurgeAt: usr.DeletionScheduledAt}, nil
+}
+
+func (s *usersService) disconnectUser(ctx context.Context, username string) (int, error) {
+	rss, err := s.resMng.GetResources(ctx, username)
+	if err != nil {
+		return 0, status.Error(codes.Internal, err.Error())
+	}
+	for _, res := range rss {
+		if err := s.router.C2S().Disconnect(ctx, res, streamerror.E(streamerror.PolicyViolation)); err != nil {
+			return 0, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return len(rss), nil
+}
+
+func (s *usersService) fetchUser(ctx context.Context, username string) (*usermodel.User, error) {
+	usr, err := s.rep.FetchUser(ctx, username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if usr == nil {
+		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("user %s not found", username))
+	}
+	return usr, nil
+}
+
 func (s *usersService) ensureUserNotFound(ctx context.Context, username string) error {
 	exists, err := s.rep.UserExists(ctx, username)
 	if err != nil {
@@ -139,11 +311,21 @@ func (s *usersService) ensureUserAlreadyExists(ctx context.Context, username str
 	return nil
 }
 
-func (s *usersService) upsertUser(ctx context.Context, username, password string) error {
+func (s *usersService) upsertUser(ctx context.Context, usr *usermodel.User, password string) error {
+	if err := s.setCredentials(usr, password); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := s.rep.UpsertUser(ctx, usr); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+func (s *usersService) setCredentials(usr *usermodel.User, password string) error {
 	salt := make([]byte, 32)
 	_, err := rand.Read(salt)
 	if err != nil {
-		return status.Error(codes.Internal, err.Error())
+		return err
 	}
 	buf := bytes.NewBuffer(salt)
 	pp := s.peppers.GetActiveKey()
@@ -156,10 +338,7 @@ func (s *usersService) upsertUser(ctx context.Context, username, password string
 	hSHA512 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha512.Size, sha512.New)
 	hSHA3512 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha512.Size, sha3.New512)
 
-	usr := usermodel.User{
-		Username: username,
-		Scram:    &usermodel.Scram{},
-	}
+	usr.Scram = &usermodel.Scram{}
 	usr.Scram.Sha1 = base64.RawURLEncoding.EncodeToString(hSHA1)
 	usr.Scram.Sha256 = base64.RawURLEncoding.EncodeToString(hSHA256)
 	usr.Scram.Sha512 = base64.RawURLEncoding.EncodeToString(hSHA512)
@@ -167,10 +346,6 @@ func (s *usersService) upsertUser(ctx context.Context, username, password string
 	usr.Scram.Salt = base64.RawURLEncoding.EncodeToString(salt)
 	usr.Scram.IterationCount = iterationCount
 	usr.Scram.PepperId = s.peppers.GetActiveID()
-
-	if err := s.rep.UpsertUser(ctx, &usr); err != nil {
-		return status.Error(codes.Internal, err.Error())
-	}
 	return nil
 }
 
@@ -0,0 +1,123 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"time"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/errorstats"
+	"github.com/ortuman/jackal/pkg/s2s"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultStatsDays = 30
+	monthlyStatsDays = 30
+
+	defaultErrorStatsMinutes = 5
+	defaultErrorStatsLimit   = 10
+)
+
+type statsService struct {
+	adminpb.UnimplementedStatsServer
+	rep      repository.Repository
+	errStats *errorstats.Tracker
+	nowFn    func() time.Time
+}
+
+func newStatsService(rep repository.Repository, errStats *errorstats.Tracker) adminpb.StatsServer {
+	return &statsService{
+		rep:      rep,
+		errStats: errStats,
+		nowFn:    time.Now,
+	}
+}
+
+func (s *statsService) GetStats(ctx context.Context, req *adminpb.GetStatsRequest) (*adminpb.GetStatsResponse, error) {
+	days := int(req.GetDays())
+	switch {
+	case days < 0:
+		return nil, status.Error(codes.InvalidArgument, "days must be a positive value")
+	case days == 0:
+		days = defaultStatsDays
+	}
+	today := s.nowFn().UTC().Truncate(24 * time.Hour)
+
+	dau, err := s.rep.CountActiveUsers(ctx, today, today)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	mau, err := s.rep.CountActiveUsers(ctx, today.AddDate(0, 0, -(monthlyStatsDays-1)), today)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	hostStats, err := s.rep.FetchHostStats(ctx, today.AddDate(0, 0, -(days-1)), today)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &adminpb.GetStatsResponse{
+		DailyActiveUsers:   int32(dau),
+		MonthlyActiveUsers: int32(mau),
+	}
+	for _, st := range hostStats {
+		resp.Hosts = append(resp.Hosts, &adminpb.HostStats{
+			Host:          st.Host,
+			Messages:      st.Messages,
+			Registrations: st.Registrations,
+		})
+	}
+	for _, st := range s2s.DomainStatuses() {
+		if st.Connected {
+			resp.S2SPeers = append(resp.S2SPeers, st.Domain)
+		}
+	}
+	return resp, nil
+}
+
+func (s *statsService) GetErrorStats(_ context.Context, req *adminpb.GetErrorStatsRequest) (*adminpb.GetErrorStatsResponse, error) {
+	minutes := int(req.GetMinutes())
+	switch {
+	case minutes < 0 || time.Duration(minutes)*time.Minute > errorstats.MaxWindow:
+		return nil, status.Errorf(codes.InvalidArgument, "minutes must be a value between 1 and %d", int(errorstats.MaxWindow/time.Minute))
+	case minutes == 0:
+		minutes = defaultErrorStatsMinutes
+	}
+	limit := int(req.GetLimit())
+	switch {
+	case limit < 0:
+		return nil, status.Error(codes.InvalidArgument, "limit must be a positive value")
+	case limit == 0:
+		limit = defaultErrorStatsLimit
+	}
+	sources, total := s.errStats.TopSources(time.Duration(minutes)*time.Minute, limit)
+
+	resp := &adminpb.GetErrorStatsResponse{
+		Total: total,
+	}
+	for _, src := range sources {
+		resp.Sources = append(resp.Sources, &adminpb.ErrorSource{
+			Condition:     src.Condition,
+			Origin:        src.Origin,
+			Peer:          src.Peer,
+			Count:         src.Count,
+			PreviousCount: src.PreviousCount,
+		})
+	}
+	return resp, nil
+}
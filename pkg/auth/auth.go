@@ -60,6 +60,9 @@ const (
 
 	// TemporaryAuthFailure represents a 'temporary-auth-failure' authentication error.
 	TemporaryAuthFailure
+
+	// AccountDisabled represents a 'account-disabled' authentication error.
+	AccountDisabled
 )
 
 // String returns SASLErrorReason string representation.
@@ -73,6 +76,8 @@ func (r SASLErrorReason) String() string {
 		return "not-authorized"
 	case TemporaryAuthFailure:
 		return "temporary-auth-failure"
+	case AccountDisabled:
+		return "account-disabled"
 	default:
 		return ""
 	}
@@ -229,9 +229,12 @@ func (s *Scram) handleStart(ctx context.Context, elem stravaganza.Element) (stra
 	if err != nil {
 		return nil, newSASLError(TemporaryAuthFailure, err)
 	}
-	if user == nil {
+	if user == nil || user.DeletionScheduledAt != nil {
 		return nil, newSASLError(NotAuthorized, nil)
 	}
+	if user.Suspended {
+		return nil, newSASLError(AccountDisabled, nil)
+	}
 	s.user = user
 
 	saltBytes, err := base64.RawURLEncoding.DecodeString(user.Scram.Salt)
@@ -37,6 +37,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
@@ -159,6 +160,30 @@ func testScramTypeCases(t *testing.T, tp ScramType) {
 			expectsError:      true,
 			expectedErrReason: NotAuthorized,
 		},
+		{
+			// Deleted user
+			name:              "DeletedUser",
+			scramType:         tp,
+			usesCb:            false,
+			gs2BindFlag:       "n",
+			n:                 "hamlet",
+			r:                 "bb769406-eaa4-4f38-a279-2b90e596f6dd",
+			password:          "1234",
+			expectsError:      true,
+			expectedErrReason: NotAuthorized,
+		},
+		{
+			// Suspended user
+			name:              "SuspendedUser",
+			scramType:         tp,
+			usesCb:            false,
+			gs2BindFlag:       "n",
+			n:                 "mariano",
+			r:                 "bb769406-eaa4-4f38-a279-2b90e596f6dd",
+			password:          "1234",
+			expectsError:      true,
+			expectedErrReason: AccountDisabled,
+		},
 		{
 			// Invalid password
 			name:              "InvalidPassword",
@@ -247,10 +272,21 @@ func processScramTestCase(t *testing.T, tc *scramAuthTestCase) *SASLError {
 	}
 	testUsr := testUser()
 	repMock.FetchUserFunc = func(_ context.Context, username string) (*usermodel.User, error) {
-		if username != "ortuman" {
-			return nil, nil
+		switch username {
+		case "ortuman":
+			return testUsr, nil
+		case "hamlet":
+			usr := testUser()
+			usr.Username = "hamlet"
+			usr.DeletionScheduledAt = timestamppb.Now()
+			return usr, nil
+		case "mariano":
+			usr := testUser()
+			usr.Username = "mariano"
+			usr.Suspended = true
+			return usr, nil
 		}
-		return testUsr, nil
+		return nil, nil
 	}
 	auth := NewScram(trMock, tc.scramType, tc.usesCb, repMock, testPeppers())
 
@@ -50,7 +50,8 @@ type ListenerConfig struct {
 	CompressionLevel string `fig:"compression_level" default:"default"`
 
 	// ResourceConflict defines the which rule should be applied in a resource conflict is detected.
-	// Valid values are `override`, `disallow` and `terminate_old`.
+	// Valid values are `override` (server suffixed resource), `disallow` and `terminate_old`.
+	// It can be overridden on a per host basis.
 	ResourceConflict string `fig:"resource_conflict" default:"terminate_old"`
 
 	// MaxStanzaSize is the maximum size a listener incoming stanza may have.
@@ -69,3 +70,19 @@ type ListenerConfig struct {
 	// RequestTimeout defines C2S stream request timeout.
 	RequestTimeout time.Duration `fig:"req_timeout" default:"15s"`
 }
+
+// RoutingConfig contains C2S bare JID routing configuration.
+type RoutingConfig struct {
+	// DeliverNegativePriority tells whether or not resources with a negative priority should be
+	// considered when routing a message addressed to a bare JID.
+	DeliverNegativePriority bool `fig:"deliver_negative_priority"`
+
+	// BareJIDMessages defines the set of resources a message addressed to a bare JID is delivered to.
+	// Valid values are `highest_priority` and `all`.
+	BareJIDMessages string `fig:"bare_jid_messages" default:"highest_priority"`
+
+	// TieBreak defines how ties among highest priority resources are broken.
+	// Valid values are `all` (deliver to every tied resource) and `most_available` (prefer the resources
+	// with the most available presence show value).
+	TieBreak string `fig:"tie_break" default:"all"`
+}
@@ -81,6 +81,7 @@ const (
 type inCfg struct {
 	authenticateTimeout time.Duration
 	reqTimeout          time.Duration
+	keepAliveTimeout    time.Duration
 	maxStanzaSize       int
 	compressionLevel    compress.Level
 	resConflict         resourceConflict
@@ -119,6 +120,10 @@ type inC2S struct {
 	doneCh       chan struct{}
 	sendDisabled bool
 
+	remoteIP  string
+	ipSess    *ipSessions
+	ipSessKey string
+
 	mu    sync.RWMutex
 	state state
 	jd    *jid.JID
@@ -130,6 +135,7 @@ type inC2S struct {
 func newInC2S(
 	cfg inCfg,
 	tr transport.Transport,
+	remoteIP string,
 	authenticators []auth.Authenticator,
 	hosts *host.Hosts,
 	router router.Router,
@@ -137,6 +143,7 @@ func newInC2S(
 	mods *module.Modules,
 	resMng resourcemanager.Manager,
 	shapers shaper.Shapers,
+	ipSess *ipSessions,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) (*inC2S, error) {
@@ -161,23 +168,25 @@ func newInC2S(
 	)
 	// init stream
 	stm := &inC2S{
-		id:      id,
-		cfg:     cfg,
-		tr:      tr,
-		inf:     c2smodel.NewInfoMap(),
-		session: session,
-		authSt:  authState{authenticators: authenticators},
-		hosts:   hosts,
-		router:  router,
-		comps:   comps,
-		mods:    mods,
-		resMng:  resMng,
-		shapers: shapers,
-		rq:      runqueue.New(id.String()),
-		doneCh:  make(chan struct{}),
-		state:   inConnecting,
-		hk:      hk,
-		logger:  sLogger,
+		id:       id,
+		cfg:      cfg,
+		tr:       tr,
+		inf:      c2smodel.NewInfoMap(),
+		session:  session,
+		authSt:   authState{authenticators: authenticators},
+		hosts:    hosts,
+		router:   router,
+		comps:    comps,
+		mods:     mods,
+		resMng:   resMng,
+		shapers:  shapers,
+		remoteIP: remoteIP,
+		ipSess:   ipSess,
+		rq:       runqueue.New(id.String()),
+		doneCh:   make(chan struct{}),
+		state:    inConnecting,
+		hk:       hk,
+		logger:   sLogger,
 	}
 	if cfg.useTLS {
 		stm.flags.setSecured() // stream already secured
@@ -276,6 +285,16 @@ func (s *inC2S) SendElement(elem stravaganza.Element) <-chan error {
 	return errCh
 }
 
+func (s *inC2S) SendWhitespace() <-chan error {
+	errCh := make(chan error, 1)
+	s.rq.Run(func() {
+		ctx, cancel := s.requestContext()
+		defer cancel()
+		errCh <- s.sendWhitespace(ctx)
+	})
+	return errCh
+}
+
 func (s *inC2S) Disconnect(streamErr *streamerror.Error) <-chan error {
 	errCh := make(chan error, 1)
 	s.rq.Run(func() {
@@ -607,6 +626,9 @@ func (s *inC2S) processIQ(ctx context.Context, iq *stravaganza.IQ) error {
 	case router.ErrRemoteServerTimeout:
 		return s.sendElement(ctx, stanzaerror.E(stanzaerror.RemoteServerTimeout, iq).Element())
 
+	case router.ErrRemoteServerNotAllowed:
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAllowed, iq).Element())
+
 	case nil, router.ErrUserNotAvailable:
 		_, err = s.runHook(ctx, hook.C2SStreamIQRouted, &hook.C2SStreamInfo{
 			ID:       s.ID().String(),
@@ -727,6 +749,9 @@ sendMsg:
 	case router.ErrRemoteServerTimeout:
 		return s.sendElement(ctx, stanzaerror.E(stanzaerror.RemoteServerTimeout, message).Element())
 
+	case router.ErrRemoteServerNotAllowed:
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAllowed, message).Element())
+
 	case nil, router.ErrUserNotAvailable:
 		halted, hErr := s.runHook(ctx, hook.C2SStreamMessageRouted, &hook.C2SStreamInfo{
 			ID:       s.ID().String(),
@@ -749,6 +774,9 @@ sendMsg:
 }
 
 func (s *inC2S) handleSessionError(ctx context.Context, err error) {
+	if s.getState() == inDisconnected {
+		return // hibernated session... it will be terminated by whoever halted its disconnection
+	}
 	if errors.Is(err, xmppparser.ErrStreamClosedByPeer) {
 		_ = s.session.Close(ctx)
 	}
@@ -822,6 +850,9 @@ func (s *inC2S) authenticatedFeatures(ctx context.Context) ([]stravaganza.Elemen
 		Build()
 	features = append(features, sessElem)
 
+	// stream limits feature
+	features = append(features, xmpputil.MakeStreamLimitsElement(s.cfg.maxStanzaSize, s.cfg.keepAliveTimeout))
+
 	// include module stream features
 	modFeatures, err := s.mods.StreamFeatures(ctx, s.JID().Domain())
 	if err != nil {
@@ -973,6 +1004,46 @@ func (s *inC2S) compress(ctx context.Context, elem stravaganza.Element) error {
 	return nil
 }
 
+func (s *inC2S) resourceConflict() resourceConflict {
+	if rc, ok := resConflictMap[s.hosts.ResourceConflict(s.Domain())]; ok {
+		return rc // host specific rule
+	}
+	return s.cfg.resConflict
+}
+
+// exceedsMaxResources tells whether binding a new resource would exceed the host maximum number of
+// concurrently bound resources per account.
+func (s *inC2S) exceedsMaxResources(rss []c2smodel.ResourceDesc, resElem stravaganza.Element) bool {
+	maxResources := s.hosts.MaxResources(s.Domain())
+	if maxResources <= 0 {
+		return false
+	}
+	bound := len(rss)
+	if resElem != nil && s.resourceConflict() == terminateOld {
+		for _, rs := range rss {
+			if rs.JID().Resource() == resElem.Text() {
+				bound-- // conflicting resource is going to be replaced
+				break
+			}
+		}
+	}
+	return bound >= maxResources
+}
+
+// acquireIPSession accounts the stream as a bound session of its remote IP address, returning false
+// in case the host maximum number of sessions per IP address has already been reached.
+func (s *inC2S) acquireIPSession() bool {
+	if s.ipSess == nil || len(s.remoteIP) == 0 || len(s.ipSessKey) > 0 {
+		return true
+	}
+	key := ipSessionKey(s.Domain(), s.remoteIP)
+	if !s.ipSess.acquire(key, s.hosts.MaxSessionsPerIP(s.Domain())) {
+		return false
+	}
+	s.ipSessKey = key
+	return true
+}
+
 func (s *inC2S) bindResource(ctx context.Context, iq *stravaganza.IQ) error {
 	bind := iq.ChildNamespace("bind", bindNamespace)
 	if iq.Attribute(stravaganza.Type) != stravaganza.SetType || bind == nil {
@@ -992,6 +1063,14 @@ func (s *inC2S) bindResource(ctx context.Context, iq *stravaganza.IQ) error {
 			Build()
 		return s.disconnect(ctx, se)
 	}
+	if s.exceedsMaxResources(rss, bind.Child("resource")) {
+		level.Info(s.logger).Log("msg", "max resources per account reached", "username", s.Username())
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.ResourceConstraint, iq).Element())
+	}
+	if !s.acquireIPSession() {
+		level.Info(s.logger).Log("msg", "max sessions per IP reached", "username", s.Username(), "ip", s.remoteIP)
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.ResourceConstraint, iq).Element())
+	}
 
 	var res string
 	if resElem := bind.Child("resource"); resElem != nil {
@@ -1002,10 +1081,10 @@ func (s *inC2S) bindResource(ctx context.Context, iq *stravaganza.IQ) error {
 			if rs.JID().Resource() != res {
 				continue
 			}
-			switch s.cfg.resConflict {
-			// replace by a server generated resourcepart
+			switch s.resourceConflict() {
+			// replace by a server generated suffixed resourcepart
 			case override:
-				res = uuid.New().String()
+				res = fmt.Sprintf("%s-%s", res, uuid.New().String()[:8])
 				break
 
 			// disconnect previously connected resource
@@ -1078,7 +1157,7 @@ func (s *inC2S) disconnect(ctx context.Context, streamErr *streamerror.Error) er
 		_ = s.session.OpenStream(ctx)
 	}
 	if streamErr != nil {
-		if err := s.sendElement(ctx, streamErr.Element()); err != nil {
+		if err := s.sendElement(ctx, xmpputil.StreamErrorElement(streamErr)); err != nil {
 			return err
 		}
 	}
@@ -1096,6 +1175,11 @@ func (s *inC2S) disconnect(ctx context.Context, streamErr *streamerror.Error) er
 		s.sendDisabled = true // avoid sending anymore stanzas while closing
 		return nil
 	}
+	if streamErr == nil {
+		// do not hand over a typed nil error, so that hook handlers can tell this disconnection
+		// wasn't caused by a stream error (i.e. to hibernate the session)
+		return s.close(ctx, nil)
+	}
 	return s.close(ctx, streamErr)
 }
 
@@ -1140,6 +1224,9 @@ func (s *inC2S) terminate(ctx context.Context) error {
 	}
 	reportConnectionUnregistered()
 
+	if len(s.ipSessKey) > 0 {
+		s.ipSess.release(s.ipSessKey)
+	}
 	// close underlying transport
 	_ = s.tr.Close()
 
@@ -1165,6 +1252,20 @@ func (s *inC2S) sendElement(ctx context.Context, elem stravaganza.Element) error
 	if s.sendDisabled {
 		return nil
 	}
+	hi := &hook.C2SStreamInfo{
+		ID:      s.ID().String(),
+		JID:     s.JID(),
+		Element: elem,
+	}
+	halted, err := s.runHook(ctx, hook.C2SStreamWillSendElement, hi)
+	if halted {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	elem = hi.Element
+
 	_ = s.session.Send(ctx, elem)
 
 	reportOutgoingRequest(
@@ -1172,7 +1273,7 @@ func (s *inC2S) sendElement(ctx context.Context, elem stravaganza.Element) error
 		elem.Attribute(stravaganza.Type),
 	)
 	// run element sent hook
-	_, err := s.runHook(ctx, hook.C2SStreamElementSent, &hook.C2SStreamInfo{
+	_, err = s.runHook(ctx, hook.C2SStreamElementSent, &hook.C2SStreamInfo{
 		ID:      s.ID().String(),
 		JID:     s.JID(),
 		Element: elem,
@@ -1180,6 +1281,13 @@ func (s *inC2S) sendElement(ctx context.Context, elem stravaganza.Element) error
 	return err
 }
 
+func (s *inC2S) sendWhitespace(ctx context.Context) error {
+	if s.sendDisabled || s.getState() != inBinded {
+		return nil
+	}
+	return s.session.SendWhitespace(ctx)
+}
+
 func (s *inC2S) getResource() c2smodel.ResourceDesc {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
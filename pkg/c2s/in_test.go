@@ -136,6 +136,52 @@ func TestInC2S_Disconnect(t *testing.T) {
 	require.Len(t, rmMock.DelResourceCalls(), 1)
 }
 
+func TestInC2S_DisconnectResumable(t *testing.T) {
+	// given
+	trMock := &transportMock{}
+	trMock.CloseFunc = func() error { return nil }
+
+	sessMock := &sessionMock{}
+	sessMock.CloseFunc = func(ctx context.Context) error { return nil }
+
+	routerMock := &routerMock{}
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.UnregisterFunc = func(stm stream.C2S) error { return nil }
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+
+	var discErr error
+	hk := hook.NewHooks()
+	hk.AddHook(hook.C2SStreamDisconnected, func(execCtx *hook.ExecutionContext) error {
+		discErr = execCtx.Info.(*hook.C2SStreamInfo).DisconnectError
+		return hook.ErrStopped // hibernate
+	}, hook.DefaultPriority)
+
+	s := &inC2S{
+		state:   inBinded,
+		session: sessMock,
+		tr:      trMock,
+		router:  routerMock,
+		rq:      runqueue.New("in_c2s:test"),
+		doneCh:  make(chan struct{}),
+		hk:      hk,
+		logger:  kitlog.NewNopLogger(),
+	}
+
+	// when
+	err := <-s.Disconnect(nil)
+	s.handleSessionResult(nil, io.EOF) // session closed
+
+	// then
+	require.Nil(t, err)
+	require.True(t, discErr == nil) // untyped nil
+	require.Equal(t, inDisconnected, s.getState())
+	require.Len(t, sessMock.CloseCalls(), 1)
+	require.Len(t, trMock.CloseCalls(), 0)
+	require.Len(t, c2sRouterMock.UnregisterCalls(), 0)
+}
+
 func TestInC2S_HandleSessionElement(t *testing.T) {
 	jd0, _ := jid.New("ortuman", "jackal.im", "yard", true)
 	jd1, _ := jid.New("ortuman", "jackal.im", "hall", true)
@@ -145,17 +191,22 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 		name string
 
 		// input
-		state         state
-		sessionResFn  func() (stravaganza.Element, error)
-		authProcessFn func(_ context.Context, _ stravaganza.Element) (stravaganza.Element, *auth.SASLError)
-		routeError    error
-		hubResources  []c2smodel.ResourceDesc
-		flags         uint8
+		state            state
+		sessionResFn     func() (stravaganza.Element, error)
+		authProcessFn    func(_ context.Context, _ stravaganza.Element) (stravaganza.Element, *auth.SASLError)
+		routeError       error
+		hubResources     []c2smodel.ResourceDesc
+		hostResConflict  string
+		hostMaxResources int
+		hostMaxIPSess    int
+		ipSessions       int
+		flags            uint8
 
 		// expectations
 		expectedOutput        string
 		expectRouted          bool
 		expectResourceUpdated bool
+		expectDisconnected    bool
 		expectedState         state
 	}{
 		{
@@ -199,7 +250,7 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 					WithAttribute(stravaganza.Version, "1.0").
 					Build(), nil
 			},
-			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' id='c2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><compression xmlns='http://jabber.org/features/compress'><method>zlib</method></compression><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><required/></bind><session xmlns='urn:ietf:params:xml:ns:xmpp-session'/></stream:features>`,
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' id='c2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><compression xmlns='http://jabber.org/features/compress'><method>zlib</method></compression><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><required/></bind><session xmlns='urn:ietf:params:xml:ns:xmpp-session'/><limits xmlns='urn:xmpp:stream-limits:0'><max-bytes>8192</max-bytes></limits></stream:features>`,
 			expectedState:  inAuthenticated,
 		},
 		{
@@ -390,6 +441,90 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 			expectedOutput: `<iq from='ortuman@localhost' to='ortuman@localhost' type='error' id='bind_2'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><resource>yard</resource></bind><error code='409' type='cancel'><conflict xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></iq>`,
 			expectedState:  inAuthenticated,
 		},
+		{
+			name:  "Authenticated/BindConflictHostTerminateOld",
+			state: inAuthenticated,
+			flags: fSecured | fCompressed | fAuthenticated,
+			sessionResFn: func() (stravaganza.Element, error) {
+				iq, _ := stravaganza.NewIQBuilder().
+					WithAttribute(stravaganza.From, "ortuman@localhost").
+					WithAttribute(stravaganza.To, "ortuman@localhost").
+					WithAttribute(stravaganza.Type, stravaganza.SetType).
+					WithAttribute(stravaganza.ID, "bind_2").
+					WithChild(
+						stravaganza.NewBuilder("bind").
+							WithAttribute(stravaganza.Namespace, bindNamespace).
+							WithChild(
+								stravaganza.NewBuilder("resource").WithText("yard").Build(),
+							).
+							Build(),
+					).
+					BuildIQ()
+				return iq, nil
+			},
+			hostResConflict: "terminate_old",
+			hubResources: []c2smodel.ResourceDesc{
+				c2smodel.NewResourceDesc("inst-2", jd0, nil, c2smodel.NewInfoMap()),
+			},
+			expectedOutput:        `<iq id='bind_2' type='result' from='ortuman@localhost' to='ortuman@localhost'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><jid>ortuman@localhost/yard</jid></bind></iq>`,
+			expectedState:         inBinded,
+			expectResourceUpdated: true,
+			expectDisconnected:    true,
+		},
+		{
+			name:  "Authenticated/BindMaxResources",
+			state: inAuthenticated,
+			flags: fSecured | fCompressed | fAuthenticated,
+			sessionResFn: func() (stravaganza.Element, error) {
+				iq, _ := stravaganza.NewIQBuilder().
+					WithAttribute(stravaganza.From, "ortuman@localhost").
+					WithAttribute(stravaganza.To, "ortuman@localhost").
+					WithAttribute(stravaganza.Type, stravaganza.SetType).
+					WithAttribute(stravaganza.ID, "bind_2").
+					WithChild(
+						stravaganza.NewBuilder("bind").
+							WithAttribute(stravaganza.Namespace, bindNamespace).
+							WithChild(
+								stravaganza.NewBuilder("resource").WithText("yard").Build(),
+							).
+							Build(),
+					).
+					BuildIQ()
+				return iq, nil
+			},
+			hostMaxResources: 1,
+			hubResources: []c2smodel.ResourceDesc{
+				c2smodel.NewResourceDesc("inst-2", jd1, nil, c2smodel.NewInfoMap()),
+			},
+			expectedOutput: `<iq from='ortuman@localhost' to='ortuman@localhost' type='error' id='bind_2'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><resource>yard</resource></bind><error code='500' type='wait'><resource-constraint xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></iq>`,
+			expectedState:  inAuthenticated,
+		},
+		{
+			name:  "Authenticated/BindMaxSessionsPerIP",
+			state: inAuthenticated,
+			flags: fSecured | fCompressed | fAuthenticated,
+			sessionResFn: func() (stravaganza.Element, error) {
+				iq, _ := stravaganza.NewIQBuilder().
+					WithAttribute(stravaganza.From, "ortuman@localhost").
+					WithAttribute(stravaganza.To, "ortuman@localhost").
+					WithAttribute(stravaganza.Type, stravaganza.SetType).
+					WithAttribute(stravaganza.ID, "bind_2").
+					WithChild(
+						stravaganza.NewBuilder("bind").
+							WithAttribute(stravaganza.Namespace, bindNamespace).
+							WithChild(
+								stravaganza.NewBuilder("resource").WithText("yard").Build(),
+							).
+							Build(),
+					).
+					BuildIQ()
+				return iq, nil
+			},
+			hostMaxIPSess:  2,
+			ipSessions:     2,
+			expectedOutput: `<iq from='ortuman@localhost' to='ortuman@localhost' type='error' id='bind_2'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><resource>yard</resource></bind><error code='500' type='wait'><resource-constraint xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></iq>`,
+			expectedState:  inAuthenticated,
+		},
 		{
 			name:  "Authenticated/BindMaxSessions",
 			state: inAuthenticated,
@@ -650,11 +785,20 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 			// hosts mock
 			hMock.IsLocalHostFunc = func(host string) bool { return host == "localhost" }
 			hMock.CertificatesFunc = func() []tls.Certificate { return nil }
+			hMock.ResourceConflictFunc = func(_ string) string { return tt.hostResConflict }
+			hMock.MaxResourcesFunc = func(_ string) int { return tt.hostMaxResources }
+			hMock.MaxSessionsPerIPFunc = func(_ string) int { return tt.hostMaxIPSess }
 
 			// router mocks
 			c2sRouterMock.BindFunc = func(id stream.C2SID) error { return nil }
 			c2sRouterMock.UnregisterFunc = func(stm stream.C2S) error { return nil }
 
+			var disconnected bool
+			c2sRouterMock.DisconnectFunc = func(_ context.Context, _ c2smodel.ResourceDesc, _ *streamerror.Error) error {
+				disconnected = true
+				return nil
+			}
+
 			routerMock.C2SFunc = func() router.C2SRouter {
 				return c2sRouterMock
 			}
@@ -719,6 +863,11 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 				return nil
 			}
 
+			ipSess := newIPSessions()
+			for i := 0; i < tt.ipSessions; i++ {
+				ipSess.acquire(ipSessionKey("localhost", "127.0.0.1"), 0)
+			}
+
 			userJID, _ := jid.NewWithString("ortuman@localhost", true)
 			stm := &inC2S{
 				cfg: inCfg{
@@ -742,10 +891,12 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 					authenticators: []auth.Authenticator{authMock},
 					active:         authMock,
 				},
-				session: ssMock,
-				resMng:  resMngMock,
-				hk:      hook.NewHooks(),
-				logger:  kitlog.NewNopLogger(),
+				session:  ssMock,
+				resMng:   resMngMock,
+				remoteIP: "127.0.0.1",
+				ipSess:   ipSess,
+				hk:       hook.NewHooks(),
+				logger:   kitlog.NewNopLogger(),
 			}
 			// when
 			stm.handleSessionResult(tt.sessionResFn())
@@ -755,6 +906,7 @@ func TestInC2S_HandleSessionElement(t *testing.T) {
 			require.Equal(t, tt.expectedState, stm.getState())
 			require.Equal(t, tt.expectRouted, routed)
 			require.Equal(t, tt.expectResourceUpdated, updatedRes)
+			require.Equal(t, tt.expectDisconnected, disconnected)
 		})
 	}
 }
@@ -781,6 +933,13 @@ func TestInC2S_HandleSessionError(t *testing.T) {
 			expectedOutput: ``,
 			expectClosed:   true,
 		},
+		{
+			name:           "HibernatedStream",
+			state:          inDisconnected,
+			sErr:           io.EOF,
+			expectedOutput: ``,
+			expectClosed:   false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -75,6 +75,9 @@ type globalC2SRouter interface {
 type hosts interface {
 	Certificates() []tls.Certificate
 	IsLocalHost(host string) bool
+	ResourceConflict(host string) string
+	MaxResources(host string) int
+	MaxSessionsPerIP(host string) int
 }
 
 //go:generate moq -out session.mock_test.go . session
@@ -82,6 +85,7 @@ type session interface {
 	SetFromJID(ssJID *jid.JID)
 
 	Send(ctx context.Context, element stravaganza.Element) error
+	SendWhitespace(ctx context.Context) error
 	Receive() (stravaganza.Element, error)
 
 	OpenStream(ctx context.Context) error
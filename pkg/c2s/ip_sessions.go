@@ -0,0 +1,60 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package c2s
+
+import "sync"
+
+// ipSessions keeps track of the number of bound sessions per host and remote IP address on this node.
+type ipSessions struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newIPSessions() *ipSessions {
+	return &ipSessions{counts: make(map[string]int)}
+}
+
+// acquire registers a new session for key, returning false in case maxSessions was already reached.
+func (s *ipSessions) acquire(key string, maxSessions int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maxSessions > 0 && s.counts[key] >= maxSessions {
+		return false
+	}
+	s.counts[key]++
+	return true
+}
+
+func (s *ipSessions) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts[key] <= 1 {
+		delete(s.counts, key)
+		return
+	}
+	s.counts[key]--
+}
+
+func (s *ipSessions) count(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[key]
+}
+
+func ipSessionKey(domain, ip string) string {
+	return domain + "/" + ip
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package c2s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPSessions_AcquireRelease(t *testing.T) {
+	// given
+	s := newIPSessions()
+	key := ipSessionKey("jackal.im", "10.0.0.1")
+
+	// when
+	ok0 := s.acquire(key, 2)
+	ok1 := s.acquire(key, 2)
+	ok2 := s.acquire(key, 2)
+	okOther := s.acquire(ipSessionKey("jackal.im", "10.0.0.2"), 2)
+
+	s.release(key)
+	ok3 := s.acquire(key, 2)
+
+	// then
+	require.True(t, ok0)
+	require.True(t, ok1)
+	require.False(t, ok2)
+	require.True(t, okOther)
+	require.True(t, ok3)
+	require.Equal(t, 2, s.count(key))
+
+	s.release(key)
+	s.release(key)
+	require.Equal(t, 0, s.count(key))
+}
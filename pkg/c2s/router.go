@@ -34,11 +34,22 @@ import (
 	"github.com/ortuman/jackal/pkg/storage/repository"
 )
 
+const (
+	highestPriorityBareJIDMessages = "highest_priority"
+	allBareJIDMessages             = "all"
+)
+
+const (
+	allTieBreak           = "all"
+	mostAvailableTieBreak = "most_available"
+)
+
 type c2sRouter struct {
 	local   localRouter
 	cluster clusterRouter
 	resMng  resourcemanager.Manager
 	rep     repository.Repository
+	cfg     RoutingConfig
 	hk      *hook.Hooks
 	logger  kitlog.Logger
 }
@@ -49,6 +60,7 @@ func NewRouter(
 	clusterRouter *clusterrouter.Router,
 	resMng resourcemanager.Manager,
 	rep repository.Repository,
+	cfg RoutingConfig,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) router.C2SRouter {
@@ -57,20 +69,32 @@ func NewRouter(
 		cluster: clusterRouter,
 		resMng:  resMng,
 		rep:     rep,
+		cfg:     cfg,
 		hk:      hk,
 		logger:  logger,
 	}
 }
 
 func (r *c2sRouter) Route(ctx context.Context, stanza stravaganza.Stanza, routingOpts router.RoutingOptions) (targets []jid.JID, err error) {
+	halted, err := r.hk.Run(hook.C2SRouterWillRouteStanza, &hook.ExecutionContext{
+		Info: &hook.C2SRouterInfo{
+			Stanza: stanza,
+		},
+		Sender:  r,
+		Context: ctx,
+	})
+	if halted || err != nil {
+		return nil, err
+	}
 	// apply validations
 	username := stanza.ToJID().Node()
 	if (routingOpts & router.CheckUserExistence) > 0 {
-		exists, err := r.rep.UserExists(ctx, username) // user exists?
+		usr, err := r.rep.FetchUser(ctx, username) // user exists?
 		if err != nil {
 			return nil, err
 		}
-		if !exists {
+		// suspended accounts don't accept any incoming stanza
+		if usr == nil || usr.Suspended {
 			return nil, router.ErrNotExistingAccount
 		}
 	}
@@ -125,6 +149,16 @@ func (r *c2sRouter) LocalStream(username, resource string) (stream.C2S, error) {
 }
 
 func (r *c2sRouter) Start(ctx context.Context) error {
+	switch r.cfg.BareJIDMessages {
+	case "", highestPriorityBareJIDMessages, allBareJIDMessages:
+	default:
+		return fmt.Errorf("c2s: unrecognized bare JID messages routing policy: %s", r.cfg.BareJIDMessages)
+	}
+	switch r.cfg.TieBreak {
+	case "", allTieBreak, mostAvailableTieBreak:
+	default:
+		return fmt.Errorf("c2s: unrecognized routing tie break: %s", r.cfg.TieBreak)
+	}
 	if err := r.cluster.Start(ctx); err != nil {
 		return err
 	}
@@ -160,25 +194,15 @@ func (r *c2sRouter) route(ctx context.Context, stanza stravaganza.Stanza, resour
 	}
 	switch stanza.(type) {
 	case *stravaganza.Message:
-		// route to highest priority resources
-		sort.Slice(resources, func(i, j int) bool {
-			return resources[i].Priority() > resources[j].Priority()
-		})
-		p0 := resources[0].Priority() // highest priority
-
-		var routed bool
-		for _, res := range resources {
-			if res.Priority() < 0 || res.Priority() != p0 {
-				break
-			}
+		msgTargets := r.messageTargets(resources)
+		if len(msgTargets) == 0 {
+			return nil, router.ErrUserNotAvailable
+		}
+		for _, res := range msgTargets {
 			if err := r.routeTo(ctx, stanza, res); err != nil {
 				return nil, err
 			}
 			targets = append(targets, *res.JID())
-			routed = true
-		}
-		if !routed {
-			return nil, router.ErrUserNotAvailable
 		}
 		return targets, nil
 	}
@@ -200,3 +224,59 @@ func (r *c2sRouter) routeTo(ctx context.Context, stanza stravaganza.Stanza, toRe
 	}
 	return r.cluster.Route(ctx, stanza, username, resource, toRes.InstanceID())
 }
+
+func (r *c2sRouter) messageTargets(resources []c2smodel.ResourceDesc) []c2smodel.ResourceDesc {
+	var eligible []c2smodel.ResourceDesc
+	for _, res := range resources {
+		if res.Priority() < 0 && !r.cfg.DeliverNegativePriority {
+			continue
+		}
+		eligible = append(eligible, res)
+	}
+	if len(eligible) == 0 || r.cfg.BareJIDMessages == allBareJIDMessages {
+		return eligible
+	}
+	// route to highest priority resources
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return eligible[i].Priority() > eligible[j].Priority()
+	})
+	p0 := eligible[0].Priority() // highest priority
+
+	var n int
+	for n < len(eligible) && eligible[n].Priority() == p0 {
+		n++
+	}
+	top := eligible[:n]
+	if r.cfg.TieBreak != mostAvailableTieBreak || len(top) == 1 {
+		return top
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return availabilityRank(top[i]) > availabilityRank(top[j])
+	})
+	r0 := availabilityRank(top[0])
+
+	n = 0
+	for n < len(top) && availabilityRank(top[n]) == r0 {
+		n++
+	}
+	return top[:n]
+}
+
+func availabilityRank(res c2smodel.ResourceDesc) int {
+	pr := res.Presence()
+	if pr == nil {
+		return 0
+	}
+	switch pr.ShowState() {
+	case stravaganza.ChatShowState:
+		return 4
+	case stravaganza.AvailableShowState:
+		return 3
+	case stravaganza.AwayShowState:
+		return 2
+	case stravaganza.ExtendedAwaysShowState:
+		return 1
+	default:
+		return 0
+	}
+}
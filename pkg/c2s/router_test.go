@@ -16,6 +16,7 @@ package c2s
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
 	"github.com/jackal-xmpp/stravaganza"
@@ -23,6 +24,7 @@ import (
 	"github.com/ortuman/jackal/pkg/cluster/instance"
 	"github.com/ortuman/jackal/pkg/hook"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/stretchr/testify/suite"
 )
@@ -52,8 +54,22 @@ func (s *routerSuite) SetupTest() {
 
 func (s *routerSuite) TestRouter_NotExistingAccount() {
 	// given
-	s.repositoryMock.UserExistsFunc = func(_ context.Context, _ string) (bool, error) {
-		return false, nil
+	s.repositoryMock.FetchUserFunc = func(_ context.Context, _ string) (*usermodel.User, error) {
+		return nil, nil
+	}
+
+	// when
+	msg := testMessageStanza()
+	_, err := s.router.Route(context.Background(), msg, router.CheckUserExistence)
+
+	// then
+	s.Require().Equal(router.ErrNotExistingAccount, err)
+}
+
+func (s *routerSuite) TestRouter_SuspendedAccount() {
+	// given
+	s.repositoryMock.FetchUserFunc = func(_ context.Context, username string) (*usermodel.User, error) {
+		return &usermodel.User{Username: username, Suspended: true}, nil
 	}
 
 	// when
@@ -157,6 +173,161 @@ func (s *routerSuite) TestRouter_ClusterRoute() {
 	s.Require().True(routed)
 }
 
+func (s *routerSuite) TestRouter_BareJIDMessageRouting() {
+	var tcs = map[string]struct {
+		cfg             RoutingConfig
+		resources       []c2smodel.ResourceDesc
+		expectedTargets []string
+		expectedErr     error
+	}{
+		"HighestPriority": {
+			resources: []c2smodel.ResourceDesc{
+				testShowResource(1, "balcony", ""),
+				testShowResource(1, "yard", "away"),
+				testShowResource(0, "garden", ""),
+			},
+			expectedTargets: []string{"balcony", "yard"},
+		},
+		"NegativePriority": {
+			resources: []c2smodel.ResourceDesc{
+				testShowResource(-1, "balcony", ""),
+			},
+			expectedErr: router.ErrUserNotAvailable,
+		},
+		"DeliverNegativePriority": {
+			cfg: RoutingConfig{DeliverNegativePriority: true},
+			resources: []c2smodel.ResourceDesc{
+				testShowResource(-1, "balcony", ""),
+				testShowResource(-2, "yard", ""),
+			},
+			expectedTargets: []string{"balcony"},
+		},
+		"AllResources": {
+			cfg: RoutingConfig{BareJIDMessages: allBareJIDMessages},
+			resources: []c2smodel.ResourceDesc{
+				testShowResource(1, "balcony", ""),
+				testShowResource(0, "yard", ""),
+				testShowResource(-1, "garden", ""),
+			},
+			expectedTargets: []string{"balcony", "yard"},
+		},
+		"MostAvailableTieBreak": {
+			cfg: RoutingConfig{TieBreak: mostAvailableTieBreak},
+			resources: []c2smodel.ResourceDesc{
+				testShowResource(1, "balcony", "away"),
+				testShowResource(1, "yard", "chat"),
+				testShowResource(1, "garden", "dnd"),
+				testShowResource(2, "hall", "xa"),
+			},
+			expectedTargets: []string{"hall"},
+		},
+		"MostAvailableTieBreakTied": {
+			cfg: RoutingConfig{TieBreak: mostAvailableTieBreak},
+			resources: []c2smodel.ResourceDesc{
+				testShowResource(1, "balcony", "away"),
+				testShowResource(1, "yard", ""),
+				testShowResource(1, "garden", ""),
+			},
+			expectedTargets: []string{"yard", "garden"},
+		},
+	}
+	for tName, tc := range tcs {
+		s.Run(tName, func() {
+			// given
+			s.SetupTest()
+			s.router.cfg = tc.cfg
+
+			s.resMngMock.GetResourcesFunc = func(_ context.Context, _ string) ([]c2smodel.ResourceDesc, error) {
+				return tc.resources, nil
+			}
+			var routedTo []string
+			s.localRouterMock.RouteFunc = func(_ stravaganza.Stanza, _ string, resource string) error {
+				routedTo = append(routedTo, resource)
+				return nil
+			}
+
+			// when
+			msg, _ := stravaganza.NewMessageBuilder().
+				WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+				WithAttribute(stravaganza.To, "ortuman@jackal.im").
+				BuildMessage()
+			targets, err := s.router.Route(context.Background(), msg, router.RoutingOptions(0))
+
+			// then
+			s.Require().Equal(tc.expectedErr, err)
+			s.Require().Equal(tc.expectedTargets, routedTo)
+			s.Require().Len(targets, len(tc.expectedTargets))
+		})
+	}
+}
+
 func TestC2SRouterSuite(t *testing.T) {
 	suite.Run(t, new(routerSuite))
 }
+
+func BenchmarkRouter_Route(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		to   string
+	}{
+		{name: "full_jid", to: "ortuman@jackal.im/balcony"},
+		{name: "bare_jid", to: "ortuman@jackal.im"},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			rss := []c2smodel.ResourceDesc{
+				testResource(instance.ID(), 1, "ortuman", "balcony"),
+				testResource(instance.ID(), 0, "ortuman", "yard"),
+			}
+			resMngMock := &resourceManagerMock{}
+			resMngMock.GetResourcesFunc = func(_ context.Context, _ string) ([]c2smodel.ResourceDesc, error) {
+				return rss, nil
+			}
+			localRouterMock := &localRouterMock{}
+			localRouterMock.RouteFunc = func(_ stravaganza.Stanza, _ string, _ string) error {
+				return nil
+			}
+			r := &c2sRouter{
+				local:  localRouterMock,
+				resMng: resMngMock,
+				hk:     hook.NewHooks(),
+			}
+			msg, _ := stravaganza.NewMessageBuilder().
+				WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+				WithAttribute(stravaganza.To, bm.to).
+				WithAttribute(stravaganza.Type, stravaganza.ChatType).
+				WithChild(
+					stravaganza.NewBuilder("body").
+						WithText("I'll give thee a wind.").
+						Build(),
+				).
+				BuildMessage()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.Route(context.Background(), msg, router.RoutingOptions(0)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func testShowResource(priority int8, resource, show string) c2smodel.ResourceDesc {
+	b := stravaganza.NewPresenceBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/"+resource).
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("priority").
+				WithText(strconv.Itoa(int(priority))).
+				Build(),
+		)
+	if len(show) > 0 {
+		b.WithChild(stravaganza.NewBuilder("show").WithText(show).Build())
+	}
+	pr, _ := b.BuildPresence()
+
+	jd, _ := jid.New("ortuman", "jackal.im", resource, true)
+	return c2smodel.NewResourceDesc(instance.ID(), jd, pr, c2smodel.NewInfoMap())
+}
@@ -74,6 +74,7 @@ type SocketListener struct {
 	hk      *hook.Hooks
 	logger  kitlog.Logger
 
+	ipSess        *ipSessions
 	tlsCfg        *tls.Config
 	connHandlerFn func(conn net.Conn)
 
@@ -96,6 +97,8 @@ func NewListeners(
 	logger kitlog.Logger,
 ) []*SocketListener {
 	var listeners []*SocketListener
+
+	ipSess := newIPSessions() // shared among all listeners
 	for _, lnCfg := range cfg {
 		ln := newSocketListener(
 			lnCfg,
@@ -107,6 +110,7 @@ func NewListeners(
 			rep,
 			peppers,
 			shapers,
+			ipSess,
 			hk,
 			logger,
 		)
@@ -125,6 +129,7 @@ func newSocketListener(
 	rep repository.Repository,
 	peppers *pepper.Keys,
 	shapers shaper.Shapers,
+	ipSess *ipSessions,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *SocketListener {
@@ -146,6 +151,7 @@ func newSocketListener(
 		rep:     rep,
 		peppers: peppers,
 		shapers: shapers,
+		ipSess:  ipSess,
 		hk:      hk,
 		logger:  logger,
 	}
@@ -153,6 +159,29 @@ func newSocketListener(
 	return ln
 }
 
+// Derive returns a new C2S listener sharing l settings and dependencies, bound to a different address.
+func (l *SocketListener) Derive(bindAddr string, port int, directTLS bool) *SocketListener {
+	cfg := l.cfg
+	cfg.BindAddr = bindAddr
+	cfg.Port = port
+	cfg.DirectTLS = directTLS
+
+	return newSocketListener(
+		cfg,
+		l.hosts,
+		l.router,
+		l.comps,
+		l.mods,
+		l.resMng,
+		l.rep,
+		l.peppers,
+		l.shapers,
+		l.ipSess,
+		l.hk,
+		l.logger,
+	)
+}
+
 // Start starts listening on a TCP network address to handle incoming C2S connections.
 func (l *SocketListener) Start(ctx context.Context) error {
 	if l.extAuth != nil {
@@ -173,8 +202,8 @@ func (l *SocketListener) Start(ctx context.Context) error {
 	}
 	if l.cfg.DirectTLS {
 		l.tlsCfg = &tls.Config{
-			Certificates: l.hosts.Certificates(),
-			MinVersion:   tls.VersionTLS12,
+			GetCertificate: l.hosts.GetCertificate, // hosts can be added at runtime
+			MinVersion:     tls.VersionTLS12,
 		}
 		ln = tls.NewListener(ln, l.tlsCfg)
 	}
@@ -216,9 +245,12 @@ func (l *SocketListener) Stop(ctx context.Context) error {
 
 func (l *SocketListener) handleConn(conn net.Conn) {
 	tr := transport.NewSocketTransport(conn, l.cfg.ConnectTimeout, l.cfg.KeepAliveTimeout)
+	remoteIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+
 	stm, err := newInC2S(
 		l.getInConfig(),
 		tr,
+		remoteIP,
 		l.getAuthenticators(tr),
 		l.hosts,
 		l.router,
@@ -226,6 +258,7 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 		l.mods,
 		l.resMng,
 		l.shapers,
+		l.ipSess,
 		l.hk,
 		l.logger,
 	)
@@ -273,6 +306,7 @@ func (l *SocketListener) getInConfig() inCfg {
 	return inCfg{
 		authenticateTimeout: l.cfg.AuthenticateTimeout,
 		reqTimeout:          l.cfg.RequestTimeout,
+		keepAliveTimeout:    l.cfg.KeepAliveTimeout,
 		maxStanzaSize:       l.cfg.MaxStanzaSize,
 		compressionLevel:    cmpLevelMap[l.cfg.CompressionLevel],
 		resConflict:         resConflictMap[l.cfg.ResourceConflict],
@@ -20,10 +20,14 @@ import (
 
 	kitlog "github.com/go-kit/log"
 	etcdkv "github.com/ortuman/jackal/pkg/cluster/kv/etcd"
+	rediskv "github.com/ortuman/jackal/pkg/cluster/kv/redis"
 	kvtypes "github.com/ortuman/jackal/pkg/cluster/kv/types"
 )
 
-const etcdKVType = "etcd"
+const (
+	etcdKVType  = "etcd"
+	redisKVType = "redis"
+)
 
 // KV represents a generic key-value store interface.
 type KV interface {
@@ -51,8 +55,9 @@ type KV interface {
 
 // Config defines cluster KV configuration.
 type Config struct {
-	Type string        `fig:"type"`
-	Etcd etcdkv.Config `fig:"etcd"`
+	Type  string         `fig:"type"`
+	Etcd  etcdkv.Config  `fig:"etcd"`
+	Redis rediskv.Config `fig:"redis"`
 }
 
 // New returns a new initialized KV instance.
@@ -61,6 +66,9 @@ func New(cfg Config, logger kitlog.Logger) (KV, error) {
 	case etcdKVType:
 		return etcdkv.New(cfg.Etcd, logger), nil
 
+	case redisKVType:
+		return rediskv.New(cfg.Redis, logger), nil
+
 	default:
 		return nil, fmt.Errorf("unrecognized cluster kv type: %s", cfg.Type)
 	}
@@ -0,0 +1,382 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rediskv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
+	kvtypes "github.com/ortuman/jackal/pkg/cluster/kv/types"
+)
+
+const (
+	redisKVType = "redis"
+
+	eventsChannel = "kv:events"
+
+	scanCount = 512
+
+	maxKeepAliveRetries = 10
+)
+
+// Config contains Redis key-value store configuration parameters.
+type Config struct {
+	Address      string        `fig:"address" default:"localhost:6379"`
+	Username     string        `fig:"username"`
+	Password     string        `fig:"password"`
+	DB           int           `fig:"db"`
+	KeyPrefix    string        `fig:"key_prefix" default:"jackal:"`
+	DialTimeout  time.Duration `fig:"dial_timeout" default:"3s"`
+	ReadTimeout  time.Duration `fig:"read_timeout" default:"5s"`
+	WriteTimeout time.Duration `fig:"write_timeout" default:"5s"`
+
+	// TTL defines how long keys stored by an instance survive after it stops refreshing them.
+	TTL time.Duration `fig:"ttl" default:"10s"`
+}
+
+type event struct {
+	Type kvtypes.WatchEventType `json:"type"`
+	Key  string                 `json:"key"`
+	Val  []byte                 `json:"val,omitempty"`
+}
+
+// KV represents a Redis key-value store implementation.
+//
+// Every key stored by an instance expires after the configured TTL unless refreshed, hence keys belonging
+// to a crashed instance are eventually removed. Changes are propagated to watchers by means of a pub/sub channel,
+// while expirations are observed through Redis keyspace notifications.
+type KV struct {
+	cfg    Config
+	cl     *redis.Client
+	logger kitlog.Logger
+
+	mu    sync.RWMutex
+	owned map[string]string
+
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	doneCh   chan struct{}
+}
+
+// New returns a new Redis key-value store instance.
+func New(cfg Config, logger kitlog.Logger) *KV {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &KV{
+		cfg:      cfg,
+		logger:   logger,
+		owned:    make(map[string]string),
+		ctx:      ctx,
+		cancelFn: cancel,
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// Put stores a new value associated to a given key.
+func (k *KV) Put(ctx context.Context, key string, value string) error {
+	if err := k.cl.Set(ctx, k.cfg.KeyPrefix+key, value, k.cfg.TTL).Err(); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	k.owned[key] = value
+	k.mu.Unlock()
+
+	return k.publish(ctx, event{Type: kvtypes.Put, Key: key, Val: []byte(value)})
+}
+
+// Get retrieves a value associated to a given key.
+func (k *KV) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := k.cl.Get(ctx, k.cfg.KeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return val, nil
+}
+
+// GetPrefix retrieves all values whose key matches prefix.
+func (k *KV) GetPrefix(ctx context.Context, prefix string) (map[string][]byte, error) {
+	var keys []string
+
+	match := escapePattern(k.cfg.KeyPrefix+prefix) + "*"
+	iter := k.cl.Scan(ctx, 0, match, scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	vs, err := k.cl.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vs {
+		s, ok := v.(string)
+		if !ok {
+			continue // expired in between
+		}
+		values[strings.TrimPrefix(keys[i], k.cfg.KeyPrefix)] = []byte(s)
+	}
+	return values, nil
+}
+
+// Del deletes a value associated to a given key.
+func (k *KV) Del(ctx context.Context, key string) error {
+	if err := k.cl.Del(ctx, k.cfg.KeyPrefix+key).Err(); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	delete(k.owned, key)
+	k.mu.Unlock()
+
+	return k.publish(ctx, event{Type: kvtypes.Del, Key: key})
+}
+
+// Watch watches on a key or prefix.
+func (k *KV) Watch(ctx context.Context, prefix string, withPrevVal bool) <-chan kvtypes.WatchResp {
+	wCh := make(chan kvtypes.WatchResp)
+
+	ps := k.cl.Subscribe(ctx, k.cfg.KeyPrefix+eventsChannel, expiredChannel(k.cfg.DB))
+	go func() {
+		defer close(wCh)
+		defer func() { _ = ps.Close() }()
+
+		// keep track of last known values to report them along with delete events
+		var lastVals map[string][]byte
+		if withPrevVal {
+			vs, err := k.GetPrefix(ctx, prefix)
+			if err != nil {
+				wCh <- kvtypes.WatchResp{Err: err}
+				return
+			}
+			lastVals = vs
+		}
+		msgCh := ps.Channel()
+		for {
+			select {
+			case msg, ok := <-msgCh:
+				if !ok {
+					return
+				}
+				ev, err := k.decodeEvent(msg)
+				if err != nil {
+					wCh <- kvtypes.WatchResp{Err: err}
+					continue
+				}
+				if ev == nil || !strings.HasPrefix(ev.Key, prefix) {
+					continue
+				}
+				wEv := kvtypes.WatchEvent{Type: ev.Type, Key: ev.Key, Val: ev.Val}
+				if lastVals != nil {
+					wEv.PrevVal = lastVals[ev.Key]
+					if ev.Type == kvtypes.Put {
+						lastVals[ev.Key] = ev.Val
+					} else {
+						delete(lastVals, ev.Key)
+					}
+				}
+				select {
+				case wCh <- kvtypes.WatchResp{Events: []kvtypes.WatchEvent{wEv}}:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return wCh
+}
+
+// Start initializes Redis key-value store.
+func (k *KV) Start(ctx context.Context) error {
+	if k.cfg.TTL <= 0 {
+		return fmt.Errorf("rediskv: ttl must be positive")
+	}
+	k.cl = redis.NewClient(&redis.Options{
+		Addr:         k.cfg.Address,
+		Username:     k.cfg.Username,
+		Password:     k.cfg.Password,
+		DB:           k.cfg.DB,
+		DialTimeout:  k.cfg.DialTimeout,
+		ReadTimeout:  k.cfg.ReadTimeout,
+		WriteTimeout: k.cfg.WriteTimeout,
+	})
+	if err := k.cl.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	// expired key events are required to notice crashed instances
+	if err := k.cl.ConfigSet(ctx, "notify-keyspace-events", "Ex").Err(); err != nil {
+		level.Warn(k.logger).Log("msg", "unable to enable keyspace notifications, make sure 'notify-keyspace-events' includes 'Ex'", "err", err)
+	}
+	go k.keepAlive()
+
+	level.Info(k.logger).Log("msg", "started kv store", "type", redisKVType)
+	return nil
+}
+
+// Stop releases Redis underlying connection.
+func (k *KV) Stop(ctx context.Context) error {
+	k.cancelFn()
+	<-k.doneCh
+
+	// release owned keys right away instead of waiting for them to expire
+	k.mu.Lock()
+	keys := make([]string, 0, len(k.owned))
+	for key := range k.owned {
+		keys = append(keys, key)
+	}
+	k.owned = make(map[string]string)
+	k.mu.Unlock()
+
+	for _, key := range keys {
+		if err := k.cl.Del(ctx, k.cfg.KeyPrefix+key).Err(); err != nil {
+			return err
+		}
+		if err := k.publish(ctx, event{Type: kvtypes.Del, Key: key}); err != nil {
+			return err
+		}
+	}
+	if err := k.cl.Close(); err != nil {
+		return err
+	}
+	level.Info(k.logger).Log("msg", "stopped kv store", "type", redisKVType)
+	return nil
+}
+
+func (k *KV) keepAlive() {
+	defer close(k.doneCh)
+
+	tc := time.NewTicker(k.cfg.TTL / 3)
+	defer tc.Stop()
+
+	var retries int
+	for {
+		select {
+		case <-tc.C:
+			if err := k.refreshOwnedKeys(); err != nil {
+				level.Warn(k.logger).Log("msg", "failed to refresh kv keys TTL", "err", err)
+
+				retries++
+				if retries == maxKeepAliveRetries {
+					level.Error(k.logger).Log("msg", "unable to refresh keys TTL: max retries reached", "max_retries", maxKeepAliveRetries)
+
+					// shutdown process to avoid split-brain scenario
+					shutdown()
+					return
+				}
+				continue
+			}
+			retries = 0
+
+		case <-k.ctx.Done():
+			return
+		}
+	}
+}
+
+func (k *KV) refreshOwnedKeys() error {
+	k.mu.RLock()
+	owned := make(map[string]string, len(k.owned))
+	for key, val := range k.owned {
+		owned[key] = val
+	}
+	k.mu.RUnlock()
+
+	if len(owned) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(k.ctx, k.cfg.TTL/3)
+	defer cancel()
+
+	cmds := make(map[string]*redis.BoolCmd, len(owned))
+	_, err := k.cl.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for key := range owned {
+			cmds[key] = p.PExpire(ctx, k.cfg.KeyPrefix+key, k.cfg.TTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for key, cmd := range cmds {
+		if cmd.Val() {
+			continue
+		}
+		// key expired meanwhile... store it again
+		if err := k.Put(ctx, key, owned[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KV) publish(ctx context.Context, ev event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return k.cl.Publish(ctx, k.cfg.KeyPrefix+eventsChannel, b).Err()
+}
+
+func (k *KV) decodeEvent(msg *redis.Message) (*event, error) {
+	if msg.Channel == expiredChannel(k.cfg.DB) {
+		if !strings.HasPrefix(msg.Payload, k.cfg.KeyPrefix) {
+			return nil, nil // not a kv key
+		}
+		return &event{Type: kvtypes.Del, Key: strings.TrimPrefix(msg.Payload, k.cfg.KeyPrefix)}, nil
+	}
+	var ev event
+	if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+func expiredChannel(db int) string {
+	return fmt.Sprintf("__keyevent@%d__:expired", db)
+}
+
+func escapePattern(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func shutdown() {
+	p, _ := os.FindProcess(os.Getpid())
+	_ = p.Signal(os.Interrupt)
+}
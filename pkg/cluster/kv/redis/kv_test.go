@@ -0,0 +1,56 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rediskv
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	kvtypes "github.com/ortuman/jackal/pkg/cluster/kv/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKV_DecodeEvent(t *testing.T) {
+	// given
+	k := New(Config{KeyPrefix: "jackal:"}, nil)
+
+	// when
+	ev1, err1 := k.decodeEvent(&redis.Message{
+		Channel: "jackal:kv:events",
+		Payload: `{"type":0,"key":"r://ortuman@yard/i1","val":"dmFs"}`,
+	})
+	ev2, err2 := k.decodeEvent(&redis.Message{
+		Channel: "__keyevent@0__:expired",
+		Payload: "jackal:i://i1",
+	})
+	ev3, err3 := k.decodeEvent(&redis.Message{
+		Channel: "__keyevent@0__:expired",
+		Payload: "session:1234",
+	})
+
+	// then
+	require.NoError(t, err1)
+	require.Equal(t, &event{Type: kvtypes.Put, Key: "r://ortuman@yard/i1", Val: []byte("val")}, ev1)
+
+	require.NoError(t, err2)
+	require.Equal(t, &event{Type: kvtypes.Del, Key: "i://i1"}, ev2)
+
+	require.NoError(t, err3)
+	require.Nil(t, ev3)
+}
+
+func TestKV_EscapePattern(t *testing.T) {
+	require.Equal(t, `jackal:r://user\[1\]\*\?`, escapePattern("jackal:r://user[1]*?"))
+}
@@ -73,6 +73,17 @@ func (r *kvResources) getAll(username string) []c2smodel.ResourceDesc {
 	return retVal
 }
 
+func (r *kvResources) all() []c2smodel.ResourceDesc {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var retVal []c2smodel.ResourceDesc
+	for _, rss := range r.store {
+		retVal = append(retVal, rss...)
+	}
+	return retVal
+}
+
 func (r *kvResources) put(res c2smodel.ResourceDesc) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -182,6 +193,17 @@ func (m *kvManager) GetResources(_ context.Context, username string) ([]c2smodel
 	return retVal, nil
 }
 
+func (m *kvManager) GetAllResources(_ context.Context) ([]c2smodel.ResourceDesc, error) {
+	m.instResMu.RLock()
+	defer m.instResMu.RUnlock()
+
+	var retVal []c2smodel.ResourceDesc
+	for _, kvr := range m.instRes {
+		retVal = append(retVal, kvr.all()...)
+	}
+	return retVal, nil
+}
+
 func (m *kvManager) DelResource(ctx context.Context, username, resource string) error {
 	rKey := resourceKey(username, resource)
 
@@ -31,6 +31,9 @@ type Manager interface {
 	// GetResources returns all user registered resources.
 	GetResources(_ context.Context, username string) ([]c2smodel.ResourceDesc, error)
 
+	// GetAllResources returns all registered resources across the cluster.
+	GetAllResources(ctx context.Context) ([]c2smodel.ResourceDesc, error)
+
 	// DelResource removes a registered resource from the manager.
 	DelResource(ctx context.Context, username, resource string) error
 
@@ -300,6 +300,14 @@ func (s *inComponent) handleHandshaking(ctx context.Context, elem stravaganza.El
 		return err
 	}
 	s.setState(authenticated)
+
+	_, err := s.runHook(ctx, hook.ExternalComponentAuthenticated, &hook.ExternalComponentInfo{
+		ID:   s.id.String(),
+		Host: s.getJID().Domain(),
+	})
+	if err != nil {
+		return err
+	}
 	return s.sendElement(ctx, stravaganza.NewBuilder("handshake").Build())
 }
 
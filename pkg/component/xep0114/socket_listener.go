@@ -112,6 +112,25 @@ func newSocketListener(
 	return ln
 }
 
+// Derive returns a new component listener sharing l settings and dependencies, bound to a different address.
+func (l *SocketListener) Derive(bindAddr string, port int) *SocketListener {
+	cfg := l.cfg
+	cfg.BindAddr = bindAddr
+	cfg.Port = port
+
+	return newSocketListener(
+		cfg,
+		l.secretKey,
+		l.hosts,
+		l.comps,
+		l.extCompMng,
+		l.router,
+		l.shapers,
+		l.hk,
+		l.logger,
+	)
+}
+
 // Start starts listening on the TCP network address bindAddr to handle incoming connections.
 func (l *SocketListener) Start(ctx context.Context) error {
 	l.stmHub.start()
@@ -0,0 +1,428 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorstats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/module"
+)
+
+const (
+	// MaxWindow is the longest time window for which error sources can be queried.
+	MaxWindow = time.Hour
+
+	bucketSize  = time.Minute
+	bucketCount = int(2 * MaxWindow / bucketSize)
+
+	relayedTTL     = time.Minute
+	maxRelayedKeys = 10000
+)
+
+const (
+	// RemoteOrigin identifies errors received from a remote server.
+	RemoteOrigin = "remote"
+
+	// ClientOrigin identifies errors sent by a local client.
+	ClientOrigin = "client"
+
+	// ComponentOrigin identifies errors sent by an external component.
+	ComponentOrigin = "component"
+
+	// C2SOrigin identifies errors generated by the server and delivered to a local client.
+	C2SOrigin = "c2s"
+
+	// S2SOrigin identifies errors generated by the server and delivered to a remote server.
+	S2SOrigin = "s2s"
+)
+
+const stanzaErrorNamespace = "urn:ietf:params:xml:ns:xmpp-stanzas"
+
+// stanzaErrorConditions contains all stanza error conditions defined in RFC 6120 (section 8.3.3).
+var stanzaErrorConditions = map[string]struct{}{
+	"bad-request":             {},
+	"conflict":                {},
+	"feature-not-implemented": {},
+	"forbidden":               {},
+	"gone":                    {},
+	"internal-server-error":   {},
+	"item-not-found":          {},
+	"jid-malformed":           {},
+	"not-acceptable":          {},
+	"not-allowed":             {},
+	"not-authorized":          {},
+	"policy-violation":        {},
+	"recipient-unavailable":   {},
+	"redirect":                {},
+	"registration-required":   {},
+	"remote-server-not-found": {},
+	"remote-server-timeout":   {},
+	"resource-constraint":     {},
+	"service-unavailable":     {},
+	"subscription-required":   {},
+	"undefined-condition":     {},
+	"unexpected-request":      {},
+}
+
+// Source identifies a class of error stanzas.
+type Source struct {
+	// Condition is the defined error condition (e.g. remote-server-not-found).
+	Condition string
+
+	// Origin is the name of the module that generated the error, or one of the generic origins
+	// (remote, client, component, c2s, s2s).
+	Origin string
+
+	// Peer is the remote domain involved in the erroring exchange, if any.
+	Peer string
+}
+
+// SourceStats contains error counters of a single source.
+type SourceStats struct {
+	Source
+
+	// Count is the number of errors within the requested window.
+	Count uint64
+
+	// PreviousCount is the number of errors within the window immediately preceding the requested one.
+	PreviousCount uint64
+}
+
+type bucket struct {
+	slot   int64
+	counts map[Source]uint64
+}
+
+type relayKey struct {
+	name, id, from string
+}
+
+// Tracker counts error stanzas flowing through the server by condition, origin and peer domain.
+//
+// Errors received from clients, components or remote servers are counted once on arrival.
+// Any other error seen on its way out of the server is considered to be generated locally.
+type Tracker struct {
+	hosts  hosts
+	mods   modules
+	hk     *hook.Hooks
+	logger kitlog.Logger
+	nowFn  func() time.Time
+
+	mu      sync.Mutex
+	buckets [bucketCount]bucket
+	relayed map[relayKey]time.Time
+}
+
+// New returns a new initialized Tracker instance.
+func New(hosts hosts, mods modules, hk *hook.Hooks, logger kitlog.Logger) *Tracker {
+	return &Tracker{
+		hosts:   hosts,
+		mods:    mods,
+		hk:      hk,
+		logger:  kitlog.With(logger, "component", "errorstats"),
+		nowFn:   time.Now,
+		relayed: make(map[relayKey]time.Time),
+	}
+}
+
+// Start starts tracking error stanzas.
+func (t *Tracker) Start(_ context.Context) error {
+	t.hk.AddHook(hook.C2SStreamElementReceived, t.onClientElement, hook.LowestPriority)
+	t.hk.AddHook(hook.S2SInStreamElementReceived, t.onRemoteElement, hook.LowestPriority)
+	t.hk.AddHook(hook.ExternalComponentElementReceived, t.onComponentElement, hook.LowestPriority)
+	t.hk.AddHook(hook.C2SStreamElementSent, t.onC2SElementSent, hook.LowestPriority)
+	t.hk.AddHook(hook.S2SOutStreamElementSent, t.onS2SElementSent, hook.LowestPriority)
+
+	level.Info(t.logger).Log("msg", "started error stanza tracker")
+	return nil
+}
+
+// Stop stops tracking error stanzas.
+func (t *Tracker) Stop(_ context.Context) error {
+	t.hk.RemoveHook(hook.C2SStreamElementReceived, t.onClientElement)
+	t.hk.RemoveHook(hook.S2SInStreamElementReceived, t.onRemoteElement)
+	t.hk.RemoveHook(hook.ExternalComponentElementReceived, t.onComponentElement)
+	t.hk.RemoveHook(hook.C2SStreamElementSent, t.onC2SElementSent)
+	t.hk.RemoveHook(hook.S2SOutStreamElementSent, t.onS2SElementSent)
+
+	level.Info(t.logger).Log("msg", "stopped error stanza tracker")
+	return nil
+}
+
+// TopSources returns the limit sources with the highest number of errors within the last window period,
+// along with the total number of errors within that period.
+func (t *Tracker) TopSources(window time.Duration, limit int) ([]SourceStats, uint64) {
+	if window > MaxWindow {
+		window = MaxWindow
+	}
+	slots := int64(window / bucketSize)
+	if slots < 1 {
+		slots = 1
+	}
+	current := t.slot(t.nowFn())
+
+	t.mu.Lock()
+	stats := make(map[Source]*SourceStats)
+	var total uint64
+	for i := range t.buckets {
+		b := &t.buckets[i]
+		if b.counts == nil {
+			continue
+		}
+		age := current - b.slot
+		switch {
+		case age >= 0 && age < slots:
+			for src, cnt := range b.counts {
+				st := sourceStats(stats, src)
+				st.Count += cnt
+				total += cnt
+			}
+		case age >= slots && age < 2*slots:
+			for src, cnt := range b.counts {
+				sourceStats(stats, src).PreviousCount += cnt
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	var ret []SourceStats
+	for _, st := range stats {
+		if st.Count == 0 {
+			continue
+		}
+		ret = append(ret, *st)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		if ret[i].Condition != ret[j].Condition {
+			return ret[i].Condition < ret[j].Condition
+		}
+		if ret[i].Origin != ret[j].Origin {
+			return ret[i].Origin < ret[j].Origin
+		}
+		return ret[i].Peer < ret[j].Peer
+	})
+	if limit > 0 && len(ret) > limit {
+		ret = ret[:limit]
+	}
+	return ret, total
+}
+
+func (t *Tracker) onClientElement(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+	t.trackReceived(inf.Element, ClientOrigin)
+	return nil
+}
+
+func (t *Tracker) onRemoteElement(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.S2SStreamInfo)
+	t.trackReceived(inf.Element, RemoteOrigin)
+	return nil
+}
+
+func (t *Tracker) onComponentElement(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+	t.trackReceived(inf.Element, ComponentOrigin)
+	return nil
+}
+
+func (t *Tracker) onC2SElementSent(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+	t.trackSent(inf.Element, C2SOrigin)
+	return nil
+}
+
+func (t *Tracker) onS2SElementSent(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.S2SStreamInfo)
+	t.trackSent(inf.Element, S2SOrigin)
+	return nil
+}
+
+func (t *Tracker) trackReceived(elem stravaganza.Element, origin string) {
+	if !isErrorStanza(elem) {
+		return
+	}
+	now := t.nowFn()
+
+	t.mu.Lock()
+	t.markRelayed(keyOf(elem), now)
+	t.mu.Unlock()
+
+	t.track(Source{
+		Condition: errorCondition(elem),
+		Origin:    origin,
+		Peer:      t.peerDomain(elem),
+	}, now)
+}
+
+func (t *Tracker) trackSent(elem stravaganza.Element, origin string) {
+	if !isErrorStanza(elem) {
+		return
+	}
+	now := t.nowFn()
+
+	// relayed errors have already been counted on arrival
+	t.mu.Lock()
+	exp, ok := t.relayed[keyOf(elem)]
+	t.mu.Unlock()
+	if ok && now.Before(exp) {
+		return
+	}
+	if modName := t.moduleName(elem); len(modName) > 0 {
+		origin = modName
+	}
+	t.track(Source{
+		Condition: errorCondition(elem),
+		Origin:    origin,
+		Peer:      t.peerDomain(elem),
+	}, now)
+}
+
+func (t *Tracker) track(src Source, now time.Time) {
+	reportError(src)
+
+	slot := t.slot(now)
+	idx := int(slot % int64(bucketCount))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := &t.buckets[idx]
+	if b.counts == nil || b.slot != slot {
+		b.slot = slot
+		b.counts = make(map[Source]uint64)
+	}
+	b.counts[src]++
+}
+
+func (t *Tracker) markRelayed(k relayKey, now time.Time) {
+	if len(t.relayed) >= maxRelayedKeys {
+		for rk, exp := range t.relayed {
+			if !now.Before(exp) {
+				delete(t.relayed, rk)
+			}
+		}
+		if len(t.relayed) >= maxRelayedKeys {
+			t.relayed = make(map[relayKey]time.Time)
+		}
+	}
+	t.relayed[k] = now.Add(relayedTTL)
+}
+
+// moduleName returns the name of the module whose namespace matches the payload of an iq error.
+func (t *Tracker) moduleName(elem stravaganza.Element) string {
+	if elem.Name() != stravaganza.IQName {
+		return ""
+	}
+	var ns string
+	for _, child := range elem.AllChildren() {
+		if child.Name() == "error" {
+			continue
+		}
+		ns = child.Attribute(stravaganza.Namespace)
+		break
+	}
+	if len(ns) == 0 {
+		return ""
+	}
+	fromJID, _ := jid.NewWithString(elem.Attribute(stravaganza.From), true)
+	serverTarget := fromJID != nil && fromJID.IsServer()
+
+	for _, mod := range t.mods.AllModules() {
+		iqPr, ok := mod.(module.IQProcessor)
+		if !ok {
+			continue
+		}
+		if iqPr.MatchesNamespace(ns, serverTarget) {
+			return iqPr.Name()
+		}
+	}
+	return ""
+}
+
+// peerDomain returns the first non local domain among stanza sender and recipient.
+func (t *Tracker) peerDomain(elem stravaganza.Element) string {
+	for _, attr := range []string{stravaganza.From, stravaganza.To} {
+		j, _ := jid.NewWithString(elem.Attribute(attr), true)
+		if j == nil || len(j.Domain()) == 0 {
+			continue
+		}
+		if !t.hosts.IsLocalHost(j.Domain()) {
+			return j.Domain()
+		}
+	}
+	return ""
+}
+
+func (t *Tracker) slot(tm time.Time) int64 {
+	return tm.UnixNano() / int64(bucketSize)
+}
+
+func sourceStats(m map[Source]*SourceStats, src Source) *SourceStats {
+	st, ok := m[src]
+	if !ok {
+		st = &SourceStats{Source: src}
+		m[src] = st
+	}
+	return st
+}
+
+func isErrorStanza(elem stravaganza.Element) bool {
+	if elem == nil || elem.Attribute(stravaganza.Type) != "error" {
+		return false
+	}
+	switch elem.Name() {
+	case stravaganza.IQName, stravaganza.MessageName, stravaganza.PresenceName:
+		return true
+	default:
+		return false
+	}
+}
+
+func errorCondition(elem stravaganza.Element) string {
+	errElem := elem.Child("error")
+	if errElem == nil {
+		return "undefined-condition"
+	}
+	for _, child := range errElem.AllChildren() {
+		if child.Name() == "text" || child.Attribute(stravaganza.Namespace) != stanzaErrorNamespace {
+			continue
+		}
+		// keep condition cardinality bounded, whatever peers send
+		if _, ok := stanzaErrorConditions[child.Name()]; !ok {
+			break
+		}
+		return child.Name()
+	}
+	return "undefined-condition"
+}
+
+func keyOf(elem stravaganza.Element) relayKey {
+	return relayKey{
+		name: elem.Name(),
+		id:   elem.Attribute(stravaganza.ID),
+		from: elem.Attribute(stravaganza.From),
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorstats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/module"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_GeneratedErrors(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 10, 12, 00, 00, 00, time.UTC)
+	hk := hook.NewHooks()
+	tr := testTracker(hk, now)
+	require.NoError(t, tr.Start(context.Background()))
+
+	iq := testIQ(t, "ortuman@jackal.im/yard", "jackal.im", "jabber:iq:version")
+	msg := testMessage(t, "ortuman@jackal.im/yard", "noelia@jabber.org")
+
+	// when
+	runHook(t, hk, hook.C2SStreamElementSent, &hook.C2SStreamInfo{
+		Element: stanzaerror.E(stanzaerror.FeatureNotImplemented, iq).Element(),
+	})
+	for i := 0; i < 3; i++ {
+		runHook(t, hk, hook.C2SStreamElementSent, &hook.C2SStreamInfo{
+			Element: stanzaerror.E(stanzaerror.RemoteServerNotFound, msg).Element(),
+		})
+	}
+	runHook(t, hk, hook.S2SOutStreamElementSent, &hook.S2SStreamInfo{
+		Element: stanzaerror.E(stanzaerror.ServiceUnavailable, testMessage(t, "noelia@jabber.org/chamber", "ortuman@jackal.im")).Element(),
+	})
+	runHook(t, hk, hook.C2SStreamElementSent, &hook.C2SStreamInfo{Element: msg}) // not an error
+
+	// then
+	sources, total := tr.TopSources(5*time.Minute, 10)
+	require.Equal(t, uint64(5), total)
+	require.Equal(t, []SourceStats{
+		{Source: Source{Condition: "remote-server-not-found", Origin: C2SOrigin, Peer: "jabber.org"}, Count: 3},
+		{Source: Source{Condition: "feature-not-implemented", Origin: "version", Peer: ""}, Count: 1},
+		{Source: Source{Condition: "service-unavailable", Origin: S2SOrigin, Peer: "jabber.org"}, Count: 1},
+	}, sources)
+
+	sources, _ = tr.TopSources(5*time.Minute, 1)
+	require.Len(t, sources, 1)
+}
+
+func TestTracker_RelayedErrors(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 10, 12, 00, 00, 00, time.UTC)
+	hk := hook.NewHooks()
+	tr := testTracker(hk, now)
+	require.NoError(t, tr.Start(context.Background()))
+
+	errElem := stanzaerror.E(stanzaerror.ItemNotFound, testMessage(t, "ortuman@jackal.im/yard", "noelia@jabber.org")).Element()
+
+	// when
+	runHook(t, hk, hook.S2SInStreamElementReceived, &hook.S2SStreamInfo{Sender: "jabber.org", Element: errElem})
+	runHook(t, hk, hook.C2SStreamElementSent, &hook.C2SStreamInfo{Element: errElem})
+
+	// then
+	sources, total := tr.TopSources(time.Minute, 0)
+	require.Equal(t, uint64(1), total)
+	require.Equal(t, []SourceStats{
+		{Source: Source{Condition: "item-not-found", Origin: RemoteOrigin, Peer: "jabber.org"}, Count: 1},
+	}, sources)
+
+	// relayed errors are forgotten once the TTL expires
+	tr.nowFn = func() time.Time { return now.Add(relayedTTL) }
+	runHook(t, hk, hook.C2SStreamElementSent, &hook.C2SStreamInfo{Element: errElem})
+
+	_, total = tr.TopSources(5*time.Minute, 0)
+	require.Equal(t, uint64(2), total)
+}
+
+func TestTracker_Window(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 10, 12, 00, 00, 00, time.UTC)
+	hk := hook.NewHooks()
+	tr := testTracker(hk, now)
+	require.NoError(t, tr.Start(context.Background()))
+
+	errElem := stanzaerror.E(stanzaerror.RemoteServerTimeout, testMessage(t, "ortuman@jackal.im/yard", "noelia@jabber.org")).Element()
+
+	sendAt := func(tm time.Time, n int) {
+		tr.nowFn = func() time.Time { return tm }
+		for i := 0; i < n; i++ {
+			runHook(t, hk, hook.C2SStreamElementSent, &hook.C2SStreamInfo{Element: errElem})
+		}
+	}
+	sendAt(now.Add(-3*time.Hour), 7) // outside of any window
+	sendAt(now.Add(-8*time.Minute), 2)
+	sendAt(now.Add(-2*time.Minute), 5)
+	sendAt(now, 1)
+
+	// when
+	sources, total := tr.TopSources(5*time.Minute, 0)
+
+	// then
+	require.Equal(t, uint64(6), total)
+	require.Len(t, sources, 1)
+	require.Equal(t, uint64(6), sources[0].Count)
+	require.Equal(t, uint64(2), sources[0].PreviousCount)
+
+	// stopped trackers do not count anymore
+	require.NoError(t, tr.Stop(context.Background()))
+	sendAt(now, 1)
+
+	_, total = tr.TopSources(5*time.Minute, 0)
+	require.Equal(t, uint64(6), total)
+}
+
+func TestTracker_UnknownCondition(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 10, 12, 00, 00, 00, time.UTC)
+	hk := hook.NewHooks()
+	tr := testTracker(hk, now)
+	require.NoError(t, tr.Start(context.Background()))
+
+	msg := testMessage(t, "noelia@jabber.org/chamber", "ortuman@jackal.im/yard")
+	errElem := stravaganza.NewBuilderFromElement(msg).
+		WithAttribute(stravaganza.Type, "error").
+		WithChild(
+			stravaganza.NewBuilder("error").
+				WithAttribute(stravaganza.Type, "cancel").
+				WithChild(
+					stravaganza.NewBuilder("made-up-condition").
+						WithAttribute(stravaganza.Namespace, stanzaErrorNamespace).
+						Build(),
+				).
+				Build(),
+		).
+		Build()
+
+	// when
+	runHook(t, hk, hook.S2SInStreamElementReceived, &hook.S2SStreamInfo{Sender: "jabber.org", Element: errElem})
+
+	// then
+	sources, _ := tr.TopSources(time.Minute, 0)
+	require.Equal(t, []SourceStats{
+		{Source: Source{Condition: "undefined-condition", Origin: RemoteOrigin, Peer: "jabber.org"}, Count: 1},
+	}, sources)
+}
+
+func testTracker(hk *hook.Hooks, now time.Time) *Tracker {
+	hostsMock := &hostsMock{
+		IsLocalHostFunc: func(h string) bool { return h == "jackal.im" },
+	}
+	modsMock := &modulesMock{
+		AllModulesFunc: func() []module.Module {
+			return []module.Module{&iqProcessorMock{
+				NameFunc: func() string { return "version" },
+				MatchesNamespaceFunc: func(namespace string, serverTarget bool) bool {
+					return namespace == "jabber:iq:version" && serverTarget
+				},
+			}}
+		},
+	}
+	tr := New(hostsMock, modsMock, hk, kitlog.NewNopLogger())
+	tr.nowFn = func() time.Time { return now }
+	return tr
+}
+
+func runHook(t *testing.T, hk *hook.Hooks, hookName string, inf interface{}) {
+	t.Helper()
+	_, err := hk.Run(hookName, &hook.ExecutionContext{Info: inf, Context: context.Background()})
+	require.NoError(t, err)
+}
+
+func testIQ(t *testing.T, from, to, ns string) *stravaganza.IQ {
+	t.Helper()
+	iq, err := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "iq-1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, to).
+		WithChild(stravaganza.NewBuilder("query").WithAttribute(stravaganza.Namespace, ns).Build()).
+		BuildIQ()
+	require.NoError(t, err)
+	return iq
+}
+
+func testMessage(t *testing.T, from, to string) *stravaganza.Message {
+	t.Helper()
+	msg, err := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, "msg-1").
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, to).
+		WithChild(stravaganza.NewBuilder("body").WithText("hi").Build()).
+		BuildMessage()
+	require.NoError(t, err)
+	return msg
+}
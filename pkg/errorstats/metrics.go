@@ -0,0 +1,42 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorstats
+
+import (
+	"github.com/ortuman/jackal/pkg/cluster/instance"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var stanzaErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "jackal",
+		Subsystem: "stanza",
+		Name:      "errors_total",
+		Help:      "The total number of error stanzas handled by the server.",
+	},
+	[]string{"instance", "condition", "origin"},
+)
+
+func init() {
+	prometheus.MustRegister(stanzaErrors)
+}
+
+func reportError(src Source) {
+	stanzaErrors.With(prometheus.Labels{
+		"instance":  instance.ID(),
+		"condition": src.Condition,
+		"origin":    src.Origin,
+	}).Inc()
+}
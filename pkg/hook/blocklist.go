@@ -27,6 +27,9 @@ const (
 
 	// BlockListItemsUnblocked hook runs when one or more JIDs are unblocked.
 	BlockListItemsUnblocked = "blocklist.items.unblocked"
+
+	// BlockListItemsReported hook runs when one or more blocked JIDs are reported as spam or abuse (XEP-0377).
+	BlockListItemsReported = "blocklist.items.reported"
 )
 
 // BlockListInfo contains all information associated to a blocklist event.
@@ -36,4 +39,19 @@ type BlockListInfo struct {
 
 	// JIDs contains all JIDs associated to this event.
 	JIDs []jid.JID
+
+	// Reports contains the spam or abuse reports filed along with the blocked JIDs.
+	Reports []BlockListReport
+}
+
+// BlockListReport represents a spam or abuse report (XEP-0377) filed against a blocked JID.
+type BlockListReport struct {
+	// JID is the reported JID.
+	JID jid.JID
+
+	// Reason is the report reason (e.g. urn:xmpp:reporting:spam).
+	Reason string
+
+	// Text is the optional report description provided by the user.
+	Text string
 }
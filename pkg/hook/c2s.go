@@ -56,8 +56,14 @@ const (
 	// C2SStreamMessageRouted hook runs when a message stanza is successfully routed to zero or more C2S streams.
 	C2SStreamMessageRouted = "c2s.stream.message_routed"
 
+	// C2SStreamWillSendElement hook runs when an XMPP element is about to be sent over a C2S stream.
+	C2SStreamWillSendElement = "c2s.stream.will_send_element"
+
 	// C2SStreamElementSent hook runs when an XMPP element is sent over a C2S stream.
 	C2SStreamElementSent = "c2s.stream.element_sent"
+
+	// C2SRouterWillRouteStanza hook runs when a stanza is about to be routed to a local domain user.
+	C2SRouterWillRouteStanza = "c2s.router.will_route_stanza"
 )
 
 // C2SStreamInfo contains all info associated to a C2S stream event.
@@ -80,3 +86,9 @@ type C2SStreamInfo struct {
 	// DisconnectError contains the original error that caused stream disconnection.
 	DisconnectError error
 }
+
+// C2SRouterInfo contains all info associated to a C2S router event.
+type C2SRouterInfo struct {
+	// Stanza is the event associated XMPP stanza.
+	Stanza stravaganza.Stanza
+}
@@ -20,6 +20,9 @@ const (
 	// ExternalComponentRegistered hook runs when a external component connection is registered.
 	ExternalComponentRegistered = "ext_component.stream.registered"
 
+	// ExternalComponentAuthenticated hook runs when a external component successfully completes the handshake.
+	ExternalComponentAuthenticated = "ext_component.stream.authenticated"
+
 	// ExternalComponentUnregistered hook runs when a external component connection is unregistered.
 	ExternalComponentUnregistered = "ext_component.stream.unregistered"
 
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"sort"
 	"sync"
+	"time"
 )
 
 // Priority defines hook execution priority.
@@ -59,6 +60,7 @@ type ExecutionContext struct {
 type handler struct {
 	h Handler
 	p Priority
+	m handlerMetrics
 }
 
 // Hooks represents a set of module hook handlers.
@@ -82,7 +84,7 @@ func (h *Hooks) AddHook(hook string, hnd Handler, priority Priority) {
 
 	handlers := h.handlers[hook]
 	handlers = append(handlers, handler{
-		h: hnd, p: priority,
+		h: hnd, p: priority, m: newHandlerMetrics(hook, hnd),
 	})
 	// sort by priority
 	sort.Slice(handlers, func(i, j int) bool { return handlers[i].p > handlers[j].p })
@@ -114,13 +116,17 @@ func (h *Hooks) Run(hook string, execCtx *ExecutionContext) (halted bool, err er
 
 	handlers := h.handlers[hook]
 	for _, handler := range handlers {
+		t0 := time.Now()
 		err := handler.h(execCtx)
+		handler.m.duration.Observe(time.Since(t0).Seconds())
+
 		switch {
 		case err == nil:
 			break
 		case errors.Is(err, ErrStopped):
 			return true, nil
 		default:
+			handler.m.errors.Inc()
 			return false, err
 		}
 	}
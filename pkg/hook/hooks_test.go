@@ -15,8 +15,13 @@
 package hook
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/ortuman/jackal/pkg/cluster/instance"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -103,3 +108,47 @@ func TestHooks_HaltedRun(t *testing.T) {
 
 	require.Equal(t, 2, i)
 }
+
+type testModule struct{}
+
+func (m *testModule) onEvent(_ *ExecutionContext) error { return errors.New("foo") }
+
+func TestHooks_HandlerMetrics(t *testing.T) {
+	// given
+	h := NewHooks()
+	m := &testModule{}
+
+	h.AddHook("h1", m.onEvent, DefaultPriority)
+
+	// when
+	_, err := h.Run("h1", &ExecutionContext{Context: context.Background()})
+
+	// then
+	require.Error(t, err)
+
+	mod, name := handlerOwner(m.onEvent)
+	require.Equal(t, "hook", mod)
+	require.Equal(t, "testModule.onEvent", name)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(hookHandlerErrors.WithLabelValues(instance.ID(), "h1", mod, name)))
+}
+
+func BenchmarkHooks_Run(b *testing.B) {
+	for _, n := range []int{1, 10} {
+		b.Run(fmt.Sprintf("handlers=%d", n), func(b *testing.B) {
+			h := NewHooks()
+			for i := 0; i < n; i++ {
+				h.AddHook("h1", func(execCtx *ExecutionContext) error { return nil }, DefaultPriority)
+			}
+			execCtx := &ExecutionContext{Context: context.Background()}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.Run("h1", execCtx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@
 package hook
 
 import (
+	"time"
+
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 )
 
@@ -24,6 +26,9 @@ const (
 
 	// ArchiveMessageArchived hook runs whenever a message is archived.
 	ArchiveMessageArchived = "mam.message.archieved"
+
+	// ArchiveMessagesPurged hook runs whenever a batch of expired archive messages is purged.
+	ArchiveMessagesPurged = "mam.messages.purged"
 )
 
 // MamInfo contains all information associated to a mam (XEP-0313) event.
@@ -37,3 +42,15 @@ type MamInfo struct {
 	// Filters contains filters applied to the archive queried event.
 	Filters *archivemodel.Filters
 }
+
+// MamPurgeInfo contains all information associated to a mam (XEP-0313) archive purge event.
+type MamPurgeInfo struct {
+	// Host is the domain whose archived messages were purged.
+	Host string
+
+	// Before is the time before which archived messages were purged.
+	Before time.Time
+
+	// Count is the number of purged messages.
+	Count int
+}
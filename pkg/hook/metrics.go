@@ -0,0 +1,85 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/ortuman/jackal/pkg/cluster/instance"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	hookHandlerDurationBucket = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "jackal",
+			Subsystem: "hook",
+			Name:      "handler_duration_bucket",
+			Help:      "Bucketed histogram of hook handler execution duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 2, 20),
+		},
+		[]string{"instance", "hook", "module", "handler"},
+	)
+	hookHandlerErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "hook",
+			Name:      "handler_errors_total",
+			Help:      "The total number of hook handler executions that returned an error.",
+		},
+		[]string{"instance", "hook", "module", "handler"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(hookHandlerDurationBucket)
+	prometheus.MustRegister(hookHandlerErrors)
+}
+
+type handlerMetrics struct {
+	duration prometheus.Observer
+	errors   prometheus.Counter
+}
+
+func newHandlerMetrics(hook string, hnd Handler) handlerMetrics {
+	mod, name := handlerOwner(hnd)
+	return handlerMetrics{
+		duration: hookHandlerDurationBucket.WithLabelValues(instance.ID(), hook, mod, name),
+		errors:   hookHandlerErrors.WithLabelValues(instance.ID(), hook, mod, name),
+	}
+}
+
+// handlerOwner infers owning package and method name from a handler function symbol.
+// i.e. 'github.com/ortuman/jackal/pkg/module/xep0313.(*Mam).onMessageRouted-fm' yields ('xep0313', 'Mam.onMessageRouted')
+func handlerOwner(hnd Handler) (module string, name string) {
+	if hnd == nil {
+		return "unknown", "unknown"
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(hnd).Pointer())
+	if fn == nil {
+		return "unknown", "unknown"
+	}
+	sym := fn.Name()
+	if i := strings.LastIndex(sym, "/"); i >= 0 {
+		sym = sym[i+1:]
+	}
+	sym = strings.TrimSuffix(sym, "-fm")
+
+	module, name, _ = strings.Cut(sym, ".")
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	return module, name
+}
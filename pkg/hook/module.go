@@ -14,15 +14,26 @@
 
 package hook
 
+import "github.com/jackal-xmpp/stravaganza"
+
 const (
 	// ModulesStarted hook runs after initializing all configured modules.
 	ModulesStarted = "modules.started"
 
 	// ModulesStopped hook runs after finishing all configured modules.
 	ModulesStopped = "modules.stopped"
+
+	// ModulesWillProcessIQ hook runs before an IQ stanza is dispatched to its matching module.
+	ModulesWillProcessIQ = "modules.will_process_iq"
 )
 
 // ModulesInfo contains all information associated to a modules event.
 type ModulesInfo struct {
 	ModuleNames []string
 }
+
+// ModulesIQInfo contains all information associated to a modules IQ event.
+type ModulesIQInfo struct {
+	// IQ is the event associated IQ stanza.
+	IQ *stravaganza.IQ
+}
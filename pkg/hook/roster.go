@@ -27,6 +27,9 @@ type RosterInfo struct {
 	// Username is the name of the roster owner.
 	Username string
 
+	// Domain is the roster owner domain.
+	Domain string
+
 	// JID is the event contact JID.
 	JID string
 
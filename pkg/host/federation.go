@@ -0,0 +1,91 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// FederationOpen allows federating with any remote domain not explicitly blocked.
+	FederationOpen = "open"
+
+	// FederationAllowlist allows federating only with explicitly allowed remote domains.
+	FederationAllowlist = "allowlist"
+)
+
+// FederationConfig contains host federation policy configuration.
+type FederationConfig struct {
+	// Mode defines federation mode. Allowed values are 'open' and 'allowlist'.
+	Mode string `fig:"mode" default:"open"`
+
+	// Allowed contains the set of remote domains allowed in 'allowlist' mode.
+	// A '*.' prefixed entry matches any subdomain of the given domain.
+	Allowed []string `fig:"allowed"`
+
+	// Blocked contains the set of remote domains with which federation is never allowed.
+	// A '*.' prefixed entry matches any subdomain of the given domain.
+	Blocked []string `fig:"blocked"`
+}
+
+type federationPolicy struct {
+	allowlist bool
+	allowed   []string
+	blocked   []string
+}
+
+func newFederationPolicy(cfg FederationConfig) (*federationPolicy, error) {
+	switch cfg.Mode {
+	case "", FederationOpen:
+		if len(cfg.Blocked) == 0 {
+			return nil, nil // open federation
+		}
+	case FederationAllowlist:
+		break
+	default:
+		return nil, fmt.Errorf("host: unrecognized federation mode: %s", cfg.Mode)
+	}
+	return &federationPolicy{
+		allowlist: cfg.Mode == FederationAllowlist,
+		allowed:   cfg.Allowed,
+		blocked:   cfg.Blocked,
+	}, nil
+}
+
+func (p *federationPolicy) isAllowed(domain string) bool {
+	if p == nil {
+		return true
+	}
+	if matchesDomain(p.blocked, domain) {
+		return false
+	}
+	if p.allowlist {
+		return matchesDomain(p.allowed, domain)
+	}
+	return true
+}
+
+func matchesDomain(patterns []string, domain string) bool {
+	for _, p := range patterns {
+		if p == domain {
+			return true
+		}
+		if strings.HasPrefix(p, "*.") && strings.HasSuffix(domain, p[1:]) {
+			return true
+		}
+	}
+	return false
+}
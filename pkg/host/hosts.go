@@ -16,19 +16,51 @@ package host
 
 import (
 	"crypto/tls"
+	"fmt"
+	"os"
 	"sort"
 	"sync"
+	"time"
 
 	tlsutil "github.com/ortuman/jackal/pkg/util/tls"
 )
 
 const defaultDomain = "localhost"
 
+var loadCertificate = tlsutil.LoadCertificate
+
 // Hosts type represents all local domains set.
 type Hosts struct {
-	mu          sync.RWMutex
-	defaultHost string
-	hosts       map[string]tls.Certificate
+	mu           sync.RWMutex
+	defaultHost  string
+	hosts        map[string]tls.Certificate
+	mobileHosts  map[string]struct{}
+	disabledMods map[string]map[string]struct{}
+	certFiles    map[string]*certFiles
+	federation   map[string]*federationPolicy
+	resConflicts map[string]string
+	maxResources map[string]int
+	maxIPSess    map[string]int
+}
+
+type certFiles struct {
+	certFile string
+	keyFile  string
+	modTime  time.Time
+}
+
+func (cf *certFiles) lastModified() (time.Time, error) {
+	var modTime time.Time
+	for _, f := range []string{cf.certFile, cf.keyFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+	}
+	return modTime, nil
 }
 
 // Configs contains a set of host configurations.
@@ -41,15 +73,46 @@ type Config struct {
 		CertFile       string `fig:"cert_file"`
 		PrivateKeyFile string `fig:"privkey_file"`
 	} `fig:"tls"`
+
+	// MobileProfile tells whether the host serves mostly mobile clients.
+	// When enabled, modules apply their battery and bandwidth friendly defaults for this host
+	// (e.g. deferring non-urgent traffic of inactive clients, less frequent pings or smaller archive pages).
+	MobileProfile bool `fig:"mobile_profile"`
+
+	// DisabledModules contains the names of the modules that should not serve this host
+	// (e.g. "mam" to disable message archiving only for this domain).
+	DisabledModules []string `fig:"disabled_modules"`
+
+	// Federation defines the set of remote domains the host is allowed to federate with.
+	Federation FederationConfig `fig:"federation"`
+
+	// ResourceConflict overrides the C2S listener rule applied when a client binds an already bound resource.
+	// Valid values are `override`, `disallow` and `terminate_old`.
+	ResourceConflict string `fig:"resource_conflict"`
+
+	// MaxResources defines the maximum number of concurrently bound resources per account.
+	// A zero value means no limit.
+	MaxResources int `fig:"max_resources"`
+
+	// MaxSessionsPerIP defines the maximum number of concurrently bound sessions per remote IP address
+	// on every cluster node. A zero value means no limit.
+	MaxSessionsPerIP int `fig:"max_sessions_per_ip"`
 }
 
 // NewHosts creates and initializes a Hosts instance.
 func NewHosts(cfg Configs) (*Hosts, error) {
 	hs := &Hosts{
-		hosts: make(map[string]tls.Certificate),
+		hosts:        make(map[string]tls.Certificate),
+		mobileHosts:  make(map[string]struct{}),
+		disabledMods: make(map[string]map[string]struct{}),
+		certFiles:    make(map[string]*certFiles),
+		federation:   make(map[string]*federationPolicy),
+		resConflicts: make(map[string]string),
+		maxResources: make(map[string]int),
+		maxIPSess:    make(map[string]int),
 	}
 	if len(cfg) == 0 {
-		cer, err := tlsutil.LoadCertificate("", "", defaultDomain)
+		cer, err := loadCertificate("", "", defaultDomain)
 		if err != nil {
 			return nil, err
 		}
@@ -57,19 +120,136 @@ func NewHosts(cfg Configs) (*Hosts, error) {
 		return hs, nil
 	}
 	for i, config := range cfg {
-		cer, err := tlsutil.LoadCertificate(config.TLS.PrivateKeyFile, config.TLS.CertFile, config.Domain)
-		if err != nil {
+		if err := hs.addHost(config, i == 0); err != nil {
 			return nil, err
 		}
-		if i == 0 {
-			hs.RegisterDefaultHost(config.Domain, cer)
-		} else {
-			hs.RegisterHost(config.Domain, cer)
-		}
 	}
 	return hs, nil
 }
 
+// AddHost registers a new local host at runtime.
+func (hs *Hosts) AddHost(cfg Config) error {
+	if len(cfg.Domain) == 0 {
+		return fmt.Errorf("host: empty domain")
+	}
+	if hs.IsLocalHost(cfg.Domain) {
+		return fmt.Errorf("host: %s already registered", cfg.Domain)
+	}
+	return hs.addHost(cfg, false)
+}
+
+// RemoveHost unregisters a local host at runtime. Default host cannot be removed.
+func (hs *Hosts) RemoveHost(h string) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if _, ok := hs.hosts[h]; !ok {
+		return fmt.Errorf("host: %s not registered", h)
+	}
+	if h == hs.defaultHost {
+		return fmt.Errorf("host: cannot remove default host %s", h)
+	}
+	delete(hs.hosts, h)
+	delete(hs.mobileHosts, h)
+	delete(hs.disabledMods, h)
+	delete(hs.certFiles, h)
+	delete(hs.federation, h)
+	delete(hs.resConflicts, h)
+	delete(hs.maxResources, h)
+	delete(hs.maxIPSess, h)
+	return nil
+}
+
+func (hs *Hosts) addHost(cfg Config, isDefault bool) error {
+	cer, err := loadCertificate(cfg.TLS.PrivateKeyFile, cfg.TLS.CertFile, cfg.Domain)
+	if err != nil {
+		return err
+	}
+	var cf *certFiles
+	if len(cfg.TLS.CertFile) > 0 && len(cfg.TLS.PrivateKeyFile) > 0 {
+		cf = &certFiles{certFile: cfg.TLS.CertFile, keyFile: cfg.TLS.PrivateKeyFile}
+		cf.modTime, _ = cf.lastModified()
+	}
+	fp, err := newFederationPolicy(cfg.Federation)
+	if err != nil {
+		return err
+	}
+	switch cfg.ResourceConflict {
+	case "", "override", "disallow", "terminate_old":
+		break
+	default:
+		return fmt.Errorf("host: unrecognized resource conflict rule for %s: %s", cfg.Domain, cfg.ResourceConflict)
+	}
+	if isDefault {
+		hs.RegisterDefaultHost(cfg.Domain, cer)
+	} else {
+		hs.RegisterHost(cfg.Domain, cer)
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if cfg.MobileProfile {
+		hs.mobileHosts[cfg.Domain] = struct{}{}
+	}
+	if len(cfg.DisabledModules) > 0 {
+		mods := make(map[string]struct{}, len(cfg.DisabledModules))
+		for _, modName := range cfg.DisabledModules {
+			mods[modName] = struct{}{}
+		}
+		hs.disabledMods[cfg.Domain] = mods
+	}
+	if fp != nil {
+		hs.federation[cfg.Domain] = fp
+	}
+	if len(cfg.ResourceConflict) > 0 {
+		hs.resConflicts[cfg.Domain] = cfg.ResourceConflict
+	}
+	if cfg.MaxResources > 0 {
+		hs.maxResources[cfg.Domain] = cfg.MaxResources
+	}
+	if cfg.MaxSessionsPerIP > 0 {
+		hs.maxIPSess[cfg.Domain] = cfg.MaxSessionsPerIP
+	}
+	if cf != nil {
+		hs.certFiles[cfg.Domain] = cf
+	}
+	return nil
+}
+
+// RefreshCertificates reloads the certificates whose files have been modified since they were loaded
+// (e.g. rotated by an external secrets agent), returning the number of reloaded certificates.
+func (hs *Hosts) RefreshCertificates() (int, error) {
+	hs.mu.RLock()
+	files := make(map[string]*certFiles, len(hs.certFiles))
+	for h, cf := range hs.certFiles {
+		files[h] = cf
+	}
+	hs.mu.RUnlock()
+
+	var n int
+	for h, cf := range files {
+		modTime, err := cf.lastModified()
+		if err != nil {
+			return n, err
+		}
+		if !modTime.After(cf.modTime) {
+			continue
+		}
+		cer, err := loadCertificate(cf.keyFile, cf.certFile, h)
+		if err != nil {
+			return n, err
+		}
+		hs.mu.Lock()
+		if _, ok := hs.hosts[h]; ok {
+			hs.hosts[h] = cer
+			cf.modTime = modTime
+			n++
+		}
+		hs.mu.Unlock()
+	}
+	return n, nil
+}
+
 // RegisterDefaultHost registers default host value along with its certificate.
 func (hs *Hosts) RegisterDefaultHost(h string, cer tls.Certificate) {
 	hs.mu.Lock()
@@ -100,6 +280,52 @@ func (hs *Hosts) IsLocalHost(h string) bool {
 	return ok
 }
 
+// IsMobileProfile tells whether or not mobile profile is enabled for h host.
+func (hs *Hosts) IsMobileProfile(h string) bool {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	_, ok := hs.mobileHosts[h]
+	return ok
+}
+
+// ResourceConflict returns the resource conflict rule configured for h host, or an empty string if none was set.
+func (hs *Hosts) ResourceConflict(h string) string {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.resConflicts[h]
+}
+
+// MaxResources returns the maximum number of concurrently bound resources per account for h host.
+// A zero value means no limit.
+func (hs *Hosts) MaxResources(h string) int {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.maxResources[h]
+}
+
+// MaxSessionsPerIP returns the maximum number of concurrently bound sessions per remote IP address for h host.
+// A zero value means no limit.
+func (hs *Hosts) MaxSessionsPerIP(h string) int {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.maxIPSess[h]
+}
+
+// IsModuleEnabled tells whether or not modName module is enabled for h host.
+func (hs *Hosts) IsModuleEnabled(h, modName string) bool {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	_, disabled := hs.disabledMods[h][modName]
+	return !disabled
+}
+
+// IsFederationAllowed tells whether or not h host is allowed to federate with remote domain.
+func (hs *Hosts) IsFederationAllowed(h, remoteDomain string) bool {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.federation[h].isAllowed(remoteDomain)
+}
+
 // HostNames returns the list of all registered local hosts.
 func (hs *Hosts) HostNames() []string {
 	hs.mu.RLock()
@@ -112,6 +338,18 @@ func (hs *Hosts) HostNames() []string {
 	return ret
 }
 
+// GetCertificate returns the certificate of the host requested by a TLS client,
+// falling back to default host certificate.
+func (hs *Hosts) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	cer, ok := hs.hosts[hello.ServerName]
+	if !ok {
+		cer = hs.hosts[hs.defaultHost]
+	}
+	return &cer, nil
+}
+
 // Certificates returns all registered domain certificates.
 func (hs *Hosts) Certificates() []tls.Certificate {
 	hs.mu.RLock()
@@ -16,8 +16,12 @@ package host
 
 import (
 	"crypto/tls"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	tlsutil "github.com/ortuman/jackal/pkg/util/tls"
 	"github.com/stretchr/testify/require"
 )
 
@@ -55,3 +59,215 @@ func TestHosts_Domains(t *testing.T) {
 	require.True(t, h.IsLocalHost("jackal.org"))
 	require.True(t, h.IsLocalHost("jackal.net"))
 }
+
+func TestHosts_Federation(t *testing.T) {
+	// given
+	h := &Hosts{
+		hosts:      make(map[string]tls.Certificate),
+		federation: make(map[string]*federationPolicy),
+	}
+	h.RegisterDefaultHost("jackal.im", tls.Certificate{})
+	h.RegisterHost("jackal.org", tls.Certificate{})
+	h.RegisterHost("jackal.net", tls.Certificate{})
+
+	fp1, err := newFederationPolicy(FederationConfig{Mode: FederationOpen, Blocked: []string{"spam.net", "*.spam.org"}})
+	require.Nil(t, err)
+	fp2, err := newFederationPolicy(FederationConfig{Mode: FederationAllowlist, Allowed: []string{"jabber.org", "*.xmpp.org"}})
+	require.Nil(t, err)
+
+	h.federation["jackal.im"] = fp1
+	h.federation["jackal.org"] = fp2
+
+	// then
+	require.True(t, h.IsFederationAllowed("jackal.im", "jabber.org"))
+	require.False(t, h.IsFederationAllowed("jackal.im", "spam.net"))
+	require.False(t, h.IsFederationAllowed("jackal.im", "mail.spam.org"))
+
+	require.True(t, h.IsFederationAllowed("jackal.org", "jabber.org"))
+	require.True(t, h.IsFederationAllowed("jackal.org", "chat.xmpp.org"))
+	require.False(t, h.IsFederationAllowed("jackal.org", "xmpp.org"))
+	require.False(t, h.IsFederationAllowed("jackal.org", "spam.net"))
+
+	require.True(t, h.IsFederationAllowed("jackal.net", "spam.net")) // open federation
+
+	_, err = newFederationPolicy(FederationConfig{Mode: "closed"})
+	require.NotNil(t, err)
+}
+
+func TestHosts_AddAndRemoveHost(t *testing.T) {
+	// given
+	loadCertificate = func(_, _, _ string) (tls.Certificate, error) {
+		return tls.Certificate{}, nil
+	}
+	t.Cleanup(func() { loadCertificate = tlsutil.LoadCertificate })
+
+	h, err := NewHosts(Configs{{Domain: "jackal.im"}})
+	require.Nil(t, err)
+
+	// when
+	err = h.AddHost(Config{Domain: "jackal.org", MobileProfile: true})
+
+	// then
+	require.Nil(t, err)
+	require.True(t, h.IsLocalHost("jackal.org"))
+	require.True(t, h.IsMobileProfile("jackal.org"))
+
+	require.NotNil(t, h.AddHost(Config{Domain: "jackal.org"}))
+
+	require.Nil(t, h.RemoveHost("jackal.org"))
+	require.False(t, h.IsLocalHost("jackal.org"))
+	require.False(t, h.IsMobileProfile("jackal.org"))
+
+	require.NotNil(t, h.RemoveHost("jackal.org"))
+	require.NotNil(t, h.RemoveHost("jackal.im"))
+}
+
+func TestHosts_DisabledModules(t *testing.T) {
+	// given
+	loadCertificate = func(_, _, _ string) (tls.Certificate, error) {
+		return tls.Certificate{}, nil
+	}
+	t.Cleanup(func() { loadCertificate = tlsutil.LoadCertificate })
+
+	// when
+	h, err := NewHosts(Configs{
+		{Domain: "jackal.im"},
+		{Domain: "jackal.org", DisabledModules: []string{"mam", "offline"}},
+	})
+
+	// then
+	require.Nil(t, err)
+	require.True(t, h.IsModuleEnabled("jackal.im", "mam"))
+	require.False(t, h.IsModuleEnabled("jackal.org", "mam"))
+	require.False(t, h.IsModuleEnabled("jackal.org", "offline"))
+	require.True(t, h.IsModuleEnabled("jackal.org", "ping"))
+
+	require.Nil(t, h.RemoveHost("jackal.org"))
+	require.True(t, h.IsModuleEnabled("jackal.org", "mam"))
+}
+
+func TestHosts_ResourceConflict(t *testing.T) {
+	// given
+	loadCertificate = func(_, _, _ string) (tls.Certificate, error) {
+		return tls.Certificate{}, nil
+	}
+	t.Cleanup(func() { loadCertificate = tlsutil.LoadCertificate })
+
+	// when
+	h, err := NewHosts(Configs{
+		{Domain: "jackal.im"},
+		{Domain: "jackal.org", ResourceConflict: "disallow"},
+	})
+	_, invalidErr := NewHosts(Configs{{Domain: "jackal.im", ResourceConflict: "replace"}})
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, "", h.ResourceConflict("jackal.im"))
+	require.Equal(t, "disallow", h.ResourceConflict("jackal.org"))
+
+	require.NotNil(t, invalidErr)
+}
+
+func TestHosts_MaxResources(t *testing.T) {
+	// given
+	loadCertificate = func(_, _, _ string) (tls.Certificate, error) {
+		return tls.Certificate{}, nil
+	}
+	t.Cleanup(func() { loadCertificate = tlsutil.LoadCertificate })
+
+	// when
+	h, err := NewHosts(Configs{
+		{Domain: "jackal.im"},
+		{Domain: "jackal.org", MaxResources: 5},
+	})
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, 0, h.MaxResources("jackal.im"))
+	require.Equal(t, 5, h.MaxResources("jackal.org"))
+
+	require.Nil(t, h.RemoveHost("jackal.org"))
+	require.Equal(t, 0, h.MaxResources("jackal.org"))
+}
+
+func TestHosts_MaxSessionsPerIP(t *testing.T) {
+	// given
+	loadCertificate = func(_, _, _ string) (tls.Certificate, error) {
+		return tls.Certificate{}, nil
+	}
+	t.Cleanup(func() { loadCertificate = tlsutil.LoadCertificate })
+
+	// when
+	h, err := NewHosts(Configs{
+		{Domain: "jackal.im"},
+		{Domain: "jackal.org", MaxSessionsPerIP: 10},
+	})
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, 0, h.MaxSessionsPerIP("jackal.im"))
+	require.Equal(t, 10, h.MaxSessionsPerIP("jackal.org"))
+
+	require.Nil(t, h.RemoveHost("jackal.org"))
+	require.Equal(t, 0, h.MaxSessionsPerIP("jackal.org"))
+}
+
+func TestHosts_RefreshCertificates(t *testing.T) {
+	// given
+	var loaded int
+	loadCertificate = func(_, _, _ string) (tls.Certificate, error) {
+		loaded++
+		return tls.Certificate{}, nil
+	}
+	t.Cleanup(func() { loadCertificate = tlsutil.LoadCertificate })
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, []byte("cert"), 0600))
+	require.NoError(t, os.WriteFile(keyFile, []byte("key"), 0600))
+
+	cfg := Config{Domain: "jackal.im"}
+	cfg.TLS.CertFile = certFile
+	cfg.TLS.PrivateKeyFile = keyFile
+
+	h, err := NewHosts(Configs{cfg})
+	require.Nil(t, err)
+
+	// when
+	n1, err1 := h.RefreshCertificates()
+
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+
+	n2, err2 := h.RefreshCertificates()
+	n3, err3 := h.RefreshCertificates()
+
+	// then
+	require.Nil(t, err1)
+	require.Nil(t, err2)
+	require.Nil(t, err3)
+	require.Equal(t, 0, n1)
+	require.Equal(t, 1, n2)
+	require.Equal(t, 0, n3)
+	require.Equal(t, 2, loaded)
+}
+
+func TestHosts_GetCertificate(t *testing.T) {
+	// given
+	h := &Hosts{
+		hosts: make(map[string]tls.Certificate),
+	}
+	c1 := tls.Certificate{OCSPStaple: []byte("jackal.im")}
+	c2 := tls.Certificate{OCSPStaple: []byte("jackal.org")}
+	h.RegisterDefaultHost("jackal.im", c1)
+	h.RegisterHost("jackal.org", c2)
+
+	// when
+	cer1, _ := h.GetCertificate(&tls.ClientHelloInfo{ServerName: "jackal.org"})
+	cer2, _ := h.GetCertificate(&tls.ClientHelloInfo{})
+
+	// then
+	require.Equal(t, []byte("jackal.org"), cer1.OCSPStaple)
+	require.Equal(t, []byte("jackal.im"), cer2.OCSPStaple)
+}
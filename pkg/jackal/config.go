@@ -18,6 +18,10 @@ import (
 	"path/filepath"
 
 	"github.com/ortuman/jackal/pkg/module/xep0313"
+	"github.com/ortuman/jackal/pkg/module/xep0352"
+	"github.com/ortuman/jackal/pkg/module/xep0355"
+	"github.com/ortuman/jackal/pkg/module/xep0356"
+	"github.com/ortuman/jackal/pkg/module/xep0455"
 
 	"github.com/kkyr/fig"
 	adminserver "github.com/ortuman/jackal/pkg/admin/server"
@@ -27,11 +31,27 @@ import (
 	clusterserver "github.com/ortuman/jackal/pkg/cluster/server"
 	"github.com/ortuman/jackal/pkg/component/xep0114"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/module/abusereport"
+	"github.com/ortuman/jackal/pkg/module/announce"
+	"github.com/ortuman/jackal/pkg/module/clickhouse"
+	"github.com/ortuman/jackal/pkg/module/emailnotify"
+	"github.com/ortuman/jackal/pkg/module/eventstream"
+	"github.com/ortuman/jackal/pkg/module/external"
+	"github.com/ortuman/jackal/pkg/module/linkpreview"
+	"github.com/ortuman/jackal/pkg/module/mediaproxy"
 	"github.com/ortuman/jackal/pkg/module/offline"
+	"github.com/ortuman/jackal/pkg/module/roster"
+	"github.com/ortuman/jackal/pkg/module/stats"
+	"github.com/ortuman/jackal/pkg/module/unifiedpush"
+	"github.com/ortuman/jackal/pkg/module/xep0030"
+	"github.com/ortuman/jackal/pkg/module/xep0054"
 	"github.com/ortuman/jackal/pkg/module/xep0092"
 	"github.com/ortuman/jackal/pkg/module/xep0198"
 	"github.com/ortuman/jackal/pkg/module/xep0199"
+	"github.com/ortuman/jackal/pkg/module/xep0215"
+	"github.com/ortuman/jackal/pkg/retention"
 	"github.com/ortuman/jackal/pkg/s2s"
+	"github.com/ortuman/jackal/pkg/secret"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/storage"
 )
@@ -67,6 +87,7 @@ func (c ClusterConfig) IsEnabled() bool {
 // C2SConfig defines C2S subsystem configuration.
 type C2SConfig struct {
 	Listeners c2s.ListenersConfig `fig:"listeners"`
+	Routing   c2s.RoutingConfig   `fig:"routing"`
 }
 
 // S2SConfig defines S2S subsystem configuration.
@@ -86,9 +107,45 @@ type ModulesConfig struct {
 	// Enabled specifies total set of enabled modules
 	Enabled []string `fig:"enabled"`
 
+	// Roster: roster management
+	Roster roster.Config `fig:"roster"`
+
 	// Offline: offline storage
 	Offline offline.Config `fig:"offline"`
 
+	// Announce: message injection endpoint
+	Announce announce.Config `fig:"announce"`
+
+	// EmailNotify: offline message email notifications
+	EmailNotify emailnotify.Config `fig:"email_notify"`
+
+	// ClickHouse: archived message analytics sink
+	ClickHouse clickhouse.Config `fig:"clickhouse"`
+
+	// EventStream: NATS event exporter
+	EventStream eventstream.Config `fig:"event_stream"`
+
+	// Stats: usage statistics
+	Stats stats.Config `fig:"stats"`
+
+	// UnifiedPush: push gateway
+	UnifiedPush unifiedpush.Config `fig:"unifiedpush"`
+
+	// MediaProxy: out-of-band media URL proxy
+	MediaProxy mediaproxy.Config `fig:"mediaproxy"`
+
+	// LinkPreview: link metadata previews
+	LinkPreview linkpreview.Config `fig:"linkpreview"`
+
+	// AbuseReport: remote abuse report forwarding
+	AbuseReport abusereport.Config `fig:"abuse_report"`
+
+	// XEP-0030: Service Discovery
+	Disco xep0030.Config `fig:"disco"`
+
+	// XEP-0054: vcard-temp
+	VCard xep0054.Config `fig:"vcard"`
+
 	// XEP-0092: Software Version
 	Version xep0092.Config `fig:"version"`
 
@@ -98,8 +155,26 @@ type ModulesConfig struct {
 	// XEP-0199: XMPP Ping
 	Ping xep0199.Config `fig:"ping"`
 
+	// XEP-0215: External Service Discovery
+	ExtDisco xep0215.Config `fig:"extdisco"`
+
 	// XEP-0313: Message Archive Management
 	Mam xep0313.Config `fig:"mam"`
+
+	// XEP-0352: Client State Indication
+	Csi xep0352.Config `fig:"csi"`
+
+	// XEP-0355: Namespace Delegation
+	Delegation xep0355.Config `fig:"delegation"`
+
+	// XEP-0356: Privileged Entity
+	Privilege xep0356.Config `fig:"privilege"`
+
+	// XEP-0455: Service Outage Status
+	OutageStatus xep0455.Config `fig:"outage_status"`
+
+	// External: out-of-process gRPC modules
+	External []external.Config `fig:"external"`
 }
 
 // Config defines jackal application configuration.
@@ -111,19 +186,23 @@ type Config struct {
 
 	HTTP HTTPConfig `fig:"http"`
 
+	Secrets secret.Config      `fig:"secrets"`
 	Peppers pepper.Config      `fig:"peppers"`
 	Admin   adminserver.Config `fig:"admin"`
 	Storage storage.Config     `fig:"storage"`
 	Hosts   host.Configs       `fig:"hosts"`
 	Shapers []shaper.Config    `fig:"shapers"`
 
+	Retention retention.Config `fig:"retention"`
+
 	C2S        C2SConfig        `fig:"c2s"`
 	S2S        S2SConfig        `fig:"s2s"`
 	Components ComponentsConfig `fig:"components"`
 	Modules    ModulesConfig    `fig:"modules"`
 }
 
-func loadConfig(configFile string) (*Config, error) {
+// LoadConfig reads jackal configuration from configFile, applying environment overrides and default values.
+func LoadConfig(configFile string) (*Config, error) {
 	var cfg Config
 	file := filepath.Base(configFile)
 	dir := filepath.Dir(configFile)
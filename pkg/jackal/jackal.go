@@ -16,6 +16,7 @@ package jackal
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -41,13 +42,18 @@ import (
 	"github.com/ortuman/jackal/pkg/component"
 	"github.com/ortuman/jackal/pkg/component/extcomponentmanager"
 	"github.com/ortuman/jackal/pkg/component/xep0114"
+	"github.com/ortuman/jackal/pkg/errorstats"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/listener"
 	"github.com/ortuman/jackal/pkg/log"
 	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/external"
 	streamqueue "github.com/ortuman/jackal/pkg/module/xep0198/queue"
+	"github.com/ortuman/jackal/pkg/retention"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/s2s"
+	"github.com/ortuman/jackal/pkg/secret"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/storage"
 	"github.com/ortuman/jackal/pkg/storage/repository"
@@ -77,6 +83,7 @@ const usageStr = `
 Usage: jackal [options]
 Server Options:
     --config <file>    Configuration file path
+    --inmemory         Use an ephemeral in-memory repository
 Common Options:
     --help             Show this message
 `
@@ -99,6 +106,7 @@ type Jackal struct {
 	output io.Writer
 	args   []string
 
+	secrets *secret.Resolver
 	peppers *pepper.Keys
 	hk      *hook.Hooks
 
@@ -120,6 +128,8 @@ type Jackal struct {
 	comps          *component.Components
 	stmQueueMap    *streamqueue.QueueMap
 	extCompMng     *extcomponentmanager.Manager
+	listeners      *listener.Manager
+	errStats       *errorstats.Tracker
 
 	starters []starter
 	stoppers []stopper
@@ -151,11 +161,12 @@ func (j *Jackal) Run() error {
 	fs.SetOutput(j.output)
 
 	var configFile string
-	var showVersion, showUsage bool
+	var showVersion, showUsage, inMemory bool
 
 	fs.BoolVar(&showUsage, "help", false, "Show this message")
 	fs.BoolVar(&showVersion, "version", false, "Print version information.")
 	fs.StringVar(&configFile, "config", "config.yaml", "Configuration file path.")
+	fs.BoolVar(&inMemory, "inmemory", false, "Use an ephemeral in-memory repository.")
 
 	fs.Usage = func() {
 		for i := range logoStr {
@@ -180,19 +191,13 @@ func (j *Jackal) Run() error {
 		configFile = envCfgFile
 	}
 	// load configuration
-	cfg, err := loadConfig(configFile)
+	cfg, err := LoadConfig(configFile)
 	if err != nil {
 		return err
 	}
-	// init logger
-	j.logger = log.NewDefaultLogger(cfg.Logger.Level, cfg.Logger.Format)
-
-	level.Info(j.logger).Log("msg", "jackal is starting...",
-		"version", version.Version,
-		"go_ver", runtime.Version(),
-		"go_os", runtime.GOOS,
-		"go_arch", runtime.GOARCH,
-	)
+	if inMemory {
+		cfg.Storage.Type = storage.MemoryRepositoryType
+	}
 	// Allocate a block of memory to alter GC behaviour. See https://github.com/golang/go/issues/23044
 	ballast := make([]byte, cfg.MemoryBallastSize)
 	runtime.KeepAlive(ballast)
@@ -204,6 +209,33 @@ func (j *Jackal) Run() error {
 	if err := setRLimit(); err != nil {
 		return err
 	}
+	if err := j.Start(cfg); err != nil {
+		return err
+	}
+	// ...wait for stop signal to shut down
+	sig := j.waitForStopSignal()
+	level.Info(j.logger).Log("msg", "received stop signal... shutting down...",
+		"signal", sig.String(),
+	)
+	return j.Stop()
+}
+
+// Start initializes all jackal subsystems from cfg configuration and starts them,
+// returning as soon as they are ready to serve.
+func (j *Jackal) Start(cfg *Config) error {
+	// init logger
+	j.logger = log.NewDefaultLogger(cfg.Logger.Level, cfg.Logger.Format)
+
+	level.Info(j.logger).Log("msg", "jackal is starting...",
+		"version", version.Version,
+		"go_ver", runtime.Version(),
+		"go_os", runtime.GOOS,
+		"go_arch", runtime.GOARCH,
+	)
+	// resolve file and vault secret references
+	if err := j.initSecrets(cfg); err != nil {
+		return err
+	}
 
 	// init pepper keys
 	peppers, err := pepper.NewKeys(cfg.Peppers)
@@ -232,7 +264,10 @@ func (j *Jackal) Run() error {
 		return err
 	}
 	j.initS2SOut(cfg.S2S.Out)
-	j.initRouters()
+	j.initRouters(cfg.C2S.Routing, cfg.S2S.Out.Queue)
+
+	// init data retention
+	j.initRetention(cfg.Retention)
 
 	// init components & modules
 	j.initComponents()
@@ -241,8 +276,13 @@ func (j *Jackal) Run() error {
 		return err
 	}
 
+	// init error stanza tracker
+	j.errStats = errorstats.New(j.hosts, j.mods, j.hk, j.logger)
+	j.registerStartStopper(j.errStats)
+
 	// init admin server
-	j.initAdminServer(cfg.Admin)
+	j.listeners = listener.NewManager(j.logger)
+	j.initAdminServer(cfg.Admin, cfg.Retention.DeletedAccounts, cfg.Cluster.Type == kvClusterType)
 
 	// init cluster server
 	if cfg.Cluster.IsEnabled() {
@@ -256,15 +296,21 @@ func (j *Jackal) Run() error {
 	// init HTTP server
 	j.registerStartStopper(newHTTPServer(cfg.HTTP.Port, j.logger))
 
-	if err := j.bootstrap(); err != nil {
+	return j.bootstrap()
+}
+
+// Stop shuts down all previously started jackal subsystems.
+func (j *Jackal) Stop() error {
+	return j.shutdown()
+}
+
+func (j *Jackal) initSecrets(cfg *Config) error {
+	j.secrets = secret.NewResolver(cfg.Secrets, j.logger)
+	if err := j.secrets.ResolveConfig(context.Background(), cfg); err != nil {
 		return err
 	}
-	// ...wait for stop signal to shut down
-	sig := j.waitForStopSignal()
-	level.Info(j.logger).Log("msg", "received stop signal... shutting down...",
-		"signal", sig.String(),
-	)
-	return j.shutdown()
+	j.registerStartStopper(j.secrets)
+	return nil
 }
 
 func (j *Jackal) initCluster(cfg ClusterConfig) error {
@@ -317,9 +363,22 @@ func (j *Jackal) initHosts(configs host.Configs) error {
 		return err
 	}
 	j.hosts = h
+
+	// pick up rotated certificate files
+	j.secrets.OnRefresh(func(_ context.Context) error {
+		n, err := h.RefreshCertificates()
+		if n > 0 {
+			level.Info(j.logger).Log("msg", "reloaded host certificates", "count", n)
+		}
+		return err
+	})
 	return nil
 }
 
+func (j *Jackal) initRetention(cfg retention.Config) {
+	j.registerStartStopper(retention.New(cfg, j.hosts, j.rep, j.hk, j.logger))
+}
+
 func (j *Jackal) initShapers(configs []shaper.Config) error {
 	j.shapers = make(shaper.Shapers, 0)
 	for _, cfg := range configs {
@@ -359,8 +418,19 @@ func (j *Jackal) initListeners(
 		j.hk,
 		j.logger,
 	)
-	for _, ln := range c2sListeners {
-		j.registerStartStopper(ln)
+	for i, ln := range c2sListeners {
+		lnCfg := c2sListenersCfg[i]
+		j.listeners.Register(listener.C2S, listener.Options{
+			BindAddr:  lnCfg.BindAddr,
+			Port:      lnCfg.Port,
+			DirectTLS: lnCfg.DirectTLS,
+		}, ln)
+	}
+	if len(c2sListeners) > 0 {
+		tmpl := c2sListeners[0] // runtime listeners inherit first configured listener settings
+		j.listeners.RegisterFactory(listener.C2S, func(opts listener.Options) (listener.Listener, error) {
+			return tmpl.Derive(opts.BindAddr, opts.Port, opts.DirectTLS), nil
+		})
 	}
 
 	// s2s listeners
@@ -381,9 +451,18 @@ func (j *Jackal) initListeners(
 			j.hk,
 			j.logger,
 		)
-		for _, ln := range s2sListeners {
-			j.registerStartStopper(ln)
+		for i, ln := range s2sListeners {
+			lnCfg := s2sListenersCfg[i]
+			j.listeners.Register(listener.S2S, listener.Options{
+				BindAddr:  lnCfg.BindAddr,
+				Port:      lnCfg.Port,
+				DirectTLS: lnCfg.DirectTLS,
+			}, ln)
 		}
+		tmpl := s2sListeners[0]
+		j.listeners.RegisterFactory(listener.S2S, func(opts listener.Options) (listener.Listener, error) {
+			return tmpl.Derive(opts.BindAddr, opts.Port, opts.DirectTLS), nil
+		})
 	}
 
 	// external component listeners
@@ -398,9 +477,23 @@ func (j *Jackal) initListeners(
 		j.hk,
 		j.logger,
 	)
-	for _, ln := range cmpListeners {
-		j.registerStartStopper(ln)
+	for i, ln := range cmpListeners {
+		lnCfg := cmpListenersCfg[i]
+		j.listeners.Register(listener.Component, listener.Options{
+			BindAddr: lnCfg.BindAddr,
+			Port:     lnCfg.Port,
+		}, ln)
+	}
+	if len(cmpListeners) > 0 {
+		tmpl := cmpListeners[0]
+		j.listeners.RegisterFactory(listener.Component, func(opts listener.Options) (listener.Listener, error) {
+			if opts.DirectTLS {
+				return nil, errors.New("main: direct TLS is not supported by component listeners")
+			}
+			return tmpl.Derive(opts.BindAddr, opts.Port), nil
+		})
 	}
+	j.registerStartStopper(j.listeners)
 	return nil
 }
 
@@ -409,13 +502,13 @@ func (j *Jackal) initS2SOut(cfg s2s.OutConfig) {
 	j.registerStartStopper(j.s2sOutProvider)
 }
 
-func (j *Jackal) initRouters() {
+func (j *Jackal) initRouters(c2sRoutingCfg c2s.RoutingConfig, s2sQueueCfg s2s.QueueConfig) {
 	// init C2S router
 	j.localRouter = c2s.NewLocalRouter(j.hosts)
 	j.clusterRouter = clusterrouter.New(j.clusterConnMng)
 
-	c2sRouter := c2s.NewRouter(j.localRouter, j.clusterRouter, j.resMng, j.rep, j.hk, j.logger)
-	s2sRouter := s2s.NewRouter(j.s2sOutProvider)
+	c2sRouter := c2s.NewRouter(j.localRouter, j.clusterRouter, j.resMng, j.rep, c2sRoutingCfg, j.hk, j.logger)
+	s2sRouter := s2s.NewRouter(j.s2sOutProvider, j.hosts, j.rep, s2sQueueCfg, j.logger)
 
 	// init global router
 	j.router = router.New(j.hosts, c2sRouter, s2sRouter)
@@ -446,13 +539,26 @@ func (j *Jackal) initModules(cfg ModulesConfig) error {
 		}
 		mods = append(mods, fn(j, &cfg))
 	}
+	// external modules
+	extNames := make(map[string]struct{})
+	for _, extCfg := range cfg.External {
+		if _, ok := modFns[extCfg.Name]; ok {
+			return fmt.Errorf("main: external module name clashes with built-in module: %s", extCfg.Name)
+		}
+		if _, ok := extNames[extCfg.Name]; ok {
+			return fmt.Errorf("main: duplicated external module name: %s", extCfg.Name)
+		}
+		extNames[extCfg.Name] = struct{}{}
+
+		mods = append(mods, external.New(extCfg, j.router, j.hk, j.logger))
+	}
 	j.mods = module.NewModules(mods, j.hosts, j.router, j.hk, j.logger)
 	j.registerStartStopper(j.mods)
 	return nil
 }
 
-func (j *Jackal) initAdminServer(cfg adminserver.Config) {
-	adminSrv := adminserver.New(cfg, j.rep, j.peppers, j.hk, j.logger)
+func (j *Jackal) initAdminServer(cfg adminserver.Config, deletionGracePeriod time.Duration, clustered bool) {
+	adminSrv := adminserver.New(cfg, j.rep, j.peppers, j.hosts, j.router, j.resMng, j.listeners, j.errStats, j.mods, j.hk, deletionGracePeriod, clustered, j.logger)
 	j.registerStartStopper(adminSrv)
 }
 
@@ -16,8 +16,17 @@ package jackal
 
 import (
 	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/abusereport"
+	"github.com/ortuman/jackal/pkg/module/announce"
+	"github.com/ortuman/jackal/pkg/module/clickhouse"
+	"github.com/ortuman/jackal/pkg/module/emailnotify"
+	"github.com/ortuman/jackal/pkg/module/eventstream"
+	"github.com/ortuman/jackal/pkg/module/linkpreview"
+	"github.com/ortuman/jackal/pkg/module/mediaproxy"
 	"github.com/ortuman/jackal/pkg/module/offline"
 	"github.com/ortuman/jackal/pkg/module/roster"
+	"github.com/ortuman/jackal/pkg/module/stats"
+	"github.com/ortuman/jackal/pkg/module/unifiedpush"
 	"github.com/ortuman/jackal/pkg/module/xep0012"
 	"github.com/ortuman/jackal/pkg/module/xep0030"
 	"github.com/ortuman/jackal/pkg/module/xep0049"
@@ -29,8 +38,13 @@ import (
 	streamqueue "github.com/ortuman/jackal/pkg/module/xep0198/queue"
 	"github.com/ortuman/jackal/pkg/module/xep0199"
 	"github.com/ortuman/jackal/pkg/module/xep0202"
+	"github.com/ortuman/jackal/pkg/module/xep0215"
 	"github.com/ortuman/jackal/pkg/module/xep0280"
 	"github.com/ortuman/jackal/pkg/module/xep0313"
+	"github.com/ortuman/jackal/pkg/module/xep0352"
+	"github.com/ortuman/jackal/pkg/module/xep0355"
+	"github.com/ortuman/jackal/pkg/module/xep0356"
+	"github.com/ortuman/jackal/pkg/module/xep0455"
 )
 
 var defaultModules = []string{
@@ -47,19 +61,65 @@ var defaultModules = []string{
 	xep0199.ModuleName,
 	xep0280.ModuleName,
 	xep0313.ModuleName,
+	xep0352.ModuleName,
 }
 
 var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	// Roster
 	// (https://xmpp.org/rfcs/rfc6121.html#roster)
-	roster.ModuleName: func(j *Jackal, _ *ModulesConfig) module.Module {
-		return roster.New(j.router, j.hosts, j.resMng, j.rep, j.hk, j.logger)
+	roster.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return roster.New(cfg.Roster, j.router, j.hosts, j.resMng, j.rep, j.hk, j.logger)
 	},
 	// Offline
 	// (https://xmpp.org/extensions/xep-0160.html)
 	offline.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
 		return offline.New(cfg.Offline, j.router, j.hosts, j.rep, j.hk, j.logger)
 	},
+	// Announce
+	// (message injection HTTP endpoint)
+	announce.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return announce.New(cfg.Announce, j.router, j.hosts, j.logger)
+	},
+	// EmailNotify
+	// (offline message email notifications)
+	emailnotify.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return emailnotify.New(cfg.EmailNotify, j.rep, j.hk, j.logger)
+	},
+	// ClickHouse
+	// (archived message analytics sink)
+	clickhouse.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return clickhouse.New(cfg.ClickHouse, j.hk, j.logger)
+	},
+	// EventStream
+	// (NATS event exporter)
+	eventstream.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return eventstream.New(cfg.EventStream, j.hk, j.logger)
+	},
+	// Stats
+	// (usage statistics)
+	stats.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return stats.New(cfg.Stats, j.hosts, j.rep, j.hk, j.logger)
+	},
+	// UnifiedPush
+	// (https://unifiedpush.org)
+	unifiedpush.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return unifiedpush.New(cfg.UnifiedPush, j.router, j.hosts, j.rep, j.hk, j.logger)
+	},
+	// MediaProxy
+	// (out-of-band media URL proxy)
+	mediaproxy.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return mediaproxy.New(cfg.MediaProxy, j.hk, j.logger)
+	},
+	// LinkPreview
+	// (link metadata previews)
+	linkpreview.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return linkpreview.New(cfg.LinkPreview, j.router, j.hk, j.logger)
+	},
+	// AbuseReport
+	// (remote abuse report forwarding)
+	abusereport.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return abusereport.New(cfg.AbuseReport, j.router, j.hosts, j.hk, j.logger)
+	},
 	// XEP-0012: Last Activity
 	// (https://xmpp.org/extensions/xep-0012.html)
 	xep0012.ModuleName: func(j *Jackal, _ *ModulesConfig) module.Module {
@@ -67,8 +127,8 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	},
 	// XEP-0030: Service Discovery
 	// (https://xmpp.org/extensions/xep-0030.html)
-	xep0030.ModuleName: func(j *Jackal, _ *ModulesConfig) module.Module {
-		return xep0030.New(j.router, j.comps, j.rep, j.resMng, j.hk, j.logger)
+	xep0030.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0030.New(cfg.Disco, j.router, j.comps, j.rep, j.resMng, j.hk, j.logger)
 	},
 	// XEP-0049: Private XML Storage
 	// (https://xmpp.org/extensions/xep-0049.html)
@@ -77,8 +137,8 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	},
 	// XEP-0054: vcard-temp
 	// (https://xmpp.org/extensions/xep-0054.html)
-	xep0054.ModuleName: func(j *Jackal, _ *ModulesConfig) module.Module {
-		return xep0054.New(j.router, j.rep, j.hk, j.logger)
+	xep0054.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0054.New(cfg.VCard, j.router, j.rep, j.hk, j.logger)
 	},
 	// XEP-0092: Software Version
 	// (https://xmpp.org/extensions/xep-0092.html)
@@ -99,18 +159,23 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	// (https://xmpp.org/extensions/xep-0198.html)
 	xep0198.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
 		j.stmQueueMap = streamqueue.NewQueueMap()
-		return xep0198.New(cfg.Stream, j.stmQueueMap, j.clusterConnMng, j.router, j.hosts, j.resMng, j.hk, j.logger)
+		return xep0198.New(cfg.Stream, j.stmQueueMap, j.clusterConnMng, j.router, j.hosts, j.resMng, j.rep, j.hk, j.logger)
 	},
 	// XEP-0199: XMPP Ping
 	// (https://xmpp.org/extensions/xep-0199.html)
 	xep0199.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
-		return xep0199.New(cfg.Ping, j.router, j.hk, j.logger)
+		return xep0199.New(cfg.Ping, j.router, j.hosts, j.hk, j.logger)
 	},
 	// XEP-0202: Entity Time
 	// (https://xmpp.org/extensions/xep-0202.html)
 	xep0202.ModuleName: func(j *Jackal, _ *ModulesConfig) module.Module {
 		return xep0202.New(j.router, j.logger)
 	},
+	// XEP-0215: External Service Discovery
+	// (https://xmpp.org/extensions/xep-0215.html)
+	xep0215.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0215.New(cfg.ExtDisco, j.router, j.logger)
+	},
 	// XEP-0280: Message Carbons
 	// (https://xmpp.org/extensions/xep-0280.html)
 	xep0280.ModuleName: func(j *Jackal, _ *ModulesConfig) module.Module {
@@ -121,4 +186,24 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	xep0313.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
 		return xep0313.New(cfg.Mam, j.router, j.hosts, j.rep, j.hk, j.logger)
 	},
+	// XEP-0352: Client State Indication
+	// (https://xmpp.org/extensions/xep-0352.html)
+	xep0352.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0352.New(cfg.Csi, j.hosts, j.hk, j.logger)
+	},
+	// XEP-0355: Namespace Delegation
+	// (https://xmpp.org/extensions/xep-0355.html)
+	xep0355.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0355.New(cfg.Delegation, j.router, j.hosts, j.comps, j.hk, j.logger)
+	},
+	// XEP-0356: Privileged Entity
+	// (https://xmpp.org/extensions/xep-0356.html)
+	xep0356.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0356.New(cfg.Privilege, j.router, j.hosts, j.comps, j.rep, j.hk, j.logger)
+	},
+	// XEP-0455: Service Outage Status
+	// (https://xmpp.org/extensions/xep-0455.html)
+	xep0455.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
+		return xep0455.New(cfg.OutageStatus, j.logger)
+	},
 }
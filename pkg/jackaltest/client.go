@@ -0,0 +1,311 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jackaltest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	xmppparser "github.com/ortuman/jackal/pkg/parser"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	tlsNamespace  = "urn:ietf:params:xml:ns:xmpp-tls"
+	saslNamespace = "urn:ietf:params:xml:ns:xmpp-sasl"
+	bindNamespace = "urn:ietf:params:xml:ns:xmpp-bind"
+)
+
+// Client represents a scripted XMPP client connected to a test server.
+type Client struct {
+	tb      testing.TB
+	conn    net.Conn
+	p       *xmppparser.Parser
+	jd      *jid.JID
+	timeout time.Duration
+
+	closeOnce sync.Once
+}
+
+// Connect opens a new client session against the test server, authenticating as username
+// and binding the given resource. The session is closed once the test completes.
+func (s *Server) Connect(username, password, resource string) *Client {
+	s.tb.Helper()
+
+	conn, err := net.DialTimeout("tcp", s.c2sAddr, defaultTimeout)
+	if err != nil {
+		s.tb.Fatalf("jackaltest: failed to connect: %v", err)
+	}
+	c := &Client{
+		tb:      s.tb,
+		conn:    conn,
+		timeout: defaultTimeout,
+	}
+	s.tb.Cleanup(c.Close)
+
+	c.startTLS()
+	c.authenticate(username, password)
+	c.bind(resource)
+	return c
+}
+
+// JID returns the client session full JID.
+func (c *Client) JID() *jid.JID {
+	return c.jd
+}
+
+// SetTimeout sets the maximum amount of time the client waits when sending or receiving elements.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Send writes an element to the client stream.
+func (c *Client) Send(elem stravaganza.Element) {
+	c.tb.Helper()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if err := elem.ToXML(c.conn, true); err != nil {
+		c.tb.Fatalf("jackaltest: %s failed to send element: %v", c.jd, err)
+	}
+}
+
+// SendPresence sends an initial available presence.
+func (c *Client) SendPresence() {
+	c.tb.Helper()
+	c.Send(stravaganza.NewPresenceBuilder().
+		WithAttribute(stravaganza.Type, stravaganza.AvailableType).
+		Build(),
+	)
+}
+
+// Receive returns the next element received by the client stream.
+func (c *Client) Receive() stravaganza.Element {
+	c.tb.Helper()
+
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	elem, err := c.p.Parse()
+	if err != nil {
+		c.tb.Fatalf("jackaltest: %s failed to receive element: %v", c.jd, err)
+	}
+	return elem
+}
+
+// ReceiveMatching returns the first received element satisfying matchFn, discarding any other one.
+func (c *Client) ReceiveMatching(matchFn func(elem stravaganza.Element) bool) stravaganza.Element {
+	c.tb.Helper()
+
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	for {
+		elem, err := c.p.Parse()
+		if err != nil {
+			c.tb.Fatalf("jackaltest: %s failed to receive matching element: %v", c.jd, err)
+		}
+		if matchFn(elem) {
+			return elem
+		}
+	}
+}
+
+// ReceiveMessage returns the first received message stanza, discarding any other element.
+func (c *Client) ReceiveMessage() *stravaganza.Message {
+	c.tb.Helper()
+
+	elem := c.ReceiveMatching(func(elem stravaganza.Element) bool {
+		return elem.Name() == "message"
+	})
+	msg, err := stravaganza.NewBuilderFromElement(elem).BuildMessage()
+	if err != nil {
+		c.tb.Fatalf("jackaltest: %s received an invalid message: %v", c.jd, err)
+	}
+	return msg
+}
+
+// Close ends the client session.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+		_, _ = c.conn.Write([]byte("</stream:stream>"))
+		_ = c.conn.Close()
+	})
+}
+
+func (c *Client) startTLS() {
+	c.tb.Helper()
+
+	c.openStream()
+	c.Send(stravaganza.NewBuilder("starttls").
+		WithAttribute(stravaganza.Namespace, tlsNamespace).
+		Build(),
+	)
+	if elem := c.Receive(); elem.Name() != "proceed" {
+		c.tb.Fatalf("jackaltest: unexpected STARTTLS response: %s", elem)
+	}
+	tlsConn := tls.Client(c.conn, &tls.Config{
+		ServerName:         Domain,
+		InsecureSkipVerify: true,
+	})
+	_ = tlsConn.SetDeadline(time.Now().Add(c.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		c.tb.Fatalf("jackaltest: TLS handshake failed: %v", err)
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+	c.conn = tlsConn
+}
+
+// authenticate performs a SCRAM-SHA-1 authentication exchange.
+func (c *Client) authenticate(username, password string) {
+	c.tb.Helper()
+
+	c.openStream()
+
+	nonceB := make([]byte, 24)
+	_, _ = rand.Read(nonceB)
+	cNonce := base64.RawStdEncoding.EncodeToString(nonceB)
+
+	const gs2Header = "n,,"
+	clientFirstBare := "n=" + username + ",r=" + cNonce
+
+	c.Send(stravaganza.NewBuilder("auth").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		WithAttribute("mechanism", "SCRAM-SHA-1").
+		WithText(base64.StdEncoding.EncodeToString([]byte(gs2Header + clientFirstBare))).
+		Build(),
+	)
+	challenge := c.Receive()
+	if challenge.Name() != "challenge" {
+		c.tb.Fatalf("jackaltest: authentication failed for %s: %s", username, challenge)
+	}
+	serverFirst, err := base64.StdEncoding.DecodeString(challenge.Text())
+	if err != nil {
+		c.tb.Fatalf("jackaltest: invalid SCRAM challenge: %v", err)
+	}
+	params := scramParameters(string(serverFirst))
+
+	salt, err := base64.StdEncoding.DecodeString(params["s"])
+	if err != nil {
+		c.tb.Fatalf("jackaltest: invalid SCRAM salt: %v", err)
+	}
+	iterations, err := strconv.Atoi(params["i"])
+	if err != nil {
+		c.tb.Fatalf("jackaltest: invalid SCRAM iteration count: %v", err)
+	}
+	if !strings.HasPrefix(params["r"], cNonce) {
+		c.tb.Fatalf("jackaltest: invalid SCRAM server nonce")
+	}
+	saltedPassword := pbkdf2.Key([]byte(password), salt, iterations, sha1.Size, sha1.New)
+	clientKey := hmacSHA1(saltedPassword, []byte("Client Key"))
+	storedKey := sha1.Sum(clientKey)
+
+	clientFinalNoProof := "c=" + base64.StdEncoding.EncodeToString([]byte(gs2Header)) + ",r=" + params["r"]
+	authMessage := clientFirstBare + "," + string(serverFirst) + "," + clientFinalNoProof
+
+	clientSignature := hmacSHA1(storedKey[:], []byte(authMessage))
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	clientFinal := clientFinalNoProof + ",p=" + base64.StdEncoding.EncodeToString(proof)
+
+	c.Send(stravaganza.NewBuilder("response").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		WithText(base64.StdEncoding.EncodeToString([]byte(clientFinal))).
+		Build(),
+	)
+	if elem := c.Receive(); elem.Name() != "success" {
+		c.tb.Fatalf("jackaltest: authentication failed for %s: %s", username, elem)
+	}
+}
+
+func (c *Client) bind(resource string) {
+	c.tb.Helper()
+
+	c.openStream()
+
+	c.Send(stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "bind_1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithChild(
+			stravaganza.NewBuilder("bind").
+				WithAttribute(stravaganza.Namespace, bindNamespace).
+				WithChild(
+					stravaganza.NewBuilder("resource").
+						WithText(resource).
+						Build(),
+				).
+				Build(),
+		).
+		Build(),
+	)
+
+	res := c.Receive()
+	jidElem := res.ChildNamespace("bind", bindNamespace)
+	if res.Attribute(stravaganza.Type) != stravaganza.ResultType || jidElem == nil || jidElem.Child("jid") == nil {
+		c.tb.Fatalf("jackaltest: resource binding failed: %s", res)
+	}
+	jd, err := jid.NewWithString(jidElem.Child("jid").Text(), false)
+	if err != nil {
+		c.tb.Fatalf("jackaltest: invalid bound JID: %v", err)
+	}
+	c.jd = jd
+}
+
+// openStream (re)opens the client stream, returning the received stream features.
+func (c *Client) openStream() stravaganza.Element {
+	c.tb.Helper()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := fmt.Fprintf(c.conn, `<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' to='%s' version='1.0'>`, Domain)
+	if err != nil {
+		c.tb.Fatalf("jackaltest: failed to open stream: %v", err)
+	}
+	c.p = xmppparser.New(c.conn, xmppparser.SocketStream, 0)
+
+	if elem := c.Receive(); elem.Name() != "stream:stream" {
+		c.tb.Fatalf("jackaltest: unexpected stream element: %s", elem)
+	}
+	features := c.Receive()
+	if features.Name() != "stream:features" {
+		c.tb.Fatalf("jackaltest: unexpected stream features element: %s", features)
+	}
+	return features
+}
+
+func scramParameters(s string) map[string]string {
+	params := make(map[string]string)
+	for _, p := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[k] = v
+		}
+	}
+	return params
+}
+
+func hmacSHA1(key, data []byte) []byte {
+	h := hmac.New(sha1.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
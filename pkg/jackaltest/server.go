@@ -0,0 +1,206 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jackaltest provides an in-process jackal server, backed by an in-memory repository
+// and listening on ephemeral ports, along with scripted XMPP clients to write end-to-end tests.
+package jackaltest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/jackal"
+	"google.golang.org/grpc"
+)
+
+// Domain is the local domain served by test servers.
+const Domain = "localhost"
+
+const defaultTimeout = time.Second * 5
+
+const configTemplate = `
+logger:
+  level: "%s"
+http:
+  port: %d
+admin:
+  bind_addr: 127.0.0.1
+  port: %d
+storage:
+  type: memory
+hosts:
+  - domain: %s
+    tls:
+      cert_file: %s
+      privkey_file: %s
+c2s:
+  listeners:
+    - bind_addr: 127.0.0.1
+      port: %d
+`
+
+// Option defines a test server configuration option.
+type Option func(cfg *jackal.Config)
+
+// WithModules sets the set of modules enabled in the test server.
+func WithModules(modNames ...string) Option {
+	return func(cfg *jackal.Config) {
+		cfg.Modules.Enabled = modNames
+	}
+}
+
+// WithLogLevel sets the test server log level. By default, logging is turned off.
+func WithLogLevel(lv string) Option {
+	return func(cfg *jackal.Config) {
+		cfg.Logger.Level = lv
+	}
+}
+
+// Server represents an in-process jackal server.
+type Server struct {
+	tb        testing.TB
+	j         *jackal.Jackal
+	c2sAddr   string
+	adminAddr string
+}
+
+// NewServer starts a new test server, which will be stopped once the test completes.
+// Configuration options are applied on top of a minimal configuration serving Domain.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	certFile, keyFile := generateCertificate(tb, dir)
+
+	c2sPort, adminPort, httpPort := freePort(tb), freePort(tb), freePort(tb)
+
+	cfgFile := filepath.Join(dir, "config.yaml")
+	cfgData := fmt.Sprintf(configTemplate, "off", httpPort, adminPort, Domain, certFile, keyFile, c2sPort)
+	if err := os.WriteFile(cfgFile, []byte(cfgData), 0600); err != nil {
+		tb.Fatalf("jackaltest: failed to write config file: %v", err)
+	}
+	cfg, err := jackal.LoadConfig(cfgFile)
+	if err != nil {
+		tb.Fatalf("jackaltest: failed to load config: %v", err)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	j := jackal.New(io.Discard, nil)
+	if err := j.Start(cfg); err != nil {
+		tb.Fatalf("jackaltest: failed to start server: %v", err)
+	}
+	tb.Cleanup(func() { _ = j.Stop() })
+
+	return &Server{
+		tb:        tb,
+		j:         j,
+		c2sAddr:   fmt.Sprintf("127.0.0.1:%d", c2sPort),
+		adminAddr: fmt.Sprintf("127.0.0.1:%d", adminPort),
+	}
+}
+
+// C2SAddress returns the address the server accepts client connections on.
+func (s *Server) C2SAddress() string {
+	return s.c2sAddr
+}
+
+// AdminAddress returns the address of the server admin gRPC endpoint.
+func (s *Server) AdminAddress() string {
+	return s.adminAddr
+}
+
+// CreateUser registers a new user account through the admin API.
+func (s *Server) CreateUser(username, password string) {
+	s.tb.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cc, err := grpc.DialContext(ctx, s.adminAddr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		s.tb.Fatalf("jackaltest: failed to dial admin server: %v", err)
+	}
+	defer func() { _ = cc.Close() }()
+
+	_, err = adminpb.NewUsersClient(cc).CreateUser(ctx, &adminpb.CreateUserRequest{
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		s.tb.Fatalf("jackaltest: failed to create user %s: %v", username, err)
+	}
+}
+
+func freePort(tb testing.TB) int {
+	tb.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("jackaltest: failed to allocate port: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func generateCertificate(tb testing.TB, dir string) (certFile, keyFile string) {
+	tb.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatalf("jackaltest: failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: Domain},
+		DNSNames:     []string{Domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour * 24),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		tb.Fatalf("jackaltest: failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		tb.Fatalf("jackaltest: failed to marshal key: %v", err)
+	}
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		tb.Fatalf("jackaltest: failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		tb.Fatalf("jackaltest: failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jackaltest
+
+import (
+	"testing"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MessageDelivery(t *testing.T) {
+	// given
+	srv := NewServer(t)
+
+	srv.CreateUser("alice", "wonderland")
+	srv.CreateUser("bob", "builder")
+
+	alice := srv.Connect("alice", "wonderland", "rabbit-hole")
+	bob := srv.Connect("bob", "builder", "yard")
+
+	alice.SendPresence()
+	bob.SendPresence()
+
+	// when
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, alice.JID().String()).
+		WithAttribute(stravaganza.To, bob.JID().String()).
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("Can we fix it?").
+				Build(),
+		).
+		BuildMessage()
+	alice.Send(msg)
+
+	// then
+	recv := bob.ReceiveMessage()
+
+	require.Equal(t, "alice@localhost/rabbit-hole", alice.JID().String())
+	require.Equal(t, alice.JID().String(), recv.FromJID().String())
+	require.Equal(t, "Can we fix it?", recv.Child("body").Text())
+}
+
+func TestServer_RosterRequest(t *testing.T) {
+	// given
+	srv := NewServer(t, WithModules("roster"))
+	srv.CreateUser("alice", "wonderland")
+
+	alice := srv.Connect("alice", "wonderland", "rabbit-hole")
+
+	// when
+	alice.Send(stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "roster_1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, "jabber:iq:roster").
+				Build(),
+		).
+		Build(),
+	)
+
+	// then
+	res := alice.ReceiveMatching(func(elem stravaganza.Element) bool {
+		return elem.Attribute(stravaganza.ID) == "roster_1"
+	})
+	require.Equal(t, stravaganza.ResultType, res.Attribute(stravaganza.Type))
+	require.NotNil(t, res.ChildNamespace("query", "jabber:iq:roster"))
+}
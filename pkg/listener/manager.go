@@ -0,0 +1,236 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// C2S represents the client-to-server listener type.
+	C2S = "c2s"
+
+	// S2S represents the server-to-server listener type.
+	S2S = "s2s"
+
+	// Component represents the external component listener type.
+	Component = "component"
+)
+
+var (
+	// ErrNotFound will be returned when trying to remove a non registered listener.
+	ErrNotFound = errors.New("listener: not found")
+
+	// ErrUnsupportedType will be returned when no runtime factory has been registered for a listener type.
+	ErrUnsupportedType = errors.New("listener: unsupported type")
+
+	// ErrAddressInUse will be returned when trying to add a listener bound to an already registered address.
+	ErrAddressInUse = errors.New("listener: address already in use")
+)
+
+// Listener represents a network listener.
+type Listener interface {
+	// Start starts accepting incoming connections.
+	Start(ctx context.Context) error
+
+	// Stop stops accepting incoming connections.
+	Stop(ctx context.Context) error
+}
+
+// Options contains the address related settings of a listener.
+type Options struct {
+	// BindAddr is the listener bind address.
+	BindAddr string
+
+	// Port is the listener port.
+	Port int
+
+	// DirectTLS tells whether TLS is negotiated right after accepting a connection.
+	DirectTLS bool
+}
+
+// Factory creates a new listener bound to the address specified by opts.
+type Factory func(opts Options) (Listener, error)
+
+// Info describes a registered listener.
+type Info struct {
+	Options
+
+	// ID is the listener unique identifier.
+	ID string
+
+	// Type is the listener type.
+	Type string
+}
+
+type entry struct {
+	inf Info
+	seq int
+	ln  Listener
+}
+
+// Manager keeps track of the set of server listeners, allowing to add and remove them at runtime.
+type Manager struct {
+	mu        sync.Mutex
+	factories map[string]Factory
+	entries   map[string]*entry
+	seq       map[string]int
+	started   bool
+	logger    kitlog.Logger
+}
+
+// NewManager returns a new initialized listener Manager instance.
+func NewManager(logger kitlog.Logger) *Manager {
+	return &Manager{
+		factories: make(map[string]Factory),
+		entries:   make(map[string]*entry),
+		seq:       make(map[string]int),
+		logger:    logger,
+	}
+}
+
+// RegisterFactory registers the factory used to create typ listeners at runtime.
+func (m *Manager) RegisterFactory(typ string, fn Factory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.factories[typ] = fn
+}
+
+// Register registers an already created listener returning its assigned identifier.
+// In case the manager has been already started, the listener is expected to be started by the caller.
+func (m *Manager) Register(typ string, opts Options, ln Listener) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.register(typ, opts, ln)
+}
+
+// Add creates a new typ listener, starting it in case the manager has been already started.
+func (m *Manager) Add(ctx context.Context, typ string, opts Options) (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn, ok := m.factories[typ]
+	if !ok {
+		return Info{}, fmt.Errorf("%w: %s", ErrUnsupportedType, typ)
+	}
+	for _, e := range m.entries {
+		if e.inf.BindAddr == opts.BindAddr && e.inf.Port == opts.Port {
+			return Info{}, fmt.Errorf("%w: %s", ErrAddressInUse, e.inf.ID)
+		}
+	}
+	ln, err := fn(opts)
+	if err != nil {
+		return Info{}, err
+	}
+	if m.started {
+		if err := ln.Start(ctx); err != nil {
+			return Info{}, err
+		}
+	}
+	id := m.register(typ, opts, ln)
+	level.Info(m.logger).Log("msg", "listener added", "id", id, "bind_addr", opts.BindAddr, "port", opts.Port)
+
+	return m.entries[id].inf, nil
+}
+
+// Remove stops and unregisters the listener identified by id.
+// Whether already established connections are kept alive depends on the listener type.
+func (m *Manager) Remove(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if m.started {
+		if err := e.ln.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	delete(m.entries, id)
+
+	level.Info(m.logger).Log("msg", "listener removed", "id", id)
+	return nil
+}
+
+// Listeners returns the description of all registered listeners.
+func (m *Manager) Listeners() []Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]*entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].inf.Type != entries[j].inf.Type {
+			return entries[i].inf.Type < entries[j].inf.Type
+		}
+		return entries[i].seq < entries[j].seq
+	})
+	var ret []Info
+	for _, e := range entries {
+		ret = append(ret, e.inf)
+	}
+	return ret
+}
+
+// Start starts all registered listeners.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.entries {
+		if err := e.ln.Start(ctx); err != nil {
+			return err
+		}
+	}
+	m.started = true
+	return nil
+}
+
+// Stop stops all registered listeners.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.entries {
+		if err := e.ln.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	m.started = false
+	return nil
+}
+
+func (m *Manager) register(typ string, opts Options, ln Listener) string {
+	m.seq[typ]++
+	id := fmt.Sprintf("%s-%d", typ, m.seq[typ])
+
+	m.entries[id] = &entry{
+		inf: Info{Options: opts, ID: id, Type: typ},
+		seq: m.seq[typ],
+		ln:  ln,
+	}
+	return id
+}
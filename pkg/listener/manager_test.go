@@ -0,0 +1,102 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StartStop(t *testing.T) {
+	// given
+	lnMock := &listenerMock{}
+	lnMock.StartFunc = func(ctx context.Context) error { return nil }
+	lnMock.StopFunc = func(ctx context.Context) error { return nil }
+
+	m := NewManager(kitlog.NewNopLogger())
+	id := m.Register(C2S, Options{Port: 5222}, lnMock)
+
+	// when
+	_ = m.Start(context.Background())
+	_ = m.Stop(context.Background())
+
+	// then
+	require.Equal(t, "c2s-1", id)
+	require.Len(t, lnMock.StartCalls(), 1)
+	require.Len(t, lnMock.StopCalls(), 1)
+}
+
+func TestManager_Add(t *testing.T) {
+	// given
+	lnMock := &listenerMock{}
+	lnMock.StartFunc = func(ctx context.Context) error { return nil }
+
+	m := NewManager(kitlog.NewNopLogger())
+	m.Register(C2S, Options{Port: 5222}, &listenerMock{
+		StartFunc: func(ctx context.Context) error { return nil },
+	})
+
+	var factoryOpts Options
+	m.RegisterFactory(C2S, func(opts Options) (Listener, error) {
+		factoryOpts = opts
+		return lnMock, nil
+	})
+	_ = m.Start(context.Background())
+
+	// when
+	inf, err := m.Add(context.Background(), C2S, Options{Port: 5223, DirectTLS: true})
+	_, dupErr := m.Add(context.Background(), C2S, Options{Port: 5222})
+	_, typErr := m.Add(context.Background(), S2S, Options{Port: 5269})
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, "c2s-2", inf.ID)
+	require.Equal(t, C2S, inf.Type)
+	require.Equal(t, Options{Port: 5223, DirectTLS: true}, factoryOpts)
+	require.Len(t, lnMock.StartCalls(), 1)
+
+	require.True(t, errors.Is(dupErr, ErrAddressInUse))
+	require.True(t, errors.Is(typErr, ErrUnsupportedType))
+
+	infos := m.Listeners()
+	require.Len(t, infos, 2)
+	require.Equal(t, "c2s-1", infos[0].ID)
+	require.Equal(t, "c2s-2", infos[1].ID)
+}
+
+func TestManager_Remove(t *testing.T) {
+	// given
+	lnMock := &listenerMock{}
+	lnMock.StartFunc = func(ctx context.Context) error { return nil }
+	lnMock.StopFunc = func(ctx context.Context) error { return nil }
+
+	m := NewManager(kitlog.NewNopLogger())
+	id := m.Register(Component, Options{Port: 5275}, lnMock)
+	_ = m.Start(context.Background())
+
+	// when
+	err := m.Remove(context.Background(), id)
+	notFoundErr := m.Remove(context.Background(), id)
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, ErrNotFound, notFoundErr)
+	require.Len(t, lnMock.StopCalls(), 1)
+	require.Len(t, m.Listeners(), 0)
+}
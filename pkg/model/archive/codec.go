@@ -35,3 +35,23 @@ func (x *Messages) MarshalBinary() (data []byte, err error) {
 func (x *Messages) UnmarshalBinary(data []byte) error {
 	return proto.Unmarshal(data, x)
 }
+
+// MarshalBinary satisfies encoding.BinaryMarshaler interface.
+func (x *Prefs) MarshalBinary() (data []byte, err error) {
+	return proto.Marshal(x)
+}
+
+// UnmarshalBinary satisfies encoding.BinaryUnmarshaler interface.
+func (x *Prefs) UnmarshalBinary(data []byte) error {
+	return proto.Unmarshal(data, x)
+}
+
+// MarshalBinary satisfies encoding.BinaryMarshaler interface.
+func (x *Quota) MarshalBinary() (data []byte, err error) {
+	return proto.Marshal(x)
+}
+
+// UnmarshalBinary satisfies encoding.BinaryUnmarshaler interface.
+func (x *Quota) UnmarshalBinary(data []byte) error {
+	return proto.Unmarshal(data, x)
+}
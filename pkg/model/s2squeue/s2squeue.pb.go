@@ -0,0 +1,218 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        v3.21.5
+// source: proto/model/v1/s2squeue.proto
+
+package s2squeuemodel
+
+import (
+	stravaganza "github.com/jackal-xmpp/stravaganza"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Stanza represents an outgoing S2S stanza queued while its target domain is unreachable.
+type Stanza struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// domain is the stanza target remote domain.
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// stanza is the queued stanza element.
+	Stanza *stravaganza.PBElement `protobuf:"bytes,2,opt,name=stanza,proto3" json:"stanza,omitempty"`
+	// expires_at tells when the stanza should be discarded.
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// sender_domain is the local domain the stanza is sent from.
+	SenderDomain string `protobuf:"bytes,4,opt,name=sender_domain,json=senderDomain,proto3" json:"sender_domain,omitempty"`
+	// id is the storage assigned stanza identifier.
+	Id int64 `protobuf:"varint,5,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *Stanza) Reset() {
+	*x = Stanza{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_model_v1_s2squeue_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Stanza) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stanza) ProtoMessage() {}
+
+func (x *Stanza) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_model_v1_s2squeue_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stanza.ProtoReflect.Descriptor instead.
+func (*Stanza) Descriptor() ([]byte, []int) {
+	return file_proto_model_v1_s2squeue_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Stanza) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Stanza) GetStanza() *stravaganza.PBElement {
+	if x != nil {
+		return x.Stanza
+	}
+	return nil
+}
+
+func (x *Stanza) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Stanza) GetSenderDomain() string {
+	if x != nil {
+		return x.SenderDomain
+	}
+	return ""
+}
+
+func (x *Stanza) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+var File_proto_model_v1_s2squeue_proto protoreflect.FileDescriptor
+
+var file_proto_model_v1_s2squeue_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2f, 0x76, 0x31,
+	0x2f, 0x73, 0x32, 0x73, 0x71, 0x75, 0x65, 0x75, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x11, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x73, 0x32, 0x73, 0x71, 0x75, 0x65, 0x75, 0x65, 0x2e,
+	0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2d, 0x78, 0x6d, 0x70, 0x70, 0x2f, 0x73, 0x74, 0x72, 0x61,
+	0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2f, 0x73, 0x74, 0x72, 0x61, 0x76, 0x61, 0x67, 0x61,
+	0x6e, 0x7a, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc0, 0x01, 0x0a, 0x06, 0x53, 0x74,
+	0x61, 0x6e, 0x7a, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x2e, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x6e, 0x7a, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73,
+	0x74, 0x72, 0x61, 0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2e, 0x50, 0x42, 0x45, 0x6c, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x73, 0x74, 0x61, 0x6e, 0x7a, 0x61, 0x12, 0x39, 0x0a, 0x0a,
+	0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x65, 0x6e, 0x64, 0x65,
+	0x72, 0x5f, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x42, 0x23, 0x5a, 0x21,
+	0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2f, 0x73, 0x32, 0x73, 0x71, 0x75, 0x65,
+	0x75, 0x65, 0x2f, 0x3b, 0x73, 0x32, 0x73, 0x71, 0x75, 0x65, 0x75, 0x65, 0x6d, 0x6f, 0x64, 0x65,
+	0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_model_v1_s2squeue_proto_rawDescOnce sync.Once
+	file_proto_model_v1_s2squeue_proto_rawDescData = file_proto_model_v1_s2squeue_proto_rawDesc
+)
+
+func file_proto_model_v1_s2squeue_proto_rawDescGZIP() []byte {
+	file_proto_model_v1_s2squeue_proto_rawDescOnce.Do(func() {
+		file_proto_model_v1_s2squeue_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_model_v1_s2squeue_proto_rawDescData)
+	})
+	return file_proto_model_v1_s2squeue_proto_rawDescData
+}
+
+var file_proto_model_v1_s2squeue_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_model_v1_s2squeue_proto_goTypes = []interface{}{
+	(*Stanza)(nil),                // 0: model.s2squeue.v1.Stanza
+	(*stravaganza.PBElement)(nil), // 1: stravaganza.PBElement
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_proto_model_v1_s2squeue_proto_depIdxs = []int32{
+	1, // 0: model.s2squeue.v1.Stanza.stanza:type_name -> stravaganza.PBElement
+	2, // 1: model.s2squeue.v1.Stanza.expires_at:type_name -> google.protobuf.Timestamp
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proto_model_v1_s2squeue_proto_init() }
+func file_proto_model_v1_s2squeue_proto_init() {
+	if File_proto_model_v1_s2squeue_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_model_v1_s2squeue_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Stanza); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_model_v1_s2squeue_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_model_v1_s2squeue_proto_goTypes,
+		DependencyIndexes: file_proto_model_v1_s2squeue_proto_depIdxs,
+		MessageInfos:      file_proto_model_v1_s2squeue_proto_msgTypes,
+	}.Build()
+	File_proto_model_v1_s2squeue_proto = out.File
+	file_proto_model_v1_s2squeue_proto_rawDesc = nil
+	file_proto_model_v1_s2squeue_proto_goTypes = nil
+	file_proto_model_v1_s2squeue_proto_depIdxs = nil
+}
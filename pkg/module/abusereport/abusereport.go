@@ -0,0 +1,423 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abusereport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/router"
+)
+
+const (
+	discoInfoNamespace = "http://jabber.org/protocol/disco#info"
+	reportingNamespace = "urn:xmpp:reporting:1"
+
+	serverInfoFormType  = "http://jabber.org/network/serverinfo"
+	abuseAddressesField = "abuse-addresses"
+
+	xmppURIPrefix = "xmpp:"
+)
+
+// ModuleName represents abuse report module name.
+const ModuleName = "abuse_report"
+
+// ErrReportNotFound will be returned by ForwardReport and DiscardReport when no pending report matches the given identifier.
+var ErrReportNotFound = errors.New("abusereport: report not found")
+
+// Config contains abuse report module configuration options.
+type Config struct {
+	// ReviewMode holds reports until an operator forwards or discards them through the admin API.
+	ReviewMode bool `fig:"review_mode"`
+
+	// MaxReports is the maximum number of reports automatically forwarded to a remote domain within Interval.
+	MaxReports int `fig:"max_reports" default:"10"`
+
+	// Interval defines the throttling window applied to automatically forwarded reports.
+	Interval time.Duration `fig:"interval" default:"1h"`
+
+	// MaxPending is the maximum number of reports awaiting review. Oldest reports are dropped first.
+	MaxPending int `fig:"max_pending" default:"1000"`
+
+	// DiscoTimeout defines how long to wait for a remote domain to publish its abuse addresses.
+	DiscoTimeout time.Duration `fig:"disco_timeout" default:"30s"`
+}
+
+// Report represents a spam or abuse report filed against a remote JID.
+type Report struct {
+	// ID is the report unique identifier.
+	ID string
+
+	// JID is the reported JID.
+	JID jid.JID
+
+	// Reason is the report reason (e.g. urn:xmpp:reporting:spam).
+	Reason string
+
+	// Text is the optional report description provided by the reporting user.
+	Text string
+
+	// ReportedAt is the time at which the report was filed.
+	ReportedAt time.Time
+}
+
+// AbuseReport represents an abuse report forwarding module type.
+//
+// Reports filed by local users against remote JIDs (XEP-0377) are forwarded to the xmpp: abuse addresses
+// the remote domain publishes in its service discovery information (XEP-0157).
+// Reporter identity is never disclosed to the remote domain.
+type AbuseReport struct {
+	cfg    Config
+	router router.Router
+	hosts  hosts
+	hk     *hook.Hooks
+	logger kitlog.Logger
+	nowFn  func() time.Time
+
+	mu        sync.Mutex
+	pending   []Report
+	forwarded map[string][]time.Time
+	reqs      map[string]Report
+	reqTms    map[string]*time.Timer
+}
+
+// New returns a new initialized AbuseReport instance.
+func New(
+	cfg Config,
+	router router.Router,
+	hosts *host.Hosts,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+) *AbuseReport {
+	return &AbuseReport{
+		cfg:       cfg,
+		router:    router,
+		hosts:     hosts,
+		hk:        hk,
+		logger:    kitlog.With(logger, "module", ModuleName),
+		nowFn:     time.Now,
+		forwarded: make(map[string][]time.Time),
+		reqs:      make(map[string]Report),
+		reqTms:    make(map[string]*time.Timer),
+	}
+}
+
+// Name returns abuse report module name.
+func (m *AbuseReport) Name() string { return ModuleName }
+
+// StreamFeature returns abuse report module stream feature.
+func (m *AbuseReport) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns abuse report server disco features.
+func (m *AbuseReport) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns abuse report account disco features.
+func (m *AbuseReport) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts abuse report module.
+func (m *AbuseReport) Start(_ context.Context) error {
+	if m.cfg.MaxReports <= 0 {
+		return fmt.Errorf("abusereport: max reports must be positive")
+	}
+	if m.cfg.Interval <= 0 {
+		return fmt.Errorf("abusereport: interval must be positive")
+	}
+	m.hk.AddHook(hook.BlockListItemsReported, m.onItemsReported, hook.DefaultPriority)
+	m.hk.AddHook(hook.S2SInStreamIQReceived, m.onS2SIQRecv, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started abuse report module", "review_mode", m.cfg.ReviewMode)
+	return nil
+}
+
+// Stop stops abuse report module.
+func (m *AbuseReport) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.BlockListItemsReported, m.onItemsReported)
+	m.hk.RemoveHook(hook.S2SInStreamIQReceived, m.onS2SIQRecv)
+
+	m.mu.Lock()
+	for reqID, tm := range m.reqTms {
+		tm.Stop()
+		delete(m.reqTms, reqID)
+		delete(m.reqs, reqID)
+	}
+	m.mu.Unlock()
+
+	level.Info(m.logger).Log("msg", "stopped abuse report module")
+	return nil
+}
+
+// PendingReports returns all reports awaiting operator review, oldest first.
+func (m *AbuseReport) PendingReports() []Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Report(nil), m.pending...)
+}
+
+// ForwardReport forwards a pending report to the remote domain abuse addresses.
+func (m *AbuseReport) ForwardReport(ctx context.Context, id string) error {
+	r, ok := m.takePending(id)
+	if !ok {
+		return ErrReportNotFound
+	}
+	m.requestAbuseAddresses(ctx, r)
+	return nil
+}
+
+// DiscardReport discards a pending report.
+func (m *AbuseReport) DiscardReport(id string) error {
+	r, ok := m.takePending(id)
+	if !ok {
+		return ErrReportNotFound
+	}
+	level.Info(m.logger).Log("msg", "abuse report discarded", "id", r.ID, "jid", r.JID.String())
+	return nil
+}
+
+func (m *AbuseReport) onItemsReported(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.BlockListInfo)
+
+	for _, br := range inf.Reports {
+		if m.hosts.IsLocalHost(br.JID.Domain()) {
+			continue
+		}
+		r := Report{
+			ID:         uuid.New().String(),
+			JID:        br.JID,
+			Reason:     br.Reason,
+			Text:       br.Text,
+			ReportedAt: m.nowFn(),
+		}
+		if m.cfg.ReviewMode {
+			m.enqueue(r)
+			continue
+		}
+		if !m.allowForward(r.JID.Domain()) {
+			level.Warn(m.logger).Log("msg", "abuse report throttled", "jid", r.JID.String(), "domain", r.JID.Domain())
+			continue
+		}
+		m.requestAbuseAddresses(execCtx.Context, r)
+	}
+	return nil
+}
+
+func (m *AbuseReport) onS2SIQRecv(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.S2SStreamInfo)
+	iq := inf.Element.(*stravaganza.IQ)
+	if !iq.IsResult() && !iq.IsError() {
+		return nil
+	}
+	reqID := iq.Attribute(stravaganza.ID)
+
+	m.mu.Lock()
+	r, ok := m.reqs[reqID]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	if tm := m.reqTms[reqID]; tm != nil {
+		tm.Stop()
+	}
+	delete(m.reqs, reqID)
+	delete(m.reqTms, reqID)
+	m.mu.Unlock()
+
+	if iq.IsError() {
+		level.Warn(m.logger).Log("msg", "failed to discover remote abuse addresses", "domain", r.JID.Domain())
+		return nil
+	}
+	addresses := xmppAbuseAddresses(iq)
+	if len(addresses) == 0 {
+		level.Warn(m.logger).Log("msg", "remote domain publishes no xmpp abuse address", "domain", r.JID.Domain())
+		return nil
+	}
+	for _, addr := range addresses {
+		_, _ = m.router.Route(execCtx.Context, m.reportMessage(r, addr))
+	}
+	level.Info(m.logger).Log("msg", "abuse report forwarded", "id", r.ID, "jid", r.JID.String(), "reason", r.Reason)
+	return nil
+}
+
+func (m *AbuseReport) enqueue(r Report) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.pending {
+		if p.JID.String() == r.JID.String() && p.Reason == r.Reason {
+			return // already awaiting review
+		}
+	}
+	if m.cfg.MaxPending > 0 && len(m.pending) >= m.cfg.MaxPending {
+		m.pending = m.pending[1:]
+	}
+	m.pending = append(m.pending, r)
+
+	level.Info(m.logger).Log("msg", "abuse report awaiting review", "id", r.ID, "jid", r.JID.String(), "reason", r.Reason)
+}
+
+func (m *AbuseReport) takePending(id string) (Report, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, r := range m.pending {
+		if r.ID != id {
+			continue
+		}
+		m.pending = append(m.pending[:i], m.pending[i+1:]...)
+		return r, true
+	}
+	return Report{}, false
+}
+
+func (m *AbuseReport) allowForward(domain string) bool {
+	now := m.nowFn()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var recent []time.Time
+	for _, ts := range m.forwarded[domain] {
+		if now.Sub(ts) < m.cfg.Interval {
+			recent = append(recent, ts)
+		}
+	}
+	if len(recent) >= m.cfg.MaxReports {
+		m.forwarded[domain] = recent
+		return false
+	}
+	m.forwarded[domain] = append(recent, now)
+	return true
+}
+
+func (m *AbuseReport) requestAbuseAddresses(ctx context.Context, r Report) {
+	reqID := uuid.New().String()
+
+	m.mu.Lock()
+	m.reqs[reqID] = r
+	m.reqTms[reqID] = time.AfterFunc(m.cfg.DiscoTimeout, func() {
+		m.mu.Lock()
+		_, ok := m.reqs[reqID]
+		delete(m.reqs, reqID)
+		delete(m.reqTms, reqID)
+		m.mu.Unlock()
+
+		if ok {
+			level.Warn(m.logger).Log("msg", "timed out discovering remote abuse addresses", "domain", r.JID.Domain())
+		}
+	})
+	m.mu.Unlock()
+
+	discoIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, reqID).
+		WithAttribute(stravaganza.From, m.hosts.DefaultHostName()).
+		WithAttribute(stravaganza.To, r.JID.Domain()).
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				Build(),
+		).
+		BuildIQ()
+
+	_, _ = m.router.Route(ctx, discoIQ)
+}
+
+func (m *AbuseReport) reportMessage(r Report, to string) *stravaganza.Message {
+	from := m.hosts.DefaultHostName()
+
+	body := fmt.Sprintf("Abuse report from %s: %s was reported (%s).", from, r.JID.String(), r.Reason)
+	if len(r.Text) > 0 {
+		body += "\n\n" + r.Text
+	}
+	form := xep0004.DataForm{
+		Type: xep0004.Result,
+		Fields: xep0004.Fields{
+			{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{reportingNamespace}},
+			{Var: "jid", Type: xep0004.JidSingle, Values: []string{r.JID.String()}},
+			{Var: "reason", Values: []string{r.Reason}},
+			{Var: "text", Type: xep0004.TextMulti, Values: []string{r.Text}},
+			{Var: "reported-at", Values: []string{r.ReportedAt.UTC().Format(time.RFC3339)}},
+		},
+	}
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, to).
+		WithAttribute(stravaganza.Type, stravaganza.NormalType).
+		WithChild(stravaganza.NewBuilder("body").WithText(body).Build()).
+		WithChild(form.Element()).
+		BuildMessage()
+	return msg
+}
+
+func xmppAbuseAddresses(iq *stravaganza.IQ) []string {
+	q := iq.ChildNamespace("query", discoInfoNamespace)
+	if q == nil {
+		return nil
+	}
+	var retVal []string
+	for _, formEl := range q.ChildrenNamespace("x", xep0004.FormNamespace) {
+		form, err := xep0004.NewFormFromElement(formEl)
+		if err != nil {
+			continue
+		}
+		if formType := fieldValues(form.Fields, xep0004.FormType); len(formType) == 0 || formType[0] != serverInfoFormType {
+			continue
+		}
+		for _, addr := range fieldValues(form.Fields, abuseAddressesField) {
+			if !strings.HasPrefix(addr, xmppURIPrefix) {
+				continue
+			}
+			addr = strings.TrimPrefix(addr, xmppURIPrefix)
+			if i := strings.IndexByte(addr, '?'); i >= 0 {
+				addr = addr[:i]
+			}
+			j, err := jid.NewWithString(addr, false)
+			if err != nil {
+				continue
+			}
+			retVal = append(retVal, j.String())
+		}
+	}
+	return retVal
+}
+
+// fieldValues returns all values of a form field regardless of its type.
+func fieldValues(fields xep0004.Fields, name string) []string {
+	for _, f := range fields {
+		if f.Var == name {
+			return f.Values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abusereport
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbuseReport_Forward(t *testing.T) {
+	// given
+	m, hk, routed := testAbuseReport(t, Config{MaxReports: 10, Interval: time.Hour, DiscoTimeout: time.Minute})
+
+	// when
+	runReported(t, hk, "spammer@jabber.org", "ortuman@jackal.im")
+
+	// then
+	stanzas := routed()
+	require.Len(t, stanzas, 1) // only remote JIDs are reported
+
+	discoIQ := stanzas[0]
+	require.Equal(t, stravaganza.IQName, discoIQ.Name())
+	require.Equal(t, "jackal.im", discoIQ.Attribute(stravaganza.From))
+	require.Equal(t, "jabber.org", discoIQ.Attribute(stravaganza.To))
+	require.NotNil(t, discoIQ.ChildNamespace("query", discoInfoNamespace))
+
+	// when
+	runDiscoResult(t, hk, discoIQ.Attribute(stravaganza.ID), []string{
+		"mailto:abuse@jabber.org",
+		"xmpp:abuse@jabber.org?message",
+	})
+
+	// then
+	stanzas = routed()
+	require.Len(t, stanzas, 2)
+
+	msg := stanzas[1]
+	require.Equal(t, stravaganza.MessageName, msg.Name())
+	require.Equal(t, "jackal.im", msg.Attribute(stravaganza.From))
+	require.Equal(t, "abuse@jabber.org", msg.Attribute(stravaganza.To))
+
+	form, err := xep0004.NewFormFromElement(msg.ChildNamespace("x", xep0004.FormNamespace))
+	require.NoError(t, err)
+	require.Equal(t, "spammer@jabber.org", form.Fields.ValueForFieldOfType("jid", xep0004.JidSingle))
+	require.Equal(t, "urn:xmpp:reporting:spam", form.Fields.ValueForField("reason"))
+	require.NotContains(t, msg.String(), "ortuman") // reporter identity is never disclosed
+
+	require.Len(t, m.reqs, 0)
+}
+
+func TestAbuseReport_Throttle(t *testing.T) {
+	// given
+	m, hk, routed := testAbuseReport(t, Config{MaxReports: 2, Interval: time.Hour, DiscoTimeout: time.Minute})
+	now := time.Now()
+
+	// when
+	for i := 0; i < 3; i++ {
+		runReported(t, hk, "spammer@jabber.org")
+	}
+	// then
+	require.Len(t, routed(), 2)
+
+	// when
+	m.nowFn = func() time.Time { return now.Add(2 * time.Hour) }
+	runReported(t, hk, "spammer@jabber.org")
+
+	// then
+	require.Len(t, routed(), 3)
+}
+
+func TestAbuseReport_ReviewMode(t *testing.T) {
+	// given
+	m, hk, routed := testAbuseReport(t, Config{ReviewMode: true, MaxReports: 10, Interval: time.Hour, MaxPending: 2, DiscoTimeout: time.Minute})
+
+	// when
+	runReported(t, hk, "spammer@jabber.org")
+	runReported(t, hk, "spammer@jabber.org") // duplicated
+	runReported(t, hk, "troll@jabber.org")
+	runReported(t, hk, "bot@xmpp.org")
+
+	// then
+	require.Len(t, routed(), 0)
+
+	pending := m.PendingReports()
+	require.Len(t, pending, 2)
+	require.Equal(t, "troll@jabber.org", pending[0].JID.String())
+	require.Equal(t, "bot@xmpp.org", pending[1].JID.String())
+
+	// when
+	require.NoError(t, m.DiscardReport(pending[0].ID))
+	require.NoError(t, m.ForwardReport(context.Background(), pending[1].ID))
+
+	// then
+	require.Len(t, m.PendingReports(), 0)
+	require.Equal(t, ErrReportNotFound, m.DiscardReport(pending[0].ID))
+
+	stanzas := routed()
+	require.Len(t, stanzas, 1)
+	require.Equal(t, "xmpp.org", stanzas[0].Attribute(stravaganza.To))
+}
+
+func testAbuseReport(t *testing.T, cfg Config) (*AbuseReport, *hook.Hooks, func() []stravaganza.Stanza) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var routed []stravaganza.Stanza
+	routerMock := &routerMock{
+		RouteFunc: func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+			mu.Lock()
+			routed = append(routed, stanza)
+			mu.Unlock()
+			return nil, nil
+		},
+	}
+	hostsMock := &hostsMock{
+		DefaultHostNameFunc: func() string { return "jackal.im" },
+		IsLocalHostFunc:     func(h string) bool { return h == "jackal.im" },
+	}
+	hk := hook.NewHooks()
+	m := &AbuseReport{
+		cfg:       cfg,
+		router:    routerMock,
+		hosts:     hostsMock,
+		hk:        hk,
+		logger:    kitlog.NewNopLogger(),
+		nowFn:     time.Now,
+		forwarded: make(map[string][]time.Time),
+		reqs:      make(map[string]Report),
+		reqTms:    make(map[string]*time.Timer),
+	}
+	require.NoError(t, m.Start(context.Background()))
+	t.Cleanup(func() { _ = m.Stop(context.Background()) })
+
+	return m, hk, func() []stravaganza.Stanza {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]stravaganza.Stanza(nil), routed...)
+	}
+}
+
+func runReported(t *testing.T, hk *hook.Hooks, jids ...string) {
+	t.Helper()
+
+	var reports []hook.BlockListReport
+	for _, js := range jids {
+		j, err := jid.NewWithString(js, false)
+		require.NoError(t, err)
+		reports = append(reports, hook.BlockListReport{JID: *j, Reason: "urn:xmpp:reporting:spam", Text: "Buy cheap stuff!"})
+	}
+	_, err := hk.Run(hook.BlockListItemsReported, &hook.ExecutionContext{
+		Info:    &hook.BlockListInfo{Username: "noelia", Reports: reports},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+}
+
+func runDiscoResult(t *testing.T, hk *hook.Hooks, id string, abuseAddresses []string) {
+	t.Helper()
+
+	form := xep0004.DataForm{
+		Type: xep0004.Result,
+		Fields: xep0004.Fields{
+			{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{serverInfoFormType}},
+			{Var: abuseAddressesField, Type: xep0004.ListMulti, Values: abuseAddresses},
+		},
+	}
+	iq, err := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, id).
+		WithAttribute(stravaganza.From, "jabber.org").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.ResultType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				WithChild(form.Element()).
+				Build(),
+		).
+		BuildIQ()
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.S2SInStreamIQReceived, &hook.ExecutionContext{
+		Info:    &hook.S2SStreamInfo{Sender: "jabber.org", Element: iq},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,280 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package announce
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/router"
+)
+
+const (
+	endpointPath = "/api/v1/messages"
+
+	maxRequestSize = 64 * 1024
+)
+
+// ModuleName represents announce module name.
+const ModuleName = "announce"
+
+// delivery status values reported for every recipient.
+const (
+	statusDelivered   = "delivered"
+	statusUnavailable = "unavailable"
+	statusNotFound    = "not_found"
+	statusFailed      = "failed"
+)
+
+// Config contains announce module configuration options.
+type Config struct {
+	// BindAddr defines message injection HTTP listener address.
+	BindAddr string `fig:"bind_addr"`
+
+	// Port defines message injection HTTP listener port.
+	Port int `fig:"port" default:"5282"`
+
+	// Token is the bearer token required to authenticate requests.
+	Token string `fig:"token"`
+
+	// From is the system JID used as injected messages sender. Defaults to the server domain.
+	From string `fig:"from"`
+
+	// Groups contains the set of named broadcast groups and their member JIDs.
+	Groups map[string][]string `fig:"groups"`
+}
+
+// Announce represents a message injection module type.
+type Announce struct {
+	cfg    Config
+	router router.Router
+	hosts  hosts
+	srv    *http.Server
+	logger kitlog.Logger
+}
+
+type messageRequest struct {
+	To      []string `json:"to"`
+	Group   string   `json:"group"`
+	Type    string   `json:"type"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+type deliveryResult struct {
+	JID    string `json:"jid"`
+	Status string `json:"status"`
+}
+
+type messageResponse struct {
+	Results []deliveryResult `json:"results"`
+}
+
+// New returns a new initialized Announce instance.
+func New(
+	cfg Config,
+	router router.Router,
+	hosts *host.Hosts,
+	logger kitlog.Logger,
+) *Announce {
+	return &Announce{
+		cfg:    cfg,
+		router: router,
+		hosts:  hosts,
+		logger: kitlog.With(logger, "module", ModuleName),
+	}
+}
+
+// Name returns announce module name.
+func (m *Announce) Name() string { return ModuleName }
+
+// StreamFeature returns announce module stream feature.
+func (m *Announce) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns announce server disco features.
+func (m *Announce) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns announce account disco features.
+func (m *Announce) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts announce module.
+func (m *Announce) Start(_ context.Context) error {
+	if len(m.cfg.Token) == 0 {
+		return errors.New("announce: token must be set")
+	}
+	if len(m.cfg.From) > 0 {
+		if _, err := jid.NewWithString(m.cfg.From, false); err != nil {
+			return fmt.Errorf("announce: invalid from jid: %s", m.cfg.From)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle(endpointPath, m)
+
+	m.srv = &http.Server{Handler: mux}
+	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", m.cfg.BindAddr, m.cfg.Port))
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := m.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			level.Error(m.logger).Log("msg", "failed to serve announce endpoint", "err", err)
+		}
+	}()
+	level.Info(m.logger).Log("msg", "started announce module", "port", m.cfg.Port)
+	return nil
+}
+
+// Stop stops announce module.
+func (m *Announce) Stop(ctx context.Context) error {
+	if err := m.srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	level.Info(m.logger).Log("msg", "stopped announce module")
+	return nil
+}
+
+// ServeHTTP handles message injection requests.
+func (m *Announce) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !m.isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req messageRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	recipients, err := m.recipients(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msgType := req.Type
+	switch msgType {
+	case "":
+		msgType = stravaganza.NormalType
+	case stravaganza.NormalType, stravaganza.ChatType, stravaganza.HeadlineType:
+		break
+	default:
+		http.Error(w, fmt.Sprintf("unsupported message type: %s", msgType), http.StatusBadRequest)
+		return
+	}
+	if len(req.Body) == 0 && len(req.Subject) == 0 {
+		http.Error(w, "empty message", http.StatusBadRequest)
+		return
+	}
+	var resp messageResponse
+	for _, toJID := range recipients {
+		resp.Results = append(resp.Results, deliveryResult{
+			JID:    toJID.String(),
+			Status: m.sendMessage(r.Context(), toJID, msgType, req.Subject, req.Body),
+		})
+	}
+	level.Info(m.logger).Log("msg", "injected message", "recipients", len(recipients), "group", req.Group)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&resp)
+}
+
+func (m *Announce) isAuthorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(m.cfg.Token)) == 1
+}
+
+func (m *Announce) recipients(req *messageRequest) ([]*jid.JID, error) {
+	jids := req.To
+	if len(req.Group) > 0 {
+		members, ok := m.cfg.Groups[req.Group]
+		if !ok {
+			return nil, fmt.Errorf("unknown group: %s", req.Group)
+		}
+		jids = append(jids, members...)
+	}
+	if len(jids) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	var retVal []*jid.JID
+
+	seen := make(map[string]struct{}, len(jids))
+	for _, j := range jids {
+		toJID, err := jid.NewWithString(j, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient: %s", j)
+		}
+		if !toJID.IsBare() || !m.hosts.IsLocalHost(toJID.Domain()) {
+			return nil, fmt.Errorf("recipient must be a local user: %s", j)
+		}
+		if _, ok := seen[toJID.String()]; ok {
+			continue
+		}
+		seen[toJID.String()] = struct{}{}
+		retVal = append(retVal, toJID)
+	}
+	return retVal, nil
+}
+
+func (m *Announce) sendMessage(ctx context.Context, toJID *jid.JID, msgType, subject, body string) string {
+	from := m.cfg.From
+	if len(from) == 0 {
+		from = m.hosts.DefaultHostName()
+	}
+	b := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, msgType).
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, toJID.String())
+	if len(subject) > 0 {
+		b.WithChild(stravaganza.NewBuilder("subject").WithText(subject).Build())
+	}
+	if len(body) > 0 {
+		b.WithChild(stravaganza.NewBuilder("body").WithText(body).Build())
+	}
+	msg, _ := b.BuildMessage()
+
+	_, err := m.router.Route(ctx, msg)
+	switch {
+	case err == nil:
+		return statusDelivered
+	case errors.Is(err, router.ErrResourceNotFound), errors.Is(err, router.ErrUserNotAvailable):
+		return statusUnavailable
+	case errors.Is(err, router.ErrNotExistingAccount):
+		return statusNotFound
+	default:
+		level.Warn(m.logger).Log("msg", "failed to route injected message", "jid", toJID.String(), "err", err)
+		return statusFailed
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+)
+
+// ModuleName represents ClickHouse sink module name.
+const ModuleName = "clickhouse"
+
+const stampLayout = "2006-01-02 15:04:05.000"
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Config contains ClickHouse sink module configuration options.
+type Config struct {
+	// URL is the ClickHouse HTTP interface endpoint.
+	URL string `fig:"url" default:"http://127.0.0.1:8123"`
+
+	// Database is the ClickHouse database name.
+	Database string `fig:"database" default:"default"`
+
+	// Table is the ClickHouse table into which archived message rows are inserted.
+	Table string `fig:"table" default:"archive_messages"`
+
+	// Username is the ClickHouse authentication username.
+	Username string `fig:"username"`
+
+	// Password is the ClickHouse authentication password.
+	Password string `fig:"password"`
+
+	// IncludeBody tells whether message bodies should be exported along with message metadata.
+	IncludeBody bool `fig:"include_body"`
+
+	// BufferSize defines the maximum number of pending rows. Rows are dropped once the buffer is full.
+	BufferSize int `fig:"buffer_size" default:"10000"`
+
+	// BatchSize defines the maximum number of rows sent in a single insert.
+	BatchSize int `fig:"batch_size" default:"1000"`
+
+	// FlushInterval defines the maximum time a row is kept in memory before being sent.
+	FlushInterval time.Duration `fig:"flush_interval" default:"5s"`
+
+	// Timeout defines insert request timeout.
+	Timeout time.Duration `fig:"timeout" default:"10s"`
+}
+
+type row struct {
+	ArchiveID string `json:"archive_id"`
+	ID        string `json:"id"`
+	From      string `json:"from_jid"`
+	To        string `json:"to_jid"`
+	Type      string `json:"type"`
+	HasBody   uint8  `json:"has_body"`
+	Body      string `json:"body,omitempty"`
+	Stamp     string `json:"stamp"`
+}
+
+// ClickHouse represents a module that streams archived message metadata into ClickHouse.
+type ClickHouse struct {
+	cfg    Config
+	hk     *hook.Hooks
+	client *http.Client
+	logger kitlog.Logger
+
+	rowCh  chan row
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New returns a new initialized ClickHouse instance.
+func New(cfg Config, hk *hook.Hooks, logger kitlog.Logger) *ClickHouse {
+	return &ClickHouse{
+		cfg:    cfg,
+		hk:     hk,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: kitlog.With(logger, "module", ModuleName),
+	}
+}
+
+// Name returns ClickHouse sink module name.
+func (m *ClickHouse) Name() string { return ModuleName }
+
+// StreamFeature returns ClickHouse sink module stream feature.
+func (m *ClickHouse) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns ClickHouse sink server disco features.
+func (m *ClickHouse) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns ClickHouse sink account disco features.
+func (m *ClickHouse) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts ClickHouse sink module.
+func (m *ClickHouse) Start(_ context.Context) error {
+	if len(m.cfg.URL) == 0 {
+		return errors.New("clickhouse: url must be set")
+	}
+	if _, err := url.Parse(m.cfg.URL); err != nil {
+		return fmt.Errorf("clickhouse: invalid url: %w", err)
+	}
+	if !identifierRe.MatchString(m.cfg.Database) || !identifierRe.MatchString(m.cfg.Table) {
+		return errors.New("clickhouse: invalid database or table name")
+	}
+	if m.cfg.BufferSize <= 0 || m.cfg.BatchSize <= 0 || m.cfg.FlushInterval <= 0 {
+		return errors.New("clickhouse: buffer_size, batch_size and flush_interval must be positive")
+	}
+	m.rowCh = make(chan row, m.cfg.BufferSize)
+	m.stopCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+	go m.loop()
+
+	m.hk.AddHook(hook.ArchiveMessageArchived, m.onMessageArchived, hook.LowestPriority)
+
+	level.Info(m.logger).Log("msg", "started clickhouse sink module", "url", m.cfg.URL, "table", m.tableName())
+	return nil
+}
+
+// Stop stops ClickHouse sink module flushing all pending rows.
+func (m *ClickHouse) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.ArchiveMessageArchived, m.onMessageArchived)
+
+	close(m.stopCh)
+	<-m.doneCh
+
+	level.Info(m.logger).Log("msg", "stopped clickhouse sink module")
+	return nil
+}
+
+func (m *ClickHouse) onMessageArchived(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.MamInfo)
+	if inf.Message == nil {
+		return nil
+	}
+	r, err := m.buildRow(inf)
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to build clickhouse row", "err", err)
+		return nil
+	}
+	select {
+	case m.rowCh <- r:
+	default:
+		level.Warn(m.logger).Log("msg", "clickhouse buffer full, dropping row", "archive_id", r.ArchiveID, "id", r.ID)
+	}
+	return nil
+}
+
+func (m *ClickHouse) buildRow(inf *hook.MamInfo) (row, error) {
+	aMsg := inf.Message
+
+	r := row{
+		ArchiveID: aMsg.ArchiveId,
+		ID:        aMsg.Id,
+		From:      aMsg.FromJid,
+		To:        aMsg.ToJid,
+		Stamp:     aMsg.Stamp.AsTime().UTC().Format(stampLayout),
+	}
+	if aMsg.Message != nil {
+		msg, err := stravaganza.NewBuilderFromProto(aMsg.Message).BuildMessage()
+		if err != nil {
+			return row{}, err
+		}
+		r.Type = msg.Type()
+		if body := msg.Child("body"); body != nil {
+			r.HasBody = 1
+			if m.cfg.IncludeBody {
+				r.Body = body.Text()
+			}
+		}
+	}
+	return r, nil
+}
+
+func (m *ClickHouse) loop() {
+	defer close(m.doneCh)
+
+	tc := time.NewTicker(m.cfg.FlushInterval)
+	defer tc.Stop()
+
+	batch := make([]row, 0, m.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := m.insert(batch); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to insert clickhouse rows", "count", len(batch), "err", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case r := <-m.rowCh:
+			batch = append(batch, r)
+			if len(batch) >= m.cfg.BatchSize {
+				flush()
+			}
+
+		case <-tc.C:
+			flush()
+
+		case <-m.stopCh:
+			for {
+				select {
+				case r := <-m.rowCh:
+					batch = append(batch, r)
+					if len(batch) >= m.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (m *ClickHouse) insert(rows []row) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	u, err := url.Parse(m.cfg.URL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", m.tableName()))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if len(m.cfg.Username) > 0 {
+		req.Header.Set("X-ClickHouse-User", m.cfg.Username)
+		req.Header.Set("X-ClickHouse-Key", m.cfg.Password)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("clickhouse: unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(b))
+	}
+	return nil
+}
+
+func (m *ClickHouse) tableName() string {
+	return m.cfg.Database + "." + m.cfg.Table
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestClickHouse_InsertRows(t *testing.T) {
+	// given
+	var mu sync.Mutex
+	var queries []string
+	var users []string
+	var rows []map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, r.URL.Query().Get("query"))
+		users = append(users, r.Header.Get("X-ClickHouse-User"))
+
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var m map[string]interface{}
+			require.NoError(t, json.Unmarshal(sc.Bytes(), &m))
+			rows = append(rows, m)
+		}
+	}))
+	defer srv.Close()
+
+	hk := hook.NewHooks()
+	m := testClickHouse(Config{
+		URL:           srv.URL,
+		Database:      "jackal",
+		Table:         "archive",
+		Username:      "jackal",
+		BufferSize:    10,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Timeout:       time.Second,
+	}, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	for i := 0; i < 3; i++ {
+		_, err := hk.Run(hook.ArchiveMessageArchived, &hook.ExecutionContext{
+			Info: &hook.MamInfo{
+				ArchiveID: "ortuman",
+				Message:   testArchiveMessage(),
+			},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, m.Stop(context.Background()))
+
+	// then
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, queries, 2)
+	require.Equal(t, "INSERT INTO jackal.archive FORMAT JSONEachRow", queries[0])
+	require.Equal(t, "jackal", users[0])
+
+	require.Len(t, rows, 3)
+	require.Equal(t, "ortuman", rows[0]["archive_id"])
+	require.Equal(t, "ortuman@jackal.im/yard", rows[0]["from_jid"])
+	require.Equal(t, "noelia@jackal.im/balcony", rows[0]["to_jid"])
+	require.Equal(t, "chat", rows[0]["type"])
+	require.Equal(t, float64(1), rows[0]["has_body"])
+	require.Equal(t, "2022-01-02 03:04:05.000", rows[0]["stamp"])
+	_, ok := rows[0]["body"]
+	require.False(t, ok)
+}
+
+func TestClickHouse_IncludeBody(t *testing.T) {
+	// given
+	m := testClickHouse(Config{IncludeBody: true}, hook.NewHooks())
+
+	// when
+	r, err := m.buildRow(&hook.MamInfo{ArchiveID: "ortuman", Message: testArchiveMessage()})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "Hi there!", r.Body)
+	require.Equal(t, uint8(1), r.HasBody)
+}
+
+func TestClickHouse_DropWhenFull(t *testing.T) {
+	// given
+	m := testClickHouse(Config{}, hook.NewHooks())
+	m.rowCh = make(chan row, 1)
+
+	// when
+	for i := 0; i < 2; i++ {
+		err := m.onMessageArchived(&hook.ExecutionContext{
+			Info:    &hook.MamInfo{ArchiveID: "ortuman", Message: testArchiveMessage()},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+
+	// then
+	require.Len(t, m.rowCh, 1)
+}
+
+func TestClickHouse_InvalidConfig(t *testing.T) {
+	// given
+	m := testClickHouse(Config{
+		URL:           "http://127.0.0.1:8123",
+		Database:      "jackal",
+		Table:         "archive; DROP TABLE users",
+		BufferSize:    10,
+		BatchSize:     10,
+		FlushInterval: time.Second,
+	}, hook.NewHooks())
+
+	// when
+	err := m.Start(context.Background())
+
+	// then
+	require.Error(t, err)
+}
+
+func testClickHouse(cfg Config, hk *hook.Hooks) *ClickHouse {
+	return New(cfg, hk, kitlog.NewNopLogger())
+}
+
+func testArchiveMessage() *archivemodel.Message {
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "ortuman@jackal.im/yard")
+	b.WithAttribute("to", "noelia@jackal.im/balcony")
+	b.WithAttribute("type", "chat")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("Hi there!").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+
+	return &archivemodel.Message{
+		ArchiveId: "ortuman",
+		Id:        "1",
+		FromJid:   "ortuman@jackal.im/yard",
+		ToJid:     "noelia@jackal.im/balcony",
+		Message:   msg.Proto(),
+		Stamp:     timestamppb.New(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emailnotify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+const (
+	// preferencesNamespace is the private storage (XEP-0049) namespace used to store user notification preferences.
+	preferencesNamespace = "jackal:email-notifications"
+
+	defaultSubject = "You have new messages on {{.Domain}}"
+
+	defaultTemplate = `Hi {{.Username}},
+
+You have {{.Count}} unread message(s) waiting for you on {{.Domain}}.
+Log in with your XMPP client to read them.
+`
+)
+
+// ModuleName represents email notification module name.
+const ModuleName = "email_notify"
+
+// Config contains email notification module configuration options.
+type Config struct {
+	// Host is the SMTP server host.
+	Host string `fig:"host"`
+
+	// Port is the SMTP server port.
+	Port int `fig:"port" default:"587"`
+
+	// Username is the SMTP authentication username.
+	Username string `fig:"username"`
+
+	// Password is the SMTP authentication password.
+	Password string `fig:"password"`
+
+	// From is the notification sender email address.
+	From string `fig:"from"`
+
+	// Subject is the notification subject template.
+	Subject string `fig:"subject"`
+
+	// Template is the notification body template.
+	Template string `fig:"template"`
+
+	// Debounce defines the time to wait after a message is stored offline before notifying the user.
+	Debounce time.Duration `fig:"debounce" default:"5m"`
+}
+
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailNotify represents an offline message email notification module type.
+type EmailNotify struct {
+	cfg      Config
+	rep      repository.Repository
+	hk       *hook.Hooks
+	logger   kitlog.Logger
+	sendMail sendMailFunc
+
+	subjectTmpl *template.Template
+	bodyTmpl    *template.Template
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+}
+
+type notification struct {
+	Username string
+	Domain   string
+	Count    int
+}
+
+// New returns a new initialized EmailNotify instance.
+func New(
+	cfg Config,
+	rep repository.Repository,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+) *EmailNotify {
+	return &EmailNotify{
+		cfg:      cfg,
+		rep:      rep,
+		hk:       hk,
+		logger:   kitlog.With(logger, "module", ModuleName),
+		sendMail: smtp.SendMail,
+		pending:  make(map[string]*time.Timer),
+	}
+}
+
+// Name returns email notification module name.
+func (m *EmailNotify) Name() string { return ModuleName }
+
+// StreamFeature returns email notification module stream feature.
+func (m *EmailNotify) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns email notification server disco features.
+func (m *EmailNotify) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns email notification account disco features.
+func (m *EmailNotify) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts email notification module.
+func (m *EmailNotify) Start(_ context.Context) error {
+	if len(m.cfg.Host) == 0 || len(m.cfg.From) == 0 {
+		return errors.New("email_notify: host and from must be set")
+	}
+	if err := m.parseTemplates(); err != nil {
+		return err
+	}
+	m.hk.AddHook(hook.OfflineMessageArchived, m.onOfflineMessageArchived, hook.DefaultPriority)
+	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started email notification module")
+	return nil
+}
+
+// Stop stops email notification module.
+func (m *EmailNotify) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.OfflineMessageArchived, m.onOfflineMessageArchived)
+	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+
+	m.mu.Lock()
+	for username, tm := range m.pending {
+		tm.Stop()
+		delete(m.pending, username)
+	}
+	m.mu.Unlock()
+
+	level.Info(m.logger).Log("msg", "stopped email notification module")
+	return nil
+}
+
+func (m *EmailNotify) parseTemplates() error {
+	subject := m.cfg.Subject
+	if len(subject) == 0 {
+		subject = defaultSubject
+	}
+	body := m.cfg.Template
+	if len(body) == 0 {
+		body = defaultTemplate
+	}
+	var err error
+	m.subjectTmpl, err = template.New("subject").Parse(subject)
+	if err != nil {
+		return fmt.Errorf("email_notify: invalid subject template: %w", err)
+	}
+	m.bodyTmpl, err = template.New("body").Parse(body)
+	if err != nil {
+		return fmt.Errorf("email_notify: invalid body template: %w", err)
+	}
+	return nil
+}
+
+func (m *EmailNotify) onOfflineMessageArchived(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.OfflineInfo)
+	domain := inf.Message.ToJID().Domain()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pending[inf.Username]; ok {
+		return nil // notification already scheduled
+	}
+	m.pending[inf.Username] = time.AfterFunc(m.cfg.Debounce, func() {
+		m.mu.Lock()
+		delete(m.pending, inf.Username)
+		m.mu.Unlock()
+
+		if err := m.notify(context.Background(), inf.Username, domain); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to send email notification", "username", inf.Username, "err", err)
+		}
+	})
+	return nil
+}
+
+func (m *EmailNotify) onUserDeleted(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.UserInfo)
+
+	m.mu.Lock()
+	if tm, ok := m.pending[inf.Username]; ok {
+		tm.Stop()
+		delete(m.pending, inf.Username)
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *EmailNotify) notify(ctx context.Context, username, domain string) error {
+	// offline queue may have been already delivered during debounce window
+	count, err := m.rep.CountOfflineMessages(ctx, username)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	optedOut, err := m.isOptedOut(ctx, username)
+	if err != nil {
+		return err
+	}
+	if optedOut {
+		return nil
+	}
+	addr, err := m.emailAddress(ctx, username)
+	if err != nil {
+		return err
+	}
+	if len(addr) == 0 {
+		return nil // no known email address
+	}
+	msg, err := m.renderMessage(addr, &notification{
+		Username: username,
+		Domain:   domain,
+		Count:    count,
+	})
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if len(m.cfg.Username) > 0 {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	if err := m.sendMail(fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port), auth, m.cfg.From, []string{addr}, msg); err != nil {
+		return err
+	}
+	level.Info(m.logger).Log("msg", "sent email notification", "username", username, "count", count)
+	return nil
+}
+
+// isOptedOut tells whether a user disabled email notifications by storing
+// <email-notifications xmlns='jackal:email-notifications' enabled='false'/> in its private storage.
+func (m *EmailNotify) isOptedOut(ctx context.Context, username string) (bool, error) {
+	prefs, err := m.rep.FetchPrivate(ctx, preferencesNamespace, username)
+	if err != nil {
+		return false, err
+	}
+	return prefs != nil && prefs.Attribute("enabled") == "false", nil
+}
+
+func (m *EmailNotify) emailAddress(ctx context.Context, username string) (string, error) {
+	vCard, err := m.rep.FetchVCard(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	if vCard == nil {
+		return "", nil
+	}
+	for _, email := range vCard.Children("EMAIL") {
+		userID := email.Child("USERID")
+		if userID == nil {
+			continue
+		}
+		addr, err := mail.ParseAddress(strings.TrimSpace(userID.Text()))
+		if err != nil {
+			continue
+		}
+		return addr.Address, nil
+	}
+	return "", nil
+}
+
+func (m *EmailNotify) renderMessage(to string, n *notification) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := m.subjectTmpl.Execute(&subject, n); err != nil {
+		return nil, err
+	}
+	if err := m.bodyTmpl.Execute(&body, n); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("From: " + m.cfg.From + "\r\n")
+	buf.WriteString("To: " + to + "\r\n")
+	buf.WriteString("Subject: " + strings.Join(strings.Fields(subject.String()), " ") + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emailnotify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailNotify_NotifyOfflineMessages(t *testing.T) {
+	// given
+	repMock := testRepository()
+
+	var mu sync.Mutex
+	var sent []string
+	hk := hook.NewHooks()
+	m := testEmailNotify(repMock, hk, func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, "smtp.jackal.im:587", addr)
+		require.Equal(t, "noreply@jackal.im", from)
+		require.Equal(t, []string{"ortuman@mail.jackal.im"}, to)
+		sent = append(sent, string(msg))
+		return nil
+	})
+	require.NoError(t, m.Start(context.Background()))
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	// when
+	for i := 0; i < 2; i++ {
+		_, err := hk.Run(hook.OfflineMessageArchived, &hook.ExecutionContext{
+			Info: &hook.OfflineInfo{
+				Username: "ortuman",
+				Message:  testMessage(),
+			},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+	time.Sleep(time.Millisecond * 100) // wait for debounce window
+
+	// then
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, sent, 1)
+	require.True(t, strings.Contains(sent[0], "Subject: You have new messages on jackal.im\r\n"))
+	require.True(t, strings.Contains(sent[0], "You have 2 unread message(s)"))
+}
+
+func TestEmailNotify_OptedOut(t *testing.T) {
+	// given
+	repMock := testRepository()
+	repMock.FetchPrivateFunc = func(ctx context.Context, namespace string, username string) (stravaganza.Element, error) {
+		return stravaganza.NewBuilder("email-notifications").
+			WithAttribute(stravaganza.Namespace, preferencesNamespace).
+			WithAttribute("enabled", "false").
+			Build(), nil
+	}
+	var sent int
+	m := testEmailNotify(repMock, hook.NewHooks(), func(_ string, _ smtp.Auth, _ string, _ []string, _ []byte) error {
+		sent++
+		return nil
+	})
+	require.NoError(t, m.parseTemplates())
+
+	// when
+	err := m.notify(context.Background(), "ortuman", "jackal.im")
+
+	// then
+	require.NoError(t, err)
+	require.Zero(t, sent)
+}
+
+func testRepository() *repositoryMock {
+	repMock := &repositoryMock{}
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 2, nil
+	}
+	repMock.FetchPrivateFunc = func(ctx context.Context, namespace string, username string) (stravaganza.Element, error) {
+		return nil, nil
+	}
+	repMock.FetchVCardFunc = func(ctx context.Context, username string) (stravaganza.Element, error) {
+		return stravaganza.NewBuilder("vCard").
+			WithAttribute(stravaganza.Namespace, "vcard-temp").
+			WithChild(
+				stravaganza.NewBuilder("EMAIL").
+					WithChild(stravaganza.NewBuilder("INTERNET").Build()).
+					WithChild(stravaganza.NewBuilder("USERID").WithText("ortuman@mail.jackal.im").Build()).
+					Build(),
+			).
+			Build(), nil
+	}
+	return repMock
+}
+
+func testEmailNotify(repMock *repositoryMock, hk *hook.Hooks, sendMail sendMailFunc) *EmailNotify {
+	return &EmailNotify{
+		cfg: Config{
+			Host:     "smtp.jackal.im",
+			Port:     587,
+			From:     "noreply@jackal.im",
+			Debounce: time.Millisecond * 20,
+		},
+		rep:      repMock,
+		hk:       hk,
+		logger:   kitlog.NewNopLogger(),
+		sendMail: sendMail,
+		pending:  make(map[string]*time.Timer),
+	}
+}
+
+func testMessage() *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(stravaganza.NewBuilder("body").WithText("I'll give thee a wind.").Build()).
+		BuildMessage()
+	return msg
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventstream
+
+import "time"
+
+// Event types. Each event is published to the `<subject_prefix>.<type>` subject.
+const (
+	MessageRoutedEvent   = "message_routed"
+	MessageArchivedEvent = "message_archived"
+	SessionOpenedEvent   = "session_opened"
+	SessionClosedEvent   = "session_closed"
+	UserRegisteredEvent  = "user_registered"
+	UserDeletedEvent     = "user_deleted"
+)
+
+var allEvents = []string{
+	MessageRoutedEvent,
+	MessageArchivedEvent,
+	SessionOpenedEvent,
+	SessionClosedEvent,
+	UserRegisteredEvent,
+	UserDeletedEvent,
+}
+
+// Event is the JSON document published for every exported event.
+// Fields not applying to a given event type are omitted.
+type Event struct {
+	// Type is the event type (e.g. `message_routed`).
+	Type string `json:"type"`
+
+	// Instance is the identifier of the jackal instance that generated the event.
+	Instance string `json:"instance"`
+
+	// Timestamp is the event generation time (RFC 3339, UTC).
+	Timestamp time.Time `json:"timestamp"`
+
+	// Username is the local user associated to the event.
+	Username string `json:"username,omitempty"`
+
+	// JID is the full session JID (session_opened and session_closed events).
+	JID string `json:"jid,omitempty"`
+
+	// From is the message sender JID.
+	From string `json:"from,omitempty"`
+
+	// To is the message recipient JID.
+	To string `json:"to,omitempty"`
+
+	// Targets contains the JIDs a message was delivered to (message_routed events).
+	Targets []string `json:"targets,omitempty"`
+
+	// MessageID is the message stanza identifier.
+	MessageID string `json:"message_id,omitempty"`
+
+	// MessageType is the message stanza type.
+	MessageType string `json:"message_type,omitempty"`
+
+	// ArchiveID is the archived message identifier (message_archived events).
+	ArchiveID string `json:"archive_id,omitempty"`
+
+	// Body is the message body. Only set when body export is enabled.
+	Body string `json:"body,omitempty"`
+}
@@ -0,0 +1,344 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/cluster/instance"
+	"github.com/ortuman/jackal/pkg/hook"
+)
+
+// ModuleName represents event stream exporter module name.
+const ModuleName = "event_stream"
+
+// Config contains event stream exporter module configuration options.
+type Config struct {
+	// URL is the NATS server URL.
+	URL string `fig:"url" default:"nats://127.0.0.1:4222"`
+
+	// Username is the NATS authentication username.
+	Username string `fig:"username"`
+
+	// Password is the NATS authentication password.
+	Password string `fig:"password"`
+
+	// Token is the NATS authentication token.
+	Token string `fig:"token"`
+
+	// SubjectPrefix is prepended to every event type to build the publishing subject.
+	SubjectPrefix string `fig:"subject_prefix" default:"jackal"`
+
+	// Events defines the set of exported event types. All events are exported if empty.
+	Events []string `fig:"events"`
+
+	// IncludeBody tells whether message bodies should be exported along with message metadata.
+	IncludeBody bool `fig:"include_body"`
+
+	// BufferSize defines the maximum number of pending events. Events are dropped once the buffer is full.
+	BufferSize int `fig:"buffer_size" default:"10000"`
+
+	// ReconnectInterval defines the time to wait before reconnecting to NATS after a failure.
+	ReconnectInterval time.Duration `fig:"reconnect_interval" default:"5s"`
+}
+
+// EventStream represents a module that publishes selected hook events to NATS subjects.
+type EventStream struct {
+	cfg    Config
+	hk     *hook.Hooks
+	events map[string]struct{}
+	logger kitlog.Logger
+
+	dialFn func() (*natsConn, error)
+
+	evCh   chan *Event
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New returns a new initialized EventStream instance.
+func New(cfg Config, hk *hook.Hooks, logger kitlog.Logger) *EventStream {
+	m := &EventStream{
+		cfg:    cfg,
+		hk:     hk,
+		events: make(map[string]struct{}),
+		logger: kitlog.With(logger, "module", ModuleName),
+	}
+	m.dialFn = func() (*natsConn, error) {
+		return dialNATS(cfg.URL, cfg.Username, cfg.Password, cfg.Token)
+	}
+	return m
+}
+
+// Name returns event stream exporter module name.
+func (m *EventStream) Name() string { return ModuleName }
+
+// StreamFeature returns event stream exporter module stream feature.
+func (m *EventStream) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns event stream exporter server disco features.
+func (m *EventStream) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns event stream exporter account disco features.
+func (m *EventStream) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts event stream exporter module.
+func (m *EventStream) Start(_ context.Context) error {
+	if len(m.cfg.SubjectPrefix) == 0 {
+		return errors.New("event_stream: subject_prefix must be set")
+	}
+	if m.cfg.BufferSize <= 0 || m.cfg.ReconnectInterval <= 0 {
+		return errors.New("event_stream: buffer_size and reconnect_interval must be positive")
+	}
+	events := m.cfg.Events
+	if len(events) == 0 {
+		events = allEvents
+	}
+	for _, ev := range events {
+		if !isValidEvent(ev) {
+			return fmt.Errorf("event_stream: unrecognized event type: %s", ev)
+		}
+		m.events[ev] = struct{}{}
+	}
+	m.evCh = make(chan *Event, m.cfg.BufferSize)
+	m.stopCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+	go m.loop()
+
+	m.hk.AddHook(hook.C2SStreamMessageRouted, m.onMessageRouted, hook.LowestPriority)
+	m.hk.AddHook(hook.ArchiveMessageArchived, m.onMessageArchived, hook.LowestPriority)
+	m.hk.AddHook(hook.C2SStreamBinded, m.onSessionOpened, hook.LowestPriority)
+	m.hk.AddHook(hook.C2SStreamDisconnected, m.onSessionClosed, hook.LowestPriority)
+	m.hk.AddHook(hook.UserCreated, m.onUserCreated, hook.LowestPriority)
+	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.LowestPriority)
+
+	level.Info(m.logger).Log("msg", "started event stream module", "url", m.cfg.URL, "events", len(m.events))
+	return nil
+}
+
+// Stop stops event stream exporter module publishing all pending events.
+func (m *EventStream) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.C2SStreamMessageRouted, m.onMessageRouted)
+	m.hk.RemoveHook(hook.ArchiveMessageArchived, m.onMessageArchived)
+	m.hk.RemoveHook(hook.C2SStreamBinded, m.onSessionOpened)
+	m.hk.RemoveHook(hook.C2SStreamDisconnected, m.onSessionClosed)
+	m.hk.RemoveHook(hook.UserCreated, m.onUserCreated)
+	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+
+	close(m.stopCh)
+	<-m.doneCh
+
+	level.Info(m.logger).Log("msg", "stopped event stream module")
+	return nil
+}
+
+func (m *EventStream) onMessageRouted(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+
+	msg, ok := inf.Element.(*stravaganza.Message)
+	if !ok || !m.exports(MessageRoutedEvent) {
+		return nil
+	}
+	ev := m.messageEvent(MessageRoutedEvent, msg)
+	ev.Username = inf.JID.Node()
+	for _, target := range inf.Targets {
+		ev.Targets = append(ev.Targets, target.String())
+	}
+	m.enqueue(ev)
+	return nil
+}
+
+func (m *EventStream) onMessageArchived(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.MamInfo)
+	if inf.Message == nil || inf.Message.Message == nil || !m.exports(MessageArchivedEvent) {
+		return nil
+	}
+	msg, err := stravaganza.NewBuilderFromProto(inf.Message.Message).BuildMessage()
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to decode archived message", "err", err)
+		return nil
+	}
+	ev := m.messageEvent(MessageArchivedEvent, msg)
+	ev.Username = inf.Message.ArchiveId
+	ev.ArchiveID = inf.Message.Id
+	m.enqueue(ev)
+	return nil
+}
+
+func (m *EventStream) onSessionOpened(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+	if !m.exports(SessionOpenedEvent) {
+		return nil
+	}
+	m.enqueue(m.sessionEvent(SessionOpenedEvent, inf.JID))
+	return nil
+}
+
+func (m *EventStream) onSessionClosed(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+	if inf.JID == nil || !inf.JID.IsFullWithUser() || !m.exports(SessionClosedEvent) {
+		return nil // session never bound
+	}
+	m.enqueue(m.sessionEvent(SessionClosedEvent, inf.JID))
+	return nil
+}
+
+func (m *EventStream) onUserCreated(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.UserInfo)
+	if !m.exports(UserRegisteredEvent) {
+		return nil
+	}
+	m.enqueue(m.newEvent(UserRegisteredEvent, func(ev *Event) { ev.Username = inf.Username }))
+	return nil
+}
+
+func (m *EventStream) onUserDeleted(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.UserInfo)
+	if !m.exports(UserDeletedEvent) {
+		return nil
+	}
+	m.enqueue(m.newEvent(UserDeletedEvent, func(ev *Event) { ev.Username = inf.Username }))
+	return nil
+}
+
+func (m *EventStream) messageEvent(typ string, msg *stravaganza.Message) *Event {
+	return m.newEvent(typ, func(ev *Event) {
+		ev.From = msg.Attribute(stravaganza.From)
+		ev.To = msg.Attribute(stravaganza.To)
+		ev.MessageID = msg.Attribute(stravaganza.ID)
+		ev.MessageType = msg.Type()
+		if body := msg.Child("body"); body != nil && m.cfg.IncludeBody {
+			ev.Body = body.Text()
+		}
+	})
+}
+
+func (m *EventStream) sessionEvent(typ string, jd *jid.JID) *Event {
+	return m.newEvent(typ, func(ev *Event) {
+		ev.Username = jd.Node()
+		ev.JID = jd.String()
+	})
+}
+
+func (m *EventStream) newEvent(typ string, fill func(ev *Event)) *Event {
+	ev := &Event{
+		Type:      typ,
+		Instance:  instance.ID(),
+		Timestamp: time.Now().UTC(),
+	}
+	fill(ev)
+	return ev
+}
+
+func (m *EventStream) exports(typ string) bool {
+	_, ok := m.events[typ]
+	return ok
+}
+
+func (m *EventStream) enqueue(ev *Event) {
+	select {
+	case m.evCh <- ev:
+	default:
+		level.Warn(m.logger).Log("msg", "event stream buffer full, dropping event", "type", ev.Type)
+	}
+}
+
+func (m *EventStream) loop() {
+	defer close(m.doneCh)
+
+	var nc *natsConn
+	defer func() {
+		if nc != nil {
+			_ = nc.close()
+		}
+	}()
+	var pending *Event
+	for {
+		if nc == nil {
+			var err error
+			if nc, err = m.dialFn(); err != nil {
+				level.Warn(m.logger).Log("msg", "failed to connect to NATS", "url", m.cfg.URL, "err", err)
+				nc = nil
+
+				select {
+				case <-time.After(m.cfg.ReconnectInterval):
+					continue
+				case <-m.stopCh:
+					return
+				}
+			}
+		}
+		if pending == nil {
+			select {
+			case pending = <-m.evCh:
+			case <-m.stopCh:
+				m.drain(nc)
+				return
+			}
+		}
+		if err := m.publish(nc, pending); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to publish event", "type", pending.Type, "err", err)
+			_ = nc.close()
+			nc = nil
+			continue // retry pending event once reconnected
+		}
+		pending = nil
+	}
+}
+
+func (m *EventStream) drain(nc *natsConn) {
+	for {
+		select {
+		case ev := <-m.evCh:
+			if err := m.publish(nc, ev); err != nil {
+				level.Warn(m.logger).Log("msg", "failed to publish event", "type", ev.Type, "err", err)
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (m *EventStream) publish(nc *natsConn, ev *Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return nc.publish(m.cfg.SubjectPrefix+"."+ev.Type, b)
+}
+
+func isValidEvent(typ string) bool {
+	for _, ev := range allEvents {
+		if ev == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+type natsPub struct {
+	subject string
+	payload []byte
+}
+
+func TestEventStream_Publish(t *testing.T) {
+	// given
+	ln, pubCh := testNATSServer(t, "")
+	defer func() { _ = ln.Close() }()
+
+	hk := hook.NewHooks()
+	m := New(Config{
+		URL:               "nats://" + ln.Addr().String(),
+		SubjectPrefix:     "jackal",
+		Events:            []string{SessionOpenedEvent, UserRegisteredEvent},
+		BufferSize:        16,
+		ReconnectInterval: time.Second,
+	}, hk, kitlog.NewNopLogger())
+
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im").
+		BuildMessage()
+
+	// when
+	require.Nil(t, m.Start(context.Background()))
+
+	_, _ = hk.Run(hook.C2SStreamBinded, &hook.ExecutionContext{
+		Info:    &hook.C2SStreamInfo{JID: jd},
+		Context: context.Background(),
+	})
+	_, _ = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info:    &hook.C2SStreamInfo{JID: jd, Element: msg},
+		Context: context.Background(),
+	})
+	_, _ = hk.Run(hook.UserCreated, &hook.ExecutionContext{
+		Info:    &hook.UserInfo{Username: "noelia"},
+		Context: context.Background(),
+	})
+	require.Nil(t, m.Stop(context.Background()))
+
+	// then
+	var pubs []natsPub
+	for i := 0; i < 2; i++ {
+		select {
+		case pub := <-pubCh:
+			pubs = append(pubs, pub)
+		case <-time.After(time.Second * 5):
+			require.Fail(t, "event not published")
+		}
+	}
+	require.Equal(t, "jackal.session_opened", pubs[0].subject)
+	require.Equal(t, "jackal.user_registered", pubs[1].subject)
+
+	var ev Event
+	require.Nil(t, json.Unmarshal(pubs[0].payload, &ev))
+	require.Equal(t, SessionOpenedEvent, ev.Type)
+	require.Equal(t, "ortuman", ev.Username)
+	require.Equal(t, "ortuman@jackal.im/yard", ev.JID)
+
+	require.Nil(t, json.Unmarshal(pubs[1].payload, &ev))
+	require.Equal(t, UserRegisteredEvent, ev.Type)
+	require.Equal(t, "noelia", ev.Username)
+
+	select {
+	case pub := <-pubCh:
+		require.Fail(t, "unexpected event published", pub.subject)
+	default:
+	}
+}
+
+func TestEventStream_NATSAuthError(t *testing.T) {
+	// given
+	ln, _ := testNATSServer(t, "-ERR 'Authorization Violation'")
+	defer func() { _ = ln.Close() }()
+
+	// when
+	_, err := dialNATS("nats://"+ln.Addr().String(), "jackal", "wrong-password", "")
+
+	// then
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Authorization Violation")
+}
+
+func testNATSServer(t *testing.T, connectReply string) (net.Listener, <-chan natsPub) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	pubCh := make(chan natsPub, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		_, _ = io.WriteString(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PING"):
+				if len(connectReply) > 0 {
+					_, _ = io.WriteString(conn, connectReply+"\r\n")
+					return
+				}
+				_, _ = io.WriteString(conn, "PONG\r\n")
+
+			case strings.HasPrefix(line, "PUB "):
+				var subject string
+				var n int
+				_, _ = fmt.Sscanf(line, "PUB %s %d", &subject, &n)
+
+				payload := make([]byte, n+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				pubCh <- natsPub{subject: subject, payload: payload[:n]}
+			}
+		}
+	}()
+	return ln, pubCh
+}
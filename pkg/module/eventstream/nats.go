@@ -0,0 +1,192 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	natsDefaultPort = "4222"
+	natsDialTimeout = 5 * time.Second
+)
+
+var errNATSConnClosed = errors.New("eventstream: NATS connection closed")
+
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// natsConn is a minimal publish-only NATS client speaking the NATS text protocol.
+type natsConn struct {
+	conn net.Conn
+
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closed bool
+	err    error
+}
+
+func dialNATS(natsURL, username, password, token string) (*natsConn, error) {
+	u, err := url.Parse(natsURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("eventstream: unsupported NATS url scheme: %s", u.Scheme)
+	}
+	host := u.Host
+	if len(u.Port()) == 0 {
+		host = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+	conn, err := net.DialTimeout("tcp", host, natsDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	nc, err := handshakeNATS(conn, username, password, token)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return nc, nil
+}
+
+func handshakeNATS(conn net.Conn, username, password, token string) (*natsConn, error) {
+	_ = conn.SetDeadline(time.Now().Add(natsDialTimeout))
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return nil, fmt.Errorf("eventstream: unexpected NATS greeting: %s", strings.TrimSpace(line))
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return nil, err
+	}
+	if info.TLSRequired {
+		return nil, errors.New("eventstream: NATS servers requiring TLS are not supported")
+	}
+	connect, _ := json.Marshal(&natsConnect{
+		Name:      "jackal",
+		Lang:      "go",
+		User:      username,
+		Pass:      password,
+		AuthToken: token,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return nil, err
+	}
+	// wait for PONG to make sure connection was accepted
+	line, err = r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(line) != "PONG" {
+		return nil, fmt.Errorf("eventstream: NATS connection rejected: %s", strings.TrimSpace(line))
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	nc := &natsConn{
+		conn: conn,
+		w:    bufio.NewWriter(conn),
+	}
+	go nc.readLoop(r)
+	return nc, nil
+}
+
+func (nc *natsConn) publish(subject string, payload []byte) error {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	if nc.closed {
+		return nc.closeErr()
+	}
+	if _, err := fmt.Fprintf(nc.w, "PUB %s %d\r\n", subject, len(payload)); err != nil {
+		return err
+	}
+	if _, err := nc.w.Write(payload); err != nil {
+		return err
+	}
+	if _, err := nc.w.WriteString("\r\n"); err != nil {
+		return err
+	}
+	return nc.w.Flush()
+}
+
+func (nc *natsConn) close() error {
+	nc.mu.Lock()
+	nc.closed = true
+	nc.mu.Unlock()
+	return nc.conn.Close()
+}
+
+func (nc *natsConn) readLoop(r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			nc.setClosed(err)
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			nc.mu.Lock()
+			_, _ = nc.w.WriteString("PONG\r\n")
+			_ = nc.w.Flush()
+			nc.mu.Unlock()
+
+		case strings.HasPrefix(line, "-ERR"):
+			nc.setClosed(fmt.Errorf("eventstream: NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))))
+			_ = nc.conn.Close()
+			return
+		}
+	}
+}
+
+func (nc *natsConn) setClosed(err error) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.closed = true
+	if nc.err == nil {
+		nc.err = err
+	}
+}
+
+func (nc *natsConn) closeErr() error {
+	if nc.err != nil {
+		return nc.err
+	}
+	return errNATSConnClosed
+}
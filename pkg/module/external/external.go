@@ -0,0 +1,278 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	modulepb "github.com/ortuman/jackal/pkg/module/external/pb"
+	"github.com/ortuman/jackal/pkg/router"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Config contains external module configuration options.
+type Config struct {
+	// Name is the module name. It can be referenced from host module settings as any built-in module.
+	Name string `fig:"name"`
+
+	// Address is the external module gRPC server address.
+	Address string `fig:"address"`
+
+	// IsSecure tells whether the gRPC connection should be established over TLS.
+	IsSecure bool `fig:"is_secure"`
+
+	// RequestTimeout defines the maximum amount of time to wait for an external module response.
+	RequestTimeout time.Duration `fig:"request_timeout" default:"5s"`
+}
+
+type iqHandler struct {
+	namespace string
+	target    modulepb.IQTarget
+}
+
+// External represents a module served by an out-of-process gRPC server.
+type External struct {
+	cfg    Config
+	router router.Router
+	hk     *hook.Hooks
+	logger kitlog.Logger
+
+	cc          *grpc.ClientConn
+	cl          modulepb.ModuleClient
+	iqHandlers  []iqHandler
+	hookHnds    map[string]hook.Handler
+	srvFeatures []string
+	accFeatures []string
+}
+
+// New returns a new initialized External instance.
+func New(cfg Config, router router.Router, hk *hook.Hooks, logger kitlog.Logger) *External {
+	return &External{
+		cfg:      cfg,
+		router:   router,
+		hk:       hk,
+		hookHnds: make(map[string]hook.Handler),
+		logger:   kitlog.With(logger, "module", cfg.Name, "address", cfg.Address),
+	}
+}
+
+// Name returns external module name.
+func (m *External) Name() string { return m.cfg.Name }
+
+// StreamFeature returns external module stream feature.
+func (m *External) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns external module server disco features.
+func (m *External) ServerFeatures(_ context.Context) ([]string, error) {
+	return m.srvFeatures, nil
+}
+
+// AccountFeatures returns external module account disco features.
+func (m *External) AccountFeatures(_ context.Context) ([]string, error) {
+	return m.accFeatures, nil
+}
+
+// MatchesNamespace tells whether namespace matches any of the external module registered iq handlers.
+func (m *External) MatchesNamespace(namespace string, serverTarget bool) bool {
+	for _, h := range m.iqHandlers {
+		if h.namespace != namespace {
+			continue
+		}
+		switch h.target {
+		case modulepb.IQTarget_IQ_TARGET_SERVER:
+			return serverTarget
+		case modulepb.IQTarget_IQ_TARGET_ACCOUNT:
+			return !serverTarget
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessIQ forwards an iq stanza to the external module, routing back its response stanzas.
+func (m *External) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	rpcCtx, cancel := context.WithTimeout(ctx, m.cfg.RequestTimeout)
+	defer cancel()
+
+	resp, err := m.cl.ProcessIQ(rpcCtx, &modulepb.ProcessIQRequest{Iq: iq.Proto()})
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to process iq", "id", iq.Attribute(stravaganza.ID), "err", err)
+
+		errResp, _ := stanzaerror.E(stanzaerror.InternalServerError, iq).Stanza(false)
+		_, _ = m.router.Route(ctx, errResp)
+		return nil
+	}
+	m.routeStanzas(ctx, resp.GetStanzas())
+	return nil
+}
+
+// Start dials external module gRPC connection and registers its iq handlers and hook subscriptions.
+func (m *External) Start(ctx context.Context) error {
+	if len(m.cfg.Name) == 0 || len(m.cfg.Address) == 0 {
+		return errors.New("external: name and address must be set")
+	}
+	if m.cfg.RequestTimeout <= 0 {
+		return fmt.Errorf("external: %s: request_timeout must be positive", m.cfg.Name)
+	}
+	var opts = []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Second * 10,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithUnaryInterceptor(grpc_prometheus.UnaryClientInterceptor),
+	}
+	if !m.cfg.IsSecure {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	cc, err := grpc.DialContext(ctx, m.cfg.Address, opts...)
+	if err != nil {
+		return err
+	}
+	m.cc = cc
+	m.cl = modulepb.NewModuleClient(cc)
+
+	rpcCtx, cancel := context.WithTimeout(ctx, m.cfg.RequestTimeout)
+	defer cancel()
+
+	desc, err := m.cl.GetDescription(rpcCtx, &modulepb.GetDescriptionRequest{})
+	if err != nil {
+		_ = cc.Close()
+		return fmt.Errorf("external: %s: failed to fetch module description: %w", m.cfg.Name, err)
+	}
+	for _, h := range desc.GetIqHandlers() {
+		m.iqHandlers = append(m.iqHandlers, iqHandler{namespace: h.GetNamespace(), target: h.GetTarget()})
+	}
+	m.srvFeatures = desc.GetServerFeatures()
+	m.accFeatures = desc.GetAccountFeatures()
+
+	for _, sub := range desc.GetHooks() {
+		if _, ok := m.hookHnds[sub.GetName()]; ok {
+			continue // already subscribed
+		}
+		hnd := m.hookHandler(sub.GetName())
+		m.hookHnds[sub.GetName()] = hnd
+		m.hk.AddHook(sub.GetName(), hnd, hook.Priority(sub.GetPriority()))
+	}
+	level.Info(m.logger).Log("msg", "started external module",
+		"iq_handlers", len(m.iqHandlers),
+		"hooks", len(m.hookHnds),
+	)
+	return nil
+}
+
+// Stop unregisters external module hook subscriptions and closes underlying gRPC connection.
+func (m *External) Stop(_ context.Context) error {
+	for name, hnd := range m.hookHnds {
+		m.hk.RemoveHook(name, hnd)
+	}
+	level.Info(m.logger).Log("msg", "stopped external module")
+	return m.cc.Close()
+}
+
+func (m *External) hookHandler(name string) hook.Handler {
+	return func(execCtx *hook.ExecutionContext) error {
+		req := hookRequest(execCtx.Info)
+		req.Hook = name
+
+		ctx, cancel := context.WithTimeout(execCtx.Context, m.cfg.RequestTimeout)
+		defer cancel()
+
+		resp, err := m.cl.ProcessHook(ctx, req)
+		if err != nil {
+			level.Warn(m.logger).Log("msg", "failed to process hook", "hook", name, "err", err)
+			return nil
+		}
+		m.routeStanzas(execCtx.Context, resp.GetStanzas())
+		if resp.GetHalt() {
+			return hook.ErrStopped
+		}
+		return nil
+	}
+}
+
+func (m *External) routeStanzas(ctx context.Context, elems []*stravaganza.PBElement) {
+	for _, elem := range elems {
+		stanza, err := stravaganza.NewBuilderFromProto(elem).BuildStanza()
+		if err != nil {
+			level.Warn(m.logger).Log("msg", "discarded invalid module stanza", "err", err)
+			continue
+		}
+		if _, err := m.router.Route(ctx, stanza); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to route module stanza", "err", err)
+		}
+	}
+}
+
+func hookRequest(info interface{}) *modulepb.ProcessHookRequest {
+	var req modulepb.ProcessHookRequest
+	switch inf := info.(type) {
+	case *hook.C2SStreamInfo:
+		req.StreamId = inf.ID
+		if inf.JID != nil {
+			req.Jid = inf.JID.String()
+			req.Username = inf.JID.Node()
+		}
+		req.Element = elementProto(inf.Element)
+		req.Targets = jidStrings(inf.Targets)
+
+	case *hook.C2SRouterInfo:
+		req.Element = elementProto(inf.Stanza)
+
+	case *hook.S2SStreamInfo:
+		req.StreamId = inf.ID
+		req.Sender = inf.Sender
+		req.Target = inf.Target
+		req.Element = elementProto(inf.Element)
+		req.Targets = jidStrings(inf.Targets)
+
+	case *hook.ExternalComponentInfo:
+		req.StreamId = inf.ID
+		req.Target = inf.Host
+		req.Element = elementProto(inf.Element)
+
+	case *hook.UserInfo:
+		req.Username = inf.Username
+	}
+	return &req
+}
+
+func elementProto(elem stravaganza.Element) *stravaganza.PBElement {
+	if elem == nil {
+		return nil
+	}
+	return elem.Proto()
+}
+
+func jidStrings(jds []jid.JID) []string {
+	var ret []string
+	for _, jd := range jds {
+		ret = append(ret, jd.String())
+	}
+	return ret
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	modulepb "github.com/ortuman/jackal/pkg/module/external/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type testModuleServer struct {
+	modulepb.UnimplementedModuleServer
+
+	iqErr   error
+	mu      sync.Mutex
+	hookReq *modulepb.ProcessHookRequest
+}
+
+func (s *testModuleServer) GetDescription(_ context.Context, _ *modulepb.GetDescriptionRequest) (*modulepb.GetDescriptionResponse, error) {
+	return &modulepb.GetDescriptionResponse{
+		IqHandlers: []*modulepb.IQHandler{
+			{Namespace: "urn:example:weather", Target: modulepb.IQTarget_IQ_TARGET_SERVER},
+		},
+		Hooks: []*modulepb.HookSubscription{
+			{Name: hook.C2SStreamMessageReceived},
+		},
+		ServerFeatures: []string{"urn:example:weather"},
+	}, nil
+}
+
+func (s *testModuleServer) ProcessIQ(_ context.Context, req *modulepb.ProcessIQRequest) (*modulepb.ProcessIQResponse, error) {
+	if s.iqErr != nil {
+		return nil, s.iqErr
+	}
+	iq, err := stravaganza.NewBuilderFromProto(req.Iq).BuildIQ()
+	if err != nil {
+		return nil, err
+	}
+	return &modulepb.ProcessIQResponse{
+		Stanzas: []*stravaganza.PBElement{iq.ResultBuilder().Build().Proto()},
+	}, nil
+}
+
+func (s *testModuleServer) ProcessHook(_ context.Context, req *modulepb.ProcessHookRequest) (*modulepb.ProcessHookResponse, error) {
+	s.mu.Lock()
+	s.hookReq = req
+	s.mu.Unlock()
+	return &modulepb.ProcessHookResponse{Halt: true}, nil
+}
+
+func TestExternal_ProcessIQ(t *testing.T) {
+	// given
+	srv := &testModuleServer{}
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	m := startTestModule(t, srv, routerMock, hook.NewHooks())
+
+	// when
+	iq := testIQ()
+	err := m.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Nil(t, err)
+
+	srvFeatures, _ := m.ServerFeatures(context.Background())
+	require.Equal(t, []string{"urn:example:weather"}, srvFeatures)
+
+	require.True(t, m.MatchesNamespace("urn:example:weather", true))
+	require.False(t, m.MatchesNamespace("urn:example:weather", false))
+	require.False(t, m.MatchesNamespace("urn:example:news", true))
+
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Attribute(stravaganza.Type))
+	require.Equal(t, "weather-1", respStanzas[0].Attribute(stravaganza.ID))
+}
+
+func TestExternal_ProcessIQFailure(t *testing.T) {
+	// given
+	srv := &testModuleServer{iqErr: errors.New("foo error")}
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	m := startTestModule(t, srv, routerMock, hook.NewHooks())
+
+	// when
+	err := m.ProcessIQ(context.Background(), testIQ())
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
+	require.NotNil(t, respStanzas[0].Child("error").Child("internal-server-error"))
+}
+
+func TestExternal_Hook(t *testing.T) {
+	// given
+	srv := &testModuleServer{}
+	hk := hook.NewHooks()
+	m := startTestModule(t, srv, &routerMock{}, hk)
+
+	// when
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im").
+		WithChild(stravaganza.NewBuilder("body").WithText("hi!").Build()).
+		BuildMessage()
+
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+	halted, err := hk.Run(hook.C2SStreamMessageReceived, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			ID:      "c2s1",
+			JID:     jd,
+			Element: msg,
+		},
+		Context: context.Background(),
+	})
+	_ = m.Stop(context.Background())
+
+	// then
+	require.Nil(t, err)
+	require.True(t, halted)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	require.NotNil(t, srv.hookReq)
+	require.Equal(t, hook.C2SStreamMessageReceived, srv.hookReq.Hook)
+	require.Equal(t, "c2s1", srv.hookReq.StreamId)
+	require.Equal(t, "ortuman@jackal.im/yard", srv.hookReq.Jid)
+	require.Equal(t, "ortuman", srv.hookReq.Username)
+	require.Equal(t, "message", srv.hookReq.Element.Name)
+}
+
+func startTestModule(t *testing.T, srv modulepb.ModuleServer, router *routerMock, hk *hook.Hooks) *External {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	gs := grpc.NewServer()
+	modulepb.RegisterModuleServer(gs, srv)
+	go func() { _ = gs.Serve(ln) }()
+	t.Cleanup(gs.Stop)
+
+	m := New(Config{
+		Name:           "weather",
+		Address:        ln.Addr().String(),
+		RequestTimeout: time.Second * 5,
+	}, router, hk, kitlog.NewNopLogger())
+	require.Nil(t, m.Start(context.Background()))
+	return m
+}
+
+func testIQ() *stravaganza.IQ {
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "weather-1").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, "urn:example:weather").
+				Build(),
+		).
+		BuildIQ()
+	return iq
+}
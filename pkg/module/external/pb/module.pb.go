@@ -0,0 +1,848 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        v3.21.5
+// source: proto/module/v1/module.proto
+
+package pb
+
+import (
+	stravaganza "github.com/jackal-xmpp/stravaganza"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// IQTarget defines the kind of entity an iq handler is bound to.
+type IQTarget int32
+
+const (
+	// IQ_TARGET_ANY matches both server and account targeted iqs.
+	IQTarget_IQ_TARGET_ANY IQTarget = 0
+	// IQ_TARGET_SERVER matches iqs addressed to the server entity.
+	IQTarget_IQ_TARGET_SERVER IQTarget = 1
+	// IQ_TARGET_ACCOUNT matches iqs addressed to a bare account JID.
+	IQTarget_IQ_TARGET_ACCOUNT IQTarget = 2
+)
+
+// Enum value maps for IQTarget.
+var (
+	IQTarget_name = map[int32]string{
+		0: "IQ_TARGET_ANY",
+		1: "IQ_TARGET_SERVER",
+		2: "IQ_TARGET_ACCOUNT",
+	}
+	IQTarget_value = map[string]int32{
+		"IQ_TARGET_ANY":     0,
+		"IQ_TARGET_SERVER":  1,
+		"IQ_TARGET_ACCOUNT": 2,
+	}
+)
+
+func (x IQTarget) Enum() *IQTarget {
+	p := new(IQTarget)
+	*p = x
+	return p
+}
+
+func (x IQTarget) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (IQTarget) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_module_v1_module_proto_enumTypes[0].Descriptor()
+}
+
+func (IQTarget) Type() protoreflect.EnumType {
+	return &file_proto_module_v1_module_proto_enumTypes[0]
+}
+
+func (x IQTarget) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use IQTarget.Descriptor instead.
+func (IQTarget) EnumDescriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{0}
+}
+
+// IQHandler defines an iq namespace handled by the module.
+type IQHandler struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// namespace is the iq child element namespace.
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// target restricts the kind of iq target entity.
+	Target IQTarget `protobuf:"varint,2,opt,name=target,proto3,enum=module.v1.IQTarget" json:"target,omitempty"`
+}
+
+func (x *IQHandler) Reset() {
+	*x = IQHandler{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IQHandler) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IQHandler) ProtoMessage() {}
+
+func (x *IQHandler) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IQHandler.ProtoReflect.Descriptor instead.
+func (*IQHandler) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *IQHandler) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *IQHandler) GetTarget() IQTarget {
+	if x != nil {
+		return x.Target
+	}
+	return IQTarget_IQ_TARGET_ANY
+}
+
+// HookSubscription defines a hook the module wants to be notified about.
+type HookSubscription struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// name is the hook name (i.e. c2s.stream.message_received).
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// priority is the hook handler execution priority. Higher priority handlers run first.
+	Priority int32 `protobuf:"varint,2,opt,name=priority,proto3" json:"priority,omitempty"`
+}
+
+func (x *HookSubscription) Reset() {
+	*x = HookSubscription{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HookSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HookSubscription) ProtoMessage() {}
+
+func (x *HookSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HookSubscription.ProtoReflect.Descriptor instead.
+func (*HookSubscription) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HookSubscription) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HookSubscription) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+// GetDescriptionRequest is the parameter message for GetDescription rpc.
+type GetDescriptionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetDescriptionRequest) Reset() {
+	*x = GetDescriptionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDescriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDescriptionRequest) ProtoMessage() {}
+
+func (x *GetDescriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDescriptionRequest.ProtoReflect.Descriptor instead.
+func (*GetDescriptionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{2}
+}
+
+// GetDescriptionResponse is the response message for GetDescription rpc.
+type GetDescriptionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// iq_handlers contains all iq namespaces handled by the module.
+	IqHandlers []*IQHandler `protobuf:"bytes,1,rep,name=iq_handlers,json=iqHandlers,proto3" json:"iq_handlers,omitempty"`
+	// hooks contains all hook subscriptions.
+	Hooks []*HookSubscription `protobuf:"bytes,2,rep,name=hooks,proto3" json:"hooks,omitempty"`
+	// server_features contains the disco features advertised on behalf of the server entity.
+	ServerFeatures []string `protobuf:"bytes,3,rep,name=server_features,json=serverFeatures,proto3" json:"server_features,omitempty"`
+	// account_features contains the disco features advertised on behalf of local accounts.
+	AccountFeatures []string `protobuf:"bytes,4,rep,name=account_features,json=accountFeatures,proto3" json:"account_features,omitempty"`
+}
+
+func (x *GetDescriptionResponse) Reset() {
+	*x = GetDescriptionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDescriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDescriptionResponse) ProtoMessage() {}
+
+func (x *GetDescriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDescriptionResponse.ProtoReflect.Descriptor instead.
+func (*GetDescriptionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetDescriptionResponse) GetIqHandlers() []*IQHandler {
+	if x != nil {
+		return x.IqHandlers
+	}
+	return nil
+}
+
+func (x *GetDescriptionResponse) GetHooks() []*HookSubscription {
+	if x != nil {
+		return x.Hooks
+	}
+	return nil
+}
+
+func (x *GetDescriptionResponse) GetServerFeatures() []string {
+	if x != nil {
+		return x.ServerFeatures
+	}
+	return nil
+}
+
+func (x *GetDescriptionResponse) GetAccountFeatures() []string {
+	if x != nil {
+		return x.AccountFeatures
+	}
+	return nil
+}
+
+// ProcessIQRequest is the parameter message for ProcessIQ rpc.
+type ProcessIQRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// iq is the iq stanza to be processed.
+	Iq *stravaganza.PBElement `protobuf:"bytes,1,opt,name=iq,proto3" json:"iq,omitempty"`
+}
+
+func (x *ProcessIQRequest) Reset() {
+	*x = ProcessIQRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProcessIQRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessIQRequest) ProtoMessage() {}
+
+func (x *ProcessIQRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessIQRequest.ProtoReflect.Descriptor instead.
+func (*ProcessIQRequest) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProcessIQRequest) GetIq() *stravaganza.PBElement {
+	if x != nil {
+		return x.Iq
+	}
+	return nil
+}
+
+// ProcessIQResponse is the response message for ProcessIQ rpc.
+type ProcessIQResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// stanzas contains the stanzas to be routed on behalf of the module (typically the iq response).
+	Stanzas []*stravaganza.PBElement `protobuf:"bytes,1,rep,name=stanzas,proto3" json:"stanzas,omitempty"`
+}
+
+func (x *ProcessIQResponse) Reset() {
+	*x = ProcessIQResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProcessIQResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessIQResponse) ProtoMessage() {}
+
+func (x *ProcessIQResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessIQResponse.ProtoReflect.Descriptor instead.
+func (*ProcessIQResponse) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ProcessIQResponse) GetStanzas() []*stravaganza.PBElement {
+	if x != nil {
+		return x.Stanzas
+	}
+	return nil
+}
+
+// ProcessHookRequest is the parameter message for ProcessHook rpc.
+// Only the fields carried by the originating hook are set.
+type ProcessHookRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// hook is the executed hook name.
+	Hook string `protobuf:"bytes,1,opt,name=hook,proto3" json:"hook,omitempty"`
+	// stream_id is the associated stream identifier.
+	StreamId string `protobuf:"bytes,2,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	// jid is the associated stream JID.
+	Jid string `protobuf:"bytes,3,opt,name=jid,proto3" json:"jid,omitempty"`
+	// username is the associated local username.
+	Username string `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	// sender is the S2S sender domain.
+	Sender string `protobuf:"bytes,5,opt,name=sender,proto3" json:"sender,omitempty"`
+	// target is the S2S target domain or external component host.
+	Target string `protobuf:"bytes,6,opt,name=target,proto3" json:"target,omitempty"`
+	// element is the associated XMPP element.
+	Element *stravaganza.PBElement `protobuf:"bytes,7,opt,name=element,proto3" json:"element,omitempty"`
+	// targets contains all JIDs to which the associated stanza was routed.
+	Targets []string `protobuf:"bytes,8,rep,name=targets,proto3" json:"targets,omitempty"`
+}
+
+func (x *ProcessHookRequest) Reset() {
+	*x = ProcessHookRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProcessHookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessHookRequest) ProtoMessage() {}
+
+func (x *ProcessHookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessHookRequest.ProtoReflect.Descriptor instead.
+func (*ProcessHookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProcessHookRequest) GetHook() string {
+	if x != nil {
+		return x.Hook
+	}
+	return ""
+}
+
+func (x *ProcessHookRequest) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *ProcessHookRequest) GetJid() string {
+	if x != nil {
+		return x.Jid
+	}
+	return ""
+}
+
+func (x *ProcessHookRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ProcessHookRequest) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *ProcessHookRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *ProcessHookRequest) GetElement() *stravaganza.PBElement {
+	if x != nil {
+		return x.Element
+	}
+	return nil
+}
+
+func (x *ProcessHookRequest) GetTargets() []string {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+// ProcessHookResponse is the response message for ProcessHook rpc.
+type ProcessHookResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// halt tells whether the remaining hook handlers should be skipped.
+	Halt bool `protobuf:"varint,1,opt,name=halt,proto3" json:"halt,omitempty"`
+	// stanzas contains the stanzas to be routed on behalf of the module.
+	Stanzas []*stravaganza.PBElement `protobuf:"bytes,2,rep,name=stanzas,proto3" json:"stanzas,omitempty"`
+}
+
+func (x *ProcessHookResponse) Reset() {
+	*x = ProcessHookResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_module_v1_module_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProcessHookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessHookResponse) ProtoMessage() {}
+
+func (x *ProcessHookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_module_v1_module_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessHookResponse.ProtoReflect.Descriptor instead.
+func (*ProcessHookResponse) Descriptor() ([]byte, []int) {
+	return file_proto_module_v1_module_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ProcessHookResponse) GetHalt() bool {
+	if x != nil {
+		return x.Halt
+	}
+	return false
+}
+
+func (x *ProcessHookResponse) GetStanzas() []*stravaganza.PBElement {
+	if x != nil {
+		return x.Stanzas
+	}
+	return nil
+}
+
+var File_proto_module_v1_module_proto protoreflect.FileDescriptor
+
+var file_proto_module_v1_module_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2f, 0x76,
+	0x31, 0x2f, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09,
+	0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x1a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2d, 0x78, 0x6d, 0x70,
+	0x70, 0x2f, 0x73, 0x74, 0x72, 0x61, 0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2f, 0x73, 0x74,
+	0x72, 0x61, 0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0x56, 0x0a, 0x09, 0x49, 0x51, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09,
+	0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x2b, 0x0a, 0x06, 0x74, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x6d, 0x6f, 0x64,
+	0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x51, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x52,
+	0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x22, 0x42, 0x0a, 0x10, 0x48, 0x6f, 0x6f, 0x6b, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x22, 0x17, 0x0a, 0x15, 0x47,
+	0x65, 0x74, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0xd6, 0x01, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x44, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x35, 0x0a, 0x0b, 0x69, 0x71, 0x5f, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31,
+	0x2e, 0x49, 0x51, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x52, 0x0a, 0x69, 0x71, 0x48, 0x61,
+	0x6e, 0x64, 0x6c, 0x65, 0x72, 0x73, 0x12, 0x31, 0x0a, 0x05, 0x68, 0x6f, 0x6f, 0x6b, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x48, 0x6f, 0x6f, 0x6b, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x05, 0x68, 0x6f, 0x6f, 0x6b, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x5f, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x0e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x66, 0x65,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x61, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22, 0x3a, 0x0a,
+	0x10, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x51, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x73, 0x74, 0x72, 0x61, 0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2e, 0x50, 0x42, 0x45, 0x6c,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x02, 0x69, 0x71, 0x22, 0x45, 0x0a, 0x11, 0x50, 0x72, 0x6f,
+	0x63, 0x65, 0x73, 0x73, 0x49, 0x51, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30,
+	0x0a, 0x07, 0x73, 0x74, 0x61, 0x6e, 0x7a, 0x61, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x73, 0x74, 0x72, 0x61, 0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2e, 0x50, 0x42,
+	0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x07, 0x73, 0x74, 0x61, 0x6e, 0x7a, 0x61, 0x73,
+	0x22, 0xef, 0x01, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x48, 0x6f, 0x6f, 0x6b,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x6f, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x6f, 0x6b, 0x12, 0x1b, 0x0a, 0x09, 0x73,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6a, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6a, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x16,
+	0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x30, 0x0a, 0x07, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x74, 0x72, 0x61, 0x76, 0x61,
+	0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2e, 0x50, 0x42, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52,
+	0x07, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x73, 0x22, 0x5b, 0x0a, 0x13, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x48, 0x6f, 0x6f,
+	0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x6c,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x68, 0x61, 0x6c, 0x74, 0x12, 0x30, 0x0a,
+	0x07, 0x73, 0x74, 0x61, 0x6e, 0x7a, 0x61, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x73, 0x74, 0x72, 0x61, 0x76, 0x61, 0x67, 0x61, 0x6e, 0x7a, 0x61, 0x2e, 0x50, 0x42, 0x45,
+	0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x07, 0x73, 0x74, 0x61, 0x6e, 0x7a, 0x61, 0x73, 0x2a,
+	0x4a, 0x0a, 0x08, 0x49, 0x51, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x11, 0x0a, 0x0d, 0x49,
+	0x51, 0x5f, 0x54, 0x41, 0x52, 0x47, 0x45, 0x54, 0x5f, 0x41, 0x4e, 0x59, 0x10, 0x00, 0x12, 0x14,
+	0x0a, 0x10, 0x49, 0x51, 0x5f, 0x54, 0x41, 0x52, 0x47, 0x45, 0x54, 0x5f, 0x53, 0x45, 0x52, 0x56,
+	0x45, 0x52, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x49, 0x51, 0x5f, 0x54, 0x41, 0x52, 0x47, 0x45,
+	0x54, 0x5f, 0x41, 0x43, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x10, 0x02, 0x32, 0xf5, 0x01, 0x0a, 0x06,
+	0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x12, 0x55, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6d, 0x6f, 0x64,
+	0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a,
+	0x09, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x51, 0x12, 0x1b, 0x2e, 0x6d, 0x6f, 0x64,
+	0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x51,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x51, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
+	0x48, 0x6f, 0x6f, 0x6b, 0x12, 0x1d, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x48, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x48, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x42, 0x18, 0x5a, 0x16, 0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x6f, 0x64, 0x75, 0x6c,
+	0x65, 0x2f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_module_v1_module_proto_rawDescOnce sync.Once
+	file_proto_module_v1_module_proto_rawDescData = file_proto_module_v1_module_proto_rawDesc
+)
+
+func file_proto_module_v1_module_proto_rawDescGZIP() []byte {
+	file_proto_module_v1_module_proto_rawDescOnce.Do(func() {
+		file_proto_module_v1_module_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_module_v1_module_proto_rawDescData)
+	})
+	return file_proto_module_v1_module_proto_rawDescData
+}
+
+var file_proto_module_v1_module_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_module_v1_module_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_module_v1_module_proto_goTypes = []interface{}{
+	(IQTarget)(0),                  // 0: module.v1.IQTarget
+	(*IQHandler)(nil),              // 1: module.v1.IQHandler
+	(*HookSubscription)(nil),       // 2: module.v1.HookSubscription
+	(*GetDescriptionRequest)(nil),  // 3: module.v1.GetDescriptionRequest
+	(*GetDescriptionResponse)(nil), // 4: module.v1.GetDescriptionResponse
+	(*ProcessIQRequest)(nil),       // 5: module.v1.ProcessIQRequest
+	(*ProcessIQResponse)(nil),      // 6: module.v1.ProcessIQResponse
+	(*ProcessHookRequest)(nil),     // 7: module.v1.ProcessHookRequest
+	(*ProcessHookResponse)(nil),    // 8: module.v1.ProcessHookResponse
+	(*stravaganza.PBElement)(nil),  // 9: stravaganza.PBElement
+}
+var file_proto_module_v1_module_proto_depIdxs = []int32{
+	0,  // 0: module.v1.IQHandler.target:type_name -> module.v1.IQTarget
+	1,  // 1: module.v1.GetDescriptionResponse.iq_handlers:type_name -> module.v1.IQHandler
+	2,  // 2: module.v1.GetDescriptionResponse.hooks:type_name -> module.v1.HookSubscription
+	9,  // 3: module.v1.ProcessIQRequest.iq:type_name -> stravaganza.PBElement
+	9,  // 4: module.v1.ProcessIQResponse.stanzas:type_name -> stravaganza.PBElement
+	9,  // 5: module.v1.ProcessHookRequest.element:type_name -> stravaganza.PBElement
+	9,  // 6: module.v1.ProcessHookResponse.stanzas:type_name -> stravaganza.PBElement
+	3,  // 7: module.v1.Module.GetDescription:input_type -> module.v1.GetDescriptionRequest
+	5,  // 8: module.v1.Module.ProcessIQ:input_type -> module.v1.ProcessIQRequest
+	7,  // 9: module.v1.Module.ProcessHook:input_type -> module.v1.ProcessHookRequest
+	4,  // 10: module.v1.Module.GetDescription:output_type -> module.v1.GetDescriptionResponse
+	6,  // 11: module.v1.Module.ProcessIQ:output_type -> module.v1.ProcessIQResponse
+	8,  // 12: module.v1.Module.ProcessHook:output_type -> module.v1.ProcessHookResponse
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_proto_module_v1_module_proto_init() }
+func file_proto_module_v1_module_proto_init() {
+	if File_proto_module_v1_module_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_module_v1_module_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IQHandler); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HookSubscription); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDescriptionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDescriptionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProcessIQRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProcessIQResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProcessHookRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_module_v1_module_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProcessHookResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_module_v1_module_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_module_v1_module_proto_goTypes,
+		DependencyIndexes: file_proto_module_v1_module_proto_depIdxs,
+		EnumInfos:         file_proto_module_v1_module_proto_enumTypes,
+		MessageInfos:      file_proto_module_v1_module_proto_msgTypes,
+	}.Build()
+	File_proto_module_v1_module_proto = out.File
+	file_proto_module_v1_module_proto_rawDesc = nil
+	file_proto_module_v1_module_proto_goTypes = nil
+	file_proto_module_v1_module_proto_depIdxs = nil
+}
@@ -0,0 +1,179 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ModuleClient is the client API for Module service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ModuleClient interface {
+	// GetDescription returns the set of namespaces, hooks and features handled by the module.
+	GetDescription(ctx context.Context, in *GetDescriptionRequest, opts ...grpc.CallOption) (*GetDescriptionResponse, error)
+	// ProcessIQ handles an incoming iq stanza targeted to one of the registered namespaces.
+	ProcessIQ(ctx context.Context, in *ProcessIQRequest, opts ...grpc.CallOption) (*ProcessIQResponse, error)
+	// ProcessHook handles a subscribed hook execution.
+	ProcessHook(ctx context.Context, in *ProcessHookRequest, opts ...grpc.CallOption) (*ProcessHookResponse, error)
+}
+
+type moduleClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewModuleClient(cc grpc.ClientConnInterface) ModuleClient {
+	return &moduleClient{cc}
+}
+
+func (c *moduleClient) GetDescription(ctx context.Context, in *GetDescriptionRequest, opts ...grpc.CallOption) (*GetDescriptionResponse, error) {
+	out := new(GetDescriptionResponse)
+	err := c.cc.Invoke(ctx, "/module.v1.Module/GetDescription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *moduleClient) ProcessIQ(ctx context.Context, in *ProcessIQRequest, opts ...grpc.CallOption) (*ProcessIQResponse, error) {
+	out := new(ProcessIQResponse)
+	err := c.cc.Invoke(ctx, "/module.v1.Module/ProcessIQ", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *moduleClient) ProcessHook(ctx context.Context, in *ProcessHookRequest, opts ...grpc.CallOption) (*ProcessHookResponse, error) {
+	out := new(ProcessHookResponse)
+	err := c.cc.Invoke(ctx, "/module.v1.Module/ProcessHook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ModuleServer is the server API for Module service.
+// All implementations must embed UnimplementedModuleServer
+// for forward compatibility
+type ModuleServer interface {
+	// GetDescription returns the set of namespaces, hooks and features handled by the module.
+	GetDescription(context.Context, *GetDescriptionRequest) (*GetDescriptionResponse, error)
+	// ProcessIQ handles an incoming iq stanza targeted to one of the registered namespaces.
+	ProcessIQ(context.Context, *ProcessIQRequest) (*ProcessIQResponse, error)
+	// ProcessHook handles a subscribed hook execution.
+	ProcessHook(context.Context, *ProcessHookRequest) (*ProcessHookResponse, error)
+	mustEmbedUnimplementedModuleServer()
+}
+
+// UnimplementedModuleServer must be embedded to have forward compatible implementations.
+type UnimplementedModuleServer struct {
+}
+
+func (UnimplementedModuleServer) GetDescription(context.Context, *GetDescriptionRequest) (*GetDescriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDescription not implemented")
+}
+func (UnimplementedModuleServer) ProcessIQ(context.Context, *ProcessIQRequest) (*ProcessIQResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessIQ not implemented")
+}
+func (UnimplementedModuleServer) ProcessHook(context.Context, *ProcessHookRequest) (*ProcessHookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessHook not implemented")
+}
+func (UnimplementedModuleServer) mustEmbedUnimplementedModuleServer() {}
+
+// UnsafeModuleServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ModuleServer will
+// result in compilation errors.
+type UnsafeModuleServer interface {
+	mustEmbedUnimplementedModuleServer()
+}
+
+func RegisterModuleServer(s grpc.ServiceRegistrar, srv ModuleServer) {
+	s.RegisterService(&Module_ServiceDesc, srv)
+}
+
+func _Module_GetDescription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDescriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModuleServer).GetDescription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/module.v1.Module/GetDescription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModuleServer).GetDescription(ctx, req.(*GetDescriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Module_ProcessIQ_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessIQRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModuleServer).ProcessIQ(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/module.v1.Module/ProcessIQ",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModuleServer).ProcessIQ(ctx, req.(*ProcessIQRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Module_ProcessHook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessHookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModuleServer).ProcessHook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/module.v1.Module/ProcessHook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModuleServer).ProcessHook(ctx, req.(*ProcessHookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Module_ServiceDesc is the grpc.ServiceDesc for Module service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Module_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "module.v1.Module",
+	HandlerType: (*ModuleServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDescription",
+			Handler:    _Module_GetDescription_Handler,
+		},
+		{
+			MethodName: "ProcessIQ",
+			Handler:    _Module_ProcessIQ_Handler,
+		},
+		{
+			MethodName: "ProcessHook",
+			Handler:    _Module_ProcessHook_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/module/v1/module.proto",
+}
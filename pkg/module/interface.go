@@ -14,12 +14,17 @@
 
 package module
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+
+	"github.com/ortuman/jackal/pkg/router"
+)
 
 //go:generate moq -out hosts.mock_test.go . hosts
 type hosts interface {
 	Certificates() []tls.Certificate
 	IsLocalHost(host string) bool
+	IsModuleEnabled(host, modName string) bool
 }
 
 //go:generate moq -out iq_processor.mock_test.go . iqProcessor
@@ -31,3 +36,8 @@ type iqProcessor interface {
 type module interface {
 	Module
 }
+
+//go:generate moq -out router.mock_test.go . globalRouter:routerMock
+type globalRouter interface {
+	router.Router
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkpreview
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	md        *metadata
+	expiresAt time.Time
+}
+
+// previewCache is an entry bounded LRU link metadata cache.
+type previewCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+func newPreviewCache(maxEntries int) *previewCache {
+	return &previewCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *previewCache) get(linkURL string, now time.Time) (*metadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[linkURL]
+	if !ok {
+		return nil, false
+	}
+	ce := e.Value.(*cacheEntry)
+	if !now.Before(ce.expiresAt) {
+		c.ll.Remove(e)
+		delete(c.items, linkURL)
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return ce.md, true
+}
+
+func (c *previewCache) put(md *metadata, expiresAt time.Time) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[md.url]; ok {
+		e.Value = &cacheEntry{md: md, expiresAt: expiresAt}
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[md.url] = c.ll.PushFront(&cacheEntry{md: md, expiresAt: expiresAt})
+
+	for c.ll.Len() > c.maxEntries {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*cacheEntry).md.url)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/router"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+)
+
+const (
+	linkPreviewNamespace = "urn:xmpp:jackal:link-preview:0"
+
+	rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	ogNamespace  = "https://ogp.me/ns#"
+)
+
+// ModuleName represents link preview module name.
+const ModuleName = "linkpreview"
+
+var urlRe = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// Config contains link preview module configuration options.
+type Config struct {
+	// Hosts defines the set of local domains whose outgoing messages get a link preview automatically attached.
+	//
+	// Messages are never delayed by a fetch: previews are only attached from cache, and an uncached link
+	// is fetched in the background so that later messages linking to it get previewed.
+	Hosts []string `fig:"hosts"`
+
+	// Allowlist defines the set of domains (including subdomains) link metadata can be fetched from.
+	Allowlist []string `fig:"allowlist"`
+
+	// MaxSize defines the maximum amount of bytes read from a linked document.
+	MaxSize int64 `fig:"max_size" default:"524288"`
+
+	// FetchTimeout defines link metadata fetch timeout.
+	FetchTimeout time.Duration `fig:"fetch_timeout" default:"5s"`
+
+	// CacheSize defines the maximum number of cached link previews.
+	CacheSize int `fig:"cache_size" default:"1024"`
+
+	// CacheTTL defines for how long a link preview is cached.
+	CacheTTL time.Duration `fig:"cache_ttl" default:"1h"`
+}
+
+// LinkPreview represents a module that generates link previews (XEP-0511 link metadata) on request,
+// or automatically for messages sent from configured hosts.
+type LinkPreview struct {
+	cfg    Config
+	router router.Router
+	hk     *hook.Hooks
+	client *http.Client
+	cache  *previewCache
+	hosts  map[string]struct{}
+	logger kitlog.Logger
+
+	fetchCtx    context.Context
+	fetchCancel context.CancelFunc
+	fetchWg     sync.WaitGroup
+
+	mu       sync.Mutex
+	inflight map[string]struct{}
+}
+
+// New returns a new initialized LinkPreview instance.
+func New(cfg Config, router router.Router, hk *hook.Hooks, logger kitlog.Logger) *LinkPreview {
+	fetchCtx, fetchCancel := context.WithCancel(context.Background())
+	m := &LinkPreview{
+		cfg:         cfg,
+		router:      router,
+		hk:          hk,
+		cache:       newPreviewCache(cfg.CacheSize),
+		hosts:       make(map[string]struct{}),
+		logger:      kitlog.With(logger, "module", ModuleName),
+		fetchCtx:    fetchCtx,
+		fetchCancel: fetchCancel,
+		inflight:    make(map[string]struct{}),
+	}
+	for _, h := range cfg.Hosts {
+		m.hosts[h] = struct{}{}
+	}
+	m.client = &http.Client{
+		Timeout:       cfg.FetchTimeout,
+		CheckRedirect: m.checkRedirect,
+	}
+	return m
+}
+
+// Name returns link preview module name.
+func (m *LinkPreview) Name() string { return ModuleName }
+
+// StreamFeature returns link preview module stream feature.
+func (m *LinkPreview) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns link preview server disco features.
+func (m *LinkPreview) ServerFeatures(_ context.Context) ([]string, error) {
+	return []string{linkPreviewNamespace}, nil
+}
+
+// AccountFeatures returns link preview account disco features.
+func (m *LinkPreview) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// MatchesNamespace tells whether namespace matches link preview module.
+func (m *LinkPreview) MatchesNamespace(namespace string, serverTarget bool) bool {
+	return serverTarget && namespace == linkPreviewNamespace
+}
+
+// ProcessIQ process a link preview iq.
+func (m *LinkPreview) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	pv := iq.ChildNamespace("preview", linkPreviewNamespace)
+	if !iq.IsGet() || pv == nil || len(pv.Attribute("url")) == 0 {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	linkURL := pv.Attribute("url")
+	if !m.isAllowed(linkURL) {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.NotAllowed))
+		return nil
+	}
+	md := m.metadata(ctx, linkURL)
+	if md.isEmpty() {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+		return nil
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq,
+		stravaganza.NewBuilder("preview").
+			WithAttribute(stravaganza.Namespace, linkPreviewNamespace).
+			WithAttribute("url", linkURL).
+			WithChild(md.element()).
+			Build(),
+	))
+	level.Info(m.logger).Log("msg", "served link preview", "jid", iq.FromJID().String(), "url", linkURL)
+	return nil
+}
+
+// Start starts link preview module.
+func (m *LinkPreview) Start(_ context.Context) error {
+	if len(m.cfg.Allowlist) == 0 {
+		return errors.New("linkpreview: allowlist must be set")
+	}
+	m.hk.AddHook(hook.C2SStreamWillRouteElement, m.onC2SElementWillRoute, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started linkpreview module")
+	return nil
+}
+
+// Stop stops link preview module.
+func (m *LinkPreview) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.C2SStreamWillRouteElement, m.onC2SElementWillRoute)
+
+	// cancel and wait for background fetches
+	m.fetchCancel()
+	m.fetchWg.Wait()
+
+	level.Info(m.logger).Log("msg", "stopped linkpreview module")
+	return nil
+}
+
+func (m *LinkPreview) onC2SElementWillRoute(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+
+	msg, ok := inf.Element.(*stravaganza.Message)
+	if !ok || !msg.IsMessageWithBody() {
+		return nil
+	}
+	if _, ok := m.hosts[msg.FromJID().Domain()]; !ok {
+		return nil
+	}
+	if msg.Child("rdf:Description") != nil {
+		return nil // already previewed by sender
+	}
+	// do not preview links contained in fallback text, such as quoted replies
+	linkURL := urlRe.FindString(xmpputil.MessageBody(msg))
+	if len(linkURL) == 0 || !m.isAllowed(linkURL) {
+		return nil
+	}
+	md, ok := m.cache.get(linkURL, time.Now())
+	if !ok {
+		m.prefetch(linkURL)
+		return nil
+	}
+	if md.isEmpty() {
+		return nil
+	}
+	previewed, err := stravaganza.NewBuilderFromElement(msg).
+		WithChild(md.element()).
+		BuildMessage()
+	if err != nil {
+		return err
+	}
+	inf.Element = previewed
+	return nil
+}
+
+// prefetch fetches and caches link metadata in the background, unless already being fetched.
+func (m *LinkPreview) prefetch(linkURL string) {
+	m.mu.Lock()
+	if _, ok := m.inflight[linkURL]; ok {
+		m.mu.Unlock()
+		return
+	}
+	m.inflight[linkURL] = struct{}{}
+	m.mu.Unlock()
+
+	m.fetchWg.Add(1)
+	go func() {
+		defer m.fetchWg.Done()
+
+		_ = m.metadata(m.fetchCtx, linkURL)
+
+		m.mu.Lock()
+		delete(m.inflight, linkURL)
+		m.mu.Unlock()
+	}()
+}
+
+func (m *LinkPreview) metadata(ctx context.Context, linkURL string) *metadata {
+	if md, ok := m.cache.get(linkURL, time.Now()); ok {
+		return md
+	}
+	md, err := m.fetchMetadata(ctx, linkURL)
+	if err != nil && ctx.Err() != nil {
+		return &metadata{url: linkURL} // canceled, do not cache
+	}
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to fetch link metadata", "url", linkURL, "err", err)
+		md = &metadata{url: linkURL} // avoid refetching failing links until expiration
+	}
+	m.cache.put(md, time.Now().Add(m.cfg.CacheTTL))
+	return md
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkpreview
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `<!DOCTYPE html>
+<html>
+<head>
+  <title>A fallback title</title>
+  <meta property="og:title" content="Romeo and Juliet">
+  <meta name="description" content="A tragedy written by William Shakespeare.">
+  <meta property="og:image" content="https://shakespeare.lit/cover.png">
+</head>
+<body><meta property="og:description" content="ignored"></body>
+</html>`
+
+func TestLinkPreview_ParseMetadata(t *testing.T) {
+	// when
+	md := parseMetadata(strings.NewReader(testDocument))
+
+	// then
+	require.Equal(t, "Romeo and Juliet", md.title)
+	require.Equal(t, "A tragedy written by William Shakespeare.", md.description)
+	require.Equal(t, "https://shakespeare.lit/cover.png", md.image)
+}
+
+func TestLinkPreview_RequestPreview(t *testing.T) {
+	// given
+	origin := testOrigin(nil)
+	defer origin.Close()
+
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	m := testLinkPreview(routerMock, nil)
+
+	for _, linkURL := range []string{origin.URL + "/romeo", "https://evil.lit/romeo"} {
+		// when
+		iq, _ := stravaganza.NewIQBuilder().
+			WithAttribute(stravaganza.ID, "lp1").
+			WithAttribute(stravaganza.Type, stravaganza.GetType).
+			WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+			WithAttribute(stravaganza.To, "jackal.im").
+			WithChild(
+				stravaganza.NewBuilder("preview").
+					WithAttribute(stravaganza.Namespace, linkPreviewNamespace).
+					WithAttribute("url", linkURL).
+					Build(),
+			).
+			BuildIQ()
+		require.Nil(t, m.ProcessIQ(context.Background(), iq))
+	}
+
+	// then
+	require.Len(t, respStanzas, 2)
+
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Attribute(stravaganza.Type))
+	desc := respStanzas[0].ChildNamespace("preview", linkPreviewNamespace).Child("rdf:Description")
+	require.NotNil(t, desc)
+	require.Equal(t, origin.URL+"/romeo", desc.Attribute("rdf:about"))
+	require.Equal(t, "Romeo and Juliet", desc.Child("og:title").Text())
+
+	require.Equal(t, stravaganza.ErrorType, respStanzas[1].Attribute(stravaganza.Type))
+	require.NotNil(t, respStanzas[1].Child("error").Child("not-allowed"))
+}
+
+func TestLinkPreview_AttachPreview(t *testing.T) {
+	// given
+	var hits int
+	origin := testOrigin(&hits)
+	defer origin.Close()
+
+	m := testLinkPreview(&routerMock{}, []string{"jackal.im"})
+
+	for i, from := range []string{"ortuman@jackal.im/yard", "ortuman@jackal.im/yard", "ortuman@jackal.im/yard", "juliet@capulet.lit/balcony"} {
+		msg, _ := stravaganza.NewMessageBuilder().
+			WithAttribute(stravaganza.From, from).
+			WithAttribute(stravaganza.To, "noelia@jackal.im").
+			WithAttribute(stravaganza.Type, stravaganza.ChatType).
+			WithChild(
+				stravaganza.NewBuilder("body").
+					WithText("have a look at " + origin.URL + "/romeo").
+					Build(),
+			).
+			BuildMessage()
+		inf := &hook.C2SStreamInfo{Element: msg}
+
+		// when
+		err := m.onC2SElementWillRoute(&hook.ExecutionContext{
+			Info:    inf,
+			Context: context.Background(),
+		})
+
+		// then
+		require.Nil(t, err)
+
+		desc := inf.Element.(*stravaganza.Message).Child("rdf:Description")
+		switch {
+		case i == 0:
+			require.Nil(t, desc) // not cached yet, fetched in background
+			m.fetchWg.Wait()
+
+		case strings.HasSuffix(from, "@jackal.im/yard"):
+			require.NotNil(t, desc)
+			require.Equal(t, "Romeo and Juliet", desc.Child("og:title").Text())
+
+		default:
+			require.Nil(t, desc) // not a preview enabled host
+		}
+	}
+	require.Equal(t, 1, hits) // served from cache
+}
+
+func TestLinkPreview_SkipFallbackLinks(t *testing.T) {
+	// given
+	var hits int
+	origin := testOrigin(&hits)
+	defer origin.Close()
+
+	m := testLinkPreview(&routerMock{}, []string{"jackal.im"})
+
+	quote := "> " + origin.URL + "/romeo\n"
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText(quote + "Sí, ahora voy").
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("fallback").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:fallback:0").
+				WithAttribute("for", "urn:xmpp:reply:0").
+				WithChild(
+					stravaganza.NewBuilder("body").
+						WithAttribute("start", "0").
+						WithAttribute("end", strconv.Itoa(len([]rune(quote)))).
+						Build(),
+				).
+				Build(),
+		).
+		BuildMessage()
+	inf := &hook.C2SStreamInfo{Element: msg}
+
+	// when
+	err := m.onC2SElementWillRoute(&hook.ExecutionContext{
+		Info:    inf,
+		Context: context.Background(),
+	})
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, inf.Element.(*stravaganza.Message).Child("rdf:Description"))
+	require.Equal(t, 0, hits)
+}
+
+func testOrigin(hits *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			*hits++
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, testDocument)
+	}))
+}
+
+func testLinkPreview(routerMock *routerMock, hosts []string) *LinkPreview {
+	return New(Config{
+		Hosts:        hosts,
+		Allowlist:    []string{"127.0.0.1"},
+		MaxSize:      4096,
+		FetchTimeout: time.Second,
+		CacheSize:    16,
+		CacheTTL:     time.Minute,
+	}, routerMock, hook.NewHooks(), kitlog.NewNopLogger())
+}
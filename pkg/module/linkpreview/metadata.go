@@ -0,0 +1,172 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"golang.org/x/net/html"
+)
+
+const maxRedirects = 5
+
+var errNotAllowed = errors.New("linkpreview: domain not allowed")
+
+type metadata struct {
+	url         string
+	title       string
+	description string
+	image       string
+}
+
+func (md *metadata) isEmpty() bool {
+	return len(md.title) == 0 && len(md.description) == 0
+}
+
+// element returns metadata XEP-0511 representation.
+func (md *metadata) element() stravaganza.Element {
+	b := stravaganza.NewBuilder("rdf:Description").
+		WithAttribute("xmlns:rdf", rdfNamespace).
+		WithAttribute("xmlns:og", ogNamespace).
+		WithAttribute("rdf:about", md.url)
+	if len(md.title) > 0 {
+		b.WithChild(stravaganza.NewBuilder("og:title").WithText(md.title).Build())
+	}
+	if len(md.description) > 0 {
+		b.WithChild(stravaganza.NewBuilder("og:description").WithText(md.description).Build())
+	}
+	if len(md.image) > 0 {
+		b.WithChild(stravaganza.NewBuilder("og:image").WithText(md.image).Build())
+	}
+	return b.Build()
+}
+
+func (m *LinkPreview) fetchMetadata(ctx context.Context, linkURL string) (*metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, linkURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("linkpreview: unexpected status code: %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return &metadata{url: linkURL}, nil // nothing to preview
+	}
+	md := parseMetadata(io.LimitReader(resp.Body, m.cfg.MaxSize))
+	md.url = linkURL
+	return md, nil
+}
+
+func (m *LinkPreview) isAllowed(linkURL string) bool {
+	u, err := url.Parse(linkURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range m.cfg.Allowlist {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *LinkPreview) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("linkpreview: stopped after %d redirects", maxRedirects)
+	}
+	if !m.isAllowed(req.URL.String()) {
+		return errNotAllowed
+	}
+	return nil
+}
+
+// parseMetadata extracts document title, description and image from an HTML document head,
+// preferring Open Graph properties when available.
+func parseMetadata(r io.Reader) *metadata {
+	var md metadata
+	var docTitle, docDescription string
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return fillMetadata(&md, docTitle, docDescription)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tn, hasAttr := z.TagName()
+			switch string(tn) {
+			case "body":
+				return fillMetadata(&md, docTitle, docDescription)
+
+			case "title":
+				if z.Next() == html.TextToken {
+					docTitle = strings.TrimSpace(string(z.Text()))
+				}
+
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = strings.TrimSpace(string(v))
+					}
+				}
+				switch key {
+				case "og:title":
+					md.title = content
+				case "og:description":
+					md.description = content
+				case "og:image":
+					md.image = content
+				case "description":
+					docDescription = content
+				}
+			}
+
+		case html.EndTagToken:
+			if tn, _ := z.TagName(); string(tn) == "head" {
+				return fillMetadata(&md, docTitle, docDescription)
+			}
+		}
+	}
+}
+
+func fillMetadata(md *metadata, docTitle, docDescription string) *metadata {
+	if len(md.title) == 0 {
+		md.title = docTitle
+	}
+	if len(md.description) == 0 {
+		md.description = docDescription
+	}
+	return md
+}
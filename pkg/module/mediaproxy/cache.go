@@ -0,0 +1,88 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaproxy
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type cachedMedia struct {
+	url         string
+	contentType string
+	b           []byte
+	expiresAt   time.Time
+}
+
+// mediaCache is a size bounded LRU media cache.
+type mediaCache struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	ll      *list.List
+	items   map[string]*list.Element
+}
+
+func newMediaCache(maxSize int64) *mediaCache {
+	return &mediaCache{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+func (c *mediaCache) get(url string, now time.Time) *cachedMedia {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[url]
+	if !ok {
+		return nil
+	}
+	md := e.Value.(*cachedMedia)
+	if !now.Before(md.expiresAt) {
+		c.remove(e)
+		return nil
+	}
+	c.ll.MoveToFront(e)
+	return md
+}
+
+func (c *mediaCache) put(md *cachedMedia, expiresAt time.Time) {
+	mdSize := int64(len(md.b))
+	if mdSize > c.maxSize {
+		return // doesn't fit
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[md.url]; ok {
+		c.remove(e)
+	}
+	md.expiresAt = expiresAt
+	c.items[md.url] = c.ll.PushFront(md)
+	c.size += mdSize
+
+	for c.size > c.maxSize {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *mediaCache) remove(e *list.Element) {
+	md := c.ll.Remove(e).(*cachedMedia)
+	delete(c.items, md.url)
+	c.size -= int64(len(md.b))
+}
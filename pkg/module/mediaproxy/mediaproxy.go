@@ -0,0 +1,362 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+)
+
+const (
+	oobNamespace       = "jabber:x:oob"
+	referenceNamespace = "urn:xmpp:reference:0"
+	simsNamespace      = "urn:xmpp:sims:1"
+
+	mediaPath = "/media/"
+)
+
+// ModuleName represents media proxy module name.
+const ModuleName = "mediaproxy"
+
+var errPrivateAddress = errors.New("mediaproxy: private address not allowed")
+
+// Config contains media proxy module configuration options.
+type Config struct {
+	// BindAddr defines media proxy HTTP listener address.
+	BindAddr string `fig:"bind_addr"`
+
+	// Port defines media proxy HTTP listener port.
+	Port int `fig:"port" default:"5283"`
+
+	// BaseURL is the externally reachable media proxy URL used to rewrite media URLs (e.g. https://media.jackal.im).
+	BaseURL string `fig:"base_url"`
+
+	// Secret is the key used to sign proxied URLs.
+	Secret string `fig:"secret"`
+
+	// MaxSize defines the maximum size in bytes of a proxied media file.
+	MaxSize int64 `fig:"max_size" default:"10485760"`
+
+	// FetchTimeout defines origin fetch timeout.
+	FetchTimeout time.Duration `fig:"fetch_timeout" default:"30s"`
+
+	// CacheSize defines the maximum amount of bytes kept in the media cache (0 disables caching).
+	CacheSize int64 `fig:"cache_size" default:"67108864"`
+
+	// CacheTTL defines for how long a fetched media file is served from cache.
+	CacheTTL time.Duration `fig:"cache_ttl" default:"10m"`
+
+	// AllowPrivateAddresses tells whether media may be fetched from loopback or private network addresses.
+	AllowPrivateAddresses bool `fig:"allow_private_addresses"`
+}
+
+// MediaProxy represents a module that proxies out-of-band (XEP-0066) and SIMS (XEP-0385) media URLs
+// through the server, so recipients don't reveal their IP addresses to third-party hosts.
+type MediaProxy struct {
+	cfg    Config
+	hk     *hook.Hooks
+	client *http.Client
+	cache  *mediaCache
+	srv    *http.Server
+	logger kitlog.Logger
+}
+
+// New returns a new initialized MediaProxy instance.
+func New(cfg Config, hk *hook.Hooks, logger kitlog.Logger) *MediaProxy {
+	m := &MediaProxy{
+		cfg:    cfg,
+		hk:     hk,
+		cache:  newMediaCache(cfg.CacheSize),
+		logger: kitlog.With(logger, "module", ModuleName),
+	}
+	dialer := &net.Dialer{Control: m.dialControl}
+	m.client = &http.Client{
+		Timeout:   cfg.FetchTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+	return m
+}
+
+// Name returns media proxy module name.
+func (m *MediaProxy) Name() string { return ModuleName }
+
+// StreamFeature returns media proxy module stream feature.
+func (m *MediaProxy) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns media proxy server disco features.
+func (m *MediaProxy) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns media proxy account disco features.
+func (m *MediaProxy) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts media proxy module.
+func (m *MediaProxy) Start(_ context.Context) error {
+	if len(m.cfg.Secret) == 0 || len(m.cfg.BaseURL) == 0 {
+		return errors.New("mediaproxy: secret and base_url must be set")
+	}
+	if m.cfg.MaxSize <= 0 {
+		return errors.New("mediaproxy: max_size must be positive")
+	}
+	mux := http.NewServeMux()
+	mux.Handle(mediaPath, m)
+
+	m.srv = &http.Server{Handler: mux}
+	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", m.cfg.BindAddr, m.cfg.Port))
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := m.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			level.Error(m.logger).Log("msg", "failed to serve media proxy", "err", err)
+		}
+	}()
+	m.hk.AddHook(hook.C2SStreamWillSendElement, m.onWillSendElement, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started mediaproxy module", "port", m.cfg.Port)
+	return nil
+}
+
+// Stop stops media proxy module.
+func (m *MediaProxy) Stop(ctx context.Context) error {
+	m.hk.RemoveHook(hook.C2SStreamWillSendElement, m.onWillSendElement)
+
+	if err := m.srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	level.Info(m.logger).Log("msg", "stopped mediaproxy module")
+	return nil
+}
+
+// ServeHTTP handles proxied media requests.
+func (m *MediaProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, mediaPath)
+	if i := strings.IndexByte(token, '/'); i >= 0 {
+		token = token[:i] // strip file name
+	}
+	mediaURL, err := decodeToken(token, []byte(m.cfg.Secret))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	md := m.cache.get(mediaURL, time.Now())
+	if md == nil {
+		var status int
+
+		md, status = m.fetch(r.Context(), mediaURL)
+		if md == nil {
+			w.WriteHeader(status)
+			return
+		}
+		m.cache.put(md, time.Now().Add(m.cfg.CacheTTL))
+	}
+	w.Header().Set("Content-Type", md.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(md.b)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(md.b)
+}
+
+func (m *MediaProxy) fetch(ctx context.Context, mediaURL string) (*cachedMedia, int) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, http.StatusNotFound
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to fetch media", "url", mediaURL, "err", err)
+		return nil, http.StatusBadGateway
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, http.StatusBadGateway
+	}
+	if resp.ContentLength > m.cfg.MaxSize {
+		return nil, http.StatusRequestEntityTooLarge
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, m.cfg.MaxSize+1))
+	if err != nil {
+		return nil, http.StatusBadGateway
+	}
+	if int64(len(b)) > m.cfg.MaxSize {
+		return nil, http.StatusRequestEntityTooLarge
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		contentType = http.DetectContentType(b)
+	}
+	level.Debug(m.logger).Log("msg", "fetched media", "url", mediaURL, "size", len(b))
+
+	return &cachedMedia{url: mediaURL, contentType: contentType, b: b}, http.StatusOK
+}
+
+func (m *MediaProxy) dialControl(_, address string, _ syscall.RawConn) error {
+	if m.cfg.AllowPrivateAddresses {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+func (m *MediaProxy) onWillSendElement(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+
+	msg, ok := inf.Element.(*stravaganza.Message)
+	if !ok {
+		return nil
+	}
+	if rewritten := m.rewriteMessage(msg); rewritten != nil {
+		inf.Element = rewritten
+	}
+	return nil
+}
+
+func (m *MediaProxy) rewriteMessage(msg *stravaganza.Message) *stravaganza.Message {
+	urls := make(map[string]string)
+
+	var children []stravaganza.Element
+	for _, child := range msg.AllChildren() {
+		ns := child.Attribute(stravaganza.Namespace)
+		switch {
+		case child.Name() == "x" && ns == oobNamespace:
+			child = m.rewriteOOB(child, urls)
+		case child.Name() == "reference" && ns == referenceNamespace:
+			child = m.rewriteSIMS(child, urls)
+		}
+		children = append(children, child)
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	// clients usually mirror the shared URL into message body
+	for i, child := range children {
+		if child.Name() != "body" {
+			continue
+		}
+		if proxiedURL, ok := urls[strings.TrimSpace(child.Text())]; ok {
+			children[i] = stravaganza.NewBuilderFromElement(child).WithText(proxiedURL).Build()
+		}
+	}
+	rewritten, err := stravaganza.NewMessageBuilder().
+		WithAttributes(msg.AllAttributes()...).
+		WithChildren(children...).
+		BuildMessage()
+	if err != nil {
+		return nil
+	}
+	return rewritten
+}
+
+func (m *MediaProxy) rewriteOOB(x stravaganza.Element, urls map[string]string) stravaganza.Element {
+	return mapChildren(x, func(u stravaganza.Element) stravaganza.Element {
+		if u.Name() != "url" {
+			return u
+		}
+		mediaURL := strings.TrimSpace(u.Text())
+		proxiedURL, ok := m.proxiedURL(mediaURL)
+		if !ok {
+			return u
+		}
+		urls[mediaURL] = proxiedURL
+		return stravaganza.NewBuilderFromElement(u).WithText(proxiedURL).Build()
+	})
+}
+
+func (m *MediaProxy) rewriteSIMS(ref stravaganza.Element, urls map[string]string) stravaganza.Element {
+	return mapChildren(ref, func(ms stravaganza.Element) stravaganza.Element {
+		if ms.Name() != "media-sharing" || ms.Attribute(stravaganza.Namespace) != simsNamespace {
+			return ms
+		}
+		return mapChildren(ms, func(sources stravaganza.Element) stravaganza.Element {
+			if sources.Name() != "sources" {
+				return sources
+			}
+			return mapChildren(sources, func(src stravaganza.Element) stravaganza.Element {
+				if src.Name() != "reference" {
+					return src
+				}
+				mediaURL := src.Attribute("uri")
+				proxiedURL, ok := m.proxiedURL(mediaURL)
+				if !ok {
+					return src
+				}
+				urls[mediaURL] = proxiedURL
+				return stravaganza.NewBuilderFromElement(src).WithAttribute("uri", proxiedURL).Build()
+			})
+		})
+	})
+}
+
+func (m *MediaProxy) proxiedURL(mediaURL string) (string, bool) {
+	u, err := url.Parse(mediaURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return "", false
+	}
+	baseURL := strings.TrimSuffix(m.cfg.BaseURL, "/")
+	if strings.HasPrefix(mediaURL, baseURL+"/") {
+		return "", false // already proxied
+	}
+	proxiedURL := baseURL + mediaPath + encodeToken(mediaURL, []byte(m.cfg.Secret))
+	if fileName := path.Base(u.Path); fileName != "/" && fileName != "." {
+		proxiedURL += "/" + url.PathEscape(fileName)
+	}
+	return proxiedURL, true
+}
+
+func mapChildren(elem stravaganza.Element, fn func(stravaganza.Element) stravaganza.Element) stravaganza.Element {
+	children := elem.AllChildren()
+	mapped := make([]stravaganza.Element, 0, len(children))
+	for _, child := range children {
+		mapped = append(mapped, fn(child))
+	}
+	return stravaganza.NewBuilder(elem.Name()).
+		WithAttributes(elem.AllAttributes()...).
+		WithChildren(mapped...).
+		WithText(elem.Text()).
+		Build()
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaProxy_RewriteMessage(t *testing.T) {
+	// given
+	m := testMediaProxy(Config{})
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/balcony").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("https://upload.example.org/a/cat.jpg").
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("x").
+				WithAttribute(stravaganza.Namespace, oobNamespace).
+				WithChild(stravaganza.NewBuilder("url").WithText("https://upload.example.org/a/cat.jpg").Build()).
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("reference").
+				WithAttribute(stravaganza.Namespace, referenceNamespace).
+				WithAttribute("type", "data").
+				WithChild(
+					stravaganza.NewBuilder("media-sharing").
+						WithAttribute(stravaganza.Namespace, simsNamespace).
+						WithChild(
+							stravaganza.NewBuilder("sources").
+								WithChild(
+									stravaganza.NewBuilder("reference").
+										WithAttribute(stravaganza.Namespace, referenceNamespace).
+										WithAttribute("uri", "http://files.example.net/dog.png").
+										Build(),
+								).
+								Build(),
+						).
+						Build(),
+				).
+				Build(),
+		).
+		BuildMessage()
+
+	inf := &hook.C2SStreamInfo{Element: msg}
+
+	// when
+	err := m.onWillSendElement(&hook.ExecutionContext{
+		Info:    inf,
+		Context: context.Background(),
+	})
+
+	// then
+	require.Nil(t, err)
+
+	rewritten, ok := inf.Element.(*stravaganza.Message)
+	require.True(t, ok)
+	require.Equal(t, "ortuman@jackal.im/balcony", rewritten.Attribute(stravaganza.To))
+
+	oobURL := rewritten.ChildNamespace("x", oobNamespace).Child("url").Text()
+	require.True(t, strings.HasPrefix(oobURL, "https://media.jackal.im/media/"))
+	require.True(t, strings.HasSuffix(oobURL, "/cat.jpg"))
+	require.Equal(t, oobURL, rewritten.Child("body").Text())
+
+	simsURL := rewritten.ChildNamespace("reference", referenceNamespace).
+		ChildNamespace("media-sharing", simsNamespace).
+		Child("sources").
+		Child("reference").
+		Attribute("uri")
+	require.True(t, strings.HasPrefix(simsURL, "https://media.jackal.im/media/"))
+	require.True(t, strings.HasSuffix(simsURL, "/dog.png"))
+}
+
+func TestMediaProxy_ServeMedia(t *testing.T) {
+	// given
+	var hits int
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = io.WriteString(w, "a-cat-picture")
+	}))
+	defer origin.Close()
+
+	m := testMediaProxy(Config{AllowPrivateAddresses: true})
+
+	proxiedURL, ok := m.proxiedURL(origin.URL + "/a/cat.jpg")
+	require.True(t, ok)
+
+	for i := 0; i < 2; i++ {
+		// when
+		req := httptest.NewRequest(http.MethodGet, strings.TrimPrefix(proxiedURL, "https://media.jackal.im"), nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		// then
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+		require.Equal(t, "a-cat-picture", rec.Body.String())
+	}
+	require.Equal(t, 1, hits) // second request served from cache
+}
+
+func TestMediaProxy_RejectedMedia(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "a-very-large-cat-picture")
+	}))
+	defer origin.Close()
+
+	var tcs = map[string]struct {
+		cfg            Config
+		path           func(m *MediaProxy) string
+		expectedStatus int
+	}{
+		"InvalidToken": {
+			cfg: Config{AllowPrivateAddresses: true},
+			path: func(_ *MediaProxy) string {
+				return mediaPath + encodeToken(origin.URL, []byte("another-secret"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		"PrivateAddress": {
+			path: func(m *MediaProxy) string {
+				u, _ := m.proxiedURL(origin.URL + "/cat.jpg")
+				return strings.TrimPrefix(u, "https://media.jackal.im")
+			},
+			expectedStatus: http.StatusBadGateway,
+		},
+		"TooLarge": {
+			cfg: Config{AllowPrivateAddresses: true, MaxSize: 8},
+			path: func(m *MediaProxy) string {
+				u, _ := m.proxiedURL(origin.URL + "/cat.jpg")
+				return strings.TrimPrefix(u, "https://media.jackal.im")
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			m := testMediaProxy(tc.cfg)
+
+			// when
+			req := httptest.NewRequest(http.MethodGet, tc.path(m), nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			// then
+			require.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func testMediaProxy(cfg Config) *MediaProxy {
+	cfg.BaseURL = "https://media.jackal.im"
+	cfg.Secret = "a-super-secret-key"
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 1024
+	}
+	cfg.FetchTimeout = time.Second
+	cfg.CacheSize = 1024
+	cfg.CacheTTL = time.Minute
+	return New(cfg, hook.NewHooks(), kitlog.NewNopLogger())
+}
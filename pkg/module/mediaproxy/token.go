@@ -0,0 +1,55 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var errInvalidToken = errors.New("mediaproxy: invalid media token")
+
+// encodeToken signs media URL, so that the proxy only fetches URLs it has previously rewritten.
+func encodeToken(mediaURL string, secret []byte) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(mediaURL))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sign(payload, secret))
+}
+
+func decodeToken(token string, secret []byte) (string, error) {
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return "", errInvalidToken
+	}
+	payload, sig := token[:i], token[i+1:]
+
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(b, sign(payload, secret)) {
+		return "", errInvalidToken
+	}
+	b, err = base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errInvalidToken
+	}
+	return string(b), nil
+}
+
+func sign(payload string, secret []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -147,11 +147,25 @@ func (m *Modules) IsModuleIQ(iq *stravaganza.IQ) bool {
 
 // ProcessIQ routes the iq to the corresponding iq handler module.
 func (m *Modules) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	halted, err := m.hk.Run(hook.ModulesWillProcessIQ, &hook.ExecutionContext{
+		Info: &hook.ModulesIQInfo{
+			IQ: iq,
+		},
+		Sender:  m,
+		Context: ctx,
+	})
+	if halted || err != nil {
+		return err
+	}
 	ns := iq.AllChildren()[0].Attribute(stravaganza.Namespace)
+	toJID := iq.ToJID()
 	for _, iqHnd := range m.iqProcessors {
-		if !iqHnd.MatchesNamespace(ns, iq.ToJID().IsServer()) {
+		if !iqHnd.MatchesNamespace(ns, toJID.IsServer()) {
 			continue
 		}
+		if !m.hosts.IsModuleEnabled(toJID.Domain(), iqHnd.Name()) {
+			break // module disabled for target host
+		}
 		return iqHnd.ProcessIQ(ctx, iq)
 	}
 	// ...IQ not handled...
@@ -164,6 +178,9 @@ func (m *Modules) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
 func (m *Modules) StreamFeatures(ctx context.Context, domain string) ([]stravaganza.Element, error) {
 	var sfs []stravaganza.Element
 	for _, mod := range m.mods {
+		if !m.hosts.IsModuleEnabled(domain, mod.Name()) {
+			continue
+		}
 		sf, err := mod.StreamFeature(ctx, domain)
 		if err != nil {
 			return nil, err
@@ -185,6 +202,11 @@ func (m *Modules) IsEnabled(moduleName string) bool {
 	return false
 }
 
+// IsEnabledForHost tells whether a specific module it's been registered and enabled for h host.
+func (m *Modules) IsEnabledForHost(moduleName, h string) bool {
+	return m.IsEnabled(moduleName) && m.hosts.IsModuleEnabled(h, moduleName)
+}
+
 // AllModules returns all configured modules.
 func (m *Modules) AllModules() []Module {
 	return m.mods
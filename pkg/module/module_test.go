@@ -21,6 +21,7 @@ import (
 	kitlog "github.com/go-kit/log"
 
 	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/stretchr/testify/require"
 )
@@ -63,6 +64,7 @@ func TestModules_ProcessIQ(t *testing.T) {
 
 	hMock := &hostsMock{}
 	hMock.IsLocalHostFunc = func(domain string) bool { return domain == "jackal.im" }
+	hMock.IsModuleEnabledFunc = func(_, _ string) bool { return true }
 
 	mods := &Modules{
 		mods:         []Module{iqPrMock},
@@ -93,3 +95,58 @@ func TestModules_ProcessIQ(t *testing.T) {
 	require.Len(t, iqPrMock.MatchesNamespaceCalls(), 1)
 	require.Len(t, iqPrMock.ProcessIQCalls(), 1)
 }
+
+func TestModules_ProcessIQDisabledForHost(t *testing.T) {
+	// given
+	iqPrMock := &iqProcessorMock{}
+	iqPrMock.NameFunc = func() string { return "ping" }
+	iqPrMock.MatchesNamespaceFunc = func(namespace string, _ bool) bool {
+		return namespace == "urn:xmpp:ping"
+	}
+	iqPrMock.ProcessIQFunc = func(ctx context.Context, iq *stravaganza.IQ) error {
+		return nil
+	}
+
+	hMock := &hostsMock{}
+	hMock.IsLocalHostFunc = func(domain string) bool { return domain == "jackal.im" }
+	hMock.IsModuleEnabledFunc = func(host, modName string) bool {
+		return !(host == "jackal.im" && modName == "ping")
+	}
+
+	var respStanzas []stravaganza.Element
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+
+	mods := &Modules{
+		mods:         []Module{iqPrMock},
+		iqProcessors: []IQProcessor{iqPrMock},
+		hosts:        hMock,
+		router:       routerMock,
+		hk:           hook.NewHooks(),
+		logger:       kitlog.NewNopLogger(),
+	}
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "iq0001").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/res0001").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("ping").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:ping").
+				Build(),
+		).
+		BuildIQ()
+
+	_ = mods.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, iqPrMock.ProcessIQCalls(), 0)
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
+	require.NotNil(t, respStanzas[0].Child("error").Child("service-unavailable"))
+}
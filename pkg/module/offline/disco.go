@@ -0,0 +1,83 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackal-xmpp/stravaganza/jid"
+	discomodel "github.com/ortuman/jackal/pkg/model/disco"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+// nodeProvider answers disco requests addressed to the offline messages node (XEP-0013).
+type nodeProvider struct {
+	rep         repository.Offline
+	onRequested func(ctx context.Context, fromJID *jid.JID) error
+}
+
+func (p *nodeProvider) Identities(_ context.Context, _, _ *jid.JID, _ string) []discomodel.Identity {
+	return []discomodel.Identity{{
+		Category: "automation",
+		Type:     "message-list",
+		Name:     "Offline Messages",
+	}}
+}
+
+func (p *nodeProvider) Features(_ context.Context, _, _ *jid.JID, _ string) ([]discomodel.Feature, error) {
+	return []discomodel.Feature{offlineNamespace}, nil
+}
+
+func (p *nodeProvider) Forms(ctx context.Context, toJID, fromJID *jid.JID, _ string) ([]xep0004.DataForm, error) {
+	if !isQueueOwner(toJID, fromJID) {
+		return nil, nil
+	}
+	count, err := p.rep.CountOfflineMessages(ctx, fromJID.Node())
+	if err != nil {
+		return nil, err
+	}
+	return []xep0004.DataForm{{
+		Type: xep0004.Result,
+		Fields: xep0004.Fields{
+			{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{offlineNamespace}},
+			{Var: "number_of_messages", Values: []string{strconv.Itoa(count)}},
+		},
+	}}, nil
+}
+
+func (p *nodeProvider) Items(ctx context.Context, toJID, fromJID *jid.JID, _ string) ([]discomodel.Item, error) {
+	if !isQueueOwner(toJID, fromJID) {
+		return nil, nil
+	}
+	ms, err := p.rep.FetchOfflineMessages(ctx, fromJID.Node())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]discomodel.Item, 0, len(ms))
+	for _, msg := range ms {
+		items = append(items, discomodel.Item{
+			Jid:  fromJID.ToBareJID().String(),
+			Node: offlineMessageNode(msg),
+			Name: msg.FromJID().String(),
+		})
+	}
+	// once headers have been retrieved, messages are not flooded at presence time
+	if err := p.onRequested(ctx, fromJID); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
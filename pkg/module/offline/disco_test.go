@@ -0,0 +1,72 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeProvider_Items(t *testing.T) {
+	// given
+	msg := testOfflineMessage("I'll give thee a wind.")
+
+	repMock := &repositoryMock{}
+	repMock.FetchOfflineMessagesFunc = func(ctx context.Context, username string) ([]*stravaganza.Message, error) {
+		return []*stravaganza.Message{msg}, nil
+	}
+	var requested bool
+	p := &nodeProvider{
+		rep: repMock,
+		onRequested: func(_ context.Context, _ *jid.JID) error {
+			requested = true
+			return nil
+		},
+	}
+	srvJID, _ := jid.NewWithString("jackal.im", true)
+	ownerJID, _ := jid.NewWithString("ortuman@jackal.im/balcony", true)
+
+	// when
+	items, err := p.Items(context.Background(), srvJID, ownerJID, offlineNamespace)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "ortuman@jackal.im", items[0].Jid)
+	require.Equal(t, offlineMessageNode(msg), items[0].Node)
+	require.Equal(t, "noelia@jackal.im/yard", items[0].Name)
+	require.True(t, requested)
+}
+
+func TestNodeProvider_ItemsNotOwner(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	p := &nodeProvider{rep: repMock}
+
+	accJID, _ := jid.NewWithString("noelia@jackal.im", true)
+	fromJID, _ := jid.NewWithString("ortuman@jackal.im/balcony", true)
+
+	// when
+	items, err := p.Items(context.Background(), accJID, fromJID, offlineNamespace)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, items, 0)
+	require.Len(t, repMock.FetchOfflineMessagesCalls(), 0)
+}
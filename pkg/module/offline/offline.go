@@ -16,6 +16,7 @@ package offline
 
 import (
 	"context"
+	"crypto/sha1"
 	"fmt"
 	"time"
 
@@ -26,6 +27,8 @@ import (
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	"github.com/ortuman/jackal/pkg/module/xep0030"
 	"github.com/ortuman/jackal/pkg/module/xep0313"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
@@ -36,16 +39,35 @@ import (
 const (
 	offlineFeature = "msgoffline"
 
-	hintsNamespace = "urn:xmpp:hints"
+	offlineNamespace = "http://jabber.org/protocol/offline"
+
+	offlineRequestedCtxKey = "offline:requested"
 )
 
 // ModuleName represents offline module name.
 const ModuleName = "offline"
 
+const (
+	// BounceOverflowPolicy rejects incoming messages with a resource-constraint error once the offline queue is full.
+	BounceOverflowPolicy = "bounce"
+
+	// DropOldestOverflowPolicy discards the oldest queued message to make room for the incoming one.
+	DropOldestOverflowPolicy = "drop_oldest"
+
+	// ArchiveOnlyOverflowPolicy stops queueing incoming messages, leaving them available through the message archive only.
+	ArchiveOnlyOverflowPolicy = "archive_only"
+)
+
 // Config contains offline module configuration value.
 type Config struct {
 	// QueueSize defines maximum offline queue size.
 	QueueSize int `fig:"queue_size" default:"200"`
+
+	// OverflowPolicy defines how incoming messages are handled once a user offline queue is full.
+	OverflowPolicy string `fig:"overflow_policy" default:"bounce"`
+
+	// IgnoreProcessingHints tells whether message processing hints (XEP-0334) should be ignored when storing offline messages.
+	IgnoreProcessingHints bool `fig:"ignore_processing_hints"`
 }
 
 // Offline represents offline module type.
@@ -56,6 +78,8 @@ type Offline struct {
 	rep    repository.Repository
 	hk     *hook.Hooks
 	logger kitlog.Logger
+
+	disco *xep0030.Disco
 }
 
 // New creates and initializes a new Offline instance.
@@ -87,19 +111,63 @@ func (m *Offline) StreamFeature(_ context.Context, _ string) (stravaganza.Elemen
 
 // ServerFeatures returns offline module server disco features.
 func (m *Offline) ServerFeatures(_ context.Context) ([]string, error) {
-	return []string{offlineFeature}, nil
+	return []string{offlineFeature, offlineNamespace}, nil
 }
 
 // AccountFeatures returns offline module account disco features.
 func (m *Offline) AccountFeatures(_ context.Context) ([]string, error) { return nil, nil }
 
+// MatchesNamespace tells whether namespace matches flexible offline message retrieval module.
+func (m *Offline) MatchesNamespace(namespace string, _ bool) bool {
+	return namespace == offlineNamespace
+}
+
+// ProcessIQ process a flexible offline message retrieval iq.
+func (m *Offline) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	fromJID := iq.FromJID()
+	toJID := iq.ToJID()
+	if !isQueueOwner(toJID, fromJID) {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.Forbidden))
+		return nil
+	}
+	offline := iq.ChildNamespace("offline", offlineNamespace)
+	if offline == nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	switch {
+	case iq.IsGet() && offline.Child("fetch") != nil:
+		return m.fetchOfflineMessages(ctx, iq)
+
+	case iq.IsSet() && offline.Child("purge") != nil:
+		return m.purgeOfflineMessages(ctx, iq)
+
+	case iq.IsGet() && isItemsAction(offline, "view"):
+		return m.viewOfflineMessages(ctx, iq, itemNodes(offline))
+
+	case iq.IsSet() && isItemsAction(offline, "remove"):
+		return m.removeOfflineMessages(ctx, iq, itemNodes(offline))
+
+	default:
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+	}
+	return nil
+}
+
 // Start starts offline module.
 func (m *Offline) Start(_ context.Context) error {
+	switch m.cfg.OverflowPolicy {
+	case "", BounceOverflowPolicy, DropOldestOverflowPolicy, ArchiveOnlyOverflowPolicy:
+		break
+	default:
+		return fmt.Errorf("offline: unrecognized overflow policy: %s", m.cfg.OverflowPolicy)
+	}
 	m.hk.AddHook(hook.C2SStreamMessageRouted, m.onMessageRouted, hook.LowestPriority)
 	m.hk.AddHook(hook.S2SInStreamMessageRouted, m.onMessageRouted, hook.LowestPriority)
 
 	m.hk.AddHook(hook.C2SStreamPresenceReceived, m.onC2SPresenceRecv, hook.DefaultPriority)
 	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+	m.hk.AddHook(hook.DiscoProvidersStarted, m.onDiscoProvidersStarted, hook.DefaultPriority)
 
 	level.Info(m.logger).Log("msg", "started offline module")
 	return nil
@@ -112,7 +180,11 @@ func (m *Offline) Stop(_ context.Context) error {
 
 	m.hk.RemoveHook(hook.C2SStreamPresenceReceived, m.onC2SPresenceRecv)
 	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+	m.hk.RemoveHook(hook.DiscoProvidersStarted, m.onDiscoProvidersStarted)
 
+	if m.disco != nil {
+		m.disco.UnregisterNodeProvider(offlineNamespace)
+	}
 	level.Info(m.logger).Log("msg", "stopped offline module")
 	return nil
 }
@@ -135,7 +207,7 @@ func (m *Offline) onMessageRouted(execCtx *hook.ExecutionContext) error {
 	}
 
 	msg, ok := elem.(*stravaganza.Message)
-	if !ok || !isMessageArchievable(msg) {
+	if !ok || !m.isMessageArchievable(msg) {
 		return nil
 	}
 	toJID := msg.ToJID()
@@ -151,6 +223,10 @@ func (m *Offline) onC2SPresenceRecv(execCtx *hook.ExecutionContext) error {
 		// user has already queried the MAM archive.
 		return nil
 	}
+	if IsOfflineRequested(stm.Info()) {
+		// user is retrieving offline messages on demand (XEP-0013).
+		return nil
+	}
 	inf := execCtx.Info.(*hook.C2SStreamInfo)
 
 	pr := inf.Element.(*stravaganza.Presence)
@@ -164,6 +240,15 @@ func (m *Offline) onC2SPresenceRecv(execCtx *hook.ExecutionContext) error {
 	return m.deliverOfflineMessages(execCtx.Context, stm)
 }
 
+func (m *Offline) onDiscoProvidersStarted(execCtx *hook.ExecutionContext) error {
+	m.disco = execCtx.Sender.(*xep0030.Disco)
+	m.disco.RegisterNodeProvider(offlineNamespace, &nodeProvider{
+		rep:         m.rep,
+		onRequested: m.setOfflineRequested,
+	})
+	return nil
+}
+
 func (m *Offline) onUserDeleted(execCtx *hook.ExecutionContext) error {
 	inf := execCtx.Info.(*hook.UserInfo)
 	ctx := execCtx.Context
@@ -208,6 +293,120 @@ func (m *Offline) deliverOfflineMessages(ctx context.Context, stm stream.C2S) er
 	return nil
 }
 
+func (m *Offline) fetchOfflineMessages(ctx context.Context, iq *stravaganza.IQ) error {
+	fromJID := iq.FromJID()
+
+	stm, err := m.router.C2S().LocalStream(fromJID.Node(), fromJID.Resource())
+	if err != nil {
+		return err
+	}
+	ms, err := m.rep.FetchOfflineMessages(ctx, fromJID.Node())
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	for _, msg := range ms {
+		stm.SendElement(makeOfflineItemMessage(msg, fromJID))
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "fetched offline messages", "queue_size", len(ms), "username", fromJID.Node())
+
+	return stm.SetInfoValue(ctx, offlineRequestedCtxKey, true)
+}
+
+func (m *Offline) viewOfflineMessages(ctx context.Context, iq *stravaganza.IQ, nodes map[string]struct{}) error {
+	fromJID := iq.FromJID()
+
+	stm, err := m.router.C2S().LocalStream(fromJID.Node(), fromJID.Resource())
+	if err != nil {
+		return err
+	}
+	ms, err := m.rep.FetchOfflineMessages(ctx, fromJID.Node())
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	var selected []*stravaganza.Message
+	for _, msg := range ms {
+		if _, ok := nodes[offlineMessageNode(msg)]; ok {
+			selected = append(selected, msg)
+		}
+	}
+	if len(selected) == 0 {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+		return nil
+	}
+	for _, msg := range selected {
+		stm.SendElement(makeOfflineItemMessage(msg, fromJID))
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "viewed offline messages", "count", len(selected), "username", fromJID.Node())
+
+	return stm.SetInfoValue(ctx, offlineRequestedCtxKey, true)
+}
+
+func (m *Offline) removeOfflineMessages(ctx context.Context, iq *stravaganza.IQ, nodes map[string]struct{}) error {
+	username := iq.FromJID().Node()
+
+	lockID := offlineQueueLockID(username)
+
+	if err := m.rep.Lock(ctx, lockID); err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	defer m.releaseLock(ctx, lockID)
+
+	n, err := m.rep.DeleteSelectedOfflineMessages(ctx, username, func(msg *stravaganza.Message) bool {
+		_, ok := nodes[offlineMessageNode(msg)]
+		return ok
+	})
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	if n == 0 {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+		return nil
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "removed offline messages", "count", n, "username", username)
+
+	return m.setOfflineRequested(ctx, iq.FromJID())
+}
+
+func (m *Offline) purgeOfflineMessages(ctx context.Context, iq *stravaganza.IQ) error {
+	username := iq.FromJID().Node()
+
+	lockID := offlineQueueLockID(username)
+
+	if err := m.rep.Lock(ctx, lockID); err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	defer m.releaseLock(ctx, lockID)
+
+	if err := m.rep.DeleteOfflineMessages(ctx, username); err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "purged offline messages", "username", username)
+
+	return m.setOfflineRequested(ctx, iq.FromJID())
+}
+
+func (m *Offline) setOfflineRequested(ctx context.Context, fromJID *jid.JID) error {
+	stm, err := m.router.C2S().LocalStream(fromJID.Node(), fromJID.Resource())
+	if err != nil {
+		return err
+	}
+	return stm.SetInfoValue(ctx, offlineRequestedCtxKey, true)
+}
+
 func (m *Offline) archiveMessage(ctx context.Context, msg *stravaganza.Message) error {
 	toJID := msg.ToJID()
 	username := toJID.Node()
@@ -223,9 +422,23 @@ func (m *Offline) archiveMessage(ctx context.Context, msg *stravaganza.Message)
 	if err != nil {
 		return err
 	}
-	if qSize == m.cfg.QueueSize { // offline queue is full
-		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(msg, stanzaerror.ServiceUnavailable))
-		return hook.ErrStopped // already handled
+	if qSize >= m.cfg.QueueSize { // offline queue is full
+		reportQuotaHit(toJID.Domain(), m.overflowPolicy())
+
+		switch m.overflowPolicy() {
+		case DropOldestOverflowPolicy:
+			if _, err := m.rep.TrimOfflineMessages(ctx, username, m.cfg.QueueSize-1); err != nil {
+				return err
+			}
+
+		case ArchiveOnlyOverflowPolicy:
+			level.Info(m.logger).Log("msg", "offline queue full, message kept in archive only", "id", msg.Attribute(stravaganza.ID), "username", username)
+			return hook.ErrStopped // already handled
+
+		default:
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(msg, stanzaerror.ResourceConstraint))
+			return hook.ErrStopped // already handled
+		}
 	}
 	// add delay info
 	dMsg := xmpputil.MakeDelayMessage(msg, time.Now(), toJID.Domain(), "Offline Storage")
@@ -250,20 +463,85 @@ func (m *Offline) archiveMessage(ctx context.Context, msg *stravaganza.Message)
 	return hook.ErrStopped // already handled
 }
 
+func (m *Offline) overflowPolicy() string {
+	if len(m.cfg.OverflowPolicy) == 0 {
+		return BounceOverflowPolicy
+	}
+	return m.cfg.OverflowPolicy
+}
+
 func (m *Offline) releaseLock(ctx context.Context, lockID string) {
 	if err := m.rep.Unlock(ctx, lockID); err != nil {
 		level.Warn(m.logger).Log("msg", "failed to release lock", "err", err)
 	}
 }
 
-func isMessageArchievable(msg *stravaganza.Message) bool {
-	if msg.ChildNamespace("no-store", hintsNamespace) != nil {
+func (m *Offline) isMessageArchievable(msg *stravaganza.Message) bool {
+	if !m.cfg.IgnoreProcessingHints {
+		switch {
+		case xmpputil.HasProcessingHint(msg, xmpputil.NoStoreHint):
+			return false
+		case xmpputil.HasProcessingHint(msg, xmpputil.StoreHint):
+			return true
+		}
+	}
+	return msg.IsNormal() || (msg.IsChat() && msg.IsMessageWithBody())
+}
+
+// IsOfflineRequested determines whether offline messages are being retrieved on demand (XEP-0013) over a C2S stream by inspecting inf parameter.
+func IsOfflineRequested(inf c2smodel.Info) bool {
+	return inf.Bool(offlineRequestedCtxKey)
+}
+
+func isQueueOwner(toJID, fromJID *jid.JID) bool {
+	if toJID.IsServer() {
+		return toJID.Domain() == fromJID.Domain()
+	}
+	return toJID.MatchesWithOptions(fromJID, jid.MatchesBare)
+}
+
+func isItemsAction(offline stravaganza.Element, action string) bool {
+	items := offline.Children("item")
+	if len(items) == 0 {
 		return false
 	}
-	if msg.ChildNamespace("store", hintsNamespace) != nil {
-		return true
+	for _, item := range items {
+		if item.Attribute("action") != action || len(item.Attribute("node")) == 0 {
+			return false
+		}
 	}
-	return msg.IsNormal() || (msg.IsChat() && msg.IsMessageWithBody())
+	return true
+}
+
+func itemNodes(offline stravaganza.Element) map[string]struct{} {
+	nodes := make(map[string]struct{})
+	for _, item := range offline.Children("item") {
+		nodes[item.Attribute("node")] = struct{}{}
+	}
+	return nodes
+}
+
+func makeOfflineItemMessage(msg *stravaganza.Message, toJID *jid.JID) *stravaganza.Message {
+	itemMsg, _ := stravaganza.NewBuilderFromElement(msg).
+		WithAttribute(stravaganza.To, toJID.String()).
+		WithChild(
+			stravaganza.NewBuilder("offline").
+				WithAttribute(stravaganza.Namespace, offlineNamespace).
+				WithChild(
+					stravaganza.NewBuilder("item").
+						WithAttribute("node", offlineMessageNode(msg)).
+						Build(),
+				).
+				Build(),
+		).
+		BuildMessage()
+	return itemMsg
+}
+
+// offlineMessageNode returns the XEP-0013 node identifying an offline message.
+// Since stored messages already carry its delay stamp, the node is derived from their content.
+func offlineMessageNode(msg *stravaganza.Message) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(msg.String())))
 }
 
 func offlineQueueLockID(username string) string {
@@ -24,6 +24,8 @@ import (
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/router/stream"
 	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"github.com/stretchr/testify/require"
 )
@@ -77,6 +79,70 @@ func TestOffline_ArchiveOfflineMessage(t *testing.T) {
 	require.Len(t, repMock.InsertOfflineMessageCalls(), 1)
 }
 
+func TestOffline_ArchiveOfflineMessageNoStoreHint(t *testing.T) {
+	tcs := map[string]struct {
+		ignoreHints   bool
+		expectedCount int
+	}{
+		"honored": {expectedCount: 0},
+		"ignored": {ignoreHints: true, expectedCount: 1},
+	}
+	for tn, tc := range tcs {
+		t.Run(tn, func(t *testing.T) {
+			// given
+			repMock := &repositoryMock{}
+			repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+			repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+			repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+				return 0, nil
+			}
+			repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string) error {
+				return nil
+			}
+			hostsMock := &hostsMock{}
+			hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+			hk := hook.NewHooks()
+			m := &Offline{
+				cfg:    Config{QueueSize: 100, IgnoreProcessingHints: tc.ignoreHints},
+				hosts:  hostsMock,
+				rep:    repMock,
+				hk:     hk,
+				logger: kitlog.NewNopLogger(),
+			}
+			b := stravaganza.NewMessageBuilder()
+			b.WithAttribute("from", "noelia@jackal.im/yard")
+			b.WithAttribute("to", "ortuman@jackal.im/balcony")
+			b.WithChild(
+				stravaganza.NewBuilder("body").
+					WithText("I'll give thee a wind.").
+					Build(),
+			)
+			b.WithChild(
+				stravaganza.NewBuilder("no-store").
+					WithAttribute(stravaganza.Namespace, "urn:xmpp:hints").
+					Build(),
+			)
+			msg, _ := b.BuildMessage()
+
+			// when
+			_ = m.Start(context.Background())
+			defer func() { _ = m.Stop(context.Background()) }()
+
+			_, _ = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+				Info: &hook.C2SStreamInfo{
+					Element: msg,
+				},
+				Context: context.Background(),
+			})
+
+			// then
+			require.Len(t, repMock.InsertOfflineMessageCalls(), tc.expectedCount)
+		})
+	}
+}
+
 func TestOffline_ArchiveOfflineMessageQueueFull(t *testing.T) {
 	// given
 	routerMock := &routerMock{}
@@ -141,7 +207,142 @@ func TestOffline_ArchiveOfflineMessageQueueFull(t *testing.T) {
 	require.Len(t, repMock.CountOfflineMessagesCalls(), 1)
 	require.Len(t, repMock.InsertOfflineMessageCalls(), 0)
 
-	require.Equal(t, `<message from='ortuman@jackal.im/balcony' to='noelia@jackal.im/yard' type='error'><body>I&#39;ll give thee a wind.</body><error code='503' type='cancel'><service-unavailable xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></message>`, output.String())
+	require.Equal(t, `<message from='ortuman@jackal.im/balcony' to='noelia@jackal.im/yard' type='error'><body>I&#39;ll give thee a wind.</body><error code='500' type='wait'><resource-constraint xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></message>`, output.String())
+}
+
+func TestOffline_ArchiveOfflineMessageQueueFullDropOldest(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	output := bytes.NewBuffer(nil)
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		_ = stanza.ToXML(output, true)
+		return nil, nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 100, nil
+	}
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string) error {
+		return nil
+	}
+	repMock.TrimOfflineMessagesFunc = func(ctx context.Context, username string, maxSize int) (int, error) {
+		return 1, nil
+	}
+	resManagerMock := &resourceManagerMock{}
+	resManagerMock.GetResourcesFunc = func(ctx context.Context, username string) ([]c2smodel.ResourceDesc, error) {
+		return nil, nil
+	}
+
+	hk := hook.NewHooks()
+	m := &Offline{
+		cfg:    Config{QueueSize: 100, OverflowPolicy: DropOldestOverflowPolicy},
+		router: routerMock,
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("I'll give thee a wind.").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+
+	// when
+	_ = m.Start(context.Background())
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	halted, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.Nil(t, err)
+	require.True(t, halted)
+
+	require.Len(t, repMock.TrimOfflineMessagesCalls(), 1)
+	require.Equal(t, 99, repMock.TrimOfflineMessagesCalls()[0].MaxSize)
+	require.Len(t, repMock.InsertOfflineMessageCalls(), 1)
+	require.Len(t, output.String(), 0)
+}
+
+func TestOffline_ArchiveOfflineMessageQueueFullArchiveOnly(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	output := bytes.NewBuffer(nil)
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		_ = stanza.ToXML(output, true)
+		return nil, nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 100, nil
+	}
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string) error {
+		return nil
+	}
+	resManagerMock := &resourceManagerMock{}
+	resManagerMock.GetResourcesFunc = func(ctx context.Context, username string) ([]c2smodel.ResourceDesc, error) {
+		return nil, nil
+	}
+
+	hk := hook.NewHooks()
+	m := &Offline{
+		cfg:    Config{QueueSize: 100, OverflowPolicy: ArchiveOnlyOverflowPolicy},
+		router: routerMock,
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("I'll give thee a wind.").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+
+	// when
+	_ = m.Start(context.Background())
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	halted, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.Nil(t, err)
+	require.True(t, halted)
+
+	require.Len(t, repMock.InsertOfflineMessageCalls(), 0)
+	require.Len(t, output.String(), 0)
 }
 
 func TestOffline_DeliverOfflineMessages(t *testing.T) {
@@ -223,3 +424,188 @@ func TestOffline_DeliverOfflineMessages(t *testing.T) {
 
 	require.Equal(t, `<message from='noelia@jackal.im/yard' to='ortuman@jackal.im/balcony'><body>I&#39;ll give thee a wind.</body></message>`, output.String())
 }
+
+func TestOffline_FetchOfflineMessages(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchOfflineMessagesFunc = func(ctx context.Context, username string) ([]*stravaganza.Message, error) {
+		return []*stravaganza.Message{testOfflineMessage("I'll give thee a wind.")}, nil
+	}
+
+	stmMock := &c2sStreamMock{}
+	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error { return nil }
+
+	var sentMsgs []stravaganza.Element
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sentMsgs = append(sentMsgs, elem)
+		ch := make(chan error)
+		close(ch)
+		return ch
+	}
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return stmMock, nil
+	}
+	routerMock := &routerMock{}
+	routerMock.C2SFunc = func() router.C2SRouter { return c2sRouterMock }
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	m := &Offline{
+		router: routerMock,
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	iq := testOfflineIQ(stravaganza.GetType, stravaganza.NewBuilder("fetch").Build())
+	err := m.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.NoError(t, err)
+
+	require.Len(t, sentMsgs, 1)
+	item := sentMsgs[0].ChildNamespace("offline", offlineNamespace).Child("item")
+	require.NotNil(t, item)
+	require.Equal(t, offlineMessageNode(testOfflineMessage("I'll give thee a wind.")), item.Attribute("node"))
+	require.Equal(t, "ortuman@jackal.im/balcony", sentMsgs[0].Attribute(stravaganza.To))
+
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Attribute(stravaganza.Type))
+
+	require.Len(t, repMock.DeleteOfflineMessagesCalls(), 0)
+	require.Len(t, stmMock.SetInfoValueCalls(), 1)
+	require.Equal(t, offlineRequestedCtxKey, stmMock.SetInfoValueCalls()[0].K)
+}
+
+func TestOffline_RemoveOfflineMessages(t *testing.T) {
+	// given
+	m0 := testOfflineMessage("I'll give thee a wind.")
+	m1 := testOfflineMessage("Thou art kind.")
+
+	var removed []*stravaganza.Message
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.DeleteSelectedOfflineMessagesFunc = func(ctx context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (int, error) {
+		for _, msg := range []*stravaganza.Message{m0, m1} {
+			if selectFn(msg) {
+				removed = append(removed, msg)
+			}
+		}
+		return len(removed), nil
+	}
+
+	stmMock := &c2sStreamMock{}
+	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error { return nil }
+
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return stmMock, nil
+	}
+	routerMock := &routerMock{}
+	routerMock.C2SFunc = func() router.C2SRouter { return c2sRouterMock }
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	m := &Offline{
+		router: routerMock,
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	iq := testOfflineIQ(stravaganza.SetType, stravaganza.NewBuilder("item").
+		WithAttribute("action", "remove").
+		WithAttribute("node", offlineMessageNode(m1)).
+		Build(),
+	)
+	err := m.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.NoError(t, err)
+
+	require.Len(t, removed, 1)
+	require.Equal(t, "Thou art kind.", removed[0].Child("body").Text())
+
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Attribute(stravaganza.Type))
+	require.Len(t, stmMock.SetInfoValueCalls(), 1)
+}
+
+func TestOffline_SkipDeliveryWhenOfflineRequested(t *testing.T) {
+	// given
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	repMock := &repositoryMock{}
+
+	inf := c2smodel.NewInfoMap()
+	inf.SetBool(offlineRequestedCtxKey, true)
+
+	stmMock := &c2sStreamMock{}
+	stmMock.InfoFunc = func() c2smodel.Info { return inf }
+
+	hk := hook.NewHooks()
+	m := &Offline{
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	_ = m.Start(context.Background())
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	fromJID, _ := jid.NewWithString("ortuman@jackal.im/balcony", true)
+	toJID, _ := jid.NewWithString("ortuman@jackal.im", true)
+
+	_, _ = hk.Run(hook.C2SStreamPresenceReceived, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: xmpputil.MakePresence(fromJID, toJID, stravaganza.AvailableType, nil),
+		},
+		Sender:  stmMock,
+		Context: context.Background(),
+	})
+
+	// then
+	require.Len(t, repMock.FetchOfflineMessagesCalls(), 0)
+}
+
+func testOfflineMessage(body string) *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText(body).
+				Build(),
+		).
+		BuildMessage()
+	return msg
+}
+
+func testOfflineIQ(typ string, children ...stravaganza.Element) *stravaganza.IQ {
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "off1").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/balcony").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, typ).
+		WithChild(
+			stravaganza.NewBuilder("offline").
+				WithAttribute(stravaganza.Namespace, offlineNamespace).
+				WithChildren(children...).
+				Build(),
+		).
+		BuildIQ()
+	return iq
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package roster
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
+)
+
+// pushBatch accumulates roster pushes addressed to a single session.
+// Pushes are coalesced by contact, but every contact is still pushed on its own, as a roster push
+// must contain exactly one item (RFC 6121, section 2.1.6).
+type pushBatch struct {
+	jd    *jid.JID
+	items []*pushItem
+	idx   map[string]int
+	tm    *time.Timer
+}
+
+type pushItem struct {
+	ri  *rostermodel.Item
+	ver int
+}
+
+func (b *pushBatch) add(ri *rostermodel.Item, ver int) {
+	if i, ok := b.idx[ri.Jid]; ok {
+		b.items[i] = &pushItem{ri: ri, ver: ver} // keep only latest contact state
+		return
+	}
+	b.idx[ri.Jid] = len(b.items)
+	b.items = append(b.items, &pushItem{ri: ri, ver: ver})
+}
+
+func (r *Roster) enqueuePush(ctx context.Context, jd *jid.JID, ri *rostermodel.Item, ver int) {
+	if r.cfg.PushBatchInterval <= 0 {
+		r.routePush(ctx, jd, ri, ver)
+		return
+	}
+	key := jd.String()
+
+	r.pushMu.Lock()
+	if r.pushBatches == nil {
+		r.pushBatches = make(map[string]*pushBatch)
+	}
+	b := r.pushBatches[key]
+	if b == nil {
+		b = &pushBatch{
+			jd:  jd,
+			idx: make(map[string]int),
+		}
+		b.tm = time.AfterFunc(r.cfg.PushBatchInterval, func() {
+			r.flushPushBatch(key, b)
+		})
+		r.pushBatches[key] = b
+	}
+	b.add(ri, ver)
+	full := r.cfg.PushBatchSize > 0 && len(b.items) >= r.cfg.PushBatchSize
+	r.pushMu.Unlock()
+
+	if full {
+		r.flushPushBatch(key, b)
+	}
+}
+
+func (r *Roster) flushPushBatch(key string, b *pushBatch) {
+	r.pushMu.Lock()
+	if r.pushBatches[key] != b {
+		// already flushed, and possibly replaced by a newer batch
+		r.pushMu.Unlock()
+		return
+	}
+	b.tm.Stop()
+	delete(r.pushBatches, key)
+	ctx := r.pushCtx
+	r.pushMu.Unlock()
+
+	// push in version order, so that the session roster version never goes backwards
+	sort.Slice(b.items, func(i, j int) bool { return b.items[i].ver < b.items[j].ver })
+	for _, it := range b.items {
+		r.routePush(ctx, b.jd, it.ri, it.ver)
+	}
+}
+
+func (r *Roster) flushAllPushBatches() {
+	r.pushMu.Lock()
+	batches := make(map[string]*pushBatch, len(r.pushBatches))
+	for k, b := range r.pushBatches {
+		batches[k] = b
+	}
+	r.pushMu.Unlock()
+
+	for k, b := range batches {
+		r.flushPushBatch(k, b)
+	}
+}
+
+func (r *Roster) routePush(ctx context.Context, jd *jid.JID, ri *rostermodel.Item, ver int) {
+	qb := stravaganza.NewBuilder("query").
+		WithAttribute(stravaganza.Namespace, rosterNamespace).
+		WithAttribute("ver", strconv.Itoa(ver)).
+		WithChild(encodeRosterItem(ri))
+	pushIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, jd.ToBareJID().String()).
+		WithAttribute(stravaganza.To, jd.String()).
+		WithChild(qb.Build()).
+		BuildIQ()
+
+	_, _ = r.router.Route(ctx, pushIQ)
+}
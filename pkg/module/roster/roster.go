@@ -19,10 +19,11 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
+	"time"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
-	"github.com/google/uuid"
 	"github.com/jackal-xmpp/stravaganza"
 	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
 	"github.com/jackal-xmpp/stravaganza/jid"
@@ -47,18 +48,39 @@ const (
 	ModuleName = "roster"
 )
 
+// Config contains roster module configuration value.
+type Config struct {
+	// PushBatchInterval defines the debounce window used to coalesce roster pushes
+	// addressed to the same session. A zero value disables batching.
+	//
+	// Every contact is still pushed in its own IQ, so the only saving comes from collapsing repeated
+	// updates of the same contact within the window into a single push.
+	PushBatchInterval time.Duration `fig:"push_batch_interval"`
+
+	// PushBatchSize defines the maximum number of coalesced contacts pending to be pushed to a session.
+	// Once reached, pending pushes are flushed before the debounce window expires.
+	PushBatchSize int `fig:"push_batch_size" default:"100"`
+}
+
 // Roster represents a roster module type.
 type Roster struct {
+	cfg    Config
 	rep    repository.Repository
 	resMng resourcemanager.Manager
 	router router.Router
 	hosts  hosts
 	hk     *hook.Hooks
 	logger kitlog.Logger
+
+	pushMu      sync.Mutex
+	pushBatches map[string]*pushBatch
+	pushCtx     context.Context
+	pushCancel  context.CancelFunc
 }
 
 // New returns a new initialized Roster instance.
 func New(
+	cfg Config,
 	router router.Router,
 	hosts *host.Hosts,
 	resMng resourcemanager.Manager,
@@ -66,13 +88,17 @@ func New(
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *Roster {
+	pushCtx, pushCancel := context.WithCancel(context.Background())
 	return &Roster{
-		router: router,
-		rep:    rep,
-		resMng: resMng,
-		hosts:  hosts,
-		hk:     hk,
-		logger: kitlog.With(logger, "module", ModuleName),
+		cfg:        cfg,
+		router:     router,
+		rep:        rep,
+		resMng:     resMng,
+		hosts:      hosts,
+		hk:         hk,
+		pushCtx:    pushCtx,
+		pushCancel: pushCancel,
+		logger:     kitlog.With(logger, "module", ModuleName),
 	}
 }
 
@@ -127,6 +153,11 @@ func (r *Roster) Stop(_ context.Context) error {
 	r.hk.RemoveHook(hook.S2SInStreamPresenceReceived, r.onPresenceRecv)
 	r.hk.RemoveHook(hook.UserDeleted, r.onUserDeleted)
 
+	r.flushAllPushBatches()
+	if r.pushCancel != nil {
+		r.pushCancel()
+	}
+
 	level.Info(r.logger).Log("msg", "stopped roster module")
 	return nil
 }
@@ -203,6 +234,9 @@ func (r *Roster) sendRoster(ctx context.Context, iq *stravaganza.IQ) error {
 		if err != nil {
 			return err
 		}
+		if !usrJID.IsFullWithUser() {
+			return nil
+		}
 		stm, err := r.router.C2S().LocalStream(usrJID.Node(), usrJID.Resource())
 		if err != nil {
 			return err
@@ -233,6 +267,9 @@ func (r *Roster) sendRoster(ctx context.Context, iq *stravaganza.IQ) error {
 	if err != nil {
 		return err
 	}
+	if !usrJID.IsFullWithUser() {
+		return nil // requested on behalf of the user (e.g. by a privileged entity)
+	}
 	stm, err := r.router.C2S().LocalStream(usrJID.Node(), usrJID.Resource())
 	if err != nil {
 		return err
@@ -263,7 +300,7 @@ func (r *Roster) updateRoster(ctx context.Context, iq *stravaganza.IQ) error {
 			return err
 		}
 	default:
-		if err := r.updateItem(ctx, ri, iq.FromJID().Node()); err != nil {
+		if err := r.updateItem(ctx, ri, iq.FromJID().ToBareJID()); err != nil {
 			_, _ = r.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
 			return err
 		}
@@ -301,7 +338,7 @@ func (r *Roster) processSubscribe(ctx context.Context, presence *stravaganza.Pre
 				Ask:          true,
 			}
 		}
-		if err := r.upsertItem(ctx, usrRi); err != nil {
+		if err := r.upsertItem(ctx, usrRi, userJID.Domain()); err != nil {
 			return err
 		}
 	}
@@ -349,7 +386,7 @@ func (r *Roster) processSubscribed(ctx context.Context, presence *stravaganza.Pr
 				Ask:          false,
 			}
 		}
-		if err := r.upsertItem(ctx, cntRi); err != nil {
+		if err := r.upsertItem(ctx, cntRi, contactJID.Domain()); err != nil {
 			return err
 		}
 	}
@@ -371,7 +408,7 @@ func (r *Roster) processSubscribed(ctx context.Context, presence *stravaganza.Pr
 				return nil
 			}
 			usrRi.Ask = false
-			if err := r.upsertItem(ctx, usrRi); err != nil {
+			if err := r.upsertItem(ctx, usrRi, userJID.Domain()); err != nil {
 				return err
 			}
 		}
@@ -401,7 +438,7 @@ func (r *Roster) processUnsubscribe(ctx context.Context, presence *stravaganza.P
 			default:
 				usrRi.Subscription = rostermodel.None
 			}
-			if err := r.upsertItem(ctx, usrRi); err != nil {
+			if err := r.upsertItem(ctx, usrRi, userJID.Domain()); err != nil {
 				return err
 			}
 		}
@@ -421,7 +458,7 @@ func (r *Roster) processUnsubscribe(ctx context.Context, presence *stravaganza.P
 			default:
 				cntRi.Subscription = rostermodel.None
 			}
-			if err := r.upsertItem(ctx, cntRi); err != nil {
+			if err := r.upsertItem(ctx, cntRi, contactJID.Domain()); err != nil {
 				return err
 			}
 		}
@@ -464,7 +501,7 @@ func (r *Roster) processUnsubscribed(ctx context.Context, presence *stravaganza.
 			default:
 				cntRi.Subscription = rostermodel.None
 			}
-			if err := r.upsertItem(ctx, cntRi); err != nil {
+			if err := r.upsertItem(ctx, cntRi, contactJID.Domain()); err != nil {
 				return err
 			}
 		}
@@ -489,7 +526,7 @@ routePresence:
 				}
 			}
 			usrRi.Ask = false
-			if err := r.upsertItem(ctx, usrRi); err != nil {
+			if err := r.upsertItem(ctx, usrRi, userJID.Domain()); err != nil {
 				return err
 			}
 		}
@@ -616,7 +653,9 @@ broadcastPresence:
 	return nil
 }
 
-func (r *Roster) updateItem(ctx context.Context, ri *rostermodel.Item, username string) error {
+func (r *Roster) updateItem(ctx context.Context, ri *rostermodel.Item, userJID *jid.JID) error {
+	username := userJID.Node()
+
 	usrRi, err := r.rep.FetchRosterItem(ctx, username, ri.Jid)
 	if err != nil {
 		return err
@@ -638,7 +677,7 @@ func (r *Roster) updateItem(ctx context.Context, ri *rostermodel.Item, username
 			Ask:          ri.Ask,
 		}
 	}
-	if err := r.upsertItem(ctx, usrRi); err != nil {
+	if err := r.upsertItem(ctx, usrRi, userJID.Domain()); err != nil {
 		return err
 	}
 	level.Info(r.logger).Log("msg", "updated roster", "jid", ri.Jid, "username", username)
@@ -673,7 +712,7 @@ func (r *Roster) removeItem(ctx context.Context, ri *rostermodel.Item, userJID *
 		if err != nil {
 			return err
 		}
-		if err := r.deleteItem(ctx, usrRi); err != nil {
+		if err := r.deleteItem(ctx, usrRi, userJID.Domain()); err != nil {
 			return err
 		}
 	}
@@ -692,14 +731,14 @@ func (r *Roster) removeItem(ctx context.Context, ri *rostermodel.Item, userJID *
 			switch cntRi.Subscription {
 			case rostermodel.Both:
 				cntRi.Subscription = rostermodel.To
-				if err := r.upsertItem(ctx, cntRi); err != nil {
+				if err := r.upsertItem(ctx, cntRi, contactJID.Domain()); err != nil {
 					return err
 				}
 				fallthrough
 
 			default:
 				cntRi.Subscription = rostermodel.None
-				if err := r.upsertItem(ctx, cntRi); err != nil {
+				if err := r.upsertItem(ctx, cntRi, contactJID.Domain()); err != nil {
 					return err
 				}
 			}
@@ -721,7 +760,7 @@ func (r *Roster) removeItem(ctx context.Context, ri *rostermodel.Item, userJID *
 	return nil
 }
 
-func (r *Roster) upsertItem(ctx context.Context, ri *rostermodel.Item) error {
+func (r *Roster) upsertItem(ctx context.Context, ri *rostermodel.Item, domain string) error {
 	err := r.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
 		ver, err := tx.TouchRosterVersion(ctx, ri.Username)
 		if err != nil {
@@ -737,12 +776,13 @@ func (r *Roster) upsertItem(ctx context.Context, ri *rostermodel.Item) error {
 	}
 	return r.runHook(ctx, hook.RosterItemUpdated, &hook.RosterInfo{
 		Username:     ri.Username,
+		Domain:       domain,
 		JID:          ri.Jid,
 		Subscription: ri.Subscription,
 	})
 }
 
-func (r *Roster) deleteItem(ctx context.Context, ri *rostermodel.Item) error {
+func (r *Roster) deleteItem(ctx context.Context, ri *rostermodel.Item, domain string) error {
 	err := r.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
 		ver, err := tx.TouchRosterVersion(ctx, ri.Username)
 		if err != nil {
@@ -758,6 +798,7 @@ func (r *Roster) deleteItem(ctx context.Context, ri *rostermodel.Item) error {
 	}
 	return r.runHook(ctx, hook.RosterItemUpdated, &hook.RosterInfo{
 		Username:     ri.Username,
+		Domain:       domain,
 		JID:          ri.Jid,
 		Subscription: rostermodel.Remove,
 	})
@@ -795,21 +836,7 @@ func (r *Roster) pushItem(ctx context.Context, ri *rostermodel.Item, ver int) er
 		if !rs.Info().Bool(rosterRequestedCtxKey) { // did request roster?
 			continue
 		}
-		pushIQ, _ := stravaganza.NewIQBuilder().
-			WithAttribute(stravaganza.ID, uuid.New().String()).
-			WithAttribute(stravaganza.Type, stravaganza.SetType).
-			WithAttribute(stravaganza.From, rs.JID().ToBareJID().String()).
-			WithAttribute(stravaganza.To, rs.JID().String()).
-			WithChild(
-				stravaganza.NewBuilder("query").
-					WithAttribute(stravaganza.Namespace, rosterNamespace).
-					WithAttribute("ver", strconv.Itoa(ver)).
-					WithChild(encodeRosterItem(ri)).
-					Build(),
-			).
-			BuildIQ()
-
-		_, _ = r.router.Route(ctx, pushIQ)
+		r.enqueuePush(ctx, rs.JID(), ri, ver)
 	}
 	return nil
 }
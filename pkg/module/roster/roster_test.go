@@ -18,6 +18,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/jackal-xmpp/stravaganza"
@@ -951,3 +952,110 @@ func TestRoster_Available(t *testing.T) {
 	require.Equal(t, "noelia@jackal.im", availPr1.Attribute("to"))
 	require.Equal(t, stravaganza.AvailableType, availPr1.Attribute("type"))
 }
+
+func TestRoster_BatchedPushes(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var mu sync.Mutex
+	var pushes []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		mu.Lock()
+		pushes = append(pushes, stanza)
+		mu.Unlock()
+		return nil, nil
+	}
+	jd0, _ := jid.New("ortuman", "jackal.im", "yard", true)
+
+	resMngMock := &resourceManagerMock{}
+	resMngMock.GetResourcesFunc = func(ctx context.Context, username string) ([]c2smodel.ResourceDesc, error) {
+		return []c2smodel.ResourceDesc{
+			c2smodel.NewResourceDesc("i0", jd0, nil, c2smodel.NewInfoMapFromMap(map[string]string{rosterRequestedCtxKey: "true"})),
+		}, nil
+	}
+	r := &Roster{
+		cfg: Config{
+			PushBatchInterval: time.Hour,
+			PushBatchSize:     3,
+		},
+		resMng:  resMngMock,
+		router:  routerMock,
+		hk:      hook.NewHooks(),
+		pushCtx: context.Background(),
+		logger:  kitlog.NewNopLogger(),
+	}
+
+	// when
+	_ = r.pushItem(context.Background(), &rostermodel.Item{Username: "ortuman", Jid: "noelia@jackal.im", Subscription: rostermodel.None}, 1)
+	_ = r.pushItem(context.Background(), &rostermodel.Item{Username: "ortuman", Jid: "noelia@jackal.im", Subscription: rostermodel.To}, 2)
+	_ = r.pushItem(context.Background(), &rostermodel.Item{Username: "ortuman", Jid: "hamlet@jackal.im", Subscription: rostermodel.None}, 3)
+
+	mu.Lock()
+	require.Len(t, pushes, 0)
+	mu.Unlock()
+
+	_ = r.Stop(context.Background())
+
+	// then
+	mu.Lock()
+	defer mu.Unlock()
+
+	// coalesced by contact, yet every push carries a single item
+	require.Len(t, pushes, 2)
+
+	for i, expected := range []struct {
+		ver, jid, subscription string
+	}{
+		{ver: "2", jid: "noelia@jackal.im", subscription: rostermodel.To},
+		{ver: "3", jid: "hamlet@jackal.im", subscription: rostermodel.None},
+	} {
+		pushIQ := pushes[i].(*stravaganza.IQ)
+		require.Equal(t, "ortuman@jackal.im/yard", pushIQ.Attribute(stravaganza.To))
+
+		query := pushIQ.ChildNamespace("query", rosterNamespace)
+		require.NotNil(t, query)
+		require.Equal(t, expected.ver, query.Attribute("ver"))
+
+		items := query.Children("item")
+		require.Len(t, items, 1)
+		require.Equal(t, expected.jid, items[0].Attribute("jid"))
+		require.Equal(t, expected.subscription, items[0].Attribute("subscription"))
+	}
+}
+
+func TestRoster_StalePushBatchFlush(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var pushes []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		pushes = append(pushes, stanza)
+		return nil, nil
+	}
+	jd0, _ := jid.New("ortuman", "jackal.im", "yard", true)
+
+	r := &Roster{
+		cfg: Config{
+			PushBatchInterval: time.Hour,
+		},
+		router:  routerMock,
+		pushCtx: context.Background(),
+		logger:  kitlog.NewNopLogger(),
+	}
+	r.enqueuePush(context.Background(), jd0, &rostermodel.Item{Username: "ortuman", Jid: "noelia@jackal.im"}, 1)
+	b0 := r.pushBatches[jd0.String()]
+
+	r.flushPushBatch(jd0.String(), b0)
+	r.enqueuePush(context.Background(), jd0, &rostermodel.Item{Username: "ortuman", Jid: "hamlet@jackal.im"}, 2)
+
+	// when
+	r.flushPushBatch(jd0.String(), b0) // fired timer of an already flushed batch
+
+	// then
+	require.Len(t, pushes, 1)
+
+	b1 := r.pushBatches[jd0.String()]
+	require.NotNil(t, b1)
+	b1.tm.Stop()
+	require.Len(t, b1.items, 1)
+}
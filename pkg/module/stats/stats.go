@@ -0,0 +1,247 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ModuleName represents usage statistics module name.
+const ModuleName = "stats"
+
+const day = 24 * time.Hour
+
+// Config contains usage statistics module configuration options.
+type Config struct {
+	// FlushInterval defines how often in-memory host counters are persisted.
+	FlushInterval time.Duration `fig:"flush_interval" default:"1m"`
+
+	// Retention defines for how long daily statistics are kept.
+	Retention time.Duration `fig:"retention" default:"8760h"`
+}
+
+type hostDay struct {
+	host string
+	day  time.Time
+}
+
+// Stats represents a usage statistics module type.
+type Stats struct {
+	cfg    Config
+	hosts  hosts
+	rep    repository.Repository
+	hk     *hook.Hooks
+	logger kitlog.Logger
+	nowFn  func() time.Time
+
+	mu       sync.Mutex
+	day      time.Time
+	active   map[string]struct{}
+	counters map[hostDay]*statsmodel.HostStats
+	purged   time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New returns a new initialized Stats instance.
+func New(
+	cfg Config,
+	hosts hosts,
+	rep repository.Repository,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+) *Stats {
+	return &Stats{
+		cfg:      cfg,
+		hosts:    hosts,
+		rep:      rep,
+		hk:       hk,
+		logger:   kitlog.With(logger, "module", ModuleName),
+		nowFn:    time.Now,
+		active:   make(map[string]struct{}),
+		counters: make(map[hostDay]*statsmodel.HostStats),
+	}
+}
+
+// Name returns usage statistics module name.
+func (m *Stats) Name() string { return ModuleName }
+
+// StreamFeature returns usage statistics module stream feature.
+func (m *Stats) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns usage statistics server disco features.
+func (m *Stats) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns usage statistics account disco features.
+func (m *Stats) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts usage statistics module.
+func (m *Stats) Start(_ context.Context) error {
+	m.hk.AddHook(hook.C2SStreamBinded, m.onBinded, hook.LowestPriority)
+	m.hk.AddHook(hook.C2SStreamMessageReceived, m.onMessageReceived, hook.LowestPriority)
+	m.hk.AddHook(hook.UserCreated, m.onUserCreated, hook.LowestPriority)
+
+	m.stopCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+	go m.loop()
+
+	level.Info(m.logger).Log("msg", "started stats module")
+	return nil
+}
+
+// Stop stops usage statistics module persisting all pending counters.
+func (m *Stats) Stop(ctx context.Context) error {
+	m.hk.RemoveHook(hook.C2SStreamBinded, m.onBinded)
+	m.hk.RemoveHook(hook.C2SStreamMessageReceived, m.onMessageReceived)
+	m.hk.RemoveHook(hook.UserCreated, m.onUserCreated)
+
+	close(m.stopCh)
+	<-m.doneCh
+
+	m.flush(ctx)
+
+	level.Info(m.logger).Log("msg", "stopped stats module")
+	return nil
+}
+
+func (m *Stats) onBinded(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+	username := inf.JID.Node()
+
+	m.mu.Lock()
+	today := m.today()
+	if _, ok := m.active[username]; ok {
+		m.mu.Unlock()
+		return nil // activity already recorded for today
+	}
+	m.active[username] = struct{}{}
+	m.mu.Unlock()
+
+	if err := m.rep.UpsertUserActivity(execCtx.Context, username, today); err != nil {
+		m.mu.Lock()
+		delete(m.active, username)
+		m.mu.Unlock()
+
+		level.Warn(m.logger).Log("msg", "failed to record user activity", "username", username, "err", err)
+	}
+	return nil
+}
+
+func (m *Stats) onMessageReceived(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+
+	m.mu.Lock()
+	m.counter(inf.JID.Domain()).Messages++
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Stats) onUserCreated(_ *hook.ExecutionContext) error {
+	m.mu.Lock()
+	m.counter(m.hosts.DefaultHostName()).Registrations++
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Stats) loop() {
+	defer close(m.doneCh)
+
+	tc := time.NewTicker(m.cfg.FlushInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.cfg.FlushInterval)
+			m.flush(ctx)
+			m.purge(ctx)
+			cancel()
+
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+func (m *Stats) flush(ctx context.Context) {
+	m.mu.Lock()
+	counters := m.counters
+	m.counters = make(map[hostDay]*statsmodel.HostStats)
+	m.mu.Unlock()
+
+	for hd, st := range counters {
+		if err := m.rep.IncrementHostStats(ctx, st); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to persist host stats", "host", hd.host, "err", err)
+		}
+	}
+}
+
+func (m *Stats) purge(ctx context.Context) {
+	m.mu.Lock()
+	today := m.today()
+	if !today.After(m.purged) {
+		m.mu.Unlock()
+		return // already purged today
+	}
+	m.purged = today
+	m.mu.Unlock()
+
+	if err := m.rep.DeleteStatsBefore(ctx, today.Add(-m.cfg.Retention)); err != nil {
+		level.Warn(m.logger).Log("msg", "failed to purge stats", "err", err)
+	}
+}
+
+// today returns current UTC day, resetting the set of active users whenever the day changes.
+// Must be called with m.mu held.
+func (m *Stats) today() time.Time {
+	today := m.nowFn().UTC().Truncate(day)
+	if !today.Equal(m.day) {
+		m.day = today
+		m.active = make(map[string]struct{})
+	}
+	return today
+}
+
+// counter returns the host counters for current day.
+// Must be called with m.mu held.
+func (m *Stats) counter(host string) *statsmodel.HostStats {
+	hd := hostDay{host: host, day: m.today()}
+	st, ok := m.counters[hd]
+	if !ok {
+		st = &statsmodel.HostStats{
+			Host: host,
+			Day:  timestamppb.New(hd.day),
+		}
+		m.counters[hd] = st
+	}
+	return st
+}
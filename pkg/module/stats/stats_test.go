@@ -0,0 +1,150 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	"github.com/stretchr/testify/require"
+)
+
+var testJID, _ = jid.NewWithString("ortuman@jackal.im/yard", true)
+
+func TestStats_UserActivity(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertUserActivityFunc = func(ctx context.Context, username string, day time.Time) error {
+		return nil
+	}
+	now := time.Date(2022, 01, 01, 10, 00, 00, 00, time.UTC)
+
+	hk := hook.NewHooks()
+	m := testStats(repMock, hk, func() time.Time { return now })
+	require.NoError(t, m.Start(context.Background()))
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	// when
+	for i := 0; i < 2; i++ {
+		_, err := hk.Run(hook.C2SStreamBinded, &hook.ExecutionContext{
+			Info:    &hook.C2SStreamInfo{JID: testJID},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+	now = now.Add(24 * time.Hour)
+
+	_, err := hk.Run(hook.C2SStreamBinded, &hook.ExecutionContext{
+		Info:    &hook.C2SStreamInfo{JID: testJID},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+
+	// then
+	calls := repMock.UpsertUserActivityCalls()
+	require.Len(t, calls, 2)
+	require.Equal(t, "ortuman", calls[0].Username)
+	require.Equal(t, time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC), calls[0].Day)
+	require.Equal(t, time.Date(2022, 01, 02, 00, 00, 00, 00, time.UTC), calls[1].Day)
+}
+
+func TestStats_HostCounters(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.IncrementHostStatsFunc = func(ctx context.Context, stats *statsmodel.HostStats) error {
+		return nil
+	}
+	now := time.Date(2022, 01, 01, 10, 00, 00, 00, time.UTC)
+
+	hk := hook.NewHooks()
+	m := testStats(repMock, hk, func() time.Time { return now })
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	for i := 0; i < 3; i++ {
+		_, err := hk.Run(hook.C2SStreamMessageReceived, &hook.ExecutionContext{
+			Info: &hook.C2SStreamInfo{
+				JID:     testJID,
+				Element: testMessage(),
+			},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+	_, err := hk.Run(hook.UserCreated, &hook.ExecutionContext{
+		Info:    &hook.UserInfo{Username: "noelia"},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Stop(context.Background()))
+
+	// then
+	calls := repMock.IncrementHostStatsCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, "jackal.im", calls[0].Stats.Host)
+	require.Equal(t, uint64(3), calls[0].Stats.Messages)
+	require.Equal(t, uint64(1), calls[0].Stats.Registrations)
+	require.Equal(t, time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC), calls[0].Stats.Day.AsTime())
+}
+
+func TestStats_Purge(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteStatsBeforeFunc = func(ctx context.Context, day time.Time) error {
+		return nil
+	}
+	now := time.Date(2022, 01, 01, 10, 00, 00, 00, time.UTC)
+
+	m := testStats(repMock, hook.NewHooks(), func() time.Time { return now })
+
+	// when
+	m.purge(context.Background())
+	m.purge(context.Background())
+
+	// then
+	calls := repMock.DeleteStatsBeforeCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, time.Date(2021, 12, 31, 00, 00, 00, 00, time.UTC), calls[0].Day)
+}
+
+func testStats(repMock *repositoryMock, hk *hook.Hooks, nowFn func() time.Time) *Stats {
+	hMock := &hostsMock{}
+	hMock.DefaultHostNameFunc = func() string { return "jackal.im" }
+
+	m := New(Config{FlushInterval: time.Hour, Retention: 24 * time.Hour}, hMock, repMock, hk, kitlog.NewNopLogger())
+	m.nowFn = nowFn
+	return m
+}
+
+func testMessage() *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im/balcony").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("I'll give thee a wind.").
+				Build(),
+		).
+		BuildMessage()
+	return msg
+}
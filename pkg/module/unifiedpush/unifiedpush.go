@@ -0,0 +1,307 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unifiedpush
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	unifiedPushNamespace = "http://gultsch.de/xmpp/drafts/unified-push"
+
+	endpointPath = "/up/"
+
+	expirationTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+// ModuleName represents UnifiedPush module name.
+const ModuleName = "unifiedpush"
+
+// Config contains UnifiedPush module configuration options.
+type Config struct {
+	// BindAddr defines push gateway HTTP listener address.
+	BindAddr string `fig:"bind_addr"`
+
+	// Port defines push gateway HTTP listener port.
+	Port int `fig:"port" default:"5281"`
+
+	// BaseURL is the externally reachable push gateway URL used to build endpoints (e.g. https://push.jackal.im).
+	BaseURL string `fig:"base_url"`
+
+	// Expiration defines endpoint validity period.
+	Expiration time.Duration `fig:"expiration" default:"168h"`
+
+	// MaxPayloadSize defines the maximum accepted push message size.
+	MaxPayloadSize int `fig:"max_payload_size" default:"4096"`
+}
+
+// UnifiedPush represents a UnifiedPush distributor and push gateway module type.
+type UnifiedPush struct {
+	cfg    Config
+	router router.Router
+	hosts  hosts
+	rep    repository.UnifiedPush
+	hk     *hook.Hooks
+	srv    *http.Server
+	logger kitlog.Logger
+}
+
+// New returns a new initialized UnifiedPush instance.
+func New(
+	cfg Config,
+	router router.Router,
+	hosts *host.Hosts,
+	rep repository.Repository,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+) *UnifiedPush {
+	return &UnifiedPush{
+		cfg:    cfg,
+		router: router,
+		hosts:  hosts,
+		rep:    rep,
+		hk:     hk,
+		logger: kitlog.With(logger, "module", ModuleName),
+	}
+}
+
+// Name returns UnifiedPush module name.
+func (m *UnifiedPush) Name() string { return ModuleName }
+
+// StreamFeature returns UnifiedPush module stream feature.
+func (m *UnifiedPush) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns UnifiedPush server disco features.
+func (m *UnifiedPush) ServerFeatures(_ context.Context) ([]string, error) {
+	return []string{unifiedPushNamespace}, nil
+}
+
+// AccountFeatures returns UnifiedPush account disco features.
+func (m *UnifiedPush) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// MatchesNamespace tells whether namespace matches UnifiedPush module.
+func (m *UnifiedPush) MatchesNamespace(namespace string, serverTarget bool) bool {
+	return serverTarget && namespace == unifiedPushNamespace
+}
+
+// ProcessIQ process a UnifiedPush iq.
+func (m *UnifiedPush) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	switch {
+	case iq.IsSet() && iq.ChildNamespace("register", unifiedPushNamespace) != nil:
+		return m.register(ctx, iq, iq.ChildNamespace("register", unifiedPushNamespace))
+	case iq.IsSet() && iq.ChildNamespace("unregister", unifiedPushNamespace) != nil:
+		return m.unregister(ctx, iq, iq.ChildNamespace("unregister", unifiedPushNamespace))
+	default:
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+}
+
+// Start starts UnifiedPush module.
+func (m *UnifiedPush) Start(_ context.Context) error {
+	if len(m.cfg.BaseURL) == 0 {
+		return errors.New("unifiedpush: base_url must be set")
+	}
+	mux := http.NewServeMux()
+	mux.Handle(endpointPath, m)
+
+	m.srv = &http.Server{Handler: mux}
+	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", m.cfg.BindAddr, m.cfg.Port))
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := m.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			level.Error(m.logger).Log("msg", "failed to serve push gateway", "err", err)
+		}
+	}()
+	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started unifiedpush module", "port", m.cfg.Port)
+	return nil
+}
+
+// Stop stops UnifiedPush module.
+func (m *UnifiedPush) Stop(ctx context.Context) error {
+	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+
+	if err := m.srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	level.Info(m.logger).Log("msg", "stopped unifiedpush module")
+	return nil
+}
+
+// ServeHTTP handles push gateway endpoint requests.
+func (m *UnifiedPush) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		// endpoint discovery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"unifiedpush":{"version":1}}`)
+
+	case http.MethodPost:
+		m.handlePush(w, r)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *UnifiedPush) register(ctx context.Context, iq *stravaganza.IQ, reg stravaganza.Element) error {
+	fromJID := iq.FromJID()
+	app, instance := reg.Attribute("application"), reg.Attribute("instance")
+	if !fromJID.IsFullWithUser() || len(app) == 0 || len(instance) == 0 {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	expiresAt := time.Now().Add(m.cfg.Expiration).UTC()
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	// a new registration replaces any previous endpoint of the same application instance
+	err = m.rep.UpsertUnifiedPushRegistration(ctx, &unifiedpushmodel.Registration{
+		Token:       token,
+		Username:    fromJID.Node(),
+		Jid:         fromJID.String(),
+		Application: app,
+		Instance:    instance,
+		ExpiresAt:   timestamppb.New(expiresAt),
+	})
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	endpoint := strings.TrimSuffix(m.cfg.BaseURL, "/") + endpointPath + token
+
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq,
+		stravaganza.NewBuilder("registered").
+			WithAttribute(stravaganza.Namespace, unifiedPushNamespace).
+			WithAttribute("endpoint", endpoint).
+			WithAttribute("expiration", expiresAt.Format(expirationTimeFormat)).
+			Build(),
+	))
+	level.Info(m.logger).Log("msg", "registered push endpoint", "jid", fromJID.String(), "application", app)
+	return nil
+}
+
+func (m *UnifiedPush) unregister(ctx context.Context, iq *stravaganza.IQ, unreg stravaganza.Element) error {
+	fromJID := iq.FromJID()
+	app, instance := unreg.Attribute("application"), unreg.Attribute("instance")
+	if !fromJID.IsFullWithUser() || len(app) == 0 || len(instance) == 0 {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	if err := m.rep.DeleteUnifiedPushRegistration(ctx, fromJID.Node(), app, instance); err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "unregistered push endpoint", "jid", fromJID.String(), "application", app)
+	return nil
+}
+
+func (m *UnifiedPush) onUserDeleted(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.UserInfo)
+	return m.rep.DeleteUnifiedPushRegistrations(execCtx.Context, inf.Username)
+}
+
+func (m *UnifiedPush) handlePush(w http.ResponseWriter, r *http.Request) {
+	reg, err := m.rep.FetchUnifiedPushRegistration(r.Context(), strings.TrimPrefix(r.URL.Path, endpointPath))
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to fetch push registration", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if reg == nil || !reg.ExpiresAt.AsTime().After(time.Now()) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(m.cfg.MaxPayloadSize)+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(body) > m.cfg.MaxPayloadSize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	toJID, err := jid.NewWithString(reg.Jid, true)
+	if err != nil || !m.hosts.IsLocalHost(toJID.Domain()) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	pushIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, toJID.Domain()).
+		WithAttribute(stravaganza.To, reg.Jid).
+		WithChild(
+			stravaganza.NewBuilder("push").
+				WithAttribute(stravaganza.Namespace, unifiedPushNamespace).
+				WithAttribute("application", reg.Application).
+				WithAttribute("instance", reg.Instance).
+				WithText(base64.StdEncoding.EncodeToString(body)).
+				Build(),
+		).
+		BuildIQ()
+
+	_, err = m.router.Route(r.Context(), pushIQ)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusCreated)
+
+	case errors.Is(err, router.ErrResourceNotFound), errors.Is(err, router.ErrUserNotAvailable):
+		// distributor is not connected at the moment
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+	case errors.Is(err, router.ErrNotExistingAccount):
+		w.WriteHeader(http.StatusNotFound)
+
+	default:
+		level.Warn(m.logger).Log("msg", "failed to route push notification", "jid", reg.Jid, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unifiedpush
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestUnifiedPush_RegisterAndPush(t *testing.T) {
+	// given
+	var routed []stravaganza.Stanza
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		routed = append(routed, stanza)
+		return nil, nil
+	}
+	m := testUnifiedPush(routerMock, testRegistrations())
+
+	// when
+	require.NoError(t, m.ProcessIQ(context.Background(), testUnifiedPushIQ("register")))
+
+	require.Len(t, routed, 1)
+	registered := routed[0].ChildNamespace("registered", unifiedPushNamespace)
+	require.NotNil(t, registered)
+
+	endpoint := registered.Attribute("endpoint")
+	require.True(t, strings.HasPrefix(endpoint, "https://push.jackal.im/up/"))
+	require.Len(t, strings.TrimPrefix(endpoint, "https://push.jackal.im/up/"), base64.RawURLEncoding.EncodedLen(tokenSize))
+
+	req := httptest.NewRequest(http.MethodPost, strings.TrimPrefix(endpoint, "https://push.jackal.im"), strings.NewReader("wake up"))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	// then
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	require.Len(t, routed, 2)
+	require.Equal(t, "ortuman@jackal.im/phone", routed[1].Attribute(stravaganza.To))
+	require.Equal(t, "jackal.im", routed[1].Attribute(stravaganza.From))
+
+	push := routed[1].ChildNamespace("push", unifiedPushNamespace)
+	require.NotNil(t, push)
+	require.Equal(t, "app-hash", push.Attribute("application"))
+	require.Equal(t, "instance-hash", push.Attribute("instance"))
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("wake up")), push.Text())
+}
+
+func TestUnifiedPush_Unregister(t *testing.T) {
+	// given
+	var routed []stravaganza.Stanza
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		routed = append(routed, stanza)
+		return nil, nil
+	}
+	m := testUnifiedPush(routerMock, testRegistrations())
+
+	require.NoError(t, m.ProcessIQ(context.Background(), testUnifiedPushIQ("register")))
+	require.Len(t, routed, 1)
+	endpoint := routed[0].ChildNamespace("registered", unifiedPushNamespace).Attribute("endpoint")
+
+	// when
+	require.NoError(t, m.ProcessIQ(context.Background(), testUnifiedPushIQ("unregister")))
+
+	req := httptest.NewRequest(http.MethodPost, strings.TrimPrefix(endpoint, "https://push.jackal.im"), strings.NewReader("wake up"))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	// then
+	require.Len(t, routed, 2)
+	require.Equal(t, stravaganza.ResultType, routed[1].Attribute(stravaganza.Type))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUnifiedPush_InvalidEndpoint(t *testing.T) {
+	// given
+	regs := testRegistrations()
+	regs["expired"] = &unifiedpushmodel.Registration{
+		Token:       "expired",
+		Username:    "ortuman",
+		Jid:         "ortuman@jackal.im/phone",
+		Application: "app-hash",
+		Instance:    "instance-hash",
+		ExpiresAt:   timestamppb.New(time.Now().Add(-time.Minute)),
+	}
+	m := testUnifiedPush(&routerMock{}, regs)
+
+	for _, token := range []string{"expired", "unknown"} {
+		// when
+		req := httptest.NewRequest(http.MethodPost, endpointPath+token, strings.NewReader("wake up"))
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		// then
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestUnifiedPush_Unavailable(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(_ context.Context, _ stravaganza.Stanza) ([]jid.JID, error) {
+		return nil, router.ErrResourceNotFound
+	}
+	regs := testRegistrations()
+	regs["t1"] = &unifiedpushmodel.Registration{
+		Token:       "t1",
+		Username:    "ortuman",
+		Jid:         "ortuman@jackal.im/phone",
+		Application: "app-hash",
+		Instance:    "instance-hash",
+		ExpiresAt:   timestamppb.New(time.Now().Add(time.Hour)),
+	}
+	m := testUnifiedPush(routerMock, regs)
+
+	// when
+	req := httptest.NewRequest(http.MethodPost, endpointPath+"t1", strings.NewReader(strings.Repeat("a", 8)))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	// then
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	// payload too large
+	req = httptest.NewRequest(http.MethodPost, endpointPath+"t1", strings.NewReader(strings.Repeat("a", 4097)))
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func testUnifiedPushIQ(op string) *stravaganza.IQ {
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "up1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/phone").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithChild(
+			stravaganza.NewBuilder(op).
+				WithAttribute(stravaganza.Namespace, unifiedPushNamespace).
+				WithAttribute("application", "app-hash").
+				WithAttribute("instance", "instance-hash").
+				Build(),
+		).
+		BuildIQ()
+	return iq
+}
+
+func testRegistrations() map[string]*unifiedpushmodel.Registration {
+	return make(map[string]*unifiedpushmodel.Registration)
+}
+
+func testUnifiedPush(routerMock *routerMock, regs map[string]*unifiedpushmodel.Registration) *UnifiedPush {
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	repMock := &repositoryMock{}
+	repMock.UpsertUnifiedPushRegistrationFunc = func(_ context.Context, reg *unifiedpushmodel.Registration) error {
+		regs[reg.Token] = reg
+		return nil
+	}
+	repMock.FetchUnifiedPushRegistrationFunc = func(_ context.Context, token string) (*unifiedpushmodel.Registration, error) {
+		return regs[token], nil
+	}
+	repMock.DeleteUnifiedPushRegistrationFunc = func(_ context.Context, username, application, instance string) error {
+		for token, reg := range regs {
+			if reg.Username == username && reg.Application == application && reg.Instance == instance {
+				delete(regs, token)
+			}
+		}
+		return nil
+	}
+	return &UnifiedPush{
+		cfg: Config{
+			BaseURL:        "https://push.jackal.im/",
+			Expiration:     time.Hour,
+			MaxPayloadSize: 4096,
+		},
+		router: routerMock,
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+}
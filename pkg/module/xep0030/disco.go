@@ -29,6 +29,7 @@ import (
 	"github.com/ortuman/jackal/pkg/hook"
 	discomodel "github.com/ortuman/jackal/pkg/model/disco"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/module/xep0059"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
@@ -37,6 +38,8 @@ import (
 const (
 	discoInfoNamespace  = "http://jabber.org/protocol/disco#info"
 	discoItemsNamespace = "http://jabber.org/protocol/disco#items"
+
+	maxItemsPageSize = 250
 )
 
 var errSubscriptionRequired = errors.New("xep0030: subscription required")
@@ -56,6 +59,12 @@ type InfoProvider interface {
 	Forms(ctx context.Context, toJID, fromJID *jid.JID, node string) ([]xep0004.DataForm, error)
 }
 
+// FormsProvider is implemented by modules attaching extended information forms (XEP-0128) to server disco info.
+type FormsProvider interface {
+	// ServerForms returns the set of forms to be attached to server disco info.
+	ServerForms(ctx context.Context) ([]xep0004.DataForm, error)
+}
+
 const (
 	// ModuleName represents disco module name.
 	ModuleName = "disco"
@@ -64,8 +73,51 @@ const (
 	XEPNumber = "0030"
 )
 
+// Config contains disco module configuration options.
+type Config struct {
+	// SoftwareInfo tells whether XEP-0232 software information form should be attached to server disco info.
+	SoftwareInfo bool `fig:"software_info"`
+
+	// Forms contains the set of extended information forms (XEP-0128) attached to server disco info.
+	Forms []FormConfig `fig:"forms"`
+
+	// Gateways contains the set of external gateways (XEP-0100) advertised as server disco items.
+	Gateways []GatewayConfig `fig:"gateways"`
+}
+
+// GatewayConfig defines an external gateway advertised by the server.
+type GatewayConfig struct {
+	// JID is the gateway service address.
+	JID string `fig:"jid"`
+
+	// Name is the gateway human readable name.
+	Name string `fig:"name"`
+
+	// Type is the gateway identity type (e.g. irc, matrix, sms).
+	Type string `fig:"type"`
+
+	// Features contains the set of features supported by the gateway.
+	Features []string `fig:"features"`
+}
+
+// FormConfig defines a server disco info extension form.
+type FormConfig struct {
+	// FormType is the value of the form hidden FORM_TYPE field.
+	FormType string `fig:"form_type"`
+
+	// Fields contains form fields.
+	Fields []FieldConfig `fig:"fields"`
+}
+
+// FieldConfig defines a server disco info extension form field.
+type FieldConfig struct {
+	Var    string   `fig:"var"`
+	Values []string `fig:"values"`
+}
+
 // Disco represents a disco info (XEP-0030) module type.
 type Disco struct {
+	cfg        Config
 	router     router.Router
 	components components
 	rosRep     repository.Roster
@@ -73,13 +125,15 @@ type Disco struct {
 	hk         *hook.Hooks
 	logger     kitlog.Logger
 
-	mu      sync.RWMutex
-	srvProv InfoProvider
-	accProv InfoProvider
+	mu        sync.RWMutex
+	srvProv   InfoProvider
+	accProv   InfoProvider
+	nodeProvs map[string]InfoProvider
 }
 
 // New returns a new initialized disco module instance.
 func New(
+	cfg Config,
 	router router.Router,
 	components *component.Components,
 	rosRep repository.Roster,
@@ -88,12 +142,14 @@ func New(
 	logger kitlog.Logger,
 ) *Disco {
 	return &Disco{
+		cfg:        cfg,
 		router:     router,
 		components: components,
 		rosRep:     rosRep,
 		resMng:     resMng,
 		hk:         hk,
 		logger:     kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+		nodeProvs:  make(map[string]InfoProvider),
 	}
 }
 
@@ -133,6 +189,9 @@ func (m *Disco) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
 
 // Start starts disco module.
 func (m *Disco) Start(_ context.Context) error {
+	if err := validateConfig(m.cfg); err != nil {
+		return err
+	}
 	m.hk.AddHook(hook.ModulesStarted, m.onModulesStarted, hook.DefaultPriority)
 
 	level.Info(m.logger).Log("msg", "started disco module")
@@ -161,12 +220,26 @@ func (m *Disco) AccountProvider() InfoProvider {
 	return m.accProv
 }
 
+// RegisterNodeProvider registers a disco info provider in charge of answering requests addressed to a given node.
+func (m *Disco) RegisterNodeProvider(node string, prov InfoProvider) {
+	m.mu.Lock()
+	m.nodeProvs[node] = prov
+	m.mu.Unlock()
+}
+
+// UnregisterNodeProvider unregisters a previously registered node disco info provider.
+func (m *Disco) UnregisterNodeProvider(node string) {
+	m.mu.Lock()
+	delete(m.nodeProvs, node)
+	m.mu.Unlock()
+}
+
 func (m *Disco) onModulesStarted(execCtx *hook.ExecutionContext) error {
 	mods := execCtx.Sender.(modules)
 
 	m.mu.Lock()
-	m.srvProv = newServerProvider(mods.AllModules(), m.components)
-	m.accProv = newAccountProvider(mods.AllModules(), m.rosRep, m.resMng)
+	m.srvProv = newServerProvider(m.cfg, mods, m.components)
+	m.accProv = newAccountProvider(mods, m.rosRep, m.resMng)
 	m.mu.Unlock()
 
 	_, err := m.hk.Run(hook.DiscoProvidersStarted, &hook.ExecutionContext{
@@ -184,8 +257,13 @@ func (m *Disco) getDiscoInfo(ctx context.Context, iq *stravaganza.IQ) error {
 	}
 	var prov InfoProvider
 
+	node := q.Attribute("node")
+
 	m.mu.RLock()
+	nodeProv := m.nodeProvs[node]
 	switch {
+	case len(node) > 0 && nodeProv != nil:
+		prov = nodeProv
 	case iq.ToJID().IsServer():
 		prov = m.srvProv
 	default:
@@ -199,7 +277,6 @@ func (m *Disco) getDiscoInfo(ctx context.Context, iq *stravaganza.IQ) error {
 	fromJID := iq.FromJID()
 	toJID := iq.ToJID()
 
-	node := q.Attribute("node")
 	switch q.Attribute(stravaganza.Namespace) {
 	case discoInfoNamespace:
 		return m.sendDiscoInfo(ctx, prov, toJID, fromJID, node, iq)
@@ -225,6 +302,9 @@ func (m *Disco) sendDiscoInfo(ctx context.Context, prov InfoProvider, toJID, fro
 	}
 	sb := stravaganza.NewBuilder("query").
 		WithAttribute(stravaganza.Namespace, discoInfoNamespace)
+	if len(node) > 0 {
+		sb.WithAttribute("node", node)
+	}
 
 	identities := prov.Identities(ctx, toJID, fromJID, node)
 	for _, identity := range identities {
@@ -269,8 +349,34 @@ func (m *Disco) sendDiscoItems(ctx context.Context, prov InfoProvider, toJID, fr
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
 		return err
 	}
+	// apply RSM paging, if requested
+	var res *xep0059.Result
+	if set := iq.ChildNamespace("query", discoItemsNamespace).ChildNamespace("set", xep0059.RSMNamespace); set != nil {
+		req, err := xep0059.NewRequestFromElement(set)
+		if err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+			return nil
+		}
+		if req.Max > maxItemsPageSize {
+			req.Max = maxItemsPageSize
+		}
+		total := len(items)
+		items, res, err = xep0059.GetResultSetPage(items, req, itemID)
+		if err != nil {
+			if errors.Is(err, xep0059.ErrPageNotFound) {
+				_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+				return nil
+			}
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+		res.Count = total
+	}
 	qb := stravaganza.NewBuilder("query").
 		WithAttribute(stravaganza.Namespace, discoItemsNamespace)
+	if len(node) > 0 {
+		qb.WithAttribute("node", node)
+	}
 
 	for _, item := range items {
 		itemB := stravaganza.NewBuilder("item")
@@ -283,6 +389,16 @@ func (m *Disco) sendDiscoItems(ctx context.Context, prov InfoProvider, toJID, fr
 		}
 		qb.WithChild(itemB.Build())
 	}
+	if res != nil {
+		qb.WithChild(res.Element())
+	}
 	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, qb.Build()))
 	return nil
 }
+
+func itemID(item discomodel.Item) string {
+	if len(item.Node) == 0 {
+		return item.Jid
+	}
+	return item.Jid + "#" + item.Node
+}
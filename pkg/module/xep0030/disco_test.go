@@ -25,14 +25,18 @@ import (
 	"github.com/ortuman/jackal/pkg/component"
 	"github.com/ortuman/jackal/pkg/hook"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	discomodel "github.com/ortuman/jackal/pkg/model/disco"
 	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
 	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/module/xep0059"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDisco_GetServerInfo(t *testing.T) {
 	// given
 	modMock := &moduleMock{}
+	modMock.NameFunc = func() string { return "m0" }
 	modMock.ServerFeaturesFunc = func(_ context.Context) ([]string, error) {
 		return []string{"https://jackal.im#feature-1", "https://jackal.im#feature-2"}, nil
 	}
@@ -53,6 +57,7 @@ func TestDisco_GetServerInfo(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
 	modsMock.AllModulesFunc = func() []module.Module {
 		return []module.Module{modMock, d}
 	}
@@ -94,6 +99,239 @@ func TestDisco_GetServerInfo(t *testing.T) {
 	require.Len(t, features, 4)
 }
 
+func TestDisco_GetServerInfoDisabledModule(t *testing.T) {
+	// given
+	modMock := &moduleMock{}
+	modMock.NameFunc = func() string { return "m0" }
+	modMock.ServerFeaturesFunc = func(_ context.Context) ([]string, error) {
+		return []string{"https://jackal.im#feature-1", "https://jackal.im#feature-2"}, nil
+	}
+
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	hk := hook.NewHooks()
+	d := &Disco{
+		router: routerMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = d.Start(context.Background())
+	defer func() { _ = d.Stop(context.Background()) }()
+
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(moduleName, host string) bool {
+		return !(moduleName == "m0" && host == "jackal.im")
+	}
+	modsMock.AllModulesFunc = func() []module.Module {
+		return []module.Module{modMock, d}
+	}
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 1)
+
+	resIQ, ok := respStanzas[0].(*stravaganza.IQ)
+	require.True(t, ok)
+	require.Equal(t, stravaganza.ResultType, resIQ.Attribute("type"))
+
+	query := resIQ.ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+
+	identity := query.Child("identity")
+	require.NotNil(t, identity)
+	require.Equal(t, "server", identity.Attribute("category"))
+	require.Equal(t, "jackal", identity.Attribute("name"))
+
+	features := query.Children("feature")
+	require.Len(t, features, 2)
+}
+
+func TestDisco_GetServerInfoExtensionForms(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	hk := hook.NewHooks()
+	d := &Disco{
+		cfg: Config{
+			SoftwareInfo: true,
+			Forms: []FormConfig{
+				{
+					FormType: "http://jabber.org/network/serverinfo",
+					Fields: []FieldConfig{
+						{Var: "abuse-addresses", Values: []string{"mailto:abuse@jackal.im"}},
+					},
+				},
+			},
+		},
+		router: routerMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = d.Start(context.Background())
+	defer func() { _ = d.Stop(context.Background()) }()
+
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
+	modsMock.AllModulesFunc = func() []module.Module {
+		return []module.Module{d}
+	}
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 1)
+
+	query := respStanzas[0].ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+
+	forms := query.ChildrenNamespace("x", xep0004.FormNamespace)
+	require.Len(t, forms, 2)
+
+	swInfo, err := xep0004.NewFormFromElement(forms[0])
+	require.NoError(t, err)
+	require.Equal(t, softwareInfoFormType, swInfo.Fields.ValueForFieldOfType(xep0004.FormType, xep0004.Hidden))
+	require.Equal(t, "jackal", swInfo.Fields.ValueForField("software"))
+
+	srvInfo, err := xep0004.NewFormFromElement(forms[1])
+	require.NoError(t, err)
+	require.Equal(t, "http://jabber.org/network/serverinfo", srvInfo.Fields.ValueForFieldOfType(xep0004.FormType, xep0004.Hidden))
+	require.Equal(t, []string{"mailto:abuse@jackal.im"}, srvInfo.Fields.ValuesForField("abuse-addresses"))
+}
+
+func TestDisco_GetServerInfoModuleForms(t *testing.T) {
+	// given
+	modMock := &formsModuleMock{moduleMock: &moduleMock{}}
+	modMock.NameFunc = func() string { return "m0" }
+	modMock.ServerFeaturesFunc = func(_ context.Context) ([]string, error) { return nil, nil }
+	modMock.forms = []xep0004.DataForm{
+		{
+			Type: xep0004.Result,
+			Fields: xep0004.Fields{
+				{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{"urn:xmpp:sos:0"}},
+				{Var: "external-status-addresses", Values: []string{"https://status.jackal.im/sos.json"}},
+			},
+		},
+	}
+
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	hk := hook.NewHooks()
+	d := &Disco{
+		router: routerMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = d.Start(context.Background())
+	defer func() { _ = d.Stop(context.Background()) }()
+
+	var enabled bool
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(moduleName, _ string) bool {
+		return moduleName != "m0" || enabled
+	}
+	modsMock.AllModulesFunc = func() []module.Module {
+		return []module.Module{modMock, d}
+	}
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), iq)
+
+	enabled = true
+	_ = d.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 2)
+
+	query := respStanzas[0].ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+	require.Len(t, query.ChildrenNamespace("x", xep0004.FormNamespace), 0)
+
+	query = respStanzas[1].ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+
+	forms := query.ChildrenNamespace("x", xep0004.FormNamespace)
+	require.Len(t, forms, 1)
+
+	sosForm, err := xep0004.NewFormFromElement(forms[0])
+	require.NoError(t, err)
+	require.Equal(t, "urn:xmpp:sos:0", sosForm.Fields.ValueForFieldOfType(xep0004.FormType, xep0004.Hidden))
+	require.Equal(t, []string{"https://status.jackal.im/sos.json"}, sosForm.Fields.ValuesForField("external-status-addresses"))
+}
+
+func TestDisco_InvalidExtensionForms(t *testing.T) {
+	d := &Disco{
+		cfg: Config{
+			Forms: []FormConfig{
+				{FormType: "urn:jackal:form"},
+				{FormType: "urn:jackal:form"},
+			},
+		},
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+	require.Error(t, d.Start(context.Background()))
+}
+
 func TestDisco_GetServerItems(t *testing.T) {
 	// given
 	routerMock := &routerMock{}
@@ -124,6 +362,7 @@ func TestDisco_GetServerItems(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
 	modsMock.AllModulesFunc = func() []module.Module {
 		return nil
 	}
@@ -163,9 +402,199 @@ func TestDisco_GetServerItems(t *testing.T) {
 	require.Equal(t, "host.jackal.im", items[0].Attribute("jid"))
 }
 
+func TestDisco_GetServerGateways(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	compsMock := &componentsMock{}
+	compsMock.AllComponentsFunc = func() []component.Component {
+		return nil
+	}
+	hk := hook.NewHooks()
+	d := &Disco{
+		cfg: Config{
+			Gateways: []GatewayConfig{
+				{JID: "irc.jackal.im", Name: "IRC", Type: "irc", Features: []string{"jabber:iq:register", "jabber:iq:gateway"}},
+			},
+		},
+		router:     routerMock,
+		components: compsMock,
+		hk:         hk,
+		logger:     kitlog.NewNopLogger(),
+	}
+	_ = d.Start(context.Background())
+	defer func() { _ = d.Stop(context.Background()) }()
+
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
+	modsMock.AllModulesFunc = func() []module.Module {
+		return nil
+	}
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+
+	// when
+	itemsIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoItemsNamespace).
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), itemsIQ)
+
+	infoIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id5678").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				WithAttribute("node", "irc.jackal.im").
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), infoIQ)
+
+	// then
+	require.Len(t, respStanzas, 2)
+
+	items := respStanzas[0].ChildNamespace("query", discoItemsNamespace).Children("item")
+	require.Len(t, items, 1)
+	require.Equal(t, "irc.jackal.im", items[0].Attribute("jid"))
+	require.Equal(t, "IRC", items[0].Attribute("name"))
+
+	query := respStanzas[1].ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+
+	identities := query.Children("identity")
+	require.Len(t, identities, 1)
+	require.Equal(t, "gateway", identities[0].Attribute("category"))
+	require.Equal(t, "irc", identities[0].Attribute("type"))
+
+	features := query.Children("feature")
+	require.Len(t, features, 2)
+	require.Equal(t, "jabber:iq:gateway", features[0].Attribute("var"))
+	require.Equal(t, "jabber:iq:register", features[1].Attribute("var"))
+}
+
+func TestDisco_InvalidGateways(t *testing.T) {
+	d := &Disco{
+		cfg: Config{
+			Gateways: []GatewayConfig{
+				{JID: "irc.jackal.im", Type: "irc"},
+				{JID: "irc.jackal.im", Type: "irc"},
+			},
+		},
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+	require.Error(t, d.Start(context.Background()))
+}
+
+func TestDisco_GetServerItemsPaged(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	var comps []component.Component
+	for _, h := range []string{"a.jackal.im", "b.jackal.im", "c.jackal.im"} {
+		compHost := h
+		compMock := &componentMock{}
+		compMock.NameFunc = func() string { return compHost }
+		compMock.HostFunc = func() string { return compHost }
+		comps = append(comps, compMock)
+	}
+	compsMock := &componentsMock{}
+	compsMock.AllComponentsFunc = func() []component.Component {
+		return comps
+	}
+	hk := hook.NewHooks()
+	d := &Disco{
+		router:     routerMock,
+		components: compsMock,
+		hk:         hk,
+		logger:     kitlog.NewNopLogger(),
+	}
+	_ = d.Start(context.Background())
+	defer func() { _ = d.Stop(context.Background()) }()
+
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
+	modsMock.AllModulesFunc = func() []module.Module {
+		return nil
+	}
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+
+	// when
+	itemsIQ := func(set stravaganza.Element) *stravaganza.IQ {
+		iq, _ := stravaganza.NewIQBuilder().
+			WithAttribute(stravaganza.ID, "id1234").
+			WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+			WithAttribute(stravaganza.To, "jackal.im").
+			WithAttribute(stravaganza.Type, stravaganza.GetType).
+			WithChild(
+				stravaganza.NewBuilder("query").
+					WithAttribute(stravaganza.Namespace, discoItemsNamespace).
+					WithChild(set).
+					Build(),
+			).
+			BuildIQ()
+		return iq
+	}
+	_ = d.ProcessIQ(context.Background(), itemsIQ(
+		stravaganza.NewBuilder("set").
+			WithAttribute(stravaganza.Namespace, xep0059.RSMNamespace).
+			WithChild(stravaganza.NewBuilder("max").WithText("2").Build()).
+			Build(),
+	))
+	_ = d.ProcessIQ(context.Background(), itemsIQ(
+		stravaganza.NewBuilder("set").
+			WithAttribute(stravaganza.Namespace, xep0059.RSMNamespace).
+			WithChild(stravaganza.NewBuilder("max").WithText("2").Build()).
+			WithChild(stravaganza.NewBuilder("after").WithText("b.jackal.im").Build()).
+			Build(),
+	))
+
+	// then
+	require.Len(t, respStanzas, 2)
+
+	query := respStanzas[0].ChildNamespace("query", discoItemsNamespace)
+	require.Len(t, query.Children("item"), 2)
+
+	set := query.ChildNamespace("set", xep0059.RSMNamespace)
+	require.NotNil(t, set)
+	require.Equal(t, "a.jackal.im", set.Child("first").Text())
+	require.Equal(t, "b.jackal.im", set.Child("last").Text())
+	require.Equal(t, "3", set.Child("count").Text())
+
+	query = respStanzas[1].ChildNamespace("query", discoItemsNamespace)
+	items := query.Children("item")
+	require.Len(t, items, 1)
+	require.Equal(t, "c.jackal.im", items[0].Attribute("jid"))
+}
+
 func TestDisco_GetAccountInfo(t *testing.T) {
 	// given
 	modMock := &moduleMock{}
+	modMock.NameFunc = func() string { return "m0" }
 	modMock.AccountFeaturesFunc = func(_ context.Context) ([]string, error) {
 		return []string{"https://jackal.im#feature-1", "https://jackal.im#feature-2"}, nil
 	}
@@ -196,6 +625,7 @@ func TestDisco_GetAccountInfo(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
 	modsMock.AllModulesFunc = func() []module.Module {
 		return []module.Module{modMock, d}
 	}
@@ -271,6 +701,7 @@ func TestDisco_GetAccountItems(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
+	modsMock.IsEnabledForHostFunc = func(_, _ string) bool { return true }
 	modsMock.AllModulesFunc = func() []module.Module {
 		return nil
 	}
@@ -308,3 +739,66 @@ func TestDisco_GetAccountItems(t *testing.T) {
 
 	require.Equal(t, "noelia@jackal.im/chamber", items[0].Attribute("jid"))
 }
+
+func TestDisco_GetNodeInfo(t *testing.T) {
+	// given
+	provMock := &infoProviderMock{}
+	provMock.IdentitiesFunc = func(_ context.Context, _, _ *jid.JID, _ string) []discomodel.Identity {
+		return []discomodel.Identity{{Category: "automation", Type: "message-list"}}
+	}
+	provMock.FeaturesFunc = func(_ context.Context, _, _ *jid.JID, _ string) ([]discomodel.Feature, error) {
+		return []discomodel.Feature{"http://jabber.org/protocol/offline"}, nil
+	}
+	provMock.FormsFunc = func(_ context.Context, _, _ *jid.JID, _ string) ([]xep0004.DataForm, error) {
+		return nil, nil
+	}
+
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	d := &Disco{
+		router:    routerMock,
+		hk:        hook.NewHooks(),
+		logger:    kitlog.NewNopLogger(),
+		srvProv:   &infoProviderMock{},
+		nodeProvs: make(map[string]InfoProvider),
+	}
+	d.RegisterNodeProvider("http://jabber.org/protocol/offline", provMock)
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				WithAttribute("node", "http://jabber.org/protocol/offline").
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, provMock.FeaturesCalls(), 1)
+	require.Len(t, respStanzas, 1)
+
+	query := respStanzas[0].ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+	require.Equal(t, "http://jabber.org/protocol/offline", query.Attribute("node"))
+	require.Equal(t, "message-list", query.Child("identity").Attribute("type"))
+	require.Len(t, query.Children("feature"), 1)
+}
+
+type formsModuleMock struct {
+	*moduleMock
+	forms []xep0004.DataForm
+}
+
+func (m *formsModuleMock) ServerForms(_ context.Context) ([]xep0004.DataForm, error) {
+	return m.forms, nil
+}
@@ -40,6 +40,7 @@ type resourceManager interface {
 //go:generate moq -out modules.mock_test.go . modules
 type modules interface {
 	AllModules() []module.Module
+	IsEnabledForHost(moduleName, host string) bool
 }
 
 //go:generate moq -out components.mock_test.go . components
@@ -56,3 +57,8 @@ type discoComponent interface {
 type discoModule interface {
 	module.Module
 }
+
+//go:generate moq -out info_provider.mock_test.go . nodeInfoProvider:infoProviderMock
+type nodeInfoProvider interface {
+	InfoProvider
+}
@@ -16,30 +16,43 @@ package xep0030
 
 import (
 	"context"
+	"fmt"
+	"runtime"
 	"sort"
+	"strings"
 
 	"github.com/jackal-xmpp/stravaganza/jid"
 	discomodel "github.com/ortuman/jackal/pkg/model/disco"
-	"github.com/ortuman/jackal/pkg/module"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/version"
 )
 
+const softwareInfoFormType = "urn:xmpp:dataforms:softwareinfo"
+
 type serverProvider struct {
-	mods  []module.Module
+	mods  modules
 	comps components
+	forms []xep0004.DataForm
+	gws   map[string]GatewayConfig
 }
 
 func newServerProvider(
-	mods []module.Module,
+	cfg Config,
+	mods modules,
 	comps components,
 ) *serverProvider {
 	return &serverProvider{
 		mods:  mods,
 		comps: comps,
+		forms: serverForms(cfg),
+		gws:   serverGateways(cfg),
 	}
 }
 
-func (p *serverProvider) Identities(_ context.Context, _, _ *jid.JID, _ string) []discomodel.Identity {
+func (p *serverProvider) Identities(_ context.Context, _, _ *jid.JID, node string) []discomodel.Identity {
+	if gw, ok := p.gws[node]; ok {
+		return []discomodel.Identity{{Type: gw.Type, Category: "gateway", Name: gw.Name}}
+	}
 	return []discomodel.Identity{{Type: "im", Category: "server", Name: "jackal"}}
 }
 
@@ -51,13 +64,28 @@ func (p *serverProvider) Items(_ context.Context, _, _ *jid.JID, _ string) ([]di
 			Name: comp.Name(),
 		})
 	}
+	for _, gw := range p.gws {
+		items = append(items, discomodel.Item{
+			Jid:  gw.JID,
+			Name: gw.Name,
+		})
+	}
 	sort.Slice(items, func(i, j int) bool { return items[i].Jid < items[j].Jid })
 	return items, nil
 }
 
-func (p *serverProvider) Features(ctx context.Context, _, _ *jid.JID, _ string) ([]discomodel.Feature, error) {
+func (p *serverProvider) Features(ctx context.Context, toJID, _ *jid.JID, node string) ([]discomodel.Feature, error) {
 	var features []discomodel.Feature
-	for _, mod := range p.mods {
+	if gw, ok := p.gws[node]; ok {
+		// gateway metadata can be fetched through a server node matching its address
+		features = append(features, gw.Features...)
+		sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+		return features, nil
+	}
+	for _, mod := range p.mods.AllModules() {
+		if !p.mods.IsEnabledForHost(mod.Name(), toJID.Domain()) {
+			continue
+		}
 		srvFeatures, err := mod.ServerFeatures(ctx)
 		if err != nil {
 			return nil, err
@@ -68,6 +96,102 @@ func (p *serverProvider) Features(ctx context.Context, _, _ *jid.JID, _ string)
 	return features, nil
 }
 
-func (p *serverProvider) Forms(ctx context.Context, toJID, fromJID *jid.JID, node string) ([]xep0004.DataForm, error) {
-	return nil, nil
+func (p *serverProvider) Forms(ctx context.Context, toJID, _ *jid.JID, node string) ([]xep0004.DataForm, error) {
+	if len(node) > 0 {
+		return nil, nil
+	}
+	forms := append([]xep0004.DataForm{}, p.forms...)
+	for _, mod := range p.mods.AllModules() {
+		formsProv, ok := mod.(FormsProvider)
+		if !ok || !p.mods.IsEnabledForHost(mod.Name(), toJID.Domain()) {
+			continue
+		}
+		modForms, err := formsProv.ServerForms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		forms = append(forms, modForms...)
+	}
+	return forms, nil
+}
+
+func serverForms(cfg Config) []xep0004.DataForm {
+	var forms []xep0004.DataForm
+	if cfg.SoftwareInfo {
+		forms = append(forms, xep0004.DataForm{
+			Type: xep0004.Result,
+			Fields: xep0004.Fields{
+				formTypeField(softwareInfoFormType),
+				{Var: "os", Values: []string{runtime.GOOS}},
+				{Var: "software", Values: []string{"jackal"}},
+				{Var: "software_version", Values: []string{strings.TrimPrefix(version.Version.String(), "v")}},
+			},
+		})
+	}
+	for _, formCfg := range cfg.Forms {
+		fields := xep0004.Fields{formTypeField(formCfg.FormType)}
+		for _, fieldCfg := range formCfg.Fields {
+			fields = append(fields, xep0004.Field{
+				Var:    fieldCfg.Var,
+				Values: fieldCfg.Values,
+			})
+		}
+		forms = append(forms, xep0004.DataForm{
+			Type:   xep0004.Result,
+			Fields: fields,
+		})
+	}
+	return forms
+}
+
+func serverGateways(cfg Config) map[string]GatewayConfig {
+	gws := make(map[string]GatewayConfig, len(cfg.Gateways))
+	for _, gwCfg := range cfg.Gateways {
+		gws[gwCfg.JID] = gwCfg
+	}
+	return gws
+}
+
+func formTypeField(formType string) xep0004.Field {
+	return xep0004.Field{
+		Var:    xep0004.FormType,
+		Type:   xep0004.Hidden,
+		Values: []string{formType},
+	}
+}
+
+func validateConfig(cfg Config) error {
+	formTypes := make(map[string]struct{}, len(cfg.Forms))
+	if cfg.SoftwareInfo {
+		formTypes[softwareInfoFormType] = struct{}{}
+	}
+	for _, formCfg := range cfg.Forms {
+		if len(formCfg.FormType) == 0 {
+			return fmt.Errorf("xep0030: missing form type")
+		}
+		if _, ok := formTypes[formCfg.FormType]; ok {
+			return fmt.Errorf("xep0030: duplicated form type: %s", formCfg.FormType)
+		}
+		formTypes[formCfg.FormType] = struct{}{}
+
+		for _, fieldCfg := range formCfg.Fields {
+			if len(fieldCfg.Var) == 0 || fieldCfg.Var == xep0004.FormType {
+				return fmt.Errorf("xep0030: invalid field var in form %s: %q", formCfg.FormType, fieldCfg.Var)
+			}
+		}
+	}
+	gwJIDs := make(map[string]struct{}, len(cfg.Gateways))
+	for _, gwCfg := range cfg.Gateways {
+		if _, err := jid.NewWithString(gwCfg.JID, false); err != nil {
+			return fmt.Errorf("xep0030: invalid gateway jid: %q", gwCfg.JID)
+		}
+		if len(gwCfg.Type) == 0 {
+			return fmt.Errorf("xep0030: missing type for gateway %s", gwCfg.JID)
+		}
+		if _, ok := gwJIDs[gwCfg.JID]; ok {
+			return fmt.Errorf("xep0030: duplicated gateway: %s", gwCfg.JID)
+		}
+		gwJIDs[gwCfg.JID] = struct{}{}
+	}
+	return nil
 }
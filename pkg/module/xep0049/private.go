@@ -178,13 +178,16 @@ func (m *Private) setPrivate(ctx context.Context, iq *stravaganza.IQ, q stravaga
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.NotAcceptable))
 		return nil
 	}
-	username := iq.FromJID().Node()
+	// validate all namespaces before storing anything
 	for _, prv := range q.AllChildren() {
-		ns := prv.Attribute(stravaganza.Namespace)
-		if !isValidNamespace(ns) {
+		if !isValidNamespace(prv.Attribute(stravaganza.Namespace)) {
 			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.NotAcceptable))
 			return nil
 		}
+	}
+	username := iq.FromJID().Node()
+	for _, prv := range q.AllChildren() {
+		ns := prv.Attribute(stravaganza.Namespace)
 		if err := m.rep.UpsertPrivate(ctx, prv, ns, username); err != nil {
 			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
 			return err
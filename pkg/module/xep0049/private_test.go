@@ -140,6 +140,57 @@ func TestPrivate_SetPrivate(t *testing.T) {
 	require.Equal(t, stravaganza.ResultType, resIQ.Attribute(stravaganza.Type))
 }
 
+func TestPrivate_SetPrivateInvalidNamespace(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertPrivateFunc = func(ctx context.Context, private stravaganza.Element, namespace string, username string) error {
+		return nil
+	}
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+
+	// when
+	p := &Private{
+		rep:    repMock,
+		router: routerMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+	reqIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.ID, "1001").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, privateNamespace).
+				WithChild(
+					stravaganza.NewBuilder("exodus").
+						WithAttribute(stravaganza.Namespace, "exodus:prefs").
+						Build(),
+				).
+				WithChild(
+					stravaganza.NewBuilder("vCard").
+						WithAttribute(stravaganza.Namespace, "vcard-temp").
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+
+	_ = p.ProcessIQ(context.Background(), reqIQ)
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
+	require.Len(t, repMock.UpsertPrivateCalls(), 0)
+}
+
 func TestPrivate_ForbiddenRequest(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
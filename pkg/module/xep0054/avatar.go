@@ -0,0 +1,179 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0054
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"unicode"
+
+	"github.com/jackal-xmpp/stravaganza"
+)
+
+const (
+	minAvatarDimension = 16
+	avatarJPEGQuality  = 85
+)
+
+var (
+	errInvalidAvatar  = errors.New("xep0054: invalid avatar data")
+	errAvatarTooLarge = errors.New("xep0054: avatar exceeds configured limits")
+)
+
+// processAvatar enforces the configured avatar limits over vCard PHOTO element,
+// returning the (possibly downscaled) vCard element to be stored.
+func (m *VCard) processAvatar(vCard stravaganza.Element) (stravaganza.Element, error) {
+	if m.cfg.MaxAvatarBytes <= 0 && m.cfg.MaxAvatarDimension <= 0 {
+		return vCard, nil
+	}
+	photo := vCard.Child("PHOTO")
+	if photo == nil {
+		return vCard, nil
+	}
+	binVal := photo.Child("BINVAL")
+	if binVal == nil {
+		return vCard, nil // external avatar (EXTVAL)
+	}
+	raw, err := base64.StdEncoding.DecodeString(stripSpaces(binVal.Text()))
+	if err != nil {
+		return nil, errInvalidAvatar
+	}
+	exceedsBytes := m.cfg.MaxAvatarBytes > 0 && len(raw) > m.cfg.MaxAvatarBytes
+
+	var exceedsDimension bool
+	if imgCfg, _, err := image.DecodeConfig(bytes.NewReader(raw)); err == nil {
+		exceedsDimension = m.cfg.MaxAvatarDimension > 0 &&
+			(imgCfg.Width > m.cfg.MaxAvatarDimension || imgCfg.Height > m.cfg.MaxAvatarDimension)
+	}
+	if !exceedsBytes && !exceedsDimension {
+		return vCard, nil
+	}
+	if !m.cfg.DownscaleAvatars {
+		return nil, errAvatarTooLarge
+	}
+	img, format, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errAvatarTooLarge // unable to re-encode an unknown format
+	}
+	b, mimeType, err := downscaleAvatar(img, format, m.cfg.MaxAvatarDimension, m.cfg.MaxAvatarBytes)
+	if err != nil {
+		return nil, err
+	}
+	newPhoto := stravaganza.NewBuilder("PHOTO").
+		WithChild(stravaganza.NewBuilder("TYPE").WithText(mimeType).Build()).
+		WithChild(stravaganza.NewBuilder("BINVAL").WithText(base64.StdEncoding.EncodeToString(b)).Build()).
+		Build()
+
+	return stravaganza.NewBuilderFromElement(vCard).
+		WithoutChildren("PHOTO").
+		WithChild(newPhoto).
+		Build(), nil
+}
+
+func downscaleAvatar(img image.Image, format string, maxDimension, maxBytes int) ([]byte, string, error) {
+	dim := img.Bounds().Dx()
+	if h := img.Bounds().Dy(); h > dim {
+		dim = h
+	}
+	if maxDimension > 0 && dim > maxDimension {
+		dim = maxDimension
+	}
+	for dim >= minAvatarDimension {
+		b, mimeType, err := encodeAvatar(scaleImage(img, dim), format)
+		if err != nil {
+			return nil, "", err
+		}
+		if maxBytes <= 0 || len(b) <= maxBytes {
+			return b, mimeType, nil
+		}
+		dim = dim * 3 / 4
+	}
+	return nil, "", errAvatarTooLarge
+}
+
+func encodeAvatar(img image.Image, format string) ([]byte, string, error) {
+	buf := bytes.NewBuffer(nil)
+	if format == "jpeg" {
+		if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// scaleImage returns a copy of img fitting within a maxDimension square, averaging source pixels (box filter).
+func scaleImage(img image.Image, maxDimension int) image.Image {
+	sb := img.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	if sw <= maxDimension && sh <= maxDimension {
+		return img
+	}
+	dw, dh := maxDimension, maxDimension
+	if sw > sh {
+		dh = maxInt(1, sh*maxDimension/sw)
+	} else {
+		dw = maxInt(1, sw*maxDimension/sh)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := sb.Min.Y+y*sh/dh, sb.Min.Y+maxInt((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := sb.Min.X+x*sw/dw, sb.Min.X+maxInt((x+1)*sw/dw, x*sw/dw+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+func stripSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
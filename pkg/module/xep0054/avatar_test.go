@@ -0,0 +1,152 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0054
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVCard_DownscaleAvatar(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+
+	var storedVCard stravaganza.Element
+	repMock.UpsertVCardFunc = func(ctx context.Context, vCard stravaganza.Element, username string) error {
+		storedVCard = vCard
+		return nil
+	}
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+
+	v := &VCard{
+		cfg: Config{
+			MaxAvatarDimension: 64,
+			DownscaleAvatars:   true,
+		},
+		rep:    repMock,
+		router: routerMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	_ = v.ProcessIQ(context.Background(), testAvatarIQ(256, 128))
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Attribute(stravaganza.Type))
+
+	require.NotNil(t, storedVCard)
+	require.NotNil(t, storedVCard.Child("FN"))
+
+	photo := storedVCard.Child("PHOTO")
+	require.NotNil(t, photo)
+	require.Equal(t, "image/png", photo.Child("TYPE").Text())
+
+	b, err := base64.StdEncoding.DecodeString(photo.Child("BINVAL").Text())
+	require.Nil(t, err)
+
+	imgCfg, err := png.DecodeConfig(bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, 64, imgCfg.Width)
+	require.Equal(t, 32, imgCfg.Height)
+}
+
+func TestVCard_RejectOversizedAvatar(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertVCardFunc = func(ctx context.Context, vCard stravaganza.Element, username string) error {
+		return nil
+	}
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+
+	v := &VCard{
+		cfg: Config{
+			MaxAvatarBytes: 32,
+		},
+		rep:    repMock,
+		router: routerMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	_ = v.ProcessIQ(context.Background(), testAvatarIQ(64, 64))
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
+	require.NotNil(t, respStanzas[0].Child("error").Child("not-acceptable"))
+
+	require.Len(t, repMock.UpsertVCardCalls(), 0)
+}
+
+func testAvatarIQ(width, height int) *stravaganza.IQ {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 0xff})
+		}
+	}
+	buf := bytes.NewBuffer(nil)
+	_ = png.Encode(buf, img)
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithChild(
+			stravaganza.NewBuilder("vCard").
+				WithAttribute(stravaganza.Namespace, vCardNamespace).
+				WithChild(
+					stravaganza.NewBuilder("FN").
+						WithText("Noelia").
+						Build(),
+				).
+				WithChild(
+					stravaganza.NewBuilder("PHOTO").
+						WithChild(stravaganza.NewBuilder("TYPE").WithText("image/png").Build()).
+						WithChild(stravaganza.NewBuilder("BINVAL").WithText(base64.StdEncoding.EncodeToString(buf.Bytes())).Build()).
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+	return iq
+}
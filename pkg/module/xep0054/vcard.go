@@ -16,6 +16,7 @@ package xep0054
 
 import (
 	"context"
+	"errors"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -37,8 +38,21 @@ const (
 	XEPNumber = "0054"
 )
 
+// Config contains vCard module configuration options.
+type Config struct {
+	// MaxAvatarBytes defines the maximum size in bytes of a vCard avatar (0 means unlimited).
+	MaxAvatarBytes int `fig:"max_avatar_bytes"`
+
+	// MaxAvatarDimension defines the maximum width and height in pixels of a vCard avatar (0 means unlimited).
+	MaxAvatarDimension int `fig:"max_avatar_dimension"`
+
+	// DownscaleAvatars tells whether oversized avatars should be downscaled instead of rejected.
+	DownscaleAvatars bool `fig:"downscale_avatars"`
+}
+
 // VCard represents a vCard (XEP-0054) module type.
 type VCard struct {
+	cfg    Config
 	rep    repository.VCard
 	router router.Router
 	hk     *hook.Hooks
@@ -47,12 +61,14 @@ type VCard struct {
 
 // New returns a new initialized VCard instance.
 func New(
+	cfg Config,
 	router router.Router,
 	rep repository.Repository,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *VCard {
 	return &VCard{
+		cfg:    cfg,
 		router: router,
 		rep:    rep,
 		hk:     hk,
@@ -166,7 +182,16 @@ func (m *VCard) setVCard(ctx context.Context, iq *stravaganza.IQ) error {
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.Forbidden))
 		return nil
 	}
-	err := m.rep.UpsertVCard(ctx, vCard, toJID.Node())
+	vCard, err := m.processAvatar(vCard)
+	if err != nil {
+		errReason := stanzaerror.BadRequest
+		if errors.Is(err, errAvatarTooLarge) {
+			errReason = stanzaerror.NotAcceptable
+		}
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, errReason))
+		return nil
+	}
+	err = m.rep.UpsertVCard(ctx, vCard, toJID.Node())
 	if err != nil {
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
 		return err
@@ -145,6 +145,10 @@ func GetResultSetPage[T any](rs []T, req *Request, getID func(i T) string) ([]T,
 	if err != nil {
 		return nil, nil, err
 	}
+	if len(page) == 0 {
+		res.Complete = true
+		return nil, res, nil
+	}
 	res.First = getID(page[0])
 	res.Last = getID(page[len(page)-1])
 
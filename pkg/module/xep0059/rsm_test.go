@@ -116,6 +116,11 @@ func Test_GetResultSetPage(t *testing.T) {
 			expectedPage:   []string{"9", "10"},
 			expectedResult: Result{Index: 2, Count: 2, First: "9", Last: "10", Complete: true},
 		},
+		"get page after id - past last item": {
+			rs:             []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+			req:            Request{After: "10", Max: 4},
+			expectedResult: Result{Index: 2, Complete: true},
+		},
 		"get page after id - not found": {
 			rs:           []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
 			req:          Request{After: "11", Max: 4},
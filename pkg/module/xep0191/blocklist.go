@@ -40,6 +40,10 @@ const (
 	blockListNamespace       = "urn:xmpp:blocking"
 	blockListErrorsNamespace = "urn:xmpp:blocking:errors"
 
+	reportingNamespace   = "urn:xmpp:reporting:1"
+	reportingSpamReason  = "urn:xmpp:reporting:spam"
+	reportingAbuseReason = "urn:xmpp:reporting:abuse"
+
 	blockedTargetErrorText = "Your active block list has denied the routing of this stanza."
 )
 
@@ -90,7 +94,7 @@ func (m *BlockList) StreamFeature(_ context.Context, _ string) (stravaganza.Elem
 
 // ServerFeatures returns blocklist server disco features.
 func (m *BlockList) ServerFeatures(_ context.Context) ([]string, error) {
-	return []string{blockListNamespace}, nil
+	return []string{blockListNamespace, reportingNamespace, reportingSpamReason, reportingAbuseReason}, nil
 }
 
 // AccountFeatures returns blocklist account disco features.
@@ -222,6 +226,11 @@ func (m *BlockList) processIncomingStanza(ctx context.Context, stanza stravaganz
 			}
 		case *stravaganza.Message:
 			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(stanza, stanzaerror.ServiceUnavailable))
+		case *stravaganza.Presence:
+			// blocked contacts always see the user as unavailable
+			if st.Type() == stravaganza.ProbeType {
+				_, _ = m.router.Route(ctx, xmpputil.MakePresence(toJID.ToBareJID(), fromJID, stravaganza.UnavailableType, nil))
+			}
 		}
 		return hook.ErrStopped // element already handled
 	}
@@ -245,6 +254,9 @@ func (m *BlockList) processOutgoingStanza(ctx context.Context, stanza stravaganz
 		if !jd.Matches(toJID) {
 			continue
 		}
+		if stanza.Attribute(stravaganza.Type) == stravaganza.ErrorType {
+			return hook.ErrStopped // do not bounce errors
+		}
 		// return <not-acceptable> stanza error
 		se := stanzaerror.E(stanzaerror.NotAcceptable, stanza)
 		se.Text = blockedTargetErrorText
@@ -397,6 +409,29 @@ func (m *BlockList) blockJIDs(ctx context.Context, iq *stravaganza.IQ, block str
 		Sender:  m,
 		Context: ctx,
 	})
+	if err != nil {
+		return err
+	}
+	// run reported hook (XEP-0377)
+	reports := getItemReports(block)
+	if len(reports) == 0 {
+		return nil
+	}
+	reportedJIDs := make([]jid.JID, 0, len(reports))
+	for _, r := range reports {
+		reportedJIDs = append(reportedJIDs, r.JID)
+
+		level.Info(m.logger).Log("msg", "reported JID", "username", username, "jid", r.JID.String(), "reason", r.Reason)
+	}
+	_, err = m.hk.Run(hook.BlockListItemsReported, &hook.ExecutionContext{
+		Info: &hook.BlockListInfo{
+			Username: username,
+			JIDs:     reportedJIDs,
+			Reports:  reports,
+		},
+		Sender:  m,
+		Context: ctx,
+	})
 	return err
 }
 
@@ -560,3 +595,27 @@ func getItemJIDs(el stravaganza.Element) ([]jid.JID, error) {
 	}
 	return retVal, nil
 }
+
+func getItemReports(el stravaganza.Element) []hook.BlockListReport {
+	var retVal []hook.BlockListReport
+	for _, itm := range el.Children("item") {
+		report := itm.ChildNamespace("report", reportingNamespace)
+		if report == nil || len(report.Attribute("reason")) == 0 {
+			continue
+		}
+		j, err := jid.NewWithString(itm.Attribute("jid"), false)
+		if err != nil {
+			continue
+		}
+		var text string
+		if txt := report.Child("text"); txt != nil {
+			text = txt.Text()
+		}
+		retVal = append(retVal, hook.BlockListReport{
+			JID:    *j,
+			Reason: report.Attribute("reason"),
+			Text:   text,
+		})
+	}
+	return retVal
+}
@@ -197,6 +197,89 @@ func TestBlockList_BlockItem(t *testing.T) {
 	require.NotNil(t, respStanzas[4].ChildNamespace("block", blockListNamespace))
 }
 
+func TestBlockList_ReportItem(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	resMngMock := &resourceManagerMock{}
+	rep := &repositoryMock{}
+	txMock := &txMock{}
+
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		return nil, nil
+	}
+	txMock.UpsertBlockListItemFunc = func(ctx context.Context, item *blocklistmodel.Item) error {
+		return nil
+	}
+	rep.FetchBlockListItemsFunc = func(ctx context.Context, username string) ([]*blocklistmodel.Item, error) {
+		return []*blocklistmodel.Item{{Username: "ortuman", Jid: "spammer@jabber.org"}}, nil
+	}
+	rep.FetchRosterItemsFunc = func(ctx context.Context, username string) ([]*rostermodel.Item, error) {
+		return nil, nil
+	}
+	rep.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	resMngMock.GetResourcesFunc = func(ctx context.Context, username string) ([]c2smodel.ResourceDesc, error) {
+		return nil, nil
+	}
+	hk := hook.NewHooks()
+
+	var reportInf *hook.BlockListInfo
+	hk.AddHook(hook.BlockListItemsReported, func(execCtx *hook.ExecutionContext) error {
+		reportInf = execCtx.Info.(*hook.BlockListInfo)
+		return nil
+	}, hook.DefaultPriority)
+
+	bl := &BlockList{
+		router: routerMock,
+		rep:    rep,
+		resMng: resMngMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("block").
+				WithAttribute(stravaganza.Namespace, blockListNamespace).
+				WithChild(
+					stravaganza.NewBuilder("item").
+						WithAttribute("jid", "spammer@jabber.org").
+						WithChild(
+							stravaganza.NewBuilder("report").
+								WithAttribute(stravaganza.Namespace, reportingNamespace).
+								WithAttribute("reason", reportingSpamReason).
+								WithChild(stravaganza.NewBuilder("text").WithText("Buy cheap stuff!").Build()).
+								Build(),
+						).
+						Build(),
+				).
+				WithChild(
+					stravaganza.NewBuilder("item").
+						WithAttribute("jid", "romeo@jabber.org").
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+
+	err := bl.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.NoError(t, err)
+	require.NotNil(t, reportInf)
+	require.Equal(t, "ortuman", reportInf.Username)
+	require.Len(t, reportInf.Reports, 1)
+	require.Equal(t, "spammer@jabber.org", reportInf.Reports[0].JID.String())
+	require.Equal(t, reportingSpamReason, reportInf.Reports[0].Reason)
+	require.Equal(t, "Buy cheap stuff!", reportInf.Reports[0].Text)
+}
+
 func TestBlockList_UnblockItem(t *testing.T) {
 	// given
 	routerMock := &routerMock{}
@@ -481,6 +564,62 @@ func TestBlockList_InterceptOutgoingStanza(t *testing.T) {
 	require.NotNil(t, errEl)
 
 	require.NotNil(t, errEl.ChildNamespace("not-acceptable", "urn:ietf:params:xml:ns:xmpp-stanzas"))
+	require.NotNil(t, errEl.ChildNamespace("blocked", blockListErrorsNamespace))
+}
+
+func TestBlockList_InterceptIncomingProbe(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	hMock := &hostsMock{}
+	rep := &repositoryMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	hMock.IsLocalHostFunc = func(h string) bool {
+		return h == "jackal.im"
+	}
+	rep.FetchBlockListItemsFunc = func(ctx context.Context, username string) ([]*blocklistmodel.Item, error) {
+		return []*blocklistmodel.Item{
+			{Username: "ortuman", Jid: "juliet@jabber.org"},
+		}, nil
+	}
+	hk := hook.NewHooks()
+	bl := &BlockList{
+		hosts:  hMock,
+		router: routerMock,
+		rep:    rep,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	pr, _ := stravaganza.NewPresenceBuilder().
+		WithAttribute(stravaganza.From, "juliet@jabber.org/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.ProbeType).
+		BuildPresence()
+
+	// when
+	_ = bl.Start(context.Background())
+	defer func() { _ = bl.Stop(context.Background()) }()
+
+	halted, err := hk.Run(hook.S2SInStreamElementReceived, &hook.ExecutionContext{
+		Info: &hook.S2SStreamInfo{
+			Element: pr,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.Nil(t, err)
+	require.True(t, halted)
+
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, "presence", respStanzas[0].Name())
+	require.Equal(t, "ortuman@jackal.im", respStanzas[0].Attribute(stravaganza.From))
+	require.Equal(t, "juliet@jabber.org/yard", respStanzas[0].Attribute(stravaganza.To))
+	require.Equal(t, stravaganza.UnavailableType, respStanzas[0].Attribute(stravaganza.Type))
 }
 
 func TestBlockList_PresenceTargets(t *testing.T) {
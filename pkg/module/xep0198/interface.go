@@ -19,6 +19,7 @@ import (
 	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/storage/repository"
 )
 
 //go:generate moq -out router.mock_test.go . globalRouter:routerMock
@@ -50,3 +51,8 @@ type clusterConn interface {
 type streamManagementService interface {
 	clusterconnmanager.StreamManagement
 }
+
+//go:generate moq -out repository.mock_test.go . streamQueueRepository:repositoryMock
+type streamQueueRepository interface {
+	repository.StreamQueue
+}
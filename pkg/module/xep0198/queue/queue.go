@@ -73,6 +73,17 @@ type Element struct {
 	H uint32
 }
 
+// All returns all queues currently stored in the map.
+func (qm *QueueMap) All() []*Queue {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	retVal := make([]*Queue, 0, len(qm.queues))
+	for _, q := range qm.queues {
+		retVal = append(retVal, q)
+	}
+	return retVal
+}
+
 // Queue represents and c2s resumable queue.
 type Queue struct {
 	stm               stream.C2S
@@ -161,6 +172,7 @@ func (q *Queue) Acknowledge(h uint32) {
 	defer q.mu.Unlock()
 	if discTm := q.discTm; discTm != nil {
 		discTm.Stop() // cancel disconnection timeout
+		q.discTm = nil
 	}
 	j := -1
 	for i, e := range q.elements {
@@ -174,6 +186,16 @@ func (q *Queue) Acknowledge(h uint32) {
 	q.setRTimer()
 }
 
+// DropOldest discards the n oldest unacknowledged stanzas.
+func (q *Queue) DropOldest(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n > len(q.elements) {
+		n = len(q.elements)
+	}
+	q.elements = q.elements[n:]
+}
+
 // SendPending sends all pending stanzas to the queue internal stream.
 func (q *Queue) SendPending() {
 	q.mu.RLock()
@@ -220,11 +242,12 @@ func (q *Queue) ScheduleR() {
 
 // CancelTimers cancels all queue internal timers.
 func (q *Queue) CancelTimers() {
-	q.mu.RLock()
-	defer q.mu.RUnlock()
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	q.rTm.Stop()
 	if discTm := q.discTm; discTm != nil {
 		discTm.Stop()
+		q.discTm = nil
 	}
 }
 
@@ -238,7 +261,10 @@ func (q *Queue) RequestAck() {
 		Build()
 	q.stm.SendElement(r)
 
-	// schedule disconnect
+	// schedule disconnect, unless a previous request is still waiting for its ack
+	if q.discTm != nil {
+		return
+	}
 	q.discTm = time.AfterFunc(q.waitForAckTimeout, func() {
 		q.stm.Disconnect(streamerror.E(streamerror.ConnectionTimeout))
 	})
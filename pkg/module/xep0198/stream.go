@@ -35,10 +35,14 @@ import (
 	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
 	streamqueue "github.com/ortuman/jackal/pkg/module/xep0198/queue"
 	xmppparser "github.com/ortuman/jackal/pkg/parser"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
@@ -53,8 +57,8 @@ const (
 
 	nonceLength = 24
 
-	// unacknowledgedStanzaCount defines the stanza count interval at which an "r" stanza will be sent
-	unacknowledgedStanzaCount = 25
+	killOverflowPolicy       = "kill"
+	dropOldestOverflowPolicy = "drop_oldest"
 )
 
 var errInvalidSMID = errors.New("xep0198: invalid stream identifier format")
@@ -81,8 +85,80 @@ type Config struct {
 	WaitForAckTimeout time.Duration `fig:"wait_for_ack_timeout" default:"30s"`
 
 	// MaxQueueSize defines maximum number of unacknowledged stanzas.
-	// When the limit is reached the c2s stream is terminated.
 	MaxQueueSize int `fig:"max_queue_size" default:"250"`
+
+	// RequestAckEvery defines the unacknowledged stanza count interval at which an "r" stanza will be sent.
+	RequestAckEvery int `fig:"request_ack_every" default:"25"`
+
+	// OverflowPolicy defines the action to be taken when MaxQueueSize is reached.
+	// Accepted values are "kill" (terminate c2s stream) and "drop_oldest" (discard oldest unacknowledged stanzas
+	// so that the queue never holds more than MaxQueueSize elements).
+	OverflowPolicy string `fig:"overflow_policy" default:"kill"`
+
+	// PersistQueues tells whether unacknowledged stream queues should be stored into the repository on shutdown,
+	// so that clients can resume their sessions after a server restart.
+	PersistQueues bool `fig:"persist_queues"`
+
+	// PersistMaxElements defines the maximum number of unacknowledged stanzas to be persisted per stream queue.
+	PersistMaxElements int `fig:"persist_max_elements" default:"100"`
+
+	// Hosts contains per-host overrides. Unset values are inherited from global configuration.
+	Hosts []HostConfig `fig:"hosts"`
+}
+
+// HostConfig contains stream management configuration options for a specific host.
+type HostConfig struct {
+	Domain             string        `fig:"domain"`
+	HibernateTime      time.Duration `fig:"hibernate_time"`
+	RequestAckInterval time.Duration `fig:"request_ack_interval"`
+	WaitForAckTimeout  time.Duration `fig:"wait_for_ack_timeout"`
+	MaxQueueSize       int           `fig:"max_queue_size"`
+	RequestAckEvery    int           `fig:"request_ack_every"`
+	OverflowPolicy     string        `fig:"overflow_policy"`
+}
+
+// forDomain returns the effective configuration for a given domain.
+func (c Config) forDomain(domain string) Config {
+	for _, hc := range c.Hosts {
+		if hc.Domain != domain {
+			continue
+		}
+		if hc.HibernateTime > 0 {
+			c.HibernateTime = hc.HibernateTime
+		}
+		if hc.RequestAckInterval > 0 {
+			c.RequestAckInterval = hc.RequestAckInterval
+		}
+		if hc.WaitForAckTimeout > 0 {
+			c.WaitForAckTimeout = hc.WaitForAckTimeout
+		}
+		if hc.MaxQueueSize > 0 {
+			c.MaxQueueSize = hc.MaxQueueSize
+		}
+		if hc.RequestAckEvery > 0 {
+			c.RequestAckEvery = hc.RequestAckEvery
+		}
+		if len(hc.OverflowPolicy) > 0 {
+			c.OverflowPolicy = hc.OverflowPolicy
+		}
+		break
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	policies := []string{c.OverflowPolicy}
+	for _, hc := range c.Hosts {
+		policies = append(policies, hc.OverflowPolicy)
+	}
+	for _, policy := range policies {
+		switch policy {
+		case "", killOverflowPolicy, dropOldestOverflowPolicy:
+		default:
+			return fmt.Errorf("xep0198: unrecognized overflow policy: %s", policy)
+		}
+	}
+	return nil
 }
 
 // Stream represents a stream (XEP-0198) module type.
@@ -91,6 +167,7 @@ type Stream struct {
 	router router.Router
 	hosts  *host.Hosts
 	resMng resourcemanager.Manager
+	rep    repository.StreamQueue
 	hk     *hook.Hooks
 	logger kitlog.Logger
 
@@ -109,6 +186,7 @@ func New(
 	router router.Router,
 	hosts *host.Hosts,
 	resMng resourcemanager.Manager,
+	rep repository.Repository,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *Stream {
@@ -117,6 +195,7 @@ func New(
 		router:         router,
 		hosts:          hosts,
 		resMng:         resMng,
+		rep:            rep,
 		stmQueueMap:    stmQueueMap,
 		clusterConnMng: clusterConnMng,
 		termTms:        make(map[string]*time.Timer),
@@ -146,7 +225,16 @@ func (m *Stream) AccountFeatures(_ context.Context) ([]string, error) {
 }
 
 // Start starts stream module.
-func (m *Stream) Start(_ context.Context) error {
+func (m *Stream) Start(ctx context.Context) error {
+	if err := m.cfg.validate(); err != nil {
+		return err
+	}
+	if m.cfg.PersistQueues {
+		// get rid of queues persisted too long ago to be resumed
+		if err := m.rep.DeleteExpiredStreamQueues(ctx); err != nil {
+			return err
+		}
+	}
 	m.hk.AddHook(hook.C2SStreamElementReceived, m.onElementRecv, hook.DefaultPriority)
 	m.hk.AddHook(hook.C2SStreamElementSent, m.onElementSent, hook.DefaultPriority)
 	m.hk.AddHook(hook.C2SStreamDisconnected, m.onDisconnect, hook.LowestPriority)
@@ -157,12 +245,18 @@ func (m *Stream) Start(_ context.Context) error {
 }
 
 // Stop stops stream module.
-func (m *Stream) Stop(_ context.Context) error {
+func (m *Stream) Stop(ctx context.Context) error {
 	m.hk.RemoveHook(hook.C2SStreamElementReceived, m.onElementRecv)
 	m.hk.RemoveHook(hook.C2SStreamElementSent, m.onElementSent)
 	m.hk.RemoveHook(hook.C2SStreamDisconnected, m.onDisconnect)
 	m.hk.RemoveHook(hook.C2SStreamTerminated, m.onTerminate)
 
+	if m.cfg.PersistQueues {
+		if err := m.persistQueues(ctx); err != nil {
+			return err
+		}
+	}
+
 	level.Info(m.logger).Log("msg", "stopped stream module")
 	return nil
 }
@@ -204,16 +298,30 @@ func (m *Stream) onElementSent(execCtx *hook.ExecutionContext) error {
 	}
 	sq.HandleOut(stanza)
 
+	cfg := m.cfg.forDomain(stm.JID().Domain())
+
 	qLen := sq.Len()
 	switch {
-	case qLen >= m.cfg.MaxQueueSize:
+	case cfg.OverflowPolicy == dropOldestOverflowPolicy && qLen > cfg.MaxQueueSize:
+		sq.DropOldest(qLen - cfg.MaxQueueSize)
+
+		level.Info(m.logger).Log("msg", "max queue size reached, dropped oldest stanza",
+			"id", stm.ID(), "username", stm.Username(), "resource", stm.Resource(),
+		)
+		// queue length remains pinned at its maximum size from now on, so the
+		// periodic ack condition below could never be met again.
+		sq.RequestAck()
+		return nil
+
+	case cfg.OverflowPolicy != dropOldestOverflowPolicy && qLen >= cfg.MaxQueueSize:
 		_ = sq.GetStream().Disconnect(streamerror.E(streamerror.PolicyViolation))
 
 		level.Info(m.logger).Log("msg", "max queue size reached",
 			"id", stm.ID(), "username", stm.Username(), "resource", stm.Resource(),
 		)
-
-	case qLen%unacknowledgedStanzaCount == 0:
+		return nil
+	}
+	if cfg.RequestAckEvery > 0 && qLen%cfg.RequestAckEvery == 0 {
 		sq.RequestAck()
 	}
 	return nil
@@ -242,7 +350,7 @@ func (m *Stream) onDisconnect(execCtx *hook.ExecutionContext) error {
 	}
 	// schedule stream termination
 	m.mu.Lock()
-	m.termTms[inf.ID] = time.AfterFunc(m.cfg.HibernateTime, func() {
+	m.termTms[inf.ID] = time.AfterFunc(m.cfg.forDomain(stm.JID().Domain()).HibernateTime, func() {
 		_ = stm.Disconnect(nil)
 
 		level.Info(m.logger).Log("msg", "hibernated stream terminated",
@@ -319,6 +427,8 @@ func (m *Stream) handleEnable(ctx context.Context, stm stream.C2S) error {
 	if err := stm.SetInfoValue(ctx, enabledInfoKey, true); err != nil {
 		return err
 	}
+	cfg := m.cfg.forDomain(stm.JID().Domain())
+
 	// generate nonce
 	nonce := make([]byte, nonceLength)
 	for i := range nonce {
@@ -331,8 +441,8 @@ func (m *Stream) handleEnable(ctx context.Context, stm stream.C2S) error {
 		nil,
 		0,
 		0,
-		m.cfg.RequestAckInterval,
-		m.cfg.WaitForAckTimeout,
+		cfg.RequestAckInterval,
+		cfg.WaitForAckTimeout,
 	)
 	m.stmQueueMap.Set(queueKey(stm.JID()), sq)
 
@@ -342,6 +452,7 @@ func (m *Stream) handleEnable(ctx context.Context, stm stream.C2S) error {
 		WithAttribute(stravaganza.Namespace, streamNamespace).
 		WithAttribute("id", smID).
 		WithAttribute("resume", "true").
+		WithAttribute("max", strconv.Itoa(int(cfg.HibernateTime.Seconds()))).
 		Build(),
 	)
 	level.Info(m.logger).Log("msg", "enabled stream management",
@@ -365,15 +476,23 @@ func (m *Stream) handleResume(ctx context.Context, stm stream.C2S, h uint32, pre
 	if err != nil {
 		return err
 	}
-	if res == nil {
-		sendFailedReply(itemNotFound, "", stm)
-		return nil
-	}
 	var sq *streamqueue.Queue
 
 	qk := queueKey(jd)
 
-	if res.InstanceID() == instance.ID() { // local retained queue
+	switch {
+	case res == nil: // queue persisted before last server shutdown
+		sq, res, err = m.restoreQueue(ctx, stm, qk, nonce)
+		if err != nil {
+			return err
+		}
+		if sq == nil {
+			sendFailedReply(itemNotFound, "", stm)
+			return nil
+		}
+		level.Info(m.logger).Log("msg", "stream queue restored", "key", qk)
+
+	case res.InstanceID() == instance.ID(): // local retained queue
 		sq = m.stmQueueMap.Get(qk)
 		if sq == nil {
 			sendFailedReply(itemNotFound, "", stm)
@@ -386,7 +505,7 @@ func (m *Stream) handleResume(ctx context.Context, stm stream.C2S, h uint32, pre
 		// set new stream
 		sq.SetStream(stm)
 
-	} else { // transfer retained queue from internal cluster instance
+	default: // transfer retained queue from internal cluster instance
 		conn, err := m.clusterConnMng.GetConnection(res.InstanceID())
 		if err != nil {
 			return err
@@ -395,14 +514,16 @@ func (m *Stream) handleResume(ctx context.Context, stm stream.C2S, h uint32, pre
 		if err != nil {
 			return err
 		}
+		cfg := m.cfg.forDomain(jd.Domain())
+
 		sq = streamqueue.New(
 			stm,
 			resp.Nonce,
 			resp.Elements,
 			resp.InH,
 			resp.OutH,
-			m.cfg.RequestAckInterval,
-			m.cfg.WaitForAckTimeout,
+			cfg.RequestAckInterval,
+			cfg.WaitForAckTimeout,
 		)
 
 		level.Info(m.logger).Log(
@@ -439,6 +560,97 @@ func (m *Stream) handleResume(ctx context.Context, stm stream.C2S, h uint32, pre
 	return nil
 }
 
+func (m *Stream) persistQueues(ctx context.Context) error {
+	var count int
+	for _, sq := range m.stmQueueMap.All() {
+		stm := sq.GetStream()
+
+		cfg := m.cfg.forDomain(stm.JID().Domain())
+
+		elements := sq.Elements()
+		if len(elements) > cfg.PersistMaxElements {
+			elements = elements[len(elements)-cfg.PersistMaxElements:]
+		}
+		q := &streamqueuemodel.Queue{
+			Jid:       queueKey(stm.JID()),
+			Nonce:     sq.Nonce(),
+			InH:       sq.InboundH(),
+			OutH:      sq.OutboundH(),
+			Info:      stm.Info().Map(),
+			ExpiresAt: timestamppb.New(time.Now().Add(cfg.HibernateTime)),
+		}
+		for _, elem := range elements {
+			q.Elements = append(q.Elements, &streamqueuemodel.Element{
+				Stanza: elem.Stanza.Proto(),
+				H:      elem.H,
+			})
+		}
+		if pr := stm.Presence(); pr != nil {
+			q.Presence = pr.Proto()
+		}
+		if err := m.rep.UpsertStreamQueue(ctx, q); err != nil {
+			return err
+		}
+		count++
+	}
+	level.Info(m.logger).Log("msg", "persisted stream queues", "count", count)
+	return nil
+}
+
+func (m *Stream) restoreQueue(ctx context.Context, stm stream.C2S, qk string, nonce []byte) (*streamqueue.Queue, c2smodel.ResourceDesc, error) {
+	if !m.cfg.PersistQueues {
+		return nil, nil, nil
+	}
+	q, err := m.rep.FetchStreamQueue(ctx, qk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if q == nil || !bytes.Equal(q.Nonce, nonce) {
+		return nil, nil, nil
+	}
+	// a persisted queue can only be resumed once
+	if err := m.rep.DeleteStreamQueue(ctx, qk); err != nil {
+		return nil, nil, err
+	}
+	if q.ExpiresAt.AsTime().Before(time.Now()) {
+		return nil, nil, nil
+	}
+	jd, err := jid.NewWithString(q.Jid, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	elements := make([]streamqueue.Element, 0, len(q.Elements))
+	for _, elem := range q.Elements {
+		stanza, err := stravaganza.NewBuilderFromProto(elem.GetStanza()).BuildStanza()
+		if err != nil {
+			return nil, nil, err
+		}
+		elements = append(elements, streamqueue.Element{
+			Stanza: stanza,
+			H:      elem.GetH(),
+		})
+	}
+	var pr *stravaganza.Presence
+	if q.Presence != nil {
+		pr, err = stravaganza.NewBuilderFromProto(q.Presence).BuildPresence()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	cfg := m.cfg.forDomain(jd.Domain())
+
+	sq := streamqueue.New(
+		stm,
+		q.Nonce,
+		elements,
+		q.InH,
+		q.OutH,
+		cfg.RequestAckInterval,
+		cfg.WaitForAckTimeout,
+	)
+	return sq, c2smodel.NewResourceDesc(instance.ID(), jd, pr, c2smodel.NewInfoMapFromMap(q.Info)), nil
+}
+
 func (m *Stream) handleA(stm stream.C2S, h uint32) {
 	sq := m.stmQueueMap.Get(queueKey(stm.JID()))
 	if sq == nil {
@@ -492,6 +704,11 @@ func sendFailedReply(reason string, text string, stm stream.C2S) {
 	_ = stm.SendElement(sb.Build())
 }
 
+// IsEnabled tells whether stream management has been enabled over a C2S stream by inspecting inf parameter.
+func IsEnabled(inf c2smodel.Info) bool {
+	return inf.Bool(enabledInfoKey)
+}
+
 func encodeSMID(jd *jid.JID, nonce []byte) string {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteString(jd.String())
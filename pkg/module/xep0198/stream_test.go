@@ -16,7 +16,9 @@ package xep0198
 
 import (
 	"context"
+	"io"
 	"math/rand"
+	"strconv"
 	"testing"
 	"time"
 
@@ -31,10 +33,12 @@ import (
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
 	streamqueue "github.com/ortuman/jackal/pkg/module/xep0198/queue"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestStream_EncodeSMID(t *testing.T) {
@@ -319,6 +323,115 @@ func TestStream_OutStanzaMaxQueueSizeReached(t *testing.T) {
 	require.Equal(t, streamerror.PolicyViolation, streamErr.Reason)
 }
 
+func TestStream_OutStanzaMaxQueueSizeDropOldest(t *testing.T) {
+	// given
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1234 }
+	stmMock.JIDFunc = func() *jid.JID { return jd }
+	stmMock.UsernameFunc = func() string { return jd.Node() }
+	stmMock.ResourceFunc = func() string { return jd.Resource() }
+	stmMock.InfoFunc = func() c2smodel.Info {
+		return c2smodel.NewInfoMapFromMap(
+			map[string]string{enabledInfoKey: "true"},
+		)
+	}
+	var sentElements []stravaganza.Element
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sentElements = append(sentElements, elem)
+		return nil
+	}
+	cfg := testSMConfig()
+	cfg.Hosts = []HostConfig{
+		{Domain: "jackal.im", MaxQueueSize: 2, OverflowPolicy: dropOldestOverflowPolicy, RequestAckEvery: 5},
+	}
+	hk := hook.NewHooks()
+	sm := &Stream{
+		cfg:         cfg,
+		stmQueueMap: streamqueue.NewQueueMap(),
+		hk:          hk,
+		logger:      kitlog.NewNopLogger(),
+	}
+	var msgs []*stravaganza.Message
+	for i := 0; i < 3; i++ {
+		msg, _ := stravaganza.NewMessageBuilder().
+			WithAttribute("from", "ortuman@jackal.im/yard").
+			WithAttribute("to", "noelia@jackal.im/yard").
+			WithAttribute("id", strconv.Itoa(i)).
+			BuildMessage()
+		msgs = append(msgs, msg)
+	}
+	sq := streamqueue.New(
+		stmMock, nil, nil, 0, 0, time.Second, time.Minute,
+	)
+	sm.stmQueueMap.Set(queueKey(jd), sq)
+
+	sq.CancelTimers() // do not send R
+	defer sq.CancelTimers()
+
+	// when
+	_ = sm.Start(context.Background())
+	defer func() { _ = sm.Stop(context.Background()) }()
+
+	for _, msg := range msgs {
+		_, err := hk.Run(hook.C2SStreamElementSent, &hook.ExecutionContext{
+			Info:    &hook.C2SStreamInfo{Element: msg},
+			Sender:  stmMock,
+			Context: context.Background(),
+		})
+		require.Nil(t, err)
+	}
+
+	// then
+	require.Len(t, stmMock.DisconnectCalls(), 0)
+
+	require.Equal(t, 2, sq.Len())
+	require.Equal(t, msgs[1], sq.Elements()[0].Stanza)
+	require.Equal(t, uint32(2), sq.Elements()[0].H)
+	require.Equal(t, msgs[2], sq.Elements()[1].Stanza)
+	require.Equal(t, uint32(3), sq.Elements()[1].H)
+
+	require.Len(t, sentElements, 1)
+	require.Equal(t, "r", sentElements[0].Name())
+}
+
+func TestStream_InvalidOverflowPolicy(t *testing.T) {
+	// given
+	cfg := testSMConfig()
+	cfg.Hosts = []HostConfig{
+		{Domain: "jackal.im", OverflowPolicy: "drop_olderst"},
+	}
+	sm := &Stream{cfg: cfg}
+
+	// when
+	err := sm.Start(context.Background())
+
+	// then
+	require.NotNil(t, err)
+}
+
+func TestStream_HostConfig(t *testing.T) {
+	// given
+	cfg := testSMConfig()
+	cfg.Hosts = []HostConfig{
+		{Domain: "jackal.im", HibernateTime: time.Hour, RequestAckEvery: 5},
+	}
+
+	// when
+	hCfg := cfg.forDomain("jackal.im")
+	dCfg := cfg.forDomain("example.org")
+
+	// then
+	require.Equal(t, time.Hour, hCfg.HibernateTime)
+	require.Equal(t, 5, hCfg.RequestAckEvery)
+	require.Equal(t, cfg.MaxQueueSize, hCfg.MaxQueueSize)
+	require.Equal(t, cfg.WaitForAckTimeout, hCfg.WaitForAckTimeout)
+
+	require.Equal(t, time.Minute, dCfg.HibernateTime)
+	require.Equal(t, 0, dCfg.RequestAckEvery)
+}
+
 func TestStream_SendR(t *testing.T) {
 	// given
 	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
@@ -515,6 +628,77 @@ func TestStream_HandleA(t *testing.T) {
 	require.Equal(t, uid3, sentEl.Attribute("id"))
 }
 
+func TestStream_HibernateOnDisconnect(t *testing.T) {
+	var tests = []struct {
+		name            string
+		disconnectErr   error
+		expectHibernate bool
+	}{
+		{name: "PingTimeout", disconnectErr: nil, expectHibernate: true},
+		{name: "ConnectionLost", disconnectErr: io.EOF, expectHibernate: true},
+		{name: "StreamError", disconnectErr: streamerror.E(streamerror.ConnectionTimeout), expectHibernate: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+			stmMock := &c2sStreamMock{}
+			stmMock.IDFunc = func() stream.C2SID { return 1234 }
+			stmMock.JIDFunc = func() *jid.JID { return jd }
+			stmMock.UsernameFunc = func() string { return jd.Node() }
+			stmMock.ResourceFunc = func() string { return jd.Resource() }
+			stmMock.InfoFunc = func() c2smodel.Info {
+				return c2smodel.NewInfoMapFromMap(
+					map[string]string{enabledInfoKey: "true"},
+				)
+			}
+
+			hk := hook.NewHooks()
+			sm := &Stream{
+				cfg:         testSMConfig(),
+				stmQueueMap: streamqueue.NewQueueMap(),
+				hk:          hk,
+				termTms:     make(map[string]*time.Timer),
+				logger:      kitlog.NewNopLogger(),
+			}
+			sq := streamqueue.New(
+				stmMock, testNonce(), nil, 0, 0, time.Second, time.Minute,
+			)
+			sm.stmQueueMap.Set(queueKey(jd), sq)
+			defer sq.CancelTimers()
+
+			_ = sm.Start(context.Background())
+			defer func() { _ = sm.Stop(context.Background()) }()
+
+			// when
+			halted, err := hk.Run(hook.C2SStreamDisconnected, &hook.ExecutionContext{
+				Info: &hook.C2SStreamInfo{
+					ID:              "c2s:1234",
+					JID:             jd,
+					Presence:        xmpputil.MakePresence(jd, jd.ToBareJID(), stravaganza.AvailableType, nil),
+					DisconnectError: tt.disconnectErr,
+				},
+				Sender:  stmMock,
+				Context: context.Background(),
+			})
+
+			// then
+			require.Nil(t, err)
+			require.Equal(t, tt.expectHibernate, halted)
+
+			sm.mu.Lock()
+			tm := sm.termTms["c2s:1234"]
+			sm.mu.Unlock()
+			require.Equal(t, tt.expectHibernate, tm != nil)
+			if tm != nil {
+				tm.Stop()
+			}
+			require.NotNil(t, sm.stmQueueMap.Get(queueKey(jd))) // still resumable
+		})
+	}
+}
+
 func TestStream_Resume(t *testing.T) {
 	// given
 	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
@@ -743,6 +927,213 @@ func TestStream_ResumeRemote(t *testing.T) {
 	require.Equal(t, msgID, sndElements[1].Attribute(stravaganza.ID))
 }
 
+func TestStream_PersistQueues(t *testing.T) {
+	// given
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+	stmMock := &c2sStreamMock{}
+	stmMock.JIDFunc = func() *jid.JID { return jd }
+	stmMock.InfoFunc = func() c2smodel.Info {
+		return c2smodel.NewInfoMapFromMap(map[string]string{enabledInfoKey: "true"})
+	}
+	stmMock.PresenceFunc = func() *stravaganza.Presence {
+		return xmpputil.MakePresence(jd, jd.ToBareJID(), stravaganza.AvailableType, nil)
+	}
+
+	var persisted []*streamqueuemodel.Queue
+	repMock := &repositoryMock{}
+	repMock.DeleteExpiredStreamQueuesFunc = func(ctx context.Context) error { return nil }
+	repMock.UpsertStreamQueueFunc = func(ctx context.Context, queue *streamqueuemodel.Queue) error {
+		persisted = append(persisted, queue)
+		return nil
+	}
+
+	elements := []streamqueue.Element{
+		{Stanza: testMessage("m1"), H: 21},
+		{Stanza: testMessage("m2"), H: 22},
+		{Stanza: testMessage("m3"), H: 23},
+	}
+	nc := testNonce()
+	sq := streamqueue.New(
+		stmMock, nc, elements, 10, 23, time.Second, time.Minute,
+	)
+	sq.CancelTimers()
+
+	cfg := testSMConfig()
+	cfg.PersistQueues = true
+	cfg.PersistMaxElements = 2
+
+	sm := &Stream{
+		cfg:         cfg,
+		rep:         repMock,
+		stmQueueMap: streamqueue.NewQueueMap(),
+		hk:          hook.NewHooks(),
+		logger:      kitlog.NewNopLogger(),
+	}
+	sm.stmQueueMap.Set(queueKey(jd), sq)
+
+	// when
+	require.NoError(t, sm.Start(context.Background()))
+	require.NoError(t, sm.Stop(context.Background()))
+
+	// then
+	require.Len(t, repMock.DeleteExpiredStreamQueuesCalls(), 1)
+	require.Len(t, persisted, 1)
+
+	q := persisted[0]
+	require.Equal(t, "ortuman@jackal.im/yard", q.Jid)
+	require.Equal(t, nc, q.Nonce)
+	require.Equal(t, uint32(10), q.InH)
+	require.Equal(t, uint32(23), q.OutH)
+	require.Equal(t, "true", q.Info[enabledInfoKey])
+	require.NotNil(t, q.Presence)
+
+	require.Len(t, q.Elements, 2)
+	require.Equal(t, uint32(22), q.Elements[0].H)
+	require.Equal(t, uint32(23), q.Elements[1].H)
+}
+
+func TestStream_ResumePersisted(t *testing.T) {
+	// given
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IsAuthenticatedFunc = func() bool { return true }
+	stmMock.IDFunc = func() stream.C2SID { return 1234 }
+	stmMock.JIDFunc = func() *jid.JID { return jd }
+	stmMock.UsernameFunc = func() string { return jd.Node() }
+	stmMock.ResourceFunc = func() string { return jd.Resource() }
+
+	sndElements := make([]stravaganza.Element, 0)
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sndElements = append(sndElements, elem)
+		return nil
+	}
+	var resumedInf c2smodel.Info
+	stmMock.ResumeFunc = func(ctx context.Context, jd *jid.JID, pr *stravaganza.Presence, inf c2smodel.Info) error {
+		resumedInf = inf
+		return nil
+	}
+
+	resMngMock := &resourceManagerMock{}
+	resMngMock.GetResourceFunc = func(ctx context.Context, username string, resource string) (c2smodel.ResourceDesc, error) {
+		return nil, nil // resource was lost on server restart
+	}
+
+	nc := testNonce()
+	testMsg := testMessage("m1")
+
+	repMock := &repositoryMock{}
+	repMock.FetchStreamQueueFunc = func(ctx context.Context, jid string) (*streamqueuemodel.Queue, error) {
+		return &streamqueuemodel.Queue{
+			Jid:   "ortuman@jackal.im/yard",
+			Nonce: nc,
+			InH:   10,
+			OutH:  22,
+			Elements: []*streamqueuemodel.Element{
+				{Stanza: testMsg.Proto(), H: 22},
+			},
+			Info:      map[string]string{enabledInfoKey: "true"},
+			ExpiresAt: timestamppb.New(time.Now().Add(time.Minute)),
+		}, nil
+	}
+	repMock.DeleteStreamQueueFunc = func(ctx context.Context, jid string) error { return nil }
+
+	cfg := testSMConfig()
+	cfg.PersistQueues = true
+
+	sm := &Stream{
+		cfg:         cfg,
+		resMng:      resMngMock,
+		rep:         repMock,
+		stmQueueMap: streamqueue.NewQueueMap(),
+		hk:          hook.NewHooks(),
+		logger:      kitlog.NewNopLogger(),
+	}
+	smID := encodeSMID(jd, nc)
+
+	// when
+	err := sm.processCmd(context.Background(), stravaganza.NewBuilder("resume").
+		WithAttribute(stravaganza.Namespace, streamNamespace).
+		WithAttribute("previd", smID).
+		WithAttribute("h", "21").
+		Build(), stmMock)
+
+	// then
+	require.NoError(t, err)
+
+	require.Len(t, repMock.DeleteStreamQueueCalls(), 1)
+	require.True(t, resumedInf.Bool(enabledInfoKey))
+
+	require.Len(t, sndElements, 2)
+	require.Equal(t, "resumed", sndElements[0].Name())
+	require.Equal(t, "10", sndElements[0].Attribute("h"))
+	require.Equal(t, "m1", sndElements[1].Attribute(stravaganza.ID))
+
+	sq := sm.stmQueueMap.Get(queueKey(jd))
+	require.NotNil(t, sq)
+	sq.CancelTimers()
+}
+
+func TestStream_ResumePersistedExpired(t *testing.T) {
+	// given
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IsAuthenticatedFunc = func() bool { return true }
+	stmMock.JIDFunc = func() *jid.JID { return jd }
+
+	var sentEl stravaganza.Element
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sentEl = elem
+		return nil
+	}
+
+	resMngMock := &resourceManagerMock{}
+	resMngMock.GetResourceFunc = func(ctx context.Context, username string, resource string) (c2smodel.ResourceDesc, error) {
+		return nil, nil
+	}
+
+	nc := testNonce()
+
+	repMock := &repositoryMock{}
+	repMock.FetchStreamQueueFunc = func(ctx context.Context, jid string) (*streamqueuemodel.Queue, error) {
+		return &streamqueuemodel.Queue{
+			Jid:       "ortuman@jackal.im/yard",
+			Nonce:     nc,
+			ExpiresAt: timestamppb.New(time.Now().Add(-time.Minute)),
+		}, nil
+	}
+	repMock.DeleteStreamQueueFunc = func(ctx context.Context, jid string) error { return nil }
+
+	cfg := testSMConfig()
+	cfg.PersistQueues = true
+
+	sm := &Stream{
+		cfg:         cfg,
+		resMng:      resMngMock,
+		rep:         repMock,
+		stmQueueMap: streamqueue.NewQueueMap(),
+		hk:          hook.NewHooks(),
+		logger:      kitlog.NewNopLogger(),
+	}
+
+	// when
+	err := sm.processCmd(context.Background(), stravaganza.NewBuilder("resume").
+		WithAttribute(stravaganza.Namespace, streamNamespace).
+		WithAttribute("previd", encodeSMID(jd, nc)).
+		WithAttribute("h", "21").
+		Build(), stmMock)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, repMock.DeleteStreamQueueCalls(), 1)
+
+	require.NotNil(t, sentEl)
+	require.Equal(t, "failed", sentEl.Name())
+	require.NotNil(t, sentEl.Child(itemNotFound))
+}
+
 func testSMConfig() Config {
 	return Config{
 		HibernateTime:      time.Minute,
@@ -759,3 +1150,17 @@ func testNonce() []byte {
 	}
 	return nonce
 }
+
+func testMessage(id string) *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, id).
+		WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("I'll give thee a wind.").
+				Build(),
+		).
+		BuildMessage()
+	return msg
+}
@@ -33,3 +33,8 @@ type globalRouter interface {
 type c2sRouter interface {
 	router.C2SRouter
 }
+
+//go:generate moq -out hosts.mock_test.go . hosts
+type hosts interface {
+	IsMobileProfile(h string) bool
+}
@@ -16,6 +16,7 @@ package xep0199
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -29,6 +30,8 @@ import (
 	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/module/xep0198"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
@@ -47,7 +50,15 @@ const (
 const (
 	modRequestTimeout = time.Second * 5
 
-	killAction = "kill"
+	killAction      = "kill"
+	hibernateAction = "hibernate"
+
+	iqMode         = "iq"
+	whitespaceMode = "whitespace"
+
+	// mobile profile hosts are pinged less often, allowing more time for radio wake up.
+	mobileInterval   = time.Minute * 5
+	mobileAckTimeout = time.Second * 90
 )
 
 // Config contains ping module configuration options.
@@ -59,13 +70,17 @@ type Config struct {
 	// SendPings tells whether server pings should be sent.
 	SendPings bool `fig:"send_pings"`
 	// TimeoutAction specifies the action to be taken when a client is considered as disconnected.
+	// Using 'hibernate', stream management enabled sessions are kept for resumption instead of being terminated.
 	TimeoutAction string `fig:"timeout_action" default:"none"`
+	// Mode tells whether clients should be probed using XEP-0199 pings (iq) or whitespace keepalives (whitespace).
+	Mode string `fig:"mode" default:"iq"`
 }
 
 // Ping represents ping (XEP-0199) module type.
 type Ping struct {
 	cfg    Config
 	router router.Router
+	hosts  hosts
 	hk     *hook.Hooks
 	logger kitlog.Logger
 
@@ -75,10 +90,11 @@ type Ping struct {
 }
 
 // New returns a new initialized ping instance.
-func New(cfg Config, router router.Router, hk *hook.Hooks, logger kitlog.Logger) *Ping {
+func New(cfg Config, router router.Router, hosts *host.Hosts, hk *hook.Hooks, logger kitlog.Logger) *Ping {
 	return &Ping{
 		cfg:        cfg,
 		router:     router,
+		hosts:      hosts,
 		hk:         hk,
 		logger:     kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
 		pingTimers: make(map[string]*time.Timer),
@@ -106,6 +122,12 @@ func (p *Ping) AccountFeatures(_ context.Context) ([]string, error) {
 
 // Start starts ping module.
 func (p *Ping) Start(_ context.Context) error {
+	switch p.cfg.Mode {
+	case "", iqMode, whitespaceMode:
+		break
+	default:
+		return fmt.Errorf("xep0199: unrecognized ping mode: %s", p.cfg.Mode)
+	}
 	if p.cfg.SendPings {
 		p.hk.AddHook(hook.C2SStreamBinded, p.onBinded, hook.DefaultPriority)
 		p.hk.AddHook(hook.C2SStreamDisconnected, p.onDisconnect, hook.HighestPriority)
@@ -175,13 +197,17 @@ func (p *Ping) onDisconnect(execCtx *hook.ExecutionContext) error {
 
 func (p *Ping) schedulePing(jd *jid.JID) {
 	p.mu.Lock()
-	p.pingTimers[jd.String()] = time.AfterFunc(p.cfg.Interval, func() {
+	p.pingTimers[jd.String()] = time.AfterFunc(p.interval(jd.Domain()), func() {
 		p.sendPing(jd)
 	})
 	p.mu.Unlock()
 }
 
 func (p *Ping) sendPing(jd *jid.JID) {
+	if p.cfg.Mode == whitespaceMode {
+		p.sendWhitespacePing(jd)
+		return
+	}
 	iq, _ := stravaganza.NewIQBuilder().
 		WithAttribute(stravaganza.ID, uuid.New().String()).
 		WithAttribute(stravaganza.Type, stravaganza.GetType).
@@ -202,7 +228,7 @@ func (p *Ping) sendPing(jd *jid.JID) {
 
 	// schedule ack timeout
 	p.mu.Lock()
-	p.ackTimers[jd.String()] = time.AfterFunc(p.cfg.AckTimeout, func() {
+	p.ackTimers[jd.String()] = time.AfterFunc(p.ackTimeout(jd.Domain()), func() {
 		p.timeout(jd)
 	})
 	p.mu.Unlock()
@@ -210,6 +236,20 @@ func (p *Ping) sendPing(jd *jid.JID) {
 	level.Info(p.logger).Log("msg", "sent ping", "jid", jd.String())
 }
 
+func (p *Ping) sendWhitespacePing(jd *jid.JID) {
+	stm, _ := p.router.C2S().LocalStream(jd.Node(), jd.Resource())
+	if stm == nil {
+		return
+	}
+	// whitespace keepalives are not answered, so only a failed write is taken as a timeout
+	if err := <-stm.SendWhitespace(); err != nil {
+		level.Info(p.logger).Log("msg", "failed to send whitespace ping", "jid", jd.String(), "err", err)
+		p.timeout(jd)
+		return
+	}
+	p.schedulePing(jd)
+}
+
 func (p *Ping) timeout(jd *jid.JID) {
 	// perform timeout action
 	switch p.cfg.TimeoutAction {
@@ -218,6 +258,20 @@ func (p *Ping) timeout(jd *jid.JID) {
 		if stm != nil {
 			_ = stm.Disconnect(streamerror.E(streamerror.ConnectionTimeout))
 		}
+
+	case hibernateAction:
+		stm, _ := p.router.C2S().LocalStream(jd.Node(), jd.Resource())
+		if stm == nil {
+			break
+		}
+		if xep0198.IsEnabled(stm.Info()) {
+			// a disconnection not caused by a stream error keeps the session resumable
+			_ = stm.Disconnect(nil)
+
+			level.Info(p.logger).Log("msg", "stream hibernated on timeout", "jid", jd.String())
+			return
+		}
+		_ = stm.Disconnect(streamerror.E(streamerror.ConnectionTimeout))
 	}
 	level.Info(p.logger).Log("msg", "stream timeout", "jid", jd.String())
 }
@@ -236,6 +290,20 @@ func (p *Ping) cancelTimers(jd *jid.JID) {
 	p.mu.Unlock()
 }
 
+func (p *Ping) interval(domain string) time.Duration {
+	if p.hosts.IsMobileProfile(domain) && p.cfg.Interval < mobileInterval {
+		return mobileInterval
+	}
+	return p.cfg.Interval
+}
+
+func (p *Ping) ackTimeout(domain string) time.Duration {
+	if p.hosts.IsMobileProfile(domain) && p.cfg.AckTimeout < mobileAckTimeout {
+		return mobileAckTimeout
+	}
+	return p.cfg.AckTimeout
+}
+
 func isPingIQ(iq *stravaganza.IQ) bool {
 	return iq.IsGet() && iq.ChildNamespace("ping", pingNamespace) != nil
 }
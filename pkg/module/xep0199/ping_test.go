@@ -27,6 +27,7 @@ import (
 	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
+	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/stretchr/testify/require"
@@ -41,7 +42,7 @@ func TestPing_Pong(t *testing.T) {
 		_ = stanza.ToXML(outBuf, true)
 		return nil, nil
 	}
-	p := New(Config{}, routerMock, &hook.Hooks{}, kitlog.NewNopLogger())
+	p := testPing(Config{}, routerMock, &hook.Hooks{}, false)
 
 	// when
 	iq, _ := stravaganza.NewIQBuilder().
@@ -74,10 +75,10 @@ func TestPing_SendPing(t *testing.T) {
 		return nil, nil
 	}
 	hk := hook.NewHooks()
-	p := New(Config{
+	p := testPing(Config{
 		Interval:  time.Millisecond * 500,
 		SendPings: true,
-	}, routerMock, hk, kitlog.NewNopLogger())
+	}, routerMock, hk, false)
 	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
 
 	// when
@@ -119,12 +120,12 @@ func TestPing_Timeout(t *testing.T) {
 	}
 
 	hk := hook.NewHooks()
-	p := New(Config{
+	p := testPing(Config{
 		Interval:      time.Millisecond * 500,
 		AckTimeout:    time.Millisecond * 250,
 		SendPings:     true,
 		TimeoutAction: killAction,
-	}, routerMock, hk, kitlog.NewNopLogger())
+	}, routerMock, hk, false)
 	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
 
 	// when
@@ -141,3 +142,129 @@ func TestPing_Timeout(t *testing.T) {
 	// then
 	require.Len(t, c2sStream.DisconnectCalls(), 1)
 }
+
+func TestPing_TimeoutHibernate(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		return nil, nil
+	}
+	c2sStream := &streamMock{}
+	c2sStream.InfoFunc = func() c2smodel.Info {
+		return c2smodel.NewInfoMapFromMap(map[string]string{"xep0198:enabled": "true"})
+	}
+	c2sStream.DisconnectFunc = func(streamErr *streamerror.Error) <-chan error {
+		return nil
+	}
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return c2sStream, nil
+	}
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+
+	hk := hook.NewHooks()
+	p := testPing(Config{
+		Interval:      time.Millisecond * 500,
+		AckTimeout:    time.Millisecond * 250,
+		SendPings:     true,
+		TimeoutAction: hibernateAction,
+	}, routerMock, hk, false)
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+	// when
+	_ = p.Start(context.Background())
+	_, _ = hk.Run(hook.C2SStreamBinded, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			ID:  "c2s1",
+			JID: jd,
+		},
+		Context: context.Background(),
+	})
+	time.Sleep(time.Second) // wait until ping is triggered
+
+	// then
+	require.Len(t, c2sStream.DisconnectCalls(), 1)
+	require.Nil(t, c2sStream.DisconnectCalls()[0].StreamErr)
+}
+
+func TestPing_WhitespacePing(t *testing.T) {
+	// given
+	var mu sync.Mutex
+	var sent int
+
+	c2sStream := &streamMock{}
+	c2sStream.SendWhitespaceFunc = func() <-chan error {
+		mu.Lock()
+		sent++
+		mu.Unlock()
+
+		errCh := make(chan error, 1)
+		errCh <- nil
+		return errCh
+	}
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return c2sStream, nil
+	}
+	routerMock := &routerMock{}
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+
+	hk := hook.NewHooks()
+	p := testPing(Config{
+		Interval:  time.Millisecond * 300,
+		SendPings: true,
+		Mode:      whitespaceMode,
+	}, routerMock, hk, false)
+	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+
+	// when
+	_ = p.Start(context.Background())
+	_, _ = hk.Run(hook.C2SStreamBinded, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			ID:  "c2s1",
+			JID: jd,
+		},
+		Context: context.Background(),
+	})
+	time.Sleep(time.Second) // wait until some pings are triggered
+
+	p.cancelTimers(jd)
+
+	// then
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.GreaterOrEqual(t, sent, 2)
+	require.Len(t, routerMock.RouteCalls(), 0)
+}
+
+func TestPing_MobileProfile(t *testing.T) {
+	// given
+	p := testPing(Config{
+		Interval:   time.Minute,
+		AckTimeout: time.Second * 32,
+	}, &routerMock{}, hook.NewHooks(), true)
+
+	// then
+	require.Equal(t, mobileInterval, p.interval("jackal.im"))
+	require.Equal(t, mobileAckTimeout, p.ackTimeout("jackal.im"))
+}
+
+func testPing(cfg Config, router *routerMock, hk *hook.Hooks, mobile bool) *Ping {
+	hostsMock := &hostsMock{}
+	hostsMock.IsMobileProfileFunc = func(_ string) bool { return mobile }
+
+	return &Ping{
+		cfg:        cfg,
+		router:     router,
+		hosts:      hostsMock,
+		hk:         hk,
+		logger:     kitlog.NewNopLogger(),
+		pingTimers: make(map[string]*time.Timer),
+		ackTimers:  make(map[string]*time.Timer),
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0215
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/ortuman/jackal/pkg/router"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+)
+
+const (
+	extDiscoNamespace = "urn:xmpp:extdisco:2"
+
+	expiresTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+const (
+	// ModuleName represents external service discovery module name.
+	ModuleName = "extdisco"
+
+	// XEPNumber represents external service discovery XEP number.
+	XEPNumber = "0215"
+)
+
+// Config contains external service discovery module configuration options.
+type Config struct {
+	// Services defines the set of announced external services.
+	Services []ServiceConfig `fig:"services"`
+
+	// Secret is the shared secret used to generate time-limited credentials (coturn's static-auth-secret).
+	Secret string `fig:"secret"`
+
+	// SecretFile is the path to a file containing the shared secret. It takes precedence over Secret,
+	// and it's read again whenever modified, allowing secret rotation (e.g. when rendered by a Vault agent).
+	SecretFile string `fig:"secret_file"`
+
+	// CredentialsTTL defines generated credentials validity period.
+	CredentialsTTL time.Duration `fig:"credentials_ttl" default:"24h"`
+}
+
+// ServiceConfig contains an external service configuration.
+type ServiceConfig struct {
+	// Type is the service type. Valid values are `stun`, `stuns`, `turn` and `turns`.
+	Type string `fig:"type"`
+
+	// Host is the service host name or IP address.
+	Host string `fig:"host"`
+
+	// Port is the service port.
+	Port int `fig:"port"`
+
+	// Transport is the underlying transport protocol (`udp` or `tcp`).
+	Transport string `fig:"transport"`
+
+	// Name is the service human readable name.
+	Name string `fig:"name"`
+
+	// Restricted tells whether time-limited credentials are required to use the service.
+	Restricted bool `fig:"restricted"`
+}
+
+// ExtDisco represents an external service discovery (XEP-0215) module type.
+type ExtDisco struct {
+	cfg    Config
+	router router.Router
+	logger kitlog.Logger
+
+	mu          sync.Mutex
+	secret      string
+	secretMTime time.Time
+}
+
+// New returns a new initialized ExtDisco instance.
+func New(cfg Config, router router.Router, logger kitlog.Logger) *ExtDisco {
+	return &ExtDisco{
+		cfg:    cfg,
+		router: router,
+		secret: cfg.Secret,
+		logger: kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+	}
+}
+
+// Name returns external service discovery module name.
+func (m *ExtDisco) Name() string { return ModuleName }
+
+// StreamFeature returns external service discovery module stream feature.
+func (m *ExtDisco) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns external service discovery server disco features.
+func (m *ExtDisco) ServerFeatures(_ context.Context) ([]string, error) {
+	return []string{extDiscoNamespace}, nil
+}
+
+// AccountFeatures returns external service discovery account disco features.
+func (m *ExtDisco) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// MatchesNamespace tells whether namespace matches external service discovery module.
+func (m *ExtDisco) MatchesNamespace(namespace string, serverTarget bool) bool {
+	return serverTarget && namespace == extDiscoNamespace
+}
+
+// ProcessIQ process an external service discovery iq.
+func (m *ExtDisco) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	if !iq.IsGet() {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	if services := iq.ChildNamespace("services", extDiscoNamespace); services != nil {
+		return m.getServices(ctx, iq, services)
+	}
+	if credentials := iq.ChildNamespace("credentials", extDiscoNamespace); credentials != nil {
+		return m.getCredentials(ctx, iq, credentials)
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+	return nil
+}
+
+// Start starts external service discovery module.
+func (m *ExtDisco) Start(_ context.Context) error {
+	var restricted bool
+	for _, svc := range m.cfg.Services {
+		switch svc.Type {
+		case "stun", "stuns", "turn", "turns":
+		default:
+			return fmt.Errorf("xep0215: unrecognized service type: %s", svc.Type)
+		}
+		if len(svc.Host) == 0 {
+			return errors.New("xep0215: service host must be set")
+		}
+		restricted = restricted || svc.Restricted
+	}
+	if restricted && len(m.cfg.Secret) == 0 && len(m.cfg.SecretFile) == 0 {
+		return errors.New("xep0215: secret or secret_file must be set for restricted services")
+	}
+	if len(m.cfg.SecretFile) > 0 {
+		if _, err := m.sharedSecret(); err != nil {
+			return err
+		}
+	}
+	level.Info(m.logger).Log("msg", "started extdisco module", "services", len(m.cfg.Services))
+	return nil
+}
+
+// Stop stops external service discovery module.
+func (m *ExtDisco) Stop(_ context.Context) error {
+	level.Info(m.logger).Log("msg", "stopped extdisco module")
+	return nil
+}
+
+func (m *ExtDisco) getServices(ctx context.Context, iq *stravaganza.IQ, services stravaganza.Element) error {
+	typ := services.Attribute("type")
+
+	sb := stravaganza.NewBuilder("services").
+		WithAttribute(stravaganza.Namespace, extDiscoNamespace)
+	if len(typ) > 0 {
+		sb.WithAttribute("type", typ)
+	}
+	for _, svc := range m.cfg.Services {
+		if len(typ) > 0 && svc.Type != typ {
+			continue
+		}
+		svcElem, err := m.serviceElement(svc, iq.FromJID().ToBareJID().String())
+		if err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+		sb.WithChild(svcElem)
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, sb.Build()))
+	return nil
+}
+
+func (m *ExtDisco) getCredentials(ctx context.Context, iq *stravaganza.IQ, credentials stravaganza.Element) error {
+	req := credentials.Child("service")
+	if req == nil || len(req.Attribute("host")) == 0 || len(req.Attribute("type")) == 0 {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	for _, svc := range m.cfg.Services {
+		if svc.Host != req.Attribute("host") || svc.Type != req.Attribute("type") {
+			continue
+		}
+		if port := req.Attribute("port"); len(port) > 0 && port != strconv.Itoa(svc.Port) {
+			continue
+		}
+		svcElem, err := m.serviceElement(svc, iq.FromJID().ToBareJID().String())
+		if err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+		_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq,
+			stravaganza.NewBuilder("credentials").
+				WithAttribute(stravaganza.Namespace, extDiscoNamespace).
+				WithChild(svcElem).
+				Build(),
+		))
+		level.Info(m.logger).Log("msg", "provided service credentials", "jid", iq.FromJID().String(), "host", svc.Host, "type", svc.Type)
+		return nil
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+	return nil
+}
+
+func (m *ExtDisco) serviceElement(svc ServiceConfig, userID string) (stravaganza.Element, error) {
+	b := stravaganza.NewBuilder("service").
+		WithAttribute("type", svc.Type).
+		WithAttribute("host", svc.Host)
+	if svc.Port > 0 {
+		b.WithAttribute("port", strconv.Itoa(svc.Port))
+	}
+	if len(svc.Transport) > 0 {
+		b.WithAttribute("transport", svc.Transport)
+	}
+	if len(svc.Name) > 0 {
+		b.WithAttribute("name", svc.Name)
+	}
+	if !svc.Restricted {
+		return b.Build(), nil
+	}
+	secret, err := m.sharedSecret()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(m.cfg.CredentialsTTL).UTC()
+	username, password := makeCredentials(secret, userID, expiresAt)
+
+	b.WithAttribute("restricted", "1")
+	b.WithAttribute("username", username)
+	b.WithAttribute("password", password)
+	b.WithAttribute("expires", expiresAt.Format(expiresTimeFormat))
+	return b.Build(), nil
+}
+
+// sharedSecret returns current credentials shared secret, reloading it from file in case it changed.
+func (m *ExtDisco) sharedSecret() (string, error) {
+	if len(m.cfg.SecretFile) == 0 {
+		return m.cfg.Secret, nil
+	}
+	fi, err := os.Stat(m.cfg.SecretFile)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if fi.ModTime().Equal(m.secretMTime) {
+		return m.secret, nil
+	}
+	b, err := os.ReadFile(m.cfg.SecretFile)
+	if err != nil {
+		return "", err
+	}
+	m.secret = strings.TrimSpace(string(b))
+	m.secretMTime = fi.ModTime()
+
+	level.Info(m.logger).Log("msg", "loaded shared secret", "file", m.cfg.SecretFile)
+	return m.secret, nil
+}
+
+// makeCredentials generates TURN REST API (coturn use-auth-secret) time-limited credentials.
+func makeCredentials(secret, userID string, expiresAt time.Time) (username, password string) {
+	username = strconv.FormatInt(expiresAt.Unix(), 10) + ":" + userID
+
+	h := hmac.New(sha1.New, []byte(secret))
+	h.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
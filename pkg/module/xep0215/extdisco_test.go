@@ -0,0 +1,160 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0215
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtDisco_GetServices(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	m := New(Config{
+		Services: []ServiceConfig{
+			{Type: "stun", Host: "stun.jackal.im", Port: 3478, Transport: "udp"},
+			{Type: "turn", Host: "turn.jackal.im", Port: 3478, Transport: "udp", Restricted: true},
+		},
+		Secret:         "a-super-secret-key",
+		CredentialsTTL: time.Hour,
+	}, routerMock, kitlog.NewNopLogger())
+
+	// when
+	require.Nil(t, m.Start(context.Background()))
+
+	for _, typ := range []string{"", "turn"} {
+		sb := stravaganza.NewBuilder("services").WithAttribute(stravaganza.Namespace, extDiscoNamespace)
+		if len(typ) > 0 {
+			sb.WithAttribute("type", typ)
+		}
+		require.Nil(t, m.ProcessIQ(context.Background(), testIQ(sb.Build())))
+	}
+
+	// then
+	require.Len(t, respStanzas, 2)
+
+	all := respStanzas[0].ChildNamespace("services", extDiscoNamespace).Children("service")
+	require.Len(t, all, 2)
+	require.Equal(t, "stun.jackal.im", all[0].Attribute("host"))
+	require.Empty(t, all[0].Attribute("username"))
+
+	turns := respStanzas[1].ChildNamespace("services", extDiscoNamespace).Children("service")
+	require.Len(t, turns, 1)
+
+	turn := turns[0]
+	require.Equal(t, "1", turn.Attribute("restricted"))
+	require.Regexp(t, `^\d+:ortuman@jackal.im$`, turn.Attribute("username"))
+	require.Equal(t, testPassword("a-super-secret-key", turn.Attribute("username")), turn.Attribute("password"))
+
+	expiresAt, err := time.Parse(expiresTimeFormat, turn.Attribute("expires"))
+	require.Nil(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+}
+
+func TestExtDisco_GetCredentials(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	secretFile := filepath.Join(t.TempDir(), "turn_secret")
+	require.Nil(t, os.WriteFile(secretFile, []byte("first-secret\n"), 0600))
+
+	m := New(Config{
+		Services: []ServiceConfig{
+			{Type: "turn", Host: "turn.jackal.im", Port: 3478, Transport: "udp", Restricted: true},
+		},
+		SecretFile:     secretFile,
+		CredentialsTTL: time.Hour,
+	}, routerMock, kitlog.NewNopLogger())
+
+	credentialsElem := stravaganza.NewBuilder("credentials").
+		WithAttribute(stravaganza.Namespace, extDiscoNamespace).
+		WithChild(
+			stravaganza.NewBuilder("service").
+				WithAttribute("type", "turn").
+				WithAttribute("host", "turn.jackal.im").
+				Build(),
+		).
+		Build()
+	unknownElem := stravaganza.NewBuilder("credentials").
+		WithAttribute(stravaganza.Namespace, extDiscoNamespace).
+		WithChild(
+			stravaganza.NewBuilder("service").
+				WithAttribute("type", "turn").
+				WithAttribute("host", "turn.jabber.org").
+				Build(),
+		).
+		Build()
+
+	// when
+	require.Nil(t, m.Start(context.Background()))
+	require.Nil(t, m.ProcessIQ(context.Background(), testIQ(credentialsElem)))
+
+	// rotate shared secret
+	require.Nil(t, os.WriteFile(secretFile, []byte("second-secret\n"), 0600))
+	require.Nil(t, os.Chtimes(secretFile, time.Now(), time.Now().Add(time.Minute)))
+
+	require.Nil(t, m.ProcessIQ(context.Background(), testIQ(credentialsElem)))
+	require.Nil(t, m.ProcessIQ(context.Background(), testIQ(unknownElem)))
+
+	// then
+	require.Len(t, respStanzas, 3)
+
+	for i, secret := range []string{"first-secret", "second-secret"} {
+		svc := respStanzas[i].ChildNamespace("credentials", extDiscoNamespace).Child("service")
+		require.NotNil(t, svc)
+		require.Equal(t, testPassword(secret, svc.Attribute("username")), svc.Attribute("password"))
+	}
+	require.Equal(t, stravaganza.ErrorType, respStanzas[2].Attribute(stravaganza.Type))
+	require.NotNil(t, respStanzas[2].Child("error").Child("item-not-found"))
+}
+
+func testIQ(child stravaganza.Element) *stravaganza.IQ {
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "ed1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithChild(child).
+		BuildIQ()
+	return iq
+}
+
+func testPassword(secret, username string) string {
+	h := hmac.New(sha1.New, []byte(secret))
+	h.Write([]byte(username))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
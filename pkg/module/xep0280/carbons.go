@@ -39,6 +39,7 @@ const (
 	deliveryReceiptsNamespace = "urn:xmpp:receipts"
 	chatStatesNamespace       = "http://jabber.org/protocol/chatstates"
 	hintsNamespace            = "urn:xmpp:hints"
+	mucUserNamespace          = "http://jabber.org/protocol/muc#user"
 )
 
 const (
@@ -226,6 +227,11 @@ func (p *Carbons) processMessage(ctx context.Context, msg *stravaganza.Message,
 			return err
 		}
 	}
+	// MUC private messages are delivered to the joined occupant resource only,
+	// as remaining resources are not present in the room.
+	if isMUCPrivateMessage(msg) && toJID.IsFullWithUser() {
+		return nil
+	}
 	if !toJID.IsServer() && p.hosts.IsLocalHost(toJID.Domain()) {
 		if err := p.routeReceivedCC(ctx, msg, toJID.Node(), ignoringTargets); err != nil {
 			return err
@@ -283,9 +289,15 @@ func (p *Carbons) getFilteredResources(ctx context.Context, username string, ign
 }
 
 func isEligibleMessage(msg *stravaganza.Message) bool {
+	switch msg.Attribute(stravaganza.Type) {
+	case stravaganza.GroupChatType, stravaganza.ErrorType:
+		return false
+	}
 	if msg.Attribute(stravaganza.Type) == stravaganza.ChatType {
 		return true
 	}
+	// a fallback only body (XEP-0428) still stands for a payload the message carries,
+	// such as encrypted content, hence it doesn't make the message any less eligible.
 	if msg.Attribute(stravaganza.Type) == stravaganza.NormalType && msg.IsMessageWithBody() {
 		return true
 	}
@@ -312,6 +324,10 @@ func isPrivateMessage(msg *stravaganza.Message) bool {
 	return msg.ChildNamespace("private", carbonsNamespace) != nil && msg.ChildNamespace("no-copy", hintsNamespace) != nil
 }
 
+func isMUCPrivateMessage(msg *stravaganza.Message) bool {
+	return msg.ChildNamespace("x", mucUserNamespace) != nil
+}
+
 func isCCMessage(msg *stravaganza.Message) bool {
 	return msg.ChildNamespace("sent", carbonsNamespace) != nil || msg.ChildNamespace("received", carbonsNamespace) != nil
 }
@@ -345,3 +345,108 @@ func TestCarbons_InterceptStanza(t *testing.T) {
 	require.Nil(t, err)
 	require.Nil(t, hInf.Element.ChildNamespace("private", carbonsNamespace))
 }
+
+func TestCarbons_MUCPrivateMessages(t *testing.T) {
+	var tcs = map[string]struct {
+		from, to, typ string
+		mucUser       bool
+		expectedCCs   []string
+	}{
+		"sent muc private message": {
+			from:        "ortuman@jackal.im/balcony",
+			to:          "coven@chat.shakespeare.lit/firstwitch",
+			typ:         stravaganza.ChatType,
+			mucUser:     true,
+			expectedCCs: []string{"sent"},
+		},
+		"received muc private message": {
+			from:    "coven@chat.shakespeare.lit/firstwitch",
+			to:      "ortuman@jackal.im/balcony",
+			typ:     stravaganza.ChatType,
+			mucUser: true,
+		},
+		"received muc private message to bare jid": {
+			from:        "coven@chat.shakespeare.lit/firstwitch",
+			to:          "ortuman@jackal.im",
+			typ:         stravaganza.ChatType,
+			mucUser:     true,
+			expectedCCs: []string{"received"},
+		},
+		"received groupchat message": {
+			from: "coven@chat.shakespeare.lit/firstwitch",
+			to:   "ortuman@jackal.im/balcony",
+			typ:  stravaganza.GroupChatType,
+		},
+	}
+	for tn, tc := range tcs {
+		t.Run(tn, func(t *testing.T) {
+			// given
+			routerMock := &routerMock{}
+
+			var respStanzas []stravaganza.Stanza
+			routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+				respStanzas = append(respStanzas, stanza)
+				return nil, nil
+			}
+			jd0, _ := jid.NewWithString("ortuman@jackal.im/balcony", true)
+			jd1, _ := jid.NewWithString("ortuman@jackal.im/hall", true)
+
+			resManagerMock := &resourceManagerMock{}
+			resManagerMock.GetResourcesFunc = func(ctx context.Context, username string) ([]c2smodel.ResourceDesc, error) {
+				return []c2smodel.ResourceDesc{
+					c2smodel.NewResourceDesc("i0", jd0, nil, c2smodel.NewInfoMapFromMap(map[string]string{carbonsEnabledCtxKey: "true"})),
+					c2smodel.NewResourceDesc("i0", jd1, nil, c2smodel.NewInfoMapFromMap(map[string]string{carbonsEnabledCtxKey: "true"})),
+				}, nil
+			}
+			hMock := &hostsMock{}
+			hMock.IsLocalHostFunc = func(h string) bool {
+				return h == "jackal.im"
+			}
+			hk := hook.NewHooks()
+			c := &Carbons{
+				router: routerMock,
+				resMng: resManagerMock,
+				hosts:  hMock,
+				hk:     hk,
+				logger: kitlog.NewNopLogger(),
+			}
+			b := stravaganza.NewMessageBuilder()
+			b.WithAttribute("id", "i1234")
+			b.WithAttribute("from", tc.from)
+			b.WithAttribute("to", tc.to)
+			b.WithAttribute("type", tc.typ)
+			b.WithChild(
+				stravaganza.NewBuilder("body").
+					WithText("I'll give thee a wind.").
+					Build(),
+			)
+			if tc.mucUser {
+				b.WithChild(
+					stravaganza.NewBuilder("x").
+						WithAttribute(stravaganza.Namespace, mucUserNamespace).
+						Build(),
+				)
+			}
+			msg, _ := b.BuildMessage()
+
+			// when
+			_ = c.Start(context.Background())
+			defer func() { _ = c.Stop(context.Background()) }()
+
+			_, _ = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+				Info: &hook.C2SStreamInfo{
+					Targets: []jid.JID{*jd0},
+					Element: msg,
+				},
+				Context: context.Background(),
+			})
+
+			// then
+			require.Len(t, respStanzas, len(tc.expectedCCs))
+			for i, ccName := range tc.expectedCCs {
+				require.Equal(t, "ortuman@jackal.im/hall", respStanzas[i].Attribute(stravaganza.To))
+				require.NotNil(t, respStanzas[i].ChildNamespace(ccName, carbonsNamespace))
+			}
+		})
+	}
+}
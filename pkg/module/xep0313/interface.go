@@ -47,5 +47,8 @@ type c2sStream interface {
 
 //go:generate moq -out hosts.mock_test.go . hosts
 type hosts interface {
+	HostNames() []string
 	IsLocalHost(h string) bool
+	IsMobileProfile(h string) bool
+	IsModuleEnabled(h, modName string) bool
 }
@@ -17,10 +17,14 @@ package xep0313
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/uuid"
 	"github.com/jackal-xmpp/stravaganza"
 	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
@@ -33,6 +37,7 @@ import (
 	"github.com/ortuman/jackal/pkg/module/xep0059"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/storage/repository"
+	ulidutil "github.com/ortuman/jackal/pkg/util/ulid"
 	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"github.com/samber/lo"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -52,8 +57,9 @@ const (
 
 	archiveRequestedCtxKey = "mam:requested"
 
-	defaultPageSize = 50
-	maxPageSize     = 250
+	defaultPageSize       = 50
+	mobileDefaultPageSize = 20
+	maxPageSize           = 250
 )
 
 type archiveIDCtxKey int
@@ -67,7 +73,30 @@ const (
 type Config struct {
 	// QueueSize defines maximum number of archive messages stanzas.
 	// When the limit is reached, the oldest message will be purged to make room for the new one.
+	// Per-user and per-host overrides can be set through the admin API, and are applied within a minute.
 	QueueSize int `fig:"queue_size" default:"1000"`
+
+	// AggregateReactions tells whether message reactions (XEP-0444) should be stored linked to their target message,
+	// allowing clients to request aggregated reaction summaries along with archive query results.
+	// Reaction messages themselves are archived as any other message.
+	AggregateReactions bool `fig:"aggregate_reactions"`
+
+	// MaxAge defines how long archived messages are kept before being purged.
+	// A zero value means messages are only bounded by QueueSize.
+	// A host override replaces MaxAge for all host archives, whereas a user override can only shorten
+	// the retention of its host, since purges remove expired messages host wide.
+	MaxAge time.Duration `fig:"max_age"`
+
+	// PurgeInterval defines how often expired archive messages are purged, taking max age overrides into account.
+	// A zero value disables periodic purges.
+	PurgeInterval time.Duration `fig:"purge_interval" default:"1h"`
+
+	// IgnoreProcessingHints tells whether message processing hints (XEP-0334) should be ignored when archiving.
+	IgnoreProcessingHints bool `fig:"ignore_processing_hints"`
+
+	// DedupWindow defines the time window in which a message carrying an already archived origin-id (XEP-0359)
+	// is considered a client resend, and therefore not archived again. A zero value disables deduplication.
+	DedupWindow time.Duration `fig:"dedup_window" default:"5m"`
 }
 
 // Mam represents a mam (XEP-0313) module type.
@@ -78,6 +107,14 @@ type Mam struct {
 	hk     *hook.Hooks
 	rep    repository.Repository
 	logger kitlog.Logger
+	nowFn  func() time.Time
+
+	quotasMu       sync.Mutex
+	quotas         *archiveQuotas
+	quotasLoadedAt time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
 }
 
 // New returns a new initialized mam instance.
@@ -96,6 +133,7 @@ func New(
 		rep:    rep,
 		hk:     hk,
 		logger: kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+		nowFn:  time.Now,
 	}
 }
 
@@ -114,11 +152,24 @@ func (m *Mam) ServerFeatures(_ context.Context) ([]string, error) {
 
 // AccountFeatures returns mam account disco features.
 func (m *Mam) AccountFeatures(_ context.Context) ([]string, error) {
-	return []string{mamNamespace, extendedMamNamespace}, nil
+	features := []string{mamNamespace, extendedMamNamespace}
+	if m.cfg.AggregateReactions {
+		features = append(features, mamReactionsNamespace)
+	}
+	return features, nil
 }
 
 // Start starts mam module.
 func (m *Mam) Start(_ context.Context) error {
+	if m.cfg.MaxAge < 0 {
+		return errors.New("xep0313: max age must not be negative")
+	}
+	if m.cfg.MaxAge > 0 && m.cfg.PurgeInterval <= 0 {
+		return errors.New("xep0313: purge interval must be positive")
+	}
+	if m.cfg.DedupWindow < 0 {
+		return errors.New("xep0313: dedup window must not be negative")
+	}
 	m.hk.AddHook(hook.C2SStreamMessageReceived, m.onMessageReceived, hook.HighestPriority)
 	m.hk.AddHook(hook.S2SInStreamMessageReceived, m.onMessageReceived, hook.HighestPriority)
 
@@ -126,6 +177,12 @@ func (m *Mam) Start(_ context.Context) error {
 	m.hk.AddHook(hook.S2SInStreamMessageRouted, m.onMessageRouted, hook.LowestPriority+2)
 	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
 
+	// max age may be set by quota overrides at any time
+	if m.cfg.PurgeInterval > 0 {
+		m.stopCh = make(chan struct{})
+		m.doneCh = make(chan struct{})
+		go m.purgeLoop()
+	}
 	level.Info(m.logger).Log("msg", "started mam module")
 	return nil
 }
@@ -138,6 +195,10 @@ func (m *Mam) Stop(_ context.Context) error {
 	m.hk.RemoveHook(hook.S2SInStreamMessageRouted, m.onMessageRouted)
 	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
 
+	if m.stopCh != nil {
+		close(m.stopCh)
+		<-m.doneCh
+	}
 	level.Info(m.logger).Log("msg", "stopped mam module")
 	return nil
 }
@@ -168,6 +229,12 @@ func (m *Mam) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
 
 	case iq.IsSet() && iq.ChildNamespace("query", mamNamespace) != nil:
 		return m.sendArchiveMessages(ctx, iq)
+
+	case iq.IsGet() && iq.ChildNamespace("prefs", mamNamespace) != nil:
+		return m.sendPrefs(ctx, iq)
+
+	case iq.IsSet() && iq.ChildNamespace("prefs", mamNamespace) != nil:
+		return m.setPrefs(ctx, iq)
 	}
 	return nil
 }
@@ -242,6 +309,10 @@ func (m *Mam) sendFormFields(ctx context.Context, iq *stravaganza.IQ) error {
 			Validator: &xep0004.OpenValidator{},
 		},
 	})
+	form.Fields = append(form.Fields, xep0004.Field{
+		Type: xep0004.TextSingle,
+		Var:  "full-text-search",
+	})
 
 	qChild := stravaganza.NewBuilder("query").
 		WithAttribute(stravaganza.Namespace, mamNamespace).
@@ -279,7 +350,15 @@ func (m *Mam) sendArchiveMessages(ctx context.Context, iq *stravaganza.IQ) error
 	}
 	archiveID := fromJID.Node()
 
-	messages, err := m.rep.FetchArchiveMessages(ctx, filters, archiveID)
+	var messages []*archivemodel.Message
+	var count int
+
+	if len(filters.Ids) > 0 {
+		messages, err = m.rep.FetchArchiveMessages(ctx, filters, nil, archiveID)
+		count = len(messages)
+	} else {
+		count, err = m.rep.CountArchiveMessages(ctx, filters, archiveID)
+	}
 	if err != nil {
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
 		return err
@@ -294,10 +373,10 @@ func (m *Mam) sendArchiveMessages(ctx context.Context, iq *stravaganza.IQ) error
 
 	// return not found error if any requested id cannot be found
 	switch {
-	case len(filters.Ids) > 0 && (len(messages) != len(filters.Ids)):
+	case len(filters.Ids) > 0 && (count != len(filters.Ids)):
 		fallthrough
 
-	case (len(filters.AfterId) > 0 || len(filters.BeforeId) > 0) && len(messages) == 0:
+	case (len(filters.AfterId) > 0 || len(filters.BeforeId) > 0) && count == 0:
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
 		return nil
 	}
@@ -317,10 +396,18 @@ func (m *Mam) sendArchiveMessages(ctx context.Context, iq *stravaganza.IQ) error
 		}
 	} else {
 		req = &xep0059.Request{Max: defaultPageSize}
+		if m.hosts.IsMobileProfile(iq.FromJID().Domain()) {
+			req.Max = mobileDefaultPageSize
+		}
+	}
+	if len(filters.Ids) > 0 {
+		// requested ids are bounded by the query itself, hence they can be paged in memory
+		messages, res, err = xep0059.GetResultSetPage(messages, req, func(m *archivemodel.Message) string {
+			return m.Id
+		})
+	} else {
+		messages, res, err = m.fetchResultSetPage(ctx, filters, req, count, archiveID)
 	}
-	messages, res, err = xep0059.GetResultSetPage(messages, req, func(m *archivemodel.Message) string {
-		return m.Id
-	})
 	if err != nil {
 		if errors.Is(err, xep0059.ErrPageNotFound) {
 			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
@@ -339,18 +426,31 @@ func (m *Mam) sendArchiveMessages(ctx context.Context, iq *stravaganza.IQ) error
 		res.First = lastID
 	}
 
+	// aggregate reactions, if requested
+	var summaries map[string]stravaganza.Element
+	if m.cfg.AggregateReactions && qChild.ChildNamespace("reactions", mamReactionsNamespace) != nil {
+		summaries, err = m.reactionSummaries(ctx, messages, archiveID, fromJID.ToBareJID().String())
+		if err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+	}
+
 	// route archive messages
 	for _, msg := range messages {
 		msgStanza, _ := stravaganza.NewBuilderFromProto(msg.Message).
 			BuildStanza()
 		stamp := msg.Stamp.AsTime()
 
-		resultElem := stravaganza.NewBuilder("result").
+		resultB := stravaganza.NewBuilder("result").
 			WithAttribute(stravaganza.Namespace, mamNamespace).
 			WithAttribute("queryid", qChild.Attribute("queryid")).
 			WithAttribute(stravaganza.ID, uuid.New().String()).
-			WithChild(xmpputil.MakeForwardedStanza(msgStanza, &stamp)).
-			Build()
+			WithChild(xmpputil.MakeForwardedStanza(msgStanza, &stamp))
+		if summary := summaries[msg.Id]; summary != nil {
+			resultB.WithChild(summary)
+		}
+		resultElem := resultB.Build()
 
 		archiveMsg, _ := stravaganza.NewMessageBuilder().
 			WithAttribute(stravaganza.From, iq.ToJID().String()).
@@ -375,18 +475,110 @@ func (m *Mam) sendArchiveMessages(ctx context.Context, iq *stravaganza.IQ) error
 	return stm.SetInfoValue(ctx, archiveRequestedCtxKey, true)
 }
 
+// fetchResultSetPage fetches the archive messages page requested by req, being count the total number of filtered messages.
+func (m *Mam) fetchResultSetPage(
+	ctx context.Context,
+	f *archivemodel.Filters,
+	req *xep0059.Request,
+	count int,
+	archiveID string,
+) ([]*archivemodel.Message, *xep0059.Result, error) {
+	switch {
+	case count == 0 && req.Index == 0:
+		return nil, &xep0059.Result{Complete: true}, nil
+
+	case req.Max == 0:
+		return nil, &xep0059.Result{Count: count}, nil
+	}
+
+	// translate request into a range of filtered messages
+	offset, limit := 0, req.Max
+
+	switch {
+	case req.LastPage:
+		offset = ((count - 1) / req.Max) * req.Max
+
+	case req.Index > 0:
+		offset = req.Index * req.Max
+		if offset > count-1 {
+			return nil, nil, xep0059.ErrPageNotFound
+		}
+
+	case len(req.After) > 0:
+		pos, err := m.messagePosition(ctx, f, req.After, archiveID)
+		if err != nil {
+			return nil, nil, err
+		}
+		offset = pos + 1
+
+	case len(req.Before) > 0:
+		pos, err := m.messagePosition(ctx, f, req.Before, archiveID)
+		if err != nil {
+			return nil, nil, err
+		}
+		offset = pos - req.Max
+		if offset < 0 {
+			offset = 0
+		}
+		limit = pos - offset
+	}
+
+	var page []*archivemodel.Message
+	if limit > 0 {
+		var err error
+		page, err = m.rep.FetchArchiveMessages(ctx, f, &archivemodel.Page{
+			Offset: int32(offset),
+			Limit:  int32(limit),
+		}, archiveID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	res := &xep0059.Result{
+		Index:    offset / req.Max,
+		Count:    len(page),
+		Complete: offset+len(page) >= count,
+	}
+	if len(page) > 0 {
+		res.First = page[0].Id
+		res.Last = page[len(page)-1].Id
+	}
+	return page, res, nil
+}
+
+// messagePosition returns the position of message id within the archive messages matching f filters.
+// In case the message cannot be found xep0059.ErrPageNotFound is returned.
+func (m *Mam) messagePosition(ctx context.Context, f *archivemodel.Filters, id, archiveID string) (int, error) {
+	pf := proto.Clone(f).(*archivemodel.Filters)
+	pf.BeforeId = id
+
+	pos, err := m.rep.CountArchiveMessages(ctx, pf, archiveID)
+	if err != nil {
+		return 0, err
+	}
+	// make sure message is part of the filtered set
+	messages, err := m.rep.FetchArchiveMessages(ctx, f, &archivemodel.Page{Offset: int32(pos), Limit: 1}, archiveID)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 || messages[0].Id != id {
+		return 0, xep0059.ErrPageNotFound
+	}
+	return pos, nil
+}
+
 func (m *Mam) onMessageReceived(execCtx *hook.ExecutionContext) error {
 	var msg *stravaganza.Message
 
 	switch inf := execCtx.Info.(type) {
 	case *hook.C2SStreamInfo:
 		msg = inf.Element.(*stravaganza.Message)
-		inf.Element = m.addRecipientStanzaID(msg)
+		inf.Element = m.addRecipientStanzaID(execCtx.Context, msg)
 		execCtx.Info = inf
 
 	case *hook.S2SStreamInfo:
 		msg = inf.Element.(*stravaganza.Message)
-		inf.Element = m.addRecipientStanzaID(msg)
+		inf.Element = m.addRecipientStanzaID(execCtx.Context, msg)
 		execCtx.Info = inf
 	}
 	return nil
@@ -406,7 +598,17 @@ func (m *Mam) onMessageRouted(execCtx *hook.ExecutionContext) error {
 
 func (m *Mam) onUserDeleted(execCtx *hook.ExecutionContext) error {
 	inf := execCtx.Info.(*hook.UserInfo)
-	return m.rep.DeleteArchive(execCtx.Context, inf.Username)
+	if err := m.rep.DeleteArchive(execCtx.Context, inf.Username); err != nil {
+		return err
+	}
+	if err := m.rep.DeleteArchivePrefs(execCtx.Context, inf.Username); err != nil {
+		return err
+	}
+	if err := m.rep.DeleteArchiveQuota(execCtx.Context, inf.Username, ""); err != nil {
+		return err
+	}
+	m.invalidateQuotas()
+	return m.rep.DeleteArchiveReactions(execCtx.Context, inf.Username)
 }
 
 func (m *Mam) handleRoutedMessage(execCtx *hook.ExecutionContext, elem stravaganza.Element) error {
@@ -414,32 +616,64 @@ func (m *Mam) handleRoutedMessage(execCtx *hook.ExecutionContext, elem stravagan
 	if !ok {
 		return nil
 	}
-	if !isMessageArchievable(msg) {
+	if m.cfg.AggregateReactions && msg.ChildNamespace("reactions", reactionsNamespace) != nil {
+		// aggregation is complementary to archiving, hence reaction messages are archived as usual
+		if err := m.handleReactions(execCtx.Context, msg); err != nil {
+			return err
+		}
+	}
+	if !m.isMessageArchievable(msg) {
 		return nil
 	}
 
 	fromJID := msg.FromJID()
-	if m.hosts.IsLocalHost(fromJID.Domain()) {
-		sentArchiveID := uuid.New().String()
-		archiveMsg := xmpputil.MakeStanzaIDMessage(msg, sentArchiveID, fromJID.ToBareJID().String())
-		if err := m.archiveMessage(execCtx.Context, archiveMsg, fromJID.Node(), sentArchiveID); err != nil {
+	toJID := msg.ToJID()
+
+	if m.isArchivingHost(fromJID.Domain()) {
+		allowed, err := m.isArchivingAllowed(execCtx.Context, fromJID.Node(), toJID)
+		if err != nil {
 			return err
 		}
-		execCtx.Context = context.WithValue(execCtx.Context, sentArchiveIDKey, sentArchiveID)
+		if allowed {
+			sentArchiveID := ulidutil.New()
+			archiveMsg := xmpputil.MakeStanzaIDMessage(msg, sentArchiveID, fromJID.ToBareJID().String())
+			sentArchiveID, err = m.archiveMessage(execCtx.Context, archiveMsg, fromJID.Domain(), fromJID.Node(), sentArchiveID)
+			if err != nil {
+				return err
+			}
+			execCtx.Context = context.WithValue(execCtx.Context, sentArchiveIDKey, sentArchiveID)
+		}
 	}
-	toJID := msg.ToJID()
-	if !m.hosts.IsLocalHost(toJID.Domain()) {
+	if !m.isArchivingHost(toJID.Domain()) {
 		return nil
 	}
-	recievedArchiveID := xmpputil.MessageStanzaID(msg)
-	if err := m.archiveMessage(execCtx.Context, msg, toJID.Node(), recievedArchiveID); err != nil {
+	allowed, err := m.isArchivingAllowed(execCtx.Context, toJID.Node(), fromJID)
+	if err != nil || !allowed {
+		return err
+	}
+	recievedArchiveID, err := m.archiveMessage(execCtx.Context, msg, toJID.Domain(), toJID.Node(), xmpputil.MessageStanzaID(msg))
+	if err != nil {
 		return err
 	}
 	execCtx.Context = context.WithValue(execCtx.Context, receivedArchiveIDKey, recievedArchiveID)
 	return nil
 }
 
-func (m *Mam) archiveMessage(ctx context.Context, message *stravaganza.Message, archiveID, id string) error {
+func (m *Mam) handleReactions(ctx context.Context, msg *stravaganza.Message) error {
+	if fromJID := msg.FromJID(); m.isArchivingHost(fromJID.Domain()) {
+		if err := m.storeReactions(ctx, msg, fromJID.Node()); err != nil {
+			return err
+		}
+	}
+	if toJID := msg.ToJID(); m.isArchivingHost(toJID.Domain()) {
+		return m.storeReactions(ctx, msg, toJID.Node())
+	}
+	return nil
+}
+
+// archiveMessage stores a message into an archive returning its archive identifier.
+// In case message is a client resend, the identifier of the previously archived copy is returned instead.
+func (m *Mam) archiveMessage(ctx context.Context, message *stravaganza.Message, host, archiveID, id string) (string, error) {
 	archiveMsg := &archivemodel.Message{
 		ArchiveId: archiveID,
 		Id:        id,
@@ -448,31 +682,100 @@ func (m *Mam) archiveMessage(ctx context.Context, message *stravaganza.Message,
 		Message:   message.Proto(),
 		Stamp:     timestamppb.Now(),
 	}
-	err := m.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
-		err := tx.InsertArchiveMessage(ctx, archiveMsg)
+	queueSize, maxAge, err := m.archiveLimits(ctx, host, archiveID)
+	if err != nil {
+		return "", err
+	}
+	var dupMsg *archivemodel.Message
+
+	err = m.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
+		var err error
+		dupMsg, err = m.fetchDuplicatedMessage(ctx, tx, archiveMsg, xmpputil.MessageOriginID(message))
+		if err != nil || dupMsg != nil {
+			return err
+		}
+		inserted, err := tx.InsertArchiveMessage(ctx, archiveMsg)
 		if err != nil {
 			return err
 		}
-		return tx.DeleteArchiveOldestMessages(ctx, archiveID, m.cfg.QueueSize)
+		if !inserted {
+			// a concurrent duplicate got archived first
+			dupMsg, err = tx.FetchArchiveMessageByOriginID(ctx, archiveID, archiveMsg.OriginId)
+			if err != nil {
+				return err
+			}
+			if dupMsg == nil {
+				return fmt.Errorf("xep0313: archived duplicate message not found: %s", archiveMsg.OriginId)
+			}
+			return nil
+		}
+		if maxAge > 0 {
+			if err := tx.DeleteArchiveExpiredMessages(ctx, archiveID, m.nowFn().Add(-maxAge)); err != nil {
+				return err
+			}
+		}
+		return tx.DeleteArchiveOldestMessages(ctx, archiveID, queueSize)
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
-	return m.runHook(ctx, hook.ArchiveMessageArchived, &hook.MamInfo{
+	if dupMsg != nil {
+		level.Debug(m.logger).Log("msg", "skipped duplicated archive message", "archive_id", archiveID, "id", dupMsg.Id)
+		return dupMsg.Id, nil
+	}
+	return id, m.runHook(ctx, hook.ArchiveMessageArchived, &hook.MamInfo{
 		ArchiveID: archiveID,
 		Message:   archiveMsg,
 	})
 }
 
-func (m *Mam) addRecipientStanzaID(originalMsg *stravaganza.Message) *stravaganza.Message {
+// fetchDuplicatedMessage returns the archived message that archiveMsg is a resend of, if any.
+// When no message with the same origin-id was archived, archiveMsg origin-id is set so that the repository
+// rejects any concurrent duplicate.
+func (m *Mam) fetchDuplicatedMessage(ctx context.Context, tx repository.Transaction, archiveMsg *archivemodel.Message, originID string) (*archivemodel.Message, error) {
+	if m.cfg.DedupWindow == 0 || len(originID) == 0 {
+		return nil, nil
+	}
+	prevMsg, err := tx.FetchArchiveMessageByOriginID(ctx, archiveMsg.ArchiveId, originID)
+	if err != nil {
+		return nil, err
+	}
+	if prevMsg == nil {
+		archiveMsg.OriginId = originID
+		return nil, nil
+	}
+	// origin-id is only unique per sender, and resends happen shortly after the original message.
+	// Otherwise, message gets archived without origin-id as it cannot be deduplicated.
+	prevFromJID, _ := jid.NewWithString(prevMsg.FromJid, true)
+	fromJID, _ := jid.NewWithString(archiveMsg.FromJid, true)
+	if prevFromJID == nil || fromJID == nil || !prevFromJID.MatchesWithOptions(fromJID, jid.MatchesBare) {
+		return nil, nil
+	}
+	if m.nowFn().Sub(prevMsg.Stamp.AsTime()) > m.cfg.DedupWindow {
+		return nil, nil
+	}
+	return prevMsg, nil
+}
+
+func (m *Mam) addRecipientStanzaID(ctx context.Context, originalMsg *stravaganza.Message) *stravaganza.Message {
 	toJID := originalMsg.ToJID()
-	if !m.hosts.IsLocalHost(toJID.Domain()) {
+	if !m.isArchivingHost(toJID.Domain()) || !m.isMessageArchievable(originalMsg) {
+		return originalMsg
+	}
+	allowed, err := m.isArchivingAllowed(ctx, toJID.Node(), originalMsg.FromJID())
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to fetch archive preferences", "archive_id", toJID.Node(), "err", err)
+	} else if !allowed {
 		return originalMsg
 	}
-	archiveID := uuid.New().String()
+	archiveID := ulidutil.New()
 	return xmpputil.MakeStanzaIDMessage(originalMsg, archiveID, toJID.ToBareJID().String())
 }
 
+func (m *Mam) isArchivingHost(h string) bool {
+	return m.hosts.IsLocalHost(h) && m.hosts.IsModuleEnabled(h, ModuleName)
+}
+
 func (m *Mam) runHook(ctx context.Context, hookName string, inf *hook.MamInfo) error {
 	_, err := m.hk.Run(hookName, &hook.ExecutionContext{
 		Info:    inf,
@@ -538,9 +841,23 @@ func formToFilters(fm *xep0004.DataForm) (*archivemodel.Filters, error) {
 	if ids := fm.Fields.ValuesForField("ids"); len(ids) > 0 {
 		retVal.Ids = ids
 	}
+	if fullTextSearch := strings.TrimSpace(fm.Fields.ValueForField("full-text-search")); len(fullTextSearch) > 0 {
+		retVal.FullTextSearch = fullTextSearch
+	}
 	return &retVal, nil
 }
 
-func isMessageArchievable(msg *stravaganza.Message) bool {
-	return (msg.IsNormal() || msg.IsChat()) && msg.IsMessageWithBody()
+func (m *Mam) isMessageArchievable(msg *stravaganza.Message) bool {
+	if !msg.IsNormal() && !msg.IsChat() {
+		return false
+	}
+	if !m.cfg.IgnoreProcessingHints {
+		switch {
+		case xmpputil.HasProcessingHint(msg, xmpputil.NoStoreHint), xmpputil.HasProcessingHint(msg, xmpputil.NoPermanentStoreHint):
+			return false
+		case xmpputil.HasProcessingHint(msg, xmpputil.StoreHint):
+			return true
+		}
+	}
+	return msg.IsMessageWithBody()
 }
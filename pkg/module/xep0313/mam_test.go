@@ -17,20 +17,25 @@ package xep0313
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	kitlog "github.com/go-kit/log"
+	"github.com/google/uuid"
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
 	"github.com/ortuman/jackal/pkg/module/xep0059"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/ortuman/jackal/pkg/storage/repository"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -78,7 +83,7 @@ func TestMam_FormFields(t *testing.T) {
 	form, _ := xep0004.NewFormFromElement(x)
 	require.NotNil(t, form)
 
-	require.Len(t, form.Fields, 7)
+	require.Len(t, form.Fields, 8)
 }
 
 func TestMam_Metadata(t *testing.T) {
@@ -148,21 +153,1173 @@ func TestMam_ArchiveMessage(t *testing.T) {
 	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
 		return nil
 	}
-	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) error {
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
 		archivedMessages = append(archivedMessages, message)
+		return true, nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return nil, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsModuleEnabledFunc = func(_, _ string) bool { return true }
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	msg := testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard")
+
+	// when
+	execCtx := &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	}
+	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+	require.NoError(t, err)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, archivedMessages, 2)
+
+	require.Equal(t, "ortuman", archivedMessages[0].ArchiveId)
+	require.Equal(t, "noelia", archivedMessages[1].ArchiveId)
+
+	require.Len(t, txMock.DeleteArchiveOldestMessagesCalls(), 2)
+	require.Len(t, txMock.InsertArchiveMessageCalls(), 2)
+
+	require.True(t, len(ExtractSentArchiveID(execCtx.Context)) > 0)
+	require.True(t, len(ExtractReceivedArchiveID(execCtx.Context)) > 0)
+}
+
+func TestMam_ArchiveMessageQuotas(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	queueSizes := make(map[string]int)
+	expiredBefore := make(map[string]time.Time)
+
+	txMock := &txMock{}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		return true, nil
+	}
+	txMock.DeleteArchiveExpiredMessagesFunc = func(ctx context.Context, archiveID string, before time.Time) error {
+		expiredBefore[archiveID] = before
+		return nil
+	}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		queueSizes[archiveID] = maxElements
+		return nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return nil, nil
+	}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return []*archivemodel.Quota{
+			{ArchiveId: "ortuman", QueueSize: 10},
+			{Host: "jackal.im", QueueSize: 100, MaxAgeSeconds: 3600},
+		}, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsModuleEnabledFunc = func(_, _ string) bool { return true }
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" || h == "jabber.org" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		cfg:    Config{QueueSize: 1000},
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		nowFn:  func() time.Time { return now },
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	// when
+	for _, msg := range []*stravaganza.Message{
+		testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard"),
+		testMessageStanzaWithParameters("b1", "romeo@jabber.org/orchard", "juliet@jabber.org/balcony"),
+	} {
+		_, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+			Info: &hook.C2SStreamInfo{
+				Element: msg,
+			},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+
+	// then
+	require.Equal(t, map[string]int{
+		"ortuman": 10,   // user override
+		"noelia":  100,  // host override
+		"romeo":   1000, // module configuration
+		"juliet":  1000,
+	}, queueSizes)
+	require.Len(t, repMock.FetchArchiveQuotasCalls(), 1) // cached quotas
+
+	require.Equal(t, map[string]time.Time{
+		"ortuman": now.Add(-time.Hour),
+		"noelia":  now.Add(-time.Hour),
+	}, expiredBefore)
+}
+
+func TestMam_ArchiveMessageDedup(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 01, 00, 10, 00, 00, time.UTC)
+
+	archived := map[string]*archivemodel.Message{
+		"noelia/origin1": {Id: "id1", FromJid: "ortuman@jackal.im/chamber", Stamp: timestamppb.New(now.Add(-time.Minute))},
+		"noelia/origin2": {Id: "id2", FromJid: "ortuman@jackal.im/chamber", Stamp: timestamppb.New(now.Add(-time.Hour))},
+		"noelia/origin3": {Id: "id3", FromJid: "romeo@jackal.im/orchard", Stamp: timestamppb.New(now.Add(-time.Minute))},
+	}
+	var insertedMessages []*archivemodel.Message
+
+	txMock := &txMock{}
+	txMock.FetchArchiveMessageByOriginIDFunc = func(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+		return archived[archiveID+"/"+originID], nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		insertedMessages = append(insertedMessages, message)
+		return true, nil
+	}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		return nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+
+	mam := &Mam{
+		cfg:    Config{DedupWindow: time.Minute * 5},
+		hk:     hook.NewHooks(),
+		rep:    repMock,
+		nowFn:  func() time.Time { return now },
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	var ids []string
+	for _, originID := range []string{"origin1", "origin2", "origin3", "origin4"} {
+		msg := testMessageWithOriginID("ortuman@jackal.im/chamber", "noelia@jackal.im/yard", originID)
+
+		id, err := mam.archiveMessage(context.Background(), msg, "jackal.im", "noelia", "new-"+originID)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// then
+	require.Equal(t, []string{"id1", "new-origin2", "new-origin3", "new-origin4"}, ids)
+
+	require.Len(t, insertedMessages, 3)
+	require.Equal(t, "", insertedMessages[0].OriginId) // out of dedup window
+	require.Equal(t, "", insertedMessages[1].OriginId) // different sender
+	require.Equal(t, "origin4", insertedMessages[2].OriginId)
+}
+
+func TestMam_ArchiveMessageConcurrentDup(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 01, 00, 10, 00, 00, time.UTC)
+
+	var insertCalls, trimCalls int
+
+	txMock := &txMock{}
+	txMock.FetchArchiveMessageByOriginIDFunc = func(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+		if insertCalls == 0 {
+			return nil, nil
+		}
+		// archived by a concurrent transaction
+		return &archivemodel.Message{Id: "id1", FromJid: "ortuman@jackal.im/chamber", Stamp: timestamppb.New(now)}, nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		insertCalls++
+		return false, nil
+	}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		trimCalls++
+		return nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+
+	var archivedHookCalls int
+
+	hk := hook.NewHooks()
+	hk.AddHook(hook.ArchiveMessageArchived, func(_ *hook.ExecutionContext) error {
+		archivedHookCalls++
+		return nil
+	}, hook.DefaultPriority)
+
+	mam := &Mam{
+		cfg:    Config{DedupWindow: time.Minute * 5},
+		hk:     hk,
+		rep:    repMock,
+		nowFn:  func() time.Time { return now },
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	msg := testMessageWithOriginID("ortuman@jackal.im/chamber", "noelia@jackal.im/yard", "origin1")
+
+	id, err := mam.archiveMessage(context.Background(), msg, "jackal.im", "noelia", "new-origin1")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "id1", id)
+	require.Equal(t, 1, insertCalls)
+	require.Equal(t, 0, trimCalls)
+	require.Equal(t, 0, archivedHookCalls)
+}
+
+func TestMam_ArchiveMessageProcessingHints(t *testing.T) {
+	tcs := map[string]struct {
+		hint          string
+		withBody      bool
+		ignoreHints   bool
+		expectedCount int
+	}{
+		"no-store": {
+			hint:          xmpputil.NoStoreHint,
+			withBody:      true,
+			expectedCount: 0,
+		},
+		"no-permanent-store": {
+			hint:          xmpputil.NoPermanentStoreHint,
+			withBody:      true,
+			expectedCount: 0,
+		},
+		"store without body": {
+			hint:          xmpputil.StoreHint,
+			expectedCount: 2,
+		},
+		"ignored hint": {
+			hint:          xmpputil.NoStoreHint,
+			withBody:      true,
+			ignoreHints:   true,
+			expectedCount: 2,
+		},
+	}
+	for tn, tc := range tcs {
+		t.Run(tn, func(t *testing.T) {
+			// given
+			txMock := &txMock{}
+			txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+				return nil
+			}
+			txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+				return true, nil
+			}
+			repMock := &repositoryMock{}
+			repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+				return nil, nil
+			}
+			repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+				return f(ctx, txMock)
+			}
+			repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+				return nil, nil
+			}
+
+			hosts := &hostsMock{}
+			hosts.IsModuleEnabledFunc = func(_, _ string) bool { return true }
+			hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+			hk := hook.NewHooks()
+			mam := &Mam{
+				cfg:    Config{IgnoreProcessingHints: tc.ignoreHints},
+				hk:     hk,
+				hosts:  hosts,
+				rep:    repMock,
+				logger: kitlog.NewNopLogger(),
+			}
+			_ = mam.Start(context.Background())
+			t.Cleanup(func() {
+				_ = mam.Stop(context.Background())
+			})
+
+			b := stravaganza.NewMessageBuilder().
+				WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+				WithAttribute(stravaganza.To, "noelia@jackal.im/yard").
+				WithAttribute(stravaganza.Type, stravaganza.ChatType).
+				WithChild(
+					stravaganza.NewBuilder(tc.hint).
+						WithAttribute(stravaganza.Namespace, "urn:xmpp:hints").
+						Build(),
+				)
+			if tc.withBody {
+				b.WithChild(stravaganza.NewBuilder("body").WithText("b0").Build())
+			}
+			msg, _ := b.BuildMessage()
+
+			// when
+			_, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+				Info: &hook.C2SStreamInfo{
+					Element: msg,
+				},
+				Context: context.Background(),
+			})
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, txMock.InsertArchiveMessageCalls(), tc.expectedCount)
+		})
+	}
+}
+
+func TestMam_ArchiveMessagePrefs(t *testing.T) {
+	// given
+	var archivedMessages []*archivemodel.Message
+
+	txMock := &txMock{}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		return nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		archivedMessages = append(archivedMessages, message)
+		return true, nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		switch archiveID {
+		case "ortuman":
+			return &archivemodel.Prefs{ArchiveId: archiveID, DefaultBehavior: "always", Never: []string{"noelia@jackal.im"}}, nil
+		case "noelia":
+			return &archivemodel.Prefs{ArchiveId: archiveID, DefaultBehavior: "roster"}, nil
+		}
+		return nil, nil
+	}
+	repMock.FetchRosterItemFunc = func(ctx context.Context, username, jid string) (*rostermodel.Item, error) {
+		if username == "noelia" && jid == "ortuman@jackal.im" {
+			return &rostermodel.Item{Username: username, Jid: jid}, nil
+		}
+		return nil, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsModuleEnabledFunc = func(_, _ string) bool { return true }
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	// when
+	for _, msg := range []*stravaganza.Message{
+		testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard"),
+		testMessageStanzaWithParameters("b1", "romeo@jackal.im/garden", "noelia@jackal.im/yard"),
+	} {
+		execCtx := &hook.ExecutionContext{
+			Info: &hook.C2SStreamInfo{
+				Element: msg,
+			},
+			Context: context.Background(),
+		}
+		_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+		require.NoError(t, err)
+
+		_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+		require.NoError(t, err)
+	}
+
+	// then
+	require.Len(t, archivedMessages, 2)
+
+	// ortuman never archives messages exchanged with noelia, who only archives messages from roster contacts
+	require.Equal(t, "noelia", archivedMessages[0].ArchiveId)
+	require.Equal(t, "ortuman@jackal.im/chamber", archivedMessages[0].FromJid)
+
+	require.Equal(t, "romeo", archivedMessages[1].ArchiveId)
+}
+
+func TestMam_Prefs(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	var storedPrefs *archivemodel.Prefs
+
+	repMock := &repositoryMock{}
+	repMock.UpsertArchivePrefsFunc = func(ctx context.Context, prefs *archivemodel.Prefs) error {
+		storedPrefs = prefs
+		return nil
+	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return storedPrefs, nil
+	}
+	mam := &Mam{
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	getIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "prefs1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				Build(),
+		).
+		BuildIQ()
+
+	setIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "prefs2").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithAttribute("default", "roster").
+				WithChild(
+					stravaganza.NewBuilder("always").
+						WithChild(stravaganza.NewBuilder("jid").WithText("noelia@jackal.im").Build()).
+						Build(),
+				).
+				WithChild(
+					stravaganza.NewBuilder("never").
+						WithChild(stravaganza.NewBuilder("jid").WithText("romeo@jackal.im/garden").Build()).
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+
+	badIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "prefs3").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithAttribute("default", "sometimes").
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), getIQ)
+	_ = mam.ProcessIQ(context.Background(), setIQ)
+	_ = mam.ProcessIQ(context.Background(), getIQ)
+	_ = mam.ProcessIQ(context.Background(), badIQ)
+
+	// then
+	require.Len(t, respStanzas, 4)
+
+	prefs := respStanzas[0].ChildNamespace("prefs", mamNamespace)
+	require.NotNil(t, prefs)
+	require.Equal(t, "always", prefs.Attribute("default"))
+
+	require.NotNil(t, storedPrefs)
+	require.Equal(t, "ortuman", storedPrefs.ArchiveId)
+	require.Equal(t, "roster", storedPrefs.DefaultBehavior)
+	require.Equal(t, []string{"noelia@jackal.im"}, storedPrefs.Always)
+	require.Equal(t, []string{"romeo@jackal.im/garden"}, storedPrefs.Never)
+
+	for _, resp := range respStanzas[1:3] {
+		require.Equal(t, stravaganza.ResultType, resp.Type())
+
+		prefs = resp.ChildNamespace("prefs", mamNamespace)
+		require.NotNil(t, prefs)
+		require.Equal(t, "roster", prefs.Attribute("default"))
+		require.Equal(t, "noelia@jackal.im", prefs.Child("always").Child("jid").Text())
+		require.Equal(t, "romeo@jackal.im/garden", prefs.Child("never").Child("jid").Text())
+	}
+
+	require.Equal(t, stravaganza.ErrorType, respStanzas[3].Type())
+	require.NotNil(t, respStanzas[3].Child("error").Child("bad-request"))
+}
+
+func TestMam_ArchiveMessageDisabledHost(t *testing.T) {
+	// given
+	var archivedMessages []*archivemodel.Message
+
+	txMock := &txMock{}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		return nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		archivedMessages = append(archivedMessages, message)
+		return true, nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return nil, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsModuleEnabledFunc = func(h, _ string) bool { return h != "jackal.org" }
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" || h == "jackal.org" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	msg := testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.org/yard")
+
+	// when
+	execCtx := &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	}
+	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, archivedMessages, 1)
+	require.Equal(t, "ortuman", archivedMessages[0].ArchiveId)
+
+	require.True(t, len(ExtractSentArchiveID(execCtx.Context)) > 0)
+	require.Len(t, ExtractReceivedArchiveID(execCtx.Context), 0)
+}
+
+func TestMam_SendArchiveMessages(t *testing.T) {
+	// given
+	archiveMessages := []*archivemodel.Message{
+		{
+			ArchiveId: "ortuman",
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)),
+			FromJid:   "ortuman@jackal.im/chamber",
+			ToJid:     "noelia@jackal.im/yard",
+			Message: testMessageStanzaWithParameters(
+				"b0",
+				"ortuman@jackal.im/chamber",
+				"noelia@jackal.im/yard",
+			).Proto(),
+		},
+		{
+			ArchiveId: "ortuman",
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 01, 00, 00, 00, time.UTC)),
+			FromJid:   "noelia@jackal.im/yard",
+			ToJid:     "ortuman@jackal.im/chamber",
+			Message: testMessageStanzaWithParameters(
+				"b1",
+				"noelia@jackal.im/yard",
+				"ortuman@jackal.im/chamber",
+			).Proto(),
+		},
+		{
+			ArchiveId: "ortuman",
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 02, 00, 00, 00, time.UTC)),
+			FromJid:   "ortuman@jackal.im/chamber",
+			ToJid:     "noelia@jackal.im/yard",
+			Message: testMessageStanzaWithParameters(
+				"b2",
+				"ortuman@jackal.im/chamber",
+				"noelia@jackal.im/yard",
+			).Proto(),
+		},
+	}
+
+	c2sInf := c2smodel.NewInfoMap()
+
+	stmMock := &c2sStreamMock{}
+	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error {
+		bVal, ok := val.(bool)
+		if !ok {
+			return errors.New("unexpected value type")
+		}
+		c2sInf.SetBool(k, bVal)
+		return nil
+	}
+
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return stmMock, nil
+	}
+
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+		return testArchivePage(archiveMessages, page), nil
+	}
+	repMock.CountArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+		return len(archiveMessages), nil
+	}
+
+	hostsMock := &hostsMock{}
+	hostsMock.IsMobileProfileFunc = func(_ string) bool { return false }
+
+	mam := &Mam{
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		hosts:  hostsMock,
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "ortuman1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 4) // 3 messages + result iq
+
+	require.Equal(t, stravaganza.MessageName, respStanzas[0].Name())
+	require.Equal(t, stravaganza.MessageName, respStanzas[1].Name())
+	require.Equal(t, stravaganza.MessageName, respStanzas[2].Name())
+	require.Equal(t, stravaganza.IQName, respStanzas[3].Name())
+
+	iqRes := respStanzas[3]
+	require.Equal(t, stravaganza.ResultType, iqRes.Type())
+
+	finElem := iqRes.ChildNamespace("fin", mamNamespace)
+	require.NotNil(t, finElem)
+
+	rsmRes := finElem.ChildNamespace("set", xep0059.RSMNamespace)
+	require.NotNil(t, rsmRes)
+
+	count := rsmRes.Child("count")
+	require.NotNil(t, count)
+	require.Equal(t, "3", count.Text())
+
+	require.Len(t, stmMock.SetInfoValueCalls(), 1)
+	require.True(t, IsArchiveRequested(c2sInf))
+}
+
+func TestMam_MobileProfileDefaultPageSize(t *testing.T) {
+	// given
+	var archiveMessages []*archivemodel.Message
+	for i := 0; i < 30; i++ {
+		archiveMessages = append(archiveMessages, &archivemodel.Message{
+			ArchiveId: "ortuman",
+			Id:        uuid.New().String(),
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 00, i, 00, 00, time.UTC)),
+			FromJid:   "ortuman@jackal.im/chamber",
+			ToJid:     "noelia@jackal.im/yard",
+			Message: testMessageStanzaWithParameters(
+				"b0",
+				"ortuman@jackal.im/chamber",
+				"noelia@jackal.im/yard",
+			).Proto(),
+		})
+	}
+	stmMock := &c2sStreamMock{}
+	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error { return nil }
+
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return stmMock, nil
+	}
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+		return testArchivePage(archiveMessages, page), nil
+	}
+	repMock.CountArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+		return len(archiveMessages), nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsMobileProfileFunc = func(_ string) bool { return true }
+
+	mam := &Mam{
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		hosts:  hostsMock,
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "ortuman1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, mobileDefaultPageSize+1) // page messages + result iq
+
+	finElem := respStanzas[mobileDefaultPageSize].ChildNamespace("fin", mamNamespace)
+	require.NotNil(t, finElem)
+	require.Empty(t, finElem.Attribute("complete"))
+}
+
+func TestMam_ResultSetPaging(t *testing.T) {
+	// given
+	var archiveMessages []*archivemodel.Message
+	for i := 0; i < 10; i++ {
+		archiveMessages = append(archiveMessages, &archivemodel.Message{
+			ArchiveId: "ortuman",
+			Id:        fmt.Sprintf("m%d", i),
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 00, i, 00, 00, time.UTC)),
+			FromJid:   "ortuman@jackal.im/chamber",
+			ToJid:     "noelia@jackal.im/yard",
+			Message: testMessageStanzaWithParameters(
+				"b0",
+				"ortuman@jackal.im/chamber",
+				"noelia@jackal.im/yard",
+			).Proto(),
+		})
+	}
+
+	tcs := map[string]struct {
+		set           stravaganza.Element
+		expectedFirst string
+		expectedLast  string
+		expectedCount int
+		complete      bool
+		itemNotFound  bool
+	}{
+		"first page": {
+			set:           testRSMSet("3", "", "", ""),
+			expectedFirst: "m0",
+			expectedLast:  "m2",
+			expectedCount: 3,
+		},
+		"by index": {
+			set:           testRSMSet("3", "", "", "3"),
+			expectedFirst: "m9",
+			expectedLast:  "m9",
+			expectedCount: 1,
+			complete:      true,
+		},
+		"after id": {
+			set:           testRSMSet("3", "m3", "", ""),
+			expectedFirst: "m4",
+			expectedLast:  "m6",
+			expectedCount: 3,
+		},
+		"before id": {
+			set:           testRSMSet("3", "", "m2", ""),
+			expectedFirst: "m0",
+			expectedLast:  "m1",
+			expectedCount: 2,
+		},
+		"last page": {
+			set: stravaganza.NewBuilder("set").
+				WithAttribute(stravaganza.Namespace, xep0059.RSMNamespace).
+				WithChild(stravaganza.NewBuilder("max").WithText("4").Build()).
+				WithChild(stravaganza.NewBuilder("before").Build()).
+				Build(),
+			expectedFirst: "m8",
+			expectedLast:  "m9",
+			expectedCount: 2,
+			complete:      true,
+		},
+		"unknown after id": {
+			set:          testRSMSet("3", "m42", "", ""),
+			itemNotFound: true,
+		},
+		"index out of range": {
+			set:          testRSMSet("3", "", "", "4"),
+			itemNotFound: true,
+		},
+	}
+	for tn, tc := range tcs {
+		t.Run(tn, func(t *testing.T) {
+			stmMock := &c2sStreamMock{}
+			stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error { return nil }
+
+			c2sRouterMock := &c2sRouterMock{}
+			c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+				return stmMock, nil
+			}
+			routerMock := &routerMock{}
+
+			var respStanzas []stravaganza.Stanza
+			routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+				respStanzas = append(respStanzas, stanza)
+				return nil, nil
+			}
+			routerMock.C2SFunc = func() router.C2SRouter {
+				return c2sRouterMock
+			}
+			repMock := &repositoryMock{}
+			repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+				return testArchivePage(archiveMessages, page), nil
+			}
+			repMock.CountArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+				for i, msg := range archiveMessages {
+					if msg.Id == f.BeforeId {
+						return i, nil
+					}
+				}
+				return len(archiveMessages), nil
+			}
+			hostsMock := &hostsMock{}
+			hostsMock.IsMobileProfileFunc = func(_ string) bool { return false }
+
+			mam := &Mam{
+				rep:    repMock,
+				hk:     hook.NewHooks(),
+				hosts:  hostsMock,
+				router: routerMock,
+				logger: kitlog.NewNopLogger(),
+			}
+
+			iq, _ := stravaganza.NewIQBuilder().
+				WithAttribute(stravaganza.ID, "ortuman1").
+				WithAttribute(stravaganza.Type, stravaganza.SetType).
+				WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+				WithAttribute(stravaganza.To, "ortuman@jackal.im").
+				WithChild(
+					stravaganza.NewBuilder("query").
+						WithAttribute(stravaganza.Namespace, mamNamespace).
+						WithChild(tc.set).
+						Build(),
+				).
+				BuildIQ()
+
+			// when
+			_ = mam.ProcessIQ(context.Background(), iq)
+
+			// then
+			if tc.itemNotFound {
+				require.Len(t, respStanzas, 1)
+				require.Equal(t, stravaganza.ErrorType, respStanzas[0].Type())
+				require.NotNil(t, respStanzas[0].Child("error").Child("item-not-found"))
+				return
+			}
+			require.Len(t, respStanzas, tc.expectedCount+1)
+
+			finElem := respStanzas[tc.expectedCount].ChildNamespace("fin", mamNamespace)
+			require.NotNil(t, finElem)
+			require.Equal(t, tc.complete, finElem.Attribute("complete") == "true")
+
+			rsmRes := finElem.ChildNamespace("set", xep0059.RSMNamespace)
+			require.NotNil(t, rsmRes)
+			require.Equal(t, tc.expectedFirst, rsmRes.Child("first").Text())
+			require.Equal(t, tc.expectedLast, rsmRes.Child("last").Text())
+		})
+	}
+}
+
+func TestMam_Forbidden(t *testing.T) {
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+
+	repMock := &repositoryMock{}
+
+	mam := &Mam{
+		rep:    repMock,
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "ortuman1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "noelia@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
+}
+
+func TestMam_DeleteArchive(t *testing.T) {
+	// given
+	var deletedArchiveID string
+
+	repMock := &repositoryMock{}
+	repMock.DeleteArchiveFunc = func(ctx context.Context, archiveID string) error {
+		deletedArchiveID = archiveID
+		return nil
+	}
+	repMock.DeleteArchiveReactionsFunc = func(ctx context.Context, archiveID string) error {
+		return nil
+	}
+	repMock.DeleteArchivePrefsFunc = func(ctx context.Context, archiveID string) error {
+		return nil
+	}
+	repMock.DeleteArchiveQuotaFunc = func(ctx context.Context, archiveID, host string) error {
+		return nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	// when
+	_, err := hk.Run(hook.UserDeleted, &hook.ExecutionContext{
+		Info: &hook.UserInfo{
+			Username: "ortuman",
+		},
+		Context: context.Background(),
+	},
+	)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, repMock.DeleteArchiveCalls(), 1)
+	require.Len(t, repMock.DeleteArchiveReactionsCalls(), 1)
+	require.Len(t, repMock.DeleteArchivePrefsCalls(), 1)
+	require.Len(t, repMock.DeleteArchiveQuotaCalls(), 1)
+
+	require.Equal(t, "ortuman", deletedArchiveID)
+}
+
+func TestMam_StoreReactions(t *testing.T) {
+	// given
+	var stored []*reactionmodel.Reactions
+
+	repMock := &repositoryMock{}
+	repMock.UpsertReactionsFunc = func(ctx context.Context, reactions *reactionmodel.Reactions) error {
+		stored = append(stored, reactions)
+		return nil
+	}
+	repMock.DeleteReactionsFunc = func(ctx context.Context, archiveID, targetID, jid string) error {
+		return nil
+	}
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+		if f.Ids[0] != "sid-b0" {
+			return nil, nil
+		}
+		return []*archivemodel.Message{{ArchiveId: archiveID, Id: "sid-b0"}}, nil
+	}
+	repMock.FetchArchiveMessageByOriginIDFunc = func(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+		if originID != "b0" {
+			return nil, nil
+		}
+		return &archivemodel.Message{ArchiveId: archiveID, Id: "sid-b0", OriginId: "b0"}, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsModuleEnabledFunc = func(_, _ string) bool { return true }
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		cfg:    Config{AggregateReactions: true},
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	// when
+	_, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: testReactionsMessage("noelia@jackal.im/yard", "ortuman@jackal.im/chamber", "b0", "👍", "👍", "🐢"),
+		},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: testReactionsMessage("noelia@jackal.im/yard", "ortuman@jackal.im/chamber", "sid-b0", "🐢"),
+		},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: testReactionsMessage("noelia@jackal.im/yard", "ortuman@jackal.im/chamber", "b0"),
+		},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: testReactionsMessage("noelia@jackal.im/yard", "ortuman@jackal.im/chamber", "b1", "👍"),
+		},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, stored, 4) // two reactions stored into sender and recipient archives
+	require.Equal(t, "noelia", stored[0].ArchiveId)
+	require.Equal(t, "ortuman", stored[1].ArchiveId)
+	require.Equal(t, "sid-b0", stored[0].TargetId) // resolved by origin-id
+	require.Equal(t, "noelia@jackal.im", stored[0].Jid)
+	require.Equal(t, []string{"👍", "🐢"}, stored[0].Reactions)
+	require.Equal(t, "sid-b0", stored[2].TargetId) // resolved by stanza-id
+	require.Equal(t, []string{"🐢"}, stored[2].Reactions)
+
+	require.Len(t, repMock.DeleteReactionsCalls(), 2)
+	require.Len(t, repMock.InTransactionCalls(), 0) // neither body nor store hint
+}
+
+func TestMam_ArchiveAggregatedReactions(t *testing.T) {
+	// given
+	txMock := &txMock{}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
 		return nil
 	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		return true, nil
+	}
 
 	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
 	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
 		return f(ctx, txMock)
 	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return nil, nil
+	}
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+		return []*archivemodel.Message{{ArchiveId: archiveID, Id: "sid-b0"}}, nil
+	}
+	repMock.UpsertReactionsFunc = func(ctx context.Context, reactions *reactionmodel.Reactions) error {
+		return nil
+	}
 
 	hosts := &hostsMock{}
+	hosts.IsModuleEnabledFunc = func(_, _ string) bool { return true }
 	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
 
 	hk := hook.NewHooks()
 	mam := &Mam{
+		cfg:    Config{AggregateReactions: true},
 		hk:     hk,
 		hosts:  hosts,
 		rep:    repMock,
@@ -173,51 +1330,46 @@ func TestMam_ArchiveMessage(t *testing.T) {
 		_ = mam.Stop(context.Background())
 	})
 
-	msg := testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard")
+	msg, _ := stravaganza.NewBuilderFromElement(
+		testReactionsMessage("noelia@jackal.im/yard", "ortuman@jackal.im/chamber", "sid-b0", "👍"),
+	).
+		WithChild(
+			stravaganza.NewBuilder("store").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:hints").
+				Build(),
+		).
+		BuildMessage()
 
 	// when
-	execCtx := &hook.ExecutionContext{
-		Info: &hook.C2SStreamInfo{
-			Element: msg,
-		},
+	_, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info:    &hook.C2SStreamInfo{Element: msg},
 		Context: context.Background(),
-	}
-	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
-	require.NoError(t, err)
-
-	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
-	require.NoError(t, err)
+	})
 
 	// then
 	require.NoError(t, err)
-	require.Len(t, archivedMessages, 2)
-
-	require.Equal(t, "ortuman", archivedMessages[0].ArchiveId)
-	require.Equal(t, "noelia", archivedMessages[1].ArchiveId)
-
-	require.Len(t, txMock.DeleteArchiveOldestMessagesCalls(), 2)
-	require.Len(t, txMock.InsertArchiveMessageCalls(), 2)
-
-	require.True(t, len(ExtractSentArchiveID(execCtx.Context)) > 0)
-	require.True(t, len(ExtractReceivedArchiveID(execCtx.Context)) > 0)
+	require.Len(t, repMock.UpsertReactionsCalls(), 2)
+	require.Len(t, txMock.InsertArchiveMessageCalls(), 2) // reaction messages are archived as well
 }
 
-func TestMam_SendArchiveMessages(t *testing.T) {
+func TestMam_SendArchiveMessagesWithReactions(t *testing.T) {
 	// given
 	archiveMessages := []*archivemodel.Message{
 		{
 			ArchiveId: "ortuman",
+			Id:        "sid-0",
 			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)),
 			FromJid:   "ortuman@jackal.im/chamber",
 			ToJid:     "noelia@jackal.im/yard",
-			Message: testMessageStanzaWithParameters(
-				"b0",
+			Message: testMessageStanzaWithID(
+				"m0",
 				"ortuman@jackal.im/chamber",
 				"noelia@jackal.im/yard",
 			).Proto(),
 		},
 		{
 			ArchiveId: "ortuman",
+			Id:        "sid-1",
 			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 01, 00, 00, 00, time.UTC)),
 			FromJid:   "noelia@jackal.im/yard",
 			ToJid:     "ortuman@jackal.im/chamber",
@@ -227,39 +1379,19 @@ func TestMam_SendArchiveMessages(t *testing.T) {
 				"ortuman@jackal.im/chamber",
 			).Proto(),
 		},
-		{
-			ArchiveId: "ortuman",
-			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 02, 00, 00, 00, time.UTC)),
-			FromJid:   "ortuman@jackal.im/chamber",
-			ToJid:     "noelia@jackal.im/yard",
-			Message: testMessageStanzaWithParameters(
-				"b2",
-				"ortuman@jackal.im/chamber",
-				"noelia@jackal.im/yard",
-			).Proto(),
-		},
 	}
 
-	c2sInf := c2smodel.NewInfoMap()
-
 	stmMock := &c2sStreamMock{}
 	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error {
-		bVal, ok := val.(bool)
-		if !ok {
-			return errors.New("unexpected value type")
-		}
-		c2sInf.SetBool(k, bVal)
 		return nil
 	}
-
 	c2sRouterMock := &c2sRouterMock{}
 	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
 		return stmMock, nil
 	}
 
-	routerMock := &routerMock{}
-
 	var respStanzas []stravaganza.Stanza
+	routerMock := &routerMock{}
 	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
 		respStanzas = append(respStanzas, stanza)
 		return nil, nil
@@ -269,70 +1401,27 @@ func TestMam_SendArchiveMessages(t *testing.T) {
 	}
 
 	repMock := &repositoryMock{}
-	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error) {
-		return archiveMessages, nil
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+		return testArchivePage(archiveMessages, page), nil
 	}
-
-	mam := &Mam{
-		rep:    repMock,
-		hk:     hook.NewHooks(),
-		router: routerMock,
-		logger: kitlog.NewNopLogger(),
+	repMock.CountArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+		return len(archiveMessages), nil
 	}
-
-	iq, _ := stravaganza.NewIQBuilder().
-		WithAttribute(stravaganza.ID, "ortuman1").
-		WithAttribute(stravaganza.Type, stravaganza.SetType).
-		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
-		WithAttribute(stravaganza.To, "ortuman@jackal.im").
-		WithChild(
-			stravaganza.NewBuilder("query").
-				WithAttribute(stravaganza.Namespace, mamNamespace).
-				Build(),
-		).
-		BuildIQ()
-
-	// when
-	_ = mam.ProcessIQ(context.Background(), iq)
-
-	// then
-	require.Len(t, respStanzas, 4) // 3 messages + result iq
-
-	require.Equal(t, stravaganza.MessageName, respStanzas[0].Name())
-	require.Equal(t, stravaganza.MessageName, respStanzas[1].Name())
-	require.Equal(t, stravaganza.MessageName, respStanzas[2].Name())
-	require.Equal(t, stravaganza.IQName, respStanzas[3].Name())
-
-	iqRes := respStanzas[3]
-	require.Equal(t, stravaganza.ResultType, iqRes.Type())
-
-	finElem := iqRes.ChildNamespace("fin", mamNamespace)
-	require.NotNil(t, finElem)
-
-	rsmRes := finElem.ChildNamespace("set", xep0059.RSMNamespace)
-	require.NotNil(t, rsmRes)
-
-	count := rsmRes.Child("count")
-	require.NotNil(t, count)
-	require.Equal(t, "3", count.Text())
-
-	require.Len(t, stmMock.SetInfoValueCalls(), 1)
-	require.True(t, IsArchiveRequested(c2sInf))
-}
-
-func TestMam_Forbidden(t *testing.T) {
-	routerMock := &routerMock{}
-
-	var respStanzas []stravaganza.Stanza
-	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
-		respStanzas = append(respStanzas, stanza)
-		return nil, nil
+	repMock.FetchReactionsFunc = func(ctx context.Context, archiveID string, targetIDs []string) ([]*reactionmodel.Reactions, error) {
+		return []*reactionmodel.Reactions{
+			{ArchiveId: "ortuman", TargetId: "sid-0", Jid: "noelia@jackal.im", Reactions: []string{"👍", "🐢"}},
+			{ArchiveId: "ortuman", TargetId: "sid-0", Jid: "ortuman@jackal.im", Reactions: []string{"👍"}},
+		}, nil
 	}
 
-	repMock := &repositoryMock{}
+	hostsMock := &hostsMock{}
+	hostsMock.IsMobileProfileFunc = func(_ string) bool { return false }
 
 	mam := &Mam{
+		cfg:    Config{AggregateReactions: true},
 		rep:    repMock,
+		hk:     hook.NewHooks(),
+		hosts:  hostsMock,
 		router: routerMock,
 		logger: kitlog.NewNopLogger(),
 	}
@@ -340,11 +1429,16 @@ func TestMam_Forbidden(t *testing.T) {
 	iq, _ := stravaganza.NewIQBuilder().
 		WithAttribute(stravaganza.ID, "ortuman1").
 		WithAttribute(stravaganza.Type, stravaganza.SetType).
-		WithAttribute(stravaganza.From, "noelia@jackal.im/chamber").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
 		WithAttribute(stravaganza.To, "ortuman@jackal.im").
 		WithChild(
 			stravaganza.NewBuilder("query").
 				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithChild(
+					stravaganza.NewBuilder("reactions").
+						WithAttribute(stravaganza.Namespace, mamReactionsNamespace).
+						Build(),
+				).
 				Build(),
 		).
 		BuildIQ()
@@ -352,49 +1446,25 @@ func TestMam_Forbidden(t *testing.T) {
 	// when
 	_ = mam.ProcessIQ(context.Background(), iq)
 
-	require.Len(t, respStanzas, 1)
-	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
-}
-
-func TestMam_DeleteArchive(t *testing.T) {
-	// given
-	var deletedArchiveID string
-
-	repMock := &repositoryMock{}
-	repMock.DeleteArchiveFunc = func(ctx context.Context, archiveID string) error {
-		deletedArchiveID = archiveID
-		return nil
-	}
-
-	hosts := &hostsMock{}
-	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+	// then
+	require.Len(t, respStanzas, 3) // 2 messages + result iq
 
-	hk := hook.NewHooks()
-	mam := &Mam{
-		hk:     hk,
-		hosts:  hosts,
-		rep:    repMock,
-		logger: kitlog.NewNopLogger(),
-	}
-	_ = mam.Start(context.Background())
-	t.Cleanup(func() {
-		_ = mam.Stop(context.Background())
-	})
+	summary := respStanzas[0].ChildNamespace("result", mamNamespace).ChildNamespace("reactions", mamReactionsNamespace)
+	require.NotNil(t, summary)
 
-	// when
-	_, err := hk.Run(hook.UserDeleted, &hook.ExecutionContext{
-		Info: &hook.UserInfo{
-			Username: "ortuman",
-		},
-		Context: context.Background(),
-	},
-	)
+	reactions := summary.Children("reaction")
+	require.Len(t, reactions, 2)
+	require.Equal(t, "👍", reactions[0].Text())
+	require.Equal(t, "2", reactions[0].Attribute("count"))
+	require.Equal(t, "true", reactions[0].Attribute("self"))
+	require.Equal(t, "🐢", reactions[1].Text())
+	require.Equal(t, "1", reactions[1].Attribute("count"))
+	require.Empty(t, reactions[1].Attribute("self"))
 
-	// then
-	require.NoError(t, err)
-	require.Len(t, repMock.DeleteArchiveCalls(), 1)
+	require.Nil(t, respStanzas[1].ChildNamespace("result", mamNamespace).ChildNamespace("reactions", mamReactionsNamespace))
 
-	require.Equal(t, "ortuman", deletedArchiveID)
+	require.Len(t, repMock.FetchReactionsCalls(), 1)
+	require.Equal(t, []string{"sid-0", "sid-1"}, repMock.FetchReactionsCalls()[0].TargetIDs)
 }
 
 func TestMam_FormToFields(t *testing.T) {
@@ -454,6 +1524,18 @@ func TestMam_FormToFields(t *testing.T) {
 				Ids: []string{"28482-98726-73623", "09af3-cc343-b409f"},
 			},
 		},
+		"full-text-search": {
+			form: &xep0004.DataForm{
+				Type: xep0004.Submit,
+				Fields: []xep0004.Field{
+					{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{mamNamespace}},
+					{Var: "full-text-search", Values: []string{" thane of cawdor "}},
+				},
+			},
+			filters: &archivemodel.Filters{
+				FullTextSearch: "thane of cawdor",
+			},
+		},
 	}
 	for tn, tc := range tcs {
 		t.Run(tn, func(t *testing.T) {
@@ -465,6 +1547,18 @@ func TestMam_FormToFields(t *testing.T) {
 	}
 }
 
+func testMessageWithOriginID(from, to, originID string) *stravaganza.Message {
+	msg, _ := stravaganza.NewBuilderFromElement(testMessageStanzaWithParameters("b0", from, to)).
+		WithChild(
+			stravaganza.NewBuilder("origin-id").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:sid:0").
+				WithAttribute("id", originID).
+				Build(),
+		).
+		BuildMessage()
+	return msg
+}
+
 func testMessageStanzaWithParameters(body, from, to string) *stravaganza.Message {
 	b := stravaganza.NewMessageBuilder()
 	b.WithAttribute("from", from)
@@ -477,3 +1571,112 @@ func testMessageStanzaWithParameters(body, from, to string) *stravaganza.Message
 	msg, _ := b.BuildMessage()
 	return msg
 }
+
+func testMessageStanzaWithID(id, from, to string) *stravaganza.Message {
+	msg, _ := stravaganza.NewBuilderFromElement(testMessageStanzaWithParameters("b0", from, to)).
+		WithAttribute(stravaganza.ID, id).
+		BuildMessage()
+	return msg
+}
+
+func BenchmarkMam_ArchiveMessage(b *testing.B) {
+	txMock := &txMock{}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		return nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		return true, nil
+	}
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return nil, nil
+	}
+	hosts := &hostsMock{}
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		hk:     hk,
+		hosts:  hosts,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	b.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+	msg := testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		execCtx := &hook.ExecutionContext{
+			Info: &hook.C2SStreamInfo{
+				Element: msg,
+			},
+			Context: context.Background(),
+		}
+		if _, err := hk.Run(hook.C2SStreamMessageReceived, execCtx); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := hk.Run(hook.C2SStreamMessageRouted, execCtx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func testReactionsMessage(from, to, targetID string, values ...string) *stravaganza.Message {
+	rb := stravaganza.NewBuilder("reactions").
+		WithAttribute(stravaganza.Namespace, reactionsNamespace).
+		WithAttribute(stravaganza.ID, targetID)
+	for _, v := range values {
+		rb.WithChild(
+			stravaganza.NewBuilder("reaction").
+				WithText(v).
+				Build(),
+		)
+	}
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, to).
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(rb.Build()).
+		BuildMessage()
+	return msg
+}
+
+func testArchivePage(messages []*archivemodel.Message, page *archivemodel.Page) []*archivemodel.Message {
+	if page == nil {
+		return messages
+	}
+	if int(page.Offset) >= len(messages) {
+		return nil
+	}
+	messages = messages[page.Offset:]
+	if page.Limit > 0 && int(page.Limit) < len(messages) {
+		messages = messages[:page.Limit]
+	}
+	return messages
+}
+
+func testRSMSet(max, after, before, index string) stravaganza.Element {
+	b := stravaganza.NewBuilder("set").
+		WithAttribute(stravaganza.Namespace, xep0059.RSMNamespace).
+		WithChild(stravaganza.NewBuilder("max").WithText(max).Build())
+	if len(after) > 0 {
+		b.WithChild(stravaganza.NewBuilder("after").WithText(after).Build())
+	}
+	if len(before) > 0 {
+		b.WithChild(stravaganza.NewBuilder("before").WithText(before).Build())
+	}
+	if len(index) > 0 {
+		b.WithChild(stravaganza.NewBuilder("index").WithText(index).Build())
+	}
+	return b.Build()
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0313
+
+import (
+	"context"
+
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+)
+
+const (
+	alwaysBehavior = "always"
+	neverBehavior  = "never"
+	rosterBehavior = "roster"
+)
+
+func (m *Mam) sendPrefs(ctx context.Context, iq *stravaganza.IQ) error {
+	archiveID := iq.FromJID().Node()
+
+	prefs, err := m.rep.FetchArchivePrefs(ctx, archiveID)
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	if prefs == nil {
+		prefs = &archivemodel.Prefs{ArchiveId: archiveID, DefaultBehavior: alwaysBehavior}
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, prefsElement(prefs)))
+
+	level.Info(m.logger).Log("msg", "requested archive preferences", "archive_id", archiveID)
+
+	return nil
+}
+
+func (m *Mam) setPrefs(ctx context.Context, iq *stravaganza.IQ) error {
+	archiveID := iq.FromJID().Node()
+
+	prefs, ok := elementToPrefs(iq.ChildNamespace("prefs", mamNamespace))
+	if !ok {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	prefs.ArchiveId = archiveID
+
+	if err := m.rep.UpsertArchivePrefs(ctx, prefs); err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, prefsElement(prefs)))
+
+	level.Info(m.logger).Log("msg", "updated archive preferences", "archive_id", archiveID, "default", prefs.DefaultBehavior)
+
+	return nil
+}
+
+// isArchivingAllowed tells whether a message exchanged with peerJID should be stored into archiveID archive
+// according to its owner preferences.
+func (m *Mam) isArchivingAllowed(ctx context.Context, archiveID string, peerJID *jid.JID) (bool, error) {
+	prefs, err := m.rep.FetchArchivePrefs(ctx, archiveID)
+	if err != nil {
+		return false, err
+	}
+	if prefs == nil {
+		return true, nil // archive everything by default
+	}
+	switch {
+	case matchesAnyJID(peerJID, prefs.Never):
+		return false, nil
+	case matchesAnyJID(peerJID, prefs.Always):
+		return true, nil
+	}
+	switch prefs.DefaultBehavior {
+	case neverBehavior:
+		return false, nil
+	case rosterBehavior:
+		ri, err := m.rep.FetchRosterItem(ctx, archiveID, peerJID.ToBareJID().String())
+		if err != nil {
+			return false, err
+		}
+		return ri != nil, nil
+	default:
+		return true, nil
+	}
+}
+
+func prefsElement(prefs *archivemodel.Prefs) stravaganza.Element {
+	return stravaganza.NewBuilder("prefs").
+		WithAttribute(stravaganza.Namespace, mamNamespace).
+		WithAttribute("default", prefs.DefaultBehavior).
+		WithChild(jidListElement(alwaysBehavior, prefs.Always)).
+		WithChild(jidListElement(neverBehavior, prefs.Never)).
+		Build()
+}
+
+func jidListElement(name string, jids []string) stravaganza.Element {
+	b := stravaganza.NewBuilder(name)
+	for _, jd := range jids {
+		b.WithChild(
+			stravaganza.NewBuilder("jid").
+				WithText(jd).
+				Build(),
+		)
+	}
+	return b.Build()
+}
+
+func elementToPrefs(elem stravaganza.Element) (*archivemodel.Prefs, bool) {
+	var prefs archivemodel.Prefs
+
+	prefs.DefaultBehavior = elem.Attribute("default")
+	switch prefs.DefaultBehavior {
+	case alwaysBehavior, neverBehavior, rosterBehavior:
+		break
+	default:
+		return nil, false
+	}
+	var ok bool
+	if prefs.Always, ok = elementToJIDList(elem.Child(alwaysBehavior)); !ok {
+		return nil, false
+	}
+	if prefs.Never, ok = elementToJIDList(elem.Child(neverBehavior)); !ok {
+		return nil, false
+	}
+	// a JID cannot be part of both lists
+	for _, jd := range prefs.Always {
+		for _, neverJID := range prefs.Never {
+			if jd == neverJID {
+				return nil, false
+			}
+		}
+	}
+	return &prefs, true
+}
+
+func elementToJIDList(elem stravaganza.Element) ([]string, bool) {
+	if elem == nil {
+		return nil, true
+	}
+	var jids []string
+	for _, jidElem := range elem.Children("jid") {
+		jd, err := jid.NewWithString(jidElem.Text(), false)
+		if err != nil {
+			return nil, false
+		}
+		jids = append(jids, jd.String())
+	}
+	return jids, true
+}
+
+// matchesAnyJID tells whether jd matches any of the jids list elements.
+// A bare JID list element matches any of its resources.
+func matchesAnyJID(jd *jid.JID, jids []string) bool {
+	for _, s := range jids {
+		if s == jd.String() || s == jd.ToBareJID().String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0313
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/ortuman/jackal/pkg/hook"
+)
+
+const purgeLockID = "mam:purge"
+
+// Purge removes from every host archive all messages older than the configured max age.
+// Host max age overrides replace the configured value, while user ones are applied on top of their host purge.
+func (m *Mam) Purge(ctx context.Context) error {
+	quotas, err := m.fetchQuotas(ctx)
+	if err != nil {
+		return err
+	}
+	if m.cfg.MaxAge == 0 && !quotas.hasMaxAge() {
+		return nil // nothing to purge
+	}
+	// prevent cluster nodes from purging concurrently
+	if err := m.rep.Lock(ctx, purgeLockID); err != nil {
+		return err
+	}
+	defer func() { _ = m.rep.Unlock(context.Background(), purgeLockID) }()
+
+	now := m.nowFn()
+
+	var total int
+	for _, h := range m.hosts.HostNames() {
+		if !m.hosts.IsModuleEnabled(h, ModuleName) {
+			continue
+		}
+		maxAge := quotas.hostMaxAge(h, m.cfg.MaxAge)
+		if maxAge == 0 {
+			continue
+		}
+		before := now.Add(-maxAge)
+
+		n, err := m.rep.DeleteArchiveMessagesBefore(ctx, h, before)
+		if err != nil {
+			return fmt.Errorf("xep0313: failed to purge archive messages of host %s: %w", h, err)
+		}
+		total += n
+
+		// run archive messages purged event
+		_, err = m.hk.Run(hook.ArchiveMessagesPurged, &hook.ExecutionContext{
+			Info: &hook.MamPurgeInfo{
+				Host:   h,
+				Before: before,
+				Count:  n,
+			},
+			Sender:  m,
+			Context: ctx,
+		})
+		if err != nil {
+			return err
+		}
+		level.Info(m.logger).Log("msg", "archive messages purged", "host", h, "before", before.UTC().Format(time.RFC3339), "purged", n)
+	}
+	for archiveID, q := range quotas.users {
+		if q.MaxAgeSeconds == 0 {
+			continue
+		}
+		before := now.Add(-time.Duration(q.MaxAgeSeconds) * time.Second)
+		if err := m.rep.DeleteArchiveExpiredMessages(ctx, archiveID, before); err != nil {
+			return fmt.Errorf("xep0313: failed to purge archive messages of user %s: %w", archiveID, err)
+		}
+	}
+	level.Info(m.logger).Log("msg", "archive purge completed", "purged", total)
+	return nil
+}
+
+func (m *Mam) purgeLoop() {
+	defer close(m.doneCh)
+
+	tc := time.NewTicker(m.cfg.PurgeInterval)
+	defer tc.Stop()
+
+	for {
+		m.purge()
+
+		select {
+		case <-tc.C:
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+func (m *Mam) purge() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// abort purge on stop
+	go func() {
+		select {
+		case <-m.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	if err := m.Purge(ctx); err != nil {
+		level.Warn(m.logger).Log("msg", "archive purge failed", "err", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0313
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/ortuman/jackal/pkg/hook"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMam_Purge(t *testing.T) {
+	// given
+	now := time.Date(2022, 10, 01, 12, 00, 00, 00, time.UTC)
+
+	hostsMock := &hostsMock{}
+	hostsMock.HostNamesFunc = func() []string { return []string{"jackal.im", "jabber.org"} }
+	hostsMock.IsModuleEnabledFunc = func(h, modName string) bool { return h == "jackal.im" }
+
+	var lockID string
+	var purgedHost string
+	var purgedBefore time.Time
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	repMock.LockFunc = func(ctx context.Context, id string) error {
+		lockID = id
+		return nil
+	}
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.DeleteArchiveMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		purgedHost = host
+		purgedBefore = before
+		return 42, nil
+	}
+
+	hk := hook.NewHooks()
+
+	var purgeInf *hook.MamPurgeInfo
+	hk.AddHook(hook.ArchiveMessagesPurged, func(execCtx *hook.ExecutionContext) error {
+		purgeInf = execCtx.Info.(*hook.MamPurgeInfo)
+		return nil
+	}, hook.DefaultPriority)
+
+	mam := &Mam{
+		cfg:    Config{MaxAge: time.Hour * 24},
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+		nowFn:  func() time.Time { return now },
+	}
+
+	// when
+	err := mam.Purge(context.Background())
+
+	// then
+	require.NoError(t, err)
+
+	require.Equal(t, purgeLockID, lockID)
+	require.Len(t, repMock.UnlockCalls(), 1)
+
+	require.Len(t, repMock.DeleteArchiveMessagesBeforeCalls(), 1)
+	require.Equal(t, "jackal.im", purgedHost)
+	require.Equal(t, now.Add(-time.Hour*24), purgedBefore)
+
+	require.NotNil(t, purgeInf)
+	require.Equal(t, "jackal.im", purgeInf.Host)
+	require.Equal(t, 42, purgeInf.Count)
+}
+
+func TestMam_PurgeQuotaOverrides(t *testing.T) {
+	// given
+	now := time.Date(2022, 10, 01, 12, 00, 00, 00, time.UTC)
+
+	hostsMock := &hostsMock{}
+	hostsMock.HostNamesFunc = func() []string { return []string{"jackal.im", "jabber.org"} }
+	hostsMock.IsModuleEnabledFunc = func(h, modName string) bool { return true }
+
+	purgedHosts := make(map[string]time.Time)
+	purgedArchives := make(map[string]time.Time)
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return []*archivemodel.Quota{
+			{Host: "jackal.im", MaxAgeSeconds: 3600},
+			{ArchiveId: "noelia", MaxAgeSeconds: 60},
+			{ArchiveId: "ortuman", QueueSize: 10},
+		}, nil
+	}
+	repMock.LockFunc = func(ctx context.Context, id string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.DeleteArchiveMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		purgedHosts[host] = before
+		return 1, nil
+	}
+	repMock.DeleteArchiveExpiredMessagesFunc = func(ctx context.Context, archiveID string, before time.Time) error {
+		purgedArchives[archiveID] = before
+		return nil
+	}
+
+	mam := &Mam{
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+		nowFn:  func() time.Time { return now },
+	}
+
+	// when
+	err := mam.Purge(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Time{"jackal.im": now.Add(-time.Hour)}, purgedHosts)
+	require.Equal(t, map[string]time.Time{"noelia": now.Add(-time.Minute)}, purgedArchives)
+}
+
+func TestMam_PurgeNoMaxAge(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return []*archivemodel.Quota{{ArchiveId: "ortuman", QueueSize: 10}}, nil
+	}
+
+	mam := &Mam{
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+		nowFn:  time.Now,
+	}
+
+	// when
+	err := mam.Purge(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, repMock.LockCalls(), 0)
+}
+
+func TestMam_InvalidMaxAge(t *testing.T) {
+	// given
+	mam := &Mam{
+		cfg:    Config{MaxAge: time.Hour, PurgeInterval: 0},
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	err := mam.Start(context.Background())
+
+	// then
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0313
+
+import (
+	"context"
+	"time"
+
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+)
+
+// quotasCacheTTL defines how long archive quota overrides are cached before being reloaded from the repository.
+const quotasCacheTTL = time.Minute
+
+type archiveQuotas struct {
+	hosts map[string]*archivemodel.Quota
+	users map[string]*archivemodel.Quota
+}
+
+// fetchQuotas returns all archive quota overrides.
+// Overrides are only modified through the admin API, so they're cached rather than fetched every time
+// a message gets archived. Any change is picked up as soon as the cached copy expires.
+func (m *Mam) fetchQuotas(ctx context.Context) (*archiveQuotas, error) {
+	m.quotasMu.Lock()
+	defer m.quotasMu.Unlock()
+
+	if m.quotas != nil && time.Since(m.quotasLoadedAt) < quotasCacheTTL {
+		return m.quotas, nil
+	}
+	quotas, err := m.rep.FetchArchiveQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aq := &archiveQuotas{
+		hosts: make(map[string]*archivemodel.Quota),
+		users: make(map[string]*archivemodel.Quota),
+	}
+	for _, q := range quotas {
+		switch {
+		case len(q.ArchiveId) > 0:
+			aq.users[q.ArchiveId] = q
+		case len(q.Host) > 0:
+			aq.hosts[q.Host] = q
+		}
+	}
+	m.quotas = aq
+	m.quotasLoadedAt = time.Now()
+	return aq, nil
+}
+
+// invalidateQuotas forces quota overrides to be reloaded on next access.
+func (m *Mam) invalidateQuotas() {
+	m.quotasMu.Lock()
+	m.quotas = nil
+	m.quotasMu.Unlock()
+}
+
+// archiveLimits returns the queue size and max age to be applied to an archive.
+// User quota overrides take precedence over host ones, which in turn take precedence over module configuration.
+func (m *Mam) archiveLimits(ctx context.Context, host, archiveID string) (queueSize int, maxAge time.Duration, err error) {
+	quotas, err := m.fetchQuotas(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	queueSize = m.cfg.QueueSize
+	maxAge = m.cfg.MaxAge
+
+	for _, q := range []*archivemodel.Quota{quotas.hosts[host], quotas.users[archiveID]} {
+		if q == nil {
+			continue
+		}
+		if q.QueueSize != 0 {
+			queueSize = int(q.QueueSize)
+		}
+		if q.MaxAgeSeconds != 0 {
+			maxAge = time.Duration(q.MaxAgeSeconds) * time.Second
+		}
+	}
+	return queueSize, maxAge, nil
+}
+
+// hostMaxAge returns the max age to be applied to host archives, falling back to defaultMaxAge
+// in case no host override was set.
+func (aq *archiveQuotas) hostMaxAge(host string, defaultMaxAge time.Duration) time.Duration {
+	if q := aq.hosts[host]; q != nil && q.MaxAgeSeconds != 0 {
+		return time.Duration(q.MaxAgeSeconds) * time.Second
+	}
+	return defaultMaxAge
+}
+
+// hasMaxAge tells whether any quota override sets a max age.
+func (aq *archiveQuotas) hasMaxAge() bool {
+	for _, qs := range []map[string]*archivemodel.Quota{aq.hosts, aq.users} {
+		for _, q := range qs {
+			if q.MaxAgeSeconds != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
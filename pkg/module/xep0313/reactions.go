@@ -0,0 +1,151 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0313
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/jackal-xmpp/stravaganza"
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	reactionsNamespace    = "urn:xmpp:reactions:0"
+	mamReactionsNamespace = "urn:xmpp:mam:2#reactions"
+)
+
+func (m *Mam) storeReactions(ctx context.Context, msg *stravaganza.Message, archiveID string) error {
+	reactionsElem := msg.ChildNamespace("reactions", reactionsNamespace)
+
+	targetID, err := m.reactionTarget(ctx, archiveID, reactionsElem.Attribute(stravaganza.ID))
+	if err != nil {
+		return err
+	}
+	if len(targetID) == 0 {
+		return nil // target message not found in archive
+	}
+	reactorJID := msg.FromJID().ToBareJID().String()
+
+	var values []string
+	seen := make(map[string]struct{})
+	for _, r := range reactionsElem.Children("reaction") {
+		v := r.Text()
+		if _, ok := seen[v]; ok || len(v) == 0 {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, v)
+	}
+	// an empty reactions element retracts all previous reactions
+	if len(values) == 0 {
+		return m.rep.DeleteReactions(ctx, archiveID, targetID, reactorJID)
+	}
+	return m.rep.UpsertReactions(ctx, &reactionmodel.Reactions{
+		ArchiveId: archiveID,
+		TargetId:  targetID,
+		Jid:       reactorJID,
+		Reactions: values,
+		UpdatedAt: timestamppb.Now(),
+	})
+}
+
+// reactionTarget resolves the archive identifier of the message referenced by a reactions element.
+// Reactions may reference a message either by its stanza-id or by its origin-id (XEP-0359) value.
+// An empty string is returned in case no such message was archived.
+func (m *Mam) reactionTarget(ctx context.Context, archiveID, id string) (string, error) {
+	if len(id) == 0 {
+		return "", nil
+	}
+	messages, err := m.rep.FetchArchiveMessages(ctx, &archivemodel.Filters{Ids: []string{id}}, nil, archiveID)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) > 0 {
+		return messages[0].Id, nil
+	}
+	msg, err := m.rep.FetchArchiveMessageByOriginID(ctx, archiveID, id)
+	if err != nil {
+		return "", err
+	}
+	if msg == nil {
+		return "", nil
+	}
+	return msg.Id, nil
+}
+
+// reactionSummaries returns the aggregated reactions summary element associated to every passed archive message.
+func (m *Mam) reactionSummaries(ctx context.Context, messages []*archivemodel.Message, archiveID, ownJID string) (map[string]stravaganza.Element, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	targetIDs := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		targetIDs = append(targetIDs, msg.Id)
+	}
+	reactions, err := m.rep.FetchReactions(ctx, archiveID, targetIDs)
+	if err != nil {
+		return nil, err
+	}
+	type reactionCount struct {
+		count int
+		self  bool
+	}
+	counts := make(map[string]map[string]*reactionCount)
+	for _, r := range reactions {
+		id := r.TargetId
+		if counts[id] == nil {
+			counts[id] = make(map[string]*reactionCount)
+		}
+		for _, v := range r.Reactions {
+			rc := counts[id][v]
+			if rc == nil {
+				rc = &reactionCount{}
+				counts[id][v] = rc
+			}
+			rc.count++
+			rc.self = rc.self || r.Jid == ownJID
+		}
+	}
+	retVal := make(map[string]stravaganza.Element, len(counts))
+	for id, rcs := range counts {
+		values := make([]string, 0, len(rcs))
+		for v := range rcs {
+			values = append(values, v)
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if rcs[values[i]].count != rcs[values[j]].count {
+				return rcs[values[i]].count > rcs[values[j]].count
+			}
+			return values[i] < values[j]
+		})
+		b := stravaganza.NewBuilder("reactions").
+			WithAttribute(stravaganza.Namespace, mamReactionsNamespace)
+		for _, v := range values {
+			rb := stravaganza.NewBuilder("reaction").
+				WithAttribute("count", strconv.Itoa(rcs[v].count)).
+				WithText(v)
+			if rcs[v].self {
+				rb.WithAttribute("self", "true")
+			}
+			b.WithChild(rb.Build())
+		}
+		retVal[id] = b.Build()
+	}
+	return retVal, nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0352
+
+import (
+	"context"
+	"sync"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/router/stream"
+)
+
+const (
+	csiNamespace = "urn:xmpp:csi:0"
+
+	chatStatesNamespace = "http://jabber.org/protocol/chatstates"
+
+	chatMarkersNamespace = "urn:xmpp:chat-markers:0"
+
+	inactiveCtxKey = "csi:inactive"
+)
+
+const (
+	// ModuleName represents client state indication module name.
+	ModuleName = "csi"
+
+	// XEPNumber represents client state indication XEP number.
+	XEPNumber = "0352"
+)
+
+// Config contains client state indication module configuration options.
+type Config struct {
+	// QueueSize defines the maximum number of deferred stanzas held for an inactive stream.
+	// Once reached, the oldest pending stanza is delivered to the client.
+	QueueSize int `fig:"queue_size" default:"1000"`
+}
+
+// pendingStanzas holds latest deferred stanza per sender and kind, preserving arrival order.
+type pendingStanzas struct {
+	mobile  bool
+	order   []string
+	stanzas map[string]stravaganza.Stanza
+}
+
+func (p *pendingStanzas) set(k string, stanza stravaganza.Stanza) {
+	if _, ok := p.stanzas[k]; !ok {
+		p.order = append(p.order, k)
+	}
+	p.stanzas[k] = stanza // only latest state is kept
+}
+
+func (p *pendingStanzas) popOldest() stravaganza.Stanza {
+	k := p.order[0]
+	p.order = p.order[1:]
+
+	stanza := p.stanzas[k]
+	delete(p.stanzas, k)
+	return stanza
+}
+
+func (p *pendingStanzas) list() []stravaganza.Stanza {
+	ret := make([]stravaganza.Stanza, 0, len(p.order))
+	for _, k := range p.order {
+		ret = append(ret, p.stanzas[k])
+	}
+	return ret
+}
+
+// ClientStateIndication represents a client state indication (XEP-0352) module type.
+type ClientStateIndication struct {
+	cfg    Config
+	hosts  hosts
+	hk     *hook.Hooks
+	logger kitlog.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pendingStanzas
+}
+
+// New returns a new initialized ClientStateIndication instance.
+func New(cfg Config, hosts *host.Hosts, hk *hook.Hooks, logger kitlog.Logger) *ClientStateIndication {
+	return &ClientStateIndication{
+		cfg:     cfg,
+		hosts:   hosts,
+		hk:      hk,
+		pending: make(map[string]*pendingStanzas),
+		logger:  kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+	}
+}
+
+// Name returns client state indication module name.
+func (m *ClientStateIndication) Name() string { return ModuleName }
+
+// StreamFeature returns client state indication module stream feature.
+func (m *ClientStateIndication) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return stravaganza.NewBuilder("csi").
+		WithAttribute(stravaganza.Namespace, csiNamespace).
+		Build(), nil
+}
+
+// ServerFeatures returns client state indication server disco features.
+func (m *ClientStateIndication) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns client state indication account disco features.
+func (m *ClientStateIndication) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts client state indication module.
+func (m *ClientStateIndication) Start(_ context.Context) error {
+	m.hk.AddHook(hook.C2SStreamElementReceived, m.onElementRecv, hook.DefaultPriority)
+	m.hk.AddHook(hook.C2SStreamWillSendElement, m.onWillSendElement, hook.DefaultPriority)
+	m.hk.AddHook(hook.C2SStreamDisconnected, m.onDisconnect, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started csi module")
+	return nil
+}
+
+// Stop stops client state indication module.
+func (m *ClientStateIndication) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.C2SStreamElementReceived, m.onElementRecv)
+	m.hk.RemoveHook(hook.C2SStreamWillSendElement, m.onWillSendElement)
+	m.hk.RemoveHook(hook.C2SStreamDisconnected, m.onDisconnect)
+
+	level.Info(m.logger).Log("msg", "stopped csi module")
+	return nil
+}
+
+func (m *ClientStateIndication) onElementRecv(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+	if inf.Element.Attribute(stravaganza.Namespace) != csiNamespace {
+		return nil
+	}
+	stm := execCtx.Sender.(stream.C2S)
+
+	switch inf.Element.Name() {
+	case "active":
+		if err := m.setActive(execCtx.Context, stm); err != nil {
+			return err
+		}
+	case "inactive":
+		if err := m.setInactive(execCtx.Context, stm); err != nil {
+			return err
+		}
+	}
+	return hook.ErrStopped // already handled
+}
+
+func (m *ClientStateIndication) onWillSendElement(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+
+	stanza, ok := inf.Element.(stravaganza.Stanza)
+	if !ok {
+		return nil
+	}
+	m.mu.Lock()
+	ps := m.pending[inf.ID]
+	if ps == nil {
+		m.mu.Unlock()
+		return nil // stream is active
+	}
+	k := deferKey(stanza, ps.mobile)
+	if len(k) == 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	ps.set(k, stanza)
+
+	var evicted stravaganza.Stanza
+	if m.cfg.QueueSize > 0 && len(ps.order) > m.cfg.QueueSize {
+		evicted = ps.popOldest()
+	}
+	m.mu.Unlock()
+
+	if evicted != nil {
+		inf.Element = evicted // queue is full: deliver oldest pending stanza in place of the current one
+		return nil
+	}
+	return hook.ErrStopped // deferred until client becomes active
+}
+
+func (m *ClientStateIndication) onDisconnect(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SStreamInfo)
+
+	m.mu.Lock()
+	ps := m.pending[inf.ID]
+	delete(m.pending, inf.ID)
+	m.mu.Unlock()
+
+	if ps == nil {
+		return nil
+	}
+	// hand deferred stanzas back to the stream, so that they get queued by stream management
+	// in case the session gets hibernated, and redelivered on resumption.
+	stm := execCtx.Sender.(stream.C2S)
+	for _, stanza := range ps.list() {
+		stm.SendElement(stanza)
+	}
+	return nil
+}
+
+func (m *ClientStateIndication) setInactive(ctx context.Context, stm stream.C2S) error {
+	id := stm.ID().String()
+
+	m.mu.Lock()
+	if m.pending[id] == nil {
+		m.pending[id] = &pendingStanzas{
+			mobile:  m.hosts.IsMobileProfile(stm.JID().Domain()),
+			stanzas: make(map[string]stravaganza.Stanza),
+		}
+	}
+	m.mu.Unlock()
+
+	level.Info(m.logger).Log("msg", "client became inactive", "id", id, "username", stm.Username(), "resource", stm.Resource())
+
+	return stm.SetInfoValue(ctx, inactiveCtxKey, true)
+}
+
+func (m *ClientStateIndication) setActive(ctx context.Context, stm stream.C2S) error {
+	id := stm.ID().String()
+
+	m.mu.Lock()
+	ps := m.pending[id]
+	delete(m.pending, id)
+	m.mu.Unlock()
+
+	if ps != nil {
+		for _, stanza := range ps.list() {
+			stm.SendElement(stanza)
+		}
+	}
+	level.Info(m.logger).Log("msg", "client became active", "id", id, "username", stm.Username(), "resource", stm.Resource())
+
+	return stm.SetInfoValue(ctx, inactiveCtxKey, false)
+}
+
+// deferKey returns the key under which stanza should be deferred, or an empty string
+// in case it must be delivered right away.
+func deferKey(stanza stravaganza.Stanza, mobile bool) string {
+	switch stanza := stanza.(type) {
+	case *stravaganza.Presence:
+		if stanza.IsAvailable() || stanza.IsUnavailable() {
+			return "presence:" + stanza.FromJID().String()
+		}
+	case *stravaganza.Message:
+		// only latest chat marker per conversation is worth delivering
+		if isChatMarker(stanza) {
+			return "marker:" + stanza.FromJID().ToBareJID().String()
+		}
+		// mobile profile also holds back standalone chat state notifications
+		if mobile && isChatStateNotification(stanza) {
+			return "chatstate:" + stanza.FromJID().String()
+		}
+	}
+	return ""
+}
+
+func isChatStateNotification(msg *stravaganza.Message) bool {
+	if msg.IsMessageWithBody() {
+		return false
+	}
+	for _, child := range msg.AllChildren() {
+		if child.Attribute(stravaganza.Namespace) == chatStatesNamespace {
+			return true
+		}
+	}
+	return false
+}
+
+func isChatMarker(msg *stravaganza.Message) bool {
+	if msg.IsMessageWithBody() {
+		return false
+	}
+	for _, child := range msg.AllChildren() {
+		if child.Attribute(stravaganza.Namespace) == chatMarkersNamespace && child.Name() != "markable" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,389 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0352
+
+import (
+	"context"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/stretchr/testify/require"
+)
+
+var testJID, _ = jid.NewWithString("ortuman@jackal.im/yard", true)
+
+func TestCSI_DeduplicatePresences(t *testing.T) {
+	// given
+	var sent []stravaganza.Element
+	var inactive interface{}
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1 }
+	stmMock.JIDFunc = func() *jid.JID { return testJID }
+	stmMock.UsernameFunc = func() string { return "ortuman" }
+	stmMock.ResourceFunc = func() string { return "yard" }
+	stmMock.SetInfoValueFunc = func(_ context.Context, k string, val interface{}) error {
+		if k == inactiveCtxKey {
+			inactive = val
+		}
+		return nil
+	}
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sent = append(sent, elem)
+		return nil
+	}
+	hk := hook.NewHooks()
+
+	m := testCSI(Config{QueueSize: 1000}, false, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	runRecv(t, hk, stmMock, "inactive")
+	require.Equal(t, true, inactive)
+
+	var halts int
+	for _, pr := range []*stravaganza.Presence{
+		testPresence("noelia@jackal.im/balcony", "away"),
+		testPresence("juliet@jackal.im/garden", "dnd"),
+		testPresence("noelia@jackal.im/balcony", "xa"),
+	} {
+		halted, err := runWillSend(hk, stmMock, pr)
+		require.NoError(t, err)
+		if halted {
+			halts++
+		}
+	}
+	// messages are never deferred
+	halted, err := runWillSend(hk, stmMock, stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jackal.im/balcony").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		Build(),
+	)
+	require.NoError(t, err)
+	require.False(t, halted)
+
+	runRecv(t, hk, stmMock, "active")
+
+	// then
+	require.Equal(t, 3, halts)
+	require.Equal(t, false, inactive)
+
+	require.Len(t, sent, 2)
+	require.Equal(t, "noelia@jackal.im/balcony", sent[0].Attribute(stravaganza.From))
+	require.Equal(t, "xa", sent[0].Child("show").Text())
+	require.Equal(t, "juliet@jackal.im/garden", sent[1].Attribute(stravaganza.From))
+}
+
+func TestCSI_FlushPresencesOnDisconnect(t *testing.T) {
+	// given
+	var sent []stravaganza.Element
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1 }
+	stmMock.JIDFunc = func() *jid.JID { return testJID }
+	stmMock.UsernameFunc = func() string { return "ortuman" }
+	stmMock.ResourceFunc = func() string { return "yard" }
+	stmMock.SetInfoValueFunc = func(_ context.Context, _ string, _ interface{}) error { return nil }
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sent = append(sent, elem)
+		return nil
+	}
+	hk := hook.NewHooks()
+
+	m := testCSI(Config{QueueSize: 1000}, false, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	runRecv(t, hk, stmMock, "inactive")
+
+	halted0, _ := runWillSend(hk, stmMock, testPresence("noelia@jackal.im/balcony", "away"))
+	halted1, _ := runWillSend(hk, stmMock, testPresence("juliet@jackal.im/garden", "dnd"))
+
+	runDisconnect(t, hk, stmMock)
+
+	// then
+	require.True(t, halted0)
+	require.True(t, halted1)
+
+	require.Len(t, sent, 2)
+	require.Equal(t, "noelia@jackal.im/balcony", sent[0].Attribute(stravaganza.From))
+	require.Equal(t, "juliet@jackal.im/garden", sent[1].Attribute(stravaganza.From))
+
+	// re-sent stanzas are not deferred again
+	halted, err := runWillSend(hk, stmMock, sent[0])
+	require.NoError(t, err)
+	require.False(t, halted)
+}
+
+func TestCSI_QueueSizeExceeded(t *testing.T) {
+	// given
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1 }
+	stmMock.JIDFunc = func() *jid.JID { return testJID }
+	stmMock.UsernameFunc = func() string { return "ortuman" }
+	stmMock.ResourceFunc = func() string { return "yard" }
+	stmMock.SetInfoValueFunc = func(_ context.Context, _ string, _ interface{}) error { return nil }
+
+	hk := hook.NewHooks()
+
+	m := testCSI(Config{QueueSize: 1}, false, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	runRecv(t, hk, stmMock, "inactive")
+
+	halted0, err0 := runWillSend(hk, stmMock, testPresence("noelia@jackal.im/balcony", "away"))
+
+	inf := &hook.C2SStreamInfo{ID: stmMock.ID().String(), Element: testPresence("juliet@jackal.im/garden", "dnd")}
+	halted1, err1 := hk.Run(hook.C2SStreamWillSendElement, &hook.ExecutionContext{
+		Info:    inf,
+		Sender:  stmMock,
+		Context: context.Background(),
+	})
+
+	// then
+	require.NoError(t, err0)
+	require.NoError(t, err1)
+
+	require.True(t, halted0)
+	require.False(t, halted1)
+	require.Equal(t, "noelia@jackal.im/balcony", inf.Element.Attribute(stravaganza.From))
+}
+
+func TestCSI_MobileProfileChatStates(t *testing.T) {
+	// given
+	var sent []stravaganza.Element
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1 }
+	stmMock.JIDFunc = func() *jid.JID { return testJID }
+	stmMock.UsernameFunc = func() string { return "ortuman" }
+	stmMock.ResourceFunc = func() string { return "yard" }
+	stmMock.SetInfoValueFunc = func(_ context.Context, _ string, _ interface{}) error { return nil }
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sent = append(sent, elem)
+		return nil
+	}
+	hk := hook.NewHooks()
+
+	m := testCSI(Config{QueueSize: 1000}, true, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	runRecv(t, hk, stmMock, "inactive")
+
+	halted0, err0 := runWillSend(hk, stmMock, testChatState("noelia@jackal.im/balcony", "composing"))
+	halted1, err1 := runWillSend(hk, stmMock, testChatState("noelia@jackal.im/balcony", "paused"))
+
+	// messages with body are never deferred
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jackal.im/balcony").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(stravaganza.NewBuilder("body").WithText("hi!").Build()).
+		WithChild(stravaganza.NewBuilder("active").WithAttribute(stravaganza.Namespace, chatStatesNamespace).Build()).
+		BuildMessage()
+	halted2, err2 := runWillSend(hk, stmMock, msg)
+	runRecv(t, hk, stmMock, "active")
+
+	// then
+	require.NoError(t, err0)
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+
+	require.True(t, halted0)
+	require.True(t, halted1)
+	require.False(t, halted2)
+
+	require.Len(t, sent, 1)
+	require.NotNil(t, sent[0].ChildNamespace("paused", chatStatesNamespace))
+}
+
+func TestCSI_CoalesceChatMarkers(t *testing.T) {
+	// given
+	var sent []stravaganza.Element
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1 }
+	stmMock.JIDFunc = func() *jid.JID { return testJID }
+	stmMock.UsernameFunc = func() string { return "ortuman" }
+	stmMock.ResourceFunc = func() string { return "yard" }
+	stmMock.SetInfoValueFunc = func(_ context.Context, _ string, _ interface{}) error { return nil }
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sent = append(sent, elem)
+		return nil
+	}
+	hk := hook.NewHooks()
+
+	m := testCSI(Config{QueueSize: 1000}, false, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	runRecv(t, hk, stmMock, "inactive")
+
+	halted0, err0 := runWillSend(hk, stmMock, testChatMarker("noelia@jackal.im/balcony", "received", "m1"))
+	halted1, err1 := runWillSend(hk, stmMock, testChatMarker("noelia@jackal.im/yard", "displayed", "m2"))
+	halted2, err2 := runWillSend(hk, stmMock, testChatMarker("juliet@jackal.im/garden", "displayed", "m3"))
+	runRecv(t, hk, stmMock, "active")
+
+	// then
+	require.NoError(t, err0)
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+
+	require.True(t, halted0)
+	require.True(t, halted1)
+	require.True(t, halted2)
+
+	require.Len(t, sent, 2)
+	require.Equal(t, "m2", sent[0].ChildNamespace("displayed", chatMarkersNamespace).Attribute(stravaganza.ID))
+	require.Equal(t, "m3", sent[1].ChildNamespace("displayed", chatMarkersNamespace).Attribute(stravaganza.ID))
+}
+
+func TestCSI_FlushChatMarkersOnDisconnect(t *testing.T) {
+	// given
+	var sent []stravaganza.Element
+
+	stmMock := &c2sStreamMock{}
+	stmMock.IDFunc = func() stream.C2SID { return 1 }
+	stmMock.JIDFunc = func() *jid.JID { return testJID }
+	stmMock.UsernameFunc = func() string { return "ortuman" }
+	stmMock.ResourceFunc = func() string { return "yard" }
+	stmMock.SetInfoValueFunc = func(_ context.Context, _ string, _ interface{}) error { return nil }
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sent = append(sent, elem)
+		return nil
+	}
+	hk := hook.NewHooks()
+
+	m := testCSI(Config{QueueSize: 1000}, false, hk)
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	runRecv(t, hk, stmMock, "inactive")
+
+	halted0, _ := runWillSend(hk, stmMock, testChatMarker("noelia@jackal.im/balcony", "received", "m1"))
+	halted1, _ := runWillSend(hk, stmMock, testChatMarker("noelia@jackal.im/yard", "displayed", "m2"))
+
+	runDisconnect(t, hk, stmMock)
+
+	// then
+	require.True(t, halted0)
+	require.True(t, halted1)
+
+	require.Len(t, sent, 1)
+	require.Equal(t, "m2", sent[0].ChildNamespace("displayed", chatMarkersNamespace).Attribute(stravaganza.ID))
+
+	m.mu.Lock()
+	require.Len(t, m.pending, 0)
+	m.mu.Unlock()
+}
+
+func runRecv(t *testing.T, hk *hook.Hooks, stm stream.C2S, name string) {
+	halted, err := hk.Run(hook.C2SStreamElementReceived, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			ID: stm.ID().String(),
+			Element: stravaganza.NewBuilder(name).
+				WithAttribute(stravaganza.Namespace, csiNamespace).
+				Build(),
+		},
+		Sender:  stm,
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+	require.True(t, halted)
+}
+
+func runDisconnect(t *testing.T, hk *hook.Hooks, stm stream.C2S) {
+	_, err := hk.Run(hook.C2SStreamDisconnected, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			ID: stm.ID().String(),
+		},
+		Sender:  stm,
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+}
+
+func runWillSend(hk *hook.Hooks, stm stream.C2S, elem stravaganza.Element) (bool, error) {
+	return hk.Run(hook.C2SStreamWillSendElement, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			ID:      stm.ID().String(),
+			Element: elem,
+		},
+		Sender:  stm,
+		Context: context.Background(),
+	})
+}
+
+func testCSI(cfg Config, mobile bool, hk *hook.Hooks) *ClientStateIndication {
+	hostsMock := &hostsMock{}
+	hostsMock.IsMobileProfileFunc = func(_ string) bool { return mobile }
+
+	return &ClientStateIndication{
+		cfg:     cfg,
+		hosts:   hostsMock,
+		hk:      hk,
+		pending: make(map[string]*pendingStanzas),
+		logger:  kitlog.NewNopLogger(),
+	}
+}
+
+func testChatState(from, state string) *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(
+			stravaganza.NewBuilder(state).
+				WithAttribute(stravaganza.Namespace, chatStatesNamespace).
+				Build(),
+		).
+		BuildMessage()
+	return msg
+}
+
+func testPresence(from, show string) *stravaganza.Presence {
+	pr, _ := stravaganza.NewBuilder("presence").
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		WithChild(
+			stravaganza.NewBuilder("show").
+				WithText(show).
+				Build(),
+		).
+		BuildPresence()
+	return pr
+}
+
+func testChatMarker(from, marker, id string) *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, from).
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(
+			stravaganza.NewBuilder(marker).
+				WithAttribute(stravaganza.Namespace, chatMarkersNamespace).
+				WithAttribute(stravaganza.ID, id).
+				Build(),
+		).
+		BuildMessage()
+	return msg
+}
@@ -0,0 +1,368 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0355
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/ortuman/jackal/pkg/component"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/router"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+)
+
+const (
+	delegationNamespace = "urn:xmpp:delegation:2"
+	forwardNamespace    = "urn:xmpp:forward:0"
+
+	bareNamespacePrefix = delegationNamespace + ":bare:"
+)
+
+const (
+	// ModuleName represents namespace delegation module name.
+	ModuleName = "delegation"
+
+	// XEPNumber represents namespace delegation XEP number.
+	XEPNumber = "0355"
+)
+
+// Config contains namespace delegation module configuration options.
+type Config struct {
+	// RequestTimeout defines the maximum amount of time to wait for a delegated request response.
+	RequestTimeout time.Duration `fig:"request_timeout" default:"15s"`
+
+	// Delegations contains the set of delegated namespaces.
+	Delegations []DelegationConfig `fig:"delegations"`
+}
+
+// DelegationConfig defines a namespace delegation.
+type DelegationConfig struct {
+	// Namespace is the delegated IQ payload namespace.
+	Namespace string `fig:"namespace"`
+
+	// Component is the host of the external component the namespace is delegated to.
+	Component string `fig:"component"`
+
+	// Bare tells whether namespace is delegated on user bare JIDs instead of on the server.
+	Bare bool `fig:"bare"`
+
+	// Attributes restricts delegation to payloads containing at least one of these attributes.
+	Attributes []string `fig:"attributes"`
+}
+
+func (c *DelegationConfig) matches(iq *stravaganza.IQ) bool {
+	if c.Bare != iq.ToJID().IsBare() {
+		return false
+	}
+	if len(c.Attributes) == 0 {
+		return true
+	}
+	payload := iq.AllChildren()[0]
+	for _, attr := range c.Attributes {
+		if len(payload.Attribute(attr)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type pendingIQ struct {
+	cHost string
+	iq    *stravaganza.IQ
+	tm    *time.Timer
+}
+
+// Delegation represents a namespace delegation (XEP-0355) module type.
+type Delegation struct {
+	cfg    Config
+	router router.Router
+	hosts  hosts
+	comps  components
+	hk     *hook.Hooks
+	logger kitlog.Logger
+
+	mu      sync.Mutex
+	authIDs map[string]string
+	pending map[string]*pendingIQ
+}
+
+// New returns a new initialized Delegation instance.
+func New(
+	cfg Config,
+	router router.Router,
+	hosts *host.Hosts,
+	comps *component.Components,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+) *Delegation {
+	return &Delegation{
+		cfg:     cfg,
+		router:  router,
+		hosts:   hosts,
+		comps:   comps,
+		hk:      hk,
+		authIDs: make(map[string]string),
+		pending: make(map[string]*pendingIQ),
+		logger:  kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+	}
+}
+
+// Name returns namespace delegation module name.
+func (m *Delegation) Name() string { return ModuleName }
+
+// StreamFeature returns namespace delegation module stream feature.
+func (m *Delegation) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns namespace delegation server disco features.
+func (m *Delegation) ServerFeatures(_ context.Context) ([]string, error) {
+	return []string{delegationNamespace}, nil
+}
+
+// AccountFeatures returns namespace delegation account disco features.
+func (m *Delegation) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts namespace delegation module.
+func (m *Delegation) Start(_ context.Context) error {
+	m.hk.AddHook(hook.ExternalComponentAuthenticated, m.onComponentAuthenticated, hook.DefaultPriority)
+	m.hk.AddHook(hook.ExternalComponentUnregistered, m.onComponentUnregistered, hook.DefaultPriority)
+	m.hk.AddHook(hook.ExternalComponentElementReceived, m.onComponentElementRecv, hook.HighestPriority)
+	m.hk.AddHook(hook.ModulesWillProcessIQ, m.onWillProcessIQ, hook.HighestPriority)
+
+	level.Info(m.logger).Log("msg", "started delegation module", "delegations", len(m.cfg.Delegations))
+	return nil
+}
+
+// Stop stops namespace delegation module.
+func (m *Delegation) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.ExternalComponentAuthenticated, m.onComponentAuthenticated)
+	m.hk.RemoveHook(hook.ExternalComponentUnregistered, m.onComponentUnregistered)
+	m.hk.RemoveHook(hook.ExternalComponentElementReceived, m.onComponentElementRecv)
+	m.hk.RemoveHook(hook.ModulesWillProcessIQ, m.onWillProcessIQ)
+
+	m.mu.Lock()
+	for id, p := range m.pending {
+		p.tm.Stop()
+		delete(m.pending, id)
+	}
+	m.mu.Unlock()
+
+	level.Info(m.logger).Log("msg", "stopped delegation module")
+	return nil
+}
+
+func (m *Delegation) onComponentAuthenticated(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+
+	db := stravaganza.NewBuilder("delegation").
+		WithAttribute(stravaganza.Namespace, delegationNamespace)
+
+	var delegated bool
+	for _, dCfg := range m.cfg.Delegations {
+		if dCfg.Component != inf.Host {
+			continue
+		}
+		ns := dCfg.Namespace
+		if dCfg.Bare {
+			ns = bareNamespacePrefix + ns
+		}
+		b := stravaganza.NewBuilder("delegated").
+			WithAttribute("namespace", ns)
+		for _, attr := range dCfg.Attributes {
+			b.WithChild(
+				stravaganza.NewBuilder("attribute").
+					WithAttribute("name", attr).
+					Build(),
+			)
+		}
+		db.WithChild(b.Build())
+		delegated = true
+	}
+	if !delegated {
+		return nil
+	}
+	m.mu.Lock()
+	m.authIDs[inf.ID] = inf.Host
+	m.mu.Unlock()
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.From, m.hosts.DefaultHostName()).
+		WithAttribute(stravaganza.To, inf.Host).
+		WithChild(db.Build()).
+		BuildMessage()
+
+	level.Info(m.logger).Log("msg", "delegated namespaces to component", "component_host", inf.Host)
+
+	return m.sendToComponent(execCtx.Context, inf.Host, msg)
+}
+
+func (m *Delegation) onComponentUnregistered(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+
+	m.mu.Lock()
+	delete(m.authIDs, inf.ID)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Delegation) onWillProcessIQ(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ModulesIQInfo)
+
+	iq := inf.IQ
+	if iq.ChildrenCount() == 0 {
+		return nil
+	}
+	ns := iq.AllChildren()[0].Attribute(stravaganza.Namespace)
+
+	for _, dCfg := range m.cfg.Delegations {
+		if dCfg.Namespace != ns || !dCfg.matches(iq) {
+			continue
+		}
+		comp := m.comps.Component(dCfg.Component)
+		if comp == nil {
+			return nil // component not available: fallback to local processing
+		}
+		if err := m.forward(execCtx.Context, comp, iq); err != nil {
+			return err
+		}
+		return hook.ErrStopped
+	}
+	return nil
+}
+
+func (m *Delegation) onComponentElementRecv(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+
+	iq, ok := inf.Element.(*stravaganza.IQ)
+	if !ok || iq.IsGet() || iq.IsSet() {
+		return nil
+	}
+	m.mu.Lock()
+	cHost := m.authIDs[inf.ID]
+	p := m.pending[iq.ID()]
+	if p == nil || p.cHost != cHost {
+		m.mu.Unlock()
+		return nil
+	}
+	delete(m.pending, iq.ID())
+	m.mu.Unlock()
+
+	p.tm.Stop()
+
+	ctx := execCtx.Context
+	if err := m.processResponse(ctx, p, iq); err != nil {
+		return err
+	}
+	return hook.ErrStopped
+}
+
+func (m *Delegation) forward(ctx context.Context, comp component.Component, iq *stravaganza.IQ) error {
+	id := uuid.New().String()
+	cHost := comp.Host()
+
+	fwdIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, id).
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, iq.ToJID().Domain()).
+		WithAttribute(stravaganza.To, cHost).
+		WithChild(
+			stravaganza.NewBuilder("delegation").
+				WithAttribute(stravaganza.Namespace, delegationNamespace).
+				WithChild(xmpputil.MakeForwardedStanza(iq, nil)).
+				Build(),
+		).
+		BuildIQ()
+
+	p := &pendingIQ{cHost: cHost, iq: iq}
+	p.tm = time.AfterFunc(m.cfg.RequestTimeout, func() {
+		m.onRequestTimeout(id)
+	})
+	m.mu.Lock()
+	m.pending[id] = p
+	m.mu.Unlock()
+
+	level.Info(m.logger).Log("msg", "delegated iq to component", "component_host", cHost, "id", iq.ID(), "from", iq.FromJID().String())
+
+	return comp.ProcessStanza(ctx, fwdIQ)
+}
+
+func (m *Delegation) processResponse(ctx context.Context, p *pendingIQ, iq *stravaganza.IQ) error {
+	var respEl stravaganza.Element
+	if iq.IsResult() {
+		if d := iq.ChildNamespace("delegation", delegationNamespace); d != nil {
+			if fwd := d.ChildNamespace("forwarded", forwardNamespace); fwd != nil {
+				respEl = fwd.Child("iq")
+			}
+		}
+	}
+	if respEl == nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(p.iq, stanzaerror.ServiceUnavailable))
+		return nil
+	}
+	typ := respEl.Attribute(stravaganza.Type)
+	if typ != stravaganza.ResultType && typ != stravaganza.ErrorType {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(p.iq, stanzaerror.ServiceUnavailable))
+		return nil
+	}
+	// response is always attributed to the original request target
+	resp, err := stravaganza.NewBuilderFromElement(respEl).
+		WithoutAttribute(stravaganza.Namespace).
+		WithAttribute(stravaganza.ID, p.iq.ID()).
+		WithAttribute(stravaganza.From, p.iq.ToJID().String()).
+		WithAttribute(stravaganza.To, p.iq.FromJID().String()).
+		BuildIQ()
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(p.iq, stanzaerror.ServiceUnavailable))
+		return nil
+	}
+	_, _ = m.router.Route(ctx, resp)
+	return nil
+}
+
+func (m *Delegation) onRequestTimeout(id string) {
+	m.mu.Lock()
+	p := m.pending[id]
+	delete(m.pending, id)
+	m.mu.Unlock()
+
+	if p == nil {
+		return
+	}
+	level.Warn(m.logger).Log("msg", "delegated iq timed out", "component_host", p.cHost, "id", p.iq.ID())
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.RequestTimeout)
+	defer cancel()
+	_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(p.iq, stanzaerror.RemoteServerTimeout))
+}
+
+func (m *Delegation) sendToComponent(ctx context.Context, cHost string, stanza stravaganza.Stanza) error {
+	comp := m.comps.Component(cHost)
+	if comp == nil {
+		return nil
+	}
+	return comp.ProcessStanza(ctx, stanza)
+}
@@ -0,0 +1,258 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0355
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/component"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+const profileNamespace = "urn:xmpp:profile:0"
+
+func TestDelegation_AdvertiseNamespaces(t *testing.T) {
+	// given
+	m, compMock, _, hk := testDelegation()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	authenticate(t, hk)
+
+	// then
+	require.Len(t, sent, 1)
+
+	d := sent[0].ChildNamespace("delegation", delegationNamespace)
+	require.NotNil(t, d)
+	require.Equal(t, bareNamespacePrefix+profileNamespace, d.Child("delegated").Attribute("namespace"))
+	require.Equal(t, "node", d.Child("delegated").Child("attribute").Attribute("name"))
+}
+
+func TestDelegation_ForwardIQ(t *testing.T) {
+	// given
+	m, compMock, routerMock, hk := testDelegation()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	var routed []stravaganza.Stanza
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		routed = append(routed, stanza)
+		return nil, nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+	authenticate(t, hk)
+	sent = nil
+
+	// when
+	iq := testProfileIQ("p1")
+	halted, err := hk.Run(hook.ModulesWillProcessIQ, &hook.ExecutionContext{
+		Info:    &hook.ModulesIQInfo{IQ: iq},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+	require.True(t, halted)
+
+	require.Len(t, sent, 1)
+	fwdIQ := sent[0]
+
+	fwd := fwdIQ.ChildNamespace("delegation", delegationNamespace).ChildNamespace("forwarded", forwardNamespace)
+	require.NotNil(t, fwd)
+	require.Equal(t, "p1", fwd.Child("iq").Attribute(stravaganza.ID))
+
+	// component response tries to spoof attribution
+	respIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, fwdIQ.Attribute(stravaganza.ID)).
+		WithAttribute(stravaganza.Type, stravaganza.ResultType).
+		WithAttribute(stravaganza.From, "profile.jackal.im").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("delegation").
+				WithAttribute(stravaganza.Namespace, delegationNamespace).
+				WithChild(
+					stravaganza.NewBuilder("forwarded").
+						WithAttribute(stravaganza.Namespace, forwardNamespace).
+						WithChild(
+							stravaganza.NewIQBuilder().
+								WithAttribute(stravaganza.Namespace, "jabber:client").
+								WithAttribute(stravaganza.ID, "p1").
+								WithAttribute(stravaganza.Type, stravaganza.ResultType).
+								WithAttribute(stravaganza.From, "noelia@jackal.im").
+								WithAttribute(stravaganza.To, "romeo@jackal.im/balcony").
+								Build(),
+						).
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+	halted, err = hk.Run(hook.ExternalComponentElementReceived, &hook.ExecutionContext{
+		Info: &hook.ExternalComponentInfo{
+			ID:      "ext_comp:1",
+			Host:    "profile.jackal.im",
+			Element: respIQ,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.NoError(t, err)
+	require.True(t, halted)
+
+	require.Len(t, routed, 1)
+	require.Equal(t, "p1", routed[0].Attribute(stravaganza.ID))
+	require.Equal(t, stravaganza.ResultType, routed[0].Attribute(stravaganza.Type))
+	require.Equal(t, "ortuman@jackal.im", routed[0].Attribute(stravaganza.From))
+	require.Equal(t, "ortuman@jackal.im/yard", routed[0].Attribute(stravaganza.To))
+	require.Len(t, m.pending, 0)
+}
+
+func TestDelegation_AttributeFiltering(t *testing.T) {
+	// given
+	m, _, _, hk := testDelegation()
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "p1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("profile").
+				WithAttribute(stravaganza.Namespace, profileNamespace).
+				Build(),
+		).
+		BuildIQ()
+	halted, err := hk.Run(hook.ModulesWillProcessIQ, &hook.ExecutionContext{
+		Info:    &hook.ModulesIQInfo{IQ: iq},
+		Context: context.Background(),
+	})
+
+	// then
+	require.NoError(t, err)
+	require.False(t, halted)
+}
+
+func TestDelegation_RequestTimeout(t *testing.T) {
+	// given
+	m, compMock, routerMock, hk := testDelegation()
+	m.cfg.RequestTimeout = time.Millisecond * 50
+
+	compMock.ProcessStanzaFunc = func(_ context.Context, _ stravaganza.Stanza) error { return nil }
+
+	routedCh := make(chan stravaganza.Stanza, 1)
+	routerMock.RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		routedCh <- stanza
+		return nil, nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	_, _ = hk.Run(hook.ModulesWillProcessIQ, &hook.ExecutionContext{
+		Info:    &hook.ModulesIQInfo{IQ: testProfileIQ("p1")},
+		Context: context.Background(),
+	})
+
+	// then
+	select {
+	case stanza := <-routedCh:
+		require.Equal(t, stravaganza.ErrorType, stanza.Attribute(stravaganza.Type))
+		require.NotNil(t, stanza.Child("error").Child("remote-server-timeout"))
+	case <-time.After(time.Second):
+		require.Fail(t, "delegated request did not time out")
+	}
+}
+
+func testDelegation() (*Delegation, *componentMock, *routerMock, *hook.Hooks) {
+	hostsMock := &hostsMock{}
+	hostsMock.DefaultHostNameFunc = func() string { return "jackal.im" }
+
+	compMock := &componentMock{}
+	compMock.HostFunc = func() string { return "profile.jackal.im" }
+
+	compsMock := &componentsMock{}
+	compsMock.ComponentFunc = func(cHost string) component.Component {
+		if cHost == "profile.jackal.im" {
+			return compMock
+		}
+		return nil
+	}
+	routerMock := &routerMock{}
+
+	hk := hook.NewHooks()
+	m := &Delegation{
+		cfg: Config{
+			RequestTimeout: time.Second * 15,
+			Delegations: []DelegationConfig{
+				{
+					Namespace:  profileNamespace,
+					Component:  "profile.jackal.im",
+					Bare:       true,
+					Attributes: []string{"node"},
+				},
+			},
+		},
+		router:  routerMock,
+		hosts:   hostsMock,
+		comps:   compsMock,
+		hk:      hk,
+		authIDs: make(map[string]string),
+		pending: make(map[string]*pendingIQ),
+		logger:  kitlog.NewNopLogger(),
+	}
+	return m, compMock, routerMock, hk
+}
+
+func testProfileIQ(id string) *stravaganza.IQ {
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, id).
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("profile").
+				WithAttribute(stravaganza.Namespace, profileNamespace).
+				WithAttribute("node", "public").
+				Build(),
+		).
+		BuildIQ()
+	return iq
+}
+
+func authenticate(t *testing.T, hk *hook.Hooks) {
+	_, err := hk.Run(hook.ExternalComponentAuthenticated, &hook.ExecutionContext{
+		Info: &hook.ExternalComponentInfo{
+			ID:   "ext_comp:1",
+			Host: "profile.jackal.im",
+		},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,560 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0356
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/ortuman/jackal/pkg/component"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+)
+
+const (
+	privilegeNamespace = "urn:xmpp:privilege:2"
+	rosterNamespace    = "jabber:iq:roster"
+	forwardNamespace   = "urn:xmpp:forward:0"
+)
+
+const (
+	// ModuleName represents privileged entity module name.
+	ModuleName = "privilege"
+
+	// XEPNumber represents privileged entity XEP number.
+	XEPNumber = "0356"
+)
+
+const (
+	permNone     = "none"
+	permGet      = "get"
+	permSet      = "set"
+	permBoth     = "both"
+	permOutgoing = "outgoing"
+)
+
+// Config contains privileged entity module configuration options.
+type Config struct {
+	// Components contains the set of privileges granted to every external component.
+	Components []ComponentConfig `fig:"components"`
+}
+
+// ComponentConfig defines the privileges granted to an external component.
+type ComponentConfig struct {
+	// Host is the external component host domain.
+	Host string `fig:"host"`
+
+	// Roster defines component roster access (none, get, set or both).
+	Roster string `fig:"roster" default:"none"`
+
+	// Message defines component message permission (none or outgoing).
+	Message string `fig:"message" default:"none"`
+
+	// IQ contains the set of namespaces the component is allowed to query on behalf of users.
+	IQ []IQConfig `fig:"iq"`
+}
+
+// IQConfig defines a namespace IQ permission.
+type IQConfig struct {
+	// Namespace is the permitted IQ payload namespace.
+	Namespace string `fig:"namespace"`
+
+	// Type defines the permitted IQ type (get, set or both).
+	Type string `fig:"type" default:"both"`
+}
+
+func (c *ComponentConfig) allowsRoster(iq *stravaganza.IQ) bool {
+	return allowsType(c.Roster, iq)
+}
+
+func (c *ComponentConfig) allowsIQ(namespace string, iq *stravaganza.IQ) bool {
+	for _, iqCfg := range c.IQ {
+		if iqCfg.Namespace == namespace && allowsType(iqCfg.Type, iq) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowsType(perm string, iq *stravaganza.IQ) bool {
+	switch perm {
+	case permBoth:
+		return iq.IsGet() || iq.IsSet()
+	case permGet:
+		return iq.IsGet()
+	case permSet:
+		return iq.IsSet()
+	}
+	return false
+}
+
+type pendingIQ struct {
+	cHost   string
+	iq      *stravaganza.IQ
+	innerID string
+	wrapped bool
+}
+
+// Privilege represents a privileged entity (XEP-0356) module type.
+type Privilege struct {
+	perms  map[string]*ComponentConfig
+	router router.Router
+	hosts  hosts
+	comps  components
+	rep    repository.Roster
+	hk     *hook.Hooks
+	logger kitlog.Logger
+
+	mu      sync.RWMutex
+	mods    modules
+	authIDs map[string]string
+	pending map[string]*pendingIQ
+}
+
+// New returns a new initialized Privilege instance.
+func New(
+	cfg Config,
+	router router.Router,
+	hosts *host.Hosts,
+	comps *component.Components,
+	rep repository.Repository,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+) *Privilege {
+	perms := make(map[string]*ComponentConfig, len(cfg.Components))
+	for i := range cfg.Components {
+		perms[cfg.Components[i].Host] = &cfg.Components[i]
+	}
+	return &Privilege{
+		perms:   perms,
+		router:  router,
+		hosts:   hosts,
+		comps:   comps,
+		rep:     rep,
+		hk:      hk,
+		authIDs: make(map[string]string),
+		pending: make(map[string]*pendingIQ),
+		logger:  kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+	}
+}
+
+// Name returns privileged entity module name.
+func (m *Privilege) Name() string { return ModuleName }
+
+// StreamFeature returns privileged entity module stream feature.
+func (m *Privilege) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns privileged entity server disco features.
+func (m *Privilege) ServerFeatures(_ context.Context) ([]string, error) {
+	return []string{privilegeNamespace}, nil
+}
+
+// AccountFeatures returns privileged entity account disco features.
+func (m *Privilege) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Start starts privileged entity module.
+func (m *Privilege) Start(_ context.Context) error {
+	m.hk.AddHook(hook.ModulesStarted, m.onModulesStarted, hook.DefaultPriority)
+	m.hk.AddHook(hook.ExternalComponentAuthenticated, m.onComponentAuthenticated, hook.DefaultPriority)
+	m.hk.AddHook(hook.ExternalComponentUnregistered, m.onComponentUnregistered, hook.DefaultPriority)
+	m.hk.AddHook(hook.ExternalComponentElementReceived, m.onComponentElementRecv, hook.HighestPriority)
+	m.hk.AddHook(hook.C2SRouterWillRouteStanza, m.onC2SWillRoute, hook.HighestPriority)
+	m.hk.AddHook(hook.RosterItemUpdated, m.onRosterItemUpdated, hook.DefaultPriority)
+
+	level.Info(m.logger).Log("msg", "started privilege module", "privileged_components", len(m.perms))
+	return nil
+}
+
+// Stop stops privileged entity module.
+func (m *Privilege) Stop(_ context.Context) error {
+	m.hk.RemoveHook(hook.ModulesStarted, m.onModulesStarted)
+	m.hk.RemoveHook(hook.ExternalComponentAuthenticated, m.onComponentAuthenticated)
+	m.hk.RemoveHook(hook.ExternalComponentUnregistered, m.onComponentUnregistered)
+	m.hk.RemoveHook(hook.ExternalComponentElementReceived, m.onComponentElementRecv)
+	m.hk.RemoveHook(hook.C2SRouterWillRouteStanza, m.onC2SWillRoute)
+	m.hk.RemoveHook(hook.RosterItemUpdated, m.onRosterItemUpdated)
+
+	level.Info(m.logger).Log("msg", "stopped privilege module")
+	return nil
+}
+
+func (m *Privilege) onModulesStarted(execCtx *hook.ExecutionContext) error {
+	m.mu.Lock()
+	m.mods = execCtx.Sender.(modules)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Privilege) onComponentAuthenticated(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+
+	perm := m.perms[inf.Host]
+	if perm == nil {
+		return nil // not a privileged component
+	}
+	m.mu.Lock()
+	m.authIDs[inf.ID] = inf.Host
+	m.mu.Unlock()
+
+	level.Info(m.logger).Log("msg", "granted component privileges", "component_host", inf.Host)
+
+	return m.sendToComponent(execCtx.Context, inf.Host, m.privilegeMessage(perm))
+}
+
+func (m *Privilege) onComponentUnregistered(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+
+	m.mu.Lock()
+	delete(m.authIDs, inf.ID)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Privilege) onComponentElementRecv(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.ExternalComponentInfo)
+
+	m.mu.RLock()
+	cHost, ok := m.authIDs[inf.ID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil // not authenticated yet or not privileged
+	}
+	perm := m.perms[cHost]
+	ctx := execCtx.Context
+
+	switch stanza := inf.Element.(type) {
+	case *stravaganza.Message:
+		prv := stanza.ChildNamespace("privilege", privilegeNamespace)
+		if prv == nil {
+			return nil
+		}
+		return m.processPrivilegedMessage(ctx, cHost, perm, stanza, prv)
+
+	case *stravaganza.IQ:
+		if !m.hosts.IsLocalHost(stanza.ToJID().Domain()) {
+			return nil
+		}
+		if piq := stanza.ChildNamespace("privileged_iq", privilegeNamespace); piq != nil {
+			return m.processPrivilegedIQ(ctx, cHost, perm, stanza, piq)
+		}
+		if stanza.ChildNamespace("query", rosterNamespace) != nil && perm.Roster != permNone && len(perm.Roster) > 0 {
+			return m.processRosterIQ(ctx, cHost, perm, stanza)
+		}
+	}
+	return nil
+}
+
+func (m *Privilege) onC2SWillRoute(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.C2SRouterInfo)
+
+	iq, ok := inf.Stanza.(*stravaganza.IQ)
+	if !ok || iq.IsGet() || iq.IsSet() {
+		return nil
+	}
+	m.mu.Lock()
+	p := m.pending[iq.ID()]
+	delete(m.pending, iq.ID())
+	m.mu.Unlock()
+
+	if p == nil {
+		return nil
+	}
+	if err := m.sendToComponent(execCtx.Context, p.cHost, p.response(iq)); err != nil {
+		return err
+	}
+	return hook.ErrStopped // already delivered to privileged component
+}
+
+func (m *Privilege) onRosterItemUpdated(execCtx *hook.ExecutionContext) error {
+	inf := execCtx.Info.(*hook.RosterInfo)
+	ctx := execCtx.Context
+
+	item := &rostermodel.Item{
+		Username:     inf.Username,
+		Jid:          inf.JID,
+		Subscription: inf.Subscription,
+	}
+	if inf.Subscription != rostermodel.Remove {
+		ri, err := m.rep.FetchRosterItem(ctx, inf.Username, inf.JID)
+		if err != nil {
+			return err
+		}
+		if ri != nil {
+			item = ri
+		}
+	}
+	for _, cHost := range m.authenticatedHosts() {
+		perm := m.perms[cHost]
+		if perm.Roster != permGet && perm.Roster != permBoth {
+			continue
+		}
+		// only push roster changes of users belonging to the component's server
+		domain := m.serverDomain(cHost)
+		if inf.Domain != domain {
+			continue
+		}
+		push, _ := stravaganza.NewIQBuilder().
+			WithAttribute(stravaganza.ID, uuid.New().String()).
+			WithAttribute(stravaganza.Type, stravaganza.SetType).
+			WithAttribute(stravaganza.From, inf.Username+"@"+domain).
+			WithAttribute(stravaganza.To, cHost).
+			WithChild(
+				stravaganza.NewBuilder("query").
+					WithAttribute(stravaganza.Namespace, rosterNamespace).
+					WithChild(encodeRosterItem(item)).
+					Build(),
+			).
+			BuildIQ()
+		if err := m.sendToComponent(ctx, cHost, push); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Privilege) processRosterIQ(ctx context.Context, cHost string, perm *ComponentConfig, iq *stravaganza.IQ) error {
+	userJID := iq.ToJID()
+	if !userJID.IsBare() || userJID.Domain() != m.serverDomain(cHost) || !perm.allowsRoster(iq) {
+		return m.replyError(ctx, cHost, iq, stanzaerror.Forbidden)
+	}
+	onBehalfIQ, _ := stravaganza.NewBuilderFromElement(iq).
+		WithAttribute(stravaganza.From, userJID.String()).
+		BuildIQ()
+
+	return m.executeOnBehalf(ctx, &pendingIQ{cHost: cHost, iq: iq}, onBehalfIQ)
+}
+
+func (m *Privilege) processPrivilegedIQ(ctx context.Context, cHost string, perm *ComponentConfig, iq *stravaganza.IQ, piq stravaganza.Element) error {
+	innerEl := piq.Child("iq")
+	if innerEl == nil {
+		return m.replyError(ctx, cHost, iq, stanzaerror.BadRequest)
+	}
+	innerIQ, err := stravaganza.NewBuilderFromElement(innerEl).BuildIQ()
+	if err != nil || innerIQ.ChildrenCount() == 0 || !(innerIQ.IsGet() || innerIQ.IsSet()) {
+		return m.replyError(ctx, cHost, iq, stanzaerror.BadRequest)
+	}
+	// entity can only act on behalf of the addressed user
+	userJID := iq.ToJID()
+	if !userJID.IsBare() || innerIQ.FromJID().ToBareJID().String() != userJID.String() {
+		return m.replyError(ctx, cHost, iq, stanzaerror.Forbidden)
+	}
+	if toJID := innerIQ.ToJID(); toJID.String() != userJID.String() && !(toJID.IsServer() && m.hosts.IsLocalHost(toJID.Domain())) {
+		return m.replyError(ctx, cHost, iq, stanzaerror.Forbidden)
+	}
+	ns := innerIQ.AllChildren()[0].Attribute(stravaganza.Namespace)
+	if !perm.allowsIQ(ns, innerIQ) {
+		return m.replyError(ctx, cHost, iq, stanzaerror.Forbidden)
+	}
+	onBehalfIQ, _ := stravaganza.NewBuilderFromElement(innerIQ).
+		WithoutAttribute(stravaganza.Namespace).
+		WithAttribute(stravaganza.From, userJID.String()).
+		BuildIQ()
+
+	return m.executeOnBehalf(ctx, &pendingIQ{cHost: cHost, iq: iq, innerID: innerIQ.ID(), wrapped: true}, onBehalfIQ)
+}
+
+func (m *Privilege) processPrivilegedMessage(ctx context.Context, cHost string, perm *ComponentConfig, msg *stravaganza.Message, prv stravaganza.Element) error {
+	if perm.Message != permOutgoing {
+		return m.replyError(ctx, cHost, msg, stanzaerror.Forbidden)
+	}
+	fwd := prv.ChildNamespace("forwarded", forwardNamespace)
+	if fwd == nil || fwd.Child("message") == nil {
+		return m.replyError(ctx, cHost, msg, stanzaerror.BadRequest)
+	}
+	fwdMsg, err := stravaganza.NewBuilderFromElement(fwd.Child("message")).
+		WithoutAttribute(stravaganza.Namespace).
+		BuildMessage()
+	if err != nil {
+		return m.replyError(ctx, cHost, msg, stanzaerror.BadRequest)
+	}
+	// forwarded message must be sent from a local user
+	fromJID := fwdMsg.FromJID()
+	if len(fromJID.Node()) == 0 || !m.hosts.IsLocalHost(fromJID.Domain()) {
+		return m.replyError(ctx, cHost, msg, stanzaerror.Forbidden)
+	}
+	_, _ = m.router.Route(ctx, fwdMsg)
+
+	level.Info(m.logger).Log("msg", "routed privileged message", "component_host", cHost, "from", fromJID.String())
+	return hook.ErrStopped
+}
+
+func (m *Privilege) executeOnBehalf(ctx context.Context, p *pendingIQ, iq *stravaganza.IQ) error {
+	m.mu.RLock()
+	mods := m.mods
+	m.mu.RUnlock()
+	if mods == nil {
+		return m.replyError(ctx, p.cHost, p.iq, stanzaerror.ServiceUnavailable)
+	}
+	// track response by a unique identifier
+	id := uuid.New().String()
+	onBehalfIQ, _ := stravaganza.NewBuilderFromElement(iq).
+		WithAttribute(stravaganza.ID, id).
+		BuildIQ()
+
+	m.mu.Lock()
+	m.pending[id] = p
+	m.mu.Unlock()
+
+	err := mods.ProcessIQ(ctx, onBehalfIQ)
+
+	m.mu.Lock()
+	_, unanswered := m.pending[id]
+	delete(m.pending, id)
+	m.mu.Unlock()
+
+	if unanswered {
+		if err := m.replyError(ctx, p.cHost, p.iq, stanzaerror.InternalServerError); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to process privileged iq", "component_host", p.cHost, "err", err)
+	}
+	return hook.ErrStopped
+}
+
+func (m *Privilege) replyError(ctx context.Context, cHost string, stanza stravaganza.Stanza, reason stanzaerror.Reason) error {
+	if err := m.sendToComponent(ctx, cHost, xmpputil.MakeErrorStanza(stanza, reason)); err != nil {
+		return err
+	}
+	return hook.ErrStopped
+}
+
+func (m *Privilege) sendToComponent(ctx context.Context, cHost string, stanza stravaganza.Stanza) error {
+	comp := m.comps.Component(cHost)
+	if comp == nil {
+		return nil // component went away
+	}
+	return comp.ProcessStanza(ctx, stanza)
+}
+
+func (m *Privilege) privilegeMessage(perm *ComponentConfig) stravaganza.Stanza {
+	pb := stravaganza.NewBuilder("privilege").
+		WithAttribute(stravaganza.Namespace, privilegeNamespace)
+	if len(perm.Roster) > 0 && perm.Roster != permNone {
+		pb.WithChild(
+			stravaganza.NewBuilder("perm").
+				WithAttribute("access", "roster").
+				WithAttribute(stravaganza.Type, perm.Roster).
+				Build(),
+		)
+	}
+	if perm.Message == permOutgoing {
+		pb.WithChild(
+			stravaganza.NewBuilder("perm").
+				WithAttribute("access", "message").
+				WithAttribute(stravaganza.Type, permOutgoing).
+				Build(),
+		)
+	}
+	if len(perm.IQ) > 0 {
+		iqb := stravaganza.NewBuilder("perm").
+			WithAttribute("access", "iq")
+		for _, iqCfg := range perm.IQ {
+			iqb.WithChild(
+				stravaganza.NewBuilder("namespace").
+					WithAttribute("ns", iqCfg.Namespace).
+					WithAttribute(stravaganza.Type, iqCfg.Type).
+					Build(),
+			)
+		}
+		pb.WithChild(iqb.Build())
+	}
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, uuid.New().String()).
+		WithAttribute(stravaganza.From, m.serverDomain(perm.Host)).
+		WithAttribute(stravaganza.To, perm.Host).
+		WithChild(pb.Build()).
+		BuildMessage()
+	return msg
+}
+
+// serverDomain returns the local domain a component host belongs to.
+func (m *Privilege) serverDomain(cHost string) string {
+	if i := strings.IndexByte(cHost, '.'); i > 0 && m.hosts.IsLocalHost(cHost[i+1:]) {
+		return cHost[i+1:]
+	}
+	return m.hosts.DefaultHostName()
+}
+
+func (m *Privilege) authenticatedHosts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ret := make([]string, 0, len(m.authIDs))
+	for _, cHost := range m.authIDs {
+		ret = append(ret, cHost)
+	}
+	return ret
+}
+
+func (p *pendingIQ) response(iq *stravaganza.IQ) stravaganza.Stanza {
+	if !p.wrapped {
+		resp, _ := stravaganza.NewBuilderFromElement(iq).
+			WithAttribute(stravaganza.ID, p.iq.ID()).
+			WithAttribute(stravaganza.From, p.iq.ToJID().String()).
+			WithAttribute(stravaganza.To, p.iq.FromJID().String()).
+			BuildIQ()
+		return resp
+	}
+	innerResp := stravaganza.NewBuilderFromElement(iq).
+		WithAttribute(stravaganza.Namespace, "jabber:client").
+		WithAttribute(stravaganza.ID, p.innerID).
+		Build()
+
+	resp, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, p.iq.ID()).
+		WithAttribute(stravaganza.Type, iq.Attribute(stravaganza.Type)).
+		WithAttribute(stravaganza.From, p.iq.ToJID().String()).
+		WithAttribute(stravaganza.To, p.iq.FromJID().String()).
+		WithChild(
+			stravaganza.NewBuilder("privileged_iq").
+				WithAttribute(stravaganza.Namespace, privilegeNamespace).
+				WithChild(innerResp).
+				Build(),
+		).
+		BuildIQ()
+	return resp
+}
+
+func encodeRosterItem(ri *rostermodel.Item) stravaganza.Element {
+	b := stravaganza.NewBuilder("item").
+		WithAttribute("jid", ri.Jid).
+		WithAttribute("subscription", ri.Subscription)
+	if len(ri.Name) > 0 {
+		b.WithAttribute("name", ri.Name)
+	}
+	for _, group := range ri.Groups {
+		b.WithChild(stravaganza.NewBuilder("group").
+			WithText(group).
+			Build(),
+		)
+	}
+	return b.Build()
+}
@@ -0,0 +1,385 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0356
+
+import (
+	"context"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/component"
+	"github.com/ortuman/jackal/pkg/hook"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivilege_AdvertisePermissions(t *testing.T) {
+	// given
+	m, compMock, _, hk := testPrivilege()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	authenticate(t, hk)
+
+	// then
+	require.Len(t, sent, 1)
+	require.Equal(t, "jackal.im", sent[0].Attribute(stravaganza.From))
+	require.Equal(t, "slidge.jackal.im", sent[0].Attribute(stravaganza.To))
+
+	prv := sent[0].ChildNamespace("privilege", privilegeNamespace)
+	require.NotNil(t, prv)
+
+	perms := prv.Children("perm")
+	require.Len(t, perms, 3)
+	require.Equal(t, "roster", perms[0].Attribute("access"))
+	require.Equal(t, "both", perms[0].Attribute(stravaganza.Type))
+	require.Equal(t, "message", perms[1].Attribute("access"))
+	require.Equal(t, "iq", perms[2].Attribute("access"))
+	require.Equal(t, "vcard-temp", perms[2].Child("namespace").Attribute("ns"))
+}
+
+func TestPrivilege_RosterOnBehalf(t *testing.T) {
+	// given
+	m, compMock, modsMock, hk := testPrivilege()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	var processed *stravaganza.IQ
+	modsMock.ProcessIQFunc = func(ctx context.Context, iq *stravaganza.IQ) error {
+		processed = iq
+
+		// emulate roster module response
+		resIQ, _ := iq.ResultBuilder().
+			WithChild(
+				stravaganza.NewBuilder("query").
+					WithAttribute(stravaganza.Namespace, rosterNamespace).
+					Build(),
+			).
+			BuildIQ()
+		_, err := hk.Run(hook.C2SRouterWillRouteStanza, &hook.ExecutionContext{
+			Info:    &hook.C2SRouterInfo{Stanza: resIQ},
+			Context: ctx,
+		})
+		return err
+	}
+	require.NoError(t, m.Start(context.Background()))
+	authenticate(t, hk)
+	sent = nil
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "r1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "slidge.jackal.im").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, rosterNamespace).
+				Build(),
+		).
+		BuildIQ()
+	halted, err := componentElementRecv(hk, iq)
+
+	// then
+	require.NoError(t, err)
+	require.True(t, halted)
+
+	require.NotNil(t, processed)
+	require.Equal(t, "ortuman@jackal.im", processed.Attribute(stravaganza.From))
+
+	require.Len(t, sent, 1)
+	require.Equal(t, "r1", sent[0].Attribute(stravaganza.ID))
+	require.Equal(t, stravaganza.ResultType, sent[0].Attribute(stravaganza.Type))
+	require.Equal(t, "ortuman@jackal.im", sent[0].Attribute(stravaganza.From))
+	require.Equal(t, "slidge.jackal.im", sent[0].Attribute(stravaganza.To))
+	require.NotNil(t, sent[0].ChildNamespace("query", rosterNamespace))
+
+	require.Len(t, m.pending, 0)
+}
+
+func TestPrivilege_RosterForeignDomain(t *testing.T) {
+	// given
+	m, compMock, modsMock, hk := testPrivilege()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+	authenticate(t, hk)
+	sent = nil
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "r1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "slidge.jackal.im").
+		WithAttribute(stravaganza.To, "ortuman@jabber.org").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, rosterNamespace).
+				Build(),
+		).
+		BuildIQ()
+	halted, err := componentElementRecv(hk, iq)
+
+	// then
+	require.NoError(t, err)
+	require.True(t, halted)
+
+	require.Len(t, modsMock.ProcessIQCalls(), 0)
+
+	require.Len(t, sent, 1)
+	require.Equal(t, stravaganza.ErrorType, sent[0].Attribute(stravaganza.Type))
+	require.NotNil(t, sent[0].Child("error").Child("forbidden"))
+}
+
+func TestPrivilege_RosterPush(t *testing.T) {
+	// given
+	m, compMock, _, hk := testPrivilege()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+	authenticate(t, hk)
+	sent = nil
+
+	// when
+	for _, domain := range []string{"jackal.im", "jabber.org"} {
+		_, err := hk.Run(hook.RosterItemUpdated, &hook.ExecutionContext{
+			Info: &hook.RosterInfo{
+				Username:     "ortuman",
+				Domain:       domain,
+				JID:          "noelia@jackal.im",
+				Subscription: rostermodel.Remove,
+			},
+			Context: context.Background(),
+		})
+		require.NoError(t, err)
+	}
+
+	// then
+	require.Len(t, sent, 1)
+	require.Equal(t, "ortuman@jackal.im", sent[0].Attribute(stravaganza.From))
+	require.Equal(t, "slidge.jackal.im", sent[0].Attribute(stravaganza.To))
+	require.NotNil(t, sent[0].ChildNamespace("query", rosterNamespace))
+}
+
+func TestPrivilege_ForbiddenIQNamespace(t *testing.T) {
+	// given
+	m, compMock, modsMock, hk := testPrivilege()
+
+	var sent []stravaganza.Stanza
+	compMock.ProcessStanzaFunc = func(_ context.Context, stanza stravaganza.Stanza) error {
+		sent = append(sent, stanza)
+		return nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+	authenticate(t, hk)
+	sent = nil
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "p1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "slidge.jackal.im").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("privileged_iq").
+				WithAttribute(stravaganza.Namespace, privilegeNamespace).
+				WithChild(
+					stravaganza.NewIQBuilder().
+						WithAttribute(stravaganza.ID, "i1").
+						WithAttribute(stravaganza.Type, stravaganza.SetType).
+						WithAttribute(stravaganza.From, "ortuman@jackal.im").
+						WithAttribute(stravaganza.To, "ortuman@jackal.im").
+						WithChild(
+							stravaganza.NewBuilder("vCard").
+								WithAttribute(stravaganza.Namespace, "vcard-temp").
+								Build(),
+						).
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+	halted, err := componentElementRecv(hk, iq)
+
+	// then
+	require.NoError(t, err)
+	require.True(t, halted)
+
+	require.Len(t, modsMock.ProcessIQCalls(), 0)
+
+	require.Len(t, sent, 1)
+	require.Equal(t, stravaganza.ErrorType, sent[0].Attribute(stravaganza.Type))
+	require.NotNil(t, sent[0].Child("error").Child("forbidden"))
+}
+
+func TestPrivilege_OutgoingMessage(t *testing.T) {
+	// given
+	m, compMock, _, hk := testPrivilege()
+
+	compMock.ProcessStanzaFunc = func(_ context.Context, _ stravaganza.Stanza) error { return nil }
+
+	var routed []stravaganza.Stanza
+	m.router.(*routerMock).RouteFunc = func(_ context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		routed = append(routed, stanza)
+		return nil, nil
+	}
+	require.NoError(t, m.Start(context.Background()))
+	authenticate(t, hk)
+
+	// when
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "slidge.jackal.im").
+		WithAttribute(stravaganza.To, "jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("privilege").
+				WithAttribute(stravaganza.Namespace, privilegeNamespace).
+				WithChild(
+					stravaganza.NewBuilder("forwarded").
+						WithAttribute(stravaganza.Namespace, forwardNamespace).
+						WithChild(
+							stravaganza.NewMessageBuilder().
+								WithAttribute(stravaganza.Namespace, "jabber:client").
+								WithAttribute(stravaganza.From, "ortuman@jackal.im").
+								WithAttribute(stravaganza.To, "noelia@jackal.im").
+								WithAttribute(stravaganza.Type, stravaganza.ChatType).
+								WithChild(
+									stravaganza.NewBuilder("body").
+										WithText("I'll give thee a wind").
+										Build(),
+								).
+								Build(),
+						).
+						Build(),
+				).
+				Build(),
+		).
+		BuildMessage()
+	halted, err := componentElementRecv(hk, msg)
+
+	// then
+	require.NoError(t, err)
+	require.True(t, halted)
+
+	require.Len(t, routed, 1)
+	require.Equal(t, "ortuman@jackal.im", routed[0].Attribute(stravaganza.From))
+	require.Equal(t, "noelia@jackal.im", routed[0].Attribute(stravaganza.To))
+	require.Equal(t, "", routed[0].Attribute(stravaganza.Namespace))
+}
+
+func TestPrivilege_UnauthenticatedComponent(t *testing.T) {
+	// given
+	m, _, modsMock, hk := testPrivilege()
+	require.NoError(t, m.Start(context.Background()))
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "r1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "slidge.jackal.im").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, rosterNamespace).
+				Build(),
+		).
+		BuildIQ()
+	halted, err := componentElementRecv(hk, iq)
+
+	// then
+	require.NoError(t, err)
+	require.False(t, halted)
+	require.Len(t, modsMock.ProcessIQCalls(), 0)
+}
+
+func testPrivilege() (*Privilege, *componentMock, *modulesMock, *hook.Hooks) {
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" || h == "jabber.org" }
+	hostsMock.DefaultHostNameFunc = func() string { return "jackal.im" }
+
+	compMock := &componentMock{}
+	compsMock := &componentsMock{}
+	compsMock.ComponentFunc = func(cHost string) component.Component {
+		if cHost == "slidge.jackal.im" {
+			return compMock
+		}
+		return nil
+	}
+	modsMock := &modulesMock{}
+
+	hk := hook.NewHooks()
+	m := &Privilege{
+		perms: map[string]*ComponentConfig{
+			"slidge.jackal.im": {
+				Host:    "slidge.jackal.im",
+				Roster:  permBoth,
+				Message: permOutgoing,
+				IQ: []IQConfig{
+					{Namespace: "vcard-temp", Type: permGet},
+				},
+			},
+		},
+		router:  &routerMock{},
+		hosts:   hostsMock,
+		comps:   compsMock,
+		rep:     &rosterRepositoryMock{},
+		mods:    modsMock,
+		hk:      hk,
+		authIDs: make(map[string]string),
+		pending: make(map[string]*pendingIQ),
+		logger:  kitlog.NewNopLogger(),
+	}
+	return m, compMock, modsMock, hk
+}
+
+func authenticate(t *testing.T, hk *hook.Hooks) {
+	_, err := hk.Run(hook.ExternalComponentAuthenticated, &hook.ExecutionContext{
+		Info: &hook.ExternalComponentInfo{
+			ID:   "ext_comp:1",
+			Host: "slidge.jackal.im",
+		},
+		Context: context.Background(),
+	})
+	require.NoError(t, err)
+}
+
+func componentElementRecv(hk *hook.Hooks, elem stravaganza.Element) (bool, error) {
+	return hk.Run(hook.ExternalComponentElementReceived, &hook.ExecutionContext{
+		Info: &hook.ExternalComponentInfo{
+			ID:      "ext_comp:1",
+			Host:    "slidge.jackal.im",
+			Element: elem,
+		},
+		Context: context.Background(),
+	})
+}
@@ -0,0 +1,187 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0455
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+)
+
+const (
+	sosFormType = "urn:xmpp:sos:0"
+
+	externalStatusAddressesField = "external-status-addresses"
+	statusField                  = "status"
+	messageField                 = "message"
+
+	degradedStatus = "degraded"
+)
+
+const (
+	// ModuleName represents outage status module name.
+	ModuleName = "outage_status"
+
+	// XEPNumber represents outage status XEP number.
+	XEPNumber = "0455"
+)
+
+// Config contains outage status module configuration options.
+type Config struct {
+	// ExternalStatusAddresses contains the URLs of the external service outage status documents.
+	ExternalStatusAddresses []string `fig:"external_status_addresses"`
+}
+
+// Status represents the service outage status announced by the server.
+type Status struct {
+	// Degraded tells whether the service is running in degraded mode.
+	Degraded bool
+
+	// Message is the optional operator provided incident description.
+	Message string
+
+	// UpdatedAt is the time at which status was last modified.
+	UpdatedAt time.Time
+}
+
+// OutageStatus represents a service outage status (XEP-0455) module type.
+//
+// External status addresses are advertised into server disco info. Operators can flip the server
+// into degraded mode through the admin API, in which case status and incident message are included
+// into the advertised form as well.
+type OutageStatus struct {
+	cfg    Config
+	logger kitlog.Logger
+	nowFn  func() time.Time
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// New returns a new initialized OutageStatus instance.
+func New(cfg Config, logger kitlog.Logger) *OutageStatus {
+	return &OutageStatus{
+		cfg:    cfg,
+		logger: kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
+		nowFn:  time.Now,
+	}
+}
+
+// Name returns outage status module name.
+func (m *OutageStatus) Name() string { return ModuleName }
+
+// StreamFeature returns outage status module stream feature.
+func (m *OutageStatus) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
+	return nil, nil
+}
+
+// ServerFeatures returns outage status server disco features.
+func (m *OutageStatus) ServerFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// AccountFeatures returns outage status account disco features.
+func (m *OutageStatus) AccountFeatures(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// ServerForms returns the service outage status form attached to server disco info.
+func (m *OutageStatus) ServerForms(_ context.Context) ([]xep0004.DataForm, error) {
+	st := m.Status()
+
+	fields := xep0004.Fields{
+		{
+			Var:    xep0004.FormType,
+			Type:   xep0004.Hidden,
+			Values: []string{sosFormType},
+		},
+		{
+			Var:    externalStatusAddressesField,
+			Type:   xep0004.ListMulti,
+			Values: m.cfg.ExternalStatusAddresses,
+		},
+	}
+	if st.Degraded {
+		fields = append(fields, xep0004.Field{
+			Var:    statusField,
+			Type:   xep0004.TextSingle,
+			Values: []string{degradedStatus},
+		})
+		if len(st.Message) > 0 {
+			fields = append(fields, xep0004.Field{
+				Var:    messageField,
+				Type:   xep0004.TextSingle,
+				Values: []string{st.Message},
+			})
+		}
+	}
+	return []xep0004.DataForm{{Type: xep0004.Result, Fields: fields}}, nil
+}
+
+// ExternalStatusAddresses returns the advertised external status document URLs.
+func (m *OutageStatus) ExternalStatusAddresses() []string {
+	return m.cfg.ExternalStatusAddresses
+}
+
+// Status returns current service outage status.
+func (m *OutageStatus) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// SetStatus updates service outage status.
+func (m *OutageStatus) SetStatus(degraded bool, message string) Status {
+	m.mu.Lock()
+	m.status = Status{
+		Degraded:  degraded,
+		Message:   message,
+		UpdatedAt: m.nowFn(),
+	}
+	st := m.status
+	m.mu.Unlock()
+
+	if degraded {
+		level.Warn(m.logger).Log("msg", "service status set to degraded", "message", message)
+	} else {
+		level.Info(m.logger).Log("msg", "service status restored")
+	}
+	return st
+}
+
+// Start starts outage status module.
+func (m *OutageStatus) Start(_ context.Context) error {
+	for _, addr := range m.cfg.ExternalStatusAddresses {
+		u, err := url.Parse(addr)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("xep0455: invalid external status address: %q", addr)
+		}
+	}
+	level.Info(m.logger).Log("msg", "started outage status module")
+	return nil
+}
+
+// Stop stops outage status module.
+func (m *OutageStatus) Stop(_ context.Context) error {
+	level.Info(m.logger).Log("msg", "stopped outage status module")
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xep0455
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutageStatus_ServerForms(t *testing.T) {
+	// given
+	m := New(Config{
+		ExternalStatusAddresses: []string{"https://status.jackal.im/sos.json"},
+	}, kitlog.NewNopLogger())
+
+	now := time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC)
+	m.nowFn = func() time.Time { return now }
+
+	// when
+	forms, err := m.ServerForms(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, forms, 1)
+
+	require.Equal(t, sosFormType, forms[0].Fields.ValueForFieldOfType(xep0004.FormType, xep0004.Hidden))
+	require.Equal(t, []string{"https://status.jackal.im/sos.json"}, forms[0].Fields.ValuesForFieldOfType(externalStatusAddressesField, xep0004.ListMulti))
+	require.Len(t, forms[0].Fields, 2)
+
+	// when
+	st := m.SetStatus(true, "Message delivery is delayed")
+	forms, _ = m.ServerForms(context.Background())
+
+	// then
+	require.True(t, st.Degraded)
+	require.Equal(t, now, st.UpdatedAt)
+
+	require.Equal(t, degradedStatus, forms[0].Fields.ValueForFieldOfType(statusField, xep0004.TextSingle))
+	require.Equal(t, "Message delivery is delayed", forms[0].Fields.ValueForFieldOfType(messageField, xep0004.TextSingle))
+
+	// when
+	m.SetStatus(false, "")
+	forms, _ = m.ServerForms(context.Background())
+
+	// then
+	require.False(t, m.Status().Degraded)
+	require.Len(t, forms[0].Fields, 2)
+}
+
+func TestOutageStatus_InvalidAddress(t *testing.T) {
+	m := New(Config{
+		ExternalStatusAddresses: []string{"ftp://status.jackal.im/sos.json"},
+	}, kitlog.NewNopLogger())
+	require.Error(t, m.Start(context.Background()))
+}
@@ -95,3 +95,18 @@ func TestParser_Stream(t *testing.T) {
 
 	require.Equal(t, ErrStreamClosedByPeer, err)
 }
+
+func BenchmarkParser_Parse(b *testing.B) {
+	docSrc := `<message xmlns="jabber:client" from="noelia@jackal.im/yard" to="ortuman@jackal.im/balcony" type="chat" id="m1">` +
+		`<body>I&apos;ll give thee a wind.</body><active xmlns="http://jabber.org/protocol/chatstates"/>` +
+		`<request xmlns="urn:xmpp:receipts"/></message>`
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := New(strings.NewReader(docSrc), SocketStream, 0)
+		if _, err := p.Parse(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
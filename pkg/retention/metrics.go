@@ -0,0 +1,92 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"github.com/ortuman/jackal/pkg/cluster/instance"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	retentionPurged = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "retention",
+			Name:      "purged_total",
+			Help:      "The total number of items removed due to retention policies.",
+		},
+		[]string{"instance", "type", "host"},
+	)
+	retentionPurgeErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "retention",
+			Name:      "purge_errors_total",
+			Help:      "The total number of failed retention purge operations.",
+		},
+		[]string{"instance", "type", "host"},
+	)
+	retentionPurgeDuration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "jackal",
+			Subsystem: "retention",
+			Name:      "last_purge_duration_seconds",
+			Help:      "Duration of the last completed retention purge.",
+		},
+		[]string{"instance"},
+	)
+	retentionLastPurge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "jackal",
+			Subsystem: "retention",
+			Name:      "last_purge_timestamp_seconds",
+			Help:      "Unix time of the last completed retention purge.",
+		},
+		[]string{"instance"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(retentionPurged)
+	prometheus.MustRegister(retentionPurgeErrors)
+	prometheus.MustRegister(retentionPurgeDuration)
+	prometheus.MustRegister(retentionLastPurge)
+}
+
+func reportPurged(dataType, host string, count int) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"type":     dataType,
+		"host":     host,
+	}
+	retentionPurged.With(metricLabel).Add(float64(count))
+}
+
+func reportPurgeError(dataType, host string) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"type":     dataType,
+		"host":     host,
+	}
+	retentionPurgeErrors.With(metricLabel).Inc()
+}
+
+func reportPurgeCompleted(durationInSecs float64) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+	}
+	retentionPurgeDuration.With(metricLabel).Set(durationInSecs)
+	retentionLastPurge.With(metricLabel).SetToCurrentTime()
+}
@@ -0,0 +1,297 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+const purgeLockID = "retention:purge"
+
+const (
+	// ArchiveDataType represents archived messages (XEP-0313) data type.
+	ArchiveDataType = "archive"
+
+	// OfflineDataType represents offline messages data type.
+	OfflineDataType = "offline"
+
+	// AccountDataType represents deleted accounts data type.
+	AccountDataType = "account"
+)
+
+// Config contains data retention configuration.
+type Config struct {
+	// Interval defines how often expired data is purged.
+	Interval time.Duration `fig:"interval" default:"1h"`
+
+	// Default contains retention durations applied to every host.
+	Default Policy `fig:"default"`
+
+	// Hosts contains per host retention durations. Non zero values override defaults.
+	Hosts map[string]Policy `fig:"hosts"`
+
+	// DeletedAccounts defines the grace period during which a deleted account is disabled
+	// and its data retained before being permanently removed.
+	// A zero duration means accounts are removed immediately.
+	DeletedAccounts time.Duration `fig:"deleted_accounts"`
+}
+
+// Policy defines how long each data type is kept. A zero duration means data is kept indefinitely.
+type Policy struct {
+	// Archive defines archived messages retention duration.
+	Archive time.Duration `fig:"archive"`
+
+	// Offline defines offline messages retention duration.
+	Offline time.Duration `fig:"offline"`
+}
+
+type purgeFunc func(ctx context.Context, host string, before time.Time) (int, error)
+
+type purger struct {
+	dataType string
+	maxAgeFn func(p Policy) time.Duration
+	purgeFn  purgeFunc
+}
+
+type purgeStep struct {
+	purger
+	host   string
+	before time.Time
+}
+
+// Retention represents a data retention scheduler type.
+type Retention struct {
+	cfg     Config
+	hosts   hosts
+	rep     repository.Repository
+	hk      *hook.Hooks
+	purgers []purger
+	logger  kitlog.Logger
+	nowFn   func() time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New returns a new initialized Retention instance.
+func New(cfg Config, hosts hosts, rep repository.Repository, hk *hook.Hooks, logger kitlog.Logger) *Retention {
+	return &Retention{
+		cfg:   cfg,
+		hosts: hosts,
+		rep:   rep,
+		hk:    hk,
+		purgers: []purger{
+			{
+				dataType: ArchiveDataType,
+				maxAgeFn: func(p Policy) time.Duration { return p.Archive },
+				purgeFn:  rep.DeleteArchiveMessagesBefore,
+			},
+			{
+				dataType: OfflineDataType,
+				maxAgeFn: func(p Policy) time.Duration { return p.Offline },
+				purgeFn:  rep.DeleteOfflineMessagesBefore,
+			},
+		},
+		logger: kitlog.With(logger, "component", "retention"),
+		nowFn:  time.Now,
+	}
+}
+
+// Start starts retention purge scheduler.
+func (r *Retention) Start(_ context.Context) error {
+	for h := range r.cfg.Hosts {
+		if !r.hosts.IsLocalHost(h) {
+			return fmt.Errorf("retention: unknown host %s", h)
+		}
+	}
+	if r.cfg.Interval <= 0 {
+		return fmt.Errorf("retention: interval must be positive")
+	}
+	if r.cfg.DeletedAccounts < 0 {
+		return fmt.Errorf("retention: deleted accounts grace period must not be negative")
+	}
+	if len(r.steps()) == 0 {
+		level.Info(r.logger).Log("msg", "data retention disabled")
+		return nil
+	}
+	r.stopCh = make(chan struct{})
+	r.doneCh = make(chan struct{})
+	go r.loop()
+
+	level.Info(r.logger).Log("msg", "started data retention scheduler", "interval", r.cfg.Interval)
+	return nil
+}
+
+// Stop stops retention purge scheduler.
+func (r *Retention) Stop(_ context.Context) error {
+	if r.stopCh == nil {
+		return nil
+	}
+	close(r.stopCh)
+	<-r.doneCh
+
+	level.Info(r.logger).Log("msg", "stopped data retention scheduler")
+	return nil
+}
+
+// Purge removes all data whose retention period has expired.
+func (r *Retention) Purge(ctx context.Context) error {
+	steps := r.steps()
+	if len(steps) == 0 {
+		return nil
+	}
+	// prevent cluster nodes from purging concurrently
+	if err := r.rep.Lock(ctx, purgeLockID); err != nil {
+		return err
+	}
+	defer func() { _ = r.rep.Unlock(context.Background(), purgeLockID) }()
+
+	t0 := time.Now()
+
+	var total int
+	for i, step := range steps {
+		n, err := step.purgeFn(ctx, step.host, step.before)
+		if err != nil {
+			reportPurgeError(step.dataType, step.host)
+			return fmt.Errorf("retention: failed to purge %s data of host %s: %w", step.dataType, step.host, err)
+		}
+		reportPurged(step.dataType, step.host, n)
+		total += n
+
+		level.Info(r.logger).Log("msg", "data retention purge progress",
+			"step", fmt.Sprintf("%d/%d", i+1, len(steps)),
+			"type", step.dataType,
+			"host", step.host,
+			"before", step.before.UTC().Format(time.RFC3339),
+			"purged", n,
+		)
+	}
+	reportPurgeCompleted(time.Since(t0).Seconds())
+
+	level.Info(r.logger).Log("msg", "data retention purge completed", "purged", total, "duration", time.Since(t0))
+	return nil
+}
+
+func (r *Retention) loop() {
+	defer close(r.doneCh)
+
+	tc := time.NewTicker(r.cfg.Interval)
+	defer tc.Stop()
+
+	for {
+		r.purge()
+
+		select {
+		case <-tc.C:
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Retention) purge() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// abort purge on stop
+	go func() {
+		select {
+		case <-r.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	if err := r.Purge(ctx); err != nil {
+		level.Warn(r.logger).Log("msg", "data retention purge failed", "err", err)
+	}
+}
+
+func (r *Retention) purgeDeletedAccounts(ctx context.Context, _ string, before time.Time) (int, error) {
+	usernames, err := r.rep.FetchUsersPendingDeletion(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	for i, username := range usernames {
+		if err := r.rep.DeleteUser(ctx, username); err != nil {
+			return i, err
+		}
+		// run user deleted hook
+		_, err := r.hk.Run(hook.UserDeleted, &hook.ExecutionContext{
+			Info: &hook.UserInfo{
+				Username: username,
+			},
+			Context: ctx,
+		})
+		if err != nil {
+			return i, err
+		}
+		level.Info(r.logger).Log("msg", "deleted account purged", "username", username)
+	}
+	return len(usernames), nil
+}
+
+func (r *Retention) steps() []purgeStep {
+	now := r.nowFn()
+
+	var retVal []purgeStep
+	if r.cfg.DeletedAccounts > 0 {
+		// accounts are not bound to a host, and their purge time is set at deletion time
+		retVal = append(retVal, purgeStep{
+			purger: purger{
+				dataType: AccountDataType,
+				purgeFn:  r.purgeDeletedAccounts,
+			},
+			before: now,
+		})
+	}
+	for _, h := range r.hosts.HostNames() {
+		p := r.policy(h)
+		for _, pr := range r.purgers {
+			maxAge := pr.maxAgeFn(p)
+			if maxAge <= 0 {
+				continue
+			}
+			retVal = append(retVal, purgeStep{
+				purger: pr,
+				host:   h,
+				before: now.Add(-maxAge),
+			})
+		}
+	}
+	return retVal
+}
+
+func (r *Retention) policy(host string) Policy {
+	p := r.cfg.Default
+
+	hp, ok := r.cfg.Hosts[host]
+	if !ok {
+		return p
+	}
+	if hp.Archive > 0 {
+		p.Archive = hp.Archive
+	}
+	if hp.Offline > 0 {
+		p.Offline = hp.Offline
+	}
+	return p
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+type purgeCall struct {
+	dataType string
+	host     string
+	before   time.Time
+}
+
+func TestRetention_Purge(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 10, 00, 00, 00, 00, time.UTC)
+
+	var calls []purgeCall
+	repMock := testRepository()
+	repMock.DeleteArchiveMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		calls = append(calls, purgeCall{dataType: ArchiveDataType, host: host, before: before})
+		return 2, nil
+	}
+	repMock.DeleteOfflineMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		calls = append(calls, purgeCall{dataType: OfflineDataType, host: host, before: before})
+		return 1, nil
+	}
+	r := testRetention(Config{
+		Interval: time.Hour,
+		Default: Policy{
+			Archive: time.Hour * 24 * 7,
+		},
+		Hosts: map[string]Policy{
+			"jabber.org": {Archive: time.Hour * 24, Offline: time.Hour * 48},
+		},
+	}, repMock, now)
+
+	// when
+	err := r.Purge(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []purgeCall{
+		{dataType: ArchiveDataType, host: "jackal.im", before: now.Add(-time.Hour * 24 * 7)},
+		{dataType: ArchiveDataType, host: "jabber.org", before: now.Add(-time.Hour * 24)},
+		{dataType: OfflineDataType, host: "jabber.org", before: now.Add(-time.Hour * 48)},
+	}, calls)
+	require.Len(t, repMock.LockCalls(), 1)
+	require.Len(t, repMock.UnlockCalls(), 1)
+}
+
+func TestRetention_PurgeError(t *testing.T) {
+	// given
+	repMock := testRepository()
+	repMock.DeleteArchiveMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		return 0, errors.New("foo error")
+	}
+	r := testRetention(Config{
+		Interval: time.Hour,
+		Default:  Policy{Archive: time.Hour, Offline: time.Hour},
+	}, repMock, time.Now())
+
+	// when
+	err := r.Purge(context.Background())
+
+	// then
+	require.Error(t, err)
+	require.Len(t, repMock.DeleteArchiveMessagesBeforeCalls(), 1)
+	require.Len(t, repMock.DeleteOfflineMessagesBeforeCalls(), 0)
+	require.Len(t, repMock.UnlockCalls(), 1)
+}
+
+func TestRetention_PurgeDeletedAccounts(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 10, 00, 00, 00, 00, time.UTC)
+
+	repMock := testRepository()
+	repMock.FetchUsersPendingDeletionFunc = func(ctx context.Context, before time.Time) ([]string, error) {
+		return []string{"noelia", "ortuman"}, nil
+	}
+	repMock.DeleteUserFunc = func(ctx context.Context, username string) error {
+		return nil
+	}
+	r := testRetention(Config{
+		Interval:        time.Hour,
+		DeletedAccounts: time.Hour * 24 * 30,
+	}, repMock, now)
+
+	var deleted []string
+	r.hk.AddHook(hook.UserDeleted, func(execCtx *hook.ExecutionContext) error {
+		deleted = append(deleted, execCtx.Info.(*hook.UserInfo).Username)
+		return nil
+	}, hook.DefaultPriority)
+
+	// when
+	err := r.Purge(context.Background())
+
+	// then
+	require.NoError(t, err)
+
+	require.Len(t, repMock.FetchUsersPendingDeletionCalls(), 1)
+	require.Equal(t, now, repMock.FetchUsersPendingDeletionCalls()[0].Before)
+	require.Len(t, repMock.DeleteUserCalls(), 2)
+	require.Equal(t, []string{"noelia", "ortuman"}, deleted)
+}
+
+func TestRetention_Disabled(t *testing.T) {
+	// given
+	repMock := testRepository()
+	r := testRetention(Config{Interval: time.Hour}, repMock, time.Now())
+
+	// when
+	err := r.Purge(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, repMock.LockCalls(), 0)
+}
+
+func TestRetention_UnknownHost(t *testing.T) {
+	// given
+	r := testRetention(Config{
+		Interval: time.Hour,
+		Hosts: map[string]Policy{
+			"example.org": {Archive: time.Hour},
+		},
+	}, testRepository(), time.Now())
+
+	// when
+	err := r.Start(context.Background())
+
+	// then
+	require.Error(t, err)
+}
+
+func testRepository() *repositoryMock {
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	return repMock
+}
+
+func testRetention(cfg Config, repMock *repositoryMock, now time.Time) *Retention {
+	hMock := &hostsMock{}
+	hMock.HostNamesFunc = func() []string {
+		return []string{"jackal.im", "jabber.org"}
+	}
+	hMock.IsLocalHostFunc = func(h string) bool {
+		return h == "jackal.im" || h == "jabber.org"
+	}
+	r := New(cfg, hMock, repMock, hook.NewHooks(), kitlog.NewNopLogger())
+	r.nowFn = func() time.Time { return now }
+	return r
+}
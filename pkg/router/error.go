@@ -32,4 +32,8 @@ var (
 	// ErrRemoteServerTimeout will be returned by Route method if maximum amount of time to establish remote connection
 	// was reached.
 	ErrRemoteServerTimeout = errors.New("router: remote server timeout")
+
+	// ErrRemoteServerNotAllowed will be returned by Route method if sender host federation policy doesn't allow
+	// communicating with the remote server.
+	ErrRemoteServerNotAllowed = errors.New("router: remote server not allowed")
 )
@@ -149,6 +149,13 @@ func (r *router) route(ctx context.Context, stanza stravaganza.Stanza, routingOp
 	if r.s2s == nil {
 		return nil, ErrRemoteServerNotFound
 	}
+	senderDomain := r.hosts.DefaultHostName()
+	if fromJID := stanza.FromJID(); fromJID != nil && r.hosts.IsLocalHost(fromJID.Domain()) {
+		senderDomain = fromJID.Domain()
+	}
+	if !r.hosts.IsFederationAllowed(senderDomain, toJID.Domain()) {
+		return nil, ErrRemoteServerNotAllowed
+	}
 	if err := r.s2s.Route(ctx, stanza, r.hosts.DefaultHostName()); err != nil {
 		return nil, err
 	}
@@ -71,6 +71,9 @@ type C2S interface {
 	// SendElement writes element string representation to the underlying stream transport.
 	SendElement(elem stravaganza.Element) <-chan error
 
+	// SendWhitespace writes a whitespace keepalive to the underlying stream transport.
+	SendWhitespace() <-chan error
+
 	// Disconnect performs disconnection over the stream.
 	Disconnect(streamErr *streamerror.Error) <-chan error
 
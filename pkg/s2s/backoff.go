@@ -0,0 +1,109 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"sync"
+	"time"
+)
+
+type backoffEntry struct {
+	failures int
+	retryAt  time.Time
+}
+
+// domainBackoff keeps track of remote domains that recently failed to connect,
+// preventing a new dial attempt until their backoff period has elapsed.
+type domainBackoff struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	nowFn           func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*backoffEntry
+}
+
+func newDomainBackoff(initialInterval, maxInterval time.Duration) *domainBackoff {
+	return &domainBackoff{
+		initialInterval: initialInterval,
+		maxInterval:     maxInterval,
+		nowFn:           time.Now,
+		entries:         make(map[string]*backoffEntry),
+	}
+}
+
+// isDead reports whether domain is still within its backoff period.
+func (b *domainBackoff) isDead(domain string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := b.entries[domain]
+	return e != nil && b.nowFn().Before(e.retryAt)
+}
+
+// failure registers a failed connection attempt and returns the resulting backoff period.
+func (b *domainBackoff) failure(domain string) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := b.entries[domain]
+	if e == nil {
+		e = &backoffEntry{}
+		b.entries[domain] = e
+	}
+	e.failures++
+
+	d := b.initialInterval
+	for i := 1; i < e.failures && d < b.maxInterval; i++ {
+		d *= 2
+	}
+	if d > b.maxInterval {
+		d = b.maxInterval
+	}
+	e.retryAt = b.nowFn().Add(d)
+	return d
+}
+
+// success clears any backoff state associated to domain.
+func (b *domainBackoff) success(domain string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	delete(b.entries, domain)
+	b.mu.Unlock()
+}
+
+// purge discards entries whose backoff period elapsed long ago.
+func (b *domainBackoff) purge() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.nowFn()
+	for domain, e := range b.entries {
+		if now.Sub(e.retryAt) > b.maxInterval {
+			delete(b.entries, domain)
+		}
+	}
+}
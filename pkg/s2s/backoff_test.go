@@ -0,0 +1,77 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainBackoff_Failure(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	b := newDomainBackoff(time.Second, 5*time.Second)
+	b.nowFn = func() time.Time { return now }
+
+	// when
+	d1 := b.failure("jabber.org")
+	d2 := b.failure("jabber.org")
+	d3 := b.failure("jabber.org")
+	d4 := b.failure("jabber.org")
+
+	// then
+	require.Equal(t, time.Second, d1)
+	require.Equal(t, 2*time.Second, d2)
+	require.Equal(t, 4*time.Second, d3)
+	require.Equal(t, 5*time.Second, d4)
+
+	require.True(t, b.isDead("jabber.org"))
+	require.False(t, b.isDead("xmpp.org"))
+
+	now = now.Add(6 * time.Second)
+	require.False(t, b.isDead("jabber.org"))
+}
+
+func TestDomainBackoff_Success(t *testing.T) {
+	// given
+	b := newDomainBackoff(time.Minute, time.Hour)
+	b.failure("jabber.org")
+
+	// when
+	b.success("jabber.org")
+
+	// then
+	require.False(t, b.isDead("jabber.org"))
+	require.Equal(t, time.Minute, b.failure("jabber.org"))
+}
+
+func TestDomainBackoff_Purge(t *testing.T) {
+	// given
+	now := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	b := newDomainBackoff(time.Second, time.Minute)
+	b.nowFn = func() time.Time { return now }
+	b.failure("jabber.org")
+
+	// when
+	now = now.Add(2 * time.Minute)
+	b.purge()
+
+	// then
+	require.Len(t, b.entries, 0)
+}
@@ -15,6 +15,7 @@
 package s2s
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -43,6 +44,9 @@ type ListenerConfig struct {
 
 	// DirectTLS, if true, tls.Listen will be used as network listener.
 	DirectTLS bool `fig:"direct_tls"`
+
+	// Unsecured defines the set of remote peers allowed to federate without TLS.
+	Unsecured UnsecuredConfig `fig:"unsecured"`
 }
 
 // OutConfig defines S2S out configuration.
@@ -61,4 +65,186 @@ type OutConfig struct {
 
 	// MaxStanzaSize is the maximum size a listener incoming stanza may have.
 	MaxStanzaSize int `fig:"max_stanza_size" default:"131072"`
+
+	// PoolSize defines the number of parallel outgoing streams per remote domain.
+	// Stanzas are balanced across pooled streams in round-robin order.
+	PoolSize int `fig:"pool_size" default:"1"`
+
+	// IdleTimeout defines the time after which an inactive outgoing stream is closed.
+	// A zero value disables idle stream closing.
+	IdleTimeout time.Duration `fig:"idle_timeout"`
+
+	// Backoff defines outgoing connection retry backoff configuration.
+	Backoff BackoffConfig `fig:"backoff"`
+
+	// DNS defines outgoing SRV and address resolution configuration.
+	DNS DNSConfig `fig:"dns"`
+
+	// DANE defines outgoing certificate DANE validation configuration.
+	DANE DANEConfig `fig:"dane"`
+
+	// POSH defines PKIX over Secure HTTP (RFC 7711) configuration.
+	POSH POSHConfig `fig:"posh"`
+
+	// Pins defines, per remote domain, the set of allowed certificate public key hashes.
+	// Each pin is the base64 encoded SHA-256 hash of a certificate SubjectPublicKeyInfo, optionally
+	// prefixed by 'sha256/'. Connections to a pinned domain fail if no certificate in the presented chain matches.
+	Pins map[string][]string `fig:"pins"`
+
+	// Unsecured defines the set of remote peers allowed to federate without TLS.
+	Unsecured UnsecuredConfig `fig:"unsecured"`
+
+	// Queue defines outgoing stanza queue configuration.
+	Queue QueueConfig `fig:"queue"`
+}
+
+// UnsecuredConfig defines the set of remote peers with which S2S streams may run without TLS.
+// It is intended for air-gapped or lab environments only, any other peer is required to use TLS.
+type UnsecuredConfig struct {
+	// Networks defines the set of trusted remote networks in CIDR notation.
+	Networks []string `fig:"networks"`
+
+	// Domains defines the set of trusted remote domains.
+	Domains []string `fig:"domains"`
+}
+
+// BackoffConfig defines S2S outgoing connection backoff configuration.
+type BackoffConfig struct {
+	// InitialInterval defines the time during which a remote domain is not dialed again after a first failed attempt.
+	// The interval doubles on each consecutive failure.
+	InitialInterval time.Duration `fig:"initial_interval" default:"5s"`
+
+	// MaxInterval defines the maximum backoff interval.
+	MaxInterval time.Duration `fig:"max_interval" default:"10m"`
+}
+
+// DNSConfig defines S2S outgoing DNS resolution configuration.
+type DNSConfig struct {
+	// Protocol defines how remote domains are resolved.
+	// Allowed values are 'system' (Go system resolver, no caching), 'udp' (falling back to TCP on truncated
+	// responses), 'tcp' and 'tls' (DNS over TLS).
+	Protocol string `fig:"protocol" default:"system"`
+
+	// Nameservers defines the set of nameserver addresses (host:port) queried in order.
+	// If not set, nameservers configured in /etc/resolv.conf will be used.
+	Nameservers []string `fig:"nameservers"`
+
+	// TLSServerName defines the name used to verify DNS over TLS nameserver certificates.
+	// If not set, nameserver host will be used.
+	TLSServerName string `fig:"tls_server_name"`
+
+	// Timeout defines a single lookup timeout.
+	Timeout time.Duration `fig:"timeout" default:"5s"`
+
+	// CacheSize defines the maximum number of cached answers.
+	CacheSize int `fig:"cache_size" default:"10000"`
+
+	// MaxTTL caps the time a positive answer is cached, regardless of its records TTL.
+	MaxTTL time.Duration `fig:"max_ttl" default:"1h"`
+
+	// NegativeTTL caps the time a non existing domain or empty answer is cached.
+	NegativeTTL time.Duration `fig:"negative_ttl" default:"5m"`
+}
+
+func (c DNSConfig) validate() error {
+	switch c.Protocol {
+	case "", dnsProtocolSystem, dnsProtocolUDP, dnsProtocolTCP, dnsProtocolTLS:
+		return nil
+	default:
+		return fmt.Errorf("s2s: unrecognized DNS protocol: %s", c.Protocol)
+	}
+}
+
+func (c DNSConfig) isSystem() bool {
+	return len(c.Protocol) == 0 || c.Protocol == dnsProtocolSystem
+}
+
+// DANEConfig defines S2S outgoing DANE configuration.
+type DANEConfig struct {
+	// Policy defines default DANE policy applied to remote domains.
+	// Allowed values are 'off', 'prefer' and 'require'.
+	Policy string `fig:"policy" default:"off"`
+
+	// Domains defines per domain DANE policy overrides.
+	Domains map[string]string `fig:"domains"`
+
+	// Resolver defines the address of a DNSSEC validating resolver.
+	// If not set, system configured resolvers will be used.
+	Resolver string `fig:"resolver"`
+
+	// Timeout defines TLSA records lookup timeout.
+	Timeout time.Duration `fig:"timeout" default:"5s"`
+}
+
+func (c DANEConfig) policy(domain string) string {
+	p, ok := c.Domains[domain]
+	if !ok {
+		p = c.Policy
+	}
+	if len(p) == 0 {
+		return danePolicyOff
+	}
+	return p
+}
+
+func (c DANEConfig) isEnabled() bool {
+	if c.policy("") != danePolicyOff {
+		return true
+	}
+	for domain := range c.Domains {
+		if c.policy(domain) != danePolicyOff {
+			return true
+		}
+	}
+	return false
+}
+
+func (c DANEConfig) validate() error {
+	if err := validateDANEPolicy(c.Policy); err != nil {
+		return err
+	}
+	for _, p := range c.Domains {
+		if err := validateDANEPolicy(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDANEPolicy(policy string) error {
+	switch policy {
+	case "", danePolicyOff, danePolicyPrefer, danePolicyRequire:
+		return nil
+	default:
+		return fmt.Errorf("s2s: unrecognized DANE policy: %s", policy)
+	}
+}
+
+// POSHConfig defines S2S outgoing POSH configuration.
+type POSHConfig struct {
+	// Enabled, if true, remote server certificates not valid for the target domain will be
+	// checked against the fingerprints published in the domain POSH document.
+	Enabled bool `fig:"enabled"`
+
+	// Timeout defines POSH document retrieval timeout.
+	Timeout time.Duration `fig:"timeout" default:"5s"`
+
+	// CacheExpiration defines the time a POSH document is cached when it doesn't specify its own expiration.
+	CacheExpiration time.Duration `fig:"cache_expiration" default:"1h"`
+}
+
+// QueueConfig defines S2S outgoing queue configuration.
+type QueueConfig struct {
+	// Enabled, if true, stanzas addressed to an unreachable domain will be persisted
+	// and delivered once the remote domain becomes available again.
+	Enabled bool `fig:"enabled"`
+
+	// MaxSize defines the maximum number of stanzas queued per remote domain.
+	MaxSize int `fig:"max_size" default:"1000"`
+
+	// Expiration defines the time after which a queued stanza is discarded.
+	Expiration time.Duration `fig:"expiration" default:"24h"`
+
+	// RetryInterval defines the interval between delivery attempts to queued domains.
+	RetryInterval time.Duration `fig:"retry_interval" default:"1m"`
 }
@@ -0,0 +1,459 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	danePolicyOff     = "off"
+	danePolicyPrefer  = "prefer"
+	danePolicyRequire = "require"
+)
+
+// TLSA certificate usages as defined in RFC 6698.
+const (
+	tlsaUsagePKIXTA = 0
+	tlsaUsagePKIXEE = 1
+	tlsaUsageDANETA = 2
+	tlsaUsageDANEEE = 3
+)
+
+const (
+	defaultResolvConf = "/etc/resolv.conf"
+
+	dnsHeaderBitAD = 1 << 5 // authentic data
+
+	dnsTypeTLSA dnsmessage.Type = 52
+)
+
+var (
+	errDANENoRecords = errors.New("s2s: no DNSSEC validated TLSA records found")
+	errDANEMismatch  = errors.New("s2s: remote certificate does not match TLSA records")
+
+	errNoPeerCertificates = errors.New("s2s: no remote certificates found")
+)
+
+type tlsaRecord struct {
+	usage        uint8
+	selector     uint8
+	matchingType uint8
+	data         []byte
+}
+
+// dnssecResolver looks up TLSA records, discarding every answer not authenticated by a
+// DNSSEC validating resolver.
+type dnssecResolver struct {
+	servers []string
+}
+
+func newDNSSECResolver(cfg DANEConfig) (*dnssecResolver, error) {
+	if len(cfg.Resolver) > 0 {
+		return &dnssecResolver{servers: []string{cfg.Resolver}}, nil
+	}
+	servers, err := readResolvConf(defaultResolvConf)
+	if err != nil {
+		return nil, err
+	}
+	return &dnssecResolver{servers: servers}, nil
+}
+
+func (r *dnssecResolver) LookupTLSA(ctx context.Context, domain string) ([]tlsaRecord, error) {
+	var names []string
+	for _, service := range []string{s2sTLSService, s2sService} {
+		p, err := r.lookup(ctx, fmt.Sprintf("_%s._tcp.%s", service, domain), dnsmessage.TypeSRV)
+		if err != nil {
+			return nil, err
+		}
+		for p != nil {
+			h, err := p.AnswerHeader()
+			if errors.Is(err, dnsmessage.ErrSectionDone) {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			if h.Type != dnsmessage.TypeSRV {
+				if err := p.SkipAnswer(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			srv, err := p.SRVResource()
+			if err != nil {
+				return nil, err
+			}
+			if target := srv.Target.String(); target != "." {
+				names = append(names, tlsaName(target, srv.Port))
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{tlsaName(domain, 5269)}
+	}
+	var retVal []tlsaRecord
+	for _, name := range names {
+		p, err := r.lookup(ctx, name, dnsTypeTLSA)
+		if err != nil {
+			return nil, err
+		}
+		for p != nil {
+			h, err := p.AnswerHeader()
+			if errors.Is(err, dnsmessage.ErrSectionDone) {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			if h.Type != dnsTypeTLSA {
+				if err := p.SkipAnswer(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			res, err := p.UnknownResource()
+			if err != nil {
+				return nil, err
+			}
+			if len(res.Data) < 4 {
+				continue
+			}
+			retVal = append(retVal, tlsaRecord{
+				usage:        res.Data[0],
+				selector:     res.Data[1],
+				matchingType: res.Data[2],
+				data:         res.Data[3:],
+			})
+		}
+	}
+	return retVal, nil
+}
+
+// lookup returns a parser positioned at the answer section, or nil if the response
+// was not authenticated by the resolver.
+func (r *dnssecResolver) lookup(ctx context.Context, name string, qType dnsmessage.Type) (*dnsmessage.Parser, error) {
+	q, err := buildDNSQuery(name, qType)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, srv := range r.servers {
+		resp, err := exchangeDNS(ctx, q, srv)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var p dnsmessage.Parser
+		h, err := p.Start(resp)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		switch h.RCode {
+		case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+			break
+		default:
+			lastErr = fmt.Errorf("s2s: DNS lookup failed: %s", h.RCode)
+			continue
+		}
+		if binary.BigEndian.Uint16(resp[2:4])&dnsHeaderBitAD == 0 {
+			return nil, nil
+		}
+		if err := p.SkipAllQuestions(); err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+	return nil, lastErr
+}
+
+func buildDNSQuery(name string, qType dnsmessage.Type) ([]byte, error) {
+	qName, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, true); err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:               uint16(rand.Uint32()),
+		RecursionDesired: true,
+	})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: qName, Type: qType, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	q, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(q[2:4], binary.BigEndian.Uint16(q[2:4])|dnsHeaderBitAD)
+	return q, nil
+}
+
+// exchangeDNS sends q to server over UDP, retrying over TCP whenever the response is truncated.
+func exchangeDNS(ctx context.Context, q []byte, server string) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(q); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, 4096)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp = resp[:n]
+	if len(resp) < 12 {
+		return nil, errors.New("s2s: malformed DNS response")
+	}
+	if binary.BigEndian.Uint16(resp[2:4])&(1<<9) == 0 { // not truncated
+		return resp, nil
+	}
+	return exchangeDNSStream(ctx, q, server, nil)
+}
+
+// exchangeDNSStream sends q to server over TCP, or over TLS in case tlsCfg is not nil.
+func exchangeDNSStream(ctx context.Context, q []byte, server string, tlsCfg *tls.Config) ([]byte, error) {
+	var d net.Dialer
+	var conn net.Conn
+	var err error
+	if tlsCfg != nil {
+		td := tls.Dialer{NetDialer: &d, Config: tlsCfg}
+		conn, err = td.DialContext(ctx, "tcp", server)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	req := make([]byte, 2+len(q))
+	binary.BigEndian.PutUint16(req, uint16(len(q)))
+	copy(req[2:], q)
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	var l [2]byte
+	if _, err := io.ReadFull(conn, l[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	if len(resp) < 12 {
+		return nil, errors.New("s2s: malformed DNS response")
+	}
+	return resp, nil
+}
+
+func readResolvConf(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var servers []string
+	for _, ln := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(ln)
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		servers = append(servers, net.JoinHostPort(fields[1], "53"))
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("s2s: no nameservers found in %s", path)
+	}
+	return servers, nil
+}
+
+func tlsaName(host string, port uint16) string {
+	return fmt.Sprintf("_%s._tcp.%s", strconv.Itoa(int(port)), strings.TrimSuffix(host, "."))
+}
+
+// verify reports whether cert matches TLSA record selector and matching type.
+func (r tlsaRecord) verify(cert *x509.Certificate) bool {
+	var data []byte
+	switch r.selector {
+	case 0:
+		data = cert.Raw
+	case 1:
+		data = cert.RawSubjectPublicKeyInfo
+	default:
+		return false
+	}
+	switch r.matchingType {
+	case 0:
+		break
+	case 1:
+		h := sha256.Sum256(data)
+		data = h[:]
+	case 2:
+		h := sha512.Sum512(data)
+		data = h[:]
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(data, r.data) == 1
+}
+
+// daneVerifier verifies remote server certificates against their TLSA records.
+type daneVerifier struct {
+	cfg      DANEConfig
+	resolver tlsaResolver
+}
+
+func newDANEVerifier(cfg DANEConfig) (*daneVerifier, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.isEnabled() {
+		return nil, nil
+	}
+	resolver, err := newDNSSECResolver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &daneVerifier{
+		cfg:      cfg,
+		resolver: resolver,
+	}, nil
+}
+
+func (v *daneVerifier) policy(domain string) string {
+	if v == nil {
+		return danePolicyOff
+	}
+	return v.cfg.policy(domain)
+}
+
+// verify checks certs against domain TLSA records. In case no records are published and policy
+// allows it, verifyIdentity is used to authenticate the remote server.
+func (v *daneVerifier) verify(certs []*x509.Certificate, domain, policy string, verifyIdentity identityVerifyFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), v.cfg.Timeout)
+	defer cancel()
+
+	records, err := v.resolver.LookupTLSA(ctx, domain)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		if policy == danePolicyRequire {
+			return errDANENoRecords
+		}
+		return verifyIdentity(certs, domain)
+	}
+	if !matchTLSA(records, certs, domain) {
+		return errDANEMismatch
+	}
+	return nil
+}
+
+// matchTLSA reports whether any of the TLSA records matches the peer certificate chain.
+func matchTLSA(records []tlsaRecord, certs []*x509.Certificate, domain string) bool {
+	leaf := certs[0]
+	for _, rec := range records {
+		switch rec.usage {
+		case tlsaUsageDANEEE:
+			if rec.verify(leaf) {
+				return true
+			}
+
+		case tlsaUsageDANETA:
+			for _, ta := range certs[1:] {
+				if !rec.verify(ta) {
+					continue
+				}
+				roots := x509.NewCertPool()
+				roots.AddCert(ta)
+				if verifyChain(certs, domain, roots) == nil {
+					return true
+				}
+			}
+
+		case tlsaUsagePKIXEE:
+			if rec.verify(leaf) && verifyPKIX(certs, domain) == nil {
+				return true
+			}
+
+		case tlsaUsagePKIXTA:
+			if verifyPKIX(certs, domain) != nil {
+				continue
+			}
+			for _, ca := range certs[1:] {
+				if rec.verify(ca) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+type identityVerifyFunc func(certs []*x509.Certificate, domain string) error
+
+func verifyPKIX(certs []*x509.Certificate, domain string) error {
+	return verifyChain(certs, domain, nil)
+}
+
+func verifyChain(certs []*x509.Certificate, domain string, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       domain,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
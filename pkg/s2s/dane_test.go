@@ -0,0 +1,137 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDANEVerifier_MatchDANEEE(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "jabber.org")
+	spkiHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	resMock := &tlsaResolverMock{}
+	resMock.LookupTLSAFunc = func(ctx context.Context, domain string) ([]tlsaRecord, error) {
+		return []tlsaRecord{{usage: tlsaUsageDANEEE, selector: 1, matchingType: 1, data: spkiHash[:]}}, nil
+	}
+	v := &daneVerifier{cfg: DANEConfig{Timeout: time.Second}, resolver: resMock}
+
+	// when
+	err := v.verify([]*x509.Certificate{cert}, "jabber.org", danePolicyRequire, verifyPKIX)
+
+	// then
+	require.Nil(t, err)
+}
+
+func TestDANEVerifier_Mismatch(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "jabber.org")
+
+	resMock := &tlsaResolverMock{}
+	resMock.LookupTLSAFunc = func(ctx context.Context, domain string) ([]tlsaRecord, error) {
+		return []tlsaRecord{{usage: tlsaUsageDANEEE, selector: 0, matchingType: 0, data: []byte("foo")}}, nil
+	}
+	v := &daneVerifier{cfg: DANEConfig{Timeout: time.Second}, resolver: resMock}
+
+	// when
+	err := v.verify([]*x509.Certificate{cert}, "jabber.org", danePolicyPrefer, verifyPKIX)
+
+	// then
+	require.Equal(t, errDANEMismatch, err)
+}
+
+func TestDANEVerifier_NoRecords(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "jabber.org")
+
+	resMock := &tlsaResolverMock{}
+	resMock.LookupTLSAFunc = func(ctx context.Context, domain string) ([]tlsaRecord, error) {
+		return nil, nil
+	}
+	v := &daneVerifier{cfg: DANEConfig{Timeout: time.Second}, resolver: resMock}
+	certs := []*x509.Certificate{cert}
+
+	// when
+	requireErr := v.verify(certs, "jabber.org", danePolicyRequire, verifyPKIX)
+	preferErr := v.verify(certs, "jabber.org", danePolicyPrefer, verifyPKIX)
+
+	// then
+	require.Equal(t, errDANENoRecords, requireErr)
+	require.NotNil(t, preferErr) // falls back to PKIX, self-signed certificate is rejected
+	require.NotEqual(t, errDANENoRecords, preferErr)
+}
+
+func TestDANEConfig_Validate(t *testing.T) {
+	require.Nil(t, DANEConfig{}.validate())
+	require.Nil(t, DANEConfig{Policy: danePolicyPrefer, Domains: map[string]string{"jabber.org": danePolicyOff}}.validate())
+	require.NotNil(t, DANEConfig{Policy: "always"}.validate())
+	require.NotNil(t, DANEConfig{Domains: map[string]string{"jabber.org": "never"}}.validate())
+
+	require.False(t, DANEConfig{Policy: danePolicyOff}.isEnabled())
+	require.True(t, DANEConfig{Domains: map[string]string{"jabber.org": danePolicyPrefer}}.isEnabled())
+}
+
+func TestDANE_BuildDNSQuery(t *testing.T) {
+	// when
+	q, err := buildDNSQuery("_5269._tcp.jabber.org", dnsTypeTLSA)
+
+	// then
+	require.Nil(t, err)
+	require.NotZero(t, binary.BigEndian.Uint16(q[2:4])&dnsHeaderBitAD)
+
+	var p dnsmessage.Parser
+	_, err = p.Start(q)
+	require.Nil(t, err)
+
+	question, err := p.Question()
+	require.Nil(t, err)
+	require.Equal(t, "_5269._tcp.jabber.org.", question.Name.String())
+	require.Equal(t, dnsTypeTLSA, question.Type)
+}
+
+func testSelfSignedCertificate(t *testing.T, domain string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return cert
+}
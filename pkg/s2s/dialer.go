@@ -35,16 +35,18 @@ type dialer interface {
 	DialContext(ctx context.Context, remoteDomain string) (conn net.Conn, usesTLS bool, err error)
 }
 
-type srvResolveFunc func(service, proto, name string) (cname string, addrs []*net.SRV, err error)
+type srvResolveFunc func(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+type lookupHostFunc func(ctx context.Context, host string) (addrs []string, err error)
 type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
 
 type outDialer struct {
 	srvResolve srvResolveFunc
+	lookupHost lookupHostFunc
 	dialCtx    dialFunc
 	dialTLSCtx dialFunc
 }
 
-func newDialer(timeout time.Duration, tlsCfg *tls.Config) *outDialer {
+func newDialer(timeout time.Duration, tlsCfg *tls.Config, resolver *dnsResolver) *outDialer {
 	d := net.Dialer{
 		Timeout:   timeout,
 		KeepAlive: outKeepAlive,
@@ -53,11 +55,16 @@ func newDialer(timeout time.Duration, tlsCfg *tls.Config) *outDialer {
 		NetDialer: &d,
 		Config:    tlsCfg,
 	}
-	return &outDialer{
-		srvResolve: net.LookupSRV,
+	od := &outDialer{
+		srvResolve: net.DefaultResolver.LookupSRV,
 		dialCtx:    d.DialContext,
 		dialTLSCtx: dTLS.DialContext,
 	}
+	if resolver != nil {
+		od.srvResolve = resolver.LookupSRV
+		od.lookupHost = resolver.LookupHost
+	}
+	return od
 }
 
 func (d *outDialer) DialContext(ctx context.Context, remoteDomain string) (conn net.Conn, usesTLS bool, err error) {
@@ -69,12 +76,12 @@ func (d *outDialer) DialContext(ctx context.Context, remoteDomain string) (conn
 	if err == nil {
 		return conn, false, nil
 	}
-	conn, err = d.dialCtx(ctx, "tcp", net.JoinHostPort(remoteDomain, "5269"))
+	conn, err = d.dialHost(ctx, d.dialCtx, remoteDomain, "5269")
 	return conn, false, err
 }
 
 func (d *outDialer) dialSRV(ctx context.Context, remoteDomain, service string, dialTLS bool) (net.Conn, error) {
-	_, addrs, err := d.srvResolve(service, "tcp", remoteDomain)
+	_, addrs, err := d.srvResolve(ctx, service, "tcp", remoteDomain)
 	if err != nil {
 		return nil, err
 	}
@@ -92,10 +99,31 @@ func (d *outDialer) dialSRV(ctx context.Context, remoteDomain, service string, d
 		default:
 			dialFn = d.dialCtx
 		}
-		conn, err := dialFn(ctx, "tcp", net.JoinHostPort(host, port))
+		conn, err := d.dialHost(ctx, dialFn, host, port)
 		if err == nil {
 			return conn, nil
 		}
 	}
 	return nil, errors.New("s2s: failed to dial SRV")
 }
+
+// dialHost dials host trying each of its resolved addresses in order.
+// When no custom resolver is configured, address resolution is delegated to dialFn.
+func (d *outDialer) dialHost(ctx context.Context, dialFn dialFunc, host, port string) (net.Conn, error) {
+	if d.lookupHost == nil {
+		return dialFn(ctx, "tcp", net.JoinHostPort(host, port))
+	}
+	addrs, err := d.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := dialFn(ctx, "tcp", net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -27,10 +27,10 @@ import (
 
 func TestDialer_ResolverError(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	mockedErr := errors.New("dialer mocked error")
-	d.srvResolve = func(_, _, _ string) (cname string, addrs []*net.SRV, err error) {
+	d.srvResolve = func(_ context.Context, _, _, _ string) (cname string, addrs []*net.SRV, err error) {
 		return "", nil, mockedErr
 	}
 
@@ -44,10 +44,10 @@ func TestDialer_ResolverError(t *testing.T) {
 
 func TestDialer_DialError(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	errFoo := errors.New("foo error")
-	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	d.srvResolve = func(_ context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
 		if service != s2sService {
 			return "", nil, nil
 		}
@@ -66,10 +66,10 @@ func TestDialer_DialError(t *testing.T) {
 
 func TestDialer_Success(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	conn := &netConnMock{}
-	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	d.srvResolve = func(_ context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
 		if service != s2sService {
 			return "", nil, nil
 		}
@@ -89,10 +89,10 @@ func TestDialer_Success(t *testing.T) {
 
 func TestDialer_TLSSuccess(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	conn := &netConnMock{}
-	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	d.srvResolve = func(_ context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
 		if service != s2sTLSService {
 			return "", nil, nil
 		}
@@ -109,3 +109,36 @@ func TestDialer_TLSSuccess(t *testing.T) {
 	require.NotNil(t, out)
 	require.True(t, isTLS)
 }
+
+func TestDialer_CustomResolver(t *testing.T) {
+	// given
+	d := newDialer(time.Minute, &tls.Config{}, nil)
+
+	conn := &netConnMock{}
+	d.srvResolve = func(_ context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+		if service != s2sService {
+			return "", nil, nil
+		}
+		return "", []*net.SRV{{Target: "xmpp.jabber.org.", Port: 5269}}, nil
+	}
+	d.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		require.Equal(t, "xmpp.jabber.org", host)
+		return []string{"192.0.2.1", "2001:db8::1"}, nil
+	}
+	var dialedAddrs []string
+	d.dialCtx = func(_ context.Context, _, address string) (net.Conn, error) {
+		dialedAddrs = append(dialedAddrs, address)
+		if address == "192.0.2.1:5269" {
+			return nil, errors.New("connection refused")
+		}
+		return conn, nil
+	}
+	// when
+	out, isTLS, err := d.DialContext(context.Background(), "jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, conn, out)
+	require.False(t, isTLS)
+	require.Equal(t, []string{"192.0.2.1:5269", "[2001:db8::1]:5269"}, dialedAddrs)
+}
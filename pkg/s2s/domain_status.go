@@ -0,0 +1,132 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DomainStatus contains connectivity state associated to a remote S2S domain.
+type DomainStatus struct {
+	// Domain is the remote domain name.
+	Domain string
+
+	// Connected tells whether an authenticated outgoing stream to the remote domain is established.
+	Connected bool
+
+	// Secured tells whether the outgoing stream is secured using TLS.
+	Secured bool
+
+	// AuthMethod is the outgoing stream authentication method (external or dialback).
+	AuthMethod string
+
+	// DialbackResult is the last outgoing dialback verification result (valid or invalid).
+	DialbackResult string
+
+	// LastError is the last error reported for the remote domain.
+	LastError string
+
+	// LastErrorAt is the time at which last error was reported.
+	LastErrorAt time.Time
+
+	// StanzasSent is the total number of stanzas sent to the remote domain.
+	StanzasSent uint64
+
+	// StanzasReceived is the total number of stanzas received from the remote domain.
+	StanzasReceived uint64
+}
+
+type domainStatuses struct {
+	mu sync.RWMutex
+	m  map[string]*DomainStatus
+}
+
+var statuses = &domainStatuses{m: make(map[string]*DomainStatus)}
+
+// DomainStatuses returns a snapshot of all known remote domains connectivity state sorted by domain name.
+func DomainStatuses() []DomainStatus {
+	statuses.mu.RLock()
+	defer statuses.mu.RUnlock()
+
+	retVal := make([]DomainStatus, 0, len(statuses.m))
+	for _, st := range statuses.m {
+		retVal = append(retVal, *st)
+	}
+	sort.Slice(retVal, func(i, j int) bool { return retVal[i].Domain < retVal[j].Domain })
+	return retVal
+}
+
+func (ds *domainStatuses) update(domain string, fn func(st *DomainStatus)) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	st := ds.m[domain]
+	if st == nil {
+		st = &DomainStatus{Domain: domain}
+		ds.m[domain] = st
+	}
+	fn(st)
+}
+
+func reportDomainConnected(domain string, secured bool, authMethod string) {
+	statuses.update(domain, func(st *DomainStatus) {
+		st.Connected = true
+		st.Secured = secured
+		st.AuthMethod = authMethod
+	})
+	reportDomainConnectionState(domain, true)
+}
+
+func reportDomainDisconnected(domain string) {
+	statuses.update(domain, func(st *DomainStatus) {
+		st.Connected = false
+		st.Secured = false
+	})
+	reportDomainConnectionState(domain, false)
+}
+
+func reportDomainDialbackResult(domain string, valid bool) {
+	statuses.update(domain, func(st *DomainStatus) {
+		if valid {
+			st.DialbackResult = "valid"
+		} else {
+			st.DialbackResult = "invalid"
+		}
+	})
+}
+
+func reportDomainError(domain string, err error) {
+	statuses.update(domain, func(st *DomainStatus) {
+		st.LastError = err.Error()
+		st.LastErrorAt = time.Now()
+	})
+	reportDomainErrorMetric(domain)
+}
+
+func reportDomainStanzaSent(domain string) {
+	statuses.update(domain, func(st *DomainStatus) {
+		st.StanzasSent++
+	})
+	reportDomainStanzaMetric(domain, "out")
+}
+
+func reportDomainStanzaReceived(domain string) {
+	statuses.update(domain, func(st *DomainStatus) {
+		st.StanzasReceived++
+	})
+	reportDomainStanzaMetric(domain, "in")
+}
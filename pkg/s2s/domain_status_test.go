@@ -0,0 +1,53 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainStatus_Report(t *testing.T) {
+	// given
+	domain := "status.jabber.org"
+
+	// when
+	reportDomainDialbackResult(domain, true)
+	reportDomainConnected(domain, true, "dialback")
+	reportDomainStanzaSent(domain)
+	reportDomainStanzaSent(domain)
+	reportDomainStanzaReceived(domain)
+	reportDomainError(domain, errors.New("connection reset"))
+	reportDomainDisconnected(domain)
+
+	// then
+	var st *DomainStatus
+	for _, s := range DomainStatuses() {
+		if s.Domain == domain {
+			st = &s
+			break
+		}
+	}
+	require.NotNil(t, st)
+	require.False(t, st.Connected)
+	require.Equal(t, "dialback", st.AuthMethod)
+	require.Equal(t, "valid", st.DialbackResult)
+	require.Equal(t, "connection reset", st.LastError)
+	require.False(t, st.LastErrorAt.IsZero())
+	require.Equal(t, uint64(2), st.StanzasSent)
+	require.Equal(t, uint64(1), st.StanzasReceived)
+}
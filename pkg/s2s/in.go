@@ -42,6 +42,7 @@ import (
 	xmppsession "github.com/ortuman/jackal/pkg/session"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/transport"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 )
 
 type inState uint32
@@ -56,10 +57,12 @@ const (
 var inDisconnectTimeout = time.Second * 5
 
 type inConfig struct {
-	reqTimeout    time.Duration
-	maxStanzaSize int
-	directTLS     bool
-	tlsConfig     *tls.Config
+	reqTimeout       time.Duration
+	keepAliveTimeout time.Duration
+	maxStanzaSize    int
+	directTLS        bool
+	tlsConfig        *tls.Config
+	allowUnsecured   func(domain string) bool
 }
 
 type inS2S struct {
@@ -266,26 +269,40 @@ func (s *inS2S) handleConnecting(ctx context.Context, elem stravaganza.Element)
 	s.jd, _ = jid.New("", s.sender, "", true)
 	s.session.SetFromJID(s.jd)
 
+	if len(s.sender) > 0 && !s.hosts.IsFederationAllowed(s.target, s.sender) {
+		level.Info(s.logger).Log("msg", "rejecting S2S stream by federation policy", "sender", s.sender, "target", s.target)
+		return s.disconnect(ctx, streamerror.E(streamerror.PolicyViolation))
+	}
+
 	fb := stravaganza.NewBuilder("stream:features")
 	fb.WithAttribute("xmlns:stream", streamNamespace)
 	fb.WithAttribute("version", "1.0")
 
 	if !s.flags.isSecured() {
+		if !s.isUnsecuredAllowed() {
+			fb.WithChild(stravaganza.NewBuilder("starttls").
+				WithAttribute(stravaganza.Namespace, tlsNamespace).
+				WithChild(
+					stravaganza.NewBuilder("required").
+						Build(),
+				).
+				Build(),
+			)
+			s.setState(inConnected)
+			if err := s.session.OpenStream(ctx); err != nil {
+				return err
+			}
+			return s.session.Send(ctx, fb.Build())
+		}
+		// trusted peer... TLS is offered but not required
+		level.Warn(s.logger).Log("msg", "accepting unsecured S2S stream from trusted peer", "sender", s.sender)
+
 		fb.WithChild(stravaganza.NewBuilder("starttls").
 			WithAttribute(stravaganza.Namespace, tlsNamespace).
-			WithChild(
-				stravaganza.NewBuilder("required").
-					Build(),
-			).
 			Build(),
 		)
-		s.setState(inConnected)
-		if err := s.session.OpenStream(ctx); err != nil {
-			return err
-		}
-		return s.session.Send(ctx, fb.Build())
 	}
-	if !s.flags.isAuthenticated() {
+	if !s.flags.isAuthenticated() && s.flags.isSecured() {
 		fb.WithChild(stravaganza.NewBuilder("mechanisms").
 			WithAttribute(stravaganza.Namespace, saslNamespace).
 			WithChild(
@@ -300,6 +317,8 @@ func (s *inS2S) handleConnecting(ctx context.Context, elem stravaganza.Element)
 		WithAttribute(stravaganza.Namespace, dialbackNamespace).
 		Build(),
 	)
+	fb.WithChild(xmpputil.MakeStreamLimitsElement(s.cfg.maxStanzaSize, s.cfg.keepAliveTimeout))
+
 	s.setState(inConnected)
 	if err := s.session.OpenStream(ctx); err != nil {
 		return err
@@ -308,11 +327,11 @@ func (s *inS2S) handleConnecting(ctx context.Context, elem stravaganza.Element)
 }
 
 func (s *inS2S) handleConnected(ctx context.Context, elem stravaganza.Element) error {
-	if !s.flags.isSecured() {
+	if !s.flags.isSecured() && (elem.Name() == "starttls" || !s.isUnsecuredAllowed()) {
 		return s.proceedStartTLS(ctx, elem)
 	}
 	switch {
-	case elem.Name() == "auth" && !s.flags.isAuthenticated():
+	case elem.Name() == "auth" && !s.flags.isAuthenticated() && s.flags.isSecured():
 		return s.authenticate(ctx, elem)
 
 	case elem.Name() == "db:result" && !s.flags.isDialbackKeyAuthorized():
@@ -350,6 +369,8 @@ func (s *inS2S) handleConnected(ctx context.Context, elem stravaganza.Element) e
 }
 
 func (s *inS2S) processStanza(ctx context.Context, stanza stravaganza.Stanza) error {
+	reportDomainStanzaReceived(s.sender)
+
 	toJID := stanza.ToJID()
 	if s.comps.IsComponentHost(toJID.Domain()) {
 		return s.comps.ProcessStanza(ctx, stanza)
@@ -413,6 +434,9 @@ func (s *inS2S) processIQ(ctx context.Context, iq *stravaganza.IQ) error {
 	case router.ErrRemoteServerTimeout:
 		return s.sendElement(ctx, stanzaerror.E(stanzaerror.RemoteServerTimeout, iq).Element())
 
+	case router.ErrRemoteServerNotAllowed:
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAllowed, iq).Element())
+
 	case nil, router.ErrUserNotAvailable:
 		_, err = s.runHook(ctx, hook.S2SInStreamIQRouted, &hook.S2SStreamInfo{
 			ID:      s.ID().String(),
@@ -477,6 +501,9 @@ sendMsg:
 	case router.ErrRemoteServerTimeout:
 		return s.sendElement(ctx, stanzaerror.E(stanzaerror.RemoteServerTimeout, message).Element())
 
+	case router.ErrRemoteServerNotAllowed:
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAllowed, message).Element())
+
 	case nil, router.ErrUserNotAvailable:
 		halted, hErr := s.runHook(ctx, hook.S2SInStreamMessageRouted, &hook.S2SStreamInfo{
 			ID:      s.ID().String(),
@@ -608,6 +635,9 @@ func (s *inS2S) authorizeDialbackKey(ctx context.Context, elem stravaganza.Eleme
 	elemFrom := elem.Attribute(stravaganza.From)
 	elemTo := elem.Attribute(stravaganza.To)
 
+	if !s.hosts.IsFederationAllowed(elemTo, elemFrom) {
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAllowed, elem).Element())
+	}
 	dbParams := DialbackParams{
 		StreamID: s.session.StreamID(),
 		From:     elemTo,
@@ -706,6 +736,10 @@ func (s *inS2S) verifyDialbackKey(ctx context.Context, elem stravaganza.Element)
 	return s.sendElement(ctx, sb.Build())
 }
 
+func (s *inS2S) isUnsecuredAllowed() bool {
+	return s.cfg.allowUnsecured != nil && s.cfg.allowUnsecured(s.sender)
+}
+
 func (s *inS2S) proceedStartTLS(ctx context.Context, elem stravaganza.Element) error {
 	if elem.Attribute(stravaganza.Namespace) != tlsNamespace {
 		return s.disconnect(ctx, streamerror.E(streamerror.InvalidNamespace))
@@ -98,6 +98,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 		kvGetFn          func(ctx context.Context, key string) ([]byte, error)
 		routeError       error
 		flags            uint8
+		unsecured        bool
 		waitBeforeAssert time.Duration
 
 		// expectations
@@ -120,6 +121,36 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>`,
 			expectedState:  inConnected,
 		},
+		{
+			name:  "Connecting/FederationNotAllowed",
+			state: inConnecting,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:stream").
+					WithAttribute(stravaganza.Namespace, "jabber:server").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithAttribute(stravaganza.From, "spam.net").
+					WithAttribute(stravaganza.To, "localhost").
+					WithAttribute(stravaganza.Version, "1.0").
+					Build(), nil
+			},
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:error><policy-violation xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></stream:error></stream:stream>`,
+			expectedState:  inDisconnected,
+		},
+		{
+			name:      "Connecting/UnsecuredTrusted",
+			state:     inConnecting,
+			unsecured: true,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:stream").
+					WithAttribute(stravaganza.Namespace, "jabber:server").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithAttribute(stravaganza.To, "localhost").
+					WithAttribute(stravaganza.Version, "1.0").
+					Build(), nil
+			},
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/><dialback xmlns='urn:xmpp:features:dialback'/><limits xmlns='urn:xmpp:stream-limits:0'><max-bytes>8192</max-bytes></limits></stream:features>`,
+			expectedState:  inConnected,
+		},
 		{
 			name:  "Connecting/Secured",
 			state: inConnecting,
@@ -132,7 +163,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 					WithAttribute(stravaganza.Version, "1.0").
 					Build(), nil
 			},
-			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>EXTERNAL</mechanism></mechanisms><dialback xmlns='urn:xmpp:features:dialback'/></stream:features>`,
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>EXTERNAL</mechanism></mechanisms><dialback xmlns='urn:xmpp:features:dialback'/><limits xmlns='urn:xmpp:stream-limits:0'><max-bytes>8192</max-bytes></limits></stream:features>`,
 			expectedState:  inConnected,
 		},
 		{
@@ -147,7 +178,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 					WithAttribute(stravaganza.Version, "1.0").
 					Build(), nil
 			},
-			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><dialback xmlns='urn:xmpp:features:dialback'/></stream:features>`,
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><dialback xmlns='urn:xmpp:features:dialback'/><limits xmlns='urn:xmpp:stream-limits:0'><max-bytes>8192</max-bytes></limits></stream:features>`,
 			expectedState:  inConnected,
 		},
 		{
@@ -341,6 +372,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 				return "jackal.im"
 			}
 			hMock.IsLocalHostFunc = func(host string) bool { return host == "jackal.im" }
+			hMock.IsFederationAllowedFunc = func(host, remoteDomain string) bool { return remoteDomain != "spam.net" }
 			hMock.CertificatesFunc = func() []tls.Certificate { return nil }
 
 			// KV mock
@@ -414,8 +446,9 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 
 			stm := &inS2S{
 				cfg: inConfig{
-					reqTimeout:    time.Minute,
-					maxStanzaSize: 8192,
+					reqTimeout:     time.Minute,
+					maxStanzaSize:  8192,
+					allowUnsecured: func(_ string) bool { return tt.unsecured },
 				},
 				state:       tt.state,
 				flags:       flags{fs: tt.flags},
@@ -431,6 +464,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 				comps:       compsMock,
 				session:     ssMock,
 				outProvider: outProviderMock,
+				inHub:       NewInHub(kitlog.NewNopLogger()),
 				hk:          hook.NewHooks(),
 				logger:      kitlog.NewNopLogger(),
 			}
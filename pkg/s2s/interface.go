@@ -18,12 +18,14 @@ import (
 	"context"
 	"crypto/tls"
 	"net"
+	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/cluster/kv"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/storage/repository"
 	"github.com/ortuman/jackal/pkg/transport"
 )
 
@@ -32,6 +34,12 @@ type kvStorage interface {
 	kv.KV
 }
 
+//go:generate moq -out repository.mock_test.go . s2sQueueRepository:repositoryMock
+type s2sQueueRepository interface {
+	repository.S2SQueue
+	repository.Locker
+}
+
 //go:generate moq -out router.mock_test.go . globalRouter:routerMock
 type globalRouter interface {
 	router.Router
@@ -53,6 +61,7 @@ type hosts interface {
 
 	Certificates() []tls.Certificate
 	IsLocalHost(host string) bool
+	IsFederationAllowed(host, remoteDomain string) bool
 }
 
 //go:generate moq -out session.mock_test.go . session
@@ -98,6 +107,7 @@ type s2sOut interface {
 	stream.S2SOut
 	dial(ctx context.Context) error
 	start() error
+	lastActivity() time.Time
 }
 
 //go:generate moq -out s2sdialback.mock_test.go . s2sDialback
@@ -106,3 +116,8 @@ type s2sDialback interface {
 	dial(ctx context.Context) error
 	start() error
 }
+
+//go:generate moq -out tlsaresolver.mock_test.go . tlsaResolver
+type tlsaResolver interface {
+	LookupTLSA(ctx context.Context, domain string) ([]tlsaRecord, error)
+}
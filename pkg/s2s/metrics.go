@@ -106,6 +106,33 @@ var (
 		},
 		[]string{"instance"},
 	)
+	s2sDomainConnected = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "domain_connected",
+			Help:      "Whether an authenticated S2S outgoing stream to a remote domain is established.",
+		},
+		[]string{"instance", "domain"},
+	)
+	s2sDomainStanzas = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "domain_stanzas_total",
+			Help:      "The total number of stanzas exchanged with a remote domain.",
+		},
+		[]string{"instance", "domain", "direction"},
+	)
+	s2sDomainErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "domain_errors_total",
+			Help:      "The total number of S2S errors associated to a remote domain.",
+		},
+		[]string{"instance", "domain"},
+	)
 )
 
 func init() {
@@ -118,6 +145,9 @@ func init() {
 	prometheus.MustRegister(s2sIncomingRequestDurationBucket)
 	prometheus.MustRegister(s2sIncomingTotalConnections)
 	prometheus.MustRegister(s2sOutgoingTotalConnections)
+	prometheus.MustRegister(s2sDomainConnected)
+	prometheus.MustRegister(s2sDomainStanzas)
+	prometheus.MustRegister(s2sDomainErrors)
 }
 
 func reportIncomingConnectionRegistered() {
@@ -182,3 +212,32 @@ func reportTotalOutgoingConnections(totalConns int) {
 	}
 	s2sOutgoingTotalConnections.With(metricLabel).Set(float64(totalConns))
 }
+
+func reportDomainConnectionState(domain string, connected bool) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"domain":   domain,
+	}
+	var val float64
+	if connected {
+		val = 1
+	}
+	s2sDomainConnected.With(metricLabel).Set(val)
+}
+
+func reportDomainStanzaMetric(domain, direction string) {
+	metricLabel := prometheus.Labels{
+		"instance":  instance.ID(),
+		"domain":    domain,
+		"direction": direction,
+	}
+	s2sDomainStanzas.With(metricLabel).Inc()
+}
+
+func reportDomainErrorMetric(domain string) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"domain":   domain,
+	}
+	s2sDomainErrors.With(metricLabel).Inc()
+}
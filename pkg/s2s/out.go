@@ -21,6 +21,7 @@ import (
 	"errors"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	kitlog "github.com/go-kit/log"
@@ -43,6 +44,7 @@ import (
 
 var (
 	errServerTimeout = errors.New("s2s: remote server timeout")
+	errDomainBackoff = errors.New("s2s: remote domain unreachable, backing off")
 )
 
 type outType int8
@@ -93,6 +95,8 @@ type outConfig struct {
 	dialTimeout   time.Duration
 	reqTimeout    time.Duration
 	maxStanzaSize int
+	unsecured     *unsecuredPolicy
+	resolver      *dnsResolver
 }
 
 type outS2S struct {
@@ -114,10 +118,13 @@ type outS2S struct {
 	logger   kitlog.Logger
 	rq       *runqueue.RunQueue
 
+	unsecuredAllowed bool
+
 	mu           sync.RWMutex
 	state        outState
 	flags        flags
 	pendingQueue []stravaganza.Element
+	lastActiveAt int64
 }
 
 func newOutS2S(
@@ -144,9 +151,10 @@ func newOutS2S(
 		shapers: shapers,
 		hk:      hk,
 		logger:  kitlog.With(logger, "sender", sender, "target", target),
-		dialer:  newDialer(cfg.dialTimeout, tlsCfg),
+		dialer:  newDialer(cfg.dialTimeout, tlsCfg, cfg.resolver),
 	}
 	stm.rq = runqueue.New(stm.ID().String())
+	stm.touch()
 	return stm
 }
 
@@ -168,7 +176,7 @@ func newDialbackS2S(
 		tlsCfg:   tlsCfg,
 		cfg:      cfg,
 		dbParams: dbParams,
-		dialer:   newDialer(cfg.dialTimeout, tlsCfg),
+		dialer:   newDialer(cfg.dialTimeout, tlsCfg, cfg.resolver),
 		dbResCh:  make(chan stream.DialbackResult, 1),
 		shapers:  shapers,
 		logger:   logger,
@@ -205,6 +213,14 @@ func (s *outS2S) Disconnect(streamErr *streamerror.Error) <-chan error {
 	return errCh
 }
 
+func (s *outS2S) lastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastActiveAt))
+}
+
+func (s *outS2S) touch() {
+	atomic.StoreInt64(&s.lastActiveAt, time.Now().UnixNano())
+}
+
 func (s *outS2S) dial(ctx context.Context) error {
 	conn, usesTLS, err := s.dialer.DialContext(ctx, s.target)
 	if err != nil {
@@ -218,6 +234,8 @@ func (s *outS2S) dial(ctx context.Context) error {
 	}
 	level.Info(s.logger).Log("msg", "dialed S2S remote connection", "direct_tls", usesTLS)
 
+	s.unsecuredAllowed = s.cfg.unsecured.allowsDomain(s.target) || s.cfg.unsecured.allowsAddr(conn.RemoteAddr())
+
 	s.tr = transport.NewSocketTransport(conn, 0, 0)
 
 	// set default rate limiter
@@ -297,6 +315,7 @@ func (s *outS2S) handleSessionResult(elem stravaganza.Element, sErr error) {
 			err := s.handleElement(ctx, elem)
 			if err != nil {
 				level.Warn(s.logger).Log("msg", "failed to process outgoing S2S session element", "err", err, "id", s.ID())
+				s.reportError(err)
 				_ = s.close(ctx)
 				return
 			}
@@ -319,6 +338,8 @@ func (s *outS2S) connTimeout() {
 func (s *outS2S) handleElement(ctx context.Context, elem stravaganza.Element) error {
 	var err error
 	t0 := time.Now()
+	s.touch()
+
 	switch s.getState() {
 	case outConnecting:
 		err = s.handleConnecting(ctx, elem)
@@ -351,16 +372,22 @@ func (s *outS2S) handleConnected(ctx context.Context, elem stravaganza.Element)
 		return s.disconnect(ctx, streamerror.E(streamerror.UnsupportedStanzaType))
 	}
 	if !s.flags.isSecured() {
-		if elem.ChildNamespace("starttls", tlsNamespace) == nil {
+		switch {
+		case elem.ChildNamespace("starttls", tlsNamespace) != nil:
+			s.setState(outSecuring)
+
+			startTLS := stravaganza.NewBuilder("starttls").
+				WithAttribute(stravaganza.Namespace, tlsNamespace).
+				Build()
+			return s.sendElement(ctx, startTLS)
+
+		case s.unsecuredAllowed:
+			level.Warn(s.logger).Log("msg", "proceeding with unsecured S2S stream to trusted peer", "target", s.target)
+
+		default:
 			// unsecured connections are unsupported
 			return s.disconnect(ctx, streamerror.E(streamerror.PolicyViolation))
 		}
-		s.setState(outSecuring)
-
-		startTLS := stravaganza.NewBuilder("starttls").
-			WithAttribute(stravaganza.Namespace, tlsNamespace).
-			Build()
-		return s.sendElement(ctx, startTLS)
 	}
 	if s.flags.isAuthenticated() {
 		return s.finishAuthentication(ctx)
@@ -456,10 +483,12 @@ func (s *outS2S) handleVerifyingDialbackKey(ctx context.Context, elem stravaganz
 		switch elem.Attribute(stravaganza.Type) {
 		case "valid":
 			level.Info(s.logger).Log("msg", "S2S dialback key successfully verified", "from", s.sender, "to", s.target)
+			reportDomainDialbackResult(s.target, true)
 			return s.finishAuthentication(ctx)
 
 		default:
 			level.Info(s.logger).Log("msg", "failed to verify S2S dialback key", "from", s.sender, "to", s.target)
+			reportDomainDialbackResult(s.target, false)
 			return s.disconnect(ctx, streamerror.E(streamerror.RemoteConnectionFailed))
 		}
 
@@ -489,9 +518,10 @@ func (s *outS2S) handleSessionError(ctx context.Context, err error) {
 	switch err {
 	case xmppparser.ErrStreamClosedByPeer:
 		_ = s.session.Close(ctx)
-		fallthrough
+		_ = s.close(ctx)
 
 	default:
+		s.reportError(err)
 		_ = s.close(ctx)
 	}
 }
@@ -499,6 +529,14 @@ func (s *outS2S) handleSessionError(ctx context.Context, err error) {
 func (s *outS2S) finishAuthentication(ctx context.Context) error {
 	s.setState(outAuthenticated)
 
+	if s.typ == defaultType {
+		authMethod := "dialback"
+		if s.flags.isAuthenticated() {
+			authMethod = "external"
+		}
+		reportDomainConnected(s.target, s.flags.isSecured(), authMethod)
+	}
+
 	// send pending elements
 	for _, elem := range s.pendingQueue {
 		if err := s.sendElement(ctx, elem); err != nil {
@@ -542,10 +580,15 @@ func (s *outS2S) sendElement(ctx context.Context, elem stravaganza.Element) erro
 	if err != nil {
 		return err
 	}
+	s.touch()
+
 	reportOutgoingRequest(
 		elem.Name(),
 		elem.Attribute(stravaganza.Type),
 	)
+	if _, ok := elem.(stravaganza.Stanza); ok && s.typ == defaultType {
+		reportDomainStanzaSent(s.target)
+	}
 	return s.runHook(ctx, hook.S2SOutStreamElementSent, &hook.S2SStreamInfo{
 		ID:      s.ID().String(),
 		Sender:  s.sender,
@@ -565,6 +608,7 @@ func (s *outS2S) close(ctx context.Context) error {
 		close(s.dbResCh)
 	}
 	if s.typ == defaultType {
+		reportDomainDisconnected(s.target)
 		level.Info(s.logger).Log("msg", "unregistered S2S out stream")
 	}
 	// run unregistered S2S hook
@@ -581,6 +625,13 @@ func (s *outS2S) close(ctx context.Context) error {
 	return nil
 }
 
+func (s *outS2S) reportError(err error) {
+	if s.typ != defaultType {
+		return
+	}
+	reportDomainError(s.target, err)
+}
+
 func (s *outS2S) setState(state outState) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -17,6 +17,7 @@ package s2s
 import (
 	"context"
 	"crypto/tls"
+	"strings"
 	"sync"
 	"time"
 
@@ -42,7 +43,13 @@ type OutProvider struct {
 	logger  kitlog.Logger
 
 	mu         sync.RWMutex
-	outStreams map[string]s2sOut
+	outStreams map[string]*outPool
+	backoff    *domainBackoff
+	dane       *daneVerifier
+	posh       *poshVerifier
+	pins       certPins
+	unsecured  *unsecuredPolicy
+	resolver   *dnsResolver
 	doneCh     chan chan struct{}
 
 	newOutFn func(sender, target string) s2sOut
@@ -65,7 +72,8 @@ func NewOutProvider(
 		kv:         kv,
 		hk:         hk,
 		logger:     logger,
-		outStreams: make(map[string]s2sOut),
+		outStreams: make(map[string]*outPool),
+		backoff:    newDomainBackoff(cfg.Backoff.InitialInterval, cfg.Backoff.MaxInterval),
 		doneCh:     make(chan chan struct{}),
 	}
 	op.newOutFn = op.newOutS2S
@@ -78,43 +86,76 @@ func (p *OutProvider) DialbackSecret() string {
 	return p.cfg.DialbackSecret
 }
 
+type outPool struct {
+	stms []s2sOut
+	next int
+}
+
+func (op *outPool) remove(stm s2sOut) {
+	for i, s := range op.stms {
+		if s == stm {
+			op.stms[i] = nil
+		}
+	}
+}
+
+func (op *outPool) isEmpty() bool {
+	for _, s := range op.stms {
+		if s != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // GetOut returns associated outgoing S2S stream given a sender-target pair domain.
+// When pooling is enabled streams are picked in round-robin order.
 func (p *OutProvider) GetOut(ctx context.Context, sender, target string) (stream.S2SOut, error) {
 	domainPair := getDomainPair(sender, target)
 
-	p.mu.RLock()
-	outStm := p.outStreams[domainPair]
-	p.mu.RUnlock()
+	p.mu.Lock()
+	pool := p.outStreams[domainPair]
+	if pool == nil {
+		pool = &outPool{stms: make([]s2sOut, p.poolSize())}
+		p.outStreams[domainPair] = pool
+	}
+	idx := pool.next
+	pool.next = (pool.next + 1) % len(pool.stms)
 
+	outStm := pool.stms[idx]
 	if outStm != nil {
+		p.mu.Unlock()
 		return outStm, nil
 	}
-	p.mu.Lock()
-	outStm = p.outStreams[domainPair] // 2nd check
-	if outStm != nil {
+	if p.backoff.isDead(target) {
+		if pool.isEmpty() {
+			delete(p.outStreams, domainPair)
+		}
 		p.mu.Unlock()
-		return outStm, nil
+		return nil, errDomainBackoff
 	}
 	outStm = p.newOutFn(sender, target)
-	p.outStreams[domainPair] = outStm
+	pool.stms[idx] = outStm
 	p.mu.Unlock()
 
 	if err := outStm.dial(ctx); err != nil {
-		p.mu.Lock()
-		delete(p.outStreams, domainPair)
-		p.mu.Unlock()
+		p.release(domainPair, outStm)
+		reportDomainError(target, err)
+		backoff := p.backoff.failure(target)
 		level.Warn(p.logger).Log("msg", "failed to dial outgoing S2S stream",
-			"err", err, "sender", sender, "target", target,
+			"err", err, "sender", sender, "target", target, "backoff", backoff,
 		)
 		return nil, err
 	}
+	p.backoff.success(target)
+
 	go func() {
 		if err := outStm.start(); err != nil {
-			p.mu.Lock()
-			delete(p.outStreams, domainPair)
-			p.mu.Unlock()
+			p.release(domainPair, outStm)
+			reportDomainError(target, err)
+			backoff := p.backoff.failure(target)
 			level.Warn(p.logger).Log("msg", "failed to start outgoing S2S stream",
-				"err", err, "sender", sender, "target", target,
+				"err", err, "sender", sender, "target", target, "backoff", backoff,
 			)
 			return
 		}
@@ -144,26 +185,56 @@ func (p *OutProvider) GetDialback(ctx context.Context, sender, target string, pa
 
 // Start starts S2S out provider.
 func (p *OutProvider) Start(_ context.Context) error {
-	go p.reportMetrics()
+	dv, err := newDANEVerifier(p.cfg.DANE)
+	if err != nil {
+		return err
+	}
+	p.dane = dv
+	p.posh = newPOSHVerifier(p.cfg.POSH)
+
+	pins, err := parseCertPins(p.cfg.Pins)
+	if err != nil {
+		return err
+	}
+	p.pins = pins
+
+	p.unsecured, err = newUnsecuredPolicy(p.cfg.Unsecured)
+	if err != nil {
+		return err
+	}
+	if err := p.cfg.DNS.validate(); err != nil {
+		return err
+	}
+	if !p.cfg.DNS.isSystem() {
+		p.resolver, err = newDNSResolver(p.cfg.DNS)
+		if err != nil {
+			return err
+		}
+		level.Info(p.logger).Log("msg", "S2S out provider using custom DNS resolver",
+			"protocol", p.cfg.DNS.Protocol,
+			"nameservers", strings.Join(p.resolver.servers, ","),
+		)
+	}
+	if p.unsecured != nil {
+		level.Warn(p.logger).Log("msg", "S2S out provider allows unsecured connections to trusted peers",
+			"networks", strings.Join(p.cfg.Unsecured.Networks, ","),
+			"domains", strings.Join(p.cfg.Unsecured.Domains, ","),
+		)
+	}
+	go p.loop()
 	level.Info(p.logger).Log("msg", "started S2S out provider")
 	return nil
 }
 
 // Stop stops S2S out provider.
 func (p *OutProvider) Stop(ctx context.Context) error {
-	// stop metrics reporting
+	// stop metrics reporting and idle checking
 	ch := make(chan struct{})
 	p.doneCh <- ch
 	<-ch
 
-	var stms []s2sOut
-
 	// grab all connections
-	p.mu.RLock()
-	for _, stm := range p.outStreams {
-		stms = append(stms, stm)
-	}
-	p.mu.RUnlock()
+	stms := p.allStreams()
 
 	// perform stream disconnection
 	var wg sync.WaitGroup
@@ -187,10 +258,53 @@ func (p *OutProvider) Stop(ctx context.Context) error {
 
 func (p *OutProvider) unregister(stm *outS2S) {
 	id := stm.ID()
-	domainPair := getDomainPair(id.Sender, id.Target)
+	p.release(getDomainPair(id.Sender, id.Target), stm)
+}
+
+func (p *OutProvider) release(domainPair string, stm s2sOut) {
 	p.mu.Lock()
-	delete(p.outStreams, domainPair)
-	p.mu.Unlock()
+	defer p.mu.Unlock()
+
+	pool := p.outStreams[domainPair]
+	if pool == nil {
+		return
+	}
+	pool.remove(stm)
+	if pool.isEmpty() {
+		delete(p.outStreams, domainPair)
+	}
+}
+
+func (p *OutProvider) allStreams() []s2sOut {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var stms []s2sOut
+	for _, pool := range p.outStreams {
+		for _, stm := range pool.stms {
+			if stm != nil {
+				stms = append(stms, stm)
+			}
+		}
+	}
+	return stms
+}
+
+func (p *OutProvider) closeIdleStreams() {
+	for _, stm := range p.allStreams() {
+		if time.Since(stm.lastActivity()) < p.cfg.IdleTimeout {
+			continue
+		}
+		level.Info(p.logger).Log("msg", "closing idle S2S out stream", "sender", stm.ID().Sender, "target", stm.ID().Target)
+		stm.Disconnect(nil)
+	}
+}
+
+func (p *OutProvider) poolSize() int {
+	if p.cfg.PoolSize < 1 {
+		return 1
+	}
+	return p.cfg.PoolSize
 }
 
 func (p *OutProvider) newOutS2S(sender, target string) s2sOut {
@@ -209,6 +323,8 @@ func (p *OutProvider) newOutS2S(sender, target string) s2sOut {
 			dialTimeout:   p.cfg.DialTimeout,
 			reqTimeout:    p.cfg.RequestTimeout,
 			maxStanzaSize: p.cfg.MaxStanzaSize,
+			unsecured:     p.unsecured,
+			resolver:      p.resolver,
 		},
 	)
 }
@@ -226,29 +342,76 @@ func (p *OutProvider) newDialbackS2S(sender, target string, dbParams DialbackPar
 			dialTimeout:   p.cfg.DialTimeout,
 			reqTimeout:    p.cfg.RequestTimeout,
 			maxStanzaSize: p.cfg.MaxStanzaSize,
+			unsecured:     p.unsecured,
+			resolver:      p.resolver,
 		},
 		dbParams,
 	)
 }
 
 func (p *OutProvider) tlsConfig(serverName string) *tls.Config {
-	return &tls.Config{
+	cfg := &tls.Config{
 		ServerName:   serverName,
 		Certificates: p.hosts.Certificates(),
 	}
+	if verifyFn := p.verifyConnectionFn(serverName); verifyFn != nil {
+		// certificate chain verification is performed by VerifyConnection
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = verifyFn
+	}
+	return cfg
+}
+
+func (p *OutProvider) verifyConnectionFn(domain string) func(tls.ConnectionState) error {
+	policy := p.dane.policy(domain)
+	pinned := p.pins.isPinned(domain)
+	if policy == danePolicyOff && p.posh == nil && !pinned {
+		return nil // default PKIX verification
+	}
+	verifyIdentity := verifyPKIX
+	if p.posh != nil {
+		verifyIdentity = p.posh.verify
+	}
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errNoPeerCertificates
+		}
+		var err error
+		switch policy {
+		case danePolicyOff:
+			err = verifyIdentity(cs.PeerCertificates, domain)
+		default:
+			err = p.dane.verify(cs.PeerCertificates, domain, policy, verifyIdentity)
+		}
+		if err != nil {
+			return err
+		}
+		if pinned {
+			return p.pins.verify(cs.PeerCertificates, domain)
+		}
+		return nil
+	}
 }
 
-func (p *OutProvider) reportMetrics() {
+func (p *OutProvider) loop() {
 	tc := time.NewTicker(reportTotalConnectionsInterval)
 	defer tc.Stop()
 
+	var idleCh <-chan time.Time
+	if p.cfg.IdleTimeout > 0 {
+		idleTc := time.NewTicker(p.cfg.IdleTimeout / 2)
+		defer idleTc.Stop()
+		idleCh = idleTc.C
+	}
 	for {
 		select {
 		case <-tc.C:
-			p.mu.RLock()
-			totalConns := len(p.outStreams)
-			p.mu.RUnlock()
-			reportTotalOutgoingConnections(totalConns)
+			reportTotalOutgoingConnections(len(p.allStreams()))
+			p.backoff.purge()
+			p.posh.purge()
+
+		case <-idleCh:
+			p.closeIdleStreams()
 
 		case ch := <-p.doneCh:
 			close(ch)
@@ -19,13 +19,17 @@ import (
 	"testing"
 	"time"
 
+	kitlog "github.com/go-kit/log"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/stretchr/testify/require"
 )
 
 func TestOutProvider_GetOut(t *testing.T) {
 	// given
 	op := &OutProvider{
-		outStreams: make(map[string]s2sOut),
+		outStreams: make(map[string]*outPool),
 	}
 	var out *s2sOutMock
 	op.newOutFn = func(sender, target string) s2sOut {
@@ -48,10 +52,135 @@ func TestOutProvider_GetOut(t *testing.T) {
 	require.Len(t, conn1.(*s2sOutMock).dialCalls(), 1)
 }
 
+func TestOutProvider_GetPooledOut(t *testing.T) {
+	// given
+	op := &OutProvider{
+		cfg:        OutConfig{PoolSize: 2},
+		outStreams: make(map[string]*outPool),
+	}
+	op.newOutFn = func(sender, target string) s2sOut {
+		out := &s2sOutMock{}
+		out.dialFunc = func(ctx context.Context) error { return nil }
+		out.startFunc = func() error { return nil }
+		return out
+	}
+
+	// when
+	conn1, _ := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+	conn2, _ := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+	conn3, _ := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+
+	time.Sleep(time.Second) // wait until started
+
+	// then
+	require.NotEqual(t, conn1, conn2)
+	require.Equal(t, conn1, conn3)
+
+	require.Len(t, op.allStreams(), 2)
+}
+
+func TestOutProvider_CloseIdleStreams(t *testing.T) {
+	// given
+	op := &OutProvider{
+		cfg:        OutConfig{PoolSize: 2, IdleTimeout: time.Minute},
+		outStreams: make(map[string]*outPool),
+		logger:     kitlog.NewNopLogger(),
+	}
+	var outs []*s2sOutMock
+	op.newOutFn = func(sender, target string) s2sOut {
+		lastActivity := time.Now()
+		if len(outs) == 0 {
+			lastActivity = lastActivity.Add(-time.Hour)
+		}
+		out := &s2sOutMock{}
+		out.dialFunc = func(ctx context.Context) error { return nil }
+		out.startFunc = func() error { return nil }
+		out.lastActivityFunc = func() time.Time { return lastActivity }
+		out.IDFunc = func() stream.S2SOutID { return stream.S2SOutID{Sender: sender, Target: target} }
+		out.DisconnectFunc = func(streamErr *streamerror.Error) <-chan error {
+			errCh := make(chan error, 1)
+			errCh <- nil
+			return errCh
+		}
+		outs = append(outs, out)
+		return out
+	}
+	_, _ = op.GetOut(context.Background(), "jackal.im", "jabber.org")
+	_, _ = op.GetOut(context.Background(), "jackal.im", "jabber.org")
+
+	time.Sleep(time.Second) // wait until started
+
+	// when
+	op.closeIdleStreams()
+
+	// then
+	require.Len(t, outs, 2)
+	require.Len(t, outs[0].DisconnectCalls(), 1)
+	require.Len(t, outs[1].DisconnectCalls(), 0)
+}
+
+func TestOutProvider_GetOutBackoff(t *testing.T) {
+	// given
+	op := &OutProvider{
+		outStreams: make(map[string]*outPool),
+		backoff:    newDomainBackoff(time.Minute, time.Hour),
+		logger:     kitlog.NewNopLogger(),
+	}
+	var dials int
+	op.newOutFn = func(sender, target string) s2sOut {
+		out := &s2sOutMock{}
+		out.dialFunc = func(ctx context.Context) error {
+			dials++
+			return errServerTimeout
+		}
+		return out
+	}
+
+	// when
+	_, err1 := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+	_, err2 := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+
+	// then
+	require.Equal(t, errServerTimeout, err1)
+	require.Equal(t, errDomainBackoff, err2)
+	require.Equal(t, 1, dials)
+	require.Len(t, op.outStreams, 0)
+}
+
+func TestOutProvider_TLSConfigVerification(t *testing.T) {
+	// given
+	op := &OutProvider{
+		hosts: &host.Hosts{},
+		dane: &daneVerifier{
+			cfg: DANEConfig{
+				Policy:  danePolicyOff,
+				Domains: map[string]string{"jabber.org": danePolicyRequire},
+			},
+		},
+	}
+
+	// when
+	tlsCfg1 := op.tlsConfig("jabber.org")
+	tlsCfg2 := op.tlsConfig("xmpp.org")
+
+	op.posh = &poshVerifier{}
+	tlsCfg3 := op.tlsConfig("xmpp.org")
+
+	// then
+	require.True(t, tlsCfg1.InsecureSkipVerify)
+	require.NotNil(t, tlsCfg1.VerifyConnection)
+
+	require.False(t, tlsCfg2.InsecureSkipVerify)
+	require.Nil(t, tlsCfg2.VerifyConnection)
+
+	require.True(t, tlsCfg3.InsecureSkipVerify)
+	require.NotNil(t, tlsCfg3.VerifyConnection)
+}
+
 func TestOutProvider_GetDialback(t *testing.T) {
 	// given
 	op := &OutProvider{
-		outStreams: make(map[string]s2sOut),
+		outStreams: make(map[string]*outPool),
 	}
 	op.newDbFn = func(sender, target string, dbParam DialbackParams) s2sDialback {
 		db := &s2sDialbackMock{}
@@ -124,6 +124,7 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 		target       string
 		sessionResFn func() (stravaganza.Element, error)
 		flags        uint8
+		unsecured    bool
 
 		// expectations
 		expectedOutput string
@@ -236,6 +237,39 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 			expectedOutput: `<db:result from='jackal.im' to='jabber.org'>21bd4eb62f7d70d22b545f38a40a023ad6fa385905f36d889612fcb4cdb4966c</db:result>`,
 			expectedState:  outVerifyingDialbackKey,
 		},
+		{
+			name:      "Connected/UnsecuredTrustedDialback",
+			state:     outConnected,
+			unsecured: true,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:features").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithChild(
+						stravaganza.NewBuilder("dialback").
+							WithAttribute(stravaganza.Namespace, dialbackNamespace).
+							Build(),
+					).
+					Build(), nil
+			},
+			expectedOutput: `<db:result from='jackal.im' to='jabber.org'>21bd4eb62f7d70d22b545f38a40a023ad6fa385905f36d889612fcb4cdb4966c</db:result>`,
+			expectedState:  outVerifyingDialbackKey,
+		},
+		{
+			name:  "Connected/UnsecuredUntrusted",
+			state: outConnected,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:features").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithChild(
+						stravaganza.NewBuilder("dialback").
+							WithAttribute(stravaganza.Namespace, dialbackNamespace).
+							Build(),
+					).
+					Build(), nil
+			},
+			expectedOutput: `<stream:error><policy-violation xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></stream:error></stream:stream>`,
+			expectedState:  outDisconnected,
+		},
 		{
 			name:  "VerifyingDialback/Valid",
 			state: outVerifyingDialbackKey,
@@ -304,15 +338,16 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 					reqTimeout:    time.Minute,
 					maxStanzaSize: 8192,
 				},
-				typ:     defaultType,
-				state:   tt.state,
-				flags:   flags{fs: tt.flags},
-				rq:      runqueue.New(tt.name),
-				tr:      trMock,
-				session: ssMock,
-				kv:      kvMock,
-				hk:      hook.NewHooks(),
-				logger:  kitlog.NewNopLogger(),
+				typ:              defaultType,
+				state:            tt.state,
+				flags:            flags{fs: tt.flags},
+				rq:               runqueue.New(tt.name),
+				tr:               trMock,
+				session:          ssMock,
+				kv:               kvMock,
+				hk:               hook.NewHooks(),
+				logger:           kitlog.NewNopLogger(),
+				unsecuredAllowed: tt.unsecured,
 			}
 			// when
 			stm.handleSessionResult(tt.sessionResFn())
@@ -0,0 +1,69 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const spkiPinPrefix = "sha256/"
+
+var errPinMismatch = errors.New("s2s: remote certificate does not match any pinned public key")
+
+// certPins holds, per remote domain, the set of SHA-256 hashes of allowed subject public keys.
+type certPins map[string][][]byte
+
+func parseCertPins(cfg map[string][]string) (certPins, error) {
+	pins := make(certPins, len(cfg))
+	for domain, domainPins := range cfg {
+		if len(domainPins) == 0 {
+			return nil, fmt.Errorf("s2s: empty pin set for domain %s", domain)
+		}
+		for _, pin := range domainPins {
+			h, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, spkiPinPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("s2s: invalid pin for domain %s: %v", domain, err)
+			}
+			if len(h) != sha256.Size {
+				return nil, fmt.Errorf("s2s: invalid pin length for domain %s", domain)
+			}
+			pins[domain] = append(pins[domain], h)
+		}
+	}
+	return pins, nil
+}
+
+func (cp certPins) isPinned(domain string) bool {
+	return len(cp[domain]) > 0
+}
+
+// verify checks that at least one certificate of the presented chain matches a domain pin.
+func (cp certPins) verify(certs []*x509.Certificate, domain string) error {
+	for _, cert := range certs {
+		h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range cp[domain] {
+			if subtle.ConstantTimeCompare(h[:], pin) == 1 {
+				return nil
+			}
+		}
+	}
+	return errPinMismatch
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertPins_Parse(t *testing.T) {
+	h := sha256.Sum256([]byte("spki"))
+	pin := base64.StdEncoding.EncodeToString(h[:])
+
+	pins, err := parseCertPins(map[string][]string{"jabber.org": {pin, spkiPinPrefix + pin}})
+	require.Nil(t, err)
+	require.True(t, pins.isPinned("jabber.org"))
+	require.False(t, pins.isPinned("xmpp.org"))
+	require.Len(t, pins["jabber.org"], 2)
+
+	_, err = parseCertPins(map[string][]string{"jabber.org": {"not-base64!"}})
+	require.NotNil(t, err)
+
+	_, err = parseCertPins(map[string][]string{"jabber.org": {base64.StdEncoding.EncodeToString([]byte("short"))}})
+	require.NotNil(t, err)
+
+	_, err = parseCertPins(map[string][]string{"jabber.org": {}})
+	require.NotNil(t, err)
+}
+
+func TestCertPins_Verify(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "jabber.org")
+	h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	otherCert := testSelfSignedCertificate(t, "jabber.org")
+
+	pins, err := parseCertPins(map[string][]string{"jabber.org": {base64.StdEncoding.EncodeToString(h[:])}})
+	require.Nil(t, err)
+
+	// then
+	require.Nil(t, pins.verify([]*x509.Certificate{cert}, "jabber.org"))
+	require.Equal(t, errPinMismatch, pins.verify([]*x509.Certificate{otherCert}, "jabber.org"))
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	poshURLFormat = "https://%s/.well-known/posh/xmpp-server.json"
+
+	poshMaxDocumentSize = 64 * 1024
+)
+
+var errPOSHMismatch = errors.New("s2s: remote certificate does not match POSH fingerprints")
+
+type poshDocument struct {
+	Fingerprints []map[string]string `json:"fingerprints"`
+	Expires      int64               `json:"expires"`
+	URL          string              `json:"url"`
+}
+
+type poshEntry struct {
+	fingerprints []map[string]string
+	expiresAt    time.Time
+}
+
+// poshVerifier authenticates remote servers by means of PKIX over Secure HTTP (RFC 7711),
+// allowing a domain to delegate its XMPP service to a host presenting a certificate for a different name.
+type poshVerifier struct {
+	cfg   POSHConfig
+	getFn func(ctx context.Context, url string) ([]byte, error)
+	nowFn func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*poshEntry
+}
+
+func newPOSHVerifier(cfg POSHConfig) *poshVerifier {
+	if !cfg.Enabled {
+		return nil
+	}
+	v := &poshVerifier{
+		cfg:   cfg,
+		nowFn: time.Now,
+		cache: make(map[string]*poshEntry),
+	}
+	hc := &http.Client{
+		Timeout: cfg.Timeout,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	v.getFn = func(ctx context.Context, url string) ([]byte, error) {
+		return httpGet(ctx, hc, url)
+	}
+	return v
+}
+
+// verify authenticates certs using PKIX, falling back to domain POSH document fingerprints.
+func (v *poshVerifier) verify(certs []*x509.Certificate, domain string) error {
+	pkixErr := verifyPKIX(certs, domain)
+	if pkixErr == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), v.cfg.Timeout)
+	defer cancel()
+
+	fingerprints, err := v.fingerprints(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("%v: %w", pkixErr, err)
+	}
+	if !matchPOSHFingerprints(fingerprints, certs[0]) {
+		return errPOSHMismatch
+	}
+	return nil
+}
+
+func (v *poshVerifier) fingerprints(ctx context.Context, domain string) ([]map[string]string, error) {
+	v.mu.Lock()
+	e := v.cache[domain]
+	v.mu.Unlock()
+
+	if e != nil && v.nowFn().Before(e.expiresAt) {
+		return e.fingerprints, nil
+	}
+	doc, err := v.fetch(ctx, fmt.Sprintf(poshURLFormat, domain))
+	if err != nil {
+		return nil, err
+	}
+	// a document may delegate to another one by means of its 'url' member
+	if len(doc.URL) > 0 {
+		if !strings.HasPrefix(doc.URL, "https://") {
+			return nil, fmt.Errorf("s2s: invalid POSH redirect URL: %s", doc.URL)
+		}
+		doc, err = v.fetch(ctx, doc.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ttl := v.cfg.CacheExpiration
+	if doc.Expires > 0 {
+		ttl = time.Duration(doc.Expires) * time.Second
+	}
+	v.mu.Lock()
+	v.cache[domain] = &poshEntry{
+		fingerprints: doc.Fingerprints,
+		expiresAt:    v.nowFn().Add(ttl),
+	}
+	v.mu.Unlock()
+
+	return doc.Fingerprints, nil
+}
+
+func (v *poshVerifier) fetch(ctx context.Context, url string) (*poshDocument, error) {
+	b, err := v.getFn(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	var doc poshDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (v *poshVerifier) purge() {
+	if v == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.nowFn()
+	for domain, e := range v.cache {
+		if now.After(e.expiresAt) {
+			delete(v.cache, domain)
+		}
+	}
+}
+
+func matchPOSHFingerprints(fingerprints []map[string]string, cert *x509.Certificate) bool {
+	for _, fp := range fingerprints {
+		for alg, val := range fp {
+			var h hash.Hash
+			switch alg {
+			case "sha-256":
+				h = sha256.New()
+			case "sha-384":
+				h = sha512.New384()
+			case "sha-512":
+				h = sha512.New()
+			default:
+				continue
+			}
+			expected, err := base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				continue
+			}
+			h.Write(cert.Raw)
+			if subtle.ConstantTimeCompare(h.Sum(nil), expected) == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func httpGet(ctx context.Context, hc *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s2s: unexpected POSH response status: %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, poshMaxDocumentSize))
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPOSHVerifier_Verify(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "hosting.example.net")
+	fp := sha256.Sum256(cert.Raw)
+
+	var urls []string
+	v := &poshVerifier{
+		cfg:   POSHConfig{Timeout: time.Second, CacheExpiration: time.Hour},
+		nowFn: time.Now,
+		cache: make(map[string]*poshEntry),
+		getFn: func(ctx context.Context, url string) ([]byte, error) {
+			urls = append(urls, url)
+			return []byte(fmt.Sprintf(`{"fingerprints":[{"sha-256":"%s"}],"expires":600}`, base64.StdEncoding.EncodeToString(fp[:]))), nil
+		},
+	}
+
+	// when
+	err1 := v.verify([]*x509.Certificate{cert}, "jabber.org")
+	err2 := v.verify([]*x509.Certificate{cert}, "jabber.org")
+
+	// then
+	require.Nil(t, err1)
+	require.Nil(t, err2)
+	require.Equal(t, []string{"https://jabber.org/.well-known/posh/xmpp-server.json"}, urls) // cached
+}
+
+func TestPOSHVerifier_Redirect(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "hosting.example.net")
+	fp := sha256.Sum256(cert.Raw)
+
+	v := &poshVerifier{
+		cfg:   POSHConfig{Timeout: time.Second, CacheExpiration: time.Hour},
+		nowFn: time.Now,
+		cache: make(map[string]*poshEntry),
+		getFn: func(ctx context.Context, url string) ([]byte, error) {
+			switch url {
+			case "https://jabber.org/.well-known/posh/xmpp-server.json":
+				return []byte(`{"url":"https://hosting.example.net/.well-known/posh/xmpp-server.json"}`), nil
+			default:
+				return []byte(fmt.Sprintf(`{"fingerprints":[{"sha-256":"%s"}]}`, base64.StdEncoding.EncodeToString(fp[:]))), nil
+			}
+		},
+	}
+
+	// when
+	err := v.verify([]*x509.Certificate{cert}, "jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, time.Hour, time.Until(v.cache["jabber.org"].expiresAt).Round(time.Minute))
+}
+
+func TestPOSHVerifier_Mismatch(t *testing.T) {
+	// given
+	cert := testSelfSignedCertificate(t, "hosting.example.net")
+
+	v := &poshVerifier{
+		cfg:   POSHConfig{Timeout: time.Second, CacheExpiration: time.Hour},
+		nowFn: time.Now,
+		cache: make(map[string]*poshEntry),
+		getFn: func(ctx context.Context, url string) ([]byte, error) {
+			return []byte(`{"fingerprints":[{"sha-256":"4/mggdlVx8A3pvHAWW5sD+qJyMtUHgiRuPjVC48N0XQ="}]}`), nil
+		},
+	}
+
+	// when
+	err := v.verify([]*x509.Certificate{cert}, "jabber.org")
+
+	// then
+	require.Equal(t, errPOSHMismatch, err)
+}
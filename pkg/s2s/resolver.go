@@ -0,0 +1,373 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	dnsProtocolSystem = "system"
+	dnsProtocolUDP    = "udp"
+	dnsProtocolTCP    = "tcp"
+	dnsProtocolTLS    = "tls"
+
+	dnsOverTLSPort = "853"
+)
+
+var errDNSNoRecords = errors.New("s2s: no DNS records found")
+
+type dnsCacheKey struct {
+	name  string
+	qType dnsmessage.Type
+}
+
+// dnsCacheEntry holds a resolved answer. An entry with no records represents a negative answer.
+type dnsCacheEntry struct {
+	srvs      []*net.SRV
+	addrs     []string
+	expiresAt time.Time
+}
+
+func (e dnsCacheEntry) isNegative() bool {
+	return len(e.srvs) == 0 && len(e.addrs) == 0
+}
+
+// dnsResolver resolves SRV and address records against a configurable set of nameservers,
+// caching both positive and negative answers for as long as their TTL allows.
+type dnsResolver struct {
+	cfg        DNSConfig
+	servers    []string
+	exchangeFn func(ctx context.Context, q []byte, server string) ([]byte, error)
+	nowFn      func() time.Time
+
+	mu    sync.Mutex
+	cache map[dnsCacheKey]dnsCacheEntry
+}
+
+func newDNSResolver(cfg DNSConfig) (*dnsResolver, error) {
+	servers := cfg.Nameservers
+	if len(servers) == 0 {
+		var err error
+		servers, err = readResolvConf(defaultResolvConf)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Protocol == dnsProtocolTLS {
+			for i, srv := range servers {
+				host, _, _ := net.SplitHostPort(srv)
+				servers[i] = net.JoinHostPort(host, dnsOverTLSPort)
+			}
+		}
+	}
+	r := &dnsResolver{
+		cfg:     cfg,
+		servers: servers,
+		nowFn:   time.Now,
+		cache:   make(map[dnsCacheKey]dnsCacheEntry),
+	}
+	switch cfg.Protocol {
+	case dnsProtocolUDP:
+		r.exchangeFn = exchangeDNS
+
+	case dnsProtocolTCP:
+		r.exchangeFn = func(ctx context.Context, q []byte, server string) ([]byte, error) {
+			return exchangeDNSStream(ctx, q, server, nil)
+		}
+
+	case dnsProtocolTLS:
+		r.exchangeFn = func(ctx context.Context, q []byte, server string) ([]byte, error) {
+			serverName := cfg.TLSServerName
+			if len(serverName) == 0 {
+				serverName, _, _ = net.SplitHostPort(server)
+			}
+			return exchangeDNSStream(ctx, q, server, &tls.Config{
+				ServerName: serverName,
+				MinVersion: tls.VersionTLS12,
+			})
+		}
+
+	default:
+		return nil, fmt.Errorf("s2s: unsupported DNS protocol: %s", cfg.Protocol)
+	}
+	return r, nil
+}
+
+// LookupSRV returns the SRV records of the given service, ordered by priority and randomized by weight.
+func (r *dnsResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	e, err := r.lookup(ctx, fmt.Sprintf("_%s._%s.%s", service, proto, name), dnsmessage.TypeSRV)
+	if err != nil {
+		return "", nil, err
+	}
+	if e.isNegative() {
+		return "", nil, errDNSNoRecords
+	}
+	srvs := make([]*net.SRV, 0, len(e.srvs))
+	for _, srv := range e.srvs {
+		cp := *srv
+		srvs = append(srvs, &cp)
+	}
+	sortSRV(srvs)
+	return "", srvs, nil
+}
+
+// LookupHost returns host IPv4 and IPv6 addresses, in that order.
+func (r *dnsResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+	var addrs []string
+	var lastErr error
+	for _, qType := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		e, err := r.lookup(ctx, host, qType)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		addrs = append(addrs, e.addrs...)
+	}
+	if len(addrs) > 0 {
+		return addrs, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, errDNSNoRecords
+}
+
+func (r *dnsResolver) lookup(ctx context.Context, name string, qType dnsmessage.Type) (dnsCacheEntry, error) {
+	key := dnsCacheKey{
+		name:  strings.ToLower(strings.TrimSuffix(name, ".")),
+		qType: qType,
+	}
+	if e, ok := r.cached(key); ok {
+		return e, nil
+	}
+	if r.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+	}
+	q, err := buildDNSQuery(name, qType)
+	if err != nil {
+		return dnsCacheEntry{}, err
+	}
+	var lastErr error
+	for _, srv := range r.servers {
+		resp, err := r.exchangeFn(ctx, q, srv)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		e, err := r.parseAnswer(resp, qType)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		r.store(key, e)
+		return e, nil
+	}
+	return dnsCacheEntry{}, lastErr
+}
+
+func (r *dnsResolver) parseAnswer(resp []byte, qType dnsmessage.Type) (dnsCacheEntry, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(resp)
+	if err != nil {
+		return dnsCacheEntry{}, err
+	}
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+		break
+	default:
+		return dnsCacheEntry{}, fmt.Errorf("s2s: DNS lookup failed: %s", h.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return dnsCacheEntry{}, err
+	}
+	var e dnsCacheEntry
+	var ttl uint32 = math.MaxUint32
+	for {
+		ah, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		} else if err != nil {
+			return dnsCacheEntry{}, err
+		}
+		if ah.Type != qType {
+			if err := p.SkipAnswer(); err != nil {
+				return dnsCacheEntry{}, err
+			}
+			continue
+		}
+		switch qType {
+		case dnsmessage.TypeSRV:
+			srv, err := p.SRVResource()
+			if err != nil {
+				return dnsCacheEntry{}, err
+			}
+			e.srvs = append(e.srvs, &net.SRV{
+				Target:   srv.Target.String(),
+				Port:     srv.Port,
+				Priority: srv.Priority,
+				Weight:   srv.Weight,
+			})
+
+		case dnsmessage.TypeA:
+			a, err := p.AResource()
+			if err != nil {
+				return dnsCacheEntry{}, err
+			}
+			e.addrs = append(e.addrs, net.IP(a.A[:]).String())
+
+		case dnsmessage.TypeAAAA:
+			aaaa, err := p.AAAAResource()
+			if err != nil {
+				return dnsCacheEntry{}, err
+			}
+			e.addrs = append(e.addrs, net.IP(aaaa.AAAA[:]).String())
+
+		default:
+			if err := p.SkipAnswer(); err != nil {
+				return dnsCacheEntry{}, err
+			}
+			continue
+		}
+		if ah.TTL < ttl {
+			ttl = ah.TTL
+		}
+	}
+	maxTTL := r.cfg.MaxTTL
+	if e.isNegative() {
+		// negative answers are cached according to the zone SOA record (RFC 2308)
+		maxTTL = r.cfg.NegativeTTL
+		for {
+			ah, err := p.AuthorityHeader()
+			if errors.Is(err, dnsmessage.ErrSectionDone) {
+				break
+			} else if err != nil {
+				return dnsCacheEntry{}, err
+			}
+			if ah.Type != dnsmessage.TypeSOA {
+				if err := p.SkipAuthority(); err != nil {
+					return dnsCacheEntry{}, err
+				}
+				continue
+			}
+			soa, err := p.SOAResource()
+			if err != nil {
+				return dnsCacheEntry{}, err
+			}
+			ttl = ah.TTL
+			if soa.MinTTL < ttl {
+				ttl = soa.MinTTL
+			}
+		}
+	}
+	d := time.Duration(ttl) * time.Second
+	if d > maxTTL {
+		d = maxTTL
+	}
+	e.expiresAt = r.nowFn().Add(d)
+	return e, nil
+}
+
+func (r *dnsResolver) cached(key dnsCacheKey) (dnsCacheEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.cache[key]
+	if !ok {
+		return dnsCacheEntry{}, false
+	}
+	if !r.nowFn().Before(e.expiresAt) {
+		delete(r.cache, key)
+		return dnsCacheEntry{}, false
+	}
+	return e, true
+}
+
+func (r *dnsResolver) store(key dnsCacheKey, e dnsCacheEntry) {
+	if r.cfg.CacheSize <= 0 {
+		return
+	}
+	now := r.nowFn()
+	if !now.Before(e.expiresAt) {
+		return // zero TTL
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.cache) >= r.cfg.CacheSize {
+		for k, ce := range r.cache {
+			if !now.Before(ce.expiresAt) {
+				delete(r.cache, k)
+			}
+		}
+	}
+	if len(r.cache) >= r.cfg.CacheSize {
+		for k := range r.cache {
+			delete(r.cache, k) // evict an arbitrary entry
+			break
+		}
+	}
+	r.cache[key] = e
+}
+
+// sortSRV orders SRV records by priority, shuffling same priority records by weight (RFC 2782).
+func sortSRV(srvs []*net.SRV) {
+	sort.Slice(srvs, func(i, j int) bool {
+		return srvs[i].Priority < srvs[j].Priority
+	})
+	i := 0
+	for j := 1; j <= len(srvs); j++ {
+		if j == len(srvs) || srvs[i].Priority != srvs[j].Priority {
+			shuffleSRVByWeight(srvs[i:j])
+			i = j
+		}
+	}
+}
+
+func shuffleSRVByWeight(srvs []*net.SRV) {
+	sum := 0
+	for _, srv := range srvs {
+		sum += int(srv.Weight)
+	}
+	for sum > 0 && len(srvs) > 1 {
+		s := 0
+		n := rand.Intn(sum)
+		for i := range srvs {
+			s += int(srvs[i].Weight)
+			if s > n {
+				srvs[0], srvs[i] = srvs[i], srvs[0]
+				break
+			}
+		}
+		sum -= int(srvs[0].Weight)
+		srvs = srvs[1:]
+	}
+}
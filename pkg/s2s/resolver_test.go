@@ -0,0 +1,204 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSResolver_LookupSRVCached(t *testing.T) {
+	// given
+	now := time.Now()
+	r, ex := testDNSResolver(t, &now, func(q dnsmessage.Question, b *dnsmessage.Builder) {
+		require.Equal(t, "_xmpp-server._tcp.jabber.org.", q.Name.String())
+
+		_ = b.StartAnswers()
+		_ = b.SRVResource(testRRHeader(q, 300), dnsmessage.SRVResource{
+			Priority: 20, Weight: 0, Port: 5269, Target: dnsmessage.MustNewName("backup.jabber.org."),
+		})
+		_ = b.SRVResource(testRRHeader(q, 120), dnsmessage.SRVResource{
+			Priority: 10, Weight: 0, Port: 5269, Target: dnsmessage.MustNewName("xmpp.jabber.org."),
+		})
+	})
+
+	// when
+	_, srvs, err := r.LookupSRV(context.Background(), s2sService, "tcp", "jabber.org")
+	_, _, _ = r.LookupSRV(context.Background(), s2sService, "tcp", "jabber.org")
+
+	now = now.Add(time.Minute * 3) // lowest TTL expired
+	_, _, _ = r.LookupSRV(context.Background(), s2sService, "tcp", "jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, srvs, 2)
+	require.Equal(t, "xmpp.jabber.org.", srvs[0].Target)
+	require.Equal(t, "backup.jabber.org.", srvs[1].Target)
+
+	require.Equal(t, 2, ex.count)
+}
+
+func TestDNSResolver_NegativeCache(t *testing.T) {
+	// given
+	now := time.Now()
+	r, ex := testDNSResolver(t, &now, func(q dnsmessage.Question, b *dnsmessage.Builder) {
+		_ = b.StartAuthorities()
+		_ = b.SOAResource(dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName("jabber.org."),
+			Class: dnsmessage.ClassINET,
+			TTL:   3600,
+		}, dnsmessage.SOAResource{
+			NS:     dnsmessage.MustNewName("ns.jabber.org."),
+			MBox:   dnsmessage.MustNewName("admin.jabber.org."),
+			MinTTL: 30,
+		})
+	})
+
+	// when
+	_, _, err := r.LookupSRV(context.Background(), s2sTLSService, "tcp", "jabber.org")
+	_, _, _ = r.LookupSRV(context.Background(), s2sTLSService, "tcp", "jabber.org")
+
+	now = now.Add(time.Second * 31) // SOA minimum TTL expired
+	_, _, _ = r.LookupSRV(context.Background(), s2sTLSService, "tcp", "jabber.org")
+
+	// then
+	require.Equal(t, errDNSNoRecords, err)
+	require.Equal(t, 2, ex.count)
+}
+
+func TestDNSResolver_LookupHost(t *testing.T) {
+	// given
+	now := time.Now()
+	r, ex := testDNSResolver(t, &now, func(q dnsmessage.Question, b *dnsmessage.Builder) {
+		_ = b.StartAnswers()
+		switch q.Type {
+		case dnsmessage.TypeA:
+			_ = b.AResource(testRRHeader(q, 60), dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+		case dnsmessage.TypeAAAA:
+			_ = b.AAAAResource(testRRHeader(q, 60), dnsmessage.AAAAResource{
+				AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1},
+			})
+		}
+	})
+
+	// when
+	addrs, err := r.LookupHost(context.Background(), "xmpp.jabber.org.")
+	ipAddrs, _ := r.LookupHost(context.Background(), "192.0.2.7")
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, []string{"192.0.2.1", "2001:db8::1"}, addrs)
+	require.Equal(t, []string{"192.0.2.7"}, ipAddrs)
+	require.Equal(t, 2, ex.count)
+}
+
+func TestDNSResolver_MaxTTL(t *testing.T) {
+	// given
+	now := time.Now()
+	r, ex := testDNSResolver(t, &now, func(q dnsmessage.Question, b *dnsmessage.Builder) {
+		_ = b.StartAnswers()
+		_ = b.AResource(testRRHeader(q, 86400), dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	})
+
+	// when
+	_, _ = r.LookupHost(context.Background(), "xmpp.jabber.org")
+
+	now = now.Add(time.Hour + time.Second)
+	_, _ = r.LookupHost(context.Background(), "xmpp.jabber.org")
+
+	// then
+	require.Equal(t, 4, ex.count) // A and AAAA lookups, twice
+}
+
+func TestDNSConfig_Validate(t *testing.T) {
+	require.Nil(t, DNSConfig{}.validate())
+	require.Nil(t, DNSConfig{Protocol: dnsProtocolTLS}.validate())
+	require.NotNil(t, DNSConfig{Protocol: "https"}.validate())
+}
+
+func TestSortSRV(t *testing.T) {
+	// given
+	srvs := []*net.SRV{
+		{Target: "c", Priority: 30},
+		{Target: "a1", Priority: 10, Weight: 10},
+		{Target: "b", Priority: 20},
+		{Target: "a2", Priority: 10, Weight: 90},
+	}
+
+	// when
+	sortSRV(srvs)
+
+	// then
+	require.Contains(t, []string{"a1", "a2"}, srvs[0].Target)
+	require.Contains(t, []string{"a1", "a2"}, srvs[1].Target)
+	require.Equal(t, "b", srvs[2].Target)
+	require.Equal(t, "c", srvs[3].Target)
+}
+
+type testDNSExchanger struct {
+	answerFn func(q dnsmessage.Question, b *dnsmessage.Builder)
+	count    int
+}
+
+func (e *testDNSExchanger) exchange(_ context.Context, q []byte, _ string) ([]byte, error) {
+	e.count++
+
+	var p dnsmessage.Parser
+	h, err := p.Start(q)
+	if err != nil {
+		return nil, err
+	}
+	question, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true})
+	_ = b.StartQuestions()
+	_ = b.Question(question)
+	e.answerFn(question, &b)
+	return b.Finish()
+}
+
+func testDNSResolver(t *testing.T, now *time.Time, answerFn func(q dnsmessage.Question, b *dnsmessage.Builder)) (*dnsResolver, *testDNSExchanger) {
+	t.Helper()
+
+	r, err := newDNSResolver(DNSConfig{
+		Protocol:    dnsProtocolUDP,
+		Nameservers: []string{"192.0.2.53:53"},
+		CacheSize:   100,
+		MaxTTL:      time.Hour,
+		NegativeTTL: time.Minute,
+	})
+	require.Nil(t, err)
+
+	ex := &testDNSExchanger{answerFn: answerFn}
+	r.exchangeFn = ex.exchange
+	r.nowFn = func() time.Time { return *now }
+	return r, ex
+}
+
+func testRRHeader(q dnsmessage.Question, ttl uint32) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{
+		Name:  q.Name,
+		Type:  q.Type,
+		Class: dnsmessage.ClassINET,
+		TTL:   ttl,
+	}
+}
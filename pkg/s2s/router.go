@@ -17,19 +17,55 @@ package s2s
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
 
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/host"
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
 	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var errS2SQueueFull = errors.New("s2s: outgoing queue is full")
+
 type s2sRouter struct {
 	outProvider outProvider
+	hosts       hosts
+	rep         s2sQueueRepository
+	cfg         QueueConfig
+	logger      kitlog.Logger
+
+	mu     sync.RWMutex
+	queued map[string]struct{}
+
+	stopCh chan struct{}
+	doneCh chan struct{}
 }
 
 // NewRouter creates and returns an initialized S2S router.
-func NewRouter(outProvider *OutProvider) router.S2SRouter {
+func NewRouter(
+	outProvider *OutProvider,
+	hosts *host.Hosts,
+	rep repository.Repository,
+	cfg QueueConfig,
+	logger kitlog.Logger,
+) router.S2SRouter {
 	return &s2sRouter{
 		outProvider: outProvider,
+		hosts:       hosts,
+		rep:         rep,
+		cfg:         cfg,
+		logger:      logger,
+		queued:      make(map[string]struct{}),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
 	}
 }
 
@@ -37,23 +73,252 @@ func (r *s2sRouter) Route(ctx context.Context, stanza stravaganza.Stanza, sender
 	remoteJID := stanza.ToJID()
 	targetDomain := remoteJID.Domain()
 
+	// preserve delivery order while the remote domain has pending stanzas
+	if r.isQueueable(stanza) && r.isQueued(targetDomain) {
+		if err := r.enqueue(ctx, stanza, senderDomain, targetDomain); err == nil {
+			return nil
+		}
+		return router.ErrRemoteServerTimeout
+	}
 	stm, err := r.outProvider.GetOut(ctx, senderDomain, targetDomain)
 	switch {
 	case err == nil:
 		break
-	case errors.Is(err, errServerTimeout):
-		return router.ErrRemoteServerTimeout
+	case r.isQueueable(stanza) && isUnreachableErr(err):
+		if qErr := r.enqueue(ctx, stanza, senderDomain, targetDomain); qErr == nil {
+			return nil
+		}
+		return routeError(err)
 	default:
-		return router.ErrRemoteServerNotFound
+		return routeError(err)
 	}
 	_ = stm.SendElement(stanza)
 	return nil
 }
 
-func (r *s2sRouter) Start(_ context.Context) error {
+func (r *s2sRouter) Start(ctx context.Context) error {
+	if !r.cfg.Enabled {
+		return nil
+	}
+	domains, err := r.rep.FetchS2SQueueDomains(ctx)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	for _, domain := range domains {
+		r.queued[domain] = struct{}{}
+	}
+	r.mu.Unlock()
+
+	go r.loop()
+
+	level.Info(r.logger).Log("msg", "started S2S outgoing queue", "queued_domains", len(domains))
 	return nil
 }
 
 func (r *s2sRouter) Stop(_ context.Context) error {
+	if !r.cfg.Enabled {
+		return nil
+	}
+	close(r.stopCh)
+	<-r.doneCh
+
+	level.Info(r.logger).Log("msg", "stopped S2S outgoing queue")
 	return nil
 }
+
+func (r *s2sRouter) loop() {
+	defer close(r.doneCh)
+
+	tc := time.NewTicker(r.cfg.RetryInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			r.retry()
+
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *s2sRouter) retry() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.RetryInterval)
+	defer cancel()
+
+	if err := r.rep.DeleteExpiredS2SQueueStanzas(ctx); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to delete expired S2S queued stanzas", "err", err)
+	}
+	for _, domain := range r.queuedDomains() {
+		if err := r.flush(ctx, domain); err != nil {
+			level.Debug(r.logger).Log("msg", "failed to flush S2S queue", "domain", domain, "err", err)
+		}
+	}
+}
+
+func (r *s2sRouter) enqueue(ctx context.Context, stanza stravaganza.Stanza, senderDomain, domain string) error {
+	lockID := s2sQueueLockID(domain)
+
+	if err := r.rep.Lock(ctx, lockID); err != nil {
+		return err
+	}
+	defer r.releaseLock(ctx, lockID)
+
+	count, err := r.rep.CountS2SQueueStanzas(ctx, domain)
+	if err != nil {
+		return err
+	}
+	if count >= r.cfg.MaxSize {
+		level.Warn(r.logger).Log("msg", "S2S queue size limit reached", "domain", domain, "size", count)
+		return errS2SQueueFull
+	}
+	err = r.rep.InsertS2SQueueStanza(ctx, &s2squeuemodel.Stanza{
+		Domain:       domain,
+		SenderDomain: senderDomain,
+		Stanza:       stanza.Proto(),
+		ExpiresAt:    timestamppb.New(time.Now().Add(r.cfg.Expiration)),
+	})
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.queued[domain] = struct{}{}
+	r.mu.Unlock()
+
+	level.Debug(r.logger).Log("msg", "queued S2S stanza", "domain", domain)
+	return nil
+}
+
+func (r *s2sRouter) flush(ctx context.Context, domain string) error {
+	lockID := s2sQueueLockID(domain)
+
+	if err := r.rep.Lock(ctx, lockID); err != nil {
+		return err
+	}
+	defer r.releaseLock(ctx, lockID)
+
+	stanzas, err := r.rep.FetchS2SQueueStanzas(ctx, domain)
+	if err != nil {
+		return err
+	}
+	var sentIDs []int64
+	var flushErr error
+
+	streams := make(map[string]stream.S2SOut)
+	failed := make(map[string]struct{})
+
+	for _, qs := range stanzas {
+		senderDomain := qs.SenderDomain
+		if len(senderDomain) == 0 {
+			senderDomain = r.hosts.DefaultHostName()
+		}
+		if _, ok := failed[senderDomain]; ok {
+			continue // preserve delivery order of the remaining sender stanzas
+		}
+		stanza, err := stravaganza.NewBuilderFromProto(qs.Stanza).BuildStanza()
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to build S2S queued stanza", "domain", domain, "err", err)
+			sentIDs = append(sentIDs, qs.Id) // discard malformed stanza
+			continue
+		}
+		if err := r.send(ctx, streams, stanza, senderDomain, domain); err != nil {
+			failed[senderDomain] = struct{}{}
+			flushErr = err
+			continue
+		}
+		sentIDs = append(sentIDs, qs.Id)
+	}
+	if err := r.rep.DeleteS2SQueueStanzas(ctx, domain, sentIDs); err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		r.mu.Lock()
+		delete(r.queued, domain)
+		r.mu.Unlock()
+	}
+	if len(sentIDs) > 0 {
+		level.Info(r.logger).Log("msg", "flushed S2S queue", "domain", domain, "count", len(sentIDs))
+	}
+	return flushErr
+}
+
+func (r *s2sRouter) send(ctx context.Context, streams map[string]stream.S2SOut, stanza stravaganza.Stanza, senderDomain, domain string) error {
+	stm := streams[senderDomain]
+	if stm == nil {
+		var err error
+		stm, err = r.outProvider.GetOut(ctx, senderDomain, domain)
+		if err != nil {
+			return err
+		}
+		streams[senderDomain] = stm
+	}
+	select {
+	case err := <-stm.SendElement(stanza):
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *s2sRouter) isQueueable(stanza stravaganza.Stanza) bool {
+	if !r.cfg.Enabled {
+		return false
+	}
+	switch stanza.(type) {
+	case *stravaganza.Message, *stravaganza.Presence:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *s2sRouter) isQueued(domain string) bool {
+	r.mu.RLock()
+	_, ok := r.queued[domain]
+	r.mu.RUnlock()
+	return ok
+}
+
+func (r *s2sRouter) queuedDomains() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	domains := make([]string, 0, len(r.queued))
+	for domain := range r.queued {
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+func (r *s2sRouter) releaseLock(ctx context.Context, lockID string) {
+	if err := r.rep.Unlock(ctx, lockID); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to release lock", "err", err)
+	}
+}
+
+func routeError(err error) error {
+	if errors.Is(err, errServerTimeout) || errors.Is(err, errDomainBackoff) {
+		return router.ErrRemoteServerTimeout
+	}
+	return router.ErrRemoteServerNotFound
+}
+
+// isUnreachableErr reports whether err denotes a transient connectivity failure,
+// as opposed to a remote domain that does not exist at all.
+func isUnreachableErr(err error) bool {
+	if errors.Is(err, errServerTimeout) || errors.Is(err, errDomainBackoff) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+func s2sQueueLockID(domain string) string {
+	return fmt.Sprintf("s2s:queue:%s", domain)
+}
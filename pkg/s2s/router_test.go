@@ -17,9 +17,14 @@ package s2s
 import (
 	"context"
 	"errors"
+	"net"
 	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
 
 	"github.com/jackal-xmpp/stravaganza"
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/stretchr/testify/require"
@@ -81,6 +86,204 @@ func TestS2sRouter_RouteServerGenericError(t *testing.T) {
 	require.Equal(t, router.ErrRemoteServerNotFound, err)
 }
 
+func TestS2sRouter_RouteQueueUnreachable(t *testing.T) {
+	// given
+	op := &outProviderMock{}
+	op.GetOutFunc = func(ctx context.Context, sender string, target string) (stream.S2SOut, error) {
+		return nil, errServerTimeout
+	}
+	var queued []*s2squeuemodel.Stanza
+
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.CountS2SQueueStanzasFunc = func(ctx context.Context, domain string) (int, error) {
+		return len(queued), nil
+	}
+	repMock.InsertS2SQueueStanzaFunc = func(ctx context.Context, stanza *s2squeuemodel.Stanza) error {
+		queued = append(queued, stanza)
+		return nil
+	}
+
+	// when
+	r := &s2sRouter{
+		outProvider: op,
+		rep:         repMock,
+		cfg:         QueueConfig{Enabled: true, MaxSize: 1, Expiration: time.Hour},
+		queued:      make(map[string]struct{}),
+		logger:      kitlog.NewNopLogger(),
+	}
+	err1 := r.Route(context.Background(), testMessageStanza(), "jackal.im")
+	err2 := r.Route(context.Background(), testMessageStanza(), "jackal.im")
+
+	// then
+	require.Nil(t, err1)
+	require.Equal(t, router.ErrRemoteServerTimeout, err2) // queue is full
+
+	require.Len(t, queued, 1)
+	require.Equal(t, "jackal.im", queued[0].Domain)
+	require.Equal(t, "jackal.im", queued[0].SenderDomain)
+	require.True(t, r.isQueued("jackal.im"))
+	require.Len(t, op.GetOutCalls(), 1)
+}
+
+func TestS2sRouter_RouteNotQueueIQ(t *testing.T) {
+	// given
+	op := &outProviderMock{}
+	op.GetOutFunc = func(ctx context.Context, sender string, target string) (stream.S2SOut, error) {
+		return nil, errServerTimeout
+	}
+	repMock := &repositoryMock{}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "iq_1").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.To, "jabber.org").
+		WithChild(stravaganza.NewBuilder("ping").WithAttribute(stravaganza.Namespace, "urn:xmpp:ping").Build()).
+		BuildIQ()
+
+	// when
+	r := &s2sRouter{
+		outProvider: op,
+		rep:         repMock,
+		cfg:         QueueConfig{Enabled: true, MaxSize: 10, Expiration: time.Hour},
+		queued:      make(map[string]struct{}),
+		logger:      kitlog.NewNopLogger(),
+	}
+	err := r.Route(context.Background(), iq, "jackal.im")
+
+	// then
+	require.Equal(t, router.ErrRemoteServerTimeout, err)
+	require.Len(t, repMock.InsertS2SQueueStanzaCalls(), 0)
+}
+
+func TestS2sRouter_FlushQueue(t *testing.T) {
+	// given
+	out := &s2sOutMock{}
+	out.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		errCh := make(chan error, 1)
+		errCh <- nil
+		return errCh
+	}
+	op := &outProviderMock{}
+	op.GetOutFunc = func(ctx context.Context, sender string, target string) (stream.S2SOut, error) {
+		return out, nil
+	}
+	hMock := &hostsMock{}
+	hMock.DefaultHostNameFunc = func() string { return "jackal.im" }
+
+	var deletedIDs []int64
+
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.DeleteExpiredS2SQueueStanzasFunc = func(ctx context.Context) error { return nil }
+	repMock.FetchS2SQueueStanzasFunc = func(ctx context.Context, domain string) ([]*s2squeuemodel.Stanza, error) {
+		return []*s2squeuemodel.Stanza{
+			{Id: 1, Domain: domain, SenderDomain: "jackal.im", Stanza: testMessageStanza().Proto()},
+			{Id: 2, Domain: domain, SenderDomain: "jackal.net", Stanza: testMessageStanza().Proto()},
+			{Id: 3, Domain: domain, SenderDomain: "jackal.im", Stanza: testMessageStanza().Proto()},
+		}, nil
+	}
+	repMock.DeleteS2SQueueStanzasFunc = func(ctx context.Context, domain string, ids []int64) error {
+		deletedIDs = ids
+		return nil
+	}
+
+	r := &s2sRouter{
+		outProvider: op,
+		hosts:       hMock,
+		rep:         repMock,
+		cfg:         QueueConfig{Enabled: true, MaxSize: 10, RetryInterval: time.Minute},
+		queued:      map[string]struct{}{"jabber.org": {}},
+		logger:      kitlog.NewNopLogger(),
+	}
+
+	// when
+	r.retry()
+
+	// then
+	require.Len(t, out.SendElementCalls(), 3)
+	require.Equal(t, []int64{1, 2, 3}, deletedIDs)
+
+	require.Len(t, op.GetOutCalls(), 2)
+	require.Equal(t, "jackal.im", op.GetOutCalls()[0].Sender)
+	require.Equal(t, "jackal.net", op.GetOutCalls()[1].Sender)
+	require.Equal(t, "jabber.org", op.GetOutCalls()[0].Target)
+
+	require.False(t, r.isQueued("jabber.org"))
+}
+
+func TestS2sRouter_FlushQueueSendError(t *testing.T) {
+	// given
+	var sent int
+
+	out := &s2sOutMock{}
+	out.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		errCh := make(chan error, 1)
+		if sent++; sent > 1 {
+			errCh <- errors.New("broken pipe")
+		} else {
+			errCh <- nil
+		}
+		return errCh
+	}
+	op := &outProviderMock{}
+	op.GetOutFunc = func(ctx context.Context, sender string, target string) (stream.S2SOut, error) {
+		return out, nil
+	}
+	hMock := &hostsMock{}
+	hMock.DefaultHostNameFunc = func() string { return "jackal.im" }
+
+	var deletedIDs []int64
+
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.DeleteExpiredS2SQueueStanzasFunc = func(ctx context.Context) error { return nil }
+	repMock.FetchS2SQueueStanzasFunc = func(ctx context.Context, domain string) ([]*s2squeuemodel.Stanza, error) {
+		return []*s2squeuemodel.Stanza{
+			{Id: 1, Domain: domain, Stanza: testMessageStanza().Proto()},
+			{Id: 2, Domain: domain, Stanza: testMessageStanza().Proto()},
+			{Id: 3, Domain: domain, Stanza: testMessageStanza().Proto()},
+		}, nil
+	}
+	repMock.DeleteS2SQueueStanzasFunc = func(ctx context.Context, domain string, ids []int64) error {
+		deletedIDs = ids
+		return nil
+	}
+
+	r := &s2sRouter{
+		outProvider: op,
+		hosts:       hMock,
+		rep:         repMock,
+		cfg:         QueueConfig{Enabled: true, MaxSize: 10, RetryInterval: time.Minute},
+		queued:      map[string]struct{}{"jabber.org": {}},
+		logger:      kitlog.NewNopLogger(),
+	}
+
+	// when
+	r.retry()
+
+	// then
+	require.Len(t, out.SendElementCalls(), 2) // stops on first failure
+	require.Equal(t, []int64{1}, deletedIDs)
+
+	require.Len(t, op.GetOutCalls(), 1)
+	require.Equal(t, "jackal.im", op.GetOutCalls()[0].Sender)
+
+	require.True(t, r.isQueued("jabber.org"))
+}
+
+func TestS2sRouter_IsUnreachableError(t *testing.T) {
+	require.True(t, isUnreachableErr(errServerTimeout))
+	require.True(t, isUnreachableErr(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	require.True(t, isUnreachableErr(&net.DNSError{IsTemporary: true}))
+	require.False(t, isUnreachableErr(&net.DNSError{IsNotFound: true}))
+	require.False(t, isUnreachableErr(errors.New("foo error")))
+}
+
 func testMessageStanza() *stravaganza.Message {
 	b := stravaganza.NewMessageBuilder()
 	b.WithAttribute("from", "noelia@jackal.im/yard")
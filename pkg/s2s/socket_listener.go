@@ -19,6 +19,7 @@ import (
 	"crypto/tls"
 	"net"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -55,6 +56,8 @@ type SocketListener struct {
 	logger        kitlog.Logger
 	connHandlerFn func(conn net.Conn)
 
+	unsecured *unsecuredPolicy
+
 	ln     net.Listener
 	active uint32
 }
@@ -123,11 +126,44 @@ func newSocketListener(
 	return ln
 }
 
+// Derive returns a new S2S listener sharing l settings and dependencies, bound to a different address.
+func (l *SocketListener) Derive(bindAddr string, port int, directTLS bool) *SocketListener {
+	cfg := l.cfg
+	cfg.BindAddr = bindAddr
+	cfg.Port = port
+	cfg.DirectTLS = directTLS
+
+	return newSocketListener(
+		cfg,
+		l.hosts,
+		l.router,
+		l.comps,
+		l.mods,
+		l.outProvider,
+		l.kv,
+		l.inHUB,
+		l.shapers,
+		l.hk,
+		l.logger,
+	)
+}
+
 // Start starts listening on the TCP network address bindAddr to handle incoming S2S connections.
 func (l *SocketListener) Start(ctx context.Context) error {
 	var err error
 	var ln net.Listener
 
+	l.unsecured, err = newUnsecuredPolicy(l.cfg.Unsecured)
+	if err != nil {
+		return err
+	}
+	if l.unsecured != nil {
+		level.Warn(l.logger).Log("msg", "S2S listener allows unsecured connections from trusted peers",
+			"bind_addr", l.getAddress(),
+			"networks", strings.Join(l.cfg.Unsecured.Networks, ","),
+			"domains", strings.Join(l.cfg.Unsecured.Domains, ","),
+		)
+	}
 	lc := net.ListenConfig{
 		KeepAlive: listenKeepAlive,
 	}
@@ -183,10 +219,12 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 		l.hk,
 		l.logger,
 		inConfig{
-			reqTimeout:    l.cfg.RequestTimeout,
-			maxStanzaSize: l.cfg.MaxStanzaSize,
-			directTLS:     l.cfg.DirectTLS,
-			tlsConfig:     l.getTLSConfig(),
+			reqTimeout:       l.cfg.RequestTimeout,
+			keepAliveTimeout: l.cfg.KeepAliveTimeout,
+			maxStanzaSize:    l.cfg.MaxStanzaSize,
+			directTLS:        l.cfg.DirectTLS,
+			tlsConfig:        l.getTLSConfig(),
+			allowUnsecured:   l.allowUnsecuredFn(conn.RemoteAddr()),
 		},
 	)
 	if err != nil {
@@ -202,9 +240,19 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 
 func (l *SocketListener) getTLSConfig() *tls.Config {
 	return &tls.Config{
-		Certificates: l.hosts.Certificates(),
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		MinVersion:   tls.VersionTLS12,
+		GetCertificate: l.hosts.GetCertificate, // hosts can be added at runtime
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+func (l *SocketListener) allowUnsecuredFn(remoteAddr net.Addr) func(domain string) bool {
+	if l.unsecured == nil {
+		return nil
+	}
+	trustedAddr := l.unsecured.allowsAddr(remoteAddr)
+	return func(domain string) bool {
+		return trustedAddr || l.unsecured.allowsDomain(domain)
 	}
 }
 
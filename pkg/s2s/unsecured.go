@@ -0,0 +1,80 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"fmt"
+	"net"
+)
+
+// unsecuredPolicy determines which remote peers are allowed to federate without TLS.
+type unsecuredPolicy struct {
+	nets    []*net.IPNet
+	domains map[string]struct{}
+}
+
+func newUnsecuredPolicy(cfg UnsecuredConfig) (*unsecuredPolicy, error) {
+	if len(cfg.Networks) == 0 && len(cfg.Domains) == 0 {
+		return nil, nil
+	}
+	p := &unsecuredPolicy{
+		domains: make(map[string]struct{}, len(cfg.Domains)),
+	}
+	for _, cidr := range cfg.Networks {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("s2s: invalid unsecured network %s: %v", cidr, err)
+		}
+		p.nets = append(p.nets, ipNet)
+	}
+	for _, domain := range cfg.Domains {
+		p.domains[domain] = struct{}{}
+	}
+	return p, nil
+}
+
+func (p *unsecuredPolicy) allowsAddr(addr net.Addr) bool {
+	if p == nil || addr == nil {
+		return false
+	}
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range p.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *unsecuredPolicy) allowsDomain(domain string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.domains[domain]
+	return ok
+}
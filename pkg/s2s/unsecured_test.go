@@ -0,0 +1,49 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsecuredPolicy_Allows(t *testing.T) {
+	p, err := newUnsecuredPolicy(UnsecuredConfig{
+		Networks: []string{"10.0.0.0/8", "fd00::/8"},
+		Domains:  []string{"lab.jackal.im"},
+	})
+	require.Nil(t, err)
+
+	require.True(t, p.allowsAddr(&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5269}))
+	require.True(t, p.allowsAddr(&net.TCPAddr{IP: net.ParseIP("fd00::1"), Port: 5269}))
+	require.False(t, p.allowsAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 5269}))
+
+	require.True(t, p.allowsDomain("lab.jackal.im"))
+	require.False(t, p.allowsDomain("jabber.org"))
+}
+
+func TestUnsecuredPolicy_Empty(t *testing.T) {
+	p, err := newUnsecuredPolicy(UnsecuredConfig{})
+	require.Nil(t, err)
+	require.Nil(t, p)
+
+	require.False(t, p.allowsDomain("jabber.org"))
+	require.False(t, p.allowsAddr(&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5269}))
+
+	_, err = newUnsecuredPolicy(UnsecuredConfig{Networks: []string{"10.0.0.0/33"}})
+	require.NotNil(t, err)
+}
@@ -0,0 +1,320 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	filePrefix  = "file:"
+	vaultPrefix = "vault:"
+)
+
+// Config contains secrets resolution configuration.
+type Config struct {
+	// Vault contains HashiCorp Vault connection parameters.
+	Vault VaultConfig `fig:"vault"`
+
+	// RefreshInterval defines how often leases are checked for renewal and refresh callbacks are invoked.
+	// Note that secret references are resolved once at startup, so only subsystems registering a refresh callback
+	// (i.e. host certificate files) pick up rotated secrets.
+	RefreshInterval time.Duration `fig:"refresh_interval" default:"1m"`
+}
+
+// VaultConfig contains HashiCorp Vault connection parameters.
+type VaultConfig struct {
+	// Address is the Vault server address. Defaults to VAULT_ADDR environment variable.
+	Address string `fig:"address"`
+
+	// Token is the Vault token. Defaults to VAULT_TOKEN environment variable.
+	Token string `fig:"token"`
+
+	// TokenFile is the path of a file containing the Vault token (e.g. written by Vault Agent).
+	TokenFile string `fig:"token_file"`
+
+	// Namespace is the Vault enterprise namespace.
+	Namespace string `fig:"namespace"`
+
+	// Timeout defines Vault requests timeout.
+	Timeout time.Duration `fig:"timeout" default:"10s"`
+}
+
+// RefreshFunc is invoked periodically to let subsystems pick up rotated secret files.
+type RefreshFunc func(ctx context.Context) error
+
+// Resolver resolves secret references contained in configuration values.
+//
+// A reference is a string value of the form "file:<path>", replaced by the trimmed content of the file,
+// or "vault:<path>#<key>", replaced by the key value of the Vault secret stored at path (KV v1 and v2 engines are supported).
+// Any other value is left untouched.
+type Resolver struct {
+	cfg    Config
+	logger kitlog.Logger
+
+	mu        sync.Mutex
+	vault     *vaultClient
+	leases    []*lease
+	refreshFn []RefreshFunc
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewResolver returns a new initialized secrets Resolver.
+func NewResolver(cfg Config, logger kitlog.Logger) *Resolver {
+	return &Resolver{
+		cfg:    cfg,
+		logger: kitlog.With(logger, "component", "secret"),
+	}
+}
+
+// ResolveConfig replaces in place every secret reference found in v string fields, including those within
+// nested structs, pointers, slices and maps. v must be a non-nil pointer.
+func (r *Resolver) ResolveConfig(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("secret: non-nil pointer expected")
+	}
+	// share a single read (and lease) among all keys referenced under the same vault path
+	return r.resolveValue(ctx, rv.Elem(), make(map[string]*vaultSecret))
+}
+
+// Resolve returns the value referenced by ref. Values not matching a secret reference are returned as is.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	return r.resolve(ctx, ref, make(map[string]*vaultSecret))
+}
+
+func (r *Resolver) resolve(ctx context.Context, ref string, secrets map[string]*vaultSecret) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, filePrefix):
+		return readFile(strings.TrimPrefix(ref, filePrefix))
+
+	case strings.HasPrefix(ref, vaultPrefix):
+		path, key, ok := strings.Cut(strings.TrimPrefix(ref, vaultPrefix), "#")
+		if !ok || len(path) == 0 || len(key) == 0 {
+			return "", fmt.Errorf("secret: invalid vault reference: %s", ref)
+		}
+		sec, err := r.readVaultSecret(ctx, path, secrets)
+		if err != nil {
+			return "", err
+		}
+		val, ok := sec.values[key]
+		if !ok {
+			return "", fmt.Errorf("secret: key %s not found at vault path %s", key, path)
+		}
+		return val, nil
+
+	default:
+		return ref, nil
+	}
+}
+
+// OnRefresh registers a function to be invoked on every refresh interval.
+func (r *Resolver) OnRefresh(fn RefreshFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshFn = append(r.refreshFn, fn)
+}
+
+// Start starts Vault leases renewal and secrets refresh loop.
+func (r *Resolver) Start(ctx context.Context) error {
+	if r.cfg.RefreshInterval <= 0 {
+		return fmt.Errorf("secret: refresh interval must be positive")
+	}
+	r.mu.Lock()
+	vc := r.vault
+	r.mu.Unlock()
+
+	if vc != nil {
+		// keep using the token for as long as the server runs
+		tl, err := vc.lookupToken(ctx)
+		if err != nil {
+			return err
+		}
+		if tl.renewable {
+			r.addLease(tl)
+		}
+	}
+	r.stopCh = make(chan struct{})
+	r.doneCh = make(chan struct{})
+	go r.loop()
+
+	level.Info(r.logger).Log("msg", "started secrets refresh loop", "interval", r.cfg.RefreshInterval)
+	return nil
+}
+
+// Stop stops Vault leases renewal and secrets refresh loop.
+func (r *Resolver) Stop(_ context.Context) error {
+	if r.stopCh == nil {
+		return nil
+	}
+	close(r.stopCh)
+	<-r.doneCh
+
+	level.Info(r.logger).Log("msg", "stopped secrets refresh loop")
+	return nil
+}
+
+// Refresh renews expiring Vault leases and invokes registered refresh functions.
+func (r *Resolver) Refresh(ctx context.Context) {
+	r.mu.Lock()
+	vc := r.vault
+	leases := append([]*lease(nil), r.leases...)
+	refreshFn := append([]RefreshFunc(nil), r.refreshFn...)
+	r.mu.Unlock()
+
+	now := time.Now()
+	for _, l := range leases {
+		if !l.renewable || now.Before(l.renewAt()) {
+			continue
+		}
+		if err := vc.renew(ctx, l); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to renew vault lease", "lease_id", l.id, "err", err)
+			continue
+		}
+		level.Debug(r.logger).Log("msg", "renewed vault lease", "lease_id", l.id, "duration", l.duration)
+	}
+	for _, fn := range refreshFn {
+		if err := fn(ctx); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to refresh secrets", "err", err)
+		}
+	}
+}
+
+func (r *Resolver) loop() {
+	defer close(r.doneCh)
+
+	tc := time.NewTicker(r.cfg.RefreshInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.cfg.RefreshInterval)
+			r.Refresh(ctx)
+			cancel()
+
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Resolver) readVaultSecret(ctx context.Context, path string, secrets map[string]*vaultSecret) (*vaultSecret, error) {
+	if sec := secrets[path]; sec != nil {
+		return sec, nil
+	}
+	vc, err := r.vaultClient()
+	if err != nil {
+		return nil, err
+	}
+	sec, err := vc.read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if sec.lease != nil {
+		r.addLease(sec.lease)
+	}
+	secrets[path] = sec
+	return sec, nil
+}
+
+func (r *Resolver) resolveValue(ctx context.Context, v reflect.Value, secrets map[string]*vaultSecret) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := r.resolve(ctx, v.String(), secrets)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.resolveValue(ctx, v.Elem(), secrets)
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := r.resolveValue(ctx, v.Field(i), secrets); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := r.resolveValue(ctx, v.Index(i), secrets); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map values are not addressable, hence resolve a copy and store it back
+			val := reflect.New(iter.Value().Type()).Elem()
+			val.Set(iter.Value())
+			if err := r.resolveValue(ctx, val, secrets); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), val)
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) vaultClient() (*vaultClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.vault != nil {
+		return r.vault, nil
+	}
+	vc, err := newVaultClient(r.cfg.Vault)
+	if err != nil {
+		return nil, err
+	}
+	r.vault = vc
+	return vc, nil
+}
+
+func (r *Resolver) addLease(l *lease) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leases = append(r.leases, l)
+}
+
+func readFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("secret: %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
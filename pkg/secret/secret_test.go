@@ -0,0 +1,214 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_ResolveConfig(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	pwdFile := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(pwdFile, []byte("s3cr3t\n"), 0600))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "t0ken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/jackal":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     map[string]interface{}{"token": "adm1n"},
+					"metadata": map[string]interface{}{"version": 1},
+				},
+			})
+		case "/v1/kv/jackal":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"pepper": "p3pp3r"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	type subConfig struct {
+		Password string
+	}
+	cfg := struct {
+		Storage subConfig
+		Tokens  []string
+		Peppers map[string]string
+		Plain   string
+		Ptr     *subConfig
+	}{
+		Storage: subConfig{Password: "file:" + pwdFile},
+		Tokens:  []string{"vault:secret/data/jackal#token"},
+		Peppers: map[string]string{"v1": "vault:kv/jackal#pepper"},
+		Plain:   "plain-value",
+		Ptr:     &subConfig{Password: "file:" + pwdFile},
+	}
+
+	r := NewResolver(Config{Vault: VaultConfig{Address: srv.URL, Token: "t0ken"}}, kitlog.NewNopLogger())
+
+	// when
+	err := r.ResolveConfig(context.Background(), &cfg)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", cfg.Storage.Password)
+	require.Equal(t, "s3cr3t", cfg.Ptr.Password)
+	require.Equal(t, []string{"adm1n"}, cfg.Tokens)
+	require.Equal(t, "p3pp3r", cfg.Peppers["v1"])
+	require.Equal(t, "plain-value", cfg.Plain)
+}
+
+func TestResolver_ResolveConfigSharedPath(t *testing.T) {
+	// given
+	var reads int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/database/creds/jackal" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		reads++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       fmt.Sprintf("database/creds/jackal/l%d", reads),
+			"lease_duration": 3600,
+			"renewable":      true,
+			"data":           map[string]interface{}{"username": fmt.Sprintf("u%d", reads), "password": fmt.Sprintf("p%d", reads)},
+		})
+	}))
+	defer srv.Close()
+
+	cfg := struct {
+		Username string
+		Password string
+	}{
+		Username: "vault:database/creds/jackal#username",
+		Password: "vault:database/creds/jackal#password",
+	}
+	r := NewResolver(Config{Vault: VaultConfig{Address: srv.URL, Token: "t0ken"}}, kitlog.NewNopLogger())
+
+	// when
+	err := r.ResolveConfig(context.Background(), &cfg)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "u1", cfg.Username)
+	require.Equal(t, "p1", cfg.Password) // both credentials belong to the same lease
+
+	require.Equal(t, 1, reads)
+	require.Len(t, r.leases, 1)
+}
+
+func TestResolver_ResolveErrors(t *testing.T) {
+	// given
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"password": "s3cr3t"},
+		})
+	}))
+	defer srv.Close()
+
+	r := NewResolver(Config{Vault: VaultConfig{Address: srv.URL, Token: "t0ken"}}, kitlog.NewNopLogger())
+
+	// when
+	_, err1 := r.Resolve(context.Background(), "file:/non/existing/file")
+	_, err2 := r.Resolve(context.Background(), "vault:kv/jackal")
+	_, err3 := r.Resolve(context.Background(), "vault:kv/jackal#username")
+
+	// then
+	require.Error(t, err1)
+	require.Error(t, err2)
+	require.Error(t, err3)
+}
+
+func TestResolver_RenewLeases(t *testing.T) {
+	// given
+	var tokenRenewals, leaseRenewals int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/database/creds/jackal":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "database/creds/jackal/l1",
+				"lease_duration": 0,
+				"renewable":      true,
+				"data":           map[string]interface{}{"password": "dyn4m1c"},
+			})
+		case "/v1/auth/token/lookup-self":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"ttl": 3600, "renewable": true},
+			})
+		case "/v1/auth/token/renew-self":
+			tokenRenewals++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth": map[string]interface{}{"lease_duration": 3600, "renewable": true},
+			})
+		case "/v1/sys/leases/renew":
+			leaseRenewals++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "database/creds/jackal/l1",
+				"lease_duration": 3600,
+				"renewable":      true,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var refreshed int
+	r := NewResolver(Config{
+		Vault:           VaultConfig{Address: srv.URL, Token: "t0ken"},
+		RefreshInterval: time.Hour,
+	}, kitlog.NewNopLogger())
+	r.OnRefresh(func(_ context.Context) error {
+		refreshed++
+		return nil
+	})
+
+	pwd, err := r.Resolve(context.Background(), "vault:database/creds/jackal#password")
+	require.NoError(t, err)
+	require.Equal(t, "dyn4m1c", pwd)
+
+	require.NoError(t, r.Start(context.Background()))
+	defer func() { _ = r.Stop(context.Background()) }()
+
+	// when
+	r.Refresh(context.Background()) // database lease is due, token is not
+	r.Refresh(context.Background()) // nothing is due
+
+	// then
+	require.Equal(t, 0, tokenRenewals)
+	require.Equal(t, 1, leaseRenewals)
+	require.Equal(t, 2, refreshed)
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	vaultTokenHeader     = "X-Vault-Token"
+	vaultNamespaceHeader = "X-Vault-Namespace"
+
+	tokenLeaseID = "token"
+)
+
+type lease struct {
+	id        string
+	duration  time.Duration
+	renewable bool
+	renewedAt time.Time
+}
+
+// renewAt returns the time at which lease should be renewed, leaving a half duration safety margin.
+func (l *lease) renewAt() time.Time {
+	return l.renewedAt.Add(l.duration / 2)
+}
+
+type vaultSecret struct {
+	values map[string]string
+	lease  *lease
+}
+
+type vaultClient struct {
+	addr      string
+	token     string
+	namespace string
+	cl        *http.Client
+}
+
+func newVaultClient(cfg VaultConfig) (*vaultClient, error) {
+	addr := cfg.Address
+	if len(addr) == 0 {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if len(addr) == 0 {
+		return nil, fmt.Errorf("secret: vault address not configured")
+	}
+	token := cfg.Token
+	if len(cfg.TokenFile) > 0 {
+		t, err := readFile(cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+	if len(token) == 0 {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if len(token) == 0 {
+		return nil, fmt.Errorf("secret: vault token not configured")
+	}
+	return &vaultClient{
+		addr:      strings.TrimSuffix(addr, "/"),
+		token:     token,
+		namespace: cfg.Namespace,
+		cl:        &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (c *vaultClient) read(ctx context.Context, path string) (*vaultSecret, error) {
+	var resp struct {
+		LeaseID       string                 `json:"lease_id"`
+		LeaseDuration int64                  `json:"lease_duration"`
+		Renewable     bool                   `json:"renewable"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, strings.TrimPrefix(path, "/"), nil, &resp); err != nil {
+		return nil, err
+	}
+	data := resp.Data
+	// KV v2 engine nests secret values along with its metadata
+	if inner, ok := data["data"].(map[string]interface{}); ok && data["metadata"] != nil {
+		data = inner
+	}
+	sec := &vaultSecret{values: make(map[string]string, len(data))}
+	for k, v := range data {
+		switch val := v.(type) {
+		case string:
+			sec.values[k] = val
+		default:
+			b, _ := json.Marshal(val)
+			sec.values[k] = string(b)
+		}
+	}
+	if len(resp.LeaseID) > 0 && resp.Renewable {
+		sec.lease = &lease{
+			id:        resp.LeaseID,
+			duration:  time.Duration(resp.LeaseDuration) * time.Second,
+			renewable: true,
+			renewedAt: time.Now(),
+		}
+	}
+	return sec, nil
+}
+
+func (c *vaultClient) lookupToken(ctx context.Context) (*lease, error) {
+	var resp struct {
+		Data struct {
+			TTL       int64 `json:"ttl"`
+			Renewable bool  `json:"renewable"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &lease{
+		id:        tokenLeaseID,
+		duration:  time.Duration(resp.Data.TTL) * time.Second,
+		renewable: resp.Data.Renewable && resp.Data.TTL > 0,
+		renewedAt: time.Now(),
+	}, nil
+}
+
+func (c *vaultClient) renew(ctx context.Context, l *lease) error {
+	var resp struct {
+		LeaseDuration int64 `json:"lease_duration"`
+		Renewable     bool  `json:"renewable"`
+		Auth          *struct {
+			LeaseDuration int64 `json:"lease_duration"`
+			Renewable     bool  `json:"renewable"`
+		} `json:"auth"`
+	}
+	var err error
+	if l.id == tokenLeaseID {
+		err = c.do(ctx, http.MethodPost, "auth/token/renew-self", map[string]interface{}{}, &resp)
+	} else {
+		err = c.do(ctx, http.MethodPut, "sys/leases/renew", map[string]interface{}{"lease_id": l.id}, &resp)
+	}
+	if err != nil {
+		return err
+	}
+	duration, renewable := resp.LeaseDuration, resp.Renewable
+	if resp.Auth != nil {
+		duration, renewable = resp.Auth.LeaseDuration, resp.Auth.Renewable
+	}
+	l.duration = time.Duration(duration) * time.Second
+	l.renewable = renewable
+	l.renewedAt = time.Now()
+	return nil
+}
+
+func (c *vaultClient) do(ctx context.Context, method, path string, body interface{}, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/v1/"+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(vaultTokenHeader, c.token)
+	if len(c.namespace) > 0 {
+		req.Header.Set(vaultNamespaceHeader, c.namespace)
+	}
+	resp, err := c.cl.Do(req)
+	if err != nil {
+		return fmt.Errorf("secret: vault request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("secret: vault %s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ortuman/jackal/pkg/host"
 	xmppparser "github.com/ortuman/jackal/pkg/parser"
 	"github.com/ortuman/jackal/pkg/transport"
+	jidutil "github.com/ortuman/jackal/pkg/util/jid"
 	"github.com/ortuman/jackal/pkg/util/ratelimiter"
 )
 
@@ -97,6 +98,7 @@ type Session struct {
 
 	streamID string
 	jd       jid.JID
+	bareJD   *jid.JID
 	opened   bool
 	started  bool
 }
@@ -127,6 +129,7 @@ func (ss *Session) StreamID() string {
 // SetFromJID updates current session from JID.
 func (ss *Session) SetFromJID(jd *jid.JID) {
 	ss.jd = *jd
+	ss.bareJD = jd.ToBareJID()
 }
 
 // OpenStream initializes a session session sending the proper XMPP payload.
@@ -235,6 +238,15 @@ func (ss *Session) Send(ctx context.Context, elem stravaganza.Element) error {
 	return ss.tr.Flush()
 }
 
+// SendWhitespace writes a whitespace keepalive over the session transport.
+func (ss *Session) SendWhitespace(ctx context.Context) error {
+	ss.setWriteDeadline(ctx)
+	if _, err := ss.tr.WriteString(" "); err != nil {
+		return err
+	}
+	return ss.tr.Flush()
+}
+
 // Receive returns next incoming session element.
 func (ss *Session) Receive() (stravaganza.Element, error) {
 	elem, err := ss.pr.Parse()
@@ -330,8 +342,8 @@ func (ss *Session) buildStanza(elem stravaganza.Element) (stravaganza.Stanza, er
 		return nil, err
 	}
 	sb := stravaganza.NewBuilderFromElement(elem).
-		WithAttribute(stravaganza.From, fromJID.String()).
-		WithAttribute(stravaganza.To, toJID.String()).
+		WithAttribute(stravaganza.From, jidutil.String(fromJID)).
+		WithAttribute(stravaganza.To, jidutil.String(toJID)).
 		WithoutAttribute(stravaganza.Namespace)
 
 	switch elem.Name() {
@@ -400,7 +412,7 @@ func (ss *Session) extractAddresses(elem stravaganza.Element) (fromJID *jid.JID,
 		fromJID = &ss.jd
 
 	default:
-		j, err := jid.NewWithString(from, false)
+		j, err := jidutil.Parse(from, false)
 		if err != nil || j.Domain() != ss.jd.Domain() {
 			return nil, nil, streamerror.E(streamerror.InvalidFrom)
 		}
@@ -410,16 +422,19 @@ func (ss *Session) extractAddresses(elem stravaganza.Element) (fromJID *jid.JID,
 	// validate 'to' address
 	to := elem.Attribute(stravaganza.To)
 	if len(to) > 0 {
-		toJID, err = jid.NewWithString(to, false)
+		toJID, err = jidutil.Parse(to, false)
 		if err != nil {
 			return nil, nil, stanzaerror.E(stanzaerror.JIDMalformed, elem)
 		}
 	} else {
 		switch ss.typ {
 		case C2SSession:
-			toJID = ss.jd.ToBareJID() // account's bare JID as default 'to'
+			toJID = ss.bareJD // account's bare JID as default 'to'
+			if toJID == nil {
+				toJID = ss.jd.ToBareJID()
+			}
 		default:
-			toJID, _ = jid.NewWithString(ss.hosts.DefaultHostName(), true)
+			toJID, _ = jidutil.Parse(ss.hosts.DefaultHostName(), true)
 		}
 	}
 	return
@@ -427,7 +442,7 @@ func (ss *Session) extractAddresses(elem stravaganza.Element) (fromJID *jid.JID,
 
 func (ss *Session) isValidFrom(from string) bool {
 	validFrom := false
-	j, err := jid.NewWithString(from, false)
+	j, err := jidutil.Parse(from, false)
 	if err == nil && j != nil {
 		node := j.Node()
 		domain := j.Domain()
@@ -194,6 +194,38 @@ func TestSession_Send(t *testing.T) {
 	require.Equal(t, expectedOutput, buf.String())
 }
 
+func TestSession_SendWhitespace(t *testing.T) {
+	// given
+	trMock := &transportMock{}
+	trMock.TypeFunc = func() transport.Type { return transport.Socket }
+	trMock.FlushFunc = func() error { return nil }
+
+	buf := bytes.NewBuffer(nil)
+	trMock.WriteStringFunc = func(s string) (int, error) {
+		return buf.WriteString(s)
+	}
+
+	ssJID, _ := jid.NewWithString("jackal.im", true)
+	ss := Session{
+		typ:    C2SSession,
+		id:     "ss-1",
+		cfg:    Config{MaxStanzaSize: 4096},
+		tr:     trMock,
+		hosts:  &hostsMock{},
+		pr:     &xmppParserMock{},
+		jd:     *ssJID,
+		opened: true,
+	}
+
+	// when
+	err := ss.SendWhitespace(context.Background())
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, " ", buf.String())
+	require.Len(t, trMock.FlushCalls(), 1)
+}
+
 func TestSession_ReceiveStreamSuccess(t *testing.T) {
 	// given
 	hMock := &hostsMock{}
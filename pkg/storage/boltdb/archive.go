@@ -15,16 +15,35 @@
 package boltdb
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	jidutil "github.com/ortuman/jackal/pkg/util/jid"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	bolt "go.etcd.io/bbolt"
 )
 
-const archiveStampFormat = "2006-01-02T15:04:05Z"
+const (
+	archiveBucketPrefix      = "archive:"
+	archiveIDsBucketPrefix   = "archive_ids:"
+	archivePrefsBucketPrefix = "archive_prefs:"
+	archivePrefsKey          = "prefs"
+	archiveQuotasBucket      = "archive_quotas"
+
+	archiveStampFormat = "2006-01-02T15:04:05Z"
+
+	archiveKeyLen = 16
+)
 
 type boltDBArchiveRep struct {
 	tx *bolt.Tx
@@ -34,13 +53,36 @@ func newArchiveRep(tx *bolt.Tx) *boltDBArchiveRep {
 	return &boltDBArchiveRep{tx: tx}
 }
 
-func (r *boltDBArchiveRep) InsertArchiveMessage(_ context.Context, message *archivemodel.Message) error {
-	op := insertSeqOp{
-		tx:     r.tx,
-		bucket: archiveBucket(message.ArchiveId),
-		obj:    message,
+func (r *boltDBArchiveRep) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) (bool, error) {
+	if len(message.OriginId) > 0 {
+		dupMsg, err := r.FetchArchiveMessageByOriginID(ctx, message.ArchiveId, message.OriginId)
+		if err != nil {
+			return false, err
+		}
+		if dupMsg != nil {
+			return false, nil // already archived
+		}
 	}
-	return op.do()
+	b, err := r.tx.CreateBucketIfNotExists([]byte(archiveBucket(message.ArchiveId)))
+	if err != nil {
+		return false, err
+	}
+	p, err := proto.Marshal(message)
+	if err != nil {
+		return false, err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return false, err
+	}
+	k := archiveKey(message.Stamp.AsTime(), seq)
+	if err := b.Put(k, p); err != nil {
+		return false, err
+	}
+	if err := r.indexMessage(message.ArchiveId, message.Id, k); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (r *boltDBArchiveRep) FetchArchiveMetadata(_ context.Context, archiveID string) (metadata *archivemodel.Metadata, err error) {
@@ -53,8 +95,10 @@ func (r *boltDBArchiveRep) FetchArchiveMetadata(_ context.Context, archiveID str
 	var retVal archivemodel.Metadata
 
 	c := b.Cursor()
-	_, val := c.First()
-
+	k, val := c.First()
+	if k == nil {
+		return nil, nil // empty archive
+	}
 	var msg archivemodel.Message
 	if err := proto.Unmarshal(val, &msg); err != nil {
 		return nil, err
@@ -72,25 +116,155 @@ func (r *boltDBArchiveRep) FetchArchiveMetadata(_ context.Context, archiveID str
 	return &retVal, nil
 }
 
-func (r *boltDBArchiveRep) FetchArchiveMessages(_ context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error) {
+func (r *boltDBArchiveRep) FetchArchiveIDs(_ context.Context) ([]string, error) {
+	var retVal []string
+
+	err := r.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		bucketID := string(name)
+		if strings.HasPrefix(bucketID, archiveBucketPrefix) {
+			retVal = append(retVal, strings.TrimPrefix(bucketID, archiveBucketPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBArchiveRep) FetchArchiveMessages(_ context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+	var offset, limit int
+	if page != nil {
+		offset, limit = int(page.Offset), int(page.Limit)
+	}
 	var retVal []*archivemodel.Message
 
-	op := iterKeysOp{
-		tx:     r.tx,
-		bucket: archiveBucket(archiveID),
-		iterFn: func(k, b []byte) error {
-			var msg archivemodel.Message
-			if err := proto.Unmarshal(b, &msg); err != nil {
+	err := r.iterFilteredMessages(f, archiveID, true, func(msg *archivemodel.Message) bool {
+		if offset > 0 {
+			offset--
+			return true
+		}
+		retVal = append(retVal, msg)
+		return limit <= 0 || len(retVal) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBArchiveRep) CountArchiveMessages(_ context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+	var count int
+	err := r.iterFilteredMessages(f, archiveID, false, func(_ *archivemodel.Message) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// iterFilteredMessages invokes fn, in stamp order, for every archive message matching f filters until fn returns false.
+// Messages are only decoded when needed to evaluate the filters, or when decode is true. Otherwise fn gets a nil message.
+func (r *boltDBArchiveRep) iterFilteredMessages(f *archivemodel.Filters, archiveID string, decode bool, fn func(msg *archivemodel.Message) bool) error {
+	b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+	if b == nil {
+		return nil
+	}
+	var withJID *jid.JID
+	if len(f.With) > 0 {
+		jd, err := jid.NewWithString(f.With, false)
+		if err != nil {
+			return err
+		}
+		withJID = jd
+	}
+	terms := strings.Fields(strings.ToLower(f.FullTextSearch))
+	decode = decode || withJID != nil || len(terms) > 0
+
+	match := func(k, v []byte) (*archivemodel.Message, bool, error) {
+		if !decode {
+			return nil, true, nil
+		}
+		var msg archivemodel.Message
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return nil, false, err
+		}
+		if withJID != nil && !matchesWith(&msg, withJID) {
+			return nil, false, nil
+		}
+		if len(terms) > 0 && !matchesFullText(&msg, terms) {
+			return nil, false, nil
+		}
+		return &msg, true, nil
+	}
+	from, to := r.archiveRange(f, archiveID)
+
+	// filtering by id
+	if len(f.Ids) > 0 {
+		var keys [][]byte
+		for _, id := range f.Ids {
+			k := r.messageKey(archiveID, id)
+			if k == nil || bytes.Compare(k, from) < 0 || (to != nil && bytes.Compare(k, to) >= 0) {
+				continue
+			}
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+		for _, k := range keys {
+			msg, ok, err := match(k, b.Get(k))
+			if err != nil {
 				return err
 			}
-			retVal = append(retVal, &msg)
+			if ok && !fn(msg) {
+				return nil
+			}
+		}
+		return nil
+	}
+	c := b.Cursor()
+	for k, v := c.Seek(from); k != nil; k, v = c.Next() {
+		if to != nil && bytes.Compare(k, to) >= 0 {
+			break
+		}
+		msg, ok, err := match(k, v)
+		if err != nil {
+			return err
+		}
+		if ok && !fn(msg) {
 			return nil
-		},
+		}
 	}
-	if err := op.do(); err != nil {
-		return nil, err
+	return nil
+}
+
+// archiveRange returns the [from, to) key range delimited by f stamp and identifier filters. A nil to value means no upper bound.
+func (r *boltDBArchiveRep) archiveRange(f *archivemodel.Filters, archiveID string) (from, to []byte) {
+	from = archiveKey(time.Time{}, 0)
+	if f.Start != nil {
+		from = archiveKey(f.Start.AsTime(), math.MaxUint64)
+	}
+	if f.End != nil {
+		to = archiveKey(f.End.AsTime(), 0)
+	}
+	if len(f.Ids) > 0 {
+		return from, to
+	}
+	if len(f.AfterId) > 0 {
+		if k := r.messageKey(archiveID, f.AfterId); k != nil {
+			if k = nextKey(k); bytes.Compare(k, from) > 0 {
+				from = k
+			}
+		}
 	}
-	return applyFilters(retVal, f)
+	if len(f.BeforeId) > 0 {
+		if k := r.messageKey(archiveID, f.BeforeId); k != nil && (to == nil || bytes.Compare(k, to) < 0) {
+			to = k
+		}
+	}
+	return from, to
 }
 
 func (r *boltDBArchiveRep) DeleteArchiveOldestMessages(_ context.Context, archiveID string, maxElements int) error {
@@ -122,15 +296,82 @@ func (r *boltDBArchiveRep) DeleteArchiveOldestMessages(_ context.Context, archiv
 		oldKeys = append(oldKeys, k)
 	}
 	// delete old values
-	for _, k := range oldKeys {
-		if err := b.Delete(k); err != nil {
-			return err
+	return r.deleteMessages(archiveID, oldKeys)
+}
+
+func (r *boltDBArchiveRep) DeleteArchiveMessagesBefore(ctx context.Context, host string, before time.Time) (int, error) {
+	archiveIDs, err := r.FetchArchiveIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, archiveID := range archiveIDs {
+		b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+
+		// archive owner bare JID is either message sender or recipient
+		ownerJID := archiveID + "@" + host
+
+		var oldKeys [][]byte
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var msg archivemodel.Message
+			if err := proto.Unmarshal(v, &msg); err != nil {
+				return 0, err
+			}
+			if !msg.Stamp.AsTime().Before(before) {
+				continue
+			}
+			if bareJID(msg.FromJid) != ownerJID && bareJID(msg.ToJid) != ownerJID {
+				continue
+			}
+			oldKeys = append(oldKeys, k)
+		}
+		if err := r.deleteMessages(archiveID, oldKeys); err != nil {
+			return 0, err
 		}
+		count += len(oldKeys)
 	}
-	return nil
+	return count, nil
+}
+
+func (r *boltDBArchiveRep) DeleteArchiveMessagesWith(_ context.Context, archiveID, with string) (int, error) {
+	jd, err := jid.NewWithString(with, false)
+	if err != nil {
+		return 0, err
+	}
+	b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+	if b == nil {
+		return 0, nil
+	}
+	var keys [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var msg archivemodel.Message
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return 0, err
+		}
+		if matchesWith(&msg, jd) {
+			keys = append(keys, k)
+		}
+	}
+	if err := r.deleteMessages(archiveID, keys); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
 }
 
 func (r *boltDBArchiveRep) DeleteArchive(_ context.Context, archiveID string) error {
+	if bucketID := archiveIDsBucket(archiveID); (bucketExistsOp{tx: r.tx, bucket: bucketID}).do() {
+		op := delBucketOp{
+			tx:     r.tx,
+			bucket: bucketID,
+		}
+		if err := op.do(); err != nil {
+			return err
+		}
+	}
 	op := delBucketOp{
 		tx:     r.tx,
 		bucket: archiveBucket(archiveID),
@@ -138,15 +379,333 @@ func (r *boltDBArchiveRep) DeleteArchive(_ context.Context, archiveID string) er
 	return op.do()
 }
 
+func (r *boltDBArchiveRep) UpsertArchivePrefs(_ context.Context, prefs *archivemodel.Prefs) error {
+	op := upsertKeyOp{
+		tx:     r.tx,
+		bucket: archivePrefsBucket(prefs.ArchiveId),
+		key:    archivePrefsKey,
+		obj:    prefs,
+	}
+	return op.do()
+}
+
+func (r *boltDBArchiveRep) FetchArchivePrefs(_ context.Context, archiveID string) (*archivemodel.Prefs, error) {
+	op := fetchKeyOp{
+		tx:     r.tx,
+		bucket: archivePrefsBucket(archiveID),
+		key:    archivePrefsKey,
+		obj:    &archivemodel.Prefs{},
+	}
+	obj, err := op.do()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj != nil:
+		return obj.(*archivemodel.Prefs), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (r *boltDBArchiveRep) DeleteArchivePrefs(_ context.Context, archiveID string) error {
+	bucketID := archivePrefsBucket(archiveID)
+	if !(bucketExistsOp{tx: r.tx, bucket: bucketID}).do() {
+		return nil
+	}
+	op := delBucketOp{
+		tx:     r.tx,
+		bucket: bucketID,
+	}
+	return op.do()
+}
+
+func (r *boltDBArchiveRep) FetchArchiveMessageByOriginID(_ context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+	b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+	if b == nil {
+		return nil, nil
+	}
+	c := b.Cursor()
+	for k, v := c.Last(); k != nil; k, v = c.Prev() {
+		var msg archivemodel.Message
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return nil, err
+		}
+		if msg.OriginId == originID {
+			return &msg, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *boltDBArchiveRep) DeleteArchiveExpiredMessages(_ context.Context, archiveID string, before time.Time) error {
+	b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+	if b == nil {
+		return nil
+	}
+	var oldKeys [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var msg archivemodel.Message
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return err
+		}
+		if msg.Stamp.AsTime().Before(before) {
+			oldKeys = append(oldKeys, k)
+		}
+	}
+	return r.deleteMessages(archiveID, oldKeys)
+}
+
+func (r *boltDBArchiveRep) UpsertArchiveQuota(_ context.Context, quota *archivemodel.Quota) error {
+	op := upsertKeyOp{
+		tx:     r.tx,
+		bucket: archiveQuotasBucket,
+		key:    archiveQuotaKey(quota.ArchiveId, quota.Host),
+		obj:    quota,
+	}
+	return op.do()
+}
+
+func (r *boltDBArchiveRep) FetchArchiveQuota(_ context.Context, archiveID, host string) (*archivemodel.Quota, error) {
+	op := fetchKeyOp{
+		tx:     r.tx,
+		bucket: archiveQuotasBucket,
+		key:    archiveQuotaKey(archiveID, host),
+		obj:    &archivemodel.Quota{},
+	}
+	obj, err := op.do()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj != nil:
+		return obj.(*archivemodel.Quota), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (r *boltDBArchiveRep) FetchArchiveQuotas(_ context.Context) ([]*archivemodel.Quota, error) {
+	var retVal []*archivemodel.Quota
+
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: archiveQuotasBucket,
+		iterFn: func(_, b []byte) error {
+			var quota archivemodel.Quota
+			if err := quota.UnmarshalBinary(b); err != nil {
+				return err
+			}
+			retVal = append(retVal, &quota)
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBArchiveRep) DeleteArchiveQuota(_ context.Context, archiveID, host string) error {
+	op := delKeyOp{
+		tx:     r.tx,
+		bucket: archiveQuotasBucket,
+		key:    archiveQuotaKey(archiveID, host),
+	}
+	return op.do()
+}
+
+func (r *boltDBArchiveRep) indexMessage(archiveID, id string, k []byte) error {
+	if len(id) == 0 {
+		return nil
+	}
+	b, err := r.tx.CreateBucketIfNotExists([]byte(archiveIDsBucket(archiveID)))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(id), k)
+}
+
+// messageKey returns the storage key of the archive message identified by id, or nil if not found.
+func (r *boltDBArchiveRep) messageKey(archiveID, id string) []byte {
+	b := r.tx.Bucket([]byte(archiveIDsBucket(archiveID)))
+	if b == nil {
+		return nil
+	}
+	return b.Get([]byte(id))
+}
+
+func (r *boltDBArchiveRep) deleteMessages(archiveID string, keys [][]byte) error {
+	b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+	if b == nil {
+		return nil
+	}
+	ib := r.tx.Bucket([]byte(archiveIDsBucket(archiveID)))
+	for _, k := range keys {
+		if ib != nil {
+			var msg archivemodel.Message
+			if err := proto.Unmarshal(b.Get(k), &msg); err != nil {
+				return err
+			}
+			if len(msg.Id) > 0 && bytes.Equal(ib.Get([]byte(msg.Id)), k) {
+				if err := ib.Delete([]byte(msg.Id)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveQuotaKey returns quota storage key. Archive identifiers are JID nodes, hence they never contain '@'.
+func archiveQuotaKey(archiveID, host string) string {
+	return archiveID + "@" + host
+}
+
+func archivePrefsBucket(archiveID string) string {
+	return archivePrefsBucketPrefix + archiveID
+}
+
 func archiveBucket(archiveID string) string {
-	return fmt.Sprintf("archive:%s", archiveID)
+	return archiveBucketPrefix + archiveID
+}
+
+func archiveIDsBucket(archiveID string) string {
+	return archiveIDsBucketPrefix + archiveID
+}
+
+// archiveKey returns the storage key of an archived message, so that archive messages are iterated
+// in stamp order, falling back to insertion order for messages sharing the same stamp.
+func archiveKey(stamp time.Time, seq uint64) []byte {
+	var ns uint64
+	if n := stamp.UnixNano(); n > 0 {
+		ns = uint64(n)
+	}
+	k := make([]byte, archiveKeyLen)
+	binary.BigEndian.PutUint64(k, ns)
+	binary.BigEndian.PutUint64(k[8:], seq)
+	return k
+}
+
+// nextKey returns the smallest archive key greater than k.
+func nextKey(k []byte) []byte {
+	next := make([]byte, archiveKeyLen)
+	binary.BigEndian.PutUint64(next, binary.BigEndian.Uint64(k))
+	binary.BigEndian.PutUint64(next[8:], binary.BigEndian.Uint64(k[8:])+1)
+	return next
+}
+
+// indexArchiveIDs builds the message identifier index of archives stored before it was introduced.
+func indexArchiveIDs(tx *bolt.Tx) error {
+	var archiveIDs []string
+
+	err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		bucketID := string(name)
+		if !strings.HasPrefix(bucketID, archiveBucketPrefix) {
+			return nil
+		}
+		archiveID := strings.TrimPrefix(bucketID, archiveBucketPrefix)
+		if tx.Bucket([]byte(archiveIDsBucket(archiveID))) == nil {
+			archiveIDs = append(archiveIDs, archiveID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rep := newArchiveRep(tx)
+	for _, archiveID := range archiveIDs {
+		c := tx.Bucket([]byte(archiveBucket(archiveID))).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var msg archivemodel.Message
+			if err := proto.Unmarshal(v, &msg); err != nil {
+				return err
+			}
+			if err := rep.indexMessage(archiveID, msg.Id, k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateArchiveKeys rewrites archive buckets keyed by insertion sequence only.
+func migrateArchiveKeys(tx *bolt.Tx) error {
+	var buckets []string
+
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !strings.HasPrefix(string(name), archiveBucketPrefix) {
+			return nil
+		}
+		if k, _ := b.Cursor().First(); k != nil && len(k) != archiveKeyLen {
+			buckets = append(buckets, string(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, bucketID := range buckets {
+		b := tx.Bucket([]byte(bucketID))
+
+		type kv struct{ k, v []byte }
+		var legacy []kv
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if len(k) == archiveKeyLen {
+				continue
+			}
+			legacy = append(legacy, kv{k: append([]byte(nil), k...), v: append([]byte(nil), v...)})
+		}
+		for _, e := range legacy {
+			seq, err := strconv.ParseUint(string(e.k), 10, 64)
+			if err != nil {
+				return err
+			}
+			var msg archivemodel.Message
+			if err := proto.Unmarshal(e.v, &msg); err != nil {
+				return err
+			}
+			if err := b.Delete(e.k); err != nil {
+				return err
+			}
+			if err := b.Put(archiveKey(msg.Stamp.AsTime(), seq), e.v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func bareJID(jd string) string {
+	if i := strings.Index(jd, "/"); i >= 0 {
+		return jd[:i]
+	}
+	return jd
 }
 
 // InsertArchiveMessage inserts a new message element into an archive queue.
-func (r *Repository) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) error {
-	return r.db.Update(func(tx *bolt.Tx) error {
-		return newArchiveRep(tx).InsertArchiveMessage(ctx, message)
+func (r *Repository) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) (inserted bool, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		inserted, err = newArchiveRep(tx).InsertArchiveMessage(ctx, message)
+		return err
 	})
+	return
+}
+
+// FetchArchiveMessageByOriginID retrieves the archived message having a given origin-id value.
+func (r *Repository) FetchArchiveMessageByOriginID(ctx context.Context, archiveID, originID string) (msg *archivemodel.Message, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		msg, err = newArchiveRep(tx).FetchArchiveMessageByOriginID(ctx, archiveID, originID)
+		return err
+	})
+	return
 }
 
 // FetchArchiveMetadata returns the metadata value associated to an archive.
@@ -158,10 +717,28 @@ func (r *Repository) FetchArchiveMetadata(ctx context.Context, archiveID string)
 	return
 }
 
+// FetchArchiveIDs returns the identifiers of all stored archives.
+func (r *Repository) FetchArchiveIDs(ctx context.Context) (archiveIDs []string, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		archiveIDs, err = newArchiveRep(tx).FetchArchiveIDs(ctx)
+		return err
+	})
+	return
+}
+
 // FetchArchiveMessages fetches archive asscociated messages applying the passed f filters.
-func (r *Repository) FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) (messages []*archivemodel.Message, err error) {
+func (r *Repository) FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) (messages []*archivemodel.Message, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		messages, err = newArchiveRep(tx).FetchArchiveMessages(ctx, f, page, archiveID)
+		return err
+	})
+	return
+}
+
+// CountArchiveMessages returns the number of archive messages matching the passed f filters.
+func (r *Repository) CountArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) (count int, err error) {
 	err = r.db.View(func(tx *bolt.Tx) error {
-		messages, err = newArchiveRep(tx).FetchArchiveMessages(ctx, f, archiveID)
+		count, err = newArchiveRep(tx).CountArchiveMessages(ctx, f, archiveID)
 		return err
 	})
 	return
@@ -174,6 +751,24 @@ func (r *Repository) DeleteArchiveOldestMessages(ctx context.Context, archiveID
 	})
 }
 
+// DeleteArchiveMessagesBefore removes all messages archived before a given time by users of a host.
+func (r *Repository) DeleteArchiveMessagesBefore(ctx context.Context, host string, before time.Time) (n int, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		n, err = newArchiveRep(tx).DeleteArchiveMessagesBefore(ctx, host, before)
+		return err
+	})
+	return
+}
+
+// DeleteArchiveMessagesWith removes all archived messages exchanged with a given JID.
+func (r *Repository) DeleteArchiveMessagesWith(ctx context.Context, archiveID, with string) (n int, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		n, err = newArchiveRep(tx).DeleteArchiveMessagesWith(ctx, archiveID, with)
+		return err
+	})
+	return
+}
+
 // DeleteArchive clears an archive queue.
 func (r *Repository) DeleteArchive(ctx context.Context, archiveID string) error {
 	return r.db.Update(func(tx *bolt.Tx) error {
@@ -181,94 +776,88 @@ func (r *Repository) DeleteArchive(ctx context.Context, archiveID string) error
 	})
 }
 
-func applyFilters(messages []*archivemodel.Message, f *archivemodel.Filters) ([]*archivemodel.Message, error) {
-	retVal := messages
+// UpsertArchivePrefs inserts or updates archive preferences.
+func (r *Repository) UpsertArchivePrefs(ctx context.Context, prefs *archivemodel.Prefs) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).UpsertArchivePrefs(ctx, prefs)
+	})
+}
 
-	// filtering by JID
-	if len(f.With) > 0 {
-		jd, err := jid.NewWithString(f.With, false)
-		if err != nil {
-			return nil, err
-		}
-		var filtered []*archivemodel.Message
-		for _, msg := range retVal {
-			var matches bool
+// FetchArchivePrefs returns the preferences associated to an archive.
+func (r *Repository) FetchArchivePrefs(ctx context.Context, archiveID string) (prefs *archivemodel.Prefs, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		prefs, err = newArchiveRep(tx).FetchArchivePrefs(ctx, archiveID)
+		return err
+	})
+	return
+}
 
-			switch {
-			case jd.IsFull():
-				matches = msg.FromJid == jd.String() || msg.ToJid == jd.String()
+// DeleteArchivePrefs removes archive preferences.
+func (r *Repository) DeleteArchivePrefs(ctx context.Context, archiveID string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).DeleteArchivePrefs(ctx, archiveID)
+	})
+}
 
-			default:
-				fromJID, _ := jid.NewWithString(msg.FromJid, true)
-				toJID, _ := jid.NewWithString(msg.ToJid, true)
-				matches = fromJID.MatchesWithOptions(jd, jid.MatchesBare) || toJID.MatchesWithOptions(jd, jid.MatchesBare)
-			}
-			if matches {
-				filtered = append(filtered, msg)
-			}
-		}
-		retVal = filtered
-	}
+// DeleteArchiveExpiredMessages removes all messages archived before a given time from an archive.
+func (r *Repository) DeleteArchiveExpiredMessages(ctx context.Context, archiveID string, before time.Time) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).DeleteArchiveExpiredMessages(ctx, archiveID, before)
+	})
+}
 
-	// filtering by id
-	if len(f.Ids) > 0 {
-		idsMap := map[string]struct{}{}
-		for _, id := range f.Ids {
-			idsMap[id] = struct{}{}
-		}
-		var filtered []*archivemodel.Message
-		for _, msg := range retVal {
-			_, ok := idsMap[msg.Id]
-			if !ok {
-				continue
-			}
-			filtered = append(filtered, msg)
-		}
-		retVal = filtered
+// UpsertArchiveQuota inserts or updates an archive quota.
+func (r *Repository) UpsertArchiveQuota(ctx context.Context, quota *archivemodel.Quota) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).UpsertArchiveQuota(ctx, quota)
+	})
+}
 
-	} else {
-		if len(f.BeforeId) > 0 {
-			for i, msg := range retVal {
-				if msg.Id != f.BeforeId {
-					continue
-				}
-				retVal = retVal[:i]
-				break
-			}
-		}
-		if len(f.AfterId) > 0 {
-			for i, msg := range retVal {
-				if msg.Id != f.AfterId {
-					continue
-				}
-				retVal = retVal[i+1:]
-				break
-			}
-		}
+// FetchArchiveQuota returns the quota associated to a user archive, or to a host in case archiveID is empty.
+func (r *Repository) FetchArchiveQuota(ctx context.Context, archiveID, host string) (quota *archivemodel.Quota, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		quota, err = newArchiveRep(tx).FetchArchiveQuota(ctx, archiveID, host)
+		return err
+	})
+	return
+}
+
+// FetchArchiveQuotas returns all stored archive quotas.
+func (r *Repository) FetchArchiveQuotas(ctx context.Context) (quotas []*archivemodel.Quota, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		quotas, err = newArchiveRep(tx).FetchArchiveQuotas(ctx)
+		return err
+	})
+	return
+}
+
+// DeleteArchiveQuota removes the quota associated to a user archive, or to a host in case archiveID is empty.
+func (r *Repository) DeleteArchiveQuota(ctx context.Context, archiveID, host string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).DeleteArchiveQuota(ctx, archiveID, host)
+	})
+}
+
+// matchesWith tells whether msg was exchanged with jd. A bare JID matches any of its resources.
+func matchesWith(msg *archivemodel.Message, jd *jid.JID) bool {
+	if jd.IsFull() {
+		return msg.FromJid == jd.String() || msg.ToJid == jd.String()
 	}
+	fromJID, _ := jidutil.Parse(msg.FromJid, true)
+	toJID, _ := jidutil.Parse(msg.ToJid, true)
+	return fromJID.MatchesWithOptions(jd, jid.MatchesBare) || toJID.MatchesWithOptions(jd, jid.MatchesBare)
+}
 
-	// filtering by timestamp
-	if f.Start != nil {
-		startTm := f.Start.AsTime()
-		for i, msg := range retVal {
-			stampTm := msg.Stamp.AsTime()
-			if !stampTm.After(startTm) {
-				continue
-			}
-			retVal = retVal[i:]
-			break
-		}
+func matchesFullText(msg *archivemodel.Message, terms []string) bool {
+	m, err := stravaganza.NewBuilderFromProto(msg.Message).BuildMessage()
+	if err != nil {
+		return false
 	}
-	if f.End != nil {
-		endTm := f.End.AsTime()
-		for i, msg := range retVal {
-			stampTm := msg.Stamp.AsTime()
-			if stampTm.Before(endTm) {
-				continue
-			}
-			retVal = retVal[:i]
-			break
+	body := strings.ToLower(xmpputil.MessageBody(m))
+	for _, term := range terms {
+		if !strings.Contains(body, term) {
+			return false
 		}
 	}
-	return retVal, nil
+	return true
 }
@@ -16,9 +16,11 @@ package boltdb
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
@@ -36,7 +38,7 @@ func TestBoltDB_InsertArchiveMessage(t *testing.T) {
 
 		m0 := testMessageStanza()
 
-		err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Message:   m0.Proto(),
 		})
@@ -47,6 +49,122 @@ func TestBoltDB_InsertArchiveMessage(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBoltDB_InsertArchiveMessageOriginID(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		for _, m := range []*archivemodel.Message{
+			{ArchiveId: "ortuman", Id: "id1", OriginId: "origin1"},
+			{ArchiveId: "ortuman", Id: "id2", OriginId: "origin1"}, // duplicated
+			{ArchiveId: "ortuman", Id: "id3", OriginId: "origin2"},
+			{ArchiveId: "ortuman", Id: "id4"},
+			{ArchiveId: "noelia", Id: "id5", OriginId: "origin1"},
+		} {
+			m.Message = testMessageStanza().Proto()
+			inserted, err := rep.InsertArchiveMessage(context.Background(), m)
+			require.NoError(t, err)
+			require.Equal(t, m.Id != "id2", inserted)
+		}
+		require.Equal(t, 3, countBucketElements(t, tx, archiveBucket("ortuman")))
+		require.Equal(t, 1, countBucketElements(t, tx, archiveBucket("noelia")))
+
+		msg, err := rep.FetchArchiveMessageByOriginID(context.Background(), "ortuman", "origin1")
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		require.Equal(t, "id1", msg.Id)
+
+		msg, err = rep.FetchArchiveMessageByOriginID(context.Background(), "ortuman", "origin3")
+		require.NoError(t, err)
+		require.Nil(t, msg)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_ArchiveStampOrder(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		for _, m := range []*archivemodel.Message{
+			{ArchiveId: "ortuman", Id: "id2", Stamp: timestamppb.New(now)},
+			{ArchiveId: "ortuman", Id: "id0", Stamp: timestamppb.New(now.Add(-time.Hour))}, // imported
+			{ArchiveId: "ortuman", Id: "id3", Stamp: timestamppb.New(now)},
+			{ArchiveId: "ortuman", Id: "id1", Stamp: timestamppb.New(now.Add(-time.Minute))},
+		} {
+			m.Message = testMessageStanza().Proto()
+			insertArchiveMessage(t, &rep, m)
+		}
+		messages, err := rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{
+			Start: timestamppb.New(now.Add(-time.Minute * 30)),
+		}, nil, "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, []string{"id1", "id2", "id3"}, archiveMessageIDs(messages))
+
+		metadata, err := rep.FetchArchiveMetadata(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, "id0", metadata.StartId)
+		require.Equal(t, "id3", metadata.EndId)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_MigrateArchiveKeys(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	// given
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(archiveBucket("ortuman")))
+		require.NoError(t, err)
+
+		for i, m := range []*archivemodel.Message{
+			{ArchiveId: "ortuman", Id: "id1", Stamp: timestamppb.New(now)},
+			{ArchiveId: "ortuman", Id: "id0", Stamp: timestamppb.New(now.Add(-time.Hour))},
+		} {
+			m.Message = testMessageStanza().Proto()
+			p, err := proto.Marshal(m)
+			require.NoError(t, err)
+			require.NoError(t, b.Put([]byte(fmt.Sprintf("%d", i+1)), p))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// when
+	err = db.Update(migrateArchiveKeys)
+	require.NoError(t, err)
+
+	// then
+	err = db.View(func(tx *bolt.Tx) error {
+		messages, err := newArchiveRep(tx).FetchArchiveMessages(context.Background(), &archivemodel.Filters{}, nil, "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, []string{"id0", "id1"}, archiveMessageIDs(messages))
+
+		k, _ := tx.Bucket([]byte(archiveBucket("ortuman"))).Cursor().First()
+		require.Len(t, k, archiveKeyLen)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestBoltDB_FetchArchiveMetadata(t *testing.T) {
 	t.Parallel()
 
@@ -64,7 +182,7 @@ func TestBoltDB_FetchArchiveMetadata(t *testing.T) {
 		now1 := now0.Add(time.Hour)
 		now2 := now1.Add(time.Hour)
 
-		err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Id:        "id0",
 			Message:   m0.Proto(),
@@ -72,7 +190,7 @@ func TestBoltDB_FetchArchiveMetadata(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Id:        "id1",
 			Message:   m1.Proto(),
@@ -80,7 +198,7 @@ func TestBoltDB_FetchArchiveMetadata(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Id:        "id2",
 			Message:   m2.Proto(),
@@ -101,6 +219,54 @@ func TestBoltDB_FetchArchiveMetadata(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBoltDB_FetchEmptyArchiveMetadata(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		insertArchiveMessage(t, &rep, &archivemodel.Message{
+			ArchiveId: "a1234",
+			Id:        "id0",
+			Message:   testMessageStanza().Proto(),
+			Stamp:     timestamppb.Now(),
+		})
+		require.NoError(t, rep.DeleteArchiveOldestMessages(context.Background(), "a1234", 0))
+
+		metadata, err := rep.FetchArchiveMetadata(context.Background(), "a1234")
+		require.NoError(t, err)
+		require.Nil(t, metadata)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_FetchArchiveIDs(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		_, err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "noelia", Message: testMessageStanza().Proto()})
+		require.NoError(t, err)
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "ortuman", Message: testMessageStanza().Proto()})
+		require.NoError(t, err)
+
+		archiveIDs, err := rep.FetchArchiveIDs(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"noelia", "ortuman"}, archiveIDs)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestBoltDB_DeleteArchive(t *testing.T) {
 	t.Parallel()
 
@@ -114,11 +280,11 @@ func TestBoltDB_DeleteArchive(t *testing.T) {
 		m1 := testMessageStanza()
 		m2 := testMessageStanza()
 
-		err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234", Message: m0.Proto()})
+		_, err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234", Message: m0.Proto()})
 		require.NoError(t, err)
-		err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234", Message: m1.Proto()})
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234", Message: m1.Proto()})
 		require.NoError(t, err)
-		err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234", Message: m2.Proto()})
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234", Message: m2.Proto()})
 		require.NoError(t, err)
 
 		require.Equal(t, 3, countBucketElements(t, tx, archiveBucket("a1234")))
@@ -145,19 +311,19 @@ func TestBoltDB_DeleteArchiveOldestMessages(t *testing.T) {
 		m1 := testMessageStanza()
 		m2 := testMessageStanza()
 
-		err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Message:   m0.Proto(),
 		})
 		require.NoError(t, err)
 
-		err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Message:   m1.Proto(),
 		})
 		require.NoError(t, err)
 
-		err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+		_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 			ArchiveId: "a1234",
 			Message:   m2.Proto(),
 		})
@@ -178,6 +344,7 @@ func TestBoltDB_DeleteArchiveOldestMessages(t *testing.T) {
 func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 	tcs := map[string]struct {
 		filters           *archivemodel.Filters
+		page              *archivemodel.Page
 		expectedResultIDs []string
 	}{
 		"filtering by jid": {
@@ -192,6 +359,28 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 			},
 			expectedResultIDs: []string{"m2"},
 		},
+		"filtering by full text search": {
+			filters: &archivemodel.Filters{
+				FullTextSearch: "B2",
+			},
+			expectedResultIDs: []string{"m2"},
+		},
+		"filtering by full text search with no matches": {
+			filters: &archivemodel.Filters{
+				FullTextSearch: "b1 b3",
+			},
+			expectedResultIDs: nil,
+		},
+		"paging": {
+			filters:           &archivemodel.Filters{},
+			page:              &archivemodel.Page{Offset: 1, Limit: 2},
+			expectedResultIDs: []string{"m1", "m2"},
+		},
+		"paging out of range": {
+			filters:           &archivemodel.Filters{With: "noelia@jackal.im"},
+			page:              &archivemodel.Page{Offset: 3, Limit: 2},
+			expectedResultIDs: nil,
+		},
 		"filtering by ids": {
 			filters: &archivemodel.Filters{
 				Ids: []string{"m0", "m2"},
@@ -222,6 +411,28 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 			},
 			expectedResultIDs: []string{"m0"},
 		},
+		"filtering by after id and jid with paging": {
+			filters: &archivemodel.Filters{
+				AfterId: "m0",
+				With:    "ortuman@jackal.im",
+			},
+			page:              &archivemodel.Page{Limit: 1},
+			expectedResultIDs: []string{"m1"},
+		},
+		"filtering by ids and end": {
+			filters: &archivemodel.Filters{
+				Ids: []string{"m3", "m1", "m0", "m9"},
+				End: timestamppb.New(time.Date(2022, 01, 03, 00, 00, 00, 00, time.UTC)),
+			},
+			expectedResultIDs: []string{"m0", "m1"},
+		},
+		"filtering by after and before id": {
+			filters: &archivemodel.Filters{
+				AfterId:  "m0",
+				BeforeId: "m3",
+			},
+			expectedResultIDs: []string{"m1", "m2"},
+		},
 	}
 	for tn, tc := range tcs {
 		t.Run(tn, func(t *testing.T) {
@@ -236,7 +447,7 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 				m2 := testMessageStanzaWithParameters("b2", "witch1@jackal.im/yard", "ortuman@jackal.im/firstwitch")
 				m3 := testMessageStanzaWithParameters("b3", "witch2@jackal.im/yard", "noelia@jackal.im/garden")
 
-				err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+				_, err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 					ArchiveId: "a1234",
 					Id:        "m0",
 					FromJid:   "noelia@jackal.im/yard",
@@ -246,7 +457,7 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 				})
 				require.NoError(t, err)
 
-				err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+				_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 					ArchiveId: "a1234",
 					Id:        "m1",
 					FromJid:   "noelia@jackal.im/orchard",
@@ -256,7 +467,7 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 				})
 				require.NoError(t, err)
 
-				err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+				_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 					ArchiveId: "a1234",
 					Id:        "m2",
 					FromJid:   "witch1@jackal.im/yard",
@@ -266,7 +477,7 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 				})
 				require.NoError(t, err)
 
-				err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+				_, err = rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
 					ArchiveId: "a1234",
 					Id:        "m3",
 					FromJid:   "witch2@jackal.im/yard",
@@ -276,9 +487,15 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 				})
 				require.NoError(t, err)
 
-				messages, err := rep.FetchArchiveMessages(context.Background(), tc.filters, "a1234")
+				messages, err := rep.FetchArchiveMessages(context.Background(), tc.filters, tc.page, "a1234")
 				require.NoError(t, err)
 
+				count, err := rep.CountArchiveMessages(context.Background(), tc.filters, "a1234")
+				require.NoError(t, err)
+				if tc.page == nil {
+					require.Equal(t, len(tc.expectedResultIDs), count)
+				}
+
 				var resultIDs []string
 				for _, msg := range messages {
 					resultIDs = append(resultIDs, msg.Id)
@@ -290,3 +507,248 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestBoltDB_ArchiveIDsIndex(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		for i, id := range []string{"id0", "id1", "id2"} {
+			insertArchiveMessage(t, &rep, &archivemodel.Message{
+				ArchiveId: "ortuman",
+				Id:        id,
+				Stamp:     timestamppb.New(now.Add(time.Duration(i) * time.Minute)),
+				Message:   testMessageStanza().Proto(),
+			})
+		}
+		require.Equal(t, 3, countBucketElements(t, tx, archiveIDsBucket("ortuman")))
+
+		require.NoError(t, rep.DeleteArchiveOldestMessages(context.Background(), "ortuman", 2))
+		require.Nil(t, rep.messageKey("ortuman", "id0"))
+		require.NotNil(t, rep.messageKey("ortuman", "id1"))
+
+		// drop index and rebuild it
+		require.NoError(t, tx.DeleteBucket([]byte(archiveIDsBucket("ortuman"))))
+		require.NoError(t, indexArchiveIDs(tx))
+		require.Equal(t, 2, countBucketElements(t, tx, archiveIDsBucket("ortuman")))
+
+		count, err := rep.CountArchiveMessages(context.Background(), &archivemodel.Filters{AfterId: "id1"}, "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		require.NoError(t, rep.DeleteArchive(context.Background(), "ortuman"))
+		require.Nil(t, tx.Bucket([]byte(archiveIDsBucket("ortuman"))))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteArchiveMessagesBefore(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		for _, m := range []*archivemodel.Message{
+			{ArchiveId: "ortuman", FromJid: "noelia@jackal.im/yard", ToJid: "ortuman@jackal.im/balcony", Stamp: timestamppb.New(now.Add(-time.Hour * 48))},
+			{ArchiveId: "ortuman", FromJid: "ortuman@jackal.im/balcony", ToJid: "noelia@jackal.im", Stamp: timestamppb.New(now.Add(-time.Hour * 48))},
+			{ArchiveId: "ortuman", FromJid: "noelia@jackal.im/yard", ToJid: "ortuman@jackal.im/balcony", Stamp: timestamppb.New(now)},
+			{ArchiveId: "ortuman", FromJid: "noelia@jabber.org/yard", ToJid: "ortuman@jabber.org/balcony", Stamp: timestamppb.New(now.Add(-time.Hour * 48))},
+		} {
+			m.Message = testMessageStanza().Proto()
+			insertArchiveMessage(t, &rep, m)
+		}
+
+		n, err := rep.DeleteArchiveMessagesBefore(context.Background(), "jackal.im", now.Add(-time.Hour*24))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		require.Equal(t, 2, countBucketElements(t, tx, archiveBucket("ortuman")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteArchiveMessagesWith(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		for _, m := range []*archivemodel.Message{
+			{ArchiveId: "ortuman", FromJid: "noelia@jackal.im/yard", ToJid: "ortuman@jackal.im/balcony"},
+			{ArchiveId: "ortuman", FromJid: "ortuman@jackal.im/balcony", ToJid: "noelia@jackal.im"},
+			{ArchiveId: "ortuman", FromJid: "juliet@jackal.im/garden", ToJid: "ortuman@jackal.im/balcony"},
+			{ArchiveId: "ortuman", FromJid: "ortuman@jackal.im/balcony", ToJid: "noelia@jackal.im/chamber"},
+		} {
+			m.Message = testMessageStanza().Proto()
+			insertArchiveMessage(t, &rep, m)
+		}
+
+		n, err := rep.DeleteArchiveMessagesWith(context.Background(), "ortuman", "noelia@jackal.im/chamber")
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		n, err = rep.DeleteArchiveMessagesWith(context.Background(), "ortuman", "noelia@jackal.im")
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		n, err = rep.DeleteArchiveMessagesWith(context.Background(), "noelia", "ortuman@jackal.im")
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		require.Equal(t, 1, countBucketElements(t, tx, archiveBucket("ortuman")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_ArchivePrefs(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		prefs, err := rep.FetchArchivePrefs(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Nil(t, prefs)
+
+		err = rep.UpsertArchivePrefs(context.Background(), &archivemodel.Prefs{
+			ArchiveId:       "ortuman",
+			DefaultBehavior: "roster",
+			Always:          []string{"noelia@jackal.im"},
+		})
+		require.NoError(t, err)
+
+		prefs, err = rep.FetchArchivePrefs(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.NotNil(t, prefs)
+		require.Equal(t, "roster", prefs.DefaultBehavior)
+		require.Equal(t, []string{"noelia@jackal.im"}, prefs.Always)
+
+		// preferences must not be reported as an archive
+		archiveIDs, err := rep.FetchArchiveIDs(context.Background())
+		require.NoError(t, err)
+		require.Len(t, archiveIDs, 0)
+
+		require.NoError(t, rep.DeleteArchivePrefs(context.Background(), "ortuman"))
+		require.NoError(t, rep.DeleteArchivePrefs(context.Background(), "ortuman"))
+
+		prefs, err = rep.FetchArchivePrefs(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Nil(t, prefs)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteArchiveExpiredMessages(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		for _, m := range []*archivemodel.Message{
+			{ArchiveId: "ortuman", FromJid: "noelia@jackal.im/yard", ToJid: "ortuman@jackal.im/balcony", Stamp: timestamppb.New(now.Add(-time.Hour * 48))},
+			{ArchiveId: "ortuman", FromJid: "ortuman@jackal.im/balcony", ToJid: "noelia@jackal.im", Stamp: timestamppb.New(now)},
+			{ArchiveId: "noelia", FromJid: "ortuman@jackal.im/balcony", ToJid: "noelia@jackal.im", Stamp: timestamppb.New(now.Add(-time.Hour * 48))},
+		} {
+			m.Message = testMessageStanza().Proto()
+			insertArchiveMessage(t, &rep, m)
+		}
+
+		require.NoError(t, rep.DeleteArchiveExpiredMessages(context.Background(), "ortuman", now.Add(-time.Hour*24)))
+		require.NoError(t, rep.DeleteArchiveExpiredMessages(context.Background(), "romeo", now.Add(-time.Hour*24)))
+
+		require.Equal(t, 1, countBucketElements(t, tx, archiveBucket("ortuman")))
+		require.Equal(t, 1, countBucketElements(t, tx, archiveBucket("noelia")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_ArchiveQuotas(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		quota, err := rep.FetchArchiveQuota(context.Background(), "ortuman", "")
+		require.NoError(t, err)
+		require.Nil(t, quota)
+
+		require.NoError(t, rep.UpsertArchiveQuota(context.Background(), &archivemodel.Quota{ArchiveId: "ortuman", QueueSize: 100}))
+		require.NoError(t, rep.UpsertArchiveQuota(context.Background(), &archivemodel.Quota{Host: "jackal.im", QueueSize: 500, MaxAgeSeconds: 3600}))
+
+		quota, err = rep.FetchArchiveQuota(context.Background(), "ortuman", "")
+		require.NoError(t, err)
+		require.NotNil(t, quota)
+		require.Equal(t, int32(100), quota.QueueSize)
+
+		quota, err = rep.FetchArchiveQuota(context.Background(), "", "jackal.im")
+		require.NoError(t, err)
+		require.NotNil(t, quota)
+		require.Equal(t, int64(3600), quota.MaxAgeSeconds)
+
+		quotas, err := rep.FetchArchiveQuotas(context.Background())
+		require.NoError(t, err)
+		require.Len(t, quotas, 2)
+
+		// quotas must not be reported as an archive
+		archiveIDs, err := rep.FetchArchiveIDs(context.Background())
+		require.NoError(t, err)
+		require.Len(t, archiveIDs, 0)
+
+		require.NoError(t, rep.DeleteArchiveQuota(context.Background(), "ortuman", ""))
+
+		quota, err = rep.FetchArchiveQuota(context.Background(), "ortuman", "")
+		require.NoError(t, err)
+		require.Nil(t, quota)
+
+		quotas, err = rep.FetchArchiveQuotas(context.Background())
+		require.NoError(t, err)
+		require.Len(t, quotas, 1)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func archiveMessageIDs(messages []*archivemodel.Message) []string {
+	var ids []string
+	for _, msg := range messages {
+		ids = append(ids, msg.Id)
+	}
+	return ids
+}
+
+func insertArchiveMessage(t *testing.T, rep *boltDBArchiveRep, m *archivemodel.Message) {
+	_, err := rep.InsertArchiveMessage(context.Background(), m)
+	require.NoError(t, err)
+}
@@ -17,12 +17,22 @@ package boltdb
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/jackal-xmpp/stravaganza"
 	bolt "go.etcd.io/bbolt"
 )
 
+const (
+	offlineBucketPrefix = "offline:"
+
+	delayNamespace = "urn:xmpp:delay"
+)
+
 type boltDBOfflineRep struct {
 	tx *bolt.Tx
 }
@@ -81,8 +91,122 @@ func (r *boltDBOfflineRep) DeleteOfflineMessages(_ context.Context, username str
 	return op.do()
 }
 
+func (r *boltDBOfflineRep) DeleteSelectedOfflineMessages(_ context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (int, error) {
+	return r.deleteSelectedMessages(offlineBucket(username), selectFn)
+}
+
+func (r *boltDBOfflineRep) TrimOfflineMessages(_ context.Context, username string, maxSize int) (int, error) {
+	bucket := offlineBucket(username)
+
+	var seqs []uint64
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: bucket,
+		iterFn: func(k, _ []byte) error {
+			seq, err := strconv.ParseUint(string(k), 10, 64)
+			if err != nil {
+				return err
+			}
+			seqs = append(seqs, seq)
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return 0, err
+	}
+	if len(seqs) <= maxSize {
+		return 0, nil
+	}
+	// keys are sequence numbers, so they must be numerically sorted to find out the oldest ones
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	n := len(seqs) - maxSize
+	for _, seq := range seqs[:n] {
+		delOp := delKeyOp{
+			tx:     r.tx,
+			bucket: bucket,
+			key:    strconv.FormatUint(seq, 10),
+		}
+		if err := delOp.do(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func (r *boltDBOfflineRep) DeleteOfflineMessagesBefore(_ context.Context, host string, before time.Time) (int, error) {
+	var buckets []string
+
+	err := r.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if bucketID := string(name); strings.HasPrefix(bucketID, offlineBucketPrefix) {
+			buckets = append(buckets, bucketID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, bucket := range buckets {
+		n, err := r.deleteSelectedMessages(bucket, func(msg *stravaganza.Message) bool {
+			if msg.ToJID().Domain() != host {
+				return false
+			}
+			// offline messages are stored along with their delay info
+			delay := msg.ChildNamespace("delay", delayNamespace)
+			if delay == nil {
+				return false
+			}
+			stamp, err := time.Parse(time.RFC3339, delay.Attribute("stamp"))
+			return err == nil && stamp.Before(before)
+		})
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+func (r *boltDBOfflineRep) deleteSelectedMessages(bucket string, selectFn func(msg *stravaganza.Message) bool) (int, error) {
+	var keys []string
+
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: bucket,
+		iterFn: func(k, b []byte) error {
+			var elem stravaganza.PBElement
+			if err := proto.Unmarshal(b, &elem); err != nil {
+				return err
+			}
+			msg, err := stravaganza.NewBuilderFromProto(&elem).BuildMessage()
+			if err != nil {
+				return err
+			}
+			if selectFn(msg) {
+				keys = append(keys, string(k))
+			}
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		delOp := delKeyOp{
+			tx:     r.tx,
+			bucket: bucket,
+			key:    k,
+		}
+		if err := delOp.do(); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
 func offlineBucket(username string) string {
-	return fmt.Sprintf("offline:%s", username)
+	return fmt.Sprintf("%s%s", offlineBucketPrefix, username)
 }
 
 // InsertOfflineMessage satisfies repository.Offline interface.
@@ -116,3 +240,30 @@ func (r *Repository) DeleteOfflineMessages(ctx context.Context, username string)
 		return newOfflineRep(tx).DeleteOfflineMessages(ctx, username)
 	})
 }
+
+// DeleteSelectedOfflineMessages satisfies repository.Offline interface.
+func (r *Repository) DeleteSelectedOfflineMessages(ctx context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (n int, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		n, err = newOfflineRep(tx).DeleteSelectedOfflineMessages(ctx, username, selectFn)
+		return err
+	})
+	return
+}
+
+// TrimOfflineMessages satisfies repository.Offline interface.
+func (r *Repository) TrimOfflineMessages(ctx context.Context, username string, maxSize int) (n int, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		n, err = newOfflineRep(tx).TrimOfflineMessages(ctx, username, maxSize)
+		return err
+	})
+	return
+}
+
+// DeleteOfflineMessagesBefore satisfies repository.Offline interface.
+func (r *Repository) DeleteOfflineMessagesBefore(ctx context.Context, host string, before time.Time) (n int, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		n, err = newOfflineRep(tx).DeleteOfflineMessagesBefore(ctx, host, before)
+		return err
+	})
+	return
+}
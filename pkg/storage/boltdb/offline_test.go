@@ -16,8 +16,12 @@ package boltdb
 
 import (
 	"context"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/jackal-xmpp/stravaganza"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
 )
@@ -109,3 +113,99 @@ func TestBoltDB_DeleteOfflineMessages(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestBoltDB_DeleteOfflineMessagesBefore(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBOfflineRep{tx: tx}
+
+		m0 := xmpputil.MakeDelayMessage(testMessageStanza(), now.Add(-time.Hour*48), "jackal.im", "Offline Storage")
+		m1 := xmpputil.MakeDelayMessage(testMessageStanza(), now, "jackal.im", "Offline Storage")
+		m2 := xmpputil.MakeDelayMessage(
+			testMessageStanzaWithParameters("Hi!", "noelia@jackal.im/yard", "ortuman@jabber.org/balcony"),
+			now.Add(-time.Hour*48),
+			"jabber.org",
+			"Offline Storage",
+		)
+		for _, m := range []*stravaganza.Message{m0, m1, m2} {
+			require.NoError(t, rep.InsertOfflineMessage(context.Background(), m, "ortuman"))
+		}
+
+		n, err := rep.DeleteOfflineMessagesBefore(context.Background(), "jackal.im", now.Add(-time.Hour*24))
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		cnt, err := rep.CountOfflineMessages(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, 2, cnt)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteSelectedOfflineMessages(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBOfflineRep{tx: tx}
+
+		m0 := testMessageStanzaWithParameters("Hi!", "noelia@jackal.im/yard", "ortuman@jackal.im/balcony")
+		m1 := testMessageStanzaWithParameters("Bye!", "noelia@jackal.im/yard", "ortuman@jackal.im/balcony")
+		for _, m := range []*stravaganza.Message{m0, m1} {
+			require.NoError(t, rep.InsertOfflineMessage(context.Background(), m, "ortuman"))
+		}
+
+		n, err := rep.DeleteSelectedOfflineMessages(context.Background(), "ortuman", func(msg *stravaganza.Message) bool {
+			return msg.Child("body").Text() == "Bye!"
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		ms, err := rep.FetchOfflineMessages(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Len(t, ms, 1)
+		require.Equal(t, "Hi!", ms[0].Child("body").Text())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_TrimOfflineMessages(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBOfflineRep{tx: tx}
+
+		for i := 0; i < 12; i++ {
+			m := testMessageStanzaWithParameters(strconv.Itoa(i), "noelia@jackal.im/yard", "ortuman@jackal.im/balcony")
+			require.NoError(t, rep.InsertOfflineMessage(context.Background(), m, "ortuman"))
+		}
+		n, err := rep.TrimOfflineMessages(context.Background(), "ortuman", 3)
+		require.NoError(t, err)
+		require.Equal(t, 9, n)
+
+		ms, err := rep.FetchOfflineMessages(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Len(t, ms, 3)
+
+		var bodies []string
+		for _, m := range ms {
+			bodies = append(bodies, m.Child("body").Text())
+		}
+		require.ElementsMatch(t, []string{"9", "10", "11"}, bodies)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -49,6 +49,27 @@ func (r *boltDBPrivateRep) FetchPrivate(_ context.Context, namespace, username s
 	}
 }
 
+func (r *boltDBPrivateRep) FetchPrivates(_ context.Context, username string) ([]stravaganza.Element, error) {
+	var retVal []stravaganza.Element
+
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: privateBucketKey(username),
+		iterFn: func(_, b []byte) error {
+			prv := stravaganza.EmptyElement()
+			if err := prv.UnmarshalBinary(b); err != nil {
+				return err
+			}
+			retVal = append(retVal, prv)
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
 func (r *boltDBPrivateRep) UpsertPrivate(_ context.Context, private stravaganza.Element, namespace, username string) error {
 	op := upsertKeyOp{
 		tx:     r.tx,
@@ -80,6 +101,15 @@ func (r *Repository) FetchPrivate(ctx context.Context, namespace, username strin
 	return
 }
 
+// FetchPrivates satisfies repository.Private interface.
+func (r *Repository) FetchPrivates(ctx context.Context, username string) (prvs []stravaganza.Element, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		prvs, err = newPrivateRep(tx).FetchPrivates(ctx, username)
+		return err
+	})
+	return
+}
+
 // UpsertPrivate satisfies repository.Private interface.
 func (r *Repository) UpsertPrivate(ctx context.Context, private stravaganza.Element, namespace, username string) error {
 	return r.db.Update(func(tx *bolt.Tx) error {
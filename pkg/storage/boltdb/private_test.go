@@ -46,6 +46,31 @@ func TestBoltDB_UpsertAndFetchPrivate(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBoltDB_FetchPrivates(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBPrivateRep{tx: tx}
+
+		err := rep.UpsertPrivate(context.Background(), stravaganza.NewBuilder("prv0").Build(), "ns0", "ortuman")
+		require.NoError(t, err)
+		err = rep.UpsertPrivate(context.Background(), stravaganza.NewBuilder("prv1").Build(), "ns1", "ortuman")
+		require.NoError(t, err)
+
+		prvs, err := rep.FetchPrivates(context.Background(), "ortuman")
+		require.NoError(t, err)
+
+		require.Len(t, prvs, 2)
+		require.Equal(t, "prv0", prvs[0].Name())
+		require.Equal(t, "prv1", prvs[1].Name())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestBoltDB_DeletePrivate(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,123 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"fmt"
+
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	bolt "go.etcd.io/bbolt"
+)
+
+type boltDBReactionRep struct {
+	tx *bolt.Tx
+}
+
+func newReactionRep(tx *bolt.Tx) *boltDBReactionRep {
+	return &boltDBReactionRep{tx: tx}
+}
+
+func (r *boltDBReactionRep) UpsertReactions(_ context.Context, reactions *reactionmodel.Reactions) error {
+	op := upsertKeyOp{
+		tx:     r.tx,
+		bucket: reactionsBucket(reactions.ArchiveId),
+		key:    reactionsKey(reactions.TargetId, reactions.Jid),
+		obj:    reactions,
+	}
+	return op.do()
+}
+
+func (r *boltDBReactionRep) FetchReactions(_ context.Context, archiveID string, targetIDs []string) ([]*reactionmodel.Reactions, error) {
+	targets := make(map[string]struct{}, len(targetIDs))
+	for _, id := range targetIDs {
+		targets[id] = struct{}{}
+	}
+	var retVal []*reactionmodel.Reactions
+
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: reactionsBucket(archiveID),
+		iterFn: func(_, b []byte) error {
+			var reactions reactionmodel.Reactions
+			if err := reactions.UnmarshalBinary(b); err != nil {
+				return err
+			}
+			if _, ok := targets[reactions.TargetId]; ok {
+				retVal = append(retVal, &reactions)
+			}
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBReactionRep) DeleteReactions(_ context.Context, archiveID, targetID, jid string) error {
+	op := delKeyOp{
+		tx:     r.tx,
+		bucket: reactionsBucket(archiveID),
+		key:    reactionsKey(targetID, jid),
+	}
+	return op.do()
+}
+
+func (r *boltDBReactionRep) DeleteArchiveReactions(_ context.Context, archiveID string) error {
+	op := delBucketOp{
+		tx:     r.tx,
+		bucket: reactionsBucket(archiveID),
+	}
+	return op.do()
+}
+
+func reactionsBucket(archiveID string) string {
+	return fmt.Sprintf("reactions:%s", archiveID)
+}
+
+func reactionsKey(targetID, jid string) string {
+	return fmt.Sprintf("%s:%s", targetID, jid)
+}
+
+// UpsertReactions satisfies repository.Reaction interface.
+func (r *Repository) UpsertReactions(ctx context.Context, reactions *reactionmodel.Reactions) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newReactionRep(tx).UpsertReactions(ctx, reactions)
+	})
+}
+
+// FetchReactions satisfies repository.Reaction interface.
+func (r *Repository) FetchReactions(ctx context.Context, archiveID string, targetIDs []string) (reactions []*reactionmodel.Reactions, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		reactions, err = newReactionRep(tx).FetchReactions(ctx, archiveID, targetIDs)
+		return err
+	})
+	return
+}
+
+// DeleteReactions satisfies repository.Reaction interface.
+func (r *Repository) DeleteReactions(ctx context.Context, archiveID, targetID, jid string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newReactionRep(tx).DeleteReactions(ctx, archiveID, targetID, jid)
+	})
+}
+
+// DeleteArchiveReactions satisfies repository.Reaction interface.
+func (r *Repository) DeleteArchiveReactions(ctx context.Context, archiveID string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newReactionRep(tx).DeleteArchiveReactions(ctx, archiveID)
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"testing"
+
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltDB_UpsertAndFetchReactions(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBReactionRep{tx: tx}
+
+		for _, r := range []*reactionmodel.Reactions{
+			{ArchiveId: "ortuman", TargetId: "msg-1", Jid: "noelia@jackal.im", Reactions: []string{"👍"}},
+			{ArchiveId: "ortuman", TargetId: "msg-1", Jid: "ortuman@jackal.im", Reactions: []string{"🐢"}},
+			{ArchiveId: "ortuman", TargetId: "msg-2", Jid: "noelia@jackal.im", Reactions: []string{"❤️"}},
+		} {
+			require.NoError(t, rep.UpsertReactions(context.Background(), r))
+		}
+		rs, err := rep.FetchReactions(context.Background(), "ortuman", []string{"msg-1"})
+		require.NoError(t, err)
+		require.Len(t, rs, 2)
+
+		require.NoError(t, rep.DeleteReactions(context.Background(), "ortuman", "msg-1", "noelia@jackal.im"))
+
+		rs, err = rep.FetchReactions(context.Background(), "ortuman", []string{"msg-1", "msg-2"})
+		require.NoError(t, err)
+		require.Len(t, rs, 2)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteArchiveReactions(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBReactionRep{tx: tx}
+
+		err := rep.UpsertReactions(context.Background(), &reactionmodel.Reactions{
+			ArchiveId: "ortuman",
+			TargetId:  "msg-1",
+			Jid:       "noelia@jackal.im",
+			Reactions: []string{"👍"},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, rep.DeleteArchiveReactions(context.Background(), "ortuman"))
+
+		rs, err := rep.FetchReactions(context.Background(), "ortuman", []string{"msg-1"})
+		require.NoError(t, err)
+		require.Len(t, rs, 0)
+		return nil
+	})
+	require.NoError(t, err)
+}
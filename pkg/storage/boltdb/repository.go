@@ -16,6 +16,7 @@ package boltdb
 
 import (
 	"context"
+	"os"
 	"time"
 
 	kitlog "github.com/go-kit/log"
@@ -39,6 +40,11 @@ type Repository struct {
 	repository.Private
 	repository.Roster
 	repository.VCard
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Archive
 	repository.Locker
 
@@ -80,6 +86,12 @@ func (r *Repository) Start(_ context.Context) error {
 	}
 	r.db = db
 
+	if err := db.Update(migrateArchiveKeys); err != nil {
+		return err
+	}
+	if err := db.Update(indexArchiveIDs); err != nil {
+		return err
+	}
 	level.Info(r.logger).Log("msg", "started BoltDB repository")
 	return nil
 }
@@ -92,3 +104,15 @@ func (r *Repository) Stop(_ context.Context) error {
 	level.Info(r.logger).Log("msg", "stopped BoltDB repository")
 	return nil
 }
+
+// Snapshot writes a consistent copy of the database into path.
+func (r *Repository) Snapshot(path string) error {
+	tmpPath := path + ".tmp"
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(tmpPath, 0600)
+	})
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
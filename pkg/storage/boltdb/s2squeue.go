@@ -0,0 +1,245 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+	bolt "go.etcd.io/bbolt"
+)
+
+const s2sQueueBucketPrefix = "s2s_queue:"
+
+type boltDBS2SQueueRep struct {
+	tx *bolt.Tx
+}
+
+func newS2SQueueRep(tx *bolt.Tx) *boltDBS2SQueueRep {
+	return &boltDBS2SQueueRep{tx: tx}
+}
+
+func (r *boltDBS2SQueueRep) InsertS2SQueueStanza(_ context.Context, stanza *s2squeuemodel.Stanza) error {
+	b, err := r.tx.CreateBucketIfNotExists([]byte(s2sQueueBucket(stanza.Domain)))
+	if err != nil {
+		return err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	stanza.Id = int64(seq)
+
+	p, err := stanza.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(s2sQueueKey(stanza.Id)), p)
+}
+
+func (r *boltDBS2SQueueRep) CountS2SQueueStanzas(ctx context.Context, domain string) (int, error) {
+	stanzas, err := r.FetchS2SQueueStanzas(ctx, domain)
+	if err != nil {
+		return 0, err
+	}
+	return len(stanzas), nil
+}
+
+func (r *boltDBS2SQueueRep) FetchS2SQueueStanzas(_ context.Context, domain string) ([]*s2squeuemodel.Stanza, error) {
+	var retVal []*s2squeuemodel.Stanza
+
+	now := time.Now()
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: s2sQueueBucket(domain),
+		iterFn: func(_, b []byte) error {
+			var stanza s2squeuemodel.Stanza
+			if err := stanza.UnmarshalBinary(b); err != nil {
+				return err
+			}
+			if !stanza.ExpiresAt.AsTime().After(now) {
+				return nil
+			}
+			retVal = append(retVal, &stanza)
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBS2SQueueRep) FetchS2SQueueDomains(ctx context.Context) ([]string, error) {
+	var domains []string
+
+	err := r.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		bucketID := string(name)
+		if strings.HasPrefix(bucketID, s2sQueueBucketPrefix) {
+			domains = append(domains, strings.TrimPrefix(bucketID, s2sQueueBucketPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var retVal []string
+	for _, domain := range domains {
+		count, err := r.CountS2SQueueStanzas(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			retVal = append(retVal, domain)
+		}
+	}
+	return retVal, nil
+}
+
+func (r *boltDBS2SQueueRep) DeleteS2SQueueStanzas(_ context.Context, domain string, ids []int64) error {
+	if r.tx.Bucket([]byte(s2sQueueBucket(domain))) == nil {
+		return nil
+	}
+	for _, id := range ids {
+		op := delKeyOp{
+			tx:     r.tx,
+			bucket: s2sQueueBucket(domain),
+			key:    s2sQueueKey(id),
+		}
+		if err := op.do(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *boltDBS2SQueueRep) DeleteExpiredS2SQueueStanzas(ctx context.Context) error {
+	var buckets []string
+
+	err := r.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if bucketID := string(name); strings.HasPrefix(bucketID, s2sQueueBucketPrefix) {
+			buckets = append(buckets, bucketID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, bucket := range buckets {
+		var expiredKeys []string
+		var total int
+
+		op := iterKeysOp{
+			tx:     r.tx,
+			bucket: bucket,
+			iterFn: func(k, b []byte) error {
+				var stanza s2squeuemodel.Stanza
+				if err := stanza.UnmarshalBinary(b); err != nil {
+					return err
+				}
+				total++
+				if !stanza.ExpiresAt.AsTime().After(now) {
+					expiredKeys = append(expiredKeys, string(k))
+				}
+				return nil
+			},
+		}
+		if err := op.do(); err != nil {
+			return err
+		}
+		if len(expiredKeys) == total {
+			delOp := delBucketOp{
+				tx:     r.tx,
+				bucket: bucket,
+			}
+			if err := delOp.do(); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, k := range expiredKeys {
+			delOp := delKeyOp{
+				tx:     r.tx,
+				bucket: bucket,
+				key:    k,
+			}
+			if err := delOp.do(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func s2sQueueBucket(domain string) string {
+	return fmt.Sprintf("%s%s", s2sQueueBucketPrefix, domain)
+}
+
+// s2sQueueKey returns a zero padded key, so that bucket iteration follows insertion order.
+func s2sQueueKey(id int64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+// InsertS2SQueueStanza satisfies repository.S2SQueue interface.
+func (r *Repository) InsertS2SQueueStanza(ctx context.Context, stanza *s2squeuemodel.Stanza) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newS2SQueueRep(tx).InsertS2SQueueStanza(ctx, stanza)
+	})
+}
+
+// CountS2SQueueStanzas satisfies repository.S2SQueue interface.
+func (r *Repository) CountS2SQueueStanzas(ctx context.Context, domain string) (c int, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		c, err = newS2SQueueRep(tx).CountS2SQueueStanzas(ctx, domain)
+		return err
+	})
+	return
+}
+
+// FetchS2SQueueStanzas satisfies repository.S2SQueue interface.
+func (r *Repository) FetchS2SQueueStanzas(ctx context.Context, domain string) (stanzas []*s2squeuemodel.Stanza, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		stanzas, err = newS2SQueueRep(tx).FetchS2SQueueStanzas(ctx, domain)
+		return err
+	})
+	return
+}
+
+// FetchS2SQueueDomains satisfies repository.S2SQueue interface.
+func (r *Repository) FetchS2SQueueDomains(ctx context.Context) (domains []string, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		domains, err = newS2SQueueRep(tx).FetchS2SQueueDomains(ctx)
+		return err
+	})
+	return
+}
+
+// DeleteS2SQueueStanzas satisfies repository.S2SQueue interface.
+func (r *Repository) DeleteS2SQueueStanzas(ctx context.Context, domain string, ids []int64) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newS2SQueueRep(tx).DeleteS2SQueueStanzas(ctx, domain, ids)
+	})
+}
+
+// DeleteExpiredS2SQueueStanzas satisfies repository.S2SQueue interface.
+func (r *Repository) DeleteExpiredS2SQueueStanzas(ctx context.Context) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newS2SQueueRep(tx).DeleteExpiredS2SQueueStanzas(ctx)
+	})
+}
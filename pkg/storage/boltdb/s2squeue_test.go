@@ -0,0 +1,119 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestBoltDB_InsertAndFetchS2SQueueStanzas(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBS2SQueueRep{tx: tx}
+
+		for i := 0; i < 12; i++ {
+			err := rep.InsertS2SQueueStanza(context.Background(), &s2squeuemodel.Stanza{
+				Domain:       "jabber.org",
+				SenderDomain: "jackal.im",
+				Stanza:       testMessageStanza().Proto(),
+				ExpiresAt:    timestamppb.New(time.Now().Add(time.Minute)),
+			})
+			require.NoError(t, err)
+		}
+		count, err := rep.CountS2SQueueStanzas(context.Background(), "jabber.org")
+		require.NoError(t, err)
+		require.Equal(t, 12, count)
+
+		stanzas, err := rep.FetchS2SQueueStanzas(context.Background(), "jabber.org")
+		require.NoError(t, err)
+		require.Len(t, stanzas, 12)
+		require.Equal(t, "jabber.org", stanzas[0].Domain)
+		require.Equal(t, "jackal.im", stanzas[0].SenderDomain)
+		for i, stanza := range stanzas {
+			require.Equal(t, int64(i+1), stanza.Id)
+		}
+
+		domains, err := rep.FetchS2SQueueDomains(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"jabber.org"}, domains)
+
+		require.NoError(t, rep.DeleteS2SQueueStanzas(context.Background(), "jabber.org", []int64{1, 10}))
+
+		stanzas, err = rep.FetchS2SQueueStanzas(context.Background(), "jabber.org")
+		require.NoError(t, err)
+		require.Len(t, stanzas, 10)
+		require.Equal(t, int64(2), stanzas[0].Id)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteExpiredS2SQueueStanzas(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBS2SQueueRep{tx: tx}
+
+		err := rep.InsertS2SQueueStanza(context.Background(), &s2squeuemodel.Stanza{
+			Domain:    "jabber.org",
+			Stanza:    testMessageStanza().Proto(),
+			ExpiresAt: timestamppb.New(time.Now().Add(-time.Minute)),
+		})
+		require.NoError(t, err)
+
+		err = rep.InsertS2SQueueStanza(context.Background(), &s2squeuemodel.Stanza{
+			Domain:    "xmpp.org",
+			Stanza:    testMessageStanza().Proto(),
+			ExpiresAt: timestamppb.New(time.Now().Add(-time.Minute)),
+		})
+		require.NoError(t, err)
+
+		err = rep.InsertS2SQueueStanza(context.Background(), &s2squeuemodel.Stanza{
+			Domain:    "xmpp.org",
+			Stanza:    testMessageStanza().Proto(),
+			ExpiresAt: timestamppb.New(time.Now().Add(time.Minute)),
+		})
+		require.NoError(t, err)
+
+		err = rep.DeleteExpiredS2SQueueStanzas(context.Background())
+		require.NoError(t, err)
+
+		require.Nil(t, tx.Bucket([]byte(s2sQueueBucket("jabber.org"))))
+
+		count, err := rep.CountS2SQueueStanzas(context.Background(), "xmpp.org")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		count, err = countKeysOp{tx: tx, bucket: s2sQueueBucket("xmpp.org")}.do()
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+		return nil
+	})
+	require.NoError(t, err)
+}
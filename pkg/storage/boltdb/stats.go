@@ -0,0 +1,232 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	userActivityBucketPrefix = "user_activity:"
+	hostStatsBucketPrefix    = "host_stats:"
+
+	statsDayLayout = "2006-01-02"
+)
+
+type boltDBStatsRep struct {
+	tx *bolt.Tx
+}
+
+func newStatsRep(tx *bolt.Tx) *boltDBStatsRep {
+	return &boltDBStatsRep{tx: tx}
+}
+
+func (r *boltDBStatsRep) UpsertUserActivity(_ context.Context, username string, day time.Time) error {
+	b, err := r.tx.CreateBucketIfNotExists([]byte(userActivityBucket(day)))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(username), []byte{})
+}
+
+func (r *boltDBStatsRep) CountActiveUsers(_ context.Context, from, to time.Time) (int, error) {
+	buckets, err := r.dayBuckets(userActivityBucketPrefix, func(day string) bool {
+		return day >= from.Format(statsDayLayout) && day <= to.Format(statsDayLayout)
+	})
+	if err != nil {
+		return 0, err
+	}
+	users := make(map[string]struct{})
+	for _, bucket := range buckets {
+		op := iterKeysOp{
+			tx:     r.tx,
+			bucket: bucket,
+			iterFn: func(k, _ []byte) error {
+				users[string(k)] = struct{}{}
+				return nil
+			},
+		}
+		if err := op.do(); err != nil {
+			return 0, err
+		}
+	}
+	return len(users), nil
+}
+
+func (r *boltDBStatsRep) IncrementHostStats(_ context.Context, stats *statsmodel.HostStats) error {
+	bucket := hostStatsBucket(stats.Day.AsTime())
+
+	op := fetchKeyOp{
+		tx:     r.tx,
+		bucket: bucket,
+		key:    stats.Host,
+		obj:    &statsmodel.HostStats{},
+	}
+	obj, err := op.do()
+	if err != nil {
+		return err
+	}
+	st := &statsmodel.HostStats{
+		Host:          stats.Host,
+		Day:           timestamppb.New(stats.Day.AsTime()),
+		Messages:      stats.Messages,
+		Registrations: stats.Registrations,
+	}
+	if obj != nil {
+		prev := obj.(*statsmodel.HostStats)
+		st.Messages += prev.Messages
+		st.Registrations += prev.Registrations
+	}
+	upsertOp := upsertKeyOp{
+		tx:     r.tx,
+		bucket: bucket,
+		key:    stats.Host,
+		obj:    st,
+	}
+	return upsertOp.do()
+}
+
+func (r *boltDBStatsRep) FetchHostStats(_ context.Context, from, to time.Time) ([]*statsmodel.HostStats, error) {
+	buckets, err := r.dayBuckets(hostStatsBucketPrefix, func(day string) bool {
+		return day >= from.Format(statsDayLayout) && day <= to.Format(statsDayLayout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]*statsmodel.HostStats)
+	for _, bucket := range buckets {
+		op := iterKeysOp{
+			tx:     r.tx,
+			bucket: bucket,
+			iterFn: func(_, b []byte) error {
+				var st statsmodel.HostStats
+				if err := st.UnmarshalBinary(b); err != nil {
+					return err
+				}
+				agg, ok := hosts[st.Host]
+				if !ok {
+					agg = &statsmodel.HostStats{Host: st.Host}
+					hosts[st.Host] = agg
+				}
+				agg.Messages += st.Messages
+				agg.Registrations += st.Registrations
+				return nil
+			},
+		}
+		if err := op.do(); err != nil {
+			return nil, err
+		}
+	}
+	retVal := make([]*statsmodel.HostStats, 0, len(hosts))
+	for _, st := range hosts {
+		retVal = append(retVal, st)
+	}
+	sort.Slice(retVal, func(i, j int) bool { return retVal[i].Host < retVal[j].Host })
+	return retVal, nil
+}
+
+func (r *boltDBStatsRep) DeleteStatsBefore(_ context.Context, day time.Time) error {
+	before := func(d string) bool {
+		return d < day.Format(statsDayLayout)
+	}
+	for _, prefix := range []string{userActivityBucketPrefix, hostStatsBucketPrefix} {
+		buckets, err := r.dayBuckets(prefix, before)
+		if err != nil {
+			return err
+		}
+		for _, bucket := range buckets {
+			op := delBucketOp{
+				tx:     r.tx,
+				bucket: bucket,
+			}
+			if err := op.do(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *boltDBStatsRep) dayBuckets(prefix string, matchFn func(day string) bool) ([]string, error) {
+	var retVal []string
+	err := r.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		bucketID := string(name)
+		if !strings.HasPrefix(bucketID, prefix) {
+			return nil
+		}
+		if matchFn(strings.TrimPrefix(bucketID, prefix)) {
+			retVal = append(retVal, bucketID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func userActivityBucket(day time.Time) string {
+	return userActivityBucketPrefix + day.Format(statsDayLayout)
+}
+
+func hostStatsBucket(day time.Time) string {
+	return hostStatsBucketPrefix + day.Format(statsDayLayout)
+}
+
+// UpsertUserActivity satisfies repository.Stats interface.
+func (r *Repository) UpsertUserActivity(ctx context.Context, username string, day time.Time) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newStatsRep(tx).UpsertUserActivity(ctx, username, day)
+	})
+}
+
+// CountActiveUsers satisfies repository.Stats interface.
+func (r *Repository) CountActiveUsers(ctx context.Context, from, to time.Time) (c int, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		c, err = newStatsRep(tx).CountActiveUsers(ctx, from, to)
+		return err
+	})
+	return
+}
+
+// IncrementHostStats satisfies repository.Stats interface.
+func (r *Repository) IncrementHostStats(ctx context.Context, stats *statsmodel.HostStats) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newStatsRep(tx).IncrementHostStats(ctx, stats)
+	})
+}
+
+// FetchHostStats satisfies repository.Stats interface.
+func (r *Repository) FetchHostStats(ctx context.Context, from, to time.Time) (stats []*statsmodel.HostStats, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		stats, err = newStatsRep(tx).FetchHostStats(ctx, from, to)
+		return err
+	})
+	return
+}
+
+// DeleteStatsBefore satisfies repository.Stats interface.
+func (r *Repository) DeleteStatsBefore(ctx context.Context, day time.Time) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newStatsRep(tx).DeleteStatsBefore(ctx, day)
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestBoltDB_UserActivity(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	day0 := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	day1 := day0.AddDate(0, 0, 1)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBStatsRep{tx: tx}
+
+		require.NoError(t, rep.UpsertUserActivity(context.Background(), "ortuman", day0))
+		require.NoError(t, rep.UpsertUserActivity(context.Background(), "ortuman", day0))
+		require.NoError(t, rep.UpsertUserActivity(context.Background(), "ortuman", day1))
+		require.NoError(t, rep.UpsertUserActivity(context.Background(), "noelia", day1))
+
+		count, err := rep.CountActiveUsers(context.Background(), day0, day0)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		count, err = rep.CountActiveUsers(context.Background(), day0, day1)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		require.NoError(t, rep.DeleteStatsBefore(context.Background(), day1))
+
+		count, err = rep.CountActiveUsers(context.Background(), day0, day1)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		count, err = rep.CountActiveUsers(context.Background(), day0, day0)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_HostStats(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	day0 := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	day1 := day0.AddDate(0, 0, 1)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBStatsRep{tx: tx}
+
+		for _, st := range []*statsmodel.HostStats{
+			{Host: "jackal.im", Day: timestamppb.New(day0), Messages: 10, Registrations: 1},
+			{Host: "jackal.im", Day: timestamppb.New(day0), Messages: 5},
+			{Host: "jackal.im", Day: timestamppb.New(day1), Messages: 2, Registrations: 2},
+			{Host: "jabber.org", Day: timestamppb.New(day1), Messages: 1},
+		} {
+			require.NoError(t, rep.IncrementHostStats(context.Background(), st))
+		}
+		stats, err := rep.FetchHostStats(context.Background(), day0, day1)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+
+		require.Equal(t, "jabber.org", stats[0].Host)
+		require.Equal(t, uint64(1), stats[0].Messages)
+		require.Equal(t, "jackal.im", stats[1].Host)
+		require.Equal(t, uint64(17), stats[1].Messages)
+		require.Equal(t, uint64(3), stats[1].Registrations)
+
+		stats, err = rep.FetchHostStats(context.Background(), day0, day0)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		require.Equal(t, uint64(15), stats[0].Messages)
+		return nil
+	})
+	require.NoError(t, err)
+}
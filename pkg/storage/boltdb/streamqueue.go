@@ -0,0 +1,135 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"time"
+
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+	bolt "go.etcd.io/bbolt"
+)
+
+const streamQueuesBucketKey = "stream_queues"
+
+type boltDBStreamQueueRep struct {
+	tx *bolt.Tx
+}
+
+func newStreamQueueRep(tx *bolt.Tx) *boltDBStreamQueueRep {
+	return &boltDBStreamQueueRep{tx: tx}
+}
+
+func (r *boltDBStreamQueueRep) UpsertStreamQueue(_ context.Context, queue *streamqueuemodel.Queue) error {
+	op := upsertKeyOp{
+		tx:     r.tx,
+		bucket: streamQueuesBucketKey,
+		key:    queue.Jid,
+		obj:    queue,
+	}
+	return op.do()
+}
+
+func (r *boltDBStreamQueueRep) FetchStreamQueue(_ context.Context, jid string) (*streamqueuemodel.Queue, error) {
+	op := fetchKeyOp{
+		tx:     r.tx,
+		bucket: streamQueuesBucketKey,
+		key:    jid,
+		obj:    &streamqueuemodel.Queue{},
+	}
+	obj, err := op.do()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj != nil:
+		return obj.(*streamqueuemodel.Queue), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (r *boltDBStreamQueueRep) DeleteStreamQueue(_ context.Context, jid string) error {
+	op := delKeyOp{
+		tx:     r.tx,
+		bucket: streamQueuesBucketKey,
+		key:    jid,
+	}
+	return op.do()
+}
+
+func (r *boltDBStreamQueueRep) DeleteExpiredStreamQueues(_ context.Context) error {
+	now := time.Now()
+
+	var expiredKeys []string
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: streamQueuesBucketKey,
+		iterFn: func(k, b []byte) error {
+			var queue streamqueuemodel.Queue
+			if err := queue.UnmarshalBinary(b); err != nil {
+				return err
+			}
+			if queue.ExpiresAt.AsTime().Before(now) {
+				expiredKeys = append(expiredKeys, string(k))
+			}
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return err
+	}
+	for _, k := range expiredKeys {
+		delOp := delKeyOp{
+			tx:     r.tx,
+			bucket: streamQueuesBucketKey,
+			key:    k,
+		}
+		if err := delOp.do(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertStreamQueue satisfies repository.StreamQueue interface.
+func (r *Repository) UpsertStreamQueue(ctx context.Context, queue *streamqueuemodel.Queue) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newStreamQueueRep(tx).UpsertStreamQueue(ctx, queue)
+	})
+}
+
+// FetchStreamQueue satisfies repository.StreamQueue interface.
+func (r *Repository) FetchStreamQueue(ctx context.Context, jid string) (queue *streamqueuemodel.Queue, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		queue, err = newStreamQueueRep(tx).FetchStreamQueue(ctx, jid)
+		return err
+	})
+	return
+}
+
+// DeleteStreamQueue satisfies repository.StreamQueue interface.
+func (r *Repository) DeleteStreamQueue(ctx context.Context, jid string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newStreamQueueRep(tx).DeleteStreamQueue(ctx, jid)
+	})
+}
+
+// DeleteExpiredStreamQueues satisfies repository.StreamQueue interface.
+func (r *Repository) DeleteExpiredStreamQueues(ctx context.Context) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newStreamQueueRep(tx).DeleteExpiredStreamQueues(ctx)
+	})
+}
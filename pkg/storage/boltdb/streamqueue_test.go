@@ -0,0 +1,91 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestBoltDB_UpsertAndFetchStreamQueue(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBStreamQueueRep{tx: tx}
+
+		err := rep.UpsertStreamQueue(context.Background(), &streamqueuemodel.Queue{
+			Jid:       "ortuman@jackal.im/yard",
+			Nonce:     []byte("nonce"),
+			InH:       10,
+			OutH:      5,
+			ExpiresAt: timestamppb.New(time.Now().Add(time.Minute)),
+		})
+		require.NoError(t, err)
+
+		q, err := rep.FetchStreamQueue(context.Background(), "ortuman@jackal.im/yard")
+		require.NoError(t, err)
+
+		require.NotNil(t, q)
+		require.Equal(t, uint32(10), q.InH)
+		require.Equal(t, []byte("nonce"), q.Nonce)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteExpiredStreamQueues(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBStreamQueueRep{tx: tx}
+
+		err := rep.UpsertStreamQueue(context.Background(), &streamqueuemodel.Queue{
+			Jid:       "ortuman@jackal.im/yard",
+			ExpiresAt: timestamppb.New(time.Now().Add(-time.Minute)),
+		})
+		require.NoError(t, err)
+
+		err = rep.UpsertStreamQueue(context.Background(), &streamqueuemodel.Queue{
+			Jid:       "noelia@jackal.im/balcony",
+			ExpiresAt: timestamppb.New(time.Now().Add(time.Minute)),
+		})
+		require.NoError(t, err)
+
+		err = rep.DeleteExpiredStreamQueues(context.Background())
+		require.NoError(t, err)
+
+		q, err := rep.FetchStreamQueue(context.Background(), "ortuman@jackal.im/yard")
+		require.NoError(t, err)
+		require.Nil(t, q)
+
+		q, err = rep.FetchStreamQueue(context.Background(), "noelia@jackal.im/balcony")
+		require.NoError(t, err)
+		require.NotNil(t, q)
+		return nil
+	})
+	require.NoError(t, err)
+}
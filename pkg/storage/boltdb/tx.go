@@ -28,6 +28,11 @@ type repTx struct {
 	repository.Private
 	repository.Roster
 	repository.VCard
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Archive
 	repository.Locker
 }
@@ -42,6 +47,11 @@ func newRepTx(tx *bolt.Tx) *repTx {
 		Private:      newPrivateRep(tx),
 		Roster:       newRosterRep(tx),
 		VCard:        newVCardRep(tx),
+		StreamQueue:  newStreamQueueRep(tx),
+		Reaction:     newReactionRep(tx),
+		S2SQueue:     newS2SQueueRep(tx),
+		Stats:        newStatsRep(tx),
+		UnifiedPush:  newUnifiedPushRep(tx),
 		Archive:      newArchiveRep(tx),
 		Locker:       newLockerRep(),
 	}
@@ -0,0 +1,170 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"fmt"
+
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	bolt "go.etcd.io/bbolt"
+)
+
+// unifiedPushTokensBucket maps every endpoint token to its owner username.
+const unifiedPushTokensBucket = "unifiedpush_tokens"
+
+type boltDBUnifiedPushRep struct {
+	tx *bolt.Tx
+}
+
+func newUnifiedPushRep(tx *bolt.Tx) *boltDBUnifiedPushRep {
+	return &boltDBUnifiedPushRep{tx: tx}
+}
+
+func (r *boltDBUnifiedPushRep) UpsertUnifiedPushRegistration(ctx context.Context, reg *unifiedpushmodel.Registration) error {
+	if err := r.DeleteUnifiedPushRegistration(ctx, reg.Username, reg.Application, reg.Instance); err != nil {
+		return err
+	}
+	op := upsertKeyOp{
+		tx:     r.tx,
+		bucket: unifiedPushBucket(reg.Username),
+		key:    reg.Token,
+		obj:    reg,
+	}
+	if err := op.do(); err != nil {
+		return err
+	}
+	b, err := r.tx.CreateBucketIfNotExists([]byte(unifiedPushTokensBucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(reg.Token), []byte(reg.Username))
+}
+
+func (r *boltDBUnifiedPushRep) FetchUnifiedPushRegistration(_ context.Context, token string) (*unifiedpushmodel.Registration, error) {
+	b := r.tx.Bucket([]byte(unifiedPushTokensBucket))
+	if b == nil {
+		return nil, nil
+	}
+	username := b.Get([]byte(token))
+	if username == nil {
+		return nil, nil
+	}
+	op := fetchKeyOp{
+		tx:     r.tx,
+		bucket: unifiedPushBucket(string(username)),
+		key:    token,
+		obj:    &unifiedpushmodel.Registration{},
+	}
+	obj, err := op.do()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj != nil:
+		return obj.(*unifiedpushmodel.Registration), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (r *boltDBUnifiedPushRep) DeleteUnifiedPushRegistration(_ context.Context, username, application, instance string) error {
+	return r.deleteRegistrations(username, func(reg *unifiedpushmodel.Registration) bool {
+		return reg.Application == application && reg.Instance == instance
+	})
+}
+
+func (r *boltDBUnifiedPushRep) DeleteUnifiedPushRegistrations(_ context.Context, username string) error {
+	if err := r.deleteRegistrations(username, func(_ *unifiedpushmodel.Registration) bool { return true }); err != nil {
+		return err
+	}
+	if r.tx.Bucket([]byte(unifiedPushBucket(username))) == nil {
+		return nil
+	}
+	op := delBucketOp{
+		tx:     r.tx,
+		bucket: unifiedPushBucket(username),
+	}
+	return op.do()
+}
+
+func (r *boltDBUnifiedPushRep) deleteRegistrations(username string, matchFn func(reg *unifiedpushmodel.Registration) bool) error {
+	var tokens []string
+
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: unifiedPushBucket(username),
+		iterFn: func(k, b []byte) error {
+			var reg unifiedpushmodel.Registration
+			if err := reg.UnmarshalBinary(b); err != nil {
+				return err
+			}
+			if matchFn(&reg) {
+				tokens = append(tokens, string(k))
+			}
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		for _, bucket := range []string{unifiedPushBucket(username), unifiedPushTokensBucket} {
+			delOp := delKeyOp{
+				tx:     r.tx,
+				bucket: bucket,
+				key:    token,
+			}
+			if err := delOp.do(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func unifiedPushBucket(username string) string {
+	return fmt.Sprintf("unifiedpush:%s", username)
+}
+
+// UpsertUnifiedPushRegistration satisfies repository.UnifiedPush interface.
+func (r *Repository) UpsertUnifiedPushRegistration(ctx context.Context, reg *unifiedpushmodel.Registration) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newUnifiedPushRep(tx).UpsertUnifiedPushRegistration(ctx, reg)
+	})
+}
+
+// FetchUnifiedPushRegistration satisfies repository.UnifiedPush interface.
+func (r *Repository) FetchUnifiedPushRegistration(ctx context.Context, token string) (reg *unifiedpushmodel.Registration, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		reg, err = newUnifiedPushRep(tx).FetchUnifiedPushRegistration(ctx, token)
+		return err
+	})
+	return
+}
+
+// DeleteUnifiedPushRegistration satisfies repository.UnifiedPush interface.
+func (r *Repository) DeleteUnifiedPushRegistration(ctx context.Context, username, application, instance string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newUnifiedPushRep(tx).DeleteUnifiedPushRegistration(ctx, username, application, instance)
+	})
+}
+
+// DeleteUnifiedPushRegistrations satisfies repository.UnifiedPush interface.
+func (r *Repository) DeleteUnifiedPushRegistrations(ctx context.Context, username string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newUnifiedPushRep(tx).DeleteUnifiedPushRegistrations(ctx, username)
+	})
+}
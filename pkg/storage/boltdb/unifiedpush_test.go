@@ -0,0 +1,93 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"context"
+	"testing"
+
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltDB_UpsertAndFetchUnifiedPushRegistration(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBUnifiedPushRep{tx: tx}
+
+		for _, reg := range []*unifiedpushmodel.Registration{
+			{Token: "t1", Username: "ortuman", Jid: "ortuman@jackal.im/phone", Application: "a1", Instance: "i1"},
+			{Token: "t2", Username: "ortuman", Jid: "ortuman@jackal.im/phone", Application: "a1", Instance: "i1"}, // re-registered
+			{Token: "t3", Username: "ortuman", Jid: "ortuman@jackal.im/phone", Application: "a2", Instance: "i1"},
+		} {
+			require.NoError(t, rep.UpsertUnifiedPushRegistration(context.Background(), reg))
+		}
+
+		reg, err := rep.FetchUnifiedPushRegistration(context.Background(), "t1")
+		require.NoError(t, err)
+		require.Nil(t, reg)
+
+		reg, err = rep.FetchUnifiedPushRegistration(context.Background(), "t2")
+		require.NoError(t, err)
+		require.NotNil(t, reg)
+		require.Equal(t, "ortuman@jackal.im/phone", reg.Jid)
+		require.Equal(t, "a1", reg.Application)
+
+		require.Equal(t, 2, countBucketElements(t, tx, unifiedPushBucket("ortuman")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteUnifiedPushRegistrations(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBUnifiedPushRep{tx: tx}
+
+		for _, reg := range []*unifiedpushmodel.Registration{
+			{Token: "t1", Username: "ortuman", Application: "a1", Instance: "i1"},
+			{Token: "t2", Username: "ortuman", Application: "a2", Instance: "i1"},
+			{Token: "t3", Username: "ortuman", Application: "a3", Instance: "i1"},
+		} {
+			require.NoError(t, rep.UpsertUnifiedPushRegistration(context.Background(), reg))
+		}
+
+		require.NoError(t, rep.DeleteUnifiedPushRegistration(context.Background(), "ortuman", "a1", "i1"))
+
+		reg, err := rep.FetchUnifiedPushRegistration(context.Background(), "t1")
+		require.NoError(t, err)
+		require.Nil(t, reg)
+
+		reg, err = rep.FetchUnifiedPushRegistration(context.Background(), "t2")
+		require.NoError(t, err)
+		require.NotNil(t, reg)
+
+		require.NoError(t, rep.DeleteUnifiedPushRegistrations(context.Background(), "ortuman"))
+
+		require.Nil(t, tx.Bucket([]byte(unifiedPushBucket("ortuman"))))
+		require.Equal(t, 0, countBucketElements(t, tx, unifiedPushTokensBucket))
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -17,12 +17,18 @@ package boltdb
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 	bolt "go.etcd.io/bbolt"
 )
 
-const userKey = "usr"
+const (
+	userKey          = "usr"
+	userBucketPrefix = "user:"
+)
 
 type boltDBUserRep struct {
 	tx *bolt.Tx
@@ -77,8 +83,37 @@ func (r *boltDBUserRep) UserExists(_ context.Context, username string) (bool, er
 	return op.do(), nil
 }
 
+func (r *boltDBUserRep) FetchUsersPendingDeletion(ctx context.Context, before time.Time) ([]string, error) {
+	var usernames []string
+
+	err := r.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if bucketID := string(name); strings.HasPrefix(bucketID, userBucketPrefix) {
+			usernames = append(usernames, strings.TrimPrefix(bucketID, userBucketPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var retVal []string
+	for _, username := range usernames {
+		usr, err := r.FetchUser(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		if usr == nil || usr.DeletionScheduledAt == nil {
+			continue
+		}
+		if usr.DeletionScheduledAt.AsTime().Before(before) {
+			retVal = append(retVal, username)
+		}
+	}
+	sort.Strings(retVal)
+	return retVal, nil
+}
+
 func userBucketKey(username string) string {
-	return fmt.Sprintf("user:%s", username)
+	return fmt.Sprintf("%s%s", userBucketPrefix, username)
 }
 
 // UpsertUser satisfies repository.User interface.
@@ -112,3 +147,12 @@ func (r *Repository) UserExists(ctx context.Context, username string) (ok bool,
 	})
 	return
 }
+
+// FetchUsersPendingDeletion satisfies repository.User interface.
+func (r *Repository) FetchUsersPendingDeletion(ctx context.Context, before time.Time) (usernames []string, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		usernames, err = newUserRep(tx).FetchUsersPendingDeletion(ctx, before)
+		return err
+	})
+	return
+}
@@ -17,10 +17,12 @@ package boltdb
 import (
 	"context"
 	"testing"
+	"time"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestBoltDB_UpsertAndFetchUser(t *testing.T) {
@@ -94,3 +96,38 @@ func TestBoltDB_DeleteUser(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestBoltDB_FetchUsersPendingDeletion(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBUserRep{tx: tx}
+
+		err := rep.UpsertUser(context.Background(), &usermodel.User{
+			Username: "ortuman",
+		})
+		require.NoError(t, err)
+		err = rep.UpsertUser(context.Background(), &usermodel.User{
+			Username:            "noelia",
+			DeletionScheduledAt: timestamppb.New(now.Add(-time.Hour)),
+		})
+		require.NoError(t, err)
+		err = rep.UpsertUser(context.Background(), &usermodel.User{
+			Username:            "hamlet",
+			DeletionScheduledAt: timestamppb.New(now.Add(time.Hour)),
+		})
+		require.NoError(t, err)
+
+		usernames, err := rep.FetchUsersPendingDeletion(context.Background(), now)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"noelia"}, usernames)
+		return nil
+	})
+	require.NoError(t, err)
+}
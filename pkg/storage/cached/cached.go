@@ -68,6 +68,11 @@ type CachedRepository struct {
 	repository.Private
 	repository.Roster
 	repository.VCard
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Archive
 	repository.Locker
 
@@ -94,6 +99,11 @@ func New(cfg Config, rep repository.Repository, logger kitlog.Logger) (repositor
 		VCard:        &cachedVCardRep{c: c, rep: rep, logger: logger},
 		Archive:      rep,
 		Offline:      rep,
+		StreamQueue:  rep,
+		Reaction:     rep,
+		S2SQueue:     rep,
+		Stats:        rep,
+		UnifiedPush:  rep,
 		Locker:       rep,
 		rep:          rep,
 		cache:        c,
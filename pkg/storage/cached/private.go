@@ -51,6 +51,10 @@ func (c *cachedPrivateRep) FetchPrivate(ctx context.Context, namespace, username
 	return nil, nil
 }
 
+func (c *cachedPrivateRep) FetchPrivates(ctx context.Context, username string) ([]stravaganza.Element, error) {
+	return c.rep.FetchPrivates(ctx, username)
+}
+
 func (c *cachedPrivateRep) UpsertPrivate(ctx context.Context, private stravaganza.Element, namespace, username string) error {
 	op := updateOp{
 		c:              c.c,
@@ -27,6 +27,11 @@ type cachedTx struct {
 	repository.Private
 	repository.Roster
 	repository.VCard
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Archive
 	repository.Locker
 }
@@ -42,6 +47,11 @@ func newCacheTx(c Cache, tx repository.Transaction) *cachedTx {
 		VCard:        &cachedVCardRep{c: c, rep: tx},
 		Archive:      tx,
 		Offline:      tx,
+		StreamQueue:  tx,
+		Reaction:     tx,
+		S2SQueue:     tx,
+		Stats:        tx,
+		UnifiedPush:  tx,
 		Locker:       tx,
 	}
 }
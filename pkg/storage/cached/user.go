@@ -17,6 +17,7 @@ package cachedrepository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/ortuman/jackal/pkg/model"
@@ -90,6 +91,10 @@ func (c *cachedUserRep) UserExists(ctx context.Context, username string) (bool,
 	return op.do(ctx)
 }
 
+func (c *cachedUserRep) FetchUsersPendingDeletion(ctx context.Context, before time.Time) ([]string, error) {
+	return c.rep.FetchUsersPendingDeletion(ctx, before)
+}
+
 func userNS(username string) string {
 	return fmt.Sprintf("usr:%s", username)
 }
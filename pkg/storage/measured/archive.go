@@ -27,11 +27,18 @@ type measuredArchiveRep struct {
 	inTx bool
 }
 
-func (m *measuredArchiveRep) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) error {
+func (m *measuredArchiveRep) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) (inserted bool, err error) {
 	t0 := time.Now()
-	err := m.rep.InsertArchiveMessage(ctx, message)
+	inserted, err = m.rep.InsertArchiveMessage(ctx, message)
 	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
-	return err
+	return
+}
+
+func (m *measuredArchiveRep) FetchArchiveMessageByOriginID(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+	t0 := time.Now()
+	msg, err := m.rep.FetchArchiveMessageByOriginID(ctx, archiveID, originID)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return msg, err
 }
 
 func (m *measuredArchiveRep) FetchArchiveMetadata(ctx context.Context, archiveID string) (metadata *archivemodel.Metadata, err error) {
@@ -41,13 +48,27 @@ func (m *measuredArchiveRep) FetchArchiveMetadata(ctx context.Context, archiveID
 	return
 }
 
-func (m *measuredArchiveRep) FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) (messages []*archivemodel.Message, err error) {
+func (m *measuredArchiveRep) FetchArchiveIDs(ctx context.Context) (archiveIDs []string, err error) {
 	t0 := time.Now()
-	messages, err = m.rep.FetchArchiveMessages(ctx, f, archiveID)
+	archiveIDs, err = m.rep.FetchArchiveIDs(ctx)
 	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return
 }
 
+func (m *measuredArchiveRep) FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) (messages []*archivemodel.Message, err error) {
+	t0 := time.Now()
+	messages, err = m.rep.FetchArchiveMessages(ctx, f, page, archiveID)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredArchiveRep) CountArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+	t0 := time.Now()
+	n, err := m.rep.CountArchiveMessages(ctx, f, archiveID)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return n, err
+}
+
 func (m *measuredArchiveRep) DeleteArchiveOldestMessages(ctx context.Context, archiveID string, maxElements int) error {
 	t0 := time.Now()
 	err := m.rep.DeleteArchiveOldestMessages(ctx, archiveID, maxElements)
@@ -55,9 +76,79 @@ func (m *measuredArchiveRep) DeleteArchiveOldestMessages(ctx context.Context, ar
 	return err
 }
 
+func (m *measuredArchiveRep) DeleteArchiveMessagesBefore(ctx context.Context, host string, before time.Time) (int, error) {
+	t0 := time.Now()
+	n, err := m.rep.DeleteArchiveMessagesBefore(ctx, host, before)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return n, err
+}
+
+func (m *measuredArchiveRep) DeleteArchiveMessagesWith(ctx context.Context, archiveID, with string) (int, error) {
+	t0 := time.Now()
+	n, err := m.rep.DeleteArchiveMessagesWith(ctx, archiveID, with)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return n, err
+}
+
 func (m *measuredArchiveRep) DeleteArchive(ctx context.Context, archiveID string) error {
 	t0 := time.Now()
 	err := m.rep.DeleteArchive(ctx, archiveID)
 	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return err
 }
+
+func (m *measuredArchiveRep) UpsertArchivePrefs(ctx context.Context, prefs *archivemodel.Prefs) error {
+	t0 := time.Now()
+	err := m.rep.UpsertArchivePrefs(ctx, prefs)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredArchiveRep) FetchArchivePrefs(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+	t0 := time.Now()
+	prefs, err := m.rep.FetchArchivePrefs(ctx, archiveID)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return prefs, err
+}
+
+func (m *measuredArchiveRep) DeleteArchivePrefs(ctx context.Context, archiveID string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteArchivePrefs(ctx, archiveID)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredArchiveRep) DeleteArchiveExpiredMessages(ctx context.Context, archiveID string, before time.Time) error {
+	t0 := time.Now()
+	err := m.rep.DeleteArchiveExpiredMessages(ctx, archiveID, before)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredArchiveRep) UpsertArchiveQuota(ctx context.Context, quota *archivemodel.Quota) error {
+	t0 := time.Now()
+	err := m.rep.UpsertArchiveQuota(ctx, quota)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredArchiveRep) FetchArchiveQuota(ctx context.Context, archiveID, host string) (*archivemodel.Quota, error) {
+	t0 := time.Now()
+	quota, err := m.rep.FetchArchiveQuota(ctx, archiveID, host)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return quota, err
+}
+
+func (m *measuredArchiveRep) FetchArchiveQuotas(ctx context.Context) ([]*archivemodel.Quota, error) {
+	t0 := time.Now()
+	quotas, err := m.rep.FetchArchiveQuotas(ctx)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return quotas, err
+}
+
+func (m *measuredArchiveRep) DeleteArchiveQuota(ctx context.Context, archiveID, host string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteArchiveQuota(ctx, archiveID, host)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
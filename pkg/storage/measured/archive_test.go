@@ -17,6 +17,7 @@ package measuredrepository
 import (
 	"context"
 	"testing"
+	"time"
 
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/stretchr/testify/require"
@@ -25,13 +26,13 @@ import (
 func TestMeasuredArchiveRep_InsertArchiveMessage(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
-	repMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) error {
-		return nil
+	repMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) (bool, error) {
+		return true, nil
 	}
 	m := &measuredArchiveRep{rep: repMock}
 
 	// when
-	_ = m.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234"})
+	_, _ = m.InsertArchiveMessage(context.Background(), &archivemodel.Message{ArchiveId: "a1234"})
 
 	// then
 	require.Len(t, repMock.InsertArchiveMessageCalls(), 1)
@@ -52,21 +53,51 @@ func TestMeasuredArchiveRep_FetchArchiveMetadata(t *testing.T) {
 	require.Len(t, repMock.FetchArchiveMetadataCalls(), 1)
 }
 
+func TestMeasuredArchiveRep_FetchArchiveIDs(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveIDsFunc = func(ctx context.Context) ([]string, error) {
+		return nil, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchArchiveIDs(context.Background())
+
+	// then
+	require.Len(t, repMock.FetchArchiveIDsCalls(), 1)
+}
+
 func TestMeasuredArchiveRep_FetchArchiveMessages(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
-	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error) {
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
 		return nil, nil
 	}
 	m := &measuredArchiveRep{rep: repMock}
 
 	// when
-	_, _ = m.FetchArchiveMessages(context.Background(), &archivemodel.Filters{}, "a1234")
+	_, _ = m.FetchArchiveMessages(context.Background(), &archivemodel.Filters{}, nil, "a1234")
 
 	// then
 	require.Len(t, repMock.FetchArchiveMessagesCalls(), 1)
 }
 
+func TestMeasuredArchiveRep_CountArchiveMessages(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.CountArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+		return 0, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.CountArchiveMessages(context.Background(), &archivemodel.Filters{}, "a1234")
+
+	// then
+	require.Len(t, repMock.CountArchiveMessagesCalls(), 1)
+}
+
 func TestMeasuredArchiveRep_DeleteArchiveOldestMessages(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
@@ -97,3 +128,168 @@ func TestMeasuredArchiveRep_DeleteArchive(t *testing.T) {
 	// then
 	require.Len(t, repMock.DeleteArchiveCalls(), 1)
 }
+
+func TestMeasuredArchiveRep_DeleteArchiveMessagesBefore(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchiveMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		return 0, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.DeleteArchiveMessagesBefore(context.Background(), "jackal.im", time.Now())
+
+	// then
+	require.Len(t, repMock.DeleteArchiveMessagesBeforeCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_DeleteArchiveMessagesWith(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchiveMessagesWithFunc = func(ctx context.Context, archiveID, with string) (int, error) {
+		return 0, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.DeleteArchiveMessagesWith(context.Background(), "ortuman", "noelia@jackal.im")
+
+	// then
+	require.Len(t, repMock.DeleteArchiveMessagesWithCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_FetchArchiveMessageByOriginID(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveMessageByOriginIDFunc = func(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+		return nil, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchArchiveMessageByOriginID(context.Background(), "ortuman", "origin1234")
+
+	// then
+	require.Len(t, repMock.FetchArchiveMessageByOriginIDCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_UpsertArchivePrefs(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertArchivePrefsFunc = func(ctx context.Context, prefs *archivemodel.Prefs) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.UpsertArchivePrefs(context.Background(), &archivemodel.Prefs{ArchiveId: "ortuman"})
+
+	// then
+	require.Len(t, repMock.UpsertArchivePrefsCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_FetchArchivePrefs(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchivePrefsFunc = func(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+		return nil, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchArchivePrefs(context.Background(), "ortuman")
+
+	// then
+	require.Len(t, repMock.FetchArchivePrefsCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_DeleteArchivePrefs(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchivePrefsFunc = func(ctx context.Context, archiveID string) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.DeleteArchivePrefs(context.Background(), "ortuman")
+
+	// then
+	require.Len(t, repMock.DeleteArchivePrefsCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_DeleteArchiveExpiredMessages(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchiveExpiredMessagesFunc = func(ctx context.Context, archiveID string, before time.Time) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.DeleteArchiveExpiredMessages(context.Background(), "ortuman", time.Now())
+
+	// then
+	require.Len(t, repMock.DeleteArchiveExpiredMessagesCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_UpsertArchiveQuota(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertArchiveQuotaFunc = func(ctx context.Context, quota *archivemodel.Quota) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.UpsertArchiveQuota(context.Background(), &archivemodel.Quota{ArchiveId: "ortuman"})
+
+	// then
+	require.Len(t, repMock.UpsertArchiveQuotaCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_FetchArchiveQuota(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotaFunc = func(ctx context.Context, archiveID, host string) (*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchArchiveQuota(context.Background(), "ortuman", "")
+
+	// then
+	require.Len(t, repMock.FetchArchiveQuotaCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_FetchArchiveQuotas(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveQuotasFunc = func(ctx context.Context) ([]*archivemodel.Quota, error) {
+		return nil, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchArchiveQuotas(context.Background())
+
+	// then
+	require.Len(t, repMock.FetchArchiveQuotasCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_DeleteArchiveQuota(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchiveQuotaFunc = func(ctx context.Context, archiveID, host string) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.DeleteArchiveQuota(context.Background(), "ortuman", "")
+
+	// then
+	require.Len(t, repMock.DeleteArchiveQuotaCalls(), 1)
+}
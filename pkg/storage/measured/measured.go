@@ -40,6 +40,11 @@ type Measured struct {
 	measuredPrivateRep
 	measuredRosterRep
 	measuredVCardRep
+	measuredStreamQueueRep
+	measuredReactionRep
+	measuredS2SQueueRep
+	measuredStatsRep
+	measuredUnifiedPushRep
 	measuredArchiveRep
 	measuredLocker
 	rep repository.Repository
@@ -56,6 +61,11 @@ func New(rep repository.Repository) repository.Repository {
 		measuredPrivateRep:      measuredPrivateRep{rep: rep},
 		measuredRosterRep:       measuredRosterRep{rep: rep},
 		measuredVCardRep:        measuredVCardRep{rep: rep},
+		measuredStreamQueueRep:  measuredStreamQueueRep{rep: rep},
+		measuredReactionRep:     measuredReactionRep{rep: rep},
+		measuredS2SQueueRep:     measuredS2SQueueRep{rep: rep},
+		measuredStatsRep:        measuredStatsRep{rep: rep},
+		measuredUnifiedPushRep:  measuredUnifiedPushRep{rep: rep},
 		measuredArchiveRep:      measuredArchiveRep{rep: rep},
 		measuredLocker:          measuredLocker{rep: rep},
 		rep:                     rep,
@@ -48,6 +48,27 @@ func (m *measuredOfflineRep) FetchOfflineMessages(ctx context.Context, username
 	return ms, err
 }
 
+func (m *measuredOfflineRep) DeleteOfflineMessagesBefore(ctx context.Context, host string, before time.Time) (int, error) {
+	t0 := time.Now()
+	n, err := m.rep.DeleteOfflineMessagesBefore(ctx, host, before)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return n, err
+}
+
+func (m *measuredOfflineRep) DeleteSelectedOfflineMessages(ctx context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (int, error) {
+	t0 := time.Now()
+	n, err := m.rep.DeleteSelectedOfflineMessages(ctx, username, selectFn)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return n, err
+}
+
+func (m *measuredOfflineRep) TrimOfflineMessages(ctx context.Context, username string, maxSize int) (int, error) {
+	t0 := time.Now()
+	n, err := m.rep.TrimOfflineMessages(ctx, username, maxSize)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return n, err
+}
+
 func (m *measuredOfflineRep) DeleteOfflineMessages(ctx context.Context, username string) error {
 	t0 := time.Now()
 	err := m.rep.DeleteOfflineMessages(ctx, username)
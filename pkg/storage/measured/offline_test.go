@@ -17,6 +17,7 @@ package measuredrepository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/stretchr/testify/require"
@@ -82,3 +83,48 @@ func TestMeasuredOfflineRep_DeleteOfflineMessage(t *testing.T) {
 	// then
 	require.Len(t, repMock.DeleteOfflineMessagesCalls(), 1)
 }
+
+func TestMeasuredOfflineRep_DeleteOfflineMessagesBefore(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteOfflineMessagesBeforeFunc = func(ctx context.Context, host string, before time.Time) (int, error) {
+		return 0, nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	_, _ = m.DeleteOfflineMessagesBefore(context.Background(), "jackal.im", time.Now())
+
+	// then
+	require.Len(t, repMock.DeleteOfflineMessagesBeforeCalls(), 1)
+}
+
+func TestMeasuredOfflineRep_DeleteSelectedOfflineMessages(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteSelectedOfflineMessagesFunc = func(ctx context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (int, error) {
+		return 0, nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	_, _ = m.DeleteSelectedOfflineMessages(context.Background(), "ortuman", func(_ *stravaganza.Message) bool { return true })
+
+	// then
+	require.Len(t, repMock.DeleteSelectedOfflineMessagesCalls(), 1)
+}
+
+func TestMeasuredOfflineRep_TrimOfflineMessages(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.TrimOfflineMessagesFunc = func(ctx context.Context, username string, maxSize int) (int, error) {
+		return 0, nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	_, _ = m.TrimOfflineMessages(context.Background(), "ortuman", 100)
+
+	// then
+	require.Len(t, repMock.TrimOfflineMessagesCalls(), 1)
+}
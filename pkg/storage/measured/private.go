@@ -34,6 +34,13 @@ func (m *measuredPrivateRep) FetchPrivate(ctx context.Context, namespace, userna
 	return
 }
 
+func (m *measuredPrivateRep) FetchPrivates(ctx context.Context, username string) (privates []stravaganza.Element, err error) {
+	t0 := time.Now()
+	privates, err = m.rep.FetchPrivates(ctx, username)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
 func (m *measuredPrivateRep) UpsertPrivate(ctx context.Context, private stravaganza.Element, namespace, username string) (err error) {
 	t0 := time.Now()
 	err = m.rep.UpsertPrivate(ctx, private, namespace, username)
@@ -39,6 +39,23 @@ func TestMeasuredPrivateRep_FetchPrivate(t *testing.T) {
 	require.Len(t, repMock.FetchPrivateCalls(), 1)
 }
 
+func TestMeasuredPrivateRep_FetchPrivates(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchPrivatesFunc = func(ctx context.Context, username string) ([]stravaganza.Element, error) {
+		return []stravaganza.Element{stravaganza.NewBuilder("e").Build()}, nil
+	}
+	m := New(repMock)
+
+	// when
+	prvs, _ := m.FetchPrivates(context.Background(), "ortuman")
+
+	// then
+	require.Len(t, prvs, 1)
+
+	require.Len(t, repMock.FetchPrivatesCalls(), 1)
+}
+
 func TestMeasuredPrivateRep_UpsertPrivate(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
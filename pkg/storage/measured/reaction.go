@@ -0,0 +1,56 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"time"
+
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+type measuredReactionRep struct {
+	rep  repository.Reaction
+	inTx bool
+}
+
+func (m *measuredReactionRep) UpsertReactions(ctx context.Context, reactions *reactionmodel.Reactions) error {
+	t0 := time.Now()
+	err := m.rep.UpsertReactions(ctx, reactions)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredReactionRep) FetchReactions(ctx context.Context, archiveID string, targetIDs []string) (reactions []*reactionmodel.Reactions, err error) {
+	t0 := time.Now()
+	reactions, err = m.rep.FetchReactions(ctx, archiveID, targetIDs)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredReactionRep) DeleteReactions(ctx context.Context, archiveID, targetID, jid string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteReactions(ctx, archiveID, targetID, jid)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredReactionRep) DeleteArchiveReactions(ctx context.Context, archiveID string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteArchiveReactions(ctx, archiveID)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
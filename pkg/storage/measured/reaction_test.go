@@ -0,0 +1,72 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"testing"
+
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredReactionRep_UpsertReactions(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertReactionsFunc = func(ctx context.Context, reactions *reactionmodel.Reactions) error {
+		return nil
+	}
+	m := &measuredReactionRep{rep: repMock}
+
+	// when
+	_ = m.UpsertReactions(context.Background(), &reactionmodel.Reactions{
+		ArchiveId: "ortuman",
+		TargetId:  "msg-1",
+		Jid:       "noelia@jackal.im",
+	})
+
+	// then
+	require.Len(t, repMock.UpsertReactionsCalls(), 1)
+}
+
+func TestMeasuredReactionRep_FetchReactions(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchReactionsFunc = func(ctx context.Context, archiveID string, targetIDs []string) ([]*reactionmodel.Reactions, error) {
+		return nil, nil
+	}
+	m := &measuredReactionRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchReactions(context.Background(), "ortuman", []string{"msg-1"})
+
+	// then
+	require.Len(t, repMock.FetchReactionsCalls(), 1)
+}
+
+func TestMeasuredReactionRep_DeleteArchiveReactions(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchiveReactionsFunc = func(ctx context.Context, archiveID string) error {
+		return nil
+	}
+	m := &measuredReactionRep{rep: repMock}
+
+	// when
+	_ = m.DeleteArchiveReactions(context.Background(), "ortuman")
+
+	// then
+	require.Len(t, repMock.DeleteArchiveReactionsCalls(), 1)
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"time"
+
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+type measuredS2SQueueRep struct {
+	rep  repository.S2SQueue
+	inTx bool
+}
+
+func (m *measuredS2SQueueRep) InsertS2SQueueStanza(ctx context.Context, stanza *s2squeuemodel.Stanza) error {
+	t0 := time.Now()
+	err := m.rep.InsertS2SQueueStanza(ctx, stanza)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredS2SQueueRep) CountS2SQueueStanzas(ctx context.Context, domain string) (int, error) {
+	t0 := time.Now()
+	count, err := m.rep.CountS2SQueueStanzas(ctx, domain)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return count, err
+}
+
+func (m *measuredS2SQueueRep) FetchS2SQueueStanzas(ctx context.Context, domain string) (stanzas []*s2squeuemodel.Stanza, err error) {
+	t0 := time.Now()
+	stanzas, err = m.rep.FetchS2SQueueStanzas(ctx, domain)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredS2SQueueRep) FetchS2SQueueDomains(ctx context.Context) (domains []string, err error) {
+	t0 := time.Now()
+	domains, err = m.rep.FetchS2SQueueDomains(ctx)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredS2SQueueRep) DeleteS2SQueueStanzas(ctx context.Context, domain string, ids []int64) error {
+	t0 := time.Now()
+	err := m.rep.DeleteS2SQueueStanzas(ctx, domain, ids)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredS2SQueueRep) DeleteExpiredS2SQueueStanzas(ctx context.Context) error {
+	t0 := time.Now()
+	err := m.rep.DeleteExpiredS2SQueueStanzas(ctx)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
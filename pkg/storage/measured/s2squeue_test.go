@@ -0,0 +1,113 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"testing"
+
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredS2SQueueRep_InsertS2SQueueStanza(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.InsertS2SQueueStanzaFunc = func(ctx context.Context, stanza *s2squeuemodel.Stanza) error {
+		return nil
+	}
+	m := &measuredS2SQueueRep{rep: repMock}
+
+	// when
+	_ = m.InsertS2SQueueStanza(context.Background(), &s2squeuemodel.Stanza{Domain: "jabber.org"})
+
+	// then
+	require.Len(t, repMock.InsertS2SQueueStanzaCalls(), 1)
+}
+
+func TestMeasuredS2SQueueRep_CountS2SQueueStanzas(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.CountS2SQueueStanzasFunc = func(ctx context.Context, domain string) (int, error) {
+		return 0, nil
+	}
+	m := &measuredS2SQueueRep{rep: repMock}
+
+	// when
+	_, _ = m.CountS2SQueueStanzas(context.Background(), "jabber.org")
+
+	// then
+	require.Len(t, repMock.CountS2SQueueStanzasCalls(), 1)
+}
+
+func TestMeasuredS2SQueueRep_FetchS2SQueueStanzas(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchS2SQueueStanzasFunc = func(ctx context.Context, domain string) ([]*s2squeuemodel.Stanza, error) {
+		return nil, nil
+	}
+	m := &measuredS2SQueueRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchS2SQueueStanzas(context.Background(), "jabber.org")
+
+	// then
+	require.Len(t, repMock.FetchS2SQueueStanzasCalls(), 1)
+}
+
+func TestMeasuredS2SQueueRep_FetchS2SQueueDomains(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchS2SQueueDomainsFunc = func(ctx context.Context) ([]string, error) {
+		return nil, nil
+	}
+	m := &measuredS2SQueueRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchS2SQueueDomains(context.Background())
+
+	// then
+	require.Len(t, repMock.FetchS2SQueueDomainsCalls(), 1)
+}
+
+func TestMeasuredS2SQueueRep_DeleteS2SQueueStanzas(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteS2SQueueStanzasFunc = func(ctx context.Context, domain string, ids []int64) error {
+		return nil
+	}
+	m := &measuredS2SQueueRep{rep: repMock}
+
+	// when
+	_ = m.DeleteS2SQueueStanzas(context.Background(), "jabber.org", []int64{1, 2})
+
+	// then
+	require.Len(t, repMock.DeleteS2SQueueStanzasCalls(), 1)
+}
+
+func TestMeasuredS2SQueueRep_DeleteExpiredS2SQueueStanzas(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteExpiredS2SQueueStanzasFunc = func(ctx context.Context) error {
+		return nil
+	}
+	m := &measuredS2SQueueRep{rep: repMock}
+
+	// when
+	_ = m.DeleteExpiredS2SQueueStanzas(context.Background())
+
+	// then
+	require.Len(t, repMock.DeleteExpiredS2SQueueStanzasCalls(), 1)
+}
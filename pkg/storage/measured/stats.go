@@ -0,0 +1,63 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"time"
+
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+type measuredStatsRep struct {
+	rep  repository.Stats
+	inTx bool
+}
+
+func (m *measuredStatsRep) UpsertUserActivity(ctx context.Context, username string, day time.Time) error {
+	t0 := time.Now()
+	err := m.rep.UpsertUserActivity(ctx, username, day)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredStatsRep) CountActiveUsers(ctx context.Context, from, to time.Time) (int, error) {
+	t0 := time.Now()
+	count, err := m.rep.CountActiveUsers(ctx, from, to)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return count, err
+}
+
+func (m *measuredStatsRep) IncrementHostStats(ctx context.Context, stats *statsmodel.HostStats) error {
+	t0 := time.Now()
+	err := m.rep.IncrementHostStats(ctx, stats)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredStatsRep) FetchHostStats(ctx context.Context, from, to time.Time) (stats []*statsmodel.HostStats, err error) {
+	t0 := time.Now()
+	stats, err = m.rep.FetchHostStats(ctx, from, to)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredStatsRep) DeleteStatsBefore(ctx context.Context, day time.Time) error {
+	t0 := time.Now()
+	err := m.rep.DeleteStatsBefore(ctx, day)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredStatsRep_UpsertUserActivity(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertUserActivityFunc = func(ctx context.Context, username string, day time.Time) error {
+		return nil
+	}
+	m := &measuredStatsRep{rep: repMock}
+
+	// when
+	_ = m.UpsertUserActivity(context.Background(), "ortuman", time.Now())
+
+	// then
+	require.Len(t, repMock.UpsertUserActivityCalls(), 1)
+}
+
+func TestMeasuredStatsRep_CountActiveUsers(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.CountActiveUsersFunc = func(ctx context.Context, from, to time.Time) (int, error) {
+		return 0, nil
+	}
+	m := &measuredStatsRep{rep: repMock}
+
+	// when
+	_, _ = m.CountActiveUsers(context.Background(), time.Now(), time.Now())
+
+	// then
+	require.Len(t, repMock.CountActiveUsersCalls(), 1)
+}
+
+func TestMeasuredStatsRep_IncrementHostStats(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.IncrementHostStatsFunc = func(ctx context.Context, stats *statsmodel.HostStats) error {
+		return nil
+	}
+	m := &measuredStatsRep{rep: repMock}
+
+	// when
+	_ = m.IncrementHostStats(context.Background(), &statsmodel.HostStats{Host: "jackal.im"})
+
+	// then
+	require.Len(t, repMock.IncrementHostStatsCalls(), 1)
+}
+
+func TestMeasuredStatsRep_FetchHostStats(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchHostStatsFunc = func(ctx context.Context, from, to time.Time) ([]*statsmodel.HostStats, error) {
+		return nil, nil
+	}
+	m := &measuredStatsRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchHostStats(context.Background(), time.Now(), time.Now())
+
+	// then
+	require.Len(t, repMock.FetchHostStatsCalls(), 1)
+}
+
+func TestMeasuredStatsRep_DeleteStatsBefore(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteStatsBeforeFunc = func(ctx context.Context, day time.Time) error {
+		return nil
+	}
+	m := &measuredStatsRep{rep: repMock}
+
+	// when
+	_ = m.DeleteStatsBefore(context.Background(), time.Now())
+
+	// then
+	require.Len(t, repMock.DeleteStatsBeforeCalls(), 1)
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"time"
+
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+type measuredStreamQueueRep struct {
+	rep  repository.StreamQueue
+	inTx bool
+}
+
+func (m *measuredStreamQueueRep) UpsertStreamQueue(ctx context.Context, queue *streamqueuemodel.Queue) error {
+	t0 := time.Now()
+	err := m.rep.UpsertStreamQueue(ctx, queue)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredStreamQueueRep) FetchStreamQueue(ctx context.Context, jid string) (queue *streamqueuemodel.Queue, err error) {
+	t0 := time.Now()
+	queue, err = m.rep.FetchStreamQueue(ctx, jid)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredStreamQueueRep) DeleteStreamQueue(ctx context.Context, jid string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteStreamQueue(ctx, jid)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredStreamQueueRep) DeleteExpiredStreamQueues(ctx context.Context) error {
+	t0 := time.Now()
+	err := m.rep.DeleteExpiredStreamQueues(ctx)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
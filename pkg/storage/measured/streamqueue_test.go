@@ -0,0 +1,70 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"testing"
+
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredStreamQueueRep_UpsertStreamQueue(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertStreamQueueFunc = func(ctx context.Context, queue *streamqueuemodel.Queue) error {
+		return nil
+	}
+	m := &measuredStreamQueueRep{rep: repMock}
+
+	// when
+	_ = m.UpsertStreamQueue(context.Background(), &streamqueuemodel.Queue{
+		Jid: "ortuman@jackal.im/yard",
+	})
+
+	// then
+	require.Len(t, repMock.UpsertStreamQueueCalls(), 1)
+}
+
+func TestMeasuredStreamQueueRep_FetchStreamQueue(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchStreamQueueFunc = func(ctx context.Context, jid string) (*streamqueuemodel.Queue, error) {
+		return &streamqueuemodel.Queue{Jid: jid}, nil
+	}
+	m := &measuredStreamQueueRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchStreamQueue(context.Background(), "ortuman@jackal.im/yard")
+
+	// then
+	require.Len(t, repMock.FetchStreamQueueCalls(), 1)
+}
+
+func TestMeasuredStreamQueueRep_DeleteStreamQueue(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteStreamQueueFunc = func(ctx context.Context, jid string) error {
+		return nil
+	}
+	m := &measuredStreamQueueRep{rep: repMock}
+
+	// when
+	_ = m.DeleteStreamQueue(context.Background(), "ortuman@jackal.im/yard")
+
+	// then
+	require.Len(t, repMock.DeleteStreamQueueCalls(), 1)
+}
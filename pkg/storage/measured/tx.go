@@ -25,6 +25,11 @@ type measuredTx struct {
 	repository.Private
 	repository.Roster
 	repository.VCard
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Archive
 	repository.Locker
 }
@@ -39,6 +44,11 @@ func newMeasuredTx(tx repository.Transaction) *measuredTx {
 		Private:      &measuredPrivateRep{rep: tx, inTx: true},
 		Roster:       &measuredRosterRep{rep: tx, inTx: true},
 		VCard:        &measuredVCardRep{rep: tx, inTx: true},
+		StreamQueue:  &measuredStreamQueueRep{rep: tx, inTx: true},
+		Reaction:     &measuredReactionRep{rep: tx, inTx: true},
+		S2SQueue:     &measuredS2SQueueRep{rep: tx, inTx: true},
+		Stats:        &measuredStatsRep{rep: tx, inTx: true},
+		UnifiedPush:  &measuredUnifiedPushRep{rep: tx, inTx: true},
 		Archive:      &measuredArchiveRep{rep: tx, inTx: true},
 		Locker:       &measuredLocker{rep: tx, inTx: true},
 	}
@@ -0,0 +1,56 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"time"
+
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+type measuredUnifiedPushRep struct {
+	rep  repository.UnifiedPush
+	inTx bool
+}
+
+func (m *measuredUnifiedPushRep) UpsertUnifiedPushRegistration(ctx context.Context, reg *unifiedpushmodel.Registration) error {
+	t0 := time.Now()
+	err := m.rep.UpsertUnifiedPushRegistration(ctx, reg)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredUnifiedPushRep) FetchUnifiedPushRegistration(ctx context.Context, token string) (reg *unifiedpushmodel.Registration, err error) {
+	t0 := time.Now()
+	reg, err = m.rep.FetchUnifiedPushRegistration(ctx, token)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredUnifiedPushRep) DeleteUnifiedPushRegistration(ctx context.Context, username, application, instance string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteUnifiedPushRegistration(ctx, username, application, instance)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredUnifiedPushRep) DeleteUnifiedPushRegistrations(ctx context.Context, username string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteUnifiedPushRegistrations(ctx, username)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
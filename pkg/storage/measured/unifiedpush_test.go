@@ -0,0 +1,73 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measuredrepository
+
+import (
+	"context"
+	"testing"
+
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredUnifiedPushRep_UpsertUnifiedPushRegistration(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertUnifiedPushRegistrationFunc = func(ctx context.Context, reg *unifiedpushmodel.Registration) error {
+		return nil
+	}
+	m := &measuredUnifiedPushRep{rep: repMock}
+
+	// when
+	_ = m.UpsertUnifiedPushRegistration(context.Background(), &unifiedpushmodel.Registration{Token: "t1"})
+
+	// then
+	require.Len(t, repMock.UpsertUnifiedPushRegistrationCalls(), 1)
+}
+
+func TestMeasuredUnifiedPushRep_FetchUnifiedPushRegistration(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchUnifiedPushRegistrationFunc = func(ctx context.Context, token string) (*unifiedpushmodel.Registration, error) {
+		return &unifiedpushmodel.Registration{Token: token}, nil
+	}
+	m := &measuredUnifiedPushRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchUnifiedPushRegistration(context.Background(), "t1")
+
+	// then
+	require.Len(t, repMock.FetchUnifiedPushRegistrationCalls(), 1)
+}
+
+func TestMeasuredUnifiedPushRep_DeleteUnifiedPushRegistrations(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteUnifiedPushRegistrationFunc = func(ctx context.Context, username, application, instance string) error {
+		return nil
+	}
+	repMock.DeleteUnifiedPushRegistrationsFunc = func(ctx context.Context, username string) error {
+		return nil
+	}
+	m := &measuredUnifiedPushRep{rep: repMock}
+
+	// when
+	_ = m.DeleteUnifiedPushRegistration(context.Background(), "ortuman", "a1", "i1")
+	_ = m.DeleteUnifiedPushRegistrations(context.Background(), "ortuman")
+
+	// then
+	require.Len(t, repMock.DeleteUnifiedPushRegistrationCalls(), 1)
+	require.Len(t, repMock.DeleteUnifiedPushRegistrationsCalls(), 1)
+}
@@ -55,3 +55,10 @@ func (m *measuredUserRep) UserExists(ctx context.Context, username string) (ok b
 	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return
 }
+
+func (m *measuredUserRep) FetchUsersPendingDeletion(ctx context.Context, before time.Time) (usernames []string, err error) {
+	t0 := time.Now()
+	usernames, err = m.rep.FetchUsersPendingDeletion(ctx, before)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
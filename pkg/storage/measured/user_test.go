@@ -17,6 +17,7 @@ package measuredrepository
 import (
 	"context"
 	"testing"
+	"time"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 
@@ -83,3 +84,19 @@ func TestMeasuredUserRep_UserExists(t *testing.T) {
 	// then
 	require.Len(t, repMock.UserExistsCalls(), 1)
 }
+
+func TestMeasuredUserRep_FetchUsersPendingDeletion(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchUsersPendingDeletionFunc = func(ctx context.Context, before time.Time) ([]string, error) {
+		return []string{"ortuman"}, nil
+	}
+	m := New(repMock)
+
+	// when
+	usernames, _ := m.FetchUsersPendingDeletion(context.Background(), time.Now())
+
+	// then
+	require.Equal(t, []string{"ortuman"}, usernames)
+	require.Len(t, repMock.FetchUsersPendingDeletionCalls(), 1)
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/ortuman/jackal/pkg/storage/boltdb"
+)
+
+// Config contains in-memory repository configuration.
+type Config struct {
+	// SnapshotPath defines the file where data is persisted on shutdown and restored from on startup.
+	// If empty, all data is lost once the server stops.
+	SnapshotPath string `fig:"snapshot_path"`
+
+	// SnapshotInterval defines how often data is additionally persisted while running.
+	// A zero value means data is only persisted on shutdown.
+	SnapshotInterval time.Duration `fig:"snapshot_interval"`
+}
+
+// Repository represents an ephemeral repository implementation, intended for tests, demos and throwaway servers.
+//
+// Data lives in a BoltDB database file placed into the system temporary directory, which is removed when the
+// repository is stopped, so that every repository operation behaves exactly as the persistent BoltDB implementation.
+type Repository struct {
+	*boltdb.Repository
+
+	cfg    Config
+	dbPath string
+	logger kitlog.Logger
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New creates and returns an initialized in-memory Repository instance.
+func New(cfg Config, logger kitlog.Logger) *Repository {
+	dbPath := filepath.Join(os.TempDir(), fmt.Sprintf("jackal-%s.db", uuid.New().String()))
+	return &Repository{
+		Repository: boltdb.New(boltdb.Config{Path: dbPath}, logger),
+		cfg:        cfg,
+		dbPath:     dbPath,
+		logger:     logger,
+	}
+}
+
+// Start implements Start interface method.
+func (r *Repository) Start(ctx context.Context) error {
+	if len(r.cfg.SnapshotPath) > 0 {
+		restored, err := restoreSnapshot(r.cfg.SnapshotPath, r.dbPath)
+		if err != nil {
+			return err
+		}
+		if restored {
+			level.Info(r.logger).Log("msg", "restored in-memory repository snapshot", "path", r.cfg.SnapshotPath)
+		}
+	}
+	if err := r.Repository.Start(ctx); err != nil {
+		return err
+	}
+	if len(r.cfg.SnapshotPath) > 0 && r.cfg.SnapshotInterval > 0 {
+		r.stopCh = make(chan struct{})
+		r.doneCh = make(chan struct{})
+		go r.loop()
+	}
+	level.Info(r.logger).Log("msg", "started in-memory repository")
+	return nil
+}
+
+// Stop implements Stop interface method.
+func (r *Repository) Stop(ctx context.Context) error {
+	if r.stopCh != nil {
+		close(r.stopCh)
+		<-r.doneCh
+	}
+	if len(r.cfg.SnapshotPath) > 0 {
+		if err := r.Snapshot(r.cfg.SnapshotPath); err != nil {
+			return err
+		}
+		level.Info(r.logger).Log("msg", "saved in-memory repository snapshot", "path", r.cfg.SnapshotPath)
+	}
+	if err := r.Repository.Stop(ctx); err != nil {
+		return err
+	}
+	if err := os.Remove(r.dbPath); err != nil {
+		return err
+	}
+	level.Info(r.logger).Log("msg", "stopped in-memory repository")
+	return nil
+}
+
+func (r *Repository) loop() {
+	defer close(r.doneCh)
+
+	tc := time.NewTicker(r.cfg.SnapshotInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			if err := r.Snapshot(r.cfg.SnapshotPath); err != nil {
+				level.Warn(r.logger).Log("msg", "failed to save in-memory repository snapshot", "err", err)
+			}
+
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func restoreSnapshot(snapshotPath, dbPath string) (bool, error) {
+	src, err := os.Open(snapshotPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(dbPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return false, err
+	}
+	return true, dst.Close()
+}
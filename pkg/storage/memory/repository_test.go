@@ -0,0 +1,70 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryrepository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Ephemeral(t *testing.T) {
+	// given
+	r := New(Config{}, kitlog.NewNopLogger())
+	require.NoError(t, r.Start(context.Background()))
+
+	// when
+	err := r.UpsertUser(context.Background(), &usermodel.User{Username: "ortuman"})
+	require.NoError(t, err)
+
+	usr, err := r.FetchUser(context.Background(), "ortuman")
+	require.NoError(t, err)
+
+	require.NoError(t, r.Stop(context.Background()))
+
+	// then
+	require.NotNil(t, usr)
+	require.Equal(t, "ortuman", usr.Username)
+
+	_, err = os.Stat(r.dbPath)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRepository_Snapshot(t *testing.T) {
+	// given
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.db")
+
+	r1 := New(Config{SnapshotPath: snapshotPath}, kitlog.NewNopLogger())
+	require.NoError(t, r1.Start(context.Background()))
+	require.NoError(t, r1.UpsertUser(context.Background(), &usermodel.User{Username: "ortuman"}))
+	require.NoError(t, r1.Stop(context.Background()))
+
+	// when
+	r2 := New(Config{SnapshotPath: snapshotPath}, kitlog.NewNopLogger())
+	require.NoError(t, r2.Start(context.Background()))
+	defer func() { _ = r2.Stop(context.Background()) }()
+
+	usr, err := r2.FetchUser(context.Background(), "ortuman")
+
+	// then
+	require.NoError(t, err)
+	require.NotNil(t, usr)
+	require.Equal(t, "ortuman", usr.Username)
+}
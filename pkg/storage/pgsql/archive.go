@@ -27,11 +27,15 @@ import (
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+	jidutil "github.com/ortuman/jackal/pkg/util/jid"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
-	archiveTableName = "archives"
+	archiveTableName       = "archives"
+	archivePrefsTableName  = "archive_prefs"
+	archiveQuotasTableName = "archive_quotas"
 
 	archiveStampFormat = "2006-01-02T15:04:05Z"
 )
@@ -41,29 +45,69 @@ type pgSQLArchiveRep struct {
 	logger kitlog.Logger
 }
 
-func (r *pgSQLArchiveRep) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) error {
+func (r *pgSQLArchiveRep) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) (bool, error) {
 	b, err := proto.Marshal(message.Message)
 	if err != nil {
-		return err
+		return false, err
 	}
-	fromJID, _ := jid.NewWithString(message.FromJid, true)
-	toJID, _ := jid.NewWithString(message.ToJid, true)
+	fromJID, _ := jidutil.Parse(message.FromJid, true)
+	toJID, _ := jidutil.Parse(message.ToJid, true)
 
+	// keep message body apart to allow full-text searches
+	var body string
+	if msg, err := stravaganza.NewBuilderFromProto(message.Message).BuildMessage(); err == nil {
+		body = xmpputil.MessageBody(msg)
+	}
+	columns := []string{"archive_id", "id", `"from"`, "from_bare", `"to"`, "to_bare", "message", "body", "origin_id"}
+	values := []interface{}{
+		message.ArchiveId,
+		message.Id,
+		fromJID.String(),
+		fromJID.ToBareJID().String(),
+		toJID.String(),
+		toJID.ToBareJID().String(),
+		b,
+		body,
+		sql.NullString{String: message.OriginId, Valid: len(message.OriginId) > 0},
+	}
+	// preserve original archiving time (i.e. imported messages)
+	if message.Stamp != nil {
+		columns = append(columns, "created_at")
+		values = append(values, message.Stamp.AsTime())
+	}
 	q := sq.Insert(archiveTableName).
 		Prefix(noLoadBalancePrefix).
-		Columns("archive_id", "id", `"from"`, "from_bare", `"to"`, "to_bare", "message").
-		Values(
-			message.ArchiveId,
-			message.Id,
-			fromJID.String(),
-			fromJID.ToBareJID().String(),
-			toJID.String(),
-			toJID.ToBareJID().String(),
-			b,
-		)
+		Columns(columns...).
+		Values(values...).
+		Suffix("ON CONFLICT (archive_id, origin_id) WHERE origin_id IS NOT NULL DO NOTHING")
 
-	_, err = q.RunWith(r.conn).ExecContext(ctx)
-	return err
+	res, err := q.RunWith(r.conn).ExecContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *pgSQLArchiveRep) FetchArchiveMessageByOriginID(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error) {
+	q := sq.Select("id", `"from"`, `"to"`, "message", "created_at").
+		From(archiveTableName).
+		Where(sq.And{sq.Eq{"archive_id": archiveID}, sq.Eq{"origin_id": originID}}).
+		PlaceholderFormat(sq.Dollar)
+
+	msg, err := scanArchiveMessage(q.RunWith(r.conn).QueryRowContext(ctx), archiveID)
+	switch err {
+	case nil:
+		msg.OriginId = originID
+		return msg, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
 }
 
 func (r *pgSQLArchiveRep) FetchArchiveMetadata(ctx context.Context, archiveID string) (*archivemodel.Metadata, error) {
@@ -99,13 +143,48 @@ func (r *pgSQLArchiveRep) FetchArchiveMetadata(ctx context.Context, archiveID st
 	}
 }
 
-func (r *pgSQLArchiveRep) FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error) {
+func (r *pgSQLArchiveRep) FetchArchiveIDs(ctx context.Context) ([]string, error) {
+	q := sq.Select("archive_id").
+		Distinct().
+		From(archiveTableName).
+		OrderBy("archive_id")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []string
+	for rows.Next() {
+		var archiveID string
+		if err := rows.Scan(&archiveID); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, archiveID)
+	}
+	return retVal, rows.Err()
+}
+
+func (r *pgSQLArchiveRep) FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error) {
+	pred, err := filtersToPred(f, archiveID)
+	if err != nil {
+		return nil, err
+	}
 	q := sq.Select("id", `"from"`, `"to"`, "message", "created_at").
 		From(archiveTableName).
-		Where(filtersToPred(f, archiveID)).
-		OrderBy("created_at").
+		Where(pred).
+		OrderBy("created_at", "serial").
 		PlaceholderFormat(sq.Dollar)
 
+	if page != nil {
+		if page.Offset > 0 {
+			q = q.Offset(uint64(page.Offset))
+		}
+		if page.Limit > 0 {
+			q = q.Limit(uint64(page.Limit))
+		}
+	}
 	rows, err := q.RunWith(r.conn).QueryContext(ctx)
 	if err != nil {
 		return nil, err
@@ -119,17 +198,74 @@ func (r *pgSQLArchiveRep) FetchArchiveMessages(ctx context.Context, f *archivemo
 	return retVal, err
 }
 
+func (r *pgSQLArchiveRep) CountArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error) {
+	pred, err := filtersToPred(f, archiveID)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+
+	q := sq.Select("COUNT(*)").
+		From(archiveTableName).
+		Where(pred).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := q.RunWith(r.conn).QueryRowContext(ctx).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *pgSQLArchiveRep) DeleteArchiveOldestMessages(ctx context.Context, archiveID string, maxElements int) error {
 	q := sq.Delete(archiveTableName).
 		Prefix(noLoadBalancePrefix).
 		Where(sq.And{
 			sq.Eq{"archive_id": archiveID},
-			sq.Expr(`"id" NOT IN (SELECT "id" FROM archives WHERE archive_id = $2 ORDER BY created_at DESC LIMIT $3 OFFSET 0)`, archiveID, maxElements),
+			sq.Expr(`"id" NOT IN (SELECT "id" FROM archives WHERE archive_id = $2 ORDER BY created_at DESC, serial DESC LIMIT $3 OFFSET 0)`, archiveID, maxElements),
 		})
 	_, err := q.RunWith(r.conn).ExecContext(ctx)
 	return err
 }
 
+func (r *pgSQLArchiveRep) DeleteArchiveMessagesBefore(ctx context.Context, host string, before time.Time) (int, error) {
+	// archive owner bare JID is either message sender or recipient
+	q := sq.Delete(archiveTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{
+			sq.Lt{"created_at": before},
+			sq.Expr("(from_bare = archive_id || '@' || ? OR to_bare = archive_id || '@' || ?)", host, host),
+		})
+	res, err := q.RunWith(r.conn).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (r *pgSQLArchiveRep) DeleteArchiveMessagesWith(ctx context.Context, archiveID, with string) (int, error) {
+	pred, err := filtersToPred(&archivemodel.Filters{With: with}, archiveID)
+	if err != nil {
+		return 0, err
+	}
+	q := sq.Delete(archiveTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(pred)
+
+	res, err := q.RunWith(r.conn).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
 func (r *pgSQLArchiveRep) DeleteArchive(ctx context.Context, archiveID string) error {
 	q := sq.Delete(archiveTableName).
 		Prefix(noLoadBalancePrefix).
@@ -138,6 +274,141 @@ func (r *pgSQLArchiveRep) DeleteArchive(ctx context.Context, archiveID string) e
 	return err
 }
 
+func (r *pgSQLArchiveRep) UpsertArchivePrefs(ctx context.Context, prefs *archivemodel.Prefs) error {
+	b, err := proto.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	q := sq.Insert(archivePrefsTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("archive_id", "prefs").
+		Values(prefs.ArchiveId, b).
+		Suffix("ON CONFLICT (archive_id) DO UPDATE SET prefs = $2")
+
+	_, err = q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) FetchArchivePrefs(ctx context.Context, archiveID string) (*archivemodel.Prefs, error) {
+	q := sq.Select("prefs").
+		From(archivePrefsTableName).
+		Where(sq.Eq{"archive_id": archiveID})
+
+	var b []byte
+	err := q.RunWith(r.conn).
+		QueryRowContext(ctx).
+		Scan(&b)
+	switch err {
+	case nil:
+		var prefs archivemodel.Prefs
+		if err := proto.Unmarshal(b, &prefs); err != nil {
+			return nil, err
+		}
+		return &prefs, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *pgSQLArchiveRep) DeleteArchivePrefs(ctx context.Context, archiveID string) error {
+	q := sq.Delete(archivePrefsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Eq{"archive_id": archiveID})
+	_, err := q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) DeleteArchiveExpiredMessages(ctx context.Context, archiveID string, before time.Time) error {
+	q := sq.Delete(archiveTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{
+			sq.Eq{"archive_id": archiveID},
+			sq.Lt{"created_at": before},
+		})
+	_, err := q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) UpsertArchiveQuota(ctx context.Context, quota *archivemodel.Quota) error {
+	b, err := proto.Marshal(quota)
+	if err != nil {
+		return err
+	}
+	q := sq.Insert(archiveQuotasTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("archive_id", "host", "quota").
+		Values(quota.ArchiveId, quota.Host, b).
+		Suffix("ON CONFLICT (archive_id, host) DO UPDATE SET quota = $3")
+
+	_, err = q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) FetchArchiveQuota(ctx context.Context, archiveID, host string) (*archivemodel.Quota, error) {
+	q := sq.Select("quota").
+		From(archiveQuotasTableName).
+		Where(sq.And{
+			sq.Eq{"archive_id": archiveID},
+			sq.Eq{"host": host},
+		})
+
+	var b []byte
+	err := q.RunWith(r.conn).
+		QueryRowContext(ctx).
+		Scan(&b)
+	switch err {
+	case nil:
+		var quota archivemodel.Quota
+		if err := proto.Unmarshal(b, &quota); err != nil {
+			return nil, err
+		}
+		return &quota, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *pgSQLArchiveRep) FetchArchiveQuotas(ctx context.Context) ([]*archivemodel.Quota, error) {
+	q := sq.Select("quota").
+		From(archiveQuotasTableName).
+		OrderBy("host", "archive_id")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []*archivemodel.Quota
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var quota archivemodel.Quota
+		if err := proto.Unmarshal(b, &quota); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, &quota)
+	}
+	return retVal, rows.Err()
+}
+
+func (r *pgSQLArchiveRep) DeleteArchiveQuota(ctx context.Context, archiveID, host string) error {
+	q := sq.Delete(archiveQuotasTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{
+			sq.Eq{"archive_id": archiveID},
+			sq.Eq{"host": host},
+		})
+	_, err := q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
 func filtersToPred(f *archivemodel.Filters, archiveID string) (interface{}, error) {
 	pred := sq.And{
 		sq.Eq{"archive_id": archiveID},
@@ -157,15 +428,20 @@ func filtersToPred(f *archivemodel.Filters, archiveID string) (interface{}, erro
 		}
 	}
 
+	// filtering by body content
+	if len(f.FullTextSearch) > 0 {
+		pred = append(pred, sq.Expr(`to_tsvector('simple', body) @@ plainto_tsquery('simple', ?)`, f.FullTextSearch))
+	}
+
 	// filtering by id
 	if len(f.Ids) > 0 {
 		pred = append(pred, sq.Eq{"id": f.Ids})
 	} else {
 		if len(f.BeforeId) > 0 {
-			pred = append(pred, sq.Expr(`(serial < (SELECT serial FROM archives WHERE "id" = ? AND archive_id = ?))`, f.BeforeId, archiveID))
+			pred = append(pred, beforeIDPred(f.BeforeId, archiveID))
 		}
 		if len(f.AfterId) > 0 {
-			pred = append(pred, sq.Expr(`(serial > (SELECT serial FROM archives WHERE "id" = ? AND archive_id = ?))`, f.AfterId, archiveID))
+			pred = append(pred, afterIDPred(f.AfterId, archiveID))
 		}
 	}
 
@@ -183,6 +459,19 @@ func filtersToPred(f *archivemodel.Filters, archiveID string) (interface{}, erro
 	return pred, nil
 }
 
+// beforeIDPred returns the predicate matching archive messages preceding id.
+//
+// Messages are paged by their (created_at, serial) position rather than by comparing ids, since neither legacy
+// UUID nor imported message ids are guaranteed to sort in archive order.
+func beforeIDPred(id, archiveID string) sq.Sqlizer {
+	return sq.Expr(`((created_at, serial) < (SELECT created_at, serial FROM archives WHERE "id" = ? AND archive_id = ?))`, id, archiveID)
+}
+
+// afterIDPred returns the predicate matching archive messages following id.
+func afterIDPred(id, archiveID string) sq.Sqlizer {
+	return sq.Expr(`((created_at, serial) > (SELECT created_at, serial FROM archives WHERE "id" = ? AND archive_id = ?))`, id, archiveID)
+}
+
 func scanArchiveMessages(scanner rowsScanner, archiveID string) ([]*archivemodel.Message, error) {
 	var ret []*archivemodel.Message
 	for scanner.Next() {
@@ -195,7 +484,7 @@ func scanArchiveMessages(scanner rowsScanner, archiveID string) ([]*archivemodel
 	return ret, nil
 }
 
-func scanArchiveMessage(scanner rowsScanner, archiveID string) (*archivemodel.Message, error) {
+func scanArchiveMessage(scanner rowScanner, archiveID string) (*archivemodel.Message, error) {
 	var ret archivemodel.Message
 
 	var b []byte
@@ -46,22 +46,125 @@ func TestPgSQLArchive_InsertArchiveMessage(t *testing.T) {
 		FromJid:   "ortuman@jackal.im/local",
 		ToJid:     "ortuman@jabber.org/remote",
 		Message:   msg.Proto(),
+		OriginId:  "origin1234",
 	}
 	msgBytes, _ := proto.Marshal(aMsg.Message)
 
 	s, mock := newArchiveMock()
-	mock.ExpectExec(`INSERT INTO archives \(archive_id,id,"from",from_bare,"to",to_bare,message\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\)`).
-		WithArgs("ortuman", "id1234", "ortuman@jackal.im/local", "ortuman@jackal.im", "ortuman@jabber.org/remote", "ortuman@jabber.org", msgBytes).
+	mock.ExpectExec(`INSERT INTO archives \(archive_id,id,"from",from_bare,"to",to_bare,message,body,origin_id\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\) ON CONFLICT \(archive_id, origin_id\) WHERE origin_id IS NOT NULL DO NOTHING`).
+		WithArgs("ortuman", "id1234", "ortuman@jackal.im/local", "ortuman@jackal.im", "ortuman@jabber.org/remote", "ortuman@jabber.org", msgBytes, "I'll give thee a wind.", "origin1234").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// when
-	err := s.InsertArchiveMessage(context.Background(), aMsg)
+	inserted, err := s.InsertArchiveMessage(context.Background(), aMsg)
 
 	// then
 	require.Nil(t, err)
+	require.True(t, inserted)
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLArchive_InsertArchiveMessageDuplicatedOriginID(t *testing.T) {
+	// given
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute("from", "noelia@jackal.im/yard").
+		WithAttribute("to", "ortuman@jackal.im/balcony").
+		BuildMessage()
+
+	aMsg := &archivemodel.Message{
+		ArchiveId: "ortuman",
+		Id:        "id1234",
+		FromJid:   "ortuman@jackal.im/local",
+		ToJid:     "ortuman@jabber.org/remote",
+		Message:   msg.Proto(),
+		OriginId:  "origin1234",
+	}
+	msgBytes, _ := proto.Marshal(aMsg.Message)
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`INSERT INTO archives \(archive_id,id,"from",from_bare,"to",to_bare,message,body,origin_id\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\) ON CONFLICT \(archive_id, origin_id\) WHERE origin_id IS NOT NULL DO NOTHING`).
+		WithArgs("ortuman", "id1234", "ortuman@jackal.im/local", "ortuman@jackal.im", "ortuman@jabber.org/remote", "ortuman@jabber.org", msgBytes, "", "origin1234").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// when
+	inserted, err := s.InsertArchiveMessage(context.Background(), aMsg)
+
+	// then
+	require.Nil(t, err)
+	require.False(t, inserted)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_InsertArchiveMessageWithStamp(t *testing.T) {
+	// given
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute("from", "noelia@jackal.im/yard").
+		WithAttribute("to", "ortuman@jackal.im/balcony").
+		BuildMessage()
+
+	stamp := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	aMsg := &archivemodel.Message{
+		ArchiveId: "ortuman",
+		Id:        "id1234",
+		FromJid:   "ortuman@jackal.im/local",
+		ToJid:     "ortuman@jabber.org/remote",
+		Message:   msg.Proto(),
+		Stamp:     timestamppb.New(stamp),
+	}
+	msgBytes, _ := proto.Marshal(aMsg.Message)
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`INSERT INTO archives \(archive_id,id,"from",from_bare,"to",to_bare,message,body,origin_id,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10\) ON CONFLICT \(archive_id, origin_id\) WHERE origin_id IS NOT NULL DO NOTHING`).
+		WithArgs("ortuman", "id1234", "ortuman@jackal.im/local", "ortuman@jackal.im", "ortuman@jabber.org/remote", "ortuman@jabber.org", msgBytes, "", nil, stamp).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	inserted, err := s.InsertArchiveMessage(context.Background(), aMsg)
+
+	// then
+	require.Nil(t, err)
+	require.True(t, inserted)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_FetchArchiveMessageByOriginID(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "ortuman@jackal.im/local")
+	b.WithAttribute("to", "noelia@jackal.im/yard")
+	msg, _ := b.BuildMessage()
+	msgBytes, _ := proto.Marshal(msg.Proto())
+
+	stamp := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND origin_id = \$2\)`).
+		WithArgs("ortuman", "origin1234").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "from", "to", "message", "created_at"}).
+				AddRow("id1234", "ortuman@jackal.im/local", "noelia@jackal.im/yard", msgBytes, stamp),
+		)
+	mock.ExpectQuery(`SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND origin_id = \$2\)`).
+		WithArgs("ortuman", "origin5678").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from", "to", "message", "created_at"}))
+
+	// when
+	aMsg, err1 := s.FetchArchiveMessageByOriginID(context.Background(), "ortuman", "origin1234")
+	noMsg, err2 := s.FetchArchiveMessageByOriginID(context.Background(), "ortuman", "origin5678")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+
+	require.Nil(t, err1)
+	require.NotNil(t, aMsg)
+	require.Equal(t, "id1234", aMsg.Id)
+	require.Equal(t, "origin1234", aMsg.OriginId)
+	require.Equal(t, stamp, aMsg.Stamp.AsTime())
+
+	require.Nil(t, err2)
+	require.Nil(t, noMsg)
+}
+
 func TestPgSQLArchive_FetchArchiveMetadata(t *testing.T) {
 	minT := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
 	maxT := time.Date(2022, 12, 12, 00, 00, 00, 00, time.UTC)
@@ -89,59 +192,89 @@ func TestPgSQLArchive_FetchArchiveMetadata(t *testing.T) {
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLArchive_FetchArchiveIDs(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT DISTINCT archive_id FROM archives ORDER BY archive_id`).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"archive_id"}).AddRow("noelia").AddRow("ortuman"),
+		)
+
+	// when
+	archiveIDs, err := s.FetchArchiveIDs(context.Background())
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+
+	require.Nil(t, err)
+	require.Equal(t, []string{"noelia", "ortuman"}, archiveIDs)
+}
+
 func TestPgSQLArchive_FetchArchiveMessages(t *testing.T) {
 	starTm := time.Date(2022, time.July, 6, 14, 7, 43, 167051000, time.UTC)
 	endTm := time.Date(2023, time.July, 7, 15, 7, 43, 167051000, time.UTC)
 
 	tcs := map[string]struct {
 		filters     *archivemodel.Filters
+		page        *archivemodel.Page
 		withArgs    []driver.Value
 		expectQuery string
 	}{
 		"by bare jid": {
 			filters:     &archivemodel.Filters{With: "noelia@jackal.im"},
 			withArgs:    []driver.Value{"ortuman", "noelia@jackal.im", "noelia@jackal.im"},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(to_bare = \$2 OR from_bare = \$3\)\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(to_bare = \$2 OR from_bare = \$3\)\) ORDER BY created_at, serial`,
 		},
 		"by full jid": {
 			filters:     &archivemodel.Filters{With: "noelia@jackal.im/yard"},
 			withArgs:    []driver.Value{"ortuman", "noelia@jackal.im/yard", "noelia@jackal.im/yard"},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \("to" = \$2 OR "from" = \$3\)\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \("to" = \$2 OR "from" = \$3\)\) ORDER BY created_at, serial`,
+		},
+		"by full text search": {
+			filters:     &archivemodel.Filters{FullTextSearch: "give wind"},
+			withArgs:    []driver.Value{"ortuman", "give wind"},
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND to_tsvector\('simple', body\) @@ plainto_tsquery\('simple', \$2\)\) ORDER BY created_at, serial`,
+		},
+		"by page": {
+			filters:     &archivemodel.Filters{With: "noelia@jackal.im"},
+			page:        &archivemodel.Page{Offset: 20, Limit: 10},
+			withArgs:    []driver.Value{"ortuman", "noelia@jackal.im", "noelia@jackal.im"},
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(to_bare = \$2 OR from_bare = \$3\)\) ORDER BY created_at, serial LIMIT 10 OFFSET 20`,
 		},
 		"by ids": {
 			filters:     &archivemodel.Filters{Ids: []string{"id1234", "id5678"}},
 			withArgs:    []driver.Value{"ortuman", "id1234", "id5678"},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND id IN \(\$2,\$3\)\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND id IN \(\$2,\$3\)\) ORDER BY created_at, serial`,
 		},
 		"by before id": {
 			filters:     &archivemodel.Filters{BeforeId: "id1234"},
 			withArgs:    []driver.Value{"ortuman", "id1234", "ortuman"},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(serial < \(SELECT serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\)\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(\(created_at, serial\) < \(SELECT created_at, serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\)\) ORDER BY created_at, serial`,
 		},
 		"by after id": {
 			filters:     &archivemodel.Filters{AfterId: "id1234"},
 			withArgs:    []driver.Value{"ortuman", "id1234", "ortuman"},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(serial > \(SELECT serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\)\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(\(created_at, serial\) > \(SELECT created_at, serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\)\) ORDER BY created_at, serial`,
 		},
 		"by before and after id": {
 			filters:     &archivemodel.Filters{BeforeId: "id1234", AfterId: "id5678"},
 			withArgs:    []driver.Value{"ortuman", "id1234", "ortuman", "id5678", "ortuman"},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(serial < \(SELECT serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\) AND \(serial > \(SELECT serial FROM archives WHERE "id" = \$4 AND archive_id = \$5\)\)\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(\(created_at, serial\) < \(SELECT created_at, serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\) AND \(\(created_at, serial\) > \(SELECT created_at, serial FROM archives WHERE "id" = \$4 AND archive_id = \$5\)\)\) ORDER BY created_at, serial`,
 		},
 		"by start timestamp": {
 			filters:     &archivemodel.Filters{Start: timestamppb.New(starTm)},
 			withArgs:    []driver.Value{"ortuman", toEpoch(timestamppb.New(starTm)) + float64(time.Millisecond)},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND EXTRACT\(epoch FROM created_at\) > \$2\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND EXTRACT\(epoch FROM created_at\) > \$2\) ORDER BY created_at, serial`,
 		},
 		"by end timestamp": {
 			filters:     &archivemodel.Filters{End: timestamppb.New(endTm)},
 			withArgs:    []driver.Value{"ortuman", toEpoch(timestamppb.New(endTm))},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND EXTRACT\(epoch FROM created_at\) < \$2\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND EXTRACT\(epoch FROM created_at\) < \$2\) ORDER BY created_at, serial`,
 		},
 		"by start and end timestamp": {
 			filters:     &archivemodel.Filters{Start: timestamppb.New(starTm), End: timestamppb.New(endTm)},
 			withArgs:    []driver.Value{"ortuman", toEpoch(timestamppb.New(starTm)) + float64(time.Millisecond), toEpoch(timestamppb.New(endTm))},
-			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND EXTRACT\(epoch FROM created_at\) > \$2 AND EXTRACT\(epoch FROM created_at\) < \$3\) ORDER BY created_at`,
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND EXTRACT\(epoch FROM created_at\) > \$2 AND EXTRACT\(epoch FROM created_at\) < \$3\) ORDER BY created_at, serial`,
 		},
 	}
 	for tn, tc := range tcs {
@@ -168,7 +301,7 @@ func TestPgSQLArchive_FetchArchiveMessages(t *testing.T) {
 				WillReturnRows(rows)
 
 			// when
-			messages, err := s.FetchArchiveMessages(context.Background(), tc.filters, "ortuman")
+			messages, err := s.FetchArchiveMessages(context.Background(), tc.filters, tc.page, "ortuman")
 
 			require.NoError(t, err)
 			require.Nil(t, mock.ExpectationsWereMet())
@@ -181,10 +314,41 @@ func TestPgSQLArchive_FetchArchiveMessages(t *testing.T) {
 	}
 }
 
+func TestPgSQLArchive_CountArchiveMessages(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM archives WHERE \(archive_id = \$1 AND \(to_bare = \$2 OR from_bare = \$3\)\)`).
+		WithArgs("ortuman", "noelia@jackal.im", "noelia@jackal.im").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(42),
+		)
+
+	// when
+	c, err := s.CountArchiveMessages(context.Background(), &archivemodel.Filters{With: "noelia@jackal.im"}, "ortuman")
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, 42, c)
+
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_CountArchiveMessagesInvalidFilter(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+
+	// when
+	_, err := s.CountArchiveMessages(context.Background(), &archivemodel.Filters{With: "@jackal.im"}, "ortuman")
+
+	// then
+	require.Error(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
 func TestPgSQLArchive_DeleteArchiveOldestMessages(t *testing.T) {
 	// given
 	s, mock := newArchiveMock()
-	mock.ExpectExec(`DELETE FROM archives WHERE \(archive_id = \$1 AND "id" NOT IN \(SELECT "id" FROM archives WHERE archive_id = \$2 ORDER BY created_at DESC LIMIT \$3 OFFSET 0\)\)`).
+	mock.ExpectExec(`DELETE FROM archives WHERE \(archive_id = \$1 AND "id" NOT IN \(SELECT "id" FROM archives WHERE archive_id = \$2 ORDER BY created_at DESC, serial DESC LIMIT \$3 OFFSET 0\)\)`).
 		WithArgs("ortuman", "ortuman", 1234).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -211,6 +375,216 @@ func TestPgSQLArchive_DeleteArchive(t *testing.T) {
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLArchive_DeleteArchiveMessagesBefore(t *testing.T) {
+	// given
+	before := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`DELETE FROM archives WHERE \(created_at < \$1 AND \(from_bare = archive_id \|\| '@' \|\| \$2 OR to_bare = archive_id \|\| '@' \|\| \$3\)\)`).
+		WithArgs(before, "jackal.im", "jackal.im").
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	// when
+	n, err := s.DeleteArchiveMessagesBefore(context.Background(), "jackal.im", before)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Equal(t, 5, n)
+}
+
+func TestPgSQLArchive_DeleteArchiveMessagesWith(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`DELETE FROM archives WHERE \(archive_id = \$1 AND \(to_bare = \$2 OR from_bare = \$3\)\)`).
+		WithArgs("ortuman", "noelia@jackal.im", "noelia@jackal.im").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// when
+	n, err := s.DeleteArchiveMessagesWith(context.Background(), "ortuman", "noelia@jackal.im")
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Equal(t, 3, n)
+}
+
+func TestPgSQLArchive_UpsertArchivePrefs(t *testing.T) {
+	// given
+	prefs := &archivemodel.Prefs{
+		ArchiveId:       "ortuman",
+		DefaultBehavior: "roster",
+		Never:           []string{"noelia@jackal.im"},
+	}
+	b, _ := proto.Marshal(prefs)
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`INSERT INTO archive_prefs \(archive_id,prefs\) VALUES \(\$1,\$2\) ON CONFLICT \(archive_id\) DO UPDATE SET prefs = \$2`).
+		WithArgs("ortuman", b).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.UpsertArchivePrefs(context.Background(), prefs)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_FetchArchivePrefs(t *testing.T) {
+	// given
+	prefs := &archivemodel.Prefs{
+		ArchiveId:       "ortuman",
+		DefaultBehavior: "always",
+		Never:           []string{"noelia@jackal.im"},
+	}
+	b, _ := proto.Marshal(prefs)
+
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT prefs FROM archive_prefs WHERE archive_id = \$1`).
+		WithArgs("ortuman").
+		WillReturnRows(sqlmock.NewRows([]string{"prefs"}).AddRow(b))
+
+	mock.ExpectQuery(`SELECT prefs FROM archive_prefs WHERE archive_id = \$1`).
+		WithArgs("noelia").
+		WillReturnRows(sqlmock.NewRows([]string{"prefs"}))
+
+	// when
+	p0, err0 := s.FetchArchivePrefs(context.Background(), "ortuman")
+	p1, err1 := s.FetchArchivePrefs(context.Background(), "noelia")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+
+	require.Nil(t, err0)
+	require.True(t, proto.Equal(prefs, p0))
+
+	require.Nil(t, err1)
+	require.Nil(t, p1)
+}
+
+func TestPgSQLArchive_DeleteArchivePrefs(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`DELETE FROM archive_prefs WHERE archive_id = \$1`).
+		WithArgs("ortuman").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteArchivePrefs(context.Background(), "ortuman")
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_DeleteArchiveExpiredMessages(t *testing.T) {
+	// given
+	before := time.Date(2022, time.July, 6, 14, 7, 43, 0, time.UTC)
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`DELETE FROM archives WHERE \(archive_id = \$1 AND created_at < \$2\)`).
+		WithArgs("ortuman", before).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+
+	// when
+	err := s.DeleteArchiveExpiredMessages(context.Background(), "ortuman", before)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_UpsertArchiveQuota(t *testing.T) {
+	// given
+	quota := &archivemodel.Quota{
+		ArchiveId: "ortuman",
+		QueueSize: 5000,
+	}
+	b, _ := proto.Marshal(quota)
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`INSERT INTO archive_quotas \(archive_id,host,quota\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \(archive_id, host\) DO UPDATE SET quota = \$3`).
+		WithArgs("ortuman", "", b).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.UpsertArchiveQuota(context.Background(), quota)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_FetchArchiveQuota(t *testing.T) {
+	// given
+	quota := &archivemodel.Quota{
+		Host:          "jackal.im",
+		MaxAgeSeconds: 3600,
+	}
+	b, _ := proto.Marshal(quota)
+
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT quota FROM archive_quotas WHERE \(archive_id = \$1 AND host = \$2\)`).
+		WithArgs("", "jackal.im").
+		WillReturnRows(sqlmock.NewRows([]string{"quota"}).AddRow(b))
+
+	mock.ExpectQuery(`SELECT quota FROM archive_quotas WHERE \(archive_id = \$1 AND host = \$2\)`).
+		WithArgs("noelia", "").
+		WillReturnRows(sqlmock.NewRows([]string{"quota"}))
+
+	// when
+	q0, err0 := s.FetchArchiveQuota(context.Background(), "", "jackal.im")
+	q1, err1 := s.FetchArchiveQuota(context.Background(), "noelia", "")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+
+	require.Nil(t, err0)
+	require.True(t, proto.Equal(quota, q0))
+
+	require.Nil(t, err1)
+	require.Nil(t, q1)
+}
+
+func TestPgSQLArchive_FetchArchiveQuotas(t *testing.T) {
+	// given
+	quota := &archivemodel.Quota{
+		ArchiveId: "ortuman",
+		QueueSize: 5000,
+	}
+	b, _ := proto.Marshal(quota)
+
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT quota FROM archive_quotas ORDER BY host, archive_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"quota"}).AddRow(b))
+
+	// when
+	quotas, err := s.FetchArchiveQuotas(context.Background())
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+
+	require.Nil(t, err)
+	require.Len(t, quotas, 1)
+	require.True(t, proto.Equal(quota, quotas[0]))
+}
+
+func TestPgSQLArchive_DeleteArchiveQuota(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`DELETE FROM archive_quotas WHERE \(archive_id = \$1 AND host = \$2\)`).
+		WithArgs("ortuman", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteArchiveQuota(context.Background(), "ortuman", "")
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
 func newArchiveMock() (*pgSQLArchiveRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
 	return &pgSQLArchiveRep{conn: s}, sqlMock
@@ -0,0 +1,212 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// connector creates PgSQL connections using the latest known credentials.
+// Whenever credentials files are rotated, connections established with the previous ones
+// are reported as invalid so that the pool discards them as soon as they're released.
+type connector struct {
+	cfg Config
+
+	mu      sync.RWMutex
+	dsn     string
+	gen     uint64
+	modTime time.Time
+}
+
+func newConnector(cfg Config) *connector {
+	return &connector{
+		cfg: cfg,
+		dsn: makeDSN(cfg, cfg.User, cfg.Password),
+	}
+}
+
+// Connect implements driver.Connector interface.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if _, err := c.refresh(); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	dsn, gen := c.dsn, c.gen
+	c.mu.RUnlock()
+
+	pqConnector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cn, err := pqConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rotatingConn{Conn: cn, gen: gen, connector: c}, nil
+}
+
+// Driver implements driver.Connector interface.
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// refresh reloads credentials in case any of the credentials files changed,
+// returning true if credentials have been rotated.
+func (c *connector) refresh() (bool, error) {
+	files := c.credentialsFiles()
+	if len(files) == 0 {
+		return false, nil
+	}
+	var modTime time.Time
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return false, err
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+	}
+	c.mu.RLock()
+	unchanged := modTime.Equal(c.modTime)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	user, password := c.cfg.User, c.cfg.Password
+	if len(c.cfg.UserFile) > 0 {
+		u, err := readCredential(c.cfg.UserFile)
+		if err != nil {
+			return false, err
+		}
+		user = u
+	}
+	if len(c.cfg.PasswordFile) > 0 {
+		p, err := readCredential(c.cfg.PasswordFile)
+		if err != nil {
+			return false, err
+		}
+		password = p
+	}
+	dsn := makeDSN(c.cfg, user, password)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.modTime = modTime
+	if dsn == c.dsn {
+		return false, nil
+	}
+	c.dsn = dsn
+	c.gen++
+	return true, nil
+}
+
+func (c *connector) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+func (c *connector) credentialsFiles() []string {
+	var files []string
+	if len(c.cfg.UserFile) > 0 {
+		files = append(files, c.cfg.UserFile)
+	}
+	if len(c.cfg.PasswordFile) > 0 {
+		files = append(files, c.cfg.PasswordFile)
+	}
+	return files
+}
+
+// rotatingConn wraps a PgSQL driver connection keeping track of the credentials generation it was established with.
+type rotatingConn struct {
+	driver.Conn
+	gen       uint64
+	connector *connector
+}
+
+// IsValid implements driver.Validator interface.
+func (cn *rotatingConn) IsValid() bool {
+	return cn.gen == cn.connector.generation()
+}
+
+// ResetSession implements driver.SessionResetter interface.
+func (cn *rotatingConn) ResetSession(_ context.Context) error {
+	if !cn.IsValid() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// BeginTx implements driver.ConnBeginTx interface.
+func (cn *rotatingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c, ok := cn.Conn.(driver.ConnBeginTx); ok {
+		return c.BeginTx(ctx, opts)
+	}
+	return cn.Conn.Begin()
+}
+
+// QueryContext implements driver.QueryerContext interface.
+func (cn *rotatingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c, ok := cn.Conn.(driver.QueryerContext); ok {
+		return c.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// ExecContext implements driver.ExecerContext interface.
+func (cn *rotatingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c, ok := cn.Conn.(driver.ExecerContext); ok {
+		return c.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// Ping implements driver.Pinger interface.
+func (cn *rotatingConn) Ping(ctx context.Context) error {
+	if c, ok := cn.Conn.(driver.Pinger); ok {
+		return c.Ping(ctx)
+	}
+	return nil
+}
+
+func makeDSN(cfg Config, user, password string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     cfg.Host,
+		Path:     "/" + cfg.Database,
+		RawQuery: fmt.Sprintf("sslmode=%s", cfg.SSLMode),
+	}
+	return u.String()
+}
+
+func readCredential(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -16,6 +16,7 @@ package pgsqlrepository
 
 import (
 	"context"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	kitlog "github.com/go-kit/log"
@@ -94,3 +95,93 @@ func (r *pgSQLOfflineRep) DeleteOfflineMessages(ctx context.Context, username st
 	_, err := q.RunWith(r.conn).ExecContext(ctx)
 	return err
 }
+
+func (r *pgSQLOfflineRep) DeleteSelectedOfflineMessages(ctx context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (int, error) {
+	q := sq.Select("id", "message").
+		From(offlineMessagesTableName).
+		Where(sq.Eq{"username": username}).
+		OrderBy("id")
+
+	ids, err := r.fetchOfflineMessageIDs(ctx, q, selectFn)
+	if err != nil {
+		return 0, err
+	}
+	return r.deleteOfflineMessagesByID(ctx, ids)
+}
+
+func (r *pgSQLOfflineRep) TrimOfflineMessages(ctx context.Context, username string, maxSize int) (int, error) {
+	res, err := sq.Delete(offlineMessagesTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Expr("id IN (SELECT id FROM offline_messages WHERE username = ? ORDER BY id DESC OFFSET ?)", username, maxSize)).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (r *pgSQLOfflineRep) DeleteOfflineMessagesBefore(ctx context.Context, host string, before time.Time) (int, error) {
+	q := sq.Select("id", "message").
+		From(offlineMessagesTableName).
+		Where(sq.Lt{"created_at": before}).
+		OrderBy("id")
+
+	// host is not stored apart, so it's taken from message recipient
+	ids, err := r.fetchOfflineMessageIDs(ctx, q, func(msg *stravaganza.Message) bool {
+		return msg.ToJID().Domain() == host
+	})
+	if err != nil {
+		return 0, err
+	}
+	return r.deleteOfflineMessagesByID(ctx, ids)
+}
+
+func (r *pgSQLOfflineRep) deleteOfflineMessagesByID(ctx context.Context, ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	_, err := sq.Delete(offlineMessagesTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Eq{"id": ids}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+func (r *pgSQLOfflineRep) fetchOfflineMessageIDs(ctx context.Context, q sq.SelectBuilder, selectFn func(msg *stravaganza.Message) bool) ([]int64, error) {
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var b []byte
+		if err := rows.Scan(&id, &b); err != nil {
+			return nil, err
+		}
+		sb, err := stravaganza.NewBuilderFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := sb.BuildMessage()
+		if err != nil {
+			return nil, err
+		}
+		if !selectFn(msg) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -17,6 +17,7 @@ package pgsqlrepository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jackal-xmpp/stravaganza"
@@ -115,6 +116,88 @@ func TestPgSQLOffline_DeleteOfflineMessage(t *testing.T) {
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLOffline_DeleteOfflineMessagesBefore(t *testing.T) {
+	// given
+	before := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	m0, _ := stravaganza.NewMessageBuilder().
+		WithAttribute("from", "noelia@jackal.im/yard").
+		WithAttribute("to", "ortuman@jackal.im/balcony").
+		BuildMessage()
+	m1, _ := stravaganza.NewMessageBuilder().
+		WithAttribute("from", "noelia@jackal.im/yard").
+		WithAttribute("to", "ortuman@jabber.org/balcony").
+		BuildMessage()
+	b0, _ := m0.MarshalBinary()
+	b1, _ := m1.MarshalBinary()
+
+	s, mock := newOfflineMock()
+	mock.ExpectQuery(`SELECT id, message FROM offline_messages WHERE created_at < \$1 ORDER BY id`).
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message"}).AddRow(1, b0).AddRow(2, b1))
+	mock.ExpectExec(`DELETE FROM offline_messages WHERE id IN \(\$1\)`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	n, err := s.DeleteOfflineMessagesBefore(context.Background(), "jackal.im", before)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Equal(t, 1, n)
+}
+
+func TestPgSQLOffline_DeleteSelectedOfflineMessages(t *testing.T) {
+	// given
+	m0, _ := stravaganza.NewMessageBuilder().
+		WithAttribute("id", "m0").
+		WithAttribute("from", "noelia@jackal.im/yard").
+		WithAttribute("to", "ortuman@jackal.im/balcony").
+		BuildMessage()
+	m1, _ := stravaganza.NewMessageBuilder().
+		WithAttribute("id", "m1").
+		WithAttribute("from", "noelia@jackal.im/yard").
+		WithAttribute("to", "ortuman@jackal.im/balcony").
+		BuildMessage()
+	b0, _ := m0.MarshalBinary()
+	b1, _ := m1.MarshalBinary()
+
+	s, mock := newOfflineMock()
+	mock.ExpectQuery(`SELECT id, message FROM offline_messages WHERE username = \$1 ORDER BY id`).
+		WithArgs("ortuman").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message"}).AddRow(1, b0).AddRow(2, b1))
+	mock.ExpectExec(`DELETE FROM offline_messages WHERE id IN \(\$1\)`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	n, err := s.DeleteSelectedOfflineMessages(context.Background(), "ortuman", func(msg *stravaganza.Message) bool {
+		return msg.Attribute("id") == "m1"
+	})
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Equal(t, 1, n)
+}
+
+func TestPgSQLOffline_TrimOfflineMessages(t *testing.T) {
+	// given
+	s, mock := newOfflineMock()
+	mock.ExpectExec(`DELETE FROM offline_messages WHERE id IN \(SELECT id FROM offline_messages WHERE username = \$1 ORDER BY id DESC OFFSET \$2\)`).
+		WithArgs("ortuman", 10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// when
+	n, err := s.TrimOfflineMessages(context.Background(), "ortuman", 10)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Equal(t, 2, n)
+}
+
 func newOfflineMock() (*pgSQLOfflineRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
 	return &pgSQLOfflineRep{conn: s}, sqlMock
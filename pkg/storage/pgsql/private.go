@@ -53,6 +53,33 @@ func (r *pgSQLPrivateRep) FetchPrivate(ctx context.Context, namespace, username
 	}
 }
 
+func (r *pgSQLPrivateRep) FetchPrivates(ctx context.Context, username string) ([]stravaganza.Element, error) {
+	q := sq.Select("data").
+		From(privateStorageTableName).
+		Where(sq.Eq{"username": username}).
+		OrderBy("namespace")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []stravaganza.Element
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		pb, err := stravaganza.NewBuilderFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, pb.Build())
+	}
+	return retVal, nil
+}
+
 func (r *pgSQLPrivateRep) UpsertPrivate(ctx context.Context, private stravaganza.Element, namespace, username string) error {
 	b, err := private.MarshalBinary()
 	if err != nil {
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	kitlog "github.com/go-kit/log"
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +46,28 @@ func TestPgSQLPrivate_FetchPrivate(t *testing.T) {
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLPrivate_FetchPrivates(t *testing.T) {
+	// given
+	prv := testPrivate()
+	b, _ := prv.MarshalBinary()
+
+	s, mock := newPrivateMock()
+	mock.ExpectQuery(`SELECT data FROM private_storage WHERE username = \$1 ORDER BY namespace`).
+		WithArgs("ortuman").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"data"}).AddRow(b).AddRow(b),
+		)
+
+	// when
+	prvs, err := s.FetchPrivates(context.Background(), "ortuman")
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, prvs, 2)
+
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
 func TestPgSQLPrivate_UpsertPrivate(t *testing.T) {
 	// given
 	prv := testPrivate()
@@ -76,7 +99,7 @@ func TestPgSQLPrivate_DeletePrivates(t *testing.T) {
 
 func newPrivateMock() (*pgSQLPrivateRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
-	return &pgSQLPrivateRep{conn: s}, sqlMock
+	return &pgSQLPrivateRep{conn: s, logger: kitlog.NewNopLogger()}, sqlMock
 }
 
 func testPrivate() stravaganza.Element {
@@ -0,0 +1,99 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	kitlog "github.com/go-kit/log"
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+)
+
+const (
+	reactionsTableName = "reactions"
+)
+
+type pgSQLReactionRep struct {
+	conn   conn
+	logger kitlog.Logger
+}
+
+func (r *pgSQLReactionRep) UpsertReactions(ctx context.Context, reactions *reactionmodel.Reactions) error {
+	b, err := reactions.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	q := sq.Insert(reactionsTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("archive_id", "target_id", "jid", "reactions").
+		Values(reactions.ArchiveId, reactions.TargetId, reactions.Jid, b).
+		Suffix("ON CONFLICT (archive_id, target_id, jid) DO UPDATE SET reactions = $4")
+
+	_, err = q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLReactionRep) FetchReactions(ctx context.Context, archiveID string, targetIDs []string) ([]*reactionmodel.Reactions, error) {
+	q := sq.Select("reactions").
+		From(reactionsTableName).
+		Where(sq.And{
+			sq.Eq{"archive_id": archiveID},
+			sq.Eq{"target_id": targetIDs},
+		}).
+		OrderBy("created_at")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []*reactionmodel.Reactions
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var reactions reactionmodel.Reactions
+		if err := reactions.UnmarshalBinary(b); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, &reactions)
+	}
+	return retVal, rows.Err()
+}
+
+func (r *pgSQLReactionRep) DeleteReactions(ctx context.Context, archiveID, targetID, jid string) error {
+	_, err := sq.Delete(reactionsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{
+			sq.Eq{"archive_id": archiveID},
+			sq.Eq{"target_id": targetID},
+			sq.Eq{"jid": jid},
+		}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLReactionRep) DeleteArchiveReactions(ctx context.Context, archiveID string) error {
+	_, err := sq.Delete(reactionsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Eq{"archive_id": archiveID}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
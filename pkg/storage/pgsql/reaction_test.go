@@ -0,0 +1,111 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	kitlog "github.com/go-kit/log"
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPgSQLReaction_Upsert(t *testing.T) {
+	// given
+	reactions := &reactionmodel.Reactions{
+		ArchiveId: "ortuman",
+		TargetId:  "msg-1",
+		Jid:       "noelia@jackal.im",
+		Reactions: []string{"👍", "🐢"},
+	}
+	b, _ := reactions.MarshalBinary()
+
+	s, mock := newReactionMock()
+	mock.ExpectExec(`INSERT INTO reactions \(archive_id,target_id,jid,reactions\) VALUES \(\$1,\$2,\$3,\$4\) ON CONFLICT \(archive_id, target_id, jid\) DO UPDATE SET reactions = \$4`).
+		WithArgs("ortuman", "msg-1", "noelia@jackal.im", b).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.UpsertReactions(context.Background(), reactions)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLReaction_Fetch(t *testing.T) {
+	// given
+	reactions := &reactionmodel.Reactions{
+		ArchiveId: "ortuman",
+		TargetId:  "msg-1",
+		Jid:       "noelia@jackal.im",
+		Reactions: []string{"👍"},
+	}
+	b, _ := reactions.MarshalBinary()
+
+	s, mock := newReactionMock()
+	mock.ExpectQuery(`SELECT reactions FROM reactions WHERE \(archive_id = \$1 AND target_id IN \(\$2,\$3\)\) ORDER BY created_at`).
+		WithArgs("ortuman", "msg-1", "msg-2").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"reactions"}).AddRow(b),
+		)
+
+	// when
+	rs, err := s.FetchReactions(context.Background(), "ortuman", []string{"msg-1", "msg-2"})
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Len(t, rs, 1)
+	require.True(t, proto.Equal(reactions, rs[0]))
+}
+
+func TestPgSQLReaction_Delete(t *testing.T) {
+	// given
+	s, mock := newReactionMock()
+	mock.ExpectExec(`DELETE FROM reactions WHERE \(archive_id = \$1 AND target_id = \$2 AND jid = \$3\)`).
+		WithArgs("ortuman", "msg-1", "noelia@jackal.im").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteReactions(context.Background(), "ortuman", "msg-1", "noelia@jackal.im")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLReaction_DeleteArchive(t *testing.T) {
+	// given
+	s, mock := newReactionMock()
+	mock.ExpectExec(`DELETE FROM reactions WHERE archive_id = \$1`).
+		WithArgs("ortuman").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteArchiveReactions(context.Background(), "ortuman")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func newReactionMock() (*pgSQLReactionRep, sqlmock.Sqlmock) {
+	s, sqlMock := newPgSQLMock()
+	return &pgSQLReactionRep{conn: s, logger: kitlog.NewNopLogger()}, sqlMock
+}
@@ -17,7 +17,6 @@ package pgsqlrepository
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -45,6 +44,15 @@ type Config struct {
 	MaxIdleConns    int           `fig:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `fig:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `fig:"conn_max_idle_time"`
+
+	// UserFile and PasswordFile define the paths of files holding the database credentials.
+	// When set, they take precedence over User and Password values and are watched for changes,
+	// so that rotated credentials are picked up without restarting the server.
+	UserFile     string `fig:"user_file"`
+	PasswordFile string `fig:"password_file"`
+
+	// CredentialsCheckInterval defines how often credentials files are checked for changes.
+	CredentialsCheckInterval time.Duration `fig:"credentials_check_interval" default:"30s"`
 }
 
 // Repository represents a PgSQL repository implementation.
@@ -58,24 +66,31 @@ type Repository struct {
 	repository.Roster
 	repository.VCard
 	repository.Archive
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Locker
 
-	host string
-	dsn  string
-	cfg  Config
+	host      string
+	connector *connector
+	cfg       Config
 
 	db     *sql.DB
 	logger kitlog.Logger
+
+	stopCh chan struct{}
+	doneCh chan struct{}
 }
 
 // New creates and returns an initialized PgSQL Repository instance.
 func New(cfg Config, logger kitlog.Logger) *Repository {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s", cfg.User, cfg.Password, cfg.Host, cfg.Database, cfg.SSLMode)
 	return &Repository{
-		host:   cfg.Host,
-		dsn:    dsn,
-		cfg:    cfg,
-		logger: logger,
+		host:      cfg.Host,
+		connector: newConnector(cfg),
+		cfg:       cfg,
+		logger:    logger,
 	}
 }
 
@@ -97,10 +112,10 @@ func (r *Repository) InTransaction(ctx context.Context, f func(ctx context.Conte
 
 // Start implements Start interface method.
 func (r *Repository) Start(ctx context.Context) error {
-	db, err := sql.Open("postgres", r.dsn)
-	if err != nil {
-		return errors.Wrap(err, "failed to start PgSQL connection")
+	if _, err := r.connector.refresh(); err != nil {
+		return errors.Wrap(err, "failed to load PgSQL credentials")
 	}
+	db := sql.OpenDB(r.connector)
 	r.db = db
 
 	db.SetMaxIdleConns(r.cfg.MaxIdleConns)
@@ -122,12 +137,30 @@ func (r *Repository) Start(ctx context.Context) error {
 	r.Roster = &pgSQLRosterRep{conn: db, logger: r.logger}
 	r.VCard = &pgSQLVCardRep{conn: db, logger: r.logger}
 	r.Archive = &pgSQLArchiveRep{conn: db, logger: r.logger}
+	r.StreamQueue = &pgSQLStreamQueueRep{conn: db, logger: r.logger}
+	r.Reaction = &pgSQLReactionRep{conn: db, logger: r.logger}
+	r.S2SQueue = &pgSQLS2SQueueRep{conn: db, logger: r.logger}
+	r.Stats = &pgSQLStatsRep{conn: db, logger: r.logger}
+	r.UnifiedPush = &pgSQLUnifiedPushRep{conn: db, logger: r.logger}
 	r.Locker = &pgSQLLocker{conn: db}
+
+	if len(r.connector.credentialsFiles()) > 0 {
+		if r.cfg.CredentialsCheckInterval <= 0 {
+			return errors.New("PgSQL credentials check interval must be positive")
+		}
+		r.stopCh = make(chan struct{})
+		r.doneCh = make(chan struct{})
+		go r.watchCredentials()
+	}
 	return nil
 }
 
 // Stop closes PgSQL database and prevents new queries from starting.
 func (r *Repository) Stop(_ context.Context) error {
+	if r.stopCh != nil {
+		close(r.stopCh)
+		<-r.doneCh
+	}
 	if err := r.db.Close(); err != nil {
 		return errors.Wrap(err, "failed to close PgSQL connection")
 	}
@@ -135,6 +168,35 @@ func (r *Repository) Stop(_ context.Context) error {
 	return nil
 }
 
+func (r *Repository) watchCredentials() {
+	defer close(r.doneCh)
+
+	tc := time.NewTicker(r.cfg.CredentialsCheckInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			rotated, err := r.connector.refresh()
+			if err != nil {
+				level.Warn(r.logger).Log("msg", "failed to reload PgSQL credentials", "err", err)
+				continue
+			}
+			if !rotated {
+				continue
+			}
+			// close idle connections right away, busy ones will be discarded once released
+			r.db.SetMaxIdleConns(-1)
+			r.db.SetMaxIdleConns(r.cfg.MaxIdleConns)
+
+			level.Info(r.logger).Log("msg", "rotated PgSQL credentials", "host", r.host)
+
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
 func closeRows(rows *sql.Rows, logger kitlog.Logger) {
 	if err := rows.Close(); err != nil {
 		level.Warn(logger).Log("msg", "failed to close SQL rows", "err", err)
@@ -0,0 +1,142 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const s2sQueueTableName = "s2s_queue"
+
+type pgSQLS2SQueueRep struct {
+	conn   conn
+	logger kitlog.Logger
+}
+
+func (r *pgSQLS2SQueueRep) InsertS2SQueueStanza(ctx context.Context, stanza *s2squeuemodel.Stanza) error {
+	b, err := proto.Marshal(stanza.Stanza)
+	if err != nil {
+		return err
+	}
+	q := sq.Insert(s2sQueueTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("domain", "sender_domain", "stanza", "expires_at").
+		Values(stanza.Domain, stanza.SenderDomain, b, stanza.ExpiresAt.AsTime())
+
+	_, err = q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLS2SQueueRep) CountS2SQueueStanzas(ctx context.Context, domain string) (int, error) {
+	var count int
+
+	q := sq.Select("COUNT(*)").
+		From(s2sQueueTableName).
+		Where(sq.And{sq.Eq{"domain": domain}, sq.Expr("expires_at > NOW()")})
+
+	if err := q.RunWith(r.conn).QueryRowContext(ctx).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *pgSQLS2SQueueRep) FetchS2SQueueStanzas(ctx context.Context, domain string) ([]*s2squeuemodel.Stanza, error) {
+	q := sq.Select("id", "sender_domain", "stanza", "expires_at").
+		From(s2sQueueTableName).
+		Where(sq.And{sq.Eq{"domain": domain}, sq.Expr("expires_at > NOW()")}).
+		OrderBy("id")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []*s2squeuemodel.Stanza
+	for rows.Next() {
+		var id int64
+		var senderDomain string
+		var b []byte
+		var expiresAt time.Time
+		if err := rows.Scan(&id, &senderDomain, &b, &expiresAt); err != nil {
+			return nil, err
+		}
+		var pb stravaganza.PBElement
+		if err := proto.Unmarshal(b, &pb); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, &s2squeuemodel.Stanza{
+			Id:           id,
+			Domain:       domain,
+			SenderDomain: senderDomain,
+			Stanza:       &pb,
+			ExpiresAt:    timestamppb.New(expiresAt),
+		})
+	}
+	return retVal, nil
+}
+
+func (r *pgSQLS2SQueueRep) FetchS2SQueueDomains(ctx context.Context) ([]string, error) {
+	q := sq.Select("domain").
+		Distinct().
+		From(s2sQueueTableName).
+		Where("expires_at > NOW()").
+		OrderBy("domain")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []string
+	for rows.Next() {
+		var domain string
+		if err := rows.Scan(&domain); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, domain)
+	}
+	return retVal, nil
+}
+
+func (r *pgSQLS2SQueueRep) DeleteS2SQueueStanzas(ctx context.Context, domain string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := sq.Delete(s2sQueueTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{sq.Eq{"domain": domain}, sq.Eq{"id": ids}}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLS2SQueueRep) DeleteExpiredS2SQueueStanzas(ctx context.Context) error {
+	_, err := sq.Delete(s2sQueueTableName).
+		Prefix(noLoadBalancePrefix).
+		Where("expires_at < NOW()").
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackal-xmpp/stravaganza"
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPgSQLS2SQueue_Insert(t *testing.T) {
+	// given
+	expiresAt := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	stanza := &s2squeuemodel.Stanza{
+		Domain:       "jabber.org",
+		SenderDomain: "jackal.im",
+		Stanza:       testS2SQueueMessage().Proto(),
+		ExpiresAt:    timestamppb.New(expiresAt),
+	}
+	b, _ := proto.Marshal(stanza.Stanza)
+
+	s, mock := newS2SQueueMock()
+	mock.ExpectExec(`INSERT INTO s2s_queue \(domain,sender_domain,stanza,expires_at\) VALUES \(\$1,\$2,\$3,\$4\)`).
+		WithArgs("jabber.org", "jackal.im", b, expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.InsertS2SQueueStanza(context.Background(), stanza)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLS2SQueue_Count(t *testing.T) {
+	// given
+	s, mock := newS2SQueueMock()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM s2s_queue WHERE \(domain = \$1 AND expires_at > NOW\(\)\)`).
+		WithArgs("jabber.org").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	// when
+	count, err := s.CountS2SQueueStanzas(context.Background(), "jabber.org")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestPgSQLS2SQueue_Fetch(t *testing.T) {
+	// given
+	expiresAt := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	msg := testS2SQueueMessage()
+	b, _ := proto.Marshal(msg.Proto())
+
+	s, mock := newS2SQueueMock()
+	mock.ExpectQuery(`SELECT id, sender_domain, stanza, expires_at FROM s2s_queue WHERE \(domain = \$1 AND expires_at > NOW\(\)\) ORDER BY id`).
+		WithArgs("jabber.org").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sender_domain", "stanza", "expires_at"}).AddRow(7, "jackal.im", b, expiresAt))
+
+	// when
+	stanzas, err := s.FetchS2SQueueStanzas(context.Background(), "jabber.org")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+
+	require.Len(t, stanzas, 1)
+	require.Equal(t, int64(7), stanzas[0].Id)
+	require.Equal(t, "jabber.org", stanzas[0].Domain)
+	require.Equal(t, "jackal.im", stanzas[0].SenderDomain)
+	require.Equal(t, expiresAt, stanzas[0].ExpiresAt.AsTime())
+	require.True(t, proto.Equal(msg.Proto(), stanzas[0].Stanza))
+}
+
+func TestPgSQLS2SQueue_FetchDomains(t *testing.T) {
+	// given
+	s, mock := newS2SQueueMock()
+	mock.ExpectQuery(`SELECT DISTINCT domain FROM s2s_queue WHERE expires_at > NOW\(\) ORDER BY domain`).
+		WillReturnRows(sqlmock.NewRows([]string{"domain"}).AddRow("jabber.org").AddRow("xmpp.org"))
+
+	// when
+	domains, err := s.FetchS2SQueueDomains(context.Background())
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Equal(t, []string{"jabber.org", "xmpp.org"}, domains)
+}
+
+func TestPgSQLS2SQueue_Delete(t *testing.T) {
+	// given
+	s, mock := newS2SQueueMock()
+	mock.ExpectExec(`DELETE FROM s2s_queue WHERE \(domain = \$1 AND id IN \(\$2,\$3\)\)`).
+		WithArgs("jabber.org", int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// when
+	err := s.DeleteS2SQueueStanzas(context.Background(), "jabber.org", []int64{1, 2})
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLS2SQueue_DeleteExpired(t *testing.T) {
+	// given
+	s, mock := newS2SQueueMock()
+	mock.ExpectExec(`DELETE FROM s2s_queue WHERE expires_at < NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteExpiredS2SQueueStanzas(context.Background())
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func newS2SQueueMock() (*pgSQLS2SQueueRep, sqlmock.Sqlmock) {
+	s, sqlMock := newPgSQLMock()
+	return &pgSQLS2SQueueRep{conn: s}, sqlMock
+}
+
+func testS2SQueueMessage() *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jabber.org").
+		WithChild(stravaganza.NewBuilder("body").WithText("I'll give thee a wind.").Build()).
+		BuildMessage()
+	return msg
+}
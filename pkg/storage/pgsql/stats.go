@@ -0,0 +1,110 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	kitlog "github.com/go-kit/log"
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+)
+
+const (
+	userActivityTableName = "user_activity"
+	hostStatsTableName    = "host_stats"
+)
+
+type pgSQLStatsRep struct {
+	conn   conn
+	logger kitlog.Logger
+}
+
+func (r *pgSQLStatsRep) UpsertUserActivity(ctx context.Context, username string, day time.Time) error {
+	_, err := sq.Insert(userActivityTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("username", "day").
+		Values(username, day).
+		Suffix("ON CONFLICT (username, day) DO NOTHING").
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLStatsRep) CountActiveUsers(ctx context.Context, from, to time.Time) (int, error) {
+	var count int
+
+	q := sq.Select("COUNT(DISTINCT username)").
+		From(userActivityTableName).
+		Where(sq.And{sq.GtOrEq{"day": from}, sq.LtOrEq{"day": to}})
+
+	if err := q.RunWith(r.conn).QueryRowContext(ctx).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *pgSQLStatsRep) IncrementHostStats(ctx context.Context, stats *statsmodel.HostStats) error {
+	_, err := sq.Insert(hostStatsTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("host", "day", "messages", "registrations").
+		Values(stats.Host, stats.Day.AsTime(), stats.Messages, stats.Registrations).
+		Suffix("ON CONFLICT (host, day) DO UPDATE SET messages = host_stats.messages + $3, registrations = host_stats.registrations + $4").
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLStatsRep) FetchHostStats(ctx context.Context, from, to time.Time) ([]*statsmodel.HostStats, error) {
+	q := sq.Select("host", "SUM(messages)", "SUM(registrations)").
+		From(hostStatsTableName).
+		Where(sq.And{sq.GtOrEq{"day": from}, sq.LtOrEq{"day": to}}).
+		GroupBy("host").
+		OrderBy("host")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []*statsmodel.HostStats
+	for rows.Next() {
+		var st statsmodel.HostStats
+		if err := rows.Scan(&st.Host, &st.Messages, &st.Registrations); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, &st)
+	}
+	return retVal, nil
+}
+
+func (r *pgSQLStatsRep) DeleteStatsBefore(ctx context.Context, day time.Time) error {
+	_, err := sq.Delete(userActivityTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Lt{"day": day}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = sq.Delete(hostStatsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Lt{"day": day}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
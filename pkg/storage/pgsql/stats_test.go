@@ -0,0 +1,134 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPgSQLStats_UpsertUserActivity(t *testing.T) {
+	// given
+	day := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newStatsMock()
+	mock.ExpectExec(`INSERT INTO user_activity \(username,day\) VALUES \(\$1,\$2\) ON CONFLICT \(username, day\) DO NOTHING`).
+		WithArgs("ortuman", day).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.UpsertUserActivity(context.Background(), "ortuman", day)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLStats_CountActiveUsers(t *testing.T) {
+	// given
+	from := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	to := from.AddDate(0, 0, 29)
+
+	s, mock := newStatsMock()
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT username\) FROM user_activity WHERE \(day >= \$1 AND day <= \$2\)`).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	// when
+	count, err := s.CountActiveUsers(context.Background(), from, to)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Equal(t, 7, count)
+}
+
+func TestPgSQLStats_IncrementHostStats(t *testing.T) {
+	// given
+	day := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newStatsMock()
+	mock.ExpectExec(`INSERT INTO host_stats \(host,day,messages,registrations\) VALUES \(\$1,\$2,\$3,\$4\) ON CONFLICT \(host, day\) DO UPDATE SET messages = host_stats.messages \+ \$3, registrations = host_stats.registrations \+ \$4`).
+		WithArgs("jackal.im", day, uint64(10), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.IncrementHostStats(context.Background(), &statsmodel.HostStats{
+		Host:          "jackal.im",
+		Day:           timestamppb.New(day),
+		Messages:      10,
+		Registrations: 1,
+	})
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLStats_FetchHostStats(t *testing.T) {
+	// given
+	from := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	s, mock := newStatsMock()
+	mock.ExpectQuery(`SELECT host, SUM\(messages\), SUM\(registrations\) FROM host_stats WHERE \(day >= \$1 AND day <= \$2\) GROUP BY host ORDER BY host`).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"host", "messages", "registrations"}).
+			AddRow("jabber.org", 1, 0).
+			AddRow("jackal.im", 17, 3),
+		)
+
+	// when
+	stats, err := s.FetchHostStats(context.Background(), from, to)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, "jackal.im", stats[1].Host)
+	require.Equal(t, uint64(17), stats[1].Messages)
+	require.Equal(t, uint64(3), stats[1].Registrations)
+}
+
+func TestPgSQLStats_DeleteStatsBefore(t *testing.T) {
+	// given
+	day := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newStatsMock()
+	mock.ExpectExec(`DELETE FROM user_activity WHERE day < \$1`).
+		WithArgs(day).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM host_stats WHERE day < \$1`).
+		WithArgs(day).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteStatsBefore(context.Background(), day)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func newStatsMock() (*pgSQLStatsRep, sqlmock.Sqlmock) {
+	s, sqlMock := newPgSQLMock()
+	return &pgSQLStatsRep{conn: s}, sqlMock
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	kitlog "github.com/go-kit/log"
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+)
+
+const (
+	streamQueuesTableName = "stream_queues"
+)
+
+type pgSQLStreamQueueRep struct {
+	conn   conn
+	logger kitlog.Logger
+}
+
+func (r *pgSQLStreamQueueRep) UpsertStreamQueue(ctx context.Context, queue *streamqueuemodel.Queue) error {
+	b, err := queue.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	q := sq.Insert(streamQueuesTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("jid", "queue", "expires_at").
+		Values(queue.Jid, b, queue.ExpiresAt.AsTime()).
+		Suffix("ON CONFLICT (jid) DO UPDATE SET queue = $2, expires_at = $3")
+
+	_, err = q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLStreamQueueRep) FetchStreamQueue(ctx context.Context, jid string) (*streamqueuemodel.Queue, error) {
+	q := sq.Select("queue").
+		From(streamQueuesTableName).
+		Where(sq.Eq{"jid": jid})
+
+	var b []byte
+	err := q.RunWith(r.conn).
+		QueryRowContext(ctx).
+		Scan(&b)
+	switch err {
+	case nil:
+		var queue streamqueuemodel.Queue
+		if err := queue.UnmarshalBinary(b); err != nil {
+			return nil, err
+		}
+		return &queue, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *pgSQLStreamQueueRep) DeleteStreamQueue(ctx context.Context, jid string) error {
+	_, err := sq.Delete(streamQueuesTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Eq{"jid": jid}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLStreamQueueRep) DeleteExpiredStreamQueues(ctx context.Context) error {
+	_, err := sq.Delete(streamQueuesTableName).
+		Prefix(noLoadBalancePrefix).
+		Where("expires_at < NOW()").
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
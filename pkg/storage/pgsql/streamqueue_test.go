@@ -0,0 +1,112 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPgSQLStreamQueue_Upsert(t *testing.T) {
+	// given
+	expiresAt := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+	queue := &streamqueuemodel.Queue{
+		Jid:       "ortuman@jackal.im/yard",
+		Nonce:     []byte{1, 2, 3},
+		InH:       4,
+		OutH:      8,
+		ExpiresAt: timestamppb.New(expiresAt),
+	}
+	b, _ := queue.MarshalBinary()
+
+	s, mock := newStreamQueueMock()
+	mock.ExpectExec(`INSERT INTO stream_queues \(jid,queue,expires_at\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \(jid\) DO UPDATE SET queue = \$2, expires_at = \$3`).
+		WithArgs("ortuman@jackal.im/yard", b, expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.UpsertStreamQueue(context.Background(), queue)
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLStreamQueue_Fetch(t *testing.T) {
+	// given
+	queue := &streamqueuemodel.Queue{
+		Jid:   "ortuman@jackal.im/yard",
+		Nonce: []byte{1, 2, 3},
+		InH:   4,
+		OutH:  8,
+	}
+	b, _ := queue.MarshalBinary()
+
+	s, mock := newStreamQueueMock()
+	mock.ExpectQuery(`SELECT queue FROM stream_queues WHERE jid = \$1`).
+		WithArgs("ortuman@jackal.im/yard").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"queue"}).AddRow(b),
+		)
+
+	// when
+	q, err := s.FetchStreamQueue(context.Background(), "ortuman@jackal.im/yard")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.True(t, proto.Equal(queue, q))
+}
+
+func TestPgSQLStreamQueue_Delete(t *testing.T) {
+	// given
+	s, mock := newStreamQueueMock()
+	mock.ExpectExec(`DELETE FROM stream_queues WHERE jid = \$1`).
+		WithArgs("ortuman@jackal.im/yard").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteStreamQueue(context.Background(), "ortuman@jackal.im/yard")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLStreamQueue_DeleteExpired(t *testing.T) {
+	// given
+	s, mock := newStreamQueueMock()
+	mock.ExpectExec(`DELETE FROM stream_queues WHERE expires_at < NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.DeleteExpiredStreamQueues(context.Background())
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func newStreamQueueMock() (*pgSQLStreamQueueRep, sqlmock.Sqlmock) {
+	s, sqlMock := newPgSQLMock()
+	return &pgSQLStreamQueueRep{conn: s}, sqlMock
+}
@@ -30,6 +30,11 @@ type repTx struct {
 	repository.Roster
 	repository.VCard
 	repository.Archive
+	repository.StreamQueue
+	repository.Reaction
+	repository.S2SQueue
+	repository.Stats
+	repository.UnifiedPush
 	repository.Locker
 }
 
@@ -44,6 +49,11 @@ func newRepTx(tx *sql.Tx) *repTx {
 		Roster:       &pgSQLRosterRep{conn: tx},
 		VCard:        &pgSQLVCardRep{conn: tx},
 		Archive:      &pgSQLArchiveRep{conn: tx},
+		StreamQueue:  &pgSQLStreamQueueRep{conn: tx},
+		Reaction:     &pgSQLReactionRep{conn: tx},
+		S2SQueue:     &pgSQLS2SQueueRep{conn: tx},
+		Stats:        &pgSQLStatsRep{conn: tx},
+		UnifiedPush:  &pgSQLUnifiedPushRep{conn: tx},
 		Locker:       &pgSQLLocker{conn: tx},
 	}
 }
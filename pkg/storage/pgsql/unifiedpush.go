@@ -0,0 +1,83 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	kitlog "github.com/go-kit/log"
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const unifiedPushRegistrationsTableName = "unifiedpush_registrations"
+
+type pgSQLUnifiedPushRep struct {
+	conn   conn
+	logger kitlog.Logger
+}
+
+func (r *pgSQLUnifiedPushRep) UpsertUnifiedPushRegistration(ctx context.Context, reg *unifiedpushmodel.Registration) error {
+	_, err := sq.Insert(unifiedPushRegistrationsTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("token", "username", "jid", "application", "instance", "expires_at").
+		Values(reg.Token, reg.Username, reg.Jid, reg.Application, reg.Instance, reg.ExpiresAt.AsTime()).
+		Suffix("ON CONFLICT (username, application, instance) DO UPDATE SET token = $1, jid = $3, expires_at = $6").
+		RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLUnifiedPushRep) FetchUnifiedPushRegistration(ctx context.Context, token string) (*unifiedpushmodel.Registration, error) {
+	q := sq.Select("username", "jid", "application", "instance", "expires_at").
+		From(unifiedPushRegistrationsTableName).
+		Where(sq.Eq{"token": token})
+
+	var expiresAt time.Time
+	reg := unifiedpushmodel.Registration{Token: token}
+
+	err := q.RunWith(r.conn).
+		QueryRowContext(ctx).
+		Scan(&reg.Username, &reg.Jid, &reg.Application, &reg.Instance, &expiresAt)
+	switch err {
+	case nil:
+		reg.ExpiresAt = timestamppb.New(expiresAt)
+		return &reg, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *pgSQLUnifiedPushRep) DeleteUnifiedPushRegistration(ctx context.Context, username, application, instance string) error {
+	_, err := sq.Delete(unifiedPushRegistrationsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{sq.Eq{"username": username}, sq.Eq{"application": application}, sq.Eq{"instance": instance}}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLUnifiedPushRep) DeleteUnifiedPushRegistrations(ctx context.Context, username string) error {
+	_, err := sq.Delete(unifiedPushRegistrationsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Eq{"username": username}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsqlrepository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPgSQLUnifiedPush_Upsert(t *testing.T) {
+	// given
+	expiresAt := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newUnifiedPushMock()
+	mock.ExpectExec(`INSERT INTO unifiedpush_registrations \(token,username,jid,application,instance,expires_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\) ON CONFLICT \(username, application, instance\) DO UPDATE SET token = \$1, jid = \$3, expires_at = \$6`).
+		WithArgs("t1", "ortuman", "ortuman@jackal.im/phone", "a1", "i1", expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := s.UpsertUnifiedPushRegistration(context.Background(), &unifiedpushmodel.Registration{
+		Token:       "t1",
+		Username:    "ortuman",
+		Jid:         "ortuman@jackal.im/phone",
+		Application: "a1",
+		Instance:    "i1",
+		ExpiresAt:   timestamppb.New(expiresAt),
+	})
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+}
+
+func TestPgSQLUnifiedPush_Fetch(t *testing.T) {
+	// given
+	expiresAt := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newUnifiedPushMock()
+	mock.ExpectQuery(`SELECT username, jid, application, instance, expires_at FROM unifiedpush_registrations WHERE token = \$1`).
+		WithArgs("t1").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"username", "jid", "application", "instance", "expires_at"}).
+				AddRow("ortuman", "ortuman@jackal.im/phone", "a1", "i1", expiresAt),
+		)
+
+	// when
+	reg, err := s.FetchUnifiedPushRegistration(context.Background(), "t1")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.NotNil(t, reg)
+	require.Equal(t, "t1", reg.Token)
+	require.Equal(t, "ortuman@jackal.im/phone", reg.Jid)
+	require.Equal(t, expiresAt, reg.ExpiresAt.AsTime())
+}
+
+func TestPgSQLUnifiedPush_Delete(t *testing.T) {
+	// given
+	s, mock := newUnifiedPushMock()
+	mock.ExpectExec(`DELETE FROM unifiedpush_registrations WHERE \(username = \$1 AND application = \$2 AND instance = \$3\)`).
+		WithArgs("ortuman", "a1", "i1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM unifiedpush_registrations WHERE username = \$1`).
+		WithArgs("ortuman").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err1 := s.DeleteUnifiedPushRegistration(context.Background(), "ortuman", "a1", "i1")
+	err2 := s.DeleteUnifiedPushRegistrations(context.Background(), "ortuman")
+
+	// then
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err1)
+	require.Nil(t, err2)
+}
+
+func newUnifiedPushMock() (*pgSQLUnifiedPushRep, sqlmock.Sqlmock) {
+	s, sqlMock := newPgSQLMock()
+	return &pgSQLUnifiedPushRep{conn: s}, sqlMock
+}
@@ -17,10 +17,12 @@ package pgsqlrepository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	kitlog "github.com/go-kit/log"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	sq "github.com/Masterminds/squirrel"
 )
@@ -44,6 +46,13 @@ func (r *pgSQLUserRep) UpsertUser(ctx context.Context, user *usermodel.User) err
 		"salt",
 		"iteration_count",
 		"pepper_id",
+		"deletion_scheduled_at",
+		"suspended",
+	}
+	var deletionScheduledAt *time.Time
+	if user.DeletionScheduledAt != nil {
+		t := user.DeletionScheduledAt.AsTime()
+		deletionScheduledAt = &t
 	}
 	vals := []interface{}{
 		user.Username,
@@ -54,12 +63,14 @@ func (r *pgSQLUserRep) UpsertUser(ctx context.Context, user *usermodel.User) err
 		user.Scram.Salt,
 		user.Scram.IterationCount,
 		user.Scram.PepperId,
+		deletionScheduledAt,
+		user.Suspended,
 	}
 	q := sq.Insert(usersTableName).
 		Prefix(noLoadBalancePrefix).
 		Columns(cols...).
 		Values(vals...).
-		Suffix("ON CONFLICT (username) DO UPDATE SET h_sha_1 = $2, h_sha_256 = $3, h_sha_512 = $4, h_sha3_512 = $5, salt = $6, iteration_count = $7, pepper_id = $8")
+		Suffix("ON CONFLICT (username) DO UPDATE SET h_sha_1 = $2, h_sha_256 = $3, h_sha_512 = $4, h_sha3_512 = $5, salt = $6, iteration_count = $7, pepper_id = $8, deletion_scheduled_at = $9, suspended = $10")
 
 	_, err := q.RunWith(r.conn).ExecContext(ctx)
 	return err
@@ -87,7 +98,11 @@ func (r *pgSQLUserRep) FetchUser(ctx context.Context, username string) (*usermod
 		"salt",
 		"iteration_count",
 		"pepper_id",
+		"deletion_scheduled_at",
+		"suspended",
 	}
+	var deletionScheduledAt sql.NullTime
+
 	q := sq.Select(cols...).
 		From(usersTableName).
 		Where(sq.Eq{"username": username})
@@ -103,9 +118,14 @@ func (r *pgSQLUserRep) FetchUser(ctx context.Context, username string) (*usermod
 			&usr.Scram.Salt,
 			&usr.Scram.IterationCount,
 			&usr.Scram.PepperId,
+			&deletionScheduledAt,
+			&usr.Suspended,
 		)
 	switch err {
 	case nil:
+		if deletionScheduledAt.Valid {
+			usr.DeletionScheduledAt = timestamppb.New(deletionScheduledAt.Time)
+		}
 		return &usr, nil
 	case sql.ErrNoRows:
 		return nil, nil
@@ -128,3 +148,29 @@ func (r *pgSQLUserRep) UserExists(ctx context.Context, username string) (bool, e
 		return false, err
 	}
 }
+
+func (r *pgSQLUserRep) FetchUsersPendingDeletion(ctx context.Context, before time.Time) ([]string, error) {
+	q := sq.Select("username").
+		From(usersTableName).
+		Where(sq.Lt{"deletion_scheduled_at": before}).
+		OrderBy("username")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var retVal []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, username)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
@@ -17,6 +17,9 @@ package pgsqlrepository
 import (
 	"context"
 	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 
@@ -26,8 +29,8 @@ import (
 
 func TestPgSQLUser_Upsert(t *testing.T) {
 	s, mock := newUserMock()
-	mock.ExpectExec(`INSERT INTO users \(username,h_sha_1,h_sha_256,h_sha_512,h_sha3_512,salt,iteration_count,pepper_id\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8\) ON CONFLICT \(username\) DO UPDATE SET h_sha_1 = \$2, h_sha_256 = \$3, h_sha_512 = \$4, h_sha3_512 = \$5, salt = \$6, iteration_count = \$7, pepper_id = \$8`).
-		WithArgs("ortuman", "v_sha_1", "v_sha_256", "v_sha_512", "v_sha3_512", "salt", 1024, "v1").
+	mock.ExpectExec(`INSERT INTO users \(username,h_sha_1,h_sha_256,h_sha_512,h_sha3_512,salt,iteration_count,pepper_id,deletion_scheduled_at,suspended\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10\) ON CONFLICT \(username\) DO UPDATE SET h_sha_1 = \$2, h_sha_256 = \$3, h_sha_512 = \$4, h_sha3_512 = \$5, salt = \$6, iteration_count = \$7, pepper_id = \$8, deletion_scheduled_at = \$9, suspended = \$10`).
+		WithArgs("ortuman", "v_sha_1", "v_sha_256", "v_sha_512", "v_sha3_512", "salt", 1024, "v1", nil, false).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	usr := usermodel.User{Username: "ortuman"}
@@ -55,13 +58,15 @@ func TestPgSQLUser_Fetch(t *testing.T) {
 		"salt",
 		"iteration_count",
 		"pepper_id",
+		"deletion_scheduled_at",
+		"suspended",
 	}
 
 	s, mock := newUserMock()
-	mock.ExpectQuery(`SELECT username, h_sha_1, h_sha_256, h_sha_512, h_sha3_512, salt, iteration_count, pepper_id FROM users WHERE username = \$1`).
+	mock.ExpectQuery(`SELECT username, h_sha_1, h_sha_256, h_sha_512, h_sha3_512, salt, iteration_count, pepper_id, deletion_scheduled_at, suspended FROM users WHERE username = \$1`).
 		WithArgs("ortuman").
 		WillReturnRows(
-			sqlmock.NewRows(cols).AddRow("ortuman", "v_sha_1", "v_sha_256", "v_sha_512", "v_sha3_512", "salt", 1024, "v1"),
+			sqlmock.NewRows(cols).AddRow("ortuman", "v_sha_1", "v_sha_256", "v_sha_512", "v_sha3_512", "salt", 1024, "v1", nil, true),
 		)
 
 	usr, err := s.FetchUser(context.Background(), "ortuman")
@@ -77,6 +82,8 @@ func TestPgSQLUser_Fetch(t *testing.T) {
 	require.Equal(t, "salt", usr.Scram.Salt)
 	require.Equal(t, int64(1024), usr.Scram.IterationCount)
 	require.Equal(t, "v1", usr.Scram.PepperId)
+	require.Nil(t, usr.DeletionScheduledAt)
+	require.True(t, usr.Suspended)
 }
 
 func TestPgSQLUser_Delete(t *testing.T) {
@@ -106,7 +113,23 @@ func TestPgSQLUser_Exists(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestPgSQLUser_FetchUsersPendingDeletion(t *testing.T) {
+	before := time.Date(2022, 04, 12, 12, 0, 0, 0, time.UTC)
+
+	s, mock := newUserMock()
+	mock.ExpectQuery(`SELECT username FROM users WHERE deletion_scheduled_at < \$1 ORDER BY username`).
+		WithArgs(before).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"username"}).AddRow("noelia").AddRow("ortuman"),
+		)
+
+	usernames, err := s.FetchUsersPendingDeletion(context.Background(), before)
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Equal(t, []string{"noelia", "ortuman"}, usernames)
+}
+
 func newUserMock() (*pgSQLUserRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
-	return &pgSQLUserRep{conn: s}, sqlMock
+	return &pgSQLUserRep{conn: s, logger: kitlog.NewNopLogger()}, sqlMock
 }
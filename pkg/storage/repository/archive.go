@@ -16,6 +16,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 )
@@ -23,17 +24,64 @@ import (
 // Archive defines storage operations for message archive
 type Archive interface {
 	// InsertArchiveMessage inserts a new message element into an archive queue.
-	InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) error
+	// Insertion is ignored in case the archive already contains a message with the same origin-id value,
+	// in which case inserted is false.
+	InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) (inserted bool, err error)
+
+	// FetchArchiveMessageByOriginID retrieves the archived message having a given origin-id (XEP-0359) value.
+	// Returns nil in case no message was found.
+	FetchArchiveMessageByOriginID(ctx context.Context, archiveID, originID string) (*archivemodel.Message, error)
 
 	// FetchArchiveMetadata returns the metadata value associated to an archive.
 	FetchArchiveMetadata(ctx context.Context, archiveID string) (*archivemodel.Metadata, error)
 
+	// FetchArchiveIDs returns the identifiers of all stored archives.
+	FetchArchiveIDs(ctx context.Context) ([]string, error)
+
 	// FetchArchiveMessages fetches archive asscociated messages applying the passed f filters.
-	FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error)
+	// If page is not nil only the requested range of filtered messages will be returned.
+	FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, page *archivemodel.Page, archiveID string) ([]*archivemodel.Message, error)
+
+	// CountArchiveMessages returns the number of archive messages matching the passed f filters.
+	CountArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) (int, error)
 
 	// DeleteArchiveOldestMessages trims archive oldest messages up to a maxElements total count.
 	DeleteArchiveOldestMessages(ctx context.Context, archiveID string, maxElements int) error
 
+	// DeleteArchiveMessagesBefore removes all messages archived before a given time by users of a host.
+	// It returns the number of removed messages.
+	DeleteArchiveMessagesBefore(ctx context.Context, host string, before time.Time) (int, error)
+
+	// DeleteArchiveMessagesWith removes all archived messages exchanged with a given JID.
+	// A bare JID matches messages exchanged with any of its resources.
+	DeleteArchiveMessagesWith(ctx context.Context, archiveID, with string) (int, error)
+
 	// DeleteArchive clears an archive queue.
 	DeleteArchive(ctx context.Context, archiveID string) error
+
+	// UpsertArchivePrefs inserts or updates archive preferences.
+	UpsertArchivePrefs(ctx context.Context, prefs *archivemodel.Prefs) error
+
+	// FetchArchivePrefs returns the preferences associated to an archive.
+	// A nil value will be returned in case no preferences were set.
+	FetchArchivePrefs(ctx context.Context, archiveID string) (*archivemodel.Prefs, error)
+
+	// DeleteArchivePrefs removes archive preferences.
+	DeleteArchivePrefs(ctx context.Context, archiveID string) error
+
+	// DeleteArchiveExpiredMessages removes all messages archived before a given time from an archive.
+	DeleteArchiveExpiredMessages(ctx context.Context, archiveID string, before time.Time) error
+
+	// UpsertArchiveQuota inserts or updates an archive quota.
+	UpsertArchiveQuota(ctx context.Context, quota *archivemodel.Quota) error
+
+	// FetchArchiveQuota returns the quota associated to a user archive, or to a host in case archiveID is empty.
+	// A nil value will be returned in case no quota was set.
+	FetchArchiveQuota(ctx context.Context, archiveID, host string) (*archivemodel.Quota, error)
+
+	// FetchArchiveQuotas returns all stored archive quotas.
+	FetchArchiveQuotas(ctx context.Context) ([]*archivemodel.Quota, error)
+
+	// DeleteArchiveQuota removes the quota associated to a user archive, or to a host in case archiveID is empty.
+	DeleteArchiveQuota(ctx context.Context, archiveID, host string) error
 }
@@ -16,6 +16,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
 )
@@ -31,6 +32,18 @@ type Offline interface {
 	// FetchOfflineMessages retrieves from repository current user offline queue.
 	FetchOfflineMessages(ctx context.Context, username string) ([]*stravaganza.Message, error)
 
+	// DeleteOfflineMessagesBefore removes all offline messages stored before a given time for users of a host.
+	// It returns the number of removed messages.
+	DeleteOfflineMessagesBefore(ctx context.Context, host string, before time.Time) (int, error)
+
+	// DeleteSelectedOfflineMessages removes from user's offline queue all messages for which selectFn returns true.
+	// It returns the number of removed messages.
+	DeleteSelectedOfflineMessages(ctx context.Context, username string, selectFn func(msg *stravaganza.Message) bool) (int, error)
+
+	// TrimOfflineMessages removes oldest messages from user's offline queue so that at most maxSize messages are kept.
+	// It returns the number of removed messages.
+	TrimOfflineMessages(ctx context.Context, username string, maxSize int) (int, error)
+
 	// DeleteOfflineMessages clears a user offline queue.
 	DeleteOfflineMessages(ctx context.Context, username string) error
 }
@@ -25,6 +25,9 @@ type Private interface {
 	// FetchPrivate retrieves a private element from storage.
 	FetchPrivate(ctx context.Context, namespace, username string) (stravaganza.Element, error)
 
+	// FetchPrivates retrieves all private elements stored by a user.
+	FetchPrivates(ctx context.Context, username string) ([]stravaganza.Element, error)
+
 	// UpsertPrivate upserts a new private element into repository.
 	UpsertPrivate(ctx context.Context, private stravaganza.Element, namespace, username string) error
 
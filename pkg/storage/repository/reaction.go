@@ -0,0 +1,36 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	reactionmodel "github.com/ortuman/jackal/pkg/model/reaction"
+)
+
+// Reaction defines storage operations for archived message reactions.
+type Reaction interface {
+	// UpsertReactions upserts the reactions sent by an entity to an archived message.
+	UpsertReactions(ctx context.Context, reactions *reactionmodel.Reactions) error
+
+	// FetchReactions retrieves all reactions associated to a set of archive message identifiers.
+	FetchReactions(ctx context.Context, archiveID string, targetIDs []string) ([]*reactionmodel.Reactions, error)
+
+	// DeleteReactions removes the reactions sent by an entity to an archived message.
+	DeleteReactions(ctx context.Context, archiveID, targetID, jid string) error
+
+	// DeleteArchiveReactions removes all reactions associated to an archive.
+	DeleteArchiveReactions(ctx context.Context, archiveID string) error
+}
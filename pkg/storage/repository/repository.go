@@ -47,5 +47,10 @@ type baseRepository interface {
 	Private
 	Roster
 	VCard
+	StreamQueue
+	Reaction
+	S2SQueue
+	Stats
+	UnifiedPush
 	Locker
 }
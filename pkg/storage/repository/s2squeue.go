@@ -0,0 +1,42 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	s2squeuemodel "github.com/ortuman/jackal/pkg/model/s2squeue"
+)
+
+// S2SQueue defines outgoing S2S queue repository operations.
+type S2SQueue interface {
+	// InsertS2SQueueStanza appends a stanza to its target domain queue.
+	InsertS2SQueueStanza(ctx context.Context, stanza *s2squeuemodel.Stanza) error
+
+	// CountS2SQueueStanzas returns current length of a domain queue.
+	CountS2SQueueStanzas(ctx context.Context, domain string) (int, error)
+
+	// FetchS2SQueueStanzas retrieves all non expired stanzas queued for a domain.
+	FetchS2SQueueStanzas(ctx context.Context, domain string) ([]*s2squeuemodel.Stanza, error)
+
+	// FetchS2SQueueDomains returns the set of domains having queued stanzas.
+	FetchS2SQueueDomains(ctx context.Context) ([]string, error)
+
+	// DeleteS2SQueueStanzas removes from a domain queue the stanzas identified by ids.
+	DeleteS2SQueueStanzas(ctx context.Context, domain string, ids []int64) error
+
+	// DeleteExpiredS2SQueueStanzas removes from storage all expired queued stanzas.
+	DeleteExpiredS2SQueueStanzas(ctx context.Context) error
+}
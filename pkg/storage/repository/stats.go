@@ -0,0 +1,40 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	statsmodel "github.com/ortuman/jackal/pkg/model/stats"
+)
+
+// Stats defines usage statistics repository operations.
+type Stats interface {
+	// UpsertUserActivity records that a user has been active during a given day.
+	UpsertUserActivity(ctx context.Context, username string, day time.Time) error
+
+	// CountActiveUsers returns the number of distinct users active within the [from, to] day range.
+	CountActiveUsers(ctx context.Context, from, to time.Time) (int, error)
+
+	// IncrementHostStats adds host counters to those stored for the same host and day.
+	IncrementHostStats(ctx context.Context, stats *statsmodel.HostStats) error
+
+	// FetchHostStats returns per host counters aggregated within the [from, to] day range.
+	FetchHostStats(ctx context.Context, from, to time.Time) ([]*statsmodel.HostStats, error)
+
+	// DeleteStatsBefore removes all statistics recorded before a given day.
+	DeleteStatsBefore(ctx context.Context, day time.Time) error
+}
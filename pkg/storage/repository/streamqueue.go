@@ -0,0 +1,36 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	streamqueuemodel "github.com/ortuman/jackal/pkg/model/streamqueue"
+)
+
+// StreamQueue defines stream management resumable queue repository operations.
+type StreamQueue interface {
+	// UpsertStreamQueue upserts a resumable queue entity into storage.
+	UpsertStreamQueue(ctx context.Context, queue *streamqueuemodel.Queue) error
+
+	// FetchStreamQueue retrieves from storage resumable queue entity associated to a stream full jid.
+	FetchStreamQueue(ctx context.Context, jid string) (*streamqueuemodel.Queue, error)
+
+	// DeleteStreamQueue removes resumable queue entity associated to a stream full jid from storage.
+	DeleteStreamQueue(ctx context.Context, jid string) error
+
+	// DeleteExpiredStreamQueues removes from storage all resumable queues that can no longer be resumed.
+	DeleteExpiredStreamQueues(ctx context.Context) error
+}
@@ -0,0 +1,36 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	unifiedpushmodel "github.com/ortuman/jackal/pkg/model/unifiedpush"
+)
+
+// UnifiedPush defines UnifiedPush endpoint registration repository operations.
+type UnifiedPush interface {
+	// UpsertUnifiedPushRegistration stores a registration, replacing any previous one for the same application instance.
+	UpsertUnifiedPushRegistration(ctx context.Context, reg *unifiedpushmodel.Registration) error
+
+	// FetchUnifiedPushRegistration retrieves the registration associated to an endpoint token.
+	FetchUnifiedPushRegistration(ctx context.Context, token string) (*unifiedpushmodel.Registration, error)
+
+	// DeleteUnifiedPushRegistration removes a user application instance registration.
+	DeleteUnifiedPushRegistration(ctx context.Context, username, application, instance string) error
+
+	// DeleteUnifiedPushRegistrations removes all user registrations.
+	DeleteUnifiedPushRegistrations(ctx context.Context, username string) error
+}
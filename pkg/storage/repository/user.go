@@ -16,6 +16,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 )
@@ -33,4 +34,7 @@ type User interface {
 
 	// UserExists tells whether or not a user exists within repository.
 	UserExists(ctx context.Context, username string) (bool, error)
+
+	// FetchUsersPendingDeletion retrieves the names of all users whose deletion was scheduled before a given time.
+	FetchUsersPendingDeletion(ctx context.Context, before time.Time) ([]string, error)
 }
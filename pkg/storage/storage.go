@@ -21,6 +21,7 @@ import (
 	"github.com/ortuman/jackal/pkg/storage/boltdb"
 	cachedrepository "github.com/ortuman/jackal/pkg/storage/cached"
 	measuredrepository "github.com/ortuman/jackal/pkg/storage/measured"
+	memoryrepository "github.com/ortuman/jackal/pkg/storage/memory"
 	pgsqlrepository "github.com/ortuman/jackal/pkg/storage/pgsql"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 )
@@ -28,6 +29,9 @@ import (
 const (
 	boltDBRepositoryType = "boltdb"
 	pgSQLRepositoryType  = "pgsql"
+
+	// MemoryRepositoryType represents the ephemeral in-memory repository type.
+	MemoryRepositoryType = "memory"
 )
 
 // Config contains generic storage configuration.
@@ -35,6 +39,7 @@ type Config struct {
 	Type   string                  `fig:"type" default:"boltdb"`
 	PgSQL  pgsqlrepository.Config  `fig:"pgsql"`
 	BoltDB boltdb.Config           `fig:"boltdb"`
+	Memory memoryrepository.Config `fig:"memory"`
 	Cache  cachedrepository.Config `fig:"cache"`
 }
 
@@ -49,6 +54,9 @@ func New(cfg Config, logger kitlog.Logger) (repository.Repository, error) {
 	case boltDBRepositoryType:
 		rep = boltdb.New(cfg.BoltDB, logger)
 
+	case MemoryRepositoryType:
+		rep = memoryrepository.New(cfg.Memory, logger)
+
 	default:
 		return nil, fmt.Errorf("unrecognized repository type: %s", cfg.Type)
 	}
@@ -0,0 +1,189 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jidutil
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/cespare/xxhash"
+	"github.com/jackal-xmpp/stravaganza/jid"
+)
+
+const (
+	shardCount = 32
+
+	// DefaultCacheSize defines the default maximum number of cached JIDs.
+	DefaultCacheSize = 65536
+)
+
+var defaultCache = NewCache(DefaultCacheSize)
+
+// Parse parses a JID string making use of the default process cache.
+func Parse(str string, skipStringPrep bool) (*jid.JID, error) {
+	return defaultCache.Parse(str, skipStringPrep)
+}
+
+// String returns the string representation of a JID making use of the default process cache.
+func String(jd *jid.JID) string {
+	return defaultCache.String(jd)
+}
+
+type parseKey struct {
+	str  string
+	prep bool
+}
+
+// jidKey identifies a JID by value. JID values are never mutated, but owners may replace
+// the instance they hold (e.g. on resource binding), so strings are never cached by pointer.
+type jidKey struct {
+	node     string
+	domain   string
+	resource string
+}
+
+type entry struct {
+	key    parseKey
+	jidKey jidKey
+	parsed bool
+	jd     *jid.JID
+	str    string
+}
+
+type shard struct {
+	mu      sync.Mutex
+	maxSize int
+	ll      *list.List
+	byKey   map[parseKey]*list.Element
+	byValue map[jidKey]*list.Element
+}
+
+// Cache is a sharded LRU cache of parsed JIDs.
+//
+// Since JID values are immutable, parsed instances are shared among all callers.
+// String caching pays off over JID.String by avoiding a per call allocation (see BenchmarkString).
+type Cache struct {
+	shards [shardCount]*shard
+}
+
+// NewCache returns a new JID cache holding up to size elements.
+func NewCache(size int) *Cache {
+	shardSize := size / shardCount
+	if shardSize < 1 {
+		shardSize = 1
+	}
+	c := &Cache{}
+	for i := range c.shards {
+		c.shards[i] = &shard{
+			maxSize: shardSize,
+			ll:      list.New(),
+			byKey:   make(map[parseKey]*list.Element),
+			byValue: make(map[jidKey]*list.Element),
+		}
+	}
+	return c
+}
+
+// Parse returns the JID associated to str, parsing and caching it if it wasn't previously cached.
+func (c *Cache) Parse(str string, skipStringPrep bool) (*jid.JID, error) {
+	key := parseKey{str: str, prep: !skipStringPrep} // prepped and non-prepped results are kept apart
+	s := c.shards[xxhash.Sum64String(str)%shardCount]
+
+	s.mu.Lock()
+	if el, ok := s.byKey[key]; ok {
+		s.ll.MoveToFront(el)
+		jd := el.Value.(*entry).jd
+		s.mu.Unlock()
+		return jd, nil
+	}
+	s.mu.Unlock()
+
+	jd, err := jid.NewWithString(str, skipStringPrep)
+	if err != nil {
+		return nil, err // do not cache invalid JIDs
+	}
+	s.add(&entry{key: key, parsed: true, jd: jd, str: jd.String()})
+	return jd, nil
+}
+
+// String returns the string representation of jd, caching it for subsequent calls.
+// Entries are keyed by JID value, so any JID equal to jd shares the same cached string.
+func (c *Cache) String(jd *jid.JID) string {
+	if jd == nil {
+		return ""
+	}
+	key := jidKey{node: jd.Node(), domain: jd.Domain(), resource: jd.Resource()}
+	s := c.shardForJID(key)
+
+	s.mu.Lock()
+	if el, ok := s.byValue[key]; ok {
+		s.ll.MoveToFront(el)
+		str := el.Value.(*entry).str
+		s.mu.Unlock()
+		return str
+	}
+	s.mu.Unlock()
+
+	str := jd.String()
+	s.add(&entry{jidKey: key, str: str})
+	return str
+}
+
+// Len returns the total number of cached elements.
+func (c *Cache) Len() int {
+	var n int
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += s.ll.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+func (c *Cache) shardForJID(key jidKey) *shard {
+	h := xxhash.Sum64String(key.node) ^ xxhash.Sum64String(key.resource)
+	return c.shards[h%shardCount]
+}
+
+func (s *shard) add(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.parsed {
+		if _, ok := s.byKey[e.key]; ok {
+			return // concurrently added
+		}
+	} else if _, ok := s.byValue[e.jidKey]; ok {
+		return
+	}
+	el := s.ll.PushFront(e)
+	if e.parsed {
+		s.byKey[e.key] = el
+	} else {
+		s.byValue[e.jidKey] = el
+	}
+	for s.ll.Len() > s.maxSize {
+		s.evict(s.ll.Back())
+	}
+}
+
+func (s *shard) evict(el *list.Element) {
+	e := s.ll.Remove(el).(*entry)
+	if e.parsed {
+		delete(s.byKey, e.key)
+	} else {
+		delete(s.byValue, e.jidKey)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jidutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Parse(t *testing.T) {
+	// given
+	c := NewCache(1024)
+
+	// when
+	jd0, err0 := c.Parse("ortuman@jackal.im/yard", false)
+	jd1, err1 := c.Parse("ortuman@jackal.im/yard", false)
+	jd2, err2 := c.Parse("ortuman@jackal.im/yard", true)
+
+	// then
+	require.NoError(t, err0)
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+
+	require.True(t, jd0 == jd1)
+	require.False(t, jd0 == jd2)
+	require.Equal(t, "ortuman@jackal.im/yard", jd0.String())
+	require.Equal(t, 2, c.Len())
+}
+
+func TestCache_ParseInvalid(t *testing.T) {
+	// given
+	c := NewCache(1024)
+
+	// when
+	jd, err := c.Parse("ortuman@", false)
+
+	// then
+	require.Nil(t, jd)
+	require.Error(t, err)
+	require.Equal(t, 0, c.Len())
+}
+
+func TestCache_String(t *testing.T) {
+	// given
+	c := NewCache(1024)
+	jd, _ := jid.New("ortuman", "jackal.im", "balcony", true)
+
+	// when
+	s0 := c.String(jd)
+	s1 := c.String(jd)
+
+	// then
+	require.Equal(t, "ortuman@jackal.im/balcony", s0)
+	require.Equal(t, s0, s1)
+	require.Equal(t, 1, c.Len())
+	require.Equal(t, "", c.String(nil))
+}
+
+func TestCache_StringUpdatedInPlace(t *testing.T) {
+	// given
+	c := NewCache(1024)
+
+	bareJID, _ := jid.New("ortuman", "jackal.im", "", true)
+	jd := *bareJID
+
+	require.Equal(t, "ortuman@jackal.im", c.String(&jd))
+
+	// when
+	for i := 0; i < 64; i++ {
+		fullJID, _ := jid.New("ortuman", "jackal.im", fmt.Sprintf("r%d", i), true)
+		jd = *fullJID // same instance, new value
+
+		// then
+		require.Equal(t, fmt.Sprintf("ortuman@jackal.im/r%d", i), c.String(&jd))
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	// given
+	c := NewCache(shardCount)
+
+	// when
+	for i := 0; i < shardCount*10; i++ {
+		_, _ = c.Parse(fmt.Sprintf("user%d@jackal.im", i), true)
+	}
+
+	// then
+	require.LessOrEqual(t, c.Len(), shardCount)
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = jid.NewWithString("ortuman@jackal.im/yard", false)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		c := NewCache(DefaultCacheSize)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = c.Parse("ortuman@jackal.im/yard", false)
+		}
+	})
+}
+
+func BenchmarkString(b *testing.B) {
+	jds := make([]*jid.JID, 256)
+	for i := range jds {
+		jds[i], _ = jid.New(fmt.Sprintf("user%d", i), "jackal.im", "yard", true)
+	}
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				_ = jds[i%len(jds)].String()
+			}
+		})
+	})
+	b.Run("cached", func(b *testing.B) {
+		c := NewCache(DefaultCacheSize)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				_ = c.String(jds[i%len(jds)])
+			}
+		})
+	})
+}
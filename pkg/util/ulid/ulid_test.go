@@ -0,0 +1,37 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ulidutil
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	// given
+	tm := time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	var ids []string
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, NewWithTime(tm))
+	}
+
+	// then
+	require.True(t, sort.StringsAreSorted(ids))
+	require.True(t, New() > ids[len(ids)-1])
+}
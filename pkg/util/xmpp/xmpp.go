@@ -15,14 +15,46 @@
 package xmpputil
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
 	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
 	"github.com/jackal-xmpp/stravaganza/jid"
 )
 
-const delayTimeFormat = "2006-01-02T15:04:05.000Z"
+const (
+	delayTimeFormat = "2006-01-02T15:04:05.000Z"
+
+	fallbackNamespace = "urn:xmpp:fallback:0"
+
+	hintsNamespace = "urn:xmpp:hints"
+
+	streamsNamespace   = "urn:ietf:params:xml:ns:xmpp-streams"
+	reconnectNamespace = "urn:xmpp:jackal:reconnect:0"
+)
+
+// Message processing hints (XEP-0334).
+const (
+	// NoPermanentStoreHint tells that a message should not be stored in any permanent archive.
+	NoPermanentStoreHint = "no-permanent-store"
+
+	// NoStoreHint tells that a message should not be stored at all.
+	NoStoreHint = "no-store"
+
+	// NoCopyHint tells that a message should not be copied to other resources.
+	NoCopyHint = "no-copy"
+
+	// StoreHint tells that a message should be stored even if it would not be by default.
+	StoreHint = "store"
+)
+
+// HasProcessingHint tells whether msg carries a given XEP-0334 processing hint.
+func HasProcessingHint(msg *stravaganza.Message, hint string) bool {
+	return msg.ChildNamespace(hint, hintsNamespace) != nil
+}
 
 // MakeResultIQ creates a new result stanza derived from iq.
 func MakeResultIQ(iq *stravaganza.IQ, queryChild stravaganza.Element) *stravaganza.IQ {
@@ -90,6 +122,15 @@ func MessageStanzaID(msg *stravaganza.Message) string {
 	return sidElem.Attribute("id")
 }
 
+// MessageOriginID returns the sender assigned origin-id value contained in msg parameter.
+func MessageOriginID(msg *stravaganza.Message) string {
+	oidElem := msg.ChildNamespace("origin-id", "urn:xmpp:sid:0")
+	if oidElem == nil {
+		return ""
+	}
+	return oidElem.Attribute("id")
+}
+
 // MakeForwardedStanza creates a new forwarded element derived from the passed stanza.
 func MakeForwardedStanza(stanza stravaganza.Stanza, stamp *time.Time) stravaganza.Element {
 	b := stravaganza.NewBuilder("forwarded").
@@ -109,3 +150,177 @@ func MakeForwardedStanza(stanza stravaganza.Stanza, stamp *time.Time) stravaganz
 	}
 	return b.Build()
 }
+
+// MakeStreamLimitsElement creates a new stream limits (XEP-0478) feature element.
+// Zero values are not announced.
+func MakeStreamLimitsElement(maxBytes int, idleTimeout time.Duration) stravaganza.Element {
+	b := stravaganza.NewBuilder("limits").
+		WithAttribute(stravaganza.Namespace, "urn:xmpp:stream-limits:0")
+	if maxBytes > 0 {
+		b.WithChild(
+			stravaganza.NewBuilder("max-bytes").
+				WithText(strconv.Itoa(maxBytes)).
+				Build(),
+		)
+	}
+	if idleSecs := int(idleTimeout / time.Second); idleSecs > 0 {
+		b.WithChild(
+			stravaganza.NewBuilder("idle-seconds").
+				WithText(strconv.Itoa(idleSecs)).
+				Build(),
+		)
+	}
+	return b.Build()
+}
+
+// MessageBody returns msg body text leaving out fallback (XEP-0428) text ranges.
+// In case the whole body is marked as fallback an empty string is returned.
+func MessageBody(msg *stravaganza.Message) string {
+	body := msg.Child("body")
+	if body == nil {
+		return ""
+	}
+	text := []rune(body.Text())
+	fallback := make([]bool, len(text))
+
+	for _, fb := range msg.AllChildren() {
+		if fb.Name() != "fallback" || fb.Attribute(stravaganza.Namespace) != fallbackNamespace {
+			continue
+		}
+		ranges := fb.Children("body")
+		if len(ranges) == 0 && len(fb.Children("subject")) == 0 {
+			return "" // whole body is fallback
+		}
+		for _, r := range ranges {
+			start, err := strconv.Atoi(r.Attribute("start"))
+			if err != nil {
+				continue
+			}
+			end, err := strconv.Atoi(r.Attribute("end"))
+			if err != nil {
+				continue
+			}
+			if start < 0 {
+				start = 0
+			}
+			if end > len(text) {
+				end = len(text)
+			}
+			for i := start; i < end; i++ {
+				fallback[i] = true
+			}
+		}
+	}
+	var sb strings.Builder
+	for i, r := range text {
+		if !fallback[i] {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// IsFallbackBody tells whether msg body only consists of fallback (XEP-0428) text.
+func IsFallbackBody(msg *stravaganza.Message) bool {
+	return msg.IsMessageWithBody() && len(strings.TrimSpace(MessageBody(msg))) == 0
+}
+
+// StreamErrorConditions contains all stream error conditions defined in RFC 6120 (section 4.9.3).
+var StreamErrorConditions = []string{
+	"bad-format",
+	"bad-namespace-prefix",
+	"conflict",
+	"connection-timeout",
+	"host-gone",
+	"host-unknown",
+	"improper-addressing",
+	"internal-server-error",
+	"invalid-from",
+	"invalid-namespace",
+	"invalid-xml",
+	"not-authorized",
+	"not-well-formed",
+	"policy-violation",
+	"remote-connection-failed",
+	"reset",
+	"resource-constraint",
+	"restricted-xml",
+	"see-other-host",
+	"system-shutdown",
+	"undefined-condition",
+	"unsupported-encoding",
+	"unsupported-feature",
+	"unsupported-stanza-type",
+	"unsupported-version",
+}
+
+// IsStreamErrorCondition tells whether condition is a stream error condition defined in RFC 6120.
+func IsStreamErrorCondition(condition string) bool {
+	for _, c := range StreamErrorConditions {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeReconnectStreamError creates a stream error carrying an arbitrary RFC 6120 condition, a descriptive text
+// and an optional reconnection hint.
+// Hint details are transported into the stream error application element, so they can be forwarded along
+// the cluster, and rendered by StreamErrorElement.
+func MakeReconnectStreamError(condition, text, seeOtherHost string, retryAfter time.Duration) *streamerror.Error {
+	b := stravaganza.NewBuilder("reconnect").
+		WithAttribute(stravaganza.Namespace, reconnectNamespace).
+		WithAttribute("condition", condition)
+	if len(seeOtherHost) > 0 {
+		b.WithAttribute("see-other-host", seeOtherHost)
+	}
+	if retryAfter > 0 {
+		b.WithAttribute("retry-after", strconv.Itoa(int(retryAfter.Seconds())))
+	}
+	return &streamerror.Error{
+		Reason:             streamerror.UndefinedCondition,
+		Text:               text,
+		ApplicationElement: b.Build(),
+	}
+}
+
+// StreamErrorElement returns the stream:error element associated to streamErr.
+// In case streamErr was created by MakeReconnectStreamError, its defined condition, text and
+// reconnection hint are rendered as described in RFC 6120, otherwise streamErr default representation is returned.
+func StreamErrorElement(streamErr *streamerror.Error) stravaganza.Element {
+	hint := streamErr.ApplicationElement
+	if hint == nil || hint.Name() != "reconnect" || hint.Attribute(stravaganza.Namespace) != reconnectNamespace {
+		return streamErr.Element()
+	}
+	condB := stravaganza.NewBuilder(hint.Attribute("condition")).
+		WithAttribute(stravaganza.Namespace, streamsNamespace)
+	if seeOtherHost := hint.Attribute("see-other-host"); len(seeOtherHost) > 0 {
+		condB.WithText(seeOtherHost)
+	}
+	b := stravaganza.NewBuilder("stream:error").
+		WithChild(condB.Build())
+
+	if len(streamErr.Text) > 0 {
+		lang := streamErr.Lang
+		if len(lang) == 0 {
+			lang = "en"
+		}
+		b.WithChild(
+			stravaganza.NewBuilder("text").
+				WithAttribute(stravaganza.Namespace, streamsNamespace).
+				WithAttribute(stravaganza.Language, lang).
+				WithText(streamErr.Text).
+				Build(),
+		)
+	}
+	if retryAfter := hint.Attribute("retry-after"); len(retryAfter) > 0 {
+		b.WithChild(
+			stravaganza.NewBuilder("retry-after").
+				WithAttribute(stravaganza.Namespace, reconnectNamespace).
+				WithText(retryAfter).
+				Build(),
+		)
+	}
+	return b.Build()
+}
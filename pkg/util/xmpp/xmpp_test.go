@@ -20,6 +20,7 @@ import (
 
 	"github.com/jackal-xmpp/stravaganza"
 	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/stretchr/testify/require"
 )
@@ -150,6 +151,27 @@ func TestMakeStanzaIDElement(t *testing.T) {
 	require.Equal(t, "ortuman@jackal.im", elem.Attribute("by"))
 }
 
+func TestMessageOriginID(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	msg, _ := b.BuildMessage()
+
+	oidMsg, _ := stravaganza.NewBuilderFromElement(msg).
+		WithChild(
+			stravaganza.NewBuilder("origin-id").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:sid:0").
+				WithAttribute("id", "de305d54-75b4-431b-adb2-eb6b9e546013").
+				Build(),
+		).
+		BuildMessage()
+
+	// then
+	require.Equal(t, "", MessageOriginID(msg))
+	require.Equal(t, "de305d54-75b4-431b-adb2-eb6b9e546013", MessageOriginID(oidMsg))
+}
+
 func TestMakeForwardedElement(t *testing.T) {
 	// given
 	b := stravaganza.NewMessageBuilder()
@@ -178,3 +200,131 @@ func TestMakeForwardedElement(t *testing.T) {
 	require.NotNil(t, bodyEl)
 	require.Equal(t, "I'll give thee a wind.", bodyEl.Text())
 }
+
+func TestMakeStreamLimitsElement(t *testing.T) {
+	// when
+	limits := MakeStreamLimitsElement(131072, time.Minute*3)
+	noIdle := MakeStreamLimitsElement(131072, 0)
+
+	// then
+	require.Equal(t, "urn:xmpp:stream-limits:0", limits.Attribute(stravaganza.Namespace))
+	require.Equal(t, "131072", limits.Child("max-bytes").Text())
+	require.Equal(t, "180", limits.Child("idle-seconds").Text())
+
+	require.Nil(t, noIdle.Child("idle-seconds"))
+}
+
+func TestMessageBody(t *testing.T) {
+	// given
+	reply, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im/balcony").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("> ¿Vienes?\nSí, ahora voy").
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("fallback").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:fallback:0").
+				WithAttribute("for", "urn:xmpp:reply:0").
+				WithChild(
+					stravaganza.NewBuilder("body").
+						WithAttribute("start", "0").
+						WithAttribute("end", "11").
+						Build(),
+				).
+				Build(),
+		).
+		BuildMessage()
+
+	encrypted, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im/balcony").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("This message is encrypted").
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("fallback").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:fallback:0").
+				WithAttribute("for", "urn:xmpp:omemo:2").
+				Build(),
+		).
+		BuildMessage()
+
+	plain, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jackal.im/balcony").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("I'll give thee a wind.").
+				Build(),
+		).
+		BuildMessage()
+
+	// then
+	require.Equal(t, "Sí, ahora voy", MessageBody(reply))
+	require.False(t, IsFallbackBody(reply))
+
+	require.Equal(t, "", MessageBody(encrypted))
+	require.True(t, IsFallbackBody(encrypted))
+
+	require.Equal(t, "I'll give thee a wind.", MessageBody(plain))
+	require.False(t, IsFallbackBody(plain))
+}
+
+func TestHasProcessingHint(t *testing.T) {
+	// given
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "noelia@jackal.im/yard").
+		WithChild(
+			stravaganza.NewBuilder("no-permanent-store").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:hints").
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("no-store").
+				WithAttribute(stravaganza.Namespace, "urn:xmpp:other").
+				Build(),
+		).
+		BuildMessage()
+
+	// then
+	require.True(t, HasProcessingHint(msg, NoPermanentStoreHint))
+	require.False(t, HasProcessingHint(msg, NoStoreHint))
+	require.False(t, HasProcessingHint(msg, StoreHint))
+}
+
+func TestStreamErrorElement(t *testing.T) {
+	// given
+	migrate := MakeReconnectStreamError("see-other-host", "Node under maintenance", "node2.jackal.im:5222", time.Second*30)
+	shutdown := MakeReconnectStreamError("system-shutdown", "", "", 0)
+	plain := streamerror.E(streamerror.PolicyViolation)
+
+	// when
+	migrateElem := StreamErrorElement(migrate)
+	shutdownElem := StreamErrorElement(shutdown)
+	plainElem := StreamErrorElement(plain)
+
+	// then
+	require.Equal(t, "stream:error", migrateElem.Name())
+
+	seeOtherHost := migrateElem.ChildNamespace("see-other-host", "urn:ietf:params:xml:ns:xmpp-streams")
+	require.NotNil(t, seeOtherHost)
+	require.Equal(t, "node2.jackal.im:5222", seeOtherHost.Text())
+	require.Equal(t, "Node under maintenance", migrateElem.Child("text").Text())
+	require.Equal(t, "30", migrateElem.Child("retry-after").Text())
+	require.Nil(t, migrateElem.Child("reconnect"))
+
+	require.NotNil(t, shutdownElem.ChildNamespace("system-shutdown", "urn:ietf:params:xml:ns:xmpp-streams"))
+	require.Nil(t, shutdownElem.Child("text"))
+	require.Nil(t, shutdownElem.Child("retry-after"))
+
+	require.Equal(t, plain.Element().String(), plainElem.String())
+
+	require.True(t, IsStreamErrorCondition("reset"))
+	require.False(t, IsStreamErrorCondition("item-not-found"))
+}
@@ -13,6 +13,14 @@ go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.1
 
 FILES=(
   "admin/v1/users.proto"
+  "admin/v1/archives.proto"
+  "admin/v1/s2s.proto"
+  "admin/v1/stats.proto"
+  "admin/v1/broadcast.proto"
+  "admin/v1/hosts.proto"
+  "admin/v1/listeners.proto"
+  "admin/v1/abuse.proto"
+  "admin/v1/outage.proto"
   "c2s/v1/resourceinfo.proto"
   "cluster/v1/cluster.proto"
   "model/v1/archive.proto"
@@ -21,6 +29,12 @@ FILES=(
   "model/v1/blocklist.proto"
   "model/v1/caps.proto"
   "model/v1/roster.proto"
+  "model/v1/streamqueue.proto"
+  "model/v1/reaction.proto"
+  "model/v1/s2squeue.proto"
+  "model/v1/stats.proto"
+  "model/v1/unifiedpush.proto"
+  "module/v1/module.proto"
 )
 
 for file in "${FILES[@]}"; do
@@ -0,0 +1,14 @@
+-- Archived message analytics table used by the clickhouse module.
+
+CREATE TABLE IF NOT EXISTS archive_messages (
+    archive_id String,
+    id String,
+    from_jid String,
+    to_jid String,
+    type LowCardinality(String),
+    has_body UInt8,
+    body String DEFAULT '',
+    stamp DateTime64(3, 'UTC')
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(stamp)
+ORDER BY (archive_id, stamp);